@@ -0,0 +1,113 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package config loads the per-repo defaults of abcoder commands.
+//
+// The config file is sectioned by subcommand, and the keys are the flag names of the subcommand:
+//
+//	parse:
+//	  exclude: [vendor, testdata]
+//	  no-need-test: true
+//	write:
+//	  compiler: /usr/local/go/bin/go
+//	agent:
+//	  model-name: gpt-4o
+//	  agent-max-steps: 100
+//
+// Values only fill the flags which are not given in the command line.
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+// FileNames are the config files searched under a directory, in order
+var FileNames = []string{"abcoder.yaml", "abcoder.yml", ".abcoder.yaml", ".abcoder.yml", "abcoder.toml", ".abcoder.toml"}
+
+type Config struct {
+	// Path of the config file
+	Path string
+	// subcommand => flag name => value
+	Sections map[string]map[string]interface{}
+}
+
+// Find returns the path of the first config file under dir, or empty if not found
+func Find(dir string) string {
+	for _, name := range FileNames {
+		fpath := filepath.Join(dir, name)
+		if info, err := os.Stat(fpath); err == nil && !info.IsDir() {
+			return fpath
+		}
+	}
+	return ""
+}
+
+// Load reads a yaml or toml config file, by its extension
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config %s failed: %v", path, err)
+	}
+	conf := &Config{Path: path}
+	switch ext := filepath.Ext(path); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &conf.Sections)
+	case ".toml":
+		err = toml.Unmarshal(data, &conf.Sections)
+	default:
+		return nil, fmt.Errorf("unsupported config format: %s", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse config %s failed: %v", path, err)
+	}
+	return conf, nil
+}
+
+// Apply sets the values of section onto flags which are not changed by the command line.
+// Lists are set element by element, thus both slice and array flags are supported.
+func (c *Config) Apply(section string, flags *pflag.FlagSet) error {
+	opts := c.Sections[section]
+	names := make([]string, 0, len(opts))
+	for name := range opts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		val := opts[name]
+		flag := flags.Lookup(name)
+		if flag == nil {
+			return fmt.Errorf("unknown option '%s.%s' in %s", section, name, c.Path)
+		}
+		if flag.Changed {
+			continue
+		}
+		vals, ok := val.([]interface{})
+		if !ok {
+			vals = []interface{}{val}
+		}
+		for _, v := range vals {
+			if err := flags.Set(name, fmt.Sprint(v)); err != nil {
+				return fmt.Errorf("invalid option '%s.%s' in %s: %v", section, name, c.Path, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+type parseFlags struct {
+	lsp      string
+	noTest   bool
+	maxSteps int
+	excludes []string
+	build    []string
+}
+
+func newParseFlags(v *parseFlags) *pflag.FlagSet {
+	fs := pflag.NewFlagSet("parse", pflag.ContinueOnError)
+	fs.StringVar(&v.lsp, "lsp", "", "")
+	fs.BoolVar(&v.noTest, "no-need-test", false, "")
+	fs.IntVar(&v.maxSteps, "agent-max-steps", 50, "")
+	fs.StringSliceVar(&v.excludes, "exclude", []string{"default"}, "")
+	fs.StringArrayVar(&v.build, "build-flag", nil, "")
+	return fs
+}
+
+func TestConfig_Apply(t *testing.T) {
+	files := map[string]string{
+		"abcoder.yaml": `
+parse:
+  lsp: /usr/bin/gopls
+  no-need-test: true
+  agent-max-steps: 100
+  exclude: [vendor, testdata]
+  build-flag: ["-tags=a,b"]
+`,
+		".abcoder.toml": `
+[parse]
+lsp = "/usr/bin/gopls"
+no-need-test = true
+agent-max-steps = 100
+exclude = ["vendor", "testdata"]
+build-flag = ["-tags=a,b"]
+`,
+	}
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			path := Find(dir)
+			if filepath.Base(path) != name {
+				t.Fatalf("Find() = %s, want %s", path, name)
+			}
+			conf, err := Load(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got parseFlags
+			fs := newParseFlags(&got)
+			// given in command line, not overridden by the file
+			if err := fs.Parse([]string{"--lsp", "gopls-cli"}); err != nil {
+				t.Fatal(err)
+			}
+			if err := conf.Apply("parse", fs); err != nil {
+				t.Fatal(err)
+			}
+			want := parseFlags{
+				lsp:      "gopls-cli",
+				noTest:   true,
+				maxSteps: 100,
+				excludes: []string{"vendor", "testdata"},
+				build:    []string{"-tags=a,b"},
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Apply() = %+v, want %+v", got, want)
+			}
+
+			// other sections are ignored
+			var other parseFlags
+			if err := conf.Apply("write", newParseFlags(&other)); err != nil {
+				t.Fatal(err)
+			}
+			if other.lsp != "" {
+				t.Errorf("Apply(write) set lsp = %s", other.lsp)
+			}
+		})
+	}
+}
+
+func TestConfig_ApplyError(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"unknown option", "parse:\n  no-such-flag: 1\n", "unknown option 'parse.no-such-flag'"},
+		{"invalid value", "parse:\n  agent-max-steps: many\n", "invalid option 'parse.agent-max-steps'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "abcoder.yaml")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			conf, err := Load(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var v parseFlags
+			err = conf.Apply("parse", newParseFlags(&v))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Apply() error = %v, want %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFind_NotFound(t *testing.T) {
+	if path := Find(t.TempDir()); path != "" {
+		t.Errorf("Find() = %s, want empty", path)
+	}
+}
@@ -0,0 +1,522 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collect
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/abcoder/lang/log"
+	. "github.com/cloudwego/abcoder/lang/lsp"
+	"github.com/cloudwego/abcoder/lang/uniast"
+)
+
+// DefaultCheckpointInterval is how often the collecting is checkpointed by default
+const DefaultCheckpointInterval = 5 * time.Minute
+
+// checkpointVersion is bumped when the format changes, checkpoints of other versions are ignored
+const checkpointVersion = 1
+
+// DefaultLSPCachePath returns the default LSPCachePath, abcoder/lsp under the user cache dir
+func DefaultLSPCachePath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "abcoder", "lsp")
+}
+
+// checkpoint is the snapshot of the symbols and dependencies collected so far.
+// Symbols are referred by their indexes in Symbols, since they are shared by the maps of the collector
+type checkpoint struct {
+	Version  int                  `json:"version"`
+	Repo     string               `json:"repo"`
+	Options  string               `json:"options"`
+	Time     time.Time            `json:"time"`
+	Files    map[string]fileStamp `json:"files"`
+	Symbols  []checkpointSymbol   `json:"symbols"`
+	Funcs    []checkpointFunc     `json:"funcs,omitempty"`
+	Deps     []checkpointDeps     `json:"deps,omitempty"`
+	Impls    []checkpointDeps     `json:"impls,omitempty"`
+	Vars     []checkpointDeps     `json:"vars,omitempty"`
+	ExtQueue []int                `json:"extQueue,omitempty"`
+	// the root symbols whose processSymbol finished
+	Processed []Location `json:"processed,omitempty"`
+	// the entity symbols whose collectDepsForEntity finished
+	Collected []Location `json:"collected,omitempty"`
+}
+
+type fileStamp struct {
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+}
+
+type checkpointSymbol struct {
+	Symbol DocumentSymbol `json:"symbol"`
+	// Stored tells if the symbol is in Collector.syms
+	Stored bool `json:"stored,omitempty"`
+}
+
+type checkpointDep struct {
+	Location Location `json:"location"`
+	// Symbol is the index of the symbol, -1 if nil
+	Symbol int `json:"symbol"`
+}
+
+type checkpointDeps struct {
+	Symbol int             `json:"symbol"`
+	Deps   []checkpointDep `json:"deps"`
+}
+
+type checkpointMethod struct {
+	Receiver    checkpointDep  `json:"receiver"`
+	Interface   *checkpointDep `json:"interface,omitempty"`
+	ImplHead    string         `json:"implHead,omitempty"`
+	DefaultImpl bool           `json:"defaultImpl,omitempty"`
+}
+
+type checkpointFunc struct {
+	Symbol           int                   `json:"symbol"`
+	Method           *checkpointMethod     `json:"method,omitempty"`
+	TypeParams       map[int]checkpointDep `json:"typeParams,omitempty"`
+	TypeParamsSorted []checkpointDep       `json:"typeParamsSorted,omitempty"`
+	Inputs           map[int]checkpointDep `json:"inputs,omitempty"`
+	InputsSorted     []checkpointDep       `json:"inputsSorted,omitempty"`
+	Outputs          map[int]checkpointDep `json:"outputs,omitempty"`
+	OutputsSorted    []checkpointDep       `json:"outputsSorted,omitempty"`
+	Signature        string                `json:"signature,omitempty"`
+}
+
+// checkpointer saves the collecting periodically.
+// Workers run each symbol under the read lock of gate, so that a checkpoint never sees a half-processed symbol
+type checkpointer struct {
+	path     string
+	interval time.Duration
+	gate     sync.RWMutex
+
+	mu        sync.Mutex
+	saving    bool
+	last      time.Time
+	files     map[string]fileStamp
+	processed map[Location]bool
+	collected map[Location]bool
+}
+
+// checkpointPath returns the checkpoint file of the repo
+func (c *Collector) checkpointPath() string {
+	dir := c.LSPCachePath
+	if dir == "" {
+		dir = DefaultLSPCachePath()
+	}
+	sum := sha256.Sum256([]byte(string(c.Language) + "\x00" + c.repo))
+	return filepath.Join(dir, "checkpoints", hex.EncodeToString(sum[:8])+".json.gz")
+}
+
+// checkpointOptions fingerprints the options which change the collected symbols
+func (c *Collector) checkpointOptions() string {
+	bs, _ := json.Marshal(struct {
+		Language           uniast.Language
+		LoadExternalSymbol bool
+		NeedStdSymbol      bool
+		NotNeedTest        bool
+		Excludes           []string
+		OnlyDirs           []string
+		IncludeKinds       []string
+		ExcludeKinds       []string
+		Sysroots           []string
+		PythonEnv          string
+	}{c.Language, c.LoadExternalSymbol, c.NeedStdSymbol, c.NotNeedTest, c.Excludes, c.OnlyDirs, c.IncludeKinds, c.ExcludeKinds, c.Sysroots, c.PythonEnv})
+	return string(bs)
+}
+
+// initCheckpoint enables checkpointing after the files are scanned, and restores the last checkpoint if Resume is set.
+// Java is not checkpointed since it is not collected by LSP
+func (c *Collector) initCheckpoint() {
+	if c.Language == uniast.Java || (c.CheckpointInterval <= 0 && !c.Resume) {
+		return
+	}
+	ck := &checkpointer{
+		path:      c.checkpointPath(),
+		interval:  c.CheckpointInterval,
+		last:      time.Now(),
+		files:     make(map[string]fileStamp, len(c.files)),
+		processed: map[Location]bool{},
+		collected: map[Location]bool{},
+	}
+	for path := range c.files {
+		if info, err := os.Stat(path); err == nil {
+			ck.files[path] = fileStamp{Size: info.Size(), ModTime: info.ModTime()}
+		}
+	}
+	c.ckpt = ck
+	if !c.Resume {
+		return
+	}
+	cp, err := ck.load()
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			log.Info("no checkpoint to resume from at %s\n", ck.path)
+		} else {
+			log.Warn("load checkpoint %s failed, collect from scratch: %v\n", ck.path, err)
+		}
+		return
+	}
+	if reason := c.checkpointMismatch(cp); reason != "" {
+		log.Warn("checkpoint %s is stale (%s), collect from scratch\n", ck.path, reason)
+		return
+	}
+	c.restoreCheckpoint(cp)
+	log.Infow("resumed from checkpoint", "path", ck.path, "time", cp.Time, "symbols", len(cp.Symbols),
+		"processed", len(cp.Processed), "collected", len(cp.Collected))
+}
+
+// checkpointMismatch tells why the checkpoint can't be resumed from, empty if it can
+func (c *Collector) checkpointMismatch(cp *checkpoint) string {
+	if cp.Version != checkpointVersion {
+		return fmt.Sprintf("version %d", cp.Version)
+	}
+	if cp.Repo != c.repo {
+		return "repo " + cp.Repo
+	}
+	if cp.Options != c.checkpointOptions() {
+		return "options changed"
+	}
+	if len(cp.Files) != len(c.ckpt.files) {
+		return "files added or removed"
+	}
+	for path, st := range c.ckpt.files {
+		if old, ok := cp.Files[path]; !ok || old.Size != st.Size || !old.ModTime.Equal(st.ModTime) {
+			return path + " changed"
+		}
+	}
+	return ""
+}
+
+func (ck *checkpointer) load() (*checkpoint, error) {
+	f, err := os.Open(ck.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	var cp checkpoint
+	if err := json.NewDecoder(zr).Decode(&cp); err != nil {
+		return nil, err
+	}
+	return &cp, nil
+}
+
+// done tells if the symbol has finished the phase, processSymbol if !deps else collectDepsForEntity
+func (ck *checkpointer) done(deps bool, loc Location) bool {
+	if ck == nil {
+		return false
+	}
+	ck.mu.Lock()
+	defer ck.mu.Unlock()
+	if deps {
+		return ck.collected[loc]
+	}
+	return ck.processed[loc]
+}
+
+// run runs fn of the phase for the symbol, and marks it done
+func (ck *checkpointer) run(deps bool, loc Location, fn func()) {
+	if ck == nil {
+		fn()
+		return
+	}
+	ck.gate.RLock()
+	fn()
+	ck.mu.Lock()
+	if deps {
+		ck.collected[loc] = true
+	} else {
+		ck.processed[loc] = true
+	}
+	ck.mu.Unlock()
+	ck.gate.RUnlock()
+}
+
+// saveCheckpoint saves the checkpoint if the interval has passed since the last one, or force is set.
+// It must not be called within checkpointer.run
+func (c *Collector) saveCheckpoint(force bool) {
+	ck := c.ckpt
+	if ck == nil || (ck.interval <= 0 && !force) {
+		return
+	}
+	ck.mu.Lock()
+	if ck.saving || (!force && time.Since(ck.last) < ck.interval) {
+		ck.mu.Unlock()
+		return
+	}
+	ck.saving = true
+	ck.mu.Unlock()
+
+	start := time.Now()
+	ck.gate.Lock()
+	err := c.writeCheckpoint(ck.path, c.snapshot())
+	ck.gate.Unlock()
+
+	ck.mu.Lock()
+	ck.saving, ck.last = false, time.Now()
+	ck.mu.Unlock()
+	if err != nil {
+		log.Error("save checkpoint %s failed: %v\n", ck.path, err)
+		return
+	}
+	log.Debugw("checkpoint saved", "path", ck.path, "cost", time.Since(start))
+}
+
+// removeCheckpoint removes the checkpoint once the collecting finishes
+func (c *Collector) removeCheckpoint() {
+	if c.ckpt == nil {
+		return
+	}
+	if err := os.Remove(c.ckpt.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Error("remove checkpoint %s failed: %v\n", c.ckpt.path, err)
+	}
+}
+
+// writeCheckpoint writes to a temp file then renames it, so that a crash in writing keeps the last checkpoint
+func (c *Collector) writeCheckpoint(path string, cp *checkpoint) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	zw := gzip.NewWriter(tmp)
+	if err := json.NewEncoder(zw).Encode(cp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// snapshot builds the checkpoint, the caller must hold the write lock of the gate
+func (c *Collector) snapshot() *checkpoint {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ck := c.ckpt
+	cp := &checkpoint{
+		Version: checkpointVersion,
+		Repo:    c.repo,
+		Options: c.checkpointOptions(),
+		Time:    time.Now(),
+		Files:   ck.files,
+	}
+	index := map[*DocumentSymbol]int{}
+	ref := func(sym *DocumentSymbol) int {
+		if sym == nil {
+			return -1
+		}
+		if i, ok := index[sym]; ok {
+			return i
+		}
+		s := *sym
+		s.Children = nil
+		index[sym] = len(cp.Symbols)
+		cp.Symbols = append(cp.Symbols, checkpointSymbol{Symbol: s})
+		return index[sym]
+	}
+	dep := func(d dependency) checkpointDep {
+		return checkpointDep{Location: d.Location, Symbol: ref(d.Symbol)}
+	}
+	deps := func(ds []dependency) []checkpointDep {
+		if ds == nil {
+			return nil
+		}
+		ret := make([]checkpointDep, len(ds))
+		for i, d := range ds {
+			ret[i] = dep(d)
+		}
+		return ret
+	}
+	depMap := func(ds map[int]dependency) map[int]checkpointDep {
+		if ds == nil {
+			return nil
+		}
+		ret := make(map[int]checkpointDep, len(ds))
+		for k, d := range ds {
+			ret[k] = dep(d)
+		}
+		return ret
+	}
+
+	for _, sym := range c.syms {
+		cp.Symbols[ref(sym)].Stored = true
+	}
+	for sym, f := range c.funcs {
+		cf := checkpointFunc{
+			Symbol:           ref(sym),
+			TypeParams:       depMap(f.TypeParams),
+			TypeParamsSorted: deps(f.TypeParamsSorted),
+			Inputs:           depMap(f.Inputs),
+			InputsSorted:     deps(f.InputsSorted),
+			Outputs:          depMap(f.Outputs),
+			OutputsSorted:    deps(f.OutputsSorted),
+			Signature:        f.Signature,
+		}
+		if m := f.Method; m != nil {
+			cf.Method = &checkpointMethod{Receiver: dep(m.Receiver), ImplHead: m.ImplHead, DefaultImpl: m.DefaultImpl}
+			if m.Interface != nil {
+				d := dep(*m.Interface)
+				cf.Method.Interface = &d
+			}
+		}
+		cp.Funcs = append(cp.Funcs, cf)
+	}
+	for sym, ds := range c.deps {
+		cp.Deps = append(cp.Deps, checkpointDeps{Symbol: ref(sym), Deps: deps(ds)})
+	}
+	for sym, ds := range c.implementsRel {
+		cp.Impls = append(cp.Impls, checkpointDeps{Symbol: ref(sym), Deps: deps(ds)})
+	}
+	for sym, d := range c.vars {
+		cp.Vars = append(cp.Vars, checkpointDeps{Symbol: ref(sym), Deps: []checkpointDep{dep(d)}})
+	}
+	for _, sym := range c.cppExtDepsQueue {
+		cp.ExtQueue = append(cp.ExtQueue, ref(sym))
+	}
+
+	ck.mu.Lock()
+	for loc := range ck.processed {
+		cp.Processed = append(cp.Processed, loc)
+	}
+	for loc := range ck.collected {
+		cp.Collected = append(cp.Collected, loc)
+	}
+	ck.mu.Unlock()
+	return cp
+}
+
+// restoreCheckpoint restores the collected symbols and dependencies.
+// The stored symbols are matched by locations to the ones just scanned, others are added as they were
+func (c *Collector) restoreCheckpoint(cp *checkpoint) {
+	syms := make([]*DocumentSymbol, len(cp.Symbols))
+	for i := range cp.Symbols {
+		cs := &cp.Symbols[i]
+		if cs.Stored {
+			c.mu.Lock()
+			sym := c.syms[cs.Symbol.Location]
+			c.mu.Unlock()
+			if sym != nil {
+				syms[i] = sym
+				continue
+			}
+		}
+		sym := cs.Symbol
+		syms[i] = &sym
+		if cs.Stored {
+			c.addSymbol(sym.Location, syms[i])
+		}
+	}
+	sym := func(i int) *DocumentSymbol {
+		if i < 0 || i >= len(syms) {
+			return nil
+		}
+		return syms[i]
+	}
+	dep := func(d checkpointDep) dependency {
+		return dependency{Location: d.Location, Symbol: sym(d.Symbol)}
+	}
+	deps := func(ds []checkpointDep) []dependency {
+		if ds == nil {
+			return nil
+		}
+		ret := make([]dependency, len(ds))
+		for i, d := range ds {
+			ret[i] = dep(d)
+		}
+		return ret
+	}
+	depMap := func(ds map[int]checkpointDep) map[int]dependency {
+		if ds == nil {
+			return nil
+		}
+		ret := make(map[int]dependency, len(ds))
+		for k, d := range ds {
+			ret[k] = dep(d)
+		}
+		return ret
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, cf := range cp.Funcs {
+		f := functionInfo{
+			TypeParams:       depMap(cf.TypeParams),
+			TypeParamsSorted: deps(cf.TypeParamsSorted),
+			Inputs:           depMap(cf.Inputs),
+			InputsSorted:     deps(cf.InputsSorted),
+			Outputs:          depMap(cf.Outputs),
+			OutputsSorted:    deps(cf.OutputsSorted),
+			Signature:        cf.Signature,
+		}
+		if m := cf.Method; m != nil {
+			f.Method = &methodInfo{Receiver: dep(m.Receiver), ImplHead: m.ImplHead, DefaultImpl: m.DefaultImpl}
+			if m.Interface != nil {
+				d := dep(*m.Interface)
+				f.Method.Interface = &d
+			}
+		}
+		c.funcs[sym(cf.Symbol)] = f
+	}
+	for _, cd := range cp.Deps {
+		c.deps[sym(cd.Symbol)] = deps(cd.Deps)
+	}
+	for _, cd := range cp.Impls {
+		c.implementsRel[sym(cd.Symbol)] = deps(cd.Deps)
+	}
+	for _, cd := range cp.Vars {
+		if len(cd.Deps) == 1 {
+			c.vars[sym(cd.Symbol)] = dep(cd.Deps[0])
+		}
+	}
+	for _, i := range cp.ExtQueue {
+		c.cppExtDepsQueue = append(c.cppExtDepsQueue, sym(i))
+	}
+
+	ck := c.ckpt
+	ck.mu.Lock()
+	for _, loc := range cp.Processed {
+		ck.processed[loc] = true
+	}
+	for _, loc := range cp.Collected {
+		ck.collected[loc] = true
+	}
+	ck.mu.Unlock()
+}
@@ -0,0 +1,115 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collect
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/abcoder/lang/lsp"
+	"github.com/cloudwego/abcoder/lang/uniast"
+)
+
+func newCheckpointCollector(repo, cacheDir string) *Collector {
+	c := &Collector{
+		repo:          repo,
+		syms:          map[lsp.Location]*lsp.DocumentSymbol{},
+		funcs:         map[*lsp.DocumentSymbol]functionInfo{},
+		deps:          map[*lsp.DocumentSymbol][]dependency{},
+		implementsRel: map[*lsp.DocumentSymbol][]dependency{},
+		vars:          map[*lsp.DocumentSymbol]dependency{},
+		files:         map[string]*uniast.File{},
+		symsByFile:    map[lsp.DocumentURI][]*lsp.DocumentSymbol{},
+	}
+	c.Language = uniast.Rust
+	c.LSPCachePath = cacheDir
+	c.CheckpointInterval = time.Hour
+	return c
+}
+
+func TestCheckpoint_Resume(t *testing.T) {
+	repo, cache := t.TempDir(), t.TempDir()
+	file := filepath.Join(repo, "lib.rs")
+	if err := os.WriteFile(file, []byte("fn f() -> S {}\nstruct S;\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	uri := lsp.NewURI(file)
+	loc := func(line int) lsp.Location {
+		return lsp.Location{URI: uri, Range: lsp.Range{Start: lsp.Position{Line: line}, End: lsp.Position{Line: line, Character: 9}}}
+	}
+	newSyms := func() (*lsp.DocumentSymbol, *lsp.DocumentSymbol) {
+		return &lsp.DocumentSymbol{Name: "f", Kind: lsp.SKFunction, Location: loc(0), Text: "fn f() -> S {}"},
+			&lsp.DocumentSymbol{Name: "S", Kind: lsp.SKStruct, Location: loc(1), Text: "struct S;"}
+	}
+
+	// the first run processed f and crashed
+	c := newCheckpointCollector(repo, cache)
+	c.files[file] = uniast.NewFile("lib.rs")
+	f, s := newSyms()
+	ext := &lsp.DocumentSymbol{Name: "Vec", Kind: lsp.SKStruct, Location: lsp.Location{URI: "file:///std/vec.rs"}}
+	c.addSymbol(f.Location, f)
+	c.addSymbol(s.Location, s)
+	c.initCheckpoint()
+	c.ckpt.run(false, f.Location, func() {
+		out := dependency{Location: loc(0), Symbol: s}
+		c.funcs[f] = functionInfo{Outputs: map[int]dependency{3: out}, OutputsSorted: []dependency{out}, Signature: "fn f() -> S"}
+		c.deps[f] = []dependency{{Location: loc(0), Symbol: ext}}
+	})
+	c.saveCheckpoint(true)
+	if _, err := os.Stat(c.checkpointPath()); err != nil {
+		t.Fatalf("checkpoint not saved: %v", err)
+	}
+
+	// the resumed run scans the symbols again
+	r := newCheckpointCollector(repo, cache)
+	r.Resume = true
+	r.files[file] = uniast.NewFile("lib.rs")
+	f2, s2 := newSyms()
+	r.addSymbol(f2.Location, f2)
+	r.addSymbol(s2.Location, s2)
+	r.initCheckpoint()
+	if !r.ckpt.done(false, f2.Location) || r.ckpt.done(false, s2.Location) {
+		t.Fatalf("unexpected processed symbols: %v", r.ckpt.processed)
+	}
+	fi, ok := r.funcs[f2]
+	if !ok || fi.Signature != "fn f() -> S" || len(fi.OutputsSorted) != 1 || fi.OutputsSorted[0].Symbol != s2 || fi.Outputs[3].Symbol != s2 {
+		t.Fatalf("unexpected restored func info: %+v", fi)
+	}
+	if ds := r.deps[f2]; len(ds) != 1 || ds[0].Symbol == nil || ds[0].Symbol.Name != "Vec" {
+		t.Fatalf("unexpected restored deps: %+v", ds)
+	}
+	if len(r.syms) != 2 {
+		t.Fatalf("external symbol should not be stored: %d", len(r.syms))
+	}
+
+	// a changed file invalidates the checkpoint
+	if err := os.WriteFile(file, []byte("fn f() -> S { S }\nstruct S;\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	n := newCheckpointCollector(repo, cache)
+	n.Resume = true
+	n.files[file] = uniast.NewFile("lib.rs")
+	n.initCheckpoint()
+	if len(n.funcs) != 0 || len(n.ckpt.processed) != 0 {
+		t.Fatal("stale checkpoint should not be restored")
+	}
+
+	r.removeCheckpoint()
+	if _, err := os.Stat(r.checkpointPath()); !os.IsNotExist(err) {
+		t.Fatalf("checkpoint not removed: %v", err)
+	}
+}
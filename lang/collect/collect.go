@@ -24,6 +24,7 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"time"
 	"unicode"
 
 	sitter "github.com/smacker/go-tree-sitter"
@@ -37,8 +38,12 @@ import (
 	javapb "github.com/cloudwego/abcoder/lang/java/pb"
 	"github.com/cloudwego/abcoder/lang/log"
 	. "github.com/cloudwego/abcoder/lang/lsp"
+	"github.com/cloudwego/abcoder/lang/php"
+	"github.com/cloudwego/abcoder/lang/progress"
 	"github.com/cloudwego/abcoder/lang/python"
+	"github.com/cloudwego/abcoder/lang/ruby"
 	"github.com/cloudwego/abcoder/lang/rust"
+	"github.com/cloudwego/abcoder/lang/scala"
 	"github.com/cloudwego/abcoder/lang/uniast"
 )
 
@@ -51,11 +56,51 @@ type CollectOption struct {
 	Excludes           []string
 	LoadByPackages     bool
 	BuildFlags         []string
+	// OnlyPkgs restricts the parsing to these packages and their direct dependencies (only works for Go)
+	OnlyPkgs []string
+	// OnlyDirs restricts the parsing to the files under these dirs, plus their direct dependencies
+	OnlyDirs []string
+	// GoTags are the build tag sets to parse Go codes with, like `linux,amd64`.
+	// Multiple tag sets are parsed one by one and merged (only works for Go)
+	GoTags []string
+	// IncludeKinds keeps only the nodes of these kinds (see uniast.NodeKinds), all kinds if empty
+	IncludeKinds []string
+	// ExcludeKinds removes the nodes of these kinds, e.g. `var,const` for call graph analysis.
+	// The dependencies of the removed nodes are not collected, which saves the parsing time
+	ExcludeKinds []string
+	// GoCallGraph is the algorithm (cha or rta) to resolve the dynamic calls of Go codes by SSA, disabled if empty
+	GoCallGraph string
+	// FetchSources downloads the sources of the exact dependency versions missing in the local caches,
+	// from GOPROXY or crates.io, thus LoadExternalSymbol can load their symbols (only works for Go and Rust)
+	FetchSources bool
+	// GoClosureMinLines parses the Go function literals spanning these lines at least as child functions, disabled if 0
+	GoClosureMinLines int
+	// StdVersion is the toolchain version of the shared std AST which the std symbols are referred to,
+	// see lang.ParseOptions.LinkStd. Not linked if empty
+	StdVersion string
 	// Sysroots is a list of filesystem prefixes whose contents should be
 	// classified under the `cstdlib` module (typically toolchain sysroots
 	// containing libstdc++/glibc/clang builtins). Currently honoured by the
 	// C++ spec only.
 	Sysroots []string
+	// PythonEnv is the python interpreter or virtualenv dir whose site-packages are resolved, see python.Interpreter.
+	// The activated virtualenv or the `.venv` of the repo is used if empty. Python only
+	PythonEnv string
+	// Progress receives the structured progress events, can be nil
+	Progress progress.Reporter
+	// LSPCachePath is the dir for the caches of LSP collecting like the checkpoints, see DefaultLSPCachePath if empty
+	LSPCachePath string
+	// CheckpointInterval is how often the collected symbols and dependencies are saved under LSPCachePath,
+	// thus a crashed collecting can be resumed. Disabled if not positive
+	CheckpointInterval time.Duration
+	// Resume continues from the checkpoint of the last unfinished collecting of the repo,
+	// unless its files or options have changed since then
+	Resume bool
+}
+
+// Kinds returns the filter of node kinds
+func (o CollectOption) Kinds() uniast.KindFilter {
+	return uniast.KindFilter{Include: o.IncludeKinds, Exclude: o.ExcludeKinds}
 }
 
 type cppFnLoc struct {
@@ -161,6 +206,9 @@ type Collector struct {
 	// javaIPC is optional; when set, Java Collect runs without LSP.
 	javaIPC *javaipc.Converter
 
+	// ckpt checkpoints the collecting, nil if disabled
+	ckpt *checkpointer
+
 	// modPatcher ModulePatcher
 
 	CollectOption
@@ -546,6 +594,8 @@ type methodInfo struct {
 	Receiver  dependency  `json:"receiver"`
 	Interface *dependency `json:"implement,omitempty"` // which interface it implements
 	ImplHead  string      `json:"implHead,omitempty"`
+	// the method is a default body declared inside the interface (Receiver), like rust trait default methods
+	DefaultImpl bool `json:"defaultImpl,omitempty"`
 }
 
 type functionInfo struct {
@@ -571,6 +621,12 @@ func switchSpec(l uniast.Language, repo string) LanguageSpec {
 		return java.NewJavaSpec(repo)
 	case uniast.Cpp:
 		return cpp.NewCppSpec()
+	case uniast.Scala:
+		return scala.NewScalaSpec()
+	case uniast.PHP:
+		return php.NewPhpSpec()
+	case uniast.Ruby:
+		return ruby.NewRubySpec()
 	default:
 		panic(fmt.Sprintf("unsupported language %s", l))
 	}
@@ -578,11 +634,17 @@ func switchSpec(l uniast.Language, repo string) LanguageSpec {
 
 // ApplyCollectOptionToSpec forwards language-specific entries from
 // CollectOption to the underlying LanguageSpec. Currently routes
-// `--sysroot` paths into CppSpec; other languages are no-ops.
+// `--sysroot` paths into CppSpec and the python env into PythonSpec;
+// other languages are no-ops.
 func (c *Collector) ApplyCollectOptionToSpec() {
 	if cs, ok := c.spec.(interface{ SetSysroots([]string) }); ok && len(c.Sysroots) > 0 {
 		cs.SetSysroots(c.Sysroots)
 	}
+	if ps, ok := c.spec.(*python.PythonSpec); ok && ps != nil {
+		if err := ps.SetInterpreter(python.Interpreter(c.PythonEnv, c.repo)); err != nil {
+			log.Error("use python env failed, fallback to %s: %v\n", ps.Interpreter(), err)
+		}
+	}
 }
 
 func NewCollector(repo string, cli *LSPClient) *Collector {
@@ -607,28 +669,38 @@ func NewCollector(repo string, cli *LSPClient) *Collector {
 	// if cli.Language == uniast.Rust {
 	// 	ret.modPatcher = &rust.RustModulePatcher{Root: repo}
 	// }
+	if adj, ok := ret.spec.(SymbolAdjuster); ok {
+		cli.SetSymbolAdjuster(adj)
+	}
 	return ret
 }
 
 func (c *Collector) configureLSP(ctx context.Context) {
 	// XXX: should be put in language specification
 	if c.Language == uniast.Python {
+		plugins := map[string]interface{}{}
 		if !c.NeedStdSymbol {
-			if c.Language == uniast.Python {
-				conf := map[string]interface{}{
-					"settings": map[string]interface{}{
-						"pylsp": map[string]interface{}{
-							"plugins": map[string]interface{}{
-								"jedi_definition": map[string]interface{}{
-									"follow_builtin_definitions": false,
-								},
-							},
-						},
-					},
-				}
-				c.cli.Notify(ctx, "workspace/didChangeConfiguration", conf)
+			plugins["jedi_definition"] = map[string]interface{}{
+				"follow_builtin_definitions": false,
 			}
 		}
+		// let jedi resolve the third-party packages in the same env as the spec
+		if ps, ok := c.spec.(*python.PythonSpec); ok && ps != nil && ps.Interpreter() != python.DefaultInterpreter {
+			plugins["jedi"] = map[string]interface{}{
+				"environment": ps.Interpreter(),
+			}
+		}
+		if len(plugins) == 0 {
+			return
+		}
+		conf := map[string]interface{}{
+			"settings": map[string]interface{}{
+				"pylsp": map[string]interface{}{
+					"plugins": plugins,
+				},
+			},
+		}
+		c.cli.Notify(ctx, "workspace/didChangeConfiguration", conf)
 	}
 }
 
@@ -667,23 +739,48 @@ func (c *Collector) Collect(ctx context.Context) error {
 
 	// collect some extra metadata
 	entity_syms := make([]*DocumentSymbol, 0, len(root_syms))
+	kinds := c.Kinds()
 	for _, sym := range root_syms {
 		// only language entity symbols need to be collect on next
 		if c.spec.IsEntitySymbol(*sym) {
 			entity_syms = append(entity_syms, sym)
 		}
 	}
+	// the dependencies of filtered symbols are useless, since they are removed at last
+	deps_syms := entity_syms
+	if !kinds.IsEmpty() {
+		deps_syms = make([]*DocumentSymbol, 0, len(entity_syms))
+		for _, sym := range entity_syms {
+			if ks := symbolKinds(sym.Kind); ks == nil || kinds.Keep(ks...) {
+				deps_syms = append(deps_syms, sym)
+			}
+		}
+	}
 	if c.Language != uniast.Java {
+		c.initCheckpoint()
+		tracker := progress.NewTracker(c.Progress, progress.PhaseSymbol, len(root_syms))
 		var psg errgroup.Group
 		psg.SetLimit(collectorConcurrency)
 		for _, sym := range root_syms {
 			sym := sym
+			if c.ckpt.done(false, sym.Location) {
+				tracker.Add(sym.Name)
+				continue
+			}
 			psg.Go(func() error {
-				c.runSafe("processSymbol", func() { c.processSymbol(ctx, sym, 1) })
+				c.ckpt.run(false, sym.Location, func() {
+					c.runSafe("processSymbol", func() { c.processSymbol(ctx, sym, 1) })
+				})
+				tracker.Add(sym.Name)
+				c.saveCheckpoint(false)
 				return nil
 			})
 		}
 		_ = psg.Wait()
+		tracker.Finish()
+	}
+	if c.Language == uniast.Python {
+		c.collectPythonDecorators(ctx, entity_syms)
 	}
 
 	// collect internal references
@@ -721,16 +818,30 @@ func (c *Collector) Collect(ctx context.Context) error {
 	// collect dependencies — parallel per entity symbol. processSymbol above
 	// already finished, so c.funcs/c.vars are read-only here. Writes to
 	// c.deps and c.syms are routed through c.mu / addSymbol.
+	tracker := progress.NewTracker(c.Progress, progress.PhaseDeps, len(deps_syms))
 	var deg errgroup.Group
 	deg.SetLimit(collectorConcurrency)
-	for _, sym := range entity_syms {
+	for _, sym := range deps_syms {
 		sym := sym
+		if c.ckpt.done(true, sym.Location) {
+			tracker.Add(sym.Name)
+			continue
+		}
 		deg.Go(func() error {
-			c.runSafe("collectDepsForEntity", func() { c.collectDepsForEntity(ctx, sym) })
+			c.ckpt.run(true, sym.Location, func() {
+				c.runSafe("collectDepsForEntity", func() { c.collectDepsForEntity(ctx, sym) })
+			})
+			tracker.Add(sym.Name)
+			c.saveCheckpoint(false)
 			return nil
 		})
 	}
 	_ = deg.Wait()
+	tracker.Finish()
+	// the queued external symbols below are collected as a whole, save before it in case of crashing
+	if c.Language == uniast.Cpp && ctx.Err() == nil {
+		c.saveCheckpoint(true)
+	}
 
 	// C++: needProcessExternal is gated on SKObject (clangd never reports
 	// that for C++), so external method/function bodies — including NVI
@@ -770,6 +881,36 @@ func (c *Collector) Collect(ctx context.Context) error {
 		}
 		_ = eg.Wait()
 	}
+	if ctx.Err() != nil {
+		// interrupted, keep what is collected for resuming
+		c.saveCheckpoint(true)
+	} else {
+		c.removeCheckpoint()
+	}
+	return nil
+}
+
+// symbolKinds returns the node kinds of the symbol kind, nil if unknown.
+// Functions may turn out to be tests, which is only known at export
+func symbolKinds(kind SymbolKind) []string {
+	switch kind {
+	case SKFunction:
+		return []string{"function"}
+	case SKMethod:
+		return []string{"method"}
+	case SKClass, SKStruct:
+		return []string{"type", string(uniast.TypeKindStruct)}
+	case SKInterface:
+		return []string{"type", string(uniast.TypeKindInterface)}
+	case SKEnum:
+		return []string{"type", string(uniast.TypeKindEnum)}
+	case SKObject, SKTypeParameter:
+		return []string{"type"}
+	case SKVariable:
+		return []string{"var"}
+	case SKConstant:
+		return []string{"const"}
+	}
 	return nil
 }
 
@@ -917,6 +1058,11 @@ func (c *Collector) ScannerByJavaIPC(ctx context.Context) ([]*DocumentSymbol, er
 	if c.javaIPC == nil {
 		converter, err := java.ParseRepositoryByIpc(ctx, c.repo, c.parserConfig())
 		if err != nil {
+			if converter == nil {
+				return nil, err
+			}
+			// the classes received so far are still collected
+			log.Error("Java parser stopped early, the output is partial: %v\n", err)
 		}
 		c.UseJavaIPC(converter)
 	}
@@ -1060,7 +1206,7 @@ func (c *Collector) ScannerByJavaIPC(ctx context.Context) ([]*DocumentSymbol, er
 		if fp == "" {
 			continue
 		}
-		if shouldExclude(fp) {
+		if shouldExclude(fp) || !c.inScope(fp) {
 			continue
 		}
 		if c.spec.ShouldSkip(fp) {
@@ -1081,8 +1227,7 @@ func (c *Collector) ScannerByJavaIPC(ctx context.Context) ([]*DocumentSymbol, er
 
 	// Module paths (same as ScannerByTreeSitter)
 	modulePaths := []string{c.repo}
-	rootPomPath := filepath.Join(c.repo, "pom.xml")
-	if rootModule, err := parser.ParseMavenProject(rootPomPath); err == nil && rootModule != nil {
+	if rootModule, err := parser.ParseProject(c.repo); err == nil && rootModule != nil {
 		modulePaths = parser.GetModulePaths(rootModule)
 		if len(modulePaths) == 0 {
 			modulePaths = []string{c.repo}
@@ -1208,7 +1353,7 @@ func (c *Collector) ScannerByJavaIPC(ctx context.Context) ([]*DocumentSymbol, er
 		sym := &DocumentSymbol{
 			Name:     name,
 			Kind:     classKind(ci),
-			Text:     ci.GetContent(),
+			Text:     c.javaIPC.Content(ci),
 			Location: loc,
 			Role:     DEFINITION,
 		}
@@ -1647,6 +1792,7 @@ func (c *Collector) parserConfig() *java.ParserConfig {
 	if c.CollectOption.LoadExternalSymbol {
 		config.IncludeExternalClasses = true
 		config.ResolveMavenDependencies = true
+		config.ResolveGradleDependencies = true
 	}
 	if c.cli.Verbose {
 		config.Debug = true
@@ -1659,6 +1805,22 @@ func (c *Collector) parserConfig() *java.ParserConfig {
 	return config
 }
 
+// inScope tells if the file is under one of OnlyDirs, all files are in scope if OnlyDirs is empty
+func (c *Collector) inScope(path string) bool {
+	if len(c.OnlyDirs) == 0 {
+		return true
+	}
+	for _, d := range c.OnlyDirs {
+		if !filepath.IsAbs(d) {
+			d = filepath.Join(c.repo, d)
+		}
+		if rel, err := filepath.Rel(d, path); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *Collector) ScannerFile(ctx context.Context) []*DocumentSymbol {
 	c.configureLSP(ctx)
 	excludes := make([]string, len(c.Excludes))
@@ -1670,9 +1832,9 @@ func (c *Collector) ScannerFile(ctx context.Context) []*DocumentSymbol {
 		}
 	}
 
-	// scan all files
-	root_syms := make([]*DocumentSymbol, 0, 1024)
-	scanner := func(path string, info os.FileInfo, err error) error {
+	// list all files first, to know the total for progress
+	var paths []string
+	walker := func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
@@ -1684,11 +1846,23 @@ func (c *Collector) ScannerFile(ctx context.Context) []*DocumentSymbol {
 				return nil
 			}
 		}
+		if !c.inScope(path) {
+			return nil
+		}
 
 		if c.spec.ShouldSkip(path) {
 			return nil
 		}
+		paths = append(paths, path)
+		return nil
+	}
+	if err := filepath.Walk(c.repo, walker); err != nil {
+		log.Error("scan files failed: %v", err)
+	}
 
+	// scan all files
+	root_syms := make([]*DocumentSymbol, 0, 1024)
+	scanner := func(path string) error {
 		file := c.files[path]
 		if file == nil {
 			rel, err := filepath.Rel(c.repo, path)
@@ -1737,9 +1911,16 @@ func (c *Collector) ScannerFile(ctx context.Context) []*DocumentSymbol {
 
 		return nil
 	}
-	if err := filepath.Walk(c.repo, scanner); err != nil {
-		log.Error("scan files failed: %v", err)
+	tracker := progress.NewTracker(c.Progress, progress.PhaseScan, len(paths))
+	for _, path := range paths {
+		if err := scanner(path); err != nil {
+			log.Error("scan files failed: %v", err)
+			break
+		}
+		rel, _ := filepath.Rel(c.repo, path)
+		tracker.Add(rel)
 	}
+	tracker.Finish()
 	return root_syms
 }
 
@@ -1767,6 +1948,9 @@ func (c *Collector) ScannerFileForConCurrentCPPScan(ctx context.Context) []*Docu
 				return nil
 			}
 		}
+		if !c.inScope(path) {
+			return nil
+		}
 
 		if c.spec.ShouldSkip(path) {
 			return nil
@@ -1791,6 +1975,7 @@ func (c *Collector) ScannerFileForConCurrentCPPScan(ctx context.Context) []*Docu
 	var root_syms []*DocumentSymbol
 	var mu sync.Mutex
 
+	tracker := progress.NewTracker(c.Progress, progress.PhaseScan, len(paths))
 	var eg errgroup.Group
 	// Limit concurrency to not overwhelm the LSP server
 	eg.SetLimit(32)
@@ -1798,6 +1983,10 @@ func (c *Collector) ScannerFileForConCurrentCPPScan(ctx context.Context) []*Docu
 	for _, path := range paths {
 		path := path // capture loop variable
 		eg.Go(func() error {
+			defer func() {
+				rel, _ := filepath.Rel(c.repo, path)
+				tracker.Add(rel)
+			}()
 			mu.Lock()
 			file := c.files[path]
 			if file == nil {
@@ -1863,15 +2052,15 @@ func (c *Collector) ScannerFileForConCurrentCPPScan(ctx context.Context) []*Docu
 	}
 
 	_ = eg.Wait()
+	tracker.Finish()
 	return root_syms
 }
 
 func (c *Collector) ScannerByTreeSitter(ctx context.Context) ([]*DocumentSymbol, error) {
 	var modulePaths []string
-	// Java uses parsing pom method to obtain hierarchical relationships
+	// Java uses parsing pom or Gradle settings method to obtain hierarchical relationships
 	if c.Language == uniast.Java {
-		rootPomPath := filepath.Join(c.repo, "pom.xml")
-		rootModule, err := parser.ParseMavenProject(rootPomPath)
+		rootModule, err := parser.ParseProject(c.repo)
 		if err != nil {
 			// 尝试直接遍历文件
 			modulePaths = append(modulePaths, c.repo)
@@ -1903,6 +2092,9 @@ func (c *Collector) ScannerByTreeSitter(ctx context.Context) ([]*DocumentSymbol,
 				return nil
 			}
 		}
+		if !c.inScope(path) {
+			return nil
+		}
 
 		if c.spec.ShouldSkip(path) {
 			return nil
@@ -2671,6 +2863,12 @@ func (c *Collector) getSymbolByLocation(ctx context.Context, loc Location, depth
 	} else {
 		// external symbol, just locate the content
 		var text string
+		if c.internal(loc) && c.Language == uniast.Rust {
+			if sym := c.rustMacroSymbol(ctx, loc, from); sym != nil {
+				c.addSymbol(loc, sym)
+				return sym, nil
+			}
+		}
 		if c.internal(loc) {
 			// maybe internal symbol not loaded, like `lazy_static!` in Rust
 			// use the before and after symbol as text
@@ -2890,7 +3088,8 @@ func (c *Collector) processSymbol(ctx context.Context, sym *DocumentSymbol, dept
 		// Recover the receiver from the enclosing class/struct in the
 		// documentSymbol tree, otherwise distinct methods of distinct
 		// external classes collapse to namespace-level overloads.
-		if rd == nil && c.Language == uniast.Cpp && c.cli != nil {
+		// Scala, PHP and Ruby methods never have a receiver in the signature, thus always take the enclosing class.
+		if rd == nil && (c.Language == uniast.Cpp || c.Language == uniast.Scala || c.Language == uniast.PHP || c.Language == uniast.Ruby) && c.cli != nil {
 			if p := c.cli.GetParent(sym); p != nil && (p.Kind == SKClass || p.Kind == SKStruct || p.Kind == SKInterface) {
 				rd = &dependency{Location: p.Location, Symbol: p}
 			}
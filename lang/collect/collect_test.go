@@ -64,3 +64,42 @@ func TestCollector_Collect(t *testing.T) {
 		}
 	})
 }
+
+func Test_hasDefaultBody(t *testing.T) {
+	tests := []struct {
+		text string
+		want bool
+	}{
+		{"fn name(&self) -> String;", false},
+		{"fn name(&self) -> String {\n    String::new()\n}", true},
+		{"fn name(&self) -> String { todo!() }  \n", true},
+		{"fn apply<F: Fn() -> ()>(&self, f: F);", false},
+	}
+	for _, tt := range tests {
+		if got := hasDefaultBody(tt.text); got != tt.want {
+			t.Errorf("hasDefaultBody(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func Test_isTestFunction(t *testing.T) {
+	tests := []struct {
+		lang uniast.Language
+		name string
+		text string
+		file string
+		want bool
+	}{
+		{uniast.Rust, "it_works", "#[test]\nfn it_works() {}", "src/lib.rs", true},
+		{uniast.Rust, "serve", "#[tokio::test(flavor = \"multi_thread\")]\nasync fn serve() {}", "tests/serve.rs", true},
+		{uniast.Rust, "helper", "/// #[test] in docs\nfn helper() { test() }", "tests/common.rs", false},
+		{uniast.Python, "test_add", "def test_add(): pass", "tests/test_calc.py", true},
+		{uniast.Python, "test_add", "def test_add(): pass", "calc/ops.py", false},
+	}
+	for _, tt := range tests {
+		sym := &lsp.DocumentSymbol{Name: tt.name, Text: tt.text}
+		if got := isTestFunction(tt.lang, sym, tt.file); got != tt.want {
+			t.Errorf("isTestFunction(%s, %q, %s) = %v, want %v", tt.lang, tt.text, tt.file, got, tt.want)
+		}
+	}
+}
@@ -20,12 +20,19 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"sort"
 	"strings"
 
+	"github.com/cloudwego/abcoder/lang/java"
 	"github.com/cloudwego/abcoder/lang/log"
 	"github.com/cloudwego/abcoder/lang/lsp"
 	. "github.com/cloudwego/abcoder/lang/lsp"
+	"github.com/cloudwego/abcoder/lang/php"
+	"github.com/cloudwego/abcoder/lang/progress"
+	"github.com/cloudwego/abcoder/lang/rust"
+	"github.com/cloudwego/abcoder/lang/scala"
 	"github.com/cloudwego/abcoder/lang/uniast"
 	"github.com/cloudwego/abcoder/lang/utils"
 )
@@ -59,44 +66,48 @@ func (c *Collector) lightIdentityForExternal(sym *DocumentSymbol) *uniast.Identi
 	return &id
 }
 
-func (c *Collector) fileLine(loc Location) uniast.FileLine {
-	var rel string
-	if c.internal(loc) {
-		rel, _ = filepath.Rel(c.repo, loc.URI.File())
-	} else {
-		rel = filepath.Base(loc.URI.File())
-	}
-	fileURI := string(loc.URI)
-	filePath := loc.URI.File()
-
-	text := ""
+// fileText returns the text of the file, false if it can't be read
+func (c *Collector) fileText(uri DocumentURI) (string, bool) {
 	// 1. Try LSP client files
 	if c.cli != nil {
-		if f := c.cli.GetFile(loc.URI); f != nil {
-			text = f.Text
+		if f := c.cli.GetFile(uri); f != nil && f.Text != "" {
+			return f.Text, true
 		}
 	}
 
 	// 2. Try internal cache
-	if text == "" {
-		if cached, ok := c.fileContentCache[filePath]; ok {
-			text = cached
-		}
+	filePath := uri.File()
+	if cached, ok := c.fileContentCache[filePath]; ok && cached != "" {
+		return cached, true
 	}
 
 	// 3. Fallback to OS ReadFile and update cache
-	if text == "" {
-		fd, err := os.ReadFile(filePath)
-		if err != nil {
-			return uniast.FileLine{File: rel, Line: loc.Range.Start.Line + 1}
-		}
-		text = string(fd)
-		c.fileContentCache[filePath] = text
+	fd, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", false
+	}
+	text := string(fd)
+	c.fileContentCache[filePath] = text
+	return text, true
+}
+
+func (c *Collector) fileLine(loc Location) uniast.FileLine {
+	var rel string
+	if c.internal(loc) {
+		rel, _ = filepath.Rel(c.repo, loc.URI.File())
+	} else {
+		rel = filepath.Base(loc.URI.File())
+	}
+	fileURI := string(loc.URI)
+	text, ok := c.fileText(loc.URI)
+	if !ok {
+		return uniast.FileLine{File: rel, Line: loc.Range.Start.Line + 1}
 	}
 
 	return uniast.FileLine{
 		File:        rel,
 		Line:        loc.Range.Start.Line + 1,
+		EndLine:     loc.Range.End.Line + 1,
 		StartOffset: PositionOffset(fileURI, text, loc.Range.Start),
 		EndOffset:   PositionOffset(fileURI, text, loc.Range.End),
 	}
@@ -271,15 +282,43 @@ func (c *Collector) Export(ctx context.Context) (*uniast.Repository, error) {
 		}
 	}
 
+	// Rust trait default methods have no impl block, so they are
+	// neither in c.funcs nor c.receivers. Take the trait as receiver,
+	// to distinguish the default body from each impl's override.
+	if c.Language == uniast.Rust && c.cli != nil {
+		for _, sym := range c.syms {
+			if sym.Kind != SKMethod && sym.Kind != SKFunction {
+				continue
+			}
+			if fi, ok := c.funcs[sym]; ok && fi.Method != nil && fi.Method.Receiver.Symbol != nil {
+				continue
+			}
+			p := c.cli.GetParent(sym)
+			if p == nil || p.Kind != SKInterface || !hasDefaultBody(sym.Text) {
+				continue
+			}
+			c.receivers[p] = append(c.receivers[p], sym)
+			fi := c.funcs[sym]
+			fi.Method = &methodInfo{
+				Receiver:    dependency{Location: p.Location, Symbol: p},
+				DefaultImpl: true,
+			}
+			c.funcs[sym] = fi
+		}
+	}
+
 	log.Info("Export: exporting %d symbols...\n", len(c.syms))
 	visited := make(map[*DocumentSymbol]*uniast.Identity)
+	tracker := progress.NewTracker(c.Progress, progress.PhaseExport, len(c.syms))
 	for _, symbol := range c.syms {
 		symbol := symbol
 		// recover per-symbol: a panic while exporting one symbol (e.g. a
 		// degenerate range tripping an out-of-range deep in fileLine) skips
 		// that symbol instead of aborting the whole export. "崩了就跳过".
 		c.runSafe("exportSymbol", func() { _, _ = c.exportSymbol(&repo, symbol, "", visited) })
+		tracker.Add(symbol.Name)
 	}
+	tracker.Finish()
 
 	// Synthesize inherited methods per derived class so the call graph can
 	// be walked through NVI/virtual dispatch. Outgoing this-edges in the
@@ -288,6 +327,9 @@ func (c *Collector) Export(ctx context.Context) (*uniast.Repository, error) {
 		log.Info("Export: synthesizing inherited C++ methods...\n")
 		c.synthesizeInheritedMethodsCpp(&repo)
 	}
+	if c.Language == uniast.Python {
+		c.linkPythonDynamicImports(&repo)
+	}
 
 	log.Info("Export: connecting files to packages...\n")
 	for fp, f := range c.files {
@@ -311,6 +353,10 @@ func (c *Collector) Export(ctx context.Context) (*uniast.Repository, error) {
 		}
 
 		m.Files[rel] = f
+		f.IsTest = isTestFile(c.Language, rel)
+		if c.cli != nil {
+			f.Diagnostics = c.cli.Diagnostics(lsp.NewURI(fp))
+		}
 		if pkgpath == "" || f.Package != "" {
 			continue
 		}
@@ -599,7 +645,8 @@ func (c *Collector) exportSymbol(repo *uniast.Repository, symbol *DocumentSymbol
 	fileLine := c.fileLine(symbol.Location)
 
 	content := symbol.Text
-	public := c.spec.IsPublicSymbol(*symbol)
+	visibility := c.visibility(symbol)
+	public := visibility.IsExported()
 
 	if !isDefinition && !isLocalMethod && !isLocalSymbol {
 		// In Java IPC mode we never rely on LSP Definition.
@@ -632,20 +679,26 @@ func (c *Collector) exportSymbol(repo *uniast.Repository, symbol *DocumentSymbol
 				isInterfaceMethod = true
 			}
 		}
-		if isInterfaceMethod {
+		isDefaultImpl := info.Method != nil && info.Method.DefaultImpl
+		if isInterfaceMethod && !isDefaultImpl {
 			// NOTICE: no need collect interface method for non-Java langs.
 			// Java still collects it but flags IsInterfaceMethod.
 			break
 		}
 		if info.Method != nil && info.Method.Receiver.Symbol != nil &&
-			info.Method.Receiver.Symbol.Kind == SKInterface {
+			info.Method.Receiver.Symbol.Kind == SKInterface && !isDefaultImpl {
 			isInterfaceMethod = true
 		}
 		obj := &uniast.Function{
 			FileLine:          fileLine,
 			Content:           content,
 			Exported:          public,
+			Visibility:        visibility,
 			IsInterfaceMethod: isInterfaceMethod,
+			IsDefaultImpl:     isDefaultImpl,
+			IsTest:            isTestFunction(c.Language, symbol, fileLine.File),
+			Annotations:       c.annotations(symbol),
+			Generated:         symbol.Generated,
 		}
 		obj.Signature = info.Signature
 		// NOTICE: type parames collect into types
@@ -691,6 +744,9 @@ func (c *Collector) exportSymbol(repo *uniast.Repository, symbol *DocumentSymbol
 				obj.Results = uniast.InsertDependency(obj.Results, dep)
 			}
 		}
+		if c.Language == uniast.Rust {
+			obj.Errors = rustErrors(obj)
+		}
 		if info.Method != nil && info.Method.Receiver.Symbol != nil {
 			tok := ""
 			if c.cli != nil {
@@ -702,6 +758,9 @@ func (c *Collector) exportSymbol(repo *uniast.Repository, symbol *DocumentSymbol
 					Type: *rid,
 					// Name: rid.Name,
 				}
+				if isDefaultImpl {
+					obj.Receiver.Interface = rid
+				}
 				obj.IsMethod = true
 				id.Name = rid.Name
 				// NOTICE: check if the method is a trait method
@@ -714,6 +773,7 @@ func (c *Collector) exportSymbol(repo *uniast.Repository, symbol *DocumentSymbol
 					iid, err := c.exportSymbol(repo, info.Method.Interface.Symbol, itok, visited)
 					if err == nil {
 						id.Name = iid.Name + "<" + id.Name + ">"
+						obj.Receiver.Interface = iid
 					}
 				}
 
@@ -845,10 +905,13 @@ func (c *Collector) exportSymbol(repo *uniast.Repository, symbol *DocumentSymbol
 			}
 		}
 		obj := &uniast.Type{
-			FileLine: fileLine,
-			Content:  content,
-			TypeKind: tkind,
-			Exported: public,
+			FileLine:    fileLine,
+			Content:     content,
+			TypeKind:    tkind,
+			Exported:    public,
+			Visibility:  visibility,
+			Annotations: c.annotations(symbol),
+			Generated:   symbol.Generated,
 		}
 		// Implements relationship is preserved as a first-class field rather
 		// than blended into the generic SubStruct dependency list.
@@ -878,6 +941,13 @@ func (c *Collector) exportSymbol(repo *uniast.Repository, symbol *DocumentSymbol
 				implSyms[rel.Symbol] = true
 			}
 		}
+		// the traits used by a PHP class are inlined like embedded structs
+		traits := map[string]bool{}
+		if c.Language == uniast.PHP {
+			for _, t := range php.UsedTraits(content) {
+				traits[t] = true
+			}
+		}
 		// collect deps
 		if deps := c.deps[symbol]; deps != nil {
 			for _, dep := range deps {
@@ -893,11 +963,17 @@ func (c *Collector) exportSymbol(repo *uniast.Repository, symbol *DocumentSymbol
 					continue
 				}
 				switch dep.Symbol.Kind {
-				case SKStruct, SKTypeParameter, SKInterface, SKEnum, SKClass:
+				case SKClass:
+					if traits[dep.Symbol.Name] {
+						obj.InlineStruct = uniast.InsertDependency(obj.InlineStruct, uniast.NewDependency(*depid, c.fileLine(dep.Location)))
+						break
+					}
+					obj.SubStruct = uniast.InsertDependency(obj.SubStruct, uniast.NewDependency(*depid, c.fileLine(dep.Location)))
+				case SKStruct, SKTypeParameter, SKInterface, SKEnum:
 					obj.SubStruct = uniast.InsertDependency(obj.SubStruct, uniast.NewDependency(*depid, c.fileLine(dep.Location)))
 				case SKConstant, SKVariable:
 				default:
-					log.Error("dep symbol %s not collected for \n", dep.Symbol, id)
+					log.Error("dep symbol %s not collected for %v\n", dep.Symbol, id)
 				}
 			}
 		}
@@ -938,10 +1014,13 @@ func (c *Collector) exportSymbol(repo *uniast.Repository, symbol *DocumentSymbol
 	// Vars
 	case SKConstant, SKVariable:
 		obj := &uniast.Var{
-			FileLine:   fileLine,
-			Content:    content,
-			IsExported: public,
-			IsConst:    k == SKConstant,
+			FileLine:    fileLine,
+			Content:     content,
+			IsExported:  public,
+			Visibility:  visibility,
+			IsConst:     k == SKConstant,
+			Annotations: c.annotations(symbol),
+			Generated:   symbol.Generated,
 		}
 		if ty, ok := c.vars[symbol]; ok {
 			tok := ""
@@ -1259,6 +1338,7 @@ func (c *Collector) synthesizeInheritedMethodsCpp(repo *uniast.Repository) {
 					Content:       bf.fn.Content,
 					Signature:     bf.fn.Signature,
 					Exported:      bf.fn.Exported,
+					Visibility:    bf.fn.Visibility,
 					IsMethod:      true,
 					Receiver:      &uniast.Receiver{IsPointer: false, Type: D.Identity},
 					MethodCalls:   cloneDeps(bf.fn.MethodCalls),
@@ -1431,3 +1511,128 @@ func (c *Collector) extractCppCallSig(sym *lsp.DocumentSymbol) (ret string) {
 
 	return name + header[openIdx:closeIdx+1]
 }
+
+// hasDefaultBody tells if a method declared in a trait carries a default body,
+// e.g. `fn name(&self) -> String { ... }` rather than `fn name(&self) -> String;`
+func hasDefaultBody(text string) bool {
+	return strings.HasSuffix(strings.TrimSpace(text), "}")
+}
+
+var rustTestAttr = regexp.MustCompile(`(?m)^\s*#\[(?:[\w:]+::)?test\b`)
+
+// isTestFunction tells if a function symbol is a test case,
+// e.g. `#[test] fn it_works()` of rust, or `def test_foo()` in a test file of python
+func isTestFunction(lang uniast.Language, sym *DocumentSymbol, file string) bool {
+	switch lang {
+	case uniast.Rust:
+		// the range of rust-analyzer symbols covers the outer attributes
+		head := sym.Text
+		if idx := strings.Index(head, "fn "); idx >= 0 {
+			head = head[:idx]
+		}
+		return rustTestAttr.MatchString(head)
+	case uniast.Python:
+		return strings.HasPrefix(sym.Name, "test") && isTestFile(lang, file)
+	case uniast.Ruby:
+		// minitest runs the methods named test_*
+		return strings.HasPrefix(sym.Name, "test_") && isTestFile(lang, file)
+	case uniast.PHP:
+		// phpunit runs the methods named test* or marked by #[Test] or @test
+		if !strings.HasSuffix(file, "Test.php") {
+			return false
+		}
+		if strings.HasPrefix(sym.Name, "test") {
+			return true
+		}
+		head := sym.Text
+		if idx := strings.Index(head, "function "); idx >= 0 {
+			head = head[:idx]
+		}
+		for _, a := range php.Annotations(head) {
+			if a.Name == "Test" || strings.HasSuffix(a.Name, `\Test`) {
+				return true
+			}
+		}
+		return strings.Contains(head, "@test")
+	}
+	return false
+}
+
+// rustErrors tells the error type of the function returning a Result by its signature,
+// which is resolved among the types of the results by the name. Nil if it does not return a Result
+func rustErrors(fn *uniast.Function) *uniast.Errors {
+	errType, ok := rust.ResultError(fn.Signature)
+	if !ok {
+		return nil
+	}
+	ret := &uniast.Errors{Returns: true}
+	// the aliases like io::Result<T>, and the generic or boxed errors are not resolved
+	if errType == "" || strings.ContainsAny(errType, "<&") {
+		return ret
+	}
+	if i := strings.LastIndex(errType, "::"); i >= 0 {
+		errType = errType[i+2:]
+	}
+	for _, dep := range fn.Results {
+		if dep.Name == errType {
+			id := dep.Identity
+			ret.Type = &id
+			break
+		}
+	}
+	return ret
+}
+
+// annotations parses the annotations of the symbol by the language
+func (c *Collector) annotations(sym *DocumentSymbol) []uniast.Annotation {
+	switch c.Language {
+	case uniast.Rust:
+		return rust.Attributes(sym.Text)
+	case uniast.Java:
+		return java.Annotations(sym.Text)
+	case uniast.Python:
+		return c.pythonDecorators(sym)
+	case uniast.Scala:
+		return scala.Annotations(sym.Text)
+	case uniast.PHP:
+		return php.Annotations(sym.Text)
+	}
+	return nil
+}
+
+// visibility tells the visibility of the symbol by the language spec,
+// or by IsPublicSymbol if the spec does not know finer visibilities
+func (c *Collector) visibility(sym *DocumentSymbol) uniast.Visibility {
+	if vs, ok := c.spec.(VisibilitySpec); ok {
+		return vs.Visibility(*sym)
+	}
+	if c.spec.IsPublicSymbol(*sym) {
+		return uniast.VisibilityPublic
+	}
+	return uniast.VisibilityPrivate
+}
+
+// isTestFile tells if a file only contains tests by the convention of the language,
+// e.g. integration tests under `tests/` of rust, `test_*.py` of python, `src/test/` of scala, `*Test.php` of php, `spec/` of ruby
+func isTestFile(lang uniast.Language, path string) bool {
+	switch lang {
+	case uniast.Rust:
+		return slices.Contains(strings.Split(filepath.ToSlash(filepath.Dir(path)), "/"), "tests")
+	case uniast.Python:
+		base := filepath.Base(path)
+		return strings.HasPrefix(base, "test_") || strings.HasSuffix(base, "_test.py") || base == "conftest.py"
+	case uniast.Scala:
+		if strings.Contains("/"+filepath.ToSlash(path), "/src/test/") {
+			return true
+		}
+		name := strings.TrimSuffix(filepath.Base(path), ".scala")
+		return strings.HasSuffix(name, "Test") || strings.HasSuffix(name, "Spec") || strings.HasSuffix(name, "Suite")
+	case uniast.Ruby:
+		dirs := strings.Split(filepath.ToSlash(filepath.Dir(path)), "/")
+		return slices.Contains(dirs, "spec") || slices.Contains(dirs, "test") ||
+			strings.HasSuffix(path, "_spec.rb") || strings.HasSuffix(path, "_test.rb")
+	case uniast.PHP:
+		return slices.Contains(strings.Split(filepath.ToSlash(filepath.Dir(path)), "/"), "tests") || strings.HasSuffix(path, "Test.php")
+	}
+	return false
+}
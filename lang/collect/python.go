@@ -0,0 +1,135 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collect
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+
+	"github.com/cloudwego/abcoder/lang/log"
+	. "github.com/cloudwego/abcoder/lang/lsp"
+	"github.com/cloudwego/abcoder/lang/python"
+	"github.com/cloudwego/abcoder/lang/uniast"
+)
+
+// collectPythonDecorators appends the tokens of the decorators to the decorated functions and classes.
+// pylsp excludes decorators from the symbol range, thus the decorators are collected as dependencies like the body.
+// It must run after processSymbol, since the token indexes of function infos are kept
+func (c *Collector) collectPythonDecorators(ctx context.Context, syms []*DocumentSymbol) {
+	lines := map[DocumentURI][]string{}
+	for _, sym := range syms {
+		if sym.Kind != SKFunction && sym.Kind != SKMethod && sym.Kind != SKClass {
+			continue
+		}
+		if strings.HasPrefix(strings.TrimSpace(sym.Text), "@") {
+			// already included
+			continue
+		}
+		uri := sym.Location.URI
+		ls, ok := lines[uri]
+		if !ok {
+			if text, ok := c.fileText(uri); ok {
+				ls = strings.Split(text, "\n")
+			}
+			lines[uri] = ls
+		}
+		start := sym.Location.Range.Start
+		if start.Line >= len(ls) {
+			continue
+		}
+		line := python.DecoratorStart(ls, start.Line)
+		if line < 0 {
+			continue
+		}
+		tokens, err := c.cli.SemanticTokens(ctx, Location{
+			URI:   uri,
+			Range: Range{Start: Position{Line: line}, End: start},
+		})
+		if err != nil {
+			log.Error("get tokens of decorators of %s failed: %v\n", sym.Name, err)
+			continue
+		}
+		sym.Tokens = append(sym.Tokens, tokens...)
+	}
+}
+
+// pythonDecorators parses the decorators of the function or class
+func (c *Collector) pythonDecorators(sym *DocumentSymbol) []uniast.Annotation {
+	if sym.Kind != SKFunction && sym.Kind != SKMethod && sym.Kind != SKClass {
+		return nil
+	}
+	if strings.HasPrefix(strings.TrimSpace(sym.Text), "@") {
+		// the decorators are in the range
+		lines := strings.Split(sym.Text, "\n")
+		for i, line := range lines {
+			if line = strings.TrimSpace(line); strings.HasPrefix(line, "def ") || strings.HasPrefix(line, "async def ") || strings.HasPrefix(line, "class ") {
+				return python.Decorators(lines[:i])
+			}
+		}
+		return nil
+	}
+	text, ok := c.fileText(sym.Location.URI)
+	if !ok {
+		return nil
+	}
+	lines := strings.Split(text, "\n")
+	line := sym.Location.Range.Start.Line
+	if line >= len(lines) {
+		return nil
+	}
+	if start := python.DecoratorStart(lines, line); start >= 0 {
+		return python.Decorators(lines[start:line])
+	}
+	return nil
+}
+
+// linkPythonDynamicImports adds the modules imported by `importlib.import_module` or `__import__`
+// to the functions and vars as dynamic dependencies, see python.DynamicImports.
+// The dependency has an empty name since it refers to the module itself, and may be unresolved
+func (c *Collector) linkPythonDynamicImports(repo *uniast.Repository) {
+	spec, ok := c.spec.(*python.PythonSpec)
+	if !ok {
+		return
+	}
+	link := func(deps []uniast.Dependency, content string, fl uniast.FileLine, pkgPath string) []uniast.Dependency {
+		isPackage := filepath.Base(fl.File) == "__init__.py"
+		for _, imp := range python.DynamicImports(content, pkgPath, isPackage) {
+			id := uniast.NewIdentity(spec.ModuleName(imp.Module), imp.Module, "")
+			dep := uniast.NewDependency(id, uniast.FileLine{
+				File:        fl.File,
+				Line:        fl.Line + strings.Count(content[:imp.Offset], "\n"),
+				StartOffset: fl.StartOffset + imp.Offset,
+				EndOffset:   fl.StartOffset + imp.Offset + len(imp.Text),
+			})
+			dep.Dynamic = true
+			deps = uniast.InsertDependency(deps, dep)
+		}
+		return deps
+	}
+	for _, mod := range repo.Modules {
+		if mod.IsExternal() {
+			continue
+		}
+		for _, pkg := range mod.Packages {
+			for _, f := range pkg.Functions {
+				f.GlobalVars = link(f.GlobalVars, f.Content, f.FileLine, string(pkg.PkgPath))
+			}
+			for _, v := range pkg.Vars {
+				v.Dependencies = link(v.Dependencies, v.Content, v.FileLine, string(pkg.PkgPath))
+			}
+		}
+	}
+}
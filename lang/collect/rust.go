@@ -0,0 +1,63 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collect
+
+import (
+	"context"
+	"strings"
+
+	"github.com/cloudwego/abcoder/lang/log"
+	. "github.com/cloudwego/abcoder/lang/lsp"
+	"github.com/cloudwego/abcoder/lang/rust"
+)
+
+// rustMacroSymbol synthesizes the symbol of the item generated by the macro call at the location,
+// like the `static ref` of `lazy_static!` or the functions of a `macro_rules!`, which rust-analyzer
+// doesn't report as document symbols. The item is the one named by the referring token in the expansion,
+// and the symbol is marked Generated with the expanded codes as its text. It returns nil if not found
+func (c *Collector) rustMacroSymbol(ctx context.Context, loc Location, from Token) *DocumentSymbol {
+	exp, err := c.cli.ExpandMacro(ctx, loc.URI, loc.Range.Start)
+	if err != nil || exp == nil || exp.Expansion == "" {
+		if err != nil && c.cli.ClientOptions.Verbose {
+			log.Error("expand macro at %v failed: %v\n", loc, err)
+		}
+		return nil
+	}
+	var found *rust.MacroItem
+	items := rust.MacroItems(exp.Expansion)
+	for i, it := range items {
+		name := it.Name
+		if j := strings.LastIndexByte(name, '.'); j >= 0 {
+			name = name[j+1:]
+		}
+		if name != from.Text {
+			continue
+		}
+		// the items may share the name, like `struct CONFIG` and `static CONFIG: CONFIG` of `lazy_static!`
+		if found == nil || it.Kind == c.spec.TokenKind(from) {
+			found = &items[i]
+		}
+	}
+	if found == nil {
+		return nil
+	}
+	return &DocumentSymbol{
+		Name:      found.Name,
+		Kind:      found.Kind,
+		Location:  loc,
+		Text:      found.Text,
+		Generated: true,
+	}
+}
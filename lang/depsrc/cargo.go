@@ -0,0 +1,76 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package depsrc
+
+import (
+	"context"
+	"os"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+const cratesIOSource = "registry+https://github.com/rust-lang/crates.io-index"
+
+// Crate is a crates.io package locked by Cargo.lock
+type Crate struct {
+	Name     string `toml:"name"`
+	Version  string `toml:"version"`
+	Source   string `toml:"source"`
+	Checksum string `toml:"checksum"`
+}
+
+// LockedCrates returns the crates.io packages of the Cargo.lock file
+func LockedCrates(lockfile string) ([]Crate, error) {
+	bs, err := os.ReadFile(lockfile)
+	if err != nil {
+		return nil, err
+	}
+	var lock struct {
+		Package []Crate `toml:"package"`
+	}
+	if err := toml.Unmarshal(bs, &lock); err != nil {
+		return nil, err
+	}
+	var ret []Crate
+	for _, c := range lock.Package {
+		if c.Source == cratesIOSource {
+			ret = append(ret, c)
+		}
+	}
+	return ret, nil
+}
+
+// FetchCrates unpacks the crates.io packages of the Cargo.lock file into the cargo registry,
+// if they are not there yet. It returns the number of the crates available in the registry and
+// the first error, while the rest are still fetched
+func (f *Fetcher) FetchCrates(ctx context.Context, lockfile string) (n int, err error) {
+	crates, err := LockedCrates(lockfile)
+	if err != nil {
+		return 0, err
+	}
+	for _, c := range crates {
+		if e := ctx.Err(); e != nil {
+			return n, e
+		}
+		if _, e := f.Crate(ctx, c.Name, c.Version, c.Checksum); e != nil {
+			if err == nil {
+				err = e
+			}
+			continue
+		}
+		n++
+	}
+	return n, err
+}
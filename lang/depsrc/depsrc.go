@@ -0,0 +1,335 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package depsrc fetches the sources of the exact dependency versions on demand,
+// from the Go module proxy or crates.io, thus the external symbols can be loaded
+// even if the dependencies are not in the local module caches.
+package depsrc
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"golang.org/x/mod/module"
+	modzip "golang.org/x/mod/zip"
+)
+
+const (
+	DefaultGoProxy   = "https://proxy.golang.org"
+	DefaultCratesURL = "https://static.crates.io/crates"
+
+	// crates.io index dir of the cargo registry, used if none exists yet
+	cratesIndexDir = "index.crates.io-1949cf8c6b5b557f"
+	// content of the .cargo-ok file which marks a crate fully unpacked
+	cargoOK = `{"v":1}`
+)
+
+// Fetcher downloads and unpacks the dependency sources. The zero value is ready to use
+type Fetcher struct {
+	// CacheDir keeps the unpacked Go modules, abcoder/deps under the user cache dir if empty
+	CacheDir string
+	// GoProxy is the Go module proxy, the first proxy of $GOPROXY or DefaultGoProxy if empty
+	GoProxy string
+	// CratesURL is where the .crate archives are downloaded from, DefaultCratesURL if empty
+	CratesURL string
+	// CargoHome is where the crates are unpacked into the cargo registry, $CARGO_HOME or ~/.cargo if empty
+	CargoHome string
+	// Client sends the downloading requests, http.DefaultClient if nil
+	Client *http.Client
+
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+// GoModule returns the source dir of the Go module path@version.
+// The module cache ($GOMODCACHE) is looked up first, then the module zip is downloaded from the proxy
+func (f *Fetcher) GoModule(ctx context.Context, path, version string) (string, error) {
+	if version == "" {
+		return "", fmt.Errorf("no version of module %s", path)
+	}
+	escPath, err := module.EscapePath(path)
+	if err != nil {
+		return "", err
+	}
+	escVer, err := module.EscapeVersion(version)
+	if err != nil {
+		return "", err
+	}
+	name := filepath.FromSlash(escPath) + "@" + escVer
+	if cache := goModCache(); cache != "" {
+		if d := filepath.Join(cache, name); isDir(d) {
+			return d, nil
+		}
+	}
+	dir := filepath.Join(f.cacheDir(), "go", name)
+	defer f.lock(dir)()
+	if isDir(dir) {
+		return dir, nil
+	}
+
+	proxy, err := f.goProxy()
+	if err != nil {
+		return "", err
+	}
+	tmp, err := os.CreateTemp("", "abcoder-mod-*.zip")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	err = f.download(ctx, proxy+"/"+escPath+"/@v/"+escVer+".zip", tmp)
+	tmp.Close()
+	if err != nil {
+		return "", fmt.Errorf("download module %s@%s: %w", path, version, err)
+	}
+	// unpack into a temp dir and rename it, thus a broken unpacking is never seen as cached
+	if err := os.MkdirAll(filepath.Dir(dir), 0o755); err != nil {
+		return "", err
+	}
+	part := dir + ".partial"
+	os.RemoveAll(part)
+	if err := modzip.Unzip(part, module.Version{Path: path, Version: version}, tmp.Name()); err != nil {
+		os.RemoveAll(part)
+		return "", fmt.Errorf("unzip module %s@%s: %w", path, version, err)
+	}
+	if err := os.Rename(part, dir); err != nil {
+		os.RemoveAll(part)
+		return "", err
+	}
+	return dir, nil
+}
+
+// Crate returns the source dir of the crate name-version in the cargo registry.
+// If it is not unpacked yet, the .crate archive is downloaded, checked against the sha256 checksum
+// (skipped if empty) of Cargo.lock and unpacked into the registry, where cargo and rust-analyzer find it
+func (f *Fetcher) Crate(ctx context.Context, name, version, checksum string) (string, error) {
+	if name == "" || version == "" || strings.ContainsAny(name+version, `/\`) {
+		return "", fmt.Errorf("invalid crate %s-%s", name, version)
+	}
+	src := filepath.Join(f.cargoHome(), "registry", "src")
+	base := name + "-" + version
+	if ds, _ := filepath.Glob(filepath.Join(src, "*", base)); len(ds) > 0 {
+		return ds[0], nil
+	}
+	index := cratesIndexDir
+	if ds, _ := filepath.Glob(filepath.Join(src, "index.crates.io-*")); len(ds) > 0 {
+		index = filepath.Base(ds[0])
+	}
+	dir := filepath.Join(src, index, base)
+	defer f.lock(dir)()
+	if isDir(dir) {
+		return dir, nil
+	}
+
+	url := strings.TrimSuffix(f.CratesURL, "/")
+	if url == "" {
+		url = DefaultCratesURL
+	}
+	tmp, err := os.CreateTemp("", "abcoder-crate-*.crate")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+	h := sha256.New()
+	if err := f.download(ctx, url+"/"+name+"/"+base+".crate", io.MultiWriter(tmp, h)); err != nil {
+		return "", fmt.Errorf("download crate %s: %w", base, err)
+	}
+	if sum := hex.EncodeToString(h.Sum(nil)); checksum != "" && !strings.EqualFold(sum, checksum) {
+		return "", fmt.Errorf("checksum mismatch of crate %s: got %s, want %s", base, sum, checksum)
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	part := filepath.Join(src, index, "."+base+".partial")
+	os.RemoveAll(part)
+	if err := untarCrate(tmp, base, part); err != nil {
+		os.RemoveAll(part)
+		return "", fmt.Errorf("unpack crate %s: %w", base, err)
+	}
+	if err := os.WriteFile(filepath.Join(part, ".cargo-ok"), []byte(cargoOK), 0o644); err != nil {
+		os.RemoveAll(part)
+		return "", err
+	}
+	if err := os.Rename(part, dir); err != nil {
+		os.RemoveAll(part)
+		return "", err
+	}
+	return dir, nil
+}
+
+// untarCrate extracts the gzipped tarball of crate base, whose entries are all under `base/`, into dir
+func untarCrate(r io.Reader, base string, dir string) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		rel, ok := strings.CutPrefix(hdr.Name, base+"/")
+		if !ok || rel == "" {
+			continue
+		}
+		rel = filepath.FromSlash(rel)
+		if !filepath.IsLocal(rel) {
+			return fmt.Errorf("invalid entry %s", hdr.Name)
+		}
+		dst := filepath.Join(dir, rel)
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(dst, 0o755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+				return err
+			}
+			if err := writeFile(dst, tr, os.FileMode(hdr.Mode)&0o755|0o644); err != nil {
+				return err
+			}
+		}
+		// links and others are never in crates published by cargo
+	}
+}
+
+func writeFile(path string, r io.Reader, mode os.FileMode) error {
+	w, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, r); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+func (f *Fetcher) download(ctx context.Context, url string, w io.Writer) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	client := f.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+// lock serializes the fetching of the same dir, returns the unlock func
+func (f *Fetcher) lock(dir string) func() {
+	f.mu.Lock()
+	if f.locks == nil {
+		f.locks = make(map[string]*sync.Mutex)
+	}
+	l := f.locks[dir]
+	if l == nil {
+		l = new(sync.Mutex)
+		f.locks[dir] = l
+	}
+	f.mu.Unlock()
+	l.Lock()
+	return l.Unlock
+}
+
+func (f *Fetcher) cacheDir() string {
+	if f.CacheDir != "" {
+		return f.CacheDir
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "abcoder", "deps")
+}
+
+var errProxyOff = errors.New("module downloading is disabled by GOPROXY=off")
+
+// goProxy returns the first http proxy of f.GoProxy or $GOPROXY, like `https://goproxy.cn,direct`
+func (f *Fetcher) goProxy() (string, error) {
+	list := f.GoProxy
+	if list == "" {
+		list = os.Getenv("GOPROXY")
+	}
+	for _, p := range strings.FieldsFunc(list, func(r rune) bool { return r == ',' || r == '|' }) {
+		switch {
+		case p == "off":
+			return "", errProxyOff
+		case strings.HasPrefix(p, "https://"), strings.HasPrefix(p, "http://"):
+			return strings.TrimSuffix(p, "/"), nil
+		}
+	}
+	return DefaultGoProxy, nil
+}
+
+func (f *Fetcher) cargoHome() string {
+	if f.CargoHome != "" {
+		return f.CargoHome
+	}
+	if d := os.Getenv("CARGO_HOME"); d != "" {
+		return d
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".cargo"
+	}
+	return filepath.Join(home, ".cargo")
+}
+
+// goModCache returns $GOMODCACHE, or $GOPATH/pkg/mod by default
+func goModCache() string {
+	if d := os.Getenv("GOMODCACHE"); d != "" {
+		return d
+	}
+	if gp := os.Getenv("GOPATH"); gp != "" {
+		return filepath.Join(filepath.SplitList(gp)[0], "pkg", "mod")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, "go", "pkg", "mod")
+}
+
+func isDir(path string) bool {
+	fi, err := os.Stat(path)
+	return err == nil && fi.IsDir()
+}
@@ -0,0 +1,140 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package depsrc
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestFetcher_GoModule(t *testing.T) {
+	t.Setenv("GOMODCACHE", t.TempDir())
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range map[string]string{
+		"go.mod":   "module example.com/Foo\n",
+		"foo.go":   "package foo\n\nfunc Foo() {}\n",
+		"bar/b.go": "package bar\n",
+	} {
+		w, _ := zw.Create("example.com/Foo@v1.2.0/" + name)
+		w.Write([]byte(content))
+	}
+	zw.Close()
+	var hits atomic.Int32
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if r.URL.Path != "/example.com/!foo/@v/v1.2.0.zip" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(buf.Bytes())
+	}))
+	defer svr.Close()
+
+	f := &Fetcher{CacheDir: t.TempDir(), GoProxy: svr.URL + ",direct"}
+	dir, err := f.GoModule(context.Background(), "example.com/Foo", "v1.2.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bs, err := os.ReadFile(filepath.Join(dir, "bar", "b.go")); err != nil || string(bs) != "package bar\n" {
+		t.Fatalf("bar/b.go: %q, %v", bs, err)
+	}
+	// cached
+	if dir2, err := f.GoModule(context.Background(), "example.com/Foo", "v1.2.0"); err != nil || dir2 != dir || hits.Load() != 1 {
+		t.Fatalf("refetched: %s, %v, %d hits", dir2, err, hits.Load())
+	}
+	if _, err := f.GoModule(context.Background(), "example.com/Foo", "v1.3.0"); err == nil {
+		t.Fatal("expect error of missing version")
+	}
+	if _, err := (&Fetcher{CacheDir: t.TempDir(), GoProxy: "off"}).GoModule(context.Background(), "example.com/Foo", "v1.2.0"); err != errProxyOff {
+		t.Fatalf("expect errProxyOff, got %v", err)
+	}
+}
+
+func TestFetcher_Crate(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range map[string]string{
+		"Cargo.toml": "[package]\nname = \"foo\"\n",
+		"src/lib.rs": "pub fn foo() {}\n",
+	} {
+		tw.WriteHeader(&tar.Header{Name: "foo-0.1.0/" + name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg})
+		tw.Write([]byte(content))
+	}
+	tw.Close()
+	gz.Close()
+	sum := sha256.Sum256(buf.Bytes())
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/foo/foo-0.1.0.crate" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(buf.Bytes())
+	}))
+	defer svr.Close()
+
+	home := t.TempDir()
+	lock := filepath.Join(t.TempDir(), "Cargo.lock")
+	os.WriteFile(lock, []byte(`version = 3
+
+[[package]]
+name = "app"
+version = "0.1.0"
+dependencies = ["foo"]
+
+[[package]]
+name = "foo"
+version = "0.1.0"
+source = "registry+https://github.com/rust-lang/crates.io-index"
+checksum = "`+hex.EncodeToString(sum[:])+`"
+`), 0o644)
+	crates, err := LockedCrates(lock)
+	if err != nil || len(crates) != 1 || crates[0].Name != "foo" {
+		t.Fatalf("LockedCrates: %+v, %v", crates, err)
+	}
+
+	f := &Fetcher{CargoHome: home, CratesURL: svr.URL}
+	if _, err := f.Crate(context.Background(), "foo", "0.1.0", strings.Repeat("0", 64)); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("expect checksum mismatch, got %v", err)
+	}
+	if n, err := f.FetchCrates(context.Background(), lock); err != nil || n != 1 {
+		t.Fatalf("FetchCrates: %d, %v", n, err)
+	}
+	dir := filepath.Join(home, "registry", "src", cratesIndexDir, "foo-0.1.0")
+	if bs, err := os.ReadFile(filepath.Join(dir, "src", "lib.rs")); err != nil || string(bs) != "pub fn foo() {}\n" {
+		t.Fatalf("src/lib.rs: %q, %v", bs, err)
+	}
+	if bs, _ := os.ReadFile(filepath.Join(dir, ".cargo-ok")); string(bs) != cargoOK {
+		t.Fatalf(".cargo-ok: %q", bs)
+	}
+	// already unpacked, no downloading
+	svr.Close()
+	if got, err := f.Crate(context.Background(), "foo", "0.1.0", ""); err != nil || got != dir {
+		t.Fatalf("Crate: %s, %v", got, err)
+	}
+}
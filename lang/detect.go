@@ -0,0 +1,157 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lang
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/cloudwego/abcoder/lang/uniast"
+)
+
+// manifests are the project files at the root of a repo, which tell its languages
+var manifests = []struct {
+	file     string
+	language uniast.Language
+}{
+	{"go.mod", uniast.Golang},
+	{"Cargo.toml", uniast.Rust},
+	{"pyproject.toml", uniast.Python},
+	{"setup.py", uniast.Python},
+	{"setup.cfg", uniast.Python},
+	{"pom.xml", uniast.Java},
+	{"build.gradle", uniast.Java},
+	{"build.gradle.kts", uniast.Java},
+	{"settings.gradle", uniast.Java},
+	{"settings.gradle.kts", uniast.Java},
+	{"build.sbt", uniast.Scala},
+	{"build.sc", uniast.Scala},
+	{"composer.json", uniast.PHP},
+	{"Gemfile", uniast.Ruby},
+	{"tsconfig.json", uniast.TypeScript},
+	{"package.json", uniast.TypeScript},
+}
+
+// sourceExts maps the extensions of source files to their languages
+var sourceExts = map[string]uniast.Language{
+	".go":    uniast.Golang,
+	".rs":    uniast.Rust,
+	".py":    uniast.Python,
+	".java":  uniast.Java,
+	".ts":    uniast.TypeScript,
+	".tsx":   uniast.TypeScript,
+	".js":    uniast.TypeScript,
+	".jsx":   uniast.TypeScript,
+	".mjs":   uniast.TypeScript,
+	".c":     uniast.Cxx,
+	".cpp":   uniast.Cpp,
+	".cc":    uniast.Cpp,
+	".cxx":   uniast.Cpp,
+	".hpp":   uniast.Cpp,
+	".scala": uniast.Scala,
+	".sc":    uniast.Scala,
+	".php":   uniast.PHP,
+	".rb":    uniast.Ruby,
+}
+
+// skipDetectDirs are the dirs of dependencies, builds or environments, whose files are not the sources of the repo
+var skipDetectDirs = map[string]bool{
+	"node_modules": true,
+	"vendor":       true,
+	"target":       true,
+	"build":        true,
+	"dist":         true,
+	"venv":         true,
+	"__pycache__":  true,
+}
+
+// DetectLanguages detects the languages of the repo to parse.
+// Languages whose manifest (go.mod, Cargo.toml, pyproject.toml, pom.xml, tsconfig.json...) is at the root
+// and which have source files are all returned, for multi-language repos.
+// Without any manifest, the language of the source files is returned only if it is the only one,
+// otherwise it is ambiguous and an error is returned, thus the language must be given explicitly.
+func DetectLanguages(dir string) ([]uniast.Language, error) {
+	sources, err := countSources(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []uniast.Language
+	seen := map[uniast.Language]bool{}
+	for _, m := range manifests {
+		if seen[m.language] || sources[m.language] == 0 {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(dir, m.file)); err == nil {
+			seen[m.language] = true
+			ret = append(ret, m.language)
+		}
+	}
+	if len(ret) > 0 {
+		return ret, nil
+	}
+
+	for l := range sources {
+		ret = append(ret, l)
+	}
+	switch len(ret) {
+	case 0:
+		return nil, fmt.Errorf("no source files of the supported languages found in %s", dir)
+	case 1:
+		return ret, nil
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if sources[ret[i]] != sources[ret[j]] {
+			return sources[ret[i]] > sources[ret[j]]
+		}
+		return ret[i] < ret[j]
+	})
+	found := make([]string, len(ret))
+	for i, l := range ret {
+		found[i] = fmt.Sprintf("%s (%d files)", l, sources[l])
+	}
+	return nil, fmt.Errorf("ambiguous languages of %s without any manifest: %s, please specify the language", dir, strings.Join(found, ", "))
+}
+
+// countSources counts the source files of each language under the dir
+func countSources(dir string) (map[uniast.Language]int, error) {
+	ret := map[uniast.Language]int{}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := d.Name()
+		if d.IsDir() {
+			if path != dir && (strings.HasPrefix(name, ".") || skipDetectDirs[name]) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if l, ok := sourceExts[filepath.Ext(name)]; ok {
+			ret[l]++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("detect languages of %s: %w", dir, err)
+	}
+	return ret, nil
+}
@@ -0,0 +1,67 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lang
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/cloudwego/abcoder/lang/uniast"
+)
+
+func TestDetectLanguages(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   []string
+		want    []uniast.Language
+		wantErr bool
+	}{
+		{"go", []string{"go.mod", "main.go", "node_modules/x/index.js"}, []uniast.Language{uniast.Golang}, false},
+		{"multi", []string{"go.mod", "main.go", "pyproject.toml", "py/a.py"}, []uniast.Language{uniast.Golang, uniast.Python}, false},
+		{"manifest without sources", []string{"go.mod", "main.go", "package.json"}, []uniast.Language{uniast.Golang}, false},
+		{"scala", []string{"build.sbt", "src/main/scala/A.scala", "target/B.java"}, []uniast.Language{uniast.Scala}, false},
+		{"php", []string{"composer.json", "src/A.php", "vendor/x/y/B.php", "package.json"}, []uniast.Language{uniast.PHP}, false},
+		{"ruby", []string{"Gemfile", "app/models/user.rb", "Rakefile"}, []uniast.Language{uniast.Ruby}, false},
+		{"sources only", []string{"a/b.rs", "c.rs"}, []uniast.Language{uniast.Rust}, false},
+		{"ambiguous", []string{"a.py", "b.java"}, nil, true},
+		{"hidden", []string{"a.py", ".github/x.js"}, []uniast.Language{uniast.Python}, false},
+		{"empty", []string{"README.md"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, f := range tt.files {
+				path := filepath.Join(dir, f)
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, nil, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := DetectLanguages(dir)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DetectLanguages() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DetectLanguages() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
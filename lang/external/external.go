@@ -0,0 +1,249 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package external runs the out-of-tree language parsers, which are executables
+// talking with abcoder through JSON lines over stdio, and producing UniAST modules.
+//
+// A parser is found by, in order:
+//   - the path given by `abcoder parse --external-parser`
+//   - the path registered by Register
+//   - the executable named `abcoder-parser-<language>` in PATH
+package external
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/cloudwego/abcoder/lang/log"
+	"github.com/cloudwego/abcoder/lang/progress"
+	"github.com/cloudwego/abcoder/lang/uniast"
+	"github.com/cloudwego/abcoder/version"
+)
+
+// ParserPrefix is the name prefix of the parser executables discovered in PATH
+const ParserPrefix = "abcoder-parser-"
+
+var (
+	parsersMu sync.RWMutex
+	parsers   = map[uniast.Language]string{}
+)
+
+// Register registers the parser executable of the language, which has no builtin parser
+func Register(lang uniast.Language, path string) {
+	parsersMu.Lock()
+	defer parsersMu.Unlock()
+	parsers[lang] = path
+}
+
+// Lookup returns the parser executable of the language, registered or in PATH
+func Lookup(lang uniast.Language) (string, bool) {
+	parsersMu.RLock()
+	path, ok := parsers[lang]
+	parsersMu.RUnlock()
+	if ok {
+		return path, true
+	}
+	if lang == uniast.Unknown {
+		return "", false
+	}
+	path, err := exec.LookPath(ParserPrefix + strings.ToLower(string(lang)))
+	if err != nil {
+		return "", false
+	}
+	return path, true
+}
+
+type Options struct {
+	// ParserPath is the parser executable, looked up by the language if empty
+	ParserPath string
+	// files or directories to skip, relative to the repo if not absolute
+	Excludes []string
+	// OnlyDirs limits the parsing to the directories
+	OnlyDirs []string
+	// LoadExternalSymbol asks to collect the symbols of the dependencies
+	LoadExternalSymbol bool
+	// NeedTest asks to collect the test files
+	NeedTest bool
+	// NeedComment asks to collect the comments
+	NeedComment bool
+	// Progress receives the count of parsed modules, can be nil
+	Progress progress.Reporter
+}
+
+// ParseRepo parses the repo by the external parser of the language
+func ParseRepo(ctx context.Context, lang uniast.Language, repoPath string, opts Options) (*uniast.Repository, error) {
+	parser := opts.ParserPath
+	if parser == "" {
+		var ok bool
+		if parser, ok = Lookup(lang); !ok {
+			return nil, fmt.Errorf("no parser found for language %s, give it by --external-parser or put %s%s in PATH", lang, ParserPrefix, lang)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, parser)
+	cmd.Dir = repoPath
+	cmd.Env = append(os.Environ(),
+		"ABCODER_TOOL_VERSION="+version.Version,
+		"ABCODER_AST_VERSION="+uniast.Version,
+	)
+	// the logs of the parser go to stderr
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	log.Info("start external parser %s\n", parser)
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start external parser %s: %w", parser, err)
+	}
+	defer func() {
+		cancel()
+		_ = cmd.Wait()
+	}()
+
+	req := ParseRequest{
+		Protocol:           ProtocolVersion,
+		ASTVersion:         uniast.Version,
+		Language:           string(lang),
+		RepoPath:           repoPath,
+		Excludes:           opts.Excludes,
+		OnlyDirs:           opts.OnlyDirs,
+		LoadExternalSymbol: opts.LoadExternalSymbol,
+		NeedTest:           opts.NeedTest,
+		NeedComment:        opts.NeedComment,
+	}
+	if err := WriteMessage(stdin, TypeParseRequest, req); err != nil {
+		return nil, fmt.Errorf("failed to write parse request: %w", err)
+	}
+	stdin.Close()
+
+	repo, err := read(NewReader(stdout), repoPath, lang, opts)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("external parser %s: %w", parser, err)
+	}
+	return repo, nil
+}
+
+// read reads the responses until done or an error
+func read(r *Reader, repoPath string, lang uniast.Language, opts Options) (*uniast.Repository, error) {
+	repo := uniast.NewRepository(filepath.Base(repoPath))
+	repo.Path = repoPath
+
+	var tracker *progress.Tracker
+	defer func() { tracker.Finish() }()
+	done := 0
+	for {
+		msg, err := r.ReadMessage()
+		if err == io.EOF {
+			return nil, fmt.Errorf("exited without done")
+		} else if err != nil {
+			return nil, err
+		}
+		switch msg.Type {
+		case TypeProgress:
+			var p Progress
+			if err := json.Unmarshal(msg.Payload, &p); err != nil {
+				return nil, fmt.Errorf("failed to decode progress: %w", err)
+			}
+			if tracker == nil {
+				tracker = progress.NewTracker(opts.Progress, progress.PhaseModule, p.Total)
+			}
+			for ; done < p.Done; done++ {
+				tracker.Add(p.Item)
+			}
+		case TypeModule:
+			var mod uniast.Module
+			if err := json.Unmarshal(msg.Payload, &mod); err != nil {
+				return nil, fmt.Errorf("failed to decode module: %w", err)
+			}
+			if mod.Name == "" {
+				return nil, fmt.Errorf("module without name")
+			}
+			if mod.Language == uniast.Unknown {
+				mod.Language = lang
+			}
+			if err := normalizeModule(&mod); err != nil {
+				return nil, fmt.Errorf("invalid module %s: %w", mod.Name, err)
+			}
+			// the later fragments win, as the parser may resend the updated symbols
+			frag := uniast.Repository{Modules: map[string]*uniast.Module{mod.Name: &mod}}
+			if err := uniast.Merge(&repo, &frag, uniast.MergeOptions{Conflict: uniast.MergeKeepSrc, DisableBuildGraph: true}); err != nil {
+				return nil, fmt.Errorf("failed to merge module %s: %w", mod.Name, err)
+			}
+		case TypeDone:
+			var d Done
+			if len(msg.Payload) > 0 {
+				if err := json.Unmarshal(msg.Payload, &d); err != nil {
+					return nil, fmt.Errorf("failed to decode done: %w", err)
+				}
+			}
+			if d.Name != "" {
+				repo.Name = d.Name
+			}
+			repo.Graph = map[string]*uniast.Node{}
+			return &repo, nil
+		case TypeError:
+			var e ErrorInfo
+			_ = json.Unmarshal(msg.Payload, &e)
+			return nil, fmt.Errorf("failed: %s", e.Message)
+		default:
+			log.Debug("unknown message type from external parser: %s\n", msg.Type)
+		}
+	}
+}
+
+// normalizeModule creates the maps decoded from `null` in the module from the parser,
+// which are assumed non-nil by the merging, and rejects the packages not keyed by their paths
+func normalizeModule(mod *uniast.Module) error {
+	if mod.Packages == nil {
+		mod.Packages = map[uniast.PkgPath]*uniast.Package{}
+	}
+	if mod.Files == nil {
+		mod.Files = map[string]*uniast.File{}
+	}
+	for path, pkg := range mod.Packages {
+		if pkg == nil {
+			return fmt.Errorf("package %s is null", path)
+		}
+		if pkg.PkgPath != path {
+			return fmt.Errorf("package %s is keyed by %s", pkg.PkgPath, path)
+		}
+		if pkg.Functions == nil {
+			pkg.Functions = map[string]*uniast.Function{}
+		}
+		if pkg.Types == nil {
+			pkg.Types = map[string]*uniast.Type{}
+		}
+		if pkg.Vars == nil {
+			pkg.Vars = map[string]*uniast.Var{}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package external
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/abcoder/lang/uniast"
+)
+
+// the test binary acts as the parser if fakeParserEnv is set
+const fakeParserEnv = "ABCODER_FAKE_PARSER"
+
+func TestMain(m *testing.M) {
+	if mode := os.Getenv(fakeParserEnv); mode != "" {
+		fakeParser(mode)
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func fakeParser(mode string) {
+	msg, err := NewReader(os.Stdin).ReadMessage()
+	if err != nil || msg.Type != TypeParseRequest {
+		os.Exit(1)
+	}
+	var req ParseRequest
+	_ = json.Unmarshal(msg.Payload, &req)
+	if mode == "error" {
+		_ = WriteMessage(os.Stdout, TypeError, ErrorInfo{Message: "bad repo " + req.RepoPath})
+		return
+	}
+	if mode == "null" || mode == "mismatch" {
+		// resend a fragment of the package, whose maps are null at first
+		path := "a"
+		if mode == "mismatch" {
+			path = "b"
+		}
+		_ = WriteMessage(os.Stdout, TypeModule, json.RawMessage(`{"Name":"a","Dir":".","Packages":{"`+path+`":{"PkgPath":"a","Functions":null,"Types":null,"Vars":null}}}`))
+		mod := uniast.NewModule("a", ".", uniast.Unknown)
+		p := uniast.NewPackage("a")
+		p.Functions["F"] = &uniast.Function{Identity: uniast.NewIdentity("a", "a", "F"), Content: "function F() {}"}
+		mod.Packages[p.PkgPath] = p
+		_ = WriteMessage(os.Stdout, TypeModule, mod)
+		_ = WriteMessage(os.Stdout, TypeDone, Done{})
+		return
+	}
+	_ = WriteMessage(os.Stdout, TypeProgress, Progress{Done: 1, Total: 2, Item: "a"})
+	for _, pkg := range []string{"a", "a/b"} {
+		mod := uniast.NewModule("a", ".", uniast.Unknown)
+		p := uniast.NewPackage(uniast.PkgPath(pkg))
+		p.Functions["F"] = &uniast.Function{Identity: uniast.NewIdentity("a", uniast.PkgPath(pkg), "F"), Content: "function F() {}"}
+		mod.Packages[p.PkgPath] = p
+		_ = WriteMessage(os.Stdout, TypeModule, mod)
+	}
+	_ = WriteMessage(os.Stdout, TypeProgress, Progress{Done: 2, Total: 2, Item: "a"})
+	_ = WriteMessage(os.Stdout, TypeDone, Done{Name: req.Language + "-repo"})
+}
+
+func TestParseRepo(t *testing.T) {
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+
+	t.Run("ok", func(t *testing.T) {
+		t.Setenv(fakeParserEnv, "ok")
+		repo, err := ParseRepo(context.Background(), "php", dir, Options{ParserPath: exe})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if repo.Name != "php-repo" || repo.Path != dir {
+			t.Errorf("repo = %s %s", repo.Name, repo.Path)
+		}
+		mod := repo.Modules["a"]
+		if mod == nil || mod.Language != "php" || len(mod.Packages) != 2 {
+			t.Fatalf("module = %+v", mod)
+		}
+		if f := repo.GetFunction(uniast.NewIdentity("a", "a/b", "F")); f == nil {
+			t.Errorf("function of the fragment is lost")
+		}
+	})
+
+	t.Run("null maps", func(t *testing.T) {
+		t.Setenv(fakeParserEnv, "null")
+		repo, err := ParseRepo(context.Background(), "php", dir, Options{ParserPath: exe})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f := repo.GetFunction(uniast.NewIdentity("a", "a", "F")); f == nil {
+			t.Errorf("function of the resent fragment is lost")
+		}
+	})
+
+	t.Run("mismatched package", func(t *testing.T) {
+		t.Setenv(fakeParserEnv, "mismatch")
+		_, err := ParseRepo(context.Background(), "php", dir, Options{ParserPath: exe})
+		if err == nil || !strings.Contains(err.Error(), "package a is keyed by b") {
+			t.Errorf("err = %v", err)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		t.Setenv(fakeParserEnv, "error")
+		_, err := ParseRepo(context.Background(), "php", dir, Options{ParserPath: exe})
+		if err == nil || !strings.Contains(err.Error(), "bad repo "+dir) {
+			t.Errorf("err = %v", err)
+		}
+	})
+}
+
+func TestLookup(t *testing.T) {
+	if _, ok := Lookup("no-such-language"); ok {
+		t.Error("found parser of no-such-language")
+	}
+	Register("fake", "/path/to/parser")
+	if path, ok := Lookup("fake"); !ok || path != "/path/to/parser" {
+		t.Errorf("Lookup(fake) = %s, %v", path, ok)
+	}
+}
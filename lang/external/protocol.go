@@ -0,0 +1,136 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package external
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// ProtocolVersion is the version of the protocol, sent in the parse request.
+// Parsers should fail on the versions they do not know.
+const ProtocolVersion = 1
+
+// message types.
+//
+// abcoder writes a parse_request line onto the stdin of the parser, and the parser writes
+// progress and module lines onto its stdout, ended by a done or an error line.
+const (
+	TypeParseRequest = "parse_request"
+	TypeProgress     = "progress"
+	TypeModule       = "module"
+	TypeDone         = "done"
+	TypeError        = "error"
+)
+
+// MaxMessageSize is the maximum size of a message line (256MB).
+// Large repositories should be sent module by module, or even package by package.
+const MaxMessageSize = 256 * 1024 * 1024
+
+// Message is a JSON object in a single line
+type Message struct {
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+type ParseRequest struct {
+	Protocol int `json:"protocol"`
+	// ASTVersion is the UniAST version abcoder expects
+	ASTVersion string `json:"ast_version"`
+	// Language is the language given to `abcoder parse`
+	Language string `json:"language"`
+	// RepoPath is the absolute path of the repository
+	RepoPath string `json:"repo_path"`
+	// path prefixes of the files to skip, relative to RepoPath if not absolute
+	Excludes []string `json:"excludes,omitempty"`
+	// OnlyDirs limits the parsing to the directories, relative to RepoPath
+	OnlyDirs []string `json:"only_dirs,omitempty"`
+	// LoadExternalSymbol asks to collect the symbols of the dependencies as external modules
+	LoadExternalSymbol bool `json:"load_external_symbol,omitempty"`
+	// NeedTest asks to collect the test files
+	NeedTest bool `json:"need_test,omitempty"`
+	// NeedComment asks to collect the comments of the symbols
+	NeedComment bool `json:"need_comment,omitempty"`
+}
+
+// Progress is the count of parsed modules
+type Progress struct {
+	Done  int    `json:"done"`
+	Total int    `json:"total"`
+	Item  string `json:"item,omitempty"`
+}
+
+// Done ends the parsing successfully
+type Done struct {
+	// Name is the repository id, the base name of the repository if empty
+	Name string `json:"id,omitempty"`
+}
+
+type ErrorInfo struct {
+	Message string `json:"message"`
+}
+
+// The payload of a module message is a uniast.Module, which can be a fragment:
+// the fragments of the same module are merged, so a parser can send a module package by package.
+
+// WriteMessage writes a message line with the payload encoded as JSON
+func WriteMessage(w io.Writer, typ string, payload any) error {
+	msg := Message{Type: typ}
+	if payload != nil {
+		bs, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("failed to marshal %s payload: %w", typ, err)
+		}
+		msg.Payload = bs
+	}
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+	_, err = w.Write(append(body, '\n'))
+	return err
+}
+
+// Reader reads the message lines
+type Reader struct {
+	sc *bufio.Scanner
+}
+
+func NewReader(r io.Reader) *Reader {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), MaxMessageSize)
+	return &Reader{sc: sc}
+}
+
+// ReadMessage reads the next message, blank lines are skipped. Returns io.EOF when the stream ends.
+func (r *Reader) ReadMessage() (*Message, error) {
+	for r.sc.Scan() {
+		line := r.sc.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var msg Message
+		if err := json.Unmarshal(line, &msg); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal JSON message: %w", err)
+		}
+		return &msg, nil
+	}
+	if err := r.sc.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read message: %w", err)
+	}
+	return nil, io.EOF
+}
@@ -0,0 +1,213 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"fmt"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/cloudwego/abcoder/lang/uniast"
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/callgraph/cha"
+	"golang.org/x/tools/go/callgraph/rta"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/ssa"
+)
+
+// call graph algorithms to resolve the dynamic calls, see Options.CallGraph
+const (
+	// CallGraphCHA is the Class Hierarchy Analysis, which takes all methods implementing the interface as targets
+	CallGraphCHA = "cha"
+	// CallGraphRTA is the Rapid Type Analysis, which only takes the types instantiated in the reachable codes.
+	// All functions of the parsed packages are the roots, since libraries have no main
+	CallGraphRTA = "rta"
+)
+
+// linkDynamicCalls builds the call graph of the packages by SSA,
+// and adds the targets of the calls through interface values and function values to Function.MethodCalls,
+// marked as Dependency.Dynamic
+func (p *GoParser) linkDynamicCalls(mod *Module, pkgs []*packages.Package) error {
+	var initial []*packages.Package
+	for _, pkg := range pkgs {
+		// test variants duplicate the product packages in SSA
+		if pkg.Types == nil || pkg.IllTyped || strings.Contains(pkg.ID, " [") || IsTestPackage(pkg.ID) {
+			continue
+		}
+		initial = append(initial, pkg)
+	}
+	if len(initial) == 0 {
+		return nil
+	}
+
+	prog, parsed := buildSSA(initial)
+
+	var cg *callgraph.Graph
+	switch p.opts.CallGraph {
+	case CallGraphCHA:
+		cg = cha.CallGraph(prog)
+	case CallGraphRTA:
+		var roots []*ssa.Function
+		for pkg := range parsed {
+			for _, m := range pkg.Members {
+				if fn, ok := m.(*ssa.Function); ok {
+					roots = append(roots, fn)
+				}
+			}
+			// methods are not members of the package
+			for _, m := range pkg.Members {
+				if t, ok := m.(*ssa.Type); ok {
+					for _, typ := range []types.Type{t.Type(), types.NewPointer(t.Type())} {
+						mset := prog.MethodSets.MethodSet(typ)
+						for i := 0; i < mset.Len(); i++ {
+							if fn := prog.MethodValue(mset.At(i)); fn != nil {
+								roots = append(roots, fn)
+							}
+						}
+					}
+				}
+			}
+		}
+		cg = rta.Analyze(roots, true).CallGraph
+	default:
+		return fmt.Errorf("unsupported call graph algorithm: %s", p.opts.CallGraph)
+	}
+
+	for fn, node := range cg.Nodes {
+		if fn == nil || !parsed[fn.Pkg] {
+			continue
+		}
+		caller := p.dynamicCaller(mod, fn)
+		if caller == nil {
+			continue
+		}
+		for _, edge := range node.Out {
+			if edge.Site == nil {
+				continue
+			}
+			common := edge.Site.Common()
+			if !common.IsInvoke() && common.StaticCallee() != nil {
+				// static calls are collected from the AST
+				continue
+			}
+			id, ok := p.dynamicCallee(mod, edge.Callee.Func)
+			if !ok || caller.Identity == id {
+				continue
+			}
+			pos := prog.Fset.Position(edge.Site.Pos())
+			rel, _ := filepath.Rel(p.homePageDir, pos.Filename)
+			dep := NewDependency(id, FileLine{File: rel, Line: pos.Line, StartOffset: pos.Offset})
+			dep.Dynamic = true
+			caller.MethodCalls = InsertDependency(caller.MethodCalls, dep)
+		}
+	}
+	return nil
+}
+
+// buildSSA builds the SSA program of the packages. Their dependencies are created from types only,
+// since they are not loaded with syntax (nor even types without packages.NeedDeps)
+func buildSSA(pkgs []*packages.Package) (*ssa.Program, map[*ssa.Package]bool) {
+	prog := ssa.NewProgram(pkgs[0].Fset, ssa.InstantiateGenerics)
+	parsed := map[*ssa.Package]bool{}
+	created := map[*types.Package]bool{}
+	var createDeps func(tpkg *types.Package)
+	createDeps = func(tpkg *types.Package) {
+		for _, imp := range tpkg.Imports() {
+			if created[imp] {
+				continue
+			}
+			created[imp] = true
+			prog.CreatePackage(imp, nil, nil, true)
+			createDeps(imp)
+		}
+	}
+	for _, pkg := range pkgs {
+		created[pkg.Types] = true
+	}
+	for _, pkg := range pkgs {
+		parsed[prog.CreatePackage(pkg.Types, pkg.Syntax, pkg.TypesInfo, true)] = true
+	}
+	for _, pkg := range pkgs {
+		createDeps(pkg.Types)
+	}
+	for pkg := range parsed {
+		pkg.Build()
+	}
+	return prog, parsed
+}
+
+// dynamicCaller returns the parsed function of the SSA function, closures are attributed to the enclosing function
+func (p *GoParser) dynamicCaller(mod *Module, fn *ssa.Function) *Function {
+	for fn.Parent() != nil {
+		fn = fn.Parent()
+	}
+	obj, ok := fn.Object().(*types.Func)
+	if !ok || fn.Synthetic != "" {
+		return nil
+	}
+	pkg := mod.Packages[obj.Pkg().Path()]
+	if pkg == nil {
+		return nil
+	}
+	return pkg.Functions[funcName(obj)]
+}
+
+// dynamicCallee returns the identity of the called function, false for the closures and std functions
+func (p *GoParser) dynamicCallee(mod *Module, fn *ssa.Function) (Identity, bool) {
+	obj, ok := fn.Object().(*types.Func)
+	if !ok {
+		return Identity{}, false
+	}
+	return funcIdentity(mod, obj)
+}
+
+// funcIdentity returns the identity of the function, false for std functions or those whose module is unknown
+func funcIdentity(mod *Module, obj *types.Func) (Identity, bool) {
+	if obj.Pkg() == nil {
+		return Identity{}, false
+	}
+	pkgPath := obj.Pkg().Path()
+	if isSysPkg(pkgPath) {
+		return Identity{}, false
+	}
+	modPath := ""
+	if _, ok := mod.Packages[pkgPath]; ok || strings.HasPrefix(pkgPath, mod.Name) {
+		modPath = mod.Name
+	} else if _, path := matchMod(pkgPath, mod.Dependencies); path != "" {
+		modPath = path
+	} else {
+		fmt.Fprintf(os.Stderr, "not found mod of callee %s\n", obj.FullName())
+		return Identity{}, false
+	}
+	return NewIdentity(modPath, pkgPath, funcName(obj)), true
+}
+
+// funcName returns the name of the function as the parser names it, like `Func` or `Type.Method`
+func funcName(obj *types.Func) string {
+	sig, ok := obj.Type().(*types.Signature)
+	if !ok || sig.Recv() == nil {
+		return obj.Name()
+	}
+	recv := sig.Recv().Type()
+	if ptr, ok := recv.(*types.Pointer); ok {
+		recv = ptr.Elem()
+	}
+	if named, ok := recv.(*types.Named); ok {
+		return named.Origin().Obj().Name() + "." + obj.Name()
+	}
+	return obj.Name()
+}
@@ -0,0 +1,91 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/cloudwego/abcoder/lang/uniast"
+)
+
+func Test_goParser_CallGraph(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module a.b/cg\n\ngo 1.21\n",
+		"shape/s.go": "package shape\n\ntype Shape interface{ Area() int }\n\n" +
+			"type Square struct{ N int }\n\nfunc (s Square) Area() int { return s.N * s.N }\n\n" +
+			"type Rect struct{ W, H int }\n\nfunc (r *Rect) Area() int { return r.W * r.H }\n",
+		"use/u.go": "package use\n\nimport \"a.b/cg/shape\"\n\n" +
+			"func Total(ss []shape.Shape) (n int) {\n\tfor _, s := range ss {\n\t\tn += s.Area()\n\t}\n\treturn\n}\n\n" +
+			"func Make() []shape.Shape { return []shape.Shape{shape.Square{N: 1}} }\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	square := NewIdentity("a.b/cg", "a.b/cg/shape", "Square.Area")
+	rect := NewIdentity("a.b/cg", "a.b/cg/shape", "Rect.Area")
+
+	for algo, want := range map[string][]Identity{
+		CallGraphCHA: {square, rect},
+		// Rect is never instantiated
+		CallGraphRTA: {square},
+	} {
+		t.Run(algo, func(t *testing.T) {
+			repo, err := NewParser(dir, dir, Options{CallGraph: algo}).ParseRepo()
+			if err != nil {
+				t.Fatal(err)
+			}
+			total := repo.GetFunction(NewIdentity("a.b/cg", "a.b/cg/use", "Total"))
+			if total == nil {
+				t.Fatal("function Total is not parsed")
+			}
+			got := map[Identity]bool{}
+			for _, dep := range total.MethodCalls {
+				if dep.Dynamic {
+					got[dep.Identity] = true
+					if dep.File != "use/u.go" || dep.Line == 0 {
+						t.Errorf("file line of %v = %+v", dep.Identity, dep.FileLine)
+					}
+				}
+			}
+			if len(got) != len(want) {
+				t.Errorf("dynamic calls = %v, want %v", got, want)
+			}
+			for _, id := range want {
+				if !got[id] {
+					t.Errorf("missing dynamic call %v", id)
+				}
+			}
+		})
+	}
+
+	repo, err := NewParser(dir, dir, Options{}).ParseRepo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, dep := range repo.GetFunction(NewIdentity("a.b/cg", "a.b/cg/use", "Total")).MethodCalls {
+		if dep.Dynamic {
+			t.Errorf("dynamic call %v without call graph", dep.Identity)
+		}
+	}
+}
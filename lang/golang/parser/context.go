@@ -0,0 +1,96 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"go/ast"
+	"go/types"
+
+	. "github.com/cloudwego/abcoder/lang/uniast"
+)
+
+// isContextType tells if t is context.Context
+func isContextType(t types.Type) bool {
+	named, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Obj()
+	return obj.Pkg() != nil && obj.Pkg().Path() == "context" && obj.Name() == "Context"
+}
+
+// takesContext tells if the function takes a context.Context parameter
+func (ctx *fileContext) takesContext(ft *ast.FuncType) bool {
+	if ft.Params == nil {
+		return false
+	}
+	for _, field := range ft.Params.List {
+		if t := ctx.pkgTypeInfo.TypeOf(field.Type); t != nil && isContextType(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// collectFreshContext records the callee of the call if it is passed a new root context,
+// like `Do(context.Background())`, see Dependency.FreshContext
+func (ctx *fileContext) collectFreshContext(call *ast.CallExpr, callee *ast.Ident, collect *collectInfos) {
+	var fresh string
+	for _, arg := range call.Args {
+		if fresh = ctx.rootContext(arg); fresh != "" {
+			break
+		}
+	}
+	if fresh == "" {
+		return
+	}
+	fn, ok := ctx.pkgTypeInfo.Uses[callee].(*types.Func)
+	if !ok || fn.Pkg() == nil {
+		return
+	}
+	mod, err := ctx.GetMod(fn.Pkg().Path())
+	if err != nil {
+		return
+	}
+	if collect.freshContexts == nil {
+		collect.freshContexts = map[Identity]string{}
+	}
+	collect.freshContexts[NewIdentity(mod, fn.Pkg().Path(), funcName(fn))] = fresh
+}
+
+// rootContext returns `context.Background` or `context.TODO` if the expression calls it, empty otherwise
+func (ctx *fileContext) rootContext(expr ast.Expr) string {
+	call, ok := ast.Unparen(expr).(*ast.CallExpr)
+	if !ok {
+		return ""
+	}
+	var ident *ast.Ident
+	switch fun := ast.Unparen(call.Fun).(type) {
+	case *ast.Ident:
+		ident = fun
+	case *ast.SelectorExpr:
+		ident = fun.Sel
+	default:
+		return ""
+	}
+	fn, ok := ctx.pkgTypeInfo.Uses[ident].(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != "context" {
+		return ""
+	}
+	if name := fn.Name(); name == "Background" || name == "TODO" {
+		return "context." + name
+	}
+	return ""
+}
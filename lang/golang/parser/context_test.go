@@ -0,0 +1,93 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/cloudwego/abcoder/lang/uniast"
+)
+
+func Test_goParser_Context(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module a.b/svc\n\ngo 1.21\n",
+		"svc.go": `package svc
+
+import "context"
+
+type Store struct{}
+
+func (s *Store) Get(ctx context.Context, key string) string { return key }
+
+func Handle(ctx context.Context, s *Store) string {
+	go s.Get(context.Background(), "async")
+	return lookup(s)
+}
+
+func lookup(s *Store) string {
+	return s.Get(context.TODO(), "k")
+}
+
+func Pass(ctx context.Context, s *Store) string { return s.Get(ctx, "k") }
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("GOFLAGS", "")
+
+	repo, err := NewParser(dir, dir, Options{}).ParseRepo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg := repo.Modules["a.b/svc"].Packages["a.b/svc"]
+	if pkg == nil {
+		t.Fatal("package a.b/svc not found")
+	}
+	for name, want := range map[string]bool{"Store.Get": true, "Handle": true, "lookup": false, "Pass": true} {
+		if fn := pkg.Functions[name]; fn == nil || fn.TakesContext != want {
+			t.Errorf("TakesContext of %s = %v, want %v", name, fn != nil && fn.TakesContext, want)
+		}
+	}
+	fresh := func(caller string) string {
+		for _, dep := range pkg.Functions[caller].MethodCalls {
+			if dep.Name == "Store.Get" {
+				return dep.FreshContext
+			}
+		}
+		return "<none>"
+	}
+	if got := fresh("Handle"); got != "context.Background" {
+		t.Errorf("FreshContext of Handle = %q", got)
+	}
+	if got := fresh("Pass"); got != "" {
+		t.Errorf("FreshContext of Pass = %q", got)
+	}
+
+	issues := repo.ContextIssues(ContextOptions{})
+	if len(issues) != 2 ||
+		issues[0].Kind != ContextFresh || issues[0].Caller.Name != "Handle" || issues[0].Context != "context.Background" ||
+		issues[1].Kind != ContextMissing || issues[1].Caller.Name != "lookup" || issues[1].Context != "context.TODO" {
+		t.Errorf("ContextIssues() = %+v", issues)
+	}
+	if issues := repo.ContextIssues(ContextOptions{Entries: []Identity{NewIdentity("a.b/svc", "a.b/svc", "Pass")}}); len(issues) != 0 {
+		t.Errorf("ContextIssues(Pass) = %+v", issues)
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"go/types"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/cloudwego/abcoder/lang/uniast"
@@ -44,6 +45,9 @@ type fileContext struct {
 	pkgTypeInfo    *types.Info
 	deps           map[string]*packages.Package
 	collectComment bool
+	otherFiles     []string          // non-Go files of the package, like assembly
+	linknames      map[string]string // `//go:linkname` directives of the file
+	stdMod         string            // the module of the std packages, see GoParser.stdModPath
 }
 
 func isExternalID(id *Identity, curmod string) bool {
@@ -62,7 +66,20 @@ func newModule(mod string, dir string) (ret *Module) {
 }
 
 func (p *GoParser) referCodes(ctx *fileContext, id *Identity, depth int) (err error) {
-	if depth == 0 || id.PkgPath == "" || !isExternalID(id, ctx.module.Name) {
+	if id.PkgPath == "" {
+		return nil
+	}
+	if p.opts.StdModPath != "" && id.ModPath == p.opts.StdModPath {
+		// the std nodes are in the shared std AST
+		return nil
+	}
+	internal := !isExternalID(id, ctx.module.Name)
+	if internal {
+		// partial parsing still collects the direct dependencies out of the selected packages
+		if !p.opts.partial() || p.selected(id.PkgPath) {
+			return nil
+		}
+	} else if depth == 0 {
 		return nil
 	}
 	// var kg bool
@@ -83,6 +100,10 @@ func (p *GoParser) referCodes(ctx *fileContext, id *Identity, depth int) (err er
 	}
 	// fmt.Printf("refer code for %v\n", id.Full())
 	pkg := ctx.deps[id.PkgPath]
+	if !internal && p.fetcher != nil && (pkg == nil || len(pkg.GoFiles) == 0) {
+		// the module is missing in the local caches, parse it from the fetched sources
+		return p.referFetchedCodes(ctx, mod, id)
+	}
 	if pkg == nil {
 		return fmt.Errorf("cannot find package %s", id.PkgPath)
 	}
@@ -100,21 +121,35 @@ func (p *GoParser) referCodes(ctx *fileContext, id *Identity, depth int) (err er
 		if err != nil {
 			return err
 		}
-		impts, e := p.parseImports(pkg.Fset, bs, mod, file.Imports)
+		ids, e := p.referFile(file, pkg.Fset, bs, mod, id, pkg.ID)
 		if e != nil {
 			err = e
 			continue
 		}
-		// println("search file", fpath)
-		_, e = p.searchOnFile(file, pkg.Fset, bs, id.ModPath, pkg.ID, impts, id.Name)
-		if e != nil {
-			err = e
-			continue
+		if internal && len(ids) > 0 {
+			// keep the files of the collected nodes, thus the partial AST is consistent
+			rel, _ := filepath.Rel(p.homePageDir, fpath)
+			if mod.Files[rel] == nil {
+				f := NewFile(rel)
+				f.Package = pkg.ID
+				f.IsTest = isTestFile(rel)
+				mod.Files[rel] = f
+			}
 		}
 	}
 	return
 }
 
+// referFile collects the node of id declared in the file of package pkgID
+func (p *GoParser) referFile(file *ast.File, fset *token.FileSet, bs []byte, mod *Module, id *Identity, pkgID string) ([]Identity, error) {
+	impts, err := p.parseImports(fset, bs, mod, file.Imports)
+	if err != nil {
+		return nil, err
+	}
+	// println("search file", fpath)
+	return p.searchOnFile(file, fset, bs, id.ModPath, pkgID, impts, id.Name)
+}
+
 func (p *GoParser) getFileBytes(path string) []byte {
 	if bs, ok := p.files[path]; ok {
 		return bs
@@ -148,7 +183,10 @@ func (ctx *fileContext) GetMod(impt string) (string, error) {
 		return ctx.module.Name, nil
 	}
 	if isSysPkg(impt) {
-		return "", errSysImport
+		if ctx.stdMod == "" {
+			return "", errSysImport
+		}
+		return ctx.stdMod, nil
 	}
 
 	// fileContext 中的 import 信息只有**当前文件的引用路径**，但是存在一种场景就是实际调用的节点在另外的一个Package，导致漏解析
@@ -179,7 +217,7 @@ func (ctx *fileContext) FileLine(node ast.Node) FileLine {
 	pos := ctx.fset.Position((node).Pos())
 	rel, _ := filepath.Rel(ctx.repoDir, pos.Filename)
 	end := ctx.fset.Position((node).End())
-	ret := FileLine{File: rel, Line: pos.Line, StartOffset: pos.Offset, EndOffset: end.Offset}
+	ret := FileLine{File: rel, Line: pos.Line, EndLine: end.Line, StartOffset: pos.Offset, EndOffset: end.Offset}
 	if _, ok := node.(*ast.TypeSpec); ok {
 		// NOTICE: type spec is not the start of the type definition
 		// so we need to adjust the offset = len("type ")
@@ -374,7 +412,7 @@ func (p *GoParser) mockTypes(typ ast.Expr, m map[string]Identity, file []byte, f
 		// may not be within the same package, thus set receiver too
 		if n := p.repo.GetType(id); n == nil {
 			st := p.newType(id.ModPath, id.PkgPath, id.Name)
-			st.Exported = isUpperCase(id.Name[0])
+			st.SetVisibility(visibility(id.Name))
 			st.File = fpath
 			st.Line = fset.Position(typ.Pos()).Line - 1 // not real
 			// FIXME: cannot get specific entity's definition unless load the whole package
@@ -449,7 +487,7 @@ func getTypeName(fset *token.FileSet, file []byte, typ ast.Expr) (ret []Identity
 	return
 }
 
-func (p *GoParser) collectTypes(ctx *fileContext, typ ast.Expr, st *Type, inlined bool) {
+func (p *GoParser) collectTypes(ctx *fileContext, typ ast.Expr, st *Type, inlined bool) typeInfo {
 	ti := ctx.GetTypeInfo(typ)
 	if !ti.IsStdOrBuiltin && ti.Id.ModPath != "" {
 		dep := NewDependency(ti.Id, ctx.FileLine(typ))
@@ -468,6 +506,59 @@ func (p *GoParser) collectTypes(ctx *fileContext, typ ast.Expr, st *Type, inline
 		}
 		st.SubStruct = InsertDependency(st.SubStruct, NewDependency(dep, ctx.FileLine(typ)))
 	}
+	return ti
+}
+
+// newFields converts a struct field declaration into fields, one for each name
+func (ctx *fileContext) newFields(decl *ast.Field, typeId *Identity) []Field {
+	f := Field{
+		Type:   string(ctx.GetRawContent(decl.Type)),
+		TypeId: typeId,
+		Line:   ctx.FileLine(decl).Line,
+	}
+	if decl.Tag != nil {
+		if tag, err := strconv.Unquote(decl.Tag.Value); err == nil {
+			f.Tag = tag
+		}
+	}
+	if ctx.collectComment {
+		var docs []string
+		for _, c := range []*ast.CommentGroup{decl.Doc, decl.Comment} {
+			if txt := strings.TrimSpace(c.Text()); txt != "" {
+				docs = append(docs, txt)
+			}
+		}
+		f.Doc = strings.Join(docs, "\n")
+	}
+	if len(decl.Names) == 0 {
+		f.Name = embeddedFieldName(decl.Type)
+		f.Embedded = true
+		return []Field{f}
+	}
+	ret := make([]Field, 0, len(decl.Names))
+	for _, n := range decl.Names {
+		f.Name = n.Name
+		ret = append(ret, f)
+	}
+	return ret
+}
+
+// embeddedFieldName returns the implicit name of an embedded field, like `Foo` of `*pkg.Foo[T]`
+func embeddedFieldName(typ ast.Expr) string {
+	switch t := typ.(type) {
+	case *ast.StarExpr:
+		return embeddedFieldName(t.X)
+	case *ast.SelectorExpr:
+		return t.Sel.Name
+	case *ast.IndexExpr:
+		return embeddedFieldName(t.X)
+	case *ast.IndexListExpr:
+		return embeddedFieldName(t.X)
+	case *ast.Ident:
+		return t.Name
+	default:
+		return ""
+	}
 }
 
 // get type id and tells if it is std or builtin
@@ -483,11 +574,11 @@ func (ctx *fileContext) getTypeinfo(typ types.Type) (ti typeInfo) {
 		if tp := tobj.Pkg(); tp != nil {
 			mod, err := ctx.GetMod(tp.Path())
 			if err == errSysImport {
-				ti.Id = Identity{"", tp.Path(), tobj.Name()}
+				ti.Id = Identity{PkgPath: tp.Path(), Name: tobj.Name()}
 				ti.IsStdOrBuiltin = true
 			} else if err != nil || mod == "" {
 				// unloaded type, mark it
-				ti.Id = Identity{"", tp.Path(), tobj.Name()}
+				ti.Id = Identity{PkgPath: tp.Path(), Name: tobj.Name()}
 				ti.IsStdOrBuiltin = false
 			} else {
 				ti.Id = NewIdentity(mod, tp.Path(), tobj.Name())
@@ -499,13 +590,13 @@ func (ctx *fileContext) getTypeinfo(typ types.Type) (ti typeInfo) {
 				ti.IsStdOrBuiltin = true
 			} else {
 				// unloaded type, mark it
-				ti.Id = Identity{"", ctx.pkgPath, tobj.Name()}
+				ti.Id = Identity{PkgPath: ctx.pkgPath, Name: tobj.Name()}
 				ti.IsStdOrBuiltin = false
 			}
 		}
 	} else {
 		// Notice: for Composite type like map, slice, regard it as builtin
-		ti.Id = Identity{"", "", typ.String()}
+		ti.Id = Identity{Name: typ.String()}
 		ti.IsStdOrBuiltin = true
 	}
 	// collect sub Named type here
@@ -0,0 +1,116 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	. "github.com/cloudwego/abcoder/lang/uniast"
+)
+
+// embedPatterns splits the patterns of the `//go:embed` directives, which may be quoted
+func embedPatterns(anns []Annotation) []string {
+	var ret []string
+	for _, ann := range anns {
+		if ann.Name != "go:embed" {
+			continue
+		}
+		s := strings.TrimSpace(ann.Args)
+		for s != "" {
+			var pattern string
+			if s[0] == '"' || s[0] == '`' {
+				end := strings.IndexByte(s[1:], s[0])
+				if end < 0 {
+					break
+				}
+				pattern, _ = strconv.Unquote(s[:end+2])
+				s = s[end+2:]
+			} else {
+				pattern, s, _ = strings.Cut(s, " ")
+			}
+			if pattern != "" {
+				ret = append(ret, pattern)
+			}
+			s = strings.TrimLeft(s, " \t")
+		}
+	}
+	return ret
+}
+
+// embeddedAssets resolves the files embedded by the `//go:embed` directives of a var declared in the file,
+// the patterns are matched like the go command: relative to the package dir, and the files under a matched dir
+// are embedded recursively, except the ones starting with `.` or `_` unless the pattern has the `all:` prefix
+func (ctx *fileContext) embeddedAssets(anns []Annotation) []Asset {
+	patterns := embedPatterns(anns)
+	if len(patterns) == 0 {
+		return nil
+	}
+	dir := filepath.Dir(ctx.filePath)
+	seen := map[string]bool{}
+	var ret []Asset
+	add := func(pattern, path string) {
+		rel, err := filepath.Rel(ctx.repoDir, path)
+		// the assets of the dependencies out of the repo are not tracked
+		if err != nil || seen[rel] || strings.HasPrefix(rel, "..") {
+			return
+		}
+		seen[rel] = true
+		ret = append(ret, Asset{File: filepath.ToSlash(rel), Pattern: pattern})
+	}
+	for _, pattern := range patterns {
+		glob, all := strings.CutPrefix(pattern, "all:")
+		matches, _ := filepath.Glob(filepath.Join(dir, filepath.FromSlash(glob)))
+		for _, m := range matches {
+			info, err := os.Stat(m)
+			if err != nil {
+				continue
+			}
+			if !info.IsDir() {
+				add(pattern, m)
+				continue
+			}
+			_ = filepath.WalkDir(m, func(path string, d fs.DirEntry, err error) error {
+				if err != nil || path == m {
+					return nil
+				}
+				name := d.Name()
+				if !all && (name[0] == '.' || name[0] == '_') {
+					if d.IsDir() {
+						return filepath.SkipDir
+					}
+					return nil
+				}
+				if d.IsDir() {
+					// a nested module is not a part of the package
+					if _, err := os.Stat(filepath.Join(path, "go.mod")); err == nil {
+						return filepath.SkipDir
+					}
+					return nil
+				}
+				if d.Type().IsRegular() {
+					add(pattern, path)
+				}
+				return nil
+			})
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].File < ret[j].File })
+	return ret
+}
@@ -0,0 +1,90 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	. "github.com/cloudwego/abcoder/lang/uniast"
+)
+
+func Test_embedPatterns(t *testing.T) {
+	anns := []Annotation{
+		{Name: "go:noinline"},
+		{Name: "go:embed", Args: `static/*.html  "my file.txt"`},
+		{Name: "go:embed", Args: "all:tpl `b c`"},
+	}
+	want := []string{"static/*.html", "my file.txt", "all:tpl", "b c"}
+	if got := embedPatterns(anns); !reflect.DeepEqual(got, want) {
+		t.Errorf("embedPatterns() = %q, want %q", got, want)
+	}
+}
+
+func Test_goParser_Embed(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":                  "module a.b/web\n\ngo 1.21\n",
+		"web/static/index.html":   "<html></html>",
+		"web/static/app.js":       "",
+		"web/static/.hidden":      "",
+		"web/static/img/logo.png": "",
+		"web/tpl/_partial.tmpl":   "",
+		"web/tpl/page.tmpl":       "",
+		"web/web.go": "package web\n\nimport \"embed\"\n\n" +
+			"//go:embed static\nvar Static embed.FS\n\n" +
+			"//go:embed all:tpl static/*.html\nvar Tpl embed.FS\n\n" +
+			"var plain = 1\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	repo, err := NewParser(dir, dir, Options{}).ParseRepo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := func(name string) Identity { return NewIdentity("a.b/web", "a.b/web/web", name) }
+
+	if got, want := repo.GetVar(id("Static")).Assets, []Asset{
+		{File: "web/static/app.js", Pattern: "static"},
+		{File: "web/static/img/logo.png", Pattern: "static"},
+		{File: "web/static/index.html", Pattern: "static"},
+	}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Static.Assets = %+v, want %+v", got, want)
+	}
+	if got, want := repo.GetVar(id("Tpl")).Assets, []Asset{
+		{File: "web/static/index.html", Pattern: "static/*.html"},
+		{File: "web/tpl/_partial.tmpl", Pattern: "all:tpl"},
+		{File: "web/tpl/page.tmpl", Pattern: "all:tpl"},
+	}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Tpl.Assets = %+v, want %+v", got, want)
+	}
+	if got := repo.GetVar(id("plain")).Assets; got != nil {
+		t.Errorf("plain.Assets = %+v", got)
+	}
+
+	uses := repo.Assets("web/static/index")
+	if len(uses) != 1 || !reflect.DeepEqual(uses[0].Vars, []Identity{id("Static"), id("Tpl")}) {
+		t.Errorf("Assets() = %+v", uses)
+	}
+}
@@ -0,0 +1,221 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strconv"
+
+	. "github.com/cloudwego/abcoder/lang/uniast"
+)
+
+var errorInterface = types.Universe.Lookup("error").Type()
+
+// errorFunc is a function creating or wrapping errors, see errorFuncs
+type errorFunc struct {
+	kind ErrorKind
+	// msg is the index of the argument of the message or the format, -1 if none
+	msg int
+	// wrapped is the index of the argument wrapped, -1 if none or all the arguments are wrapped (errors.Join)
+	wrapped int
+}
+
+// errorFuncs are the functions creating or wrapping errors, keyed by the package paths and the names.
+// fmt.Errorf wraps the arguments of the %w verbs
+var errorFuncs = map[string]errorFunc{
+	"errors.New":                         {ErrorNew, 0, -1},
+	"errors.Join":                        {ErrorWrap, -1, -1},
+	"fmt.Errorf":                         {ErrorNew, 0, -1},
+	"github.com/pkg/errors.New":          {ErrorNew, 0, -1},
+	"github.com/pkg/errors.Errorf":       {ErrorNew, 0, -1},
+	"github.com/pkg/errors.Wrap":         {ErrorWrap, 1, 0},
+	"github.com/pkg/errors.Wrapf":        {ErrorWrap, 1, 0},
+	"github.com/pkg/errors.WithMessage":  {ErrorWrap, 1, 0},
+	"github.com/pkg/errors.WithMessagef": {ErrorWrap, 1, 0},
+	"github.com/pkg/errors.WithStack":    {ErrorWrap, -1, 0},
+}
+
+// isErrorType tells if t implements error, by the value or the pointer
+func isErrorType(t types.Type) bool {
+	if t == nil {
+		return false
+	}
+	if types.Implements(t, errorInterface.Underlying().(*types.Interface)) {
+		return true
+	}
+	_, isPtr := t.(*types.Pointer)
+	return !isPtr && !types.IsInterface(t) && types.Implements(types.NewPointer(t), errorInterface.Underlying().(*types.Interface))
+}
+
+// errorTypeId returns the identity of the named error type (or the pointer to it), nil for the interface error
+func (ctx *fileContext) errorTypeId(t types.Type) *Identity {
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	named, ok := t.(*types.Named)
+	if !ok || named.Obj().Pkg() == nil {
+		return nil
+	}
+	mod, err := ctx.GetMod(named.Obj().Pkg().Path())
+	if err != nil && err != errSysImport {
+		return nil
+	}
+	id := NewIdentity(mod, named.Obj().Pkg().Path(), named.Obj().Name())
+	return &id
+}
+
+// errorFlow returns the error flow of the function by its signature and the sites collected from its body,
+// nil if it neither returns nor originates any error
+func (ctx *fileContext) errorFlow(ft *ast.FuncType, sites []ErrorSite) *Errors {
+	var ret Errors
+	if ft.Results != nil && len(ft.Results.List) > 0 {
+		last := ft.Results.List[len(ft.Results.List)-1].Type
+		if t := ctx.pkgTypeInfo.TypeOf(last); isErrorType(t) {
+			ret.Returns = true
+			if !types.Identical(t, errorInterface) {
+				ret.Type = ctx.errorTypeId(t)
+			}
+		}
+	}
+	if !ret.Returns && len(sites) == 0 {
+		return nil
+	}
+	ret.Sites = sites
+	return &ret
+}
+
+// collectErrorCall records the call creating or wrapping an error, like `fmt.Errorf("...: %w", err)`, see errorFuncs
+func (ctx *fileContext) collectErrorCall(call *ast.CallExpr, callee *ast.Ident, collect *collectInfos) {
+	fn, ok := ctx.pkgTypeInfo.Uses[callee].(*types.Func)
+	if !ok || fn.Pkg() == nil {
+		return
+	}
+	ef, ok := errorFuncs[fn.Pkg().Path()+"."+fn.Name()]
+	if !ok {
+		return
+	}
+	site := ErrorSite{Kind: ef.kind, FileLine: ctx.FileLine(call)}
+	if ef.msg >= 0 && ef.msg < len(call.Args) {
+		site.Message = stringLit(call.Args[ef.msg])
+	}
+	var wrapped []ast.Expr
+	switch {
+	case ef.wrapped >= 0 && ef.wrapped < len(call.Args):
+		wrapped = call.Args[ef.wrapped : ef.wrapped+1]
+	case fn.Name() == "Join":
+		wrapped = call.Args
+	case fn.Name() == "Errorf" && fn.Pkg().Path() == "fmt":
+		for _, i := range wrapVerbs(site.Message) {
+			if i+1 < len(call.Args) {
+				site.Kind = ErrorWrap
+				wrapped = append(wrapped, call.Args[i+1])
+			}
+		}
+	}
+	for _, arg := range wrapped {
+		if site.Error = ctx.sentinel(arg); site.Error != nil {
+			break
+		}
+	}
+	collect.errorSites = append(collect.errorSites, site)
+}
+
+// collectErrorLit records the composite literal of an error type, like `&PathError{...}`
+func (ctx *fileContext) collectErrorLit(lit *ast.CompositeLit, collect *collectInfos) {
+	t := ctx.pkgTypeInfo.TypeOf(lit)
+	if _, ok := t.(*types.Named); !ok || !isErrorType(t) {
+		return
+	}
+	if id := ctx.errorTypeId(t); id != nil {
+		collect.errorSites = append(collect.errorSites, ErrorSite{Kind: ErrorTyped, Error: id, FileLine: ctx.FileLine(lit)})
+	}
+}
+
+// collectSentinels records the sentinel error vars returned, like `return nil, io.EOF`
+func (ctx *fileContext) collectSentinels(ret *ast.ReturnStmt, collect *collectInfos) {
+	for _, res := range ret.Results {
+		if id := ctx.sentinel(res); id != nil {
+			collect.errorSites = append(collect.errorSites, ErrorSite{Kind: ErrorSentinel, Error: id, FileLine: ctx.FileLine(res)})
+		}
+	}
+}
+
+// sentinel returns the identity of the package-level error var referred by the expression, nil otherwise.
+// The std ones have no module unless the std lib is linked, like io.EOF
+func (ctx *fileContext) sentinel(expr ast.Expr) *Identity {
+	var ident *ast.Ident
+	switch e := ast.Unparen(expr).(type) {
+	case *ast.Ident:
+		ident = e
+	case *ast.SelectorExpr:
+		ident = e.Sel
+	default:
+		return nil
+	}
+	v, ok := ctx.pkgTypeInfo.Uses[ident].(*types.Var)
+	if !ok || v.Pkg() == nil || !isPkgScope(v.Parent()) || !isErrorType(v.Type()) {
+		return nil
+	}
+	mod, err := ctx.GetMod(v.Pkg().Path())
+	if err != nil && err != errSysImport {
+		return nil
+	}
+	id := NewIdentity(mod, v.Pkg().Path(), v.Name())
+	return &id
+}
+
+// stringLit returns the value of the string literal, empty if it is not
+func stringLit(expr ast.Expr) string {
+	lit, ok := ast.Unparen(expr).(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return ""
+	}
+	s, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return ""
+	}
+	return s
+}
+
+// wrapVerbs returns the indexes of the operands of the %w verbs in the format, like [1] for `%s: %w`.
+// Explicit argument indexes like %[1]w are not supported
+func wrapVerbs(format string) []int {
+	var ret []int
+	n := 0
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+		i++
+		// flags, width and precision
+		for i < len(format) && (format[i] == '+' || format[i] == '-' || format[i] == '#' || format[i] == ' ' ||
+			format[i] == '0' || format[i] == '.' || format[i] == '*' || (format[i] >= '1' && format[i] <= '9')) {
+			if format[i] == '*' {
+				n++
+			}
+			i++
+		}
+		if i >= len(format) || format[i] == '%' {
+			continue
+		}
+		if format[i] == 'w' {
+			ret = append(ret, n)
+		}
+		n++
+	}
+	return ret
+}
@@ -0,0 +1,133 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	. "github.com/cloudwego/abcoder/lang/uniast"
+)
+
+func Test_goParser_Errors(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module a.b/store\n\ngo 1.21\n",
+		"store.go": `package store
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+var ErrNotFound = errors.New("not found")
+
+type KeyError struct{ Key string }
+
+func (e *KeyError) Error() string { return "bad key " + e.Key }
+
+func check(key string) *KeyError {
+	if key == "" {
+		return &KeyError{Key: key}
+	}
+	return nil
+}
+
+func lookup(key string) (string, error) {
+	if key == "eof" {
+		return "", io.EOF
+	}
+	return "", ErrNotFound
+}
+
+func Get(key string) (string, error) {
+	if err := check(key); err != nil {
+		return "", err
+	}
+	v, err := lookup(key)
+	if err != nil {
+		return "", fmt.Errorf("get %s: %w", key, err)
+	}
+	if v == "" {
+		return "", fmt.Errorf("empty %q: %w", key, ErrNotFound)
+	}
+	return v, errors.New("unreachable")
+}
+
+func Size(key string) int { return len(key) }
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("GOFLAGS", "")
+
+	repo, err := NewParser(dir, dir, Options{}).ParseRepo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg := repo.Modules["a.b/store"].Packages["a.b/store"]
+	if pkg == nil {
+		t.Fatal("package a.b/store not found")
+	}
+	notFound := NewIdentity("a.b/store", "a.b/store", "ErrNotFound")
+	keyError := NewIdentity("a.b/store", "a.b/store", "KeyError")
+	eof := NewIdentity("", "io", "EOF")
+	kinds := func(name string) (ret []ErrorKind, errs []*Identity) {
+		for _, site := range pkg.Functions[name].Errors.Sites {
+			ret = append(ret, site.Kind)
+			errs = append(errs, site.Error)
+		}
+		return
+	}
+
+	if fn := pkg.Functions["Size"]; fn.Errors != nil {
+		t.Errorf("Errors of Size = %+v", fn.Errors)
+	}
+	if e := pkg.Functions["check"].Errors; e == nil || !e.Returns || e.Type == nil || *e.Type != keyError {
+		t.Errorf("Errors of check = %+v", e)
+	}
+	if got, errs := kinds("lookup"); !reflect.DeepEqual(got, []ErrorKind{ErrorSentinel, ErrorSentinel}) || *errs[0] != eof || *errs[1] != notFound {
+		t.Errorf("sites of lookup = %v %v", got, errs)
+	}
+	get := pkg.Functions["Get"].Errors
+	if get == nil || !get.Returns || get.Type != nil {
+		t.Fatalf("Errors of Get = %+v", get)
+	}
+	if got, errs := kinds("Get"); !reflect.DeepEqual(got, []ErrorKind{ErrorWrap, ErrorWrap, ErrorNew}) ||
+		errs[0] != nil || *errs[1] != notFound || errs[2] != nil || get.Sites[0].Message != "get %s: %w" {
+		t.Errorf("sites of Get = %v %v", got, get.Sites)
+	}
+
+	var origins []string
+	for _, o := range repo.ErrorOrigins(NewIdentity("a.b/store", "a.b/store", "Get")) {
+		origins = append(origins, o.Function.Name+":"+string(o.Kind))
+	}
+	if want := []string{"check:type", "lookup:sentinel", "lookup:sentinel", "Get:wrap", "Get:wrap", "Get:new"}; !reflect.DeepEqual(origins, want) {
+		t.Errorf("ErrorOrigins(Get) = %v, want %v", origins, want)
+	}
+	origins = nil
+	for _, o := range repo.ErrorOrigins(notFound) {
+		origins = append(origins, o.Function.Name+":"+string(o.Kind))
+	}
+	if want := []string{"lookup:sentinel", "Get:wrap"}; !reflect.DeepEqual(origins, want) {
+		t.Errorf("ErrorOrigins(ErrNotFound) = %v, want %v", origins, want)
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"context"
+	"fmt"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/cloudwego/abcoder/lang/uniast"
+)
+
+// referFetchedCodes collects the node of id from the fetched sources of its module version,
+// since the package is not loaded when the module is missing in the local caches
+func (p *GoParser) referFetchedCodes(ctx *fileContext, mod *Module, id *Identity) error {
+	i := strings.LastIndex(id.ModPath, "@")
+	if i < 0 {
+		return fmt.Errorf("cannot find package %s: no version of module %s", id.PkgPath, id.ModPath)
+	}
+	path, version := id.ModPath[:i], id.ModPath[i+1:]
+	// the package lives under the required module, which differs from the versioned one if it is replaced
+	req := path
+	for k, v := range ctx.module.Dependencies {
+		if v == id.ModPath && len(k) > len(req) && (id.PkgPath == k || strings.HasPrefix(id.PkgPath, k+"/")) {
+			req = k
+		}
+	}
+	if id.PkgPath != req && !strings.HasPrefix(id.PkgPath, req+"/") {
+		return fmt.Errorf("cannot find package %s in module %s", id.PkgPath, id.ModPath)
+	}
+	dir, err := p.fetcher.GoModule(context.Background(), path, version)
+	if err != nil {
+		return fmt.Errorf("cannot find package %s: %w", id.PkgPath, err)
+	}
+	dir = filepath.Join(dir, filepath.FromSlash(strings.TrimPrefix(id.PkgPath[len(req):], "/")))
+	files, err := p.packageGoFiles(dir)
+	if err != nil {
+		return fmt.Errorf("cannot find package %s: %w", id.PkgPath, err)
+	}
+
+	fset := token.NewFileSet()
+	for _, fpath := range files {
+		bs := p.getFileBytes(fpath)
+		file, e := parser.ParseFile(fset, fpath, bs, parser.ParseComments)
+		if e != nil {
+			err = e
+			continue
+		}
+		if _, e := p.referFile(file, fset, bs, mod, id, id.PkgPath); e != nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// packageGoFiles returns the non-test go files in dir matching the build tags
+func (p *GoParser) packageGoFiles(dir string) ([]string, error) {
+	es, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	bctx := build.Default
+	bctx.BuildTags = p.opts.Tags
+	var files []string
+	for _, e := range es {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".go") || isTestFile(e.Name()) {
+			continue
+		}
+		if ok, err := bctx.MatchFile(dir, e.Name()); err != nil || !ok {
+			continue
+		}
+		files = append(files, filepath.Join(dir, e.Name()))
+	}
+	return files, nil
+}
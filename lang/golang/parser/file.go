@@ -33,10 +33,14 @@ const (
 
 func (p *GoParser) parseFile(ctx *fileContext, f *ast.File) error {
 	cont := true
+	ctx.linknames = linknames(f)
 	ast.Inspect(f, func(node ast.Node) bool {
 		if funcDecl, ok := node.(*ast.FuncDecl); ok {
 			// parse funcs
-			_, ct := p.parseFunc(ctx, funcDecl)
+			f, ct := p.parseFunc(ctx, funcDecl)
+			if f != nil {
+				f.Annotations = directives(funcDecl.Doc)
+			}
 			// fileFuncs[f.Name] = f
 			cont = ct
 		} else if decl, ok := node.(*ast.GenDecl); ok {
@@ -49,14 +53,18 @@ func (p *GoParser) parseFile(ctx *fileContext, f *ast.File) error {
 			case token.TYPE:
 				for _, spec := range decl.Specs {
 					typDecl := spec.(*ast.TypeSpec)
-					_, ct = p.parseType(ctx, typDecl, doc)
+					var st *Type
+					st, ct = p.parseType(ctx, typDecl, doc)
+					if st != nil {
+						st.Annotations = specDirectives(decl, typDecl.Doc)
+					}
 				}
 			case token.VAR:
 				var firstVal *float64
 				for _, spec := range decl.Specs {
 					vspec, ok := spec.(*ast.ValueSpec)
 					if ok {
-						_, _, firstVal = p.parseVar(ctx, vspec, false, nil, firstVal, doc)
+						_, _, firstVal = p.parseVar(ctx, vspec, false, nil, firstVal, doc, specDirectives(decl, vspec.Doc))
 					}
 				}
 			case token.CONST:
@@ -67,7 +75,7 @@ func (p *GoParser) parseFile(ctx *fileContext, f *ast.File) error {
 				for _, spec := range decl.Specs {
 					vspec, ok := spec.(*ast.ValueSpec)
 					if ok {
-						curType, v, curVal = p.parseVar(ctx, vspec, true, curType, curVal, doc)
+						curType, v, curVal = p.parseVar(ctx, vspec, true, curType, curVal, doc, specDirectives(decl, vspec.Doc))
 						if v != nil {
 							vars = append(vars, v)
 						}
@@ -96,14 +104,14 @@ func (p *GoParser) parseFile(ctx *fileContext, f *ast.File) error {
 
 func (p *GoParser) newVar(mod string, pkg string, name string, isConst bool) *Var {
 	ret := &Var{
-		Identity:   NewIdentity(mod, pkg, name),
-		IsConst:    isConst,
-		IsExported: isUpperCase(name[0]),
+		Identity: NewIdentity(mod, pkg, name),
+		IsConst:  isConst,
 	}
+	ret.SetVisibility(visibility(name))
 	return p.repo.SetVar(ret.Identity, ret)
 }
 
-func (p *GoParser) parseVar(ctx *fileContext, vspec *ast.ValueSpec, isConst bool, lastType *Identity, lastValue *float64, doc *ast.CommentGroup) (*Identity, *Var, *float64) {
+func (p *GoParser) parseVar(ctx *fileContext, vspec *ast.ValueSpec, isConst bool, lastType *Identity, lastValue *float64, doc *ast.CommentGroup, anns []Annotation) (*Identity, *Var, *float64) {
 	var typ *Identity
 	var val *ast.Expr
 	var v *Var
@@ -123,11 +131,14 @@ func (p *GoParser) parseVar(ctx *fileContext, vspec *ast.ValueSpec, isConst bool
 		}
 		v = p.newVar(ctx.module.Name, ctx.pkgPath, name.Name, isConst)
 		v.FileLine = ctx.FileLine(vspec)
+		v.Annotations = anns
+		v.Assets = ctx.embeddedAssets(anns)
 
 		// collect func value dependencies, in case of var a = func() {...}
 		if val != nil && !isConst {
 			collects := collectInfos{
 				directCalls: map[FileLine]bool{},
+				parent:      &v.Identity,
 			}
 			ast.Inspect(*val, func(n ast.Node) bool {
 				return p.parseASTNode(ctx, n, &collects)
@@ -268,20 +279,15 @@ func (p *GoParser) parseVar(ctx *fileContext, vspec *ast.ValueSpec, isConst bool
 
 // newFunc allocate a function in the repo
 func (p *GoParser) newFunc(mod, pkg, name string) *Function {
-	var exported bool
-	if ind := strings.LastIndexByte(name, '.'); ind != -1 && ind+1 < len(name) {
-		exported = isUpperCase(name[ind+1])
-	} else {
-		exported = isUpperCase(name[0])
-	}
-
-	ret := &Function{Identity: NewIdentity(mod, pkg, name), Exported: exported}
+	ret := &Function{Identity: NewIdentity(mod, pkg, name)}
+	ret.SetVisibility(visibility(name))
 	return p.repo.SetFunction(ret.Identity, ret)
 }
 
 // newType allocate a struct in the repo
 func (p *GoParser) newType(mod, pkg, name string) *Type {
-	ret := &Type{Identity: NewIdentity(mod, pkg, name), Exported: isUpperCase(name[0])}
+	ret := &Type{Identity: NewIdentity(mod, pkg, name)}
+	ret.SetVisibility(visibility(name))
 	return p.repo.SetType(ret.Identity, ret)
 }
 
@@ -399,6 +405,48 @@ type collectInfos struct {
 
 	directCalls        map[FileLine]bool
 	anonymousFunctions []FileLine // record anonymous function
+	// freshContexts are the callees passed a new root context, see Dependency.FreshContext
+	freshContexts map[Identity]string
+	// errorSites are where the errors are created, wrapped or returned, see Errors.Sites
+	errorSites []ErrorSite
+
+	// parent is the function or var being parsed, whose function literals are parsed as its children, see parseFuncLit
+	parent   *Identity
+	closures int
+}
+
+// fill sets the collected dependencies to the function
+func (c *collectInfos) fill(f *Function) {
+	f.FunctionCalls = c.functionCalls
+	f.MethodCalls = c.methodCalls
+	f.GlobalVars = c.globalVars
+	f.Types = c.tys
+	if len(c.directCalls) > 0 {
+		for i, dep := range f.FunctionCalls {
+			if c.directCalls[dep.FileLine] {
+				f.FunctionCalls[i].SetExtra(ExtraKey_IsInvoked, true)
+			}
+		}
+		for i, dep := range f.MethodCalls {
+			if c.directCalls[dep.FileLine] {
+				f.MethodCalls[i].SetExtra(ExtraKey_IsInvoked, true)
+			}
+		}
+		for i, dep := range f.GlobalVars {
+			if c.directCalls[dep.FileLine] {
+				f.GlobalVars[i].SetExtra(ExtraKey_IsInvoked, true)
+			}
+		}
+	}
+	if len(c.anonymousFunctions) > 0 {
+		f.SetExtra(ExtraKey_AnonymousFunctions, c.anonymousFunctions)
+	}
+	for i, dep := range f.FunctionCalls {
+		f.FunctionCalls[i].FreshContext = c.freshContexts[dep.Identity]
+	}
+	for i, dep := range f.MethodCalls {
+		f.MethodCalls[i].FreshContext = c.freshContexts[dep.Identity]
+	}
 }
 
 func (p *GoParser) parseASTNode(ctx *fileContext, node ast.Node, collect *collectInfos) bool {
@@ -407,7 +455,16 @@ func (p *GoParser) parseASTNode(ctx *fileContext, node ast.Node, collect *collec
 		return p.parseSelector(ctx, expr, collect)
 	case *ast.CallExpr:
 		p.parseCall(ctx, expr, collect)
+	case *ast.CompositeLit:
+		ctx.collectErrorLit(expr, collect)
+	case *ast.ReturnStmt:
+		ctx.collectSentinels(expr, collect)
 	case *ast.FuncLit:
+		if fn := p.parseFuncLit(ctx, expr, collect); fn != nil {
+			// the dependencies in it belong to the child
+			collect.functionCalls = InsertDependency(collect.functionCalls, NewDependency(fn.Identity, ctx.FileLine(expr.Type)))
+			return false
+		}
 		collect.anonymousFunctions = append(collect.anonymousFunctions, ctx.FileLine(expr))
 	case *ast.Ident:
 		callName := expr.Name
@@ -486,6 +543,8 @@ func (p *GoParser) parseCall(ctx *fileContext, expr *ast.CallExpr, collect *coll
 
 	if ident != nil {
 		collect.directCalls[ctx.FileLine(ident)] = true
+		ctx.collectFreshContext(expr, ident, collect)
+		ctx.collectErrorCall(expr, ident, collect)
 	}
 }
 
@@ -538,64 +597,94 @@ func (p *GoParser) parseFunc(ctx *fileContext, funcDecl *ast.FuncDecl) (*Functio
 	// collect content
 	content := string(ctx.GetRawContent(funcDecl))
 
-	collects := collectInfos{
-		directCalls: map[FileLine]bool{},
-	}
-	if funcDecl.Body == nil {
-		goto set_func
-	}
-
-	ast.Inspect(funcDecl.Body, func(n ast.Node) bool {
-		return p.parseASTNode(ctx, n, &collects)
-	})
-
-set_func:
 	if fname == "init" && p.repo.GetFunction(NewIdentity(ctx.module.Name, ctx.pkgPath, fname)) != nil {
 		// according to https://go.dev/ref/spec#Program_initialization_and_execution,
 		// duplicated init() is allowed and never be referenced, thus add a subfix
 		fname += "_" + strconv.Itoa(int(funcDecl.Pos()))
 	}
+	id := NewIdentity(ctx.module.Name, ctx.pkgPath, fname)
+
+	collects := collectInfos{
+		directCalls: map[FileLine]bool{},
+		parent:      &id,
+	}
+	if funcDecl.Body != nil {
+		ast.Inspect(funcDecl.Body, func(n ast.Node) bool {
+			return p.parseASTNode(ctx, n, &collects)
+		})
+	}
 
 	// update detailed function call info
 	f := p.newFunc(ctx.module.Name, ctx.pkgPath, fname)
 	f.FileLine = ctx.FileLine(funcDecl)
 	f.Content = content
-	f.FunctionCalls = collects.functionCalls
-	f.MethodCalls = collects.methodCalls
+	collects.fill(f)
 	f.IsMethod = isMethod
+	f.IsTest = !isMethod && isTestFile(ctx.filePath) && isTestFunc(funcDecl.Name.Name)
 	f.Receiver = receiver
 	f.Params = params
 	f.Results = results
-	f.GlobalVars = collects.globalVars
-	f.Types = collects.tys
 	for _, t := range tparams {
 		f.Types = InsertDependency(f.Types, t)
 	}
 	f.Signature = string(sig)
+	f.TakesContext = ctx.takesContext(funcDecl.Type)
+	f.Errors = ctx.errorFlow(funcDecl.Type, collects.errorSites)
 
-	if len(collects.directCalls) > 0 {
-		for i, dep := range f.FunctionCalls {
-			if collects.directCalls[dep.FileLine] {
-				f.FunctionCalls[i].SetExtra(ExtraKey_IsInvoked, true)
-			}
-		}
-		for i, dep := range f.MethodCalls {
-			if collects.directCalls[dep.FileLine] {
-				f.MethodCalls[i].SetExtra(ExtraKey_IsInvoked, true)
-			}
-		}
-		for i, dep := range f.GlobalVars {
-			if collects.directCalls[dep.FileLine] {
-				f.GlobalVars[i].SetExtra(ExtraKey_IsInvoked, true)
-			}
-		}
-	}
-	if len(collects.anonymousFunctions) > 0 {
-		f.SetExtra(ExtraKey_AnonymousFunctions, collects.anonymousFunctions)
+	if funcDecl.Body == nil {
+		p.linkExternal(ctx, funcDecl, f)
+	} else if isCgoFunc(fname) {
+		f.SetExtra(ExtraKey_External, ExternalCgo)
 	}
 	return f, false
 }
 
+// parseFuncLit parses the function literal as a child function of the parent if it spans Options.ClosureMinLines lines at least,
+// thus the closures like HTTP handlers are addressable. It is named like `Parent.func1` or `Parent.func1.1` for the nested ones,
+// as the go compiler does. Returns nil if it is left in the parent
+func (p *GoParser) parseFuncLit(ctx *fileContext, lit *ast.FuncLit, parent *collectInfos) *Function {
+	min := p.opts.ClosureMinLines
+	if min <= 0 || parent.parent == nil || lit.Body == nil {
+		return nil
+	}
+	if ctx.fset.Position(lit.End()).Line-ctx.fset.Position(lit.Pos()).Line+1 < min {
+		return nil
+	}
+	parent.closures++
+	pid := *parent.parent
+	name := pid.Name + ".func" + strconv.Itoa(parent.closures)
+	if pf := p.repo.GetFunction(pid); pf != nil && pf.ParentFunction != nil {
+		name = pid.Name + "." + strconv.Itoa(parent.closures)
+	}
+
+	var params, results []Dependency
+	if lit.Type.Params != nil {
+		ctx.collectFields(lit.Type.Params.List, &params)
+	}
+	if lit.Type.Results != nil {
+		ctx.collectFields(lit.Type.Results.List, &results)
+	}
+
+	f := p.newFunc(ctx.module.Name, ctx.pkgPath, name)
+	f.FileLine = ctx.FileLine(lit)
+	f.Content = string(ctx.GetRawContent(lit))
+	f.Signature = string(ctx.GetRawContent(lit.Type))
+	f.Params = params
+	f.Results = results
+	f.TakesContext = ctx.takesContext(lit.Type)
+	f.ParentFunction = &pid
+	collects := collectInfos{
+		directCalls: map[FileLine]bool{},
+		parent:      &f.Identity,
+	}
+	ast.Inspect(lit.Body, func(n ast.Node) bool {
+		return p.parseASTNode(ctx, n, &collects)
+	})
+	collects.fill(f)
+	f.Errors = ctx.errorFlow(lit.Type, collects.errorSites)
+	return f
+}
+
 func (p *GoParser) parseType(ctx *fileContext, typDecl *ast.TypeSpec, doc *ast.CommentGroup) (st *Type, ct bool) {
 	switch decl := typDecl.Type.(type) {
 	case *ast.StructType:
@@ -642,6 +731,7 @@ func (p *GoParser) parseStruct(ctx *fileContext, struName string, name *ast.Iden
 			// Fixme: join names?
 			fieldname = fieldDecl.Names[0].Name
 		}
+		var typeId *Identity
 		if stru, ok := fieldDecl.Type.(*ast.StructType); ok {
 			// anonymous struct. parse it
 			as, _ := p.parseStruct(ctx, "_"+fieldname, nil, stru)
@@ -655,8 +745,12 @@ func (p *GoParser) parseStruct(ctx *fileContext, struName string, name *ast.Iden
 			// remove the anonymous struct from the repo
 			delete(p.repo.GetPackage(as.ModPath, as.PkgPath).Types, as.Name)
 		} else {
-			p.collectTypes(ctx, fieldDecl.Type, st, inlined)
+			ti := p.collectTypes(ctx, fieldDecl.Type, st, inlined)
+			if ti.IsNamed && ti.Id.Name != "" {
+				typeId = &ti.Id
+			}
 		}
+		st.Fields = append(st.Fields, ctx.newFields(fieldDecl, typeId)...)
 	}
 	// check if it implements any parser.interfaces
 	if name != nil {
@@ -698,6 +792,7 @@ func (p *GoParser) parseInterface(ctx *fileContext, name *ast.Ident, decl *ast.I
 			fn.FileLine = ctx.FileLine(fieldDecl)
 			fn.IsMethod = true
 			fn.IsInterfaceMethod = true
+			fn.Receiver = &Receiver{Type: st.Identity}
 			fn.Signature = string(ctx.GetRawContent(fieldDecl))
 			// collect func signature deps
 			ty := ctx.GetTypeInfo(fieldDecl.Type)
@@ -728,6 +823,25 @@ func (p *GoParser) parseInterface(ctx *fileContext, name *ast.Ident, decl *ast.I
 			if !iface.Empty() {
 				p.interfaces[iface] = st.Identity
 			}
+			// methods of embedded interfaces, point to the interface which declares them
+			for i := 0; i < iface.NumMethods(); i++ {
+				m := iface.Method(i)
+				if _, ok := st.Methods[m.Name()]; ok {
+					continue
+				}
+				recv := m.Type().(*types.Signature).Recv()
+				if recv == nil {
+					continue
+				}
+				ti := ctx.getTypeinfo(recv.Type())
+				if !ti.IsNamed || ti.Id.Name == "" {
+					continue
+				}
+				if st.Methods == nil {
+					st.Methods = make(map[string]Identity)
+				}
+				st.Methods[m.Name()] = NewIdentity(ti.Id.ModPath, ti.Id.PkgPath, ti.Id.Name+"."+m.Name())
+			}
 		}
 	}
 
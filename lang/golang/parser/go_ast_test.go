@@ -17,6 +17,9 @@ package parser
 import (
 	"bytes"
 	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	. "github.com/cloudwego/abcoder/lang/uniast"
@@ -336,3 +339,77 @@ func TestCases(t *testing.T) {
 		})
 	}
 }
+
+func Test_goParser_Closures(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module a.b/cl\n\ngo 1.21\n",
+		"srv/s.go": "package srv\n\n" +
+			"func Handle(path string, h func(string) error) {}\n\n" +
+			"func check(s string) error { return nil }\n\n" +
+			"var Default = func(s string) error {\n\treturn check(s)\n}\n\n" +
+			"func Routes() {\n" +
+			"\tHandle(\"/a\", func(s string) error {\n\t\tf := func() error {\n\t\t\treturn check(s)\n\t\t}\n\t\treturn f()\n\t})\n" +
+			"\tHandle(\"/b\", func(string) error { return nil })\n" +
+			"}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	id := func(name string) Identity { return NewIdentity("a.b/cl", "a.b/cl/srv", name) }
+
+	repo, err := NewParser(dir, dir, Options{ClosureMinLines: 3}).ParseRepo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	routes := repo.GetFunction(id("Routes"))
+	if routes == nil {
+		t.Fatal("function Routes is not parsed")
+	}
+	closure := repo.GetFunction(id("Routes.func1"))
+	if closure == nil || closure.ParentFunction == nil || *closure.ParentFunction != routes.Identity {
+		t.Fatalf("closure = %+v", closure)
+	}
+	if closure.Line != 12 || !strings.HasPrefix(closure.Content, "func(s string) error {") || closure.Signature != "func(s string) error" {
+		t.Errorf("closure = %+v", closure)
+	}
+	nested := repo.GetFunction(id("Routes.func1.1"))
+	if nested == nil || *nested.ParentFunction != closure.Identity || len(nested.FunctionCalls) != 1 || nested.FunctionCalls[0].Identity != id("check") {
+		t.Fatalf("nested closure = %+v", nested)
+	}
+	// the one-line closure is left in the parent
+	if repo.GetFunction(id("Routes.func2")) != nil {
+		t.Error("the short closure should not be parsed")
+	}
+	hasCall := func(deps []Dependency, id Identity) bool {
+		for _, d := range deps {
+			if d.Identity == id {
+				return true
+			}
+		}
+		return false
+	}
+	if !hasCall(routes.FunctionCalls, closure.Identity) || hasCall(routes.FunctionCalls, id("check")) {
+		t.Errorf("calls of Routes = %+v", routes.FunctionCalls)
+	}
+	if !hasCall(closure.FunctionCalls, nested.Identity) || hasCall(closure.FunctionCalls, id("check")) {
+		t.Errorf("calls of the closure = %+v", closure.FunctionCalls)
+	}
+	if v := repo.GetFunction(id("Default.func1")); v == nil || *v.ParentFunction != id("Default") {
+		t.Errorf("closure of var = %+v", v)
+	}
+
+	repo, err = NewParser(dir, dir, Options{}).ParseRepo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repo.GetFunction(id("Routes.func1")) != nil {
+		t.Error("closures are parsed by default")
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	. "github.com/cloudwego/abcoder/lang/uniast"
+)
+
+func Test_goParser_Implements(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":         "module a.b/buf\n\ngo 1.21\n",
+		"sizer/sizer.go": "package sizer\n\ntype Sizer interface {\n\tSize() int\n}\n",
+		"buf.go": "package buf\n\nimport (\n\t\"io\"\n\n\t\"a.b/buf/sizer\"\n)\n\n" +
+			"type Buf struct{ b []byte }\n\n" +
+			"func (b *Buf) Read(p []byte) (int, error) { return copy(p, b.b), io.EOF }\n\n" +
+			"func (b Buf) Size() int { return len(b.b) }\n\n" +
+			"func Sizes(ss ...sizer.Sizer) {}\n\n" +
+			"func Copy(w io.Writer, r io.Reader) {}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	repo, err := NewParser(dir, dir, Options{}).ParseRepo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := NewIdentity("a.b/buf", "a.b/buf", "Buf")
+	reader := Identity{PkgPath: "io", Name: "Reader"}
+	sizer := NewIdentity("a.b/buf", "a.b/buf/sizer", "Sizer")
+
+	if got, want := repo.GetImplementedInterfaces(buf), []Identity{reader, sizer}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GetImplementedInterfaces() = %v, want %v", got, want)
+	}
+	if got, want := repo.GetImplementations(reader), []Identity{buf}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GetImplementations(io.Reader) = %v, want %v", got, want)
+	}
+	if got := repo.GetImplementations(Identity{PkgPath: "io", Name: "Writer"}); len(got) != 0 {
+		t.Errorf("GetImplementations(io.Writer) = %v, want none", got)
+	}
+}
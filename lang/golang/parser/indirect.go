@@ -0,0 +1,213 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"go/ast"
+	"go/types"
+	"path/filepath"
+	"strconv"
+
+	. "github.com/cloudwego/abcoder/lang/uniast"
+	"golang.org/x/tools/go/packages"
+)
+
+// ExtraKey_Indirect marks a call edge through a variable or struct field holding a function,
+// whose callee is resolved from the assignments of the package, see linkIndirectCalls
+const ExtraKey_Indirect = "Indirect"
+
+// funcValues tracks what are assigned to the variables and struct fields of a package.
+// A source is either a *types.Func (a function, method value or method expression) or another *types.Var
+type funcValues struct {
+	info    *types.Info
+	sources map[*types.Var][]types.Object
+}
+
+// linkIndirectCalls adds the functions assigned to variables and struct fields in the package,
+// like `s.Field = Func`, `f := obj.Method` or `T{Field: Func}`, as the callees of the calls through them, e.g. `s.Field(x)`.
+// It is best-effort and flow-insensitive: every function ever assigned to the variable in the package is a possible callee
+func (p *GoParser) linkIndirectCalls(mod *Module, pkg *packages.Package) {
+	obj := mod.Packages[pkg.ID]
+	if obj == nil || pkg.TypesInfo == nil {
+		return
+	}
+	fv := &funcValues{info: pkg.TypesInfo, sources: map[*types.Var][]types.Object{}}
+	for _, file := range pkg.Syntax {
+		ast.Inspect(file, fv.collect)
+	}
+	if len(fv.sources) == 0 {
+		return
+	}
+
+	for _, file := range pkg.Syntax {
+		for _, decl := range file.Decls {
+			fd, ok := decl.(*ast.FuncDecl)
+			if !ok || fd.Body == nil {
+				continue
+			}
+			caller := indirectCaller(obj, pkg.TypesInfo, fd)
+			if caller == nil {
+				continue
+			}
+			ast.Inspect(fd.Body, func(n ast.Node) bool {
+				call, ok := n.(*ast.CallExpr)
+				if !ok {
+					return true
+				}
+				fun := ast.Unparen(call.Fun)
+				v := fv.slot(fun)
+				if v == nil {
+					return true
+				}
+				pos := pkg.Fset.Position(fun.Pos())
+				end := pkg.Fset.Position(fun.End())
+				rel, _ := filepath.Rel(p.homePageDir, pos.Filename)
+				for _, fn := range fv.resolve(v) {
+					id, ok := funcIdentity(mod, fn)
+					if !ok || id == caller.Identity {
+						continue
+					}
+					dep := NewDependency(id, FileLine{File: rel, Line: pos.Line, StartOffset: pos.Offset, EndOffset: end.Offset})
+					dep.SetExtra(ExtraKey_Indirect, true)
+					if fn.Type().(*types.Signature).Recv() != nil {
+						caller.MethodCalls = InsertDependency(caller.MethodCalls, dep)
+					} else {
+						caller.FunctionCalls = InsertDependency(caller.FunctionCalls, dep)
+					}
+				}
+				return true
+			})
+		}
+	}
+}
+
+// indirectCaller returns the parsed function of the declaration, duplicated init() are suffixed as parseFunc does
+func indirectCaller(pkg *Package, info *types.Info, fd *ast.FuncDecl) *Function {
+	fn, ok := info.Defs[fd.Name].(*types.Func)
+	if !ok {
+		return nil
+	}
+	name := funcName(fn)
+	if name == "init" {
+		if f := pkg.Functions[name+"_"+strconv.Itoa(int(fd.Pos()))]; f != nil {
+			return f
+		}
+	}
+	return pkg.Functions[name]
+}
+
+// collect records the assignments, var declarations and struct literals of the node
+func (fv *funcValues) collect(n ast.Node) bool {
+	switch n := n.(type) {
+	case *ast.AssignStmt:
+		if len(n.Lhs) == len(n.Rhs) {
+			for i, lhs := range n.Lhs {
+				fv.assign(fv.slot(lhs), n.Rhs[i])
+			}
+		}
+	case *ast.ValueSpec:
+		if len(n.Names) == len(n.Values) {
+			for i, name := range n.Names {
+				v, _ := fv.info.Defs[name].(*types.Var)
+				fv.assign(v, n.Values[i])
+			}
+		}
+	case *ast.CompositeLit:
+		tv, ok := fv.info.Types[n]
+		if !ok {
+			return true
+		}
+		st, ok := tv.Type.Underlying().(*types.Struct)
+		if !ok {
+			return true
+		}
+		for i, elt := range n.Elts {
+			if kv, ok := elt.(*ast.KeyValueExpr); ok {
+				if key, ok := kv.Key.(*ast.Ident); ok {
+					fv.assign(fv.slot(key), kv.Value)
+				}
+			} else if i < st.NumFields() {
+				fv.assign(st.Field(i).Origin(), elt)
+			}
+		}
+	}
+	return true
+}
+
+// slot returns the variable or struct field denoted by the expression, nil if it is not
+func (fv *funcValues) slot(expr ast.Expr) *types.Var {
+	var ident *ast.Ident
+	switch e := ast.Unparen(expr).(type) {
+	case *ast.Ident:
+		ident = e
+	case *ast.SelectorExpr:
+		ident = e.Sel
+	default:
+		return nil
+	}
+	if v, ok := fv.info.ObjectOf(ident).(*types.Var); ok {
+		return v.Origin()
+	}
+	return nil
+}
+
+func (fv *funcValues) assign(v *types.Var, value ast.Expr) {
+	if v == nil {
+		return
+	}
+	var ident *ast.Ident
+	switch e := ast.Unparen(value).(type) {
+	case *ast.Ident:
+		ident = e
+	case *ast.SelectorExpr:
+		ident = e.Sel
+	default:
+		return
+	}
+	switch src := fv.info.ObjectOf(ident).(type) {
+	case *types.Func:
+		fv.sources[v] = append(fv.sources[v], src.Origin())
+	case *types.Var:
+		if src = src.Origin(); src != v {
+			fv.sources[v] = append(fv.sources[v], src)
+		}
+	}
+}
+
+// resolve returns the functions assigned to the variable, directly or through other variables
+func (fv *funcValues) resolve(v *types.Var) []*types.Func {
+	var ret []*types.Func
+	visited := map[types.Object]bool{}
+	var walk func(v *types.Var)
+	walk = func(v *types.Var) {
+		if visited[v] {
+			return
+		}
+		visited[v] = true
+		for _, src := range fv.sources[v] {
+			switch src := src.(type) {
+			case *types.Func:
+				if !visited[src] {
+					visited[src] = true
+					ret = append(ret, src)
+				}
+			case *types.Var:
+				walk(src)
+			}
+		}
+	}
+	walk(v)
+	return ret
+}
@@ -0,0 +1,77 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/cloudwego/abcoder/lang/uniast"
+)
+
+func Test_goParser_IndirectCalls(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module a.b/ic\n\ngo 1.21\n",
+		"ic.go": "package ic\n\n" +
+			"type Struct struct {\n\tHook func(in []byte)\n\tNext func() int\n}\n\n" +
+			"type Counter struct{ n int }\n\nfunc (c *Counter) Incr() int { c.n++; return c.n }\n\n" +
+			"func InternalFunc(in []byte) {}\n\n" +
+			"var handler = InternalFunc\n\n" +
+			"func Setup(s *Struct) {\n\ts.Hook = InternalFunc\n\tc := &Counter{}\n\t*s = Struct{Next: c.Incr}\n}\n\n" +
+			"func Run(s *Struct, in []byte) int {\n\ts.Hook(in)\n\th := handler\n\th(in)\n\treturn s.Next()\n}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	repo, err := NewParser(dir, dir, Options{}).ParseRepo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := repo.GetFunction(NewIdentity("a.b/ic", "a.b/ic", "Run"))
+	if run == nil {
+		t.Fatal("function Run is not parsed")
+	}
+	indirect := func(deps []Dependency, name string) *Dependency {
+		for i, dep := range deps {
+			if dep.Name == name {
+				if v, _ := dep.GetExtra(ExtraKey_Indirect).(bool); v {
+					return &deps[i]
+				}
+			}
+		}
+		return nil
+	}
+	dep := indirect(run.FunctionCalls, "InternalFunc")
+	if dep == nil {
+		t.Fatalf("missing indirect call of InternalFunc in %+v", run.FunctionCalls)
+	}
+	if dep.File != "ic.go" || dep.Line != 23 {
+		t.Errorf("file line of InternalFunc = %+v", dep.FileLine)
+	}
+	if indirect(run.MethodCalls, "Counter.Incr") == nil {
+		t.Errorf("missing indirect call of Counter.Incr in %+v", run.MethodCalls)
+	}
+
+	setup := repo.GetFunction(NewIdentity("a.b/ic", "a.b/ic", "Setup"))
+	if dep := indirect(setup.FunctionCalls, "InternalFunc"); dep != nil {
+		t.Errorf("assignment is taken as an indirect call: %+v", dep)
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"go/ast"
+	"sort"
+
+	. "github.com/cloudwego/abcoder/lang/uniast"
+	"golang.org/x/tools/go/packages"
+)
+
+// collectInitOrder records the Package.InitOrder by https://go.dev/ref/spec#Package_initialization:
+// the package-level vars are initialized in the order computed by go/types, then the init functions
+// run in the order of the files sorted by names and of their declarations in each file
+func collectInitOrder(mod *Module, pkg *packages.Package) {
+	obj := mod.Packages[pkg.ID]
+	if obj == nil || pkg.TypesInfo == nil {
+		return
+	}
+	var order []Identity
+	for _, initer := range pkg.TypesInfo.InitOrder {
+		for _, v := range initer.Lhs {
+			// blank vars are not parsed
+			if obj.Vars[v.Name()] != nil {
+				order = append(order, obj.Vars[v.Name()].Identity)
+			}
+		}
+	}
+
+	files := make([]*ast.File, len(pkg.Syntax))
+	copy(files, pkg.Syntax)
+	sort.SliceStable(files, func(i, j int) bool {
+		return pkg.Fset.Position(files[i].Package).Filename < pkg.Fset.Position(files[j].Package).Filename
+	})
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			fd, ok := decl.(*ast.FuncDecl)
+			if !ok || fd.Recv != nil || fd.Name.Name != "init" {
+				continue
+			}
+			if fn := indirectCaller(obj, pkg.TypesInfo, fd); fn != nil {
+				order = append(order, fn.Identity)
+			}
+		}
+	}
+	obj.InitOrder = order
+}
@@ -0,0 +1,87 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	. "github.com/cloudwego/abcoder/lang/uniast"
+)
+
+func Test_goParser_InitOrder(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module a.b/svc\n\ngo 1.21\n",
+		"b.go": "package svc\n\n" +
+			"var registry = newRegistry()\n\n" +
+			"var total = count + 1\n\n" +
+			"var base = 1\n\n" +
+			"func init() { registry[\"b\"] = total }\n",
+		"a.go": "package svc\n\n" +
+			"var count = base * 2\n\n" +
+			"func newRegistry() map[string]int { return map[string]int{} }\n\n" +
+			"func init() { registry[\"a\"] = count }\n\n" +
+			"func init() { count++ }\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("GOFLAGS", "")
+
+	repo, err := NewParser(dir, dir, Options{}).ParseRepo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg := repo.Modules["a.b/svc"].Packages["a.b/svc"]
+	if pkg == nil {
+		t.Fatal("package a.b/svc not found")
+	}
+	var got []string
+	for _, id := range pkg.InitOrder {
+		got = append(got, id.Name)
+	}
+	// the earliest declared vars ready for initialization first, then the init functions of a.go before b.go
+	if len(got) != 7 || !reflect.DeepEqual(got[:4], []string{"registry", "base", "count", "total"}) ||
+		got[4] != "init" || got[5] == "init" || got[6] == "init" {
+		t.Fatalf("InitOrder = %v", got)
+	}
+	second := pkg.Functions[got[5]]
+	if second == nil || second.Content != "func init() { count++ }" {
+		t.Errorf("second init = %+v", second)
+	}
+	last := pkg.Functions[got[6]]
+	if last == nil || last.File != "b.go" {
+		t.Fatalf("last init = %+v", last)
+	}
+	var touched []string
+	for _, dep := range last.GlobalVars {
+		touched = append(touched, dep.Name)
+	}
+	if !reflect.DeepEqual(touched, []string{"registry", "total"}) {
+		t.Errorf("GlobalVars of init in b.go = %v", touched)
+	}
+	var deps []Identity
+	for _, dep := range pkg.Vars["registry"].Dependencies {
+		deps = append(deps, dep.Identity)
+	}
+	if len(deps) == 0 || deps[0].Name != "newRegistry" {
+		t.Errorf("Dependencies of registry = %v", deps)
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"go/ast"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	. "github.com/cloudwego/abcoder/lang/uniast"
+)
+
+const (
+	// ExtraKey_External marks a function implemented outside Go, the value is ExternalAsm or ExternalCgo
+	ExtraKey_External = "External"
+	// ExtraKey_ExternalFile records the assembly file which implements the function
+	ExtraKey_ExternalFile = "ExternalFile"
+)
+
+const (
+	ExternalAsm = "asm"
+	ExternalCgo = "cgo"
+)
+
+// prefixes of the functions generated by cgo, which call C functions (`C.xxx`) or are called by C (`//export`)
+var cgoFuncPrefixes = []string{"_Cfunc_", "_cgoexp_", "_Cmacro_"}
+
+// matches `TEXT ·name(SB)` or `TEXT pkg·name(SB)` in assembly files
+var asmTextRegex = regexp.MustCompile(`(?m)^\s*TEXT\s+[\w./]*·(\w+)(?:<\w+>)?\(SB\)`)
+
+// linknames collects the `//go:linkname local target` directives of the file, local name => target
+func linknames(f *ast.File) map[string]string {
+	var ret map[string]string
+	for _, cg := range f.Comments {
+		for _, c := range cg.List {
+			fields := strings.Fields(c.Text)
+			if len(fields) != 3 || fields[0] != "//go:linkname" {
+				continue
+			}
+			if ret == nil {
+				ret = map[string]string{}
+			}
+			ret[fields[1]] = fields[2]
+		}
+	}
+	return ret
+}
+
+// directiveRegex matches the directive comments as go/ast defines, like `//go:noinline` or `//export Name`
+var directiveRegex = regexp.MustCompile(`^//(?:line |extern |export |[a-z0-9]+:[a-z0-9])`)
+
+// directives collects the directives in the doc comments, like `//go:embed static/*`,
+// the name is `go:embed` and the args are `static/*`
+func directives(groups ...*ast.CommentGroup) []Annotation {
+	var ret []Annotation
+	for _, cg := range groups {
+		if cg == nil {
+			continue
+		}
+		for _, c := range cg.List {
+			if !directiveRegex.MatchString(c.Text) {
+				continue
+			}
+			name, args, _ := strings.Cut(strings.TrimPrefix(c.Text, "//"), " ")
+			ret = append(ret, Annotation{Name: name, Args: strings.TrimSpace(args)})
+		}
+	}
+	return ret
+}
+
+// specDirectives collects the directives of a type or value spec.
+// The doc of an ungrouped declaration belongs to its only spec
+func specDirectives(decl *ast.GenDecl, specDoc *ast.CommentGroup) []Annotation {
+	if decl.Lparen.IsValid() {
+		return directives(specDoc)
+	}
+	return directives(decl.Doc, specDoc)
+}
+
+// splitLinkname splits the linkname target into the package path and the function name,
+// e.g. `runtime.nanotime` => (runtime, nanotime), `a/b.(*T).m` => (a/b, T.m)
+func splitLinkname(target string) (pkg string, name string, ok bool) {
+	slash := strings.LastIndex(target, "/")
+	dot := strings.Index(target[slash+1:], ".")
+	if dot < 0 {
+		return "", "", false
+	}
+	pkg, name = target[:slash+1+dot], target[slash+2+dot:]
+	name = strings.NewReplacer("(", "", ")", "", "*", "").Replace(name)
+	return pkg, name, pkg != "" && name != ""
+}
+
+// linkExternal links the function declared without body to its implementation:
+// the target of `//go:linkname`, or the assembly of the package.
+func (p *GoParser) linkExternal(ctx *fileContext, funcDecl *ast.FuncDecl, f *Function) {
+	if target, ok := ctx.linknames[funcDecl.Name.Name]; ok && funcDecl.Recv == nil {
+		pkg, name, ok := splitLinkname(target)
+		if !ok {
+			return
+		}
+		mod, err := ctx.GetMod(pkg)
+		if err != nil && err != errSysImport {
+			return
+		}
+		dep := NewDependency(NewIdentity(mod, pkg, name), ctx.FileLine(funcDecl.Name))
+		f.FunctionCalls = InsertDependency(f.FunctionCalls, dep)
+		return
+	}
+
+	var asms []string
+	for _, file := range ctx.otherFiles {
+		if strings.HasSuffix(file, ".s") {
+			asms = append(asms, file)
+		}
+	}
+	if len(asms) == 0 {
+		return
+	}
+	f.SetExtra(ExtraKey_External, ExternalAsm)
+	for _, file := range asms {
+		for _, m := range asmTextRegex.FindAllSubmatch(p.getFileBytes(file), -1) {
+			if string(m[1]) != funcDecl.Name.Name {
+				continue
+			}
+			if rel, err := filepath.Rel(p.homePageDir, file); err == nil {
+				file = rel
+			}
+			f.SetExtra(ExtraKey_ExternalFile, file)
+			return
+		}
+	}
+}
+
+// isCgoFunc tells if the function is generated by cgo
+func isCgoFunc(name string) bool {
+	for _, prefix := range cgoFuncPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
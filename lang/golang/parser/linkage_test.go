@@ -0,0 +1,131 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+
+	. "github.com/cloudwego/abcoder/lang/uniast"
+)
+
+func Test_splitLinkname(t *testing.T) {
+	for target, want := range map[string][2]string{
+		"runtime.nanotime":  {"runtime", "nanotime"},
+		"a.b/c/d.(*T).m":    {"a.b/c/d", "T.m"},
+		"a.b/c/d.Func":      {"a.b/c/d", "Func"},
+		"_cgoexp_xxx_Hello": {"", ""},
+	} {
+		pkg, name, _ := splitLinkname(target)
+		if pkg != want[0] || name != want[1] {
+			t.Errorf("splitLinkname(%q) = %q, %q, want %q", target, pkg, name, want)
+		}
+	}
+}
+
+func Test_goParser_Linkage(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":   "module a.b/link\n\ngo 1.21\n",
+		"lib/l.go": "package lib\n\nfunc Impl() int64 { return 1 }\n",
+		"asm/a.go": "package asm\n\nimport (\n\t_ \"unsafe\"\n\n\t_ \"a.b/link/lib\"\n)\n\n" +
+			"func Sum(a, b int64) int64\n\n" +
+			"//go:linkname impl a.b/link/lib.Impl\nfunc impl() int64\n\n" +
+			"//go:linkname nanotime runtime.nanotime\nfunc nanotime() int64\n\n" +
+			"func Use() int64 { return Sum(1, 2) + impl() + nanotime() }\n",
+		"asm/sum_" + runtime.GOARCH + ".s": "#include \"textflag.h\"\n\nTEXT ·Sum(SB),NOSPLIT,$0-24\n\tRET\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	repo, err := NewParser(dir, dir, Options{}).ParseRepo()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sum := repo.GetFunction(NewIdentity("a.b/link", "a.b/link/asm", "Sum"))
+	if sum == nil {
+		t.Fatal("function Sum is not parsed")
+	}
+	if v, _ := sum.GetExtra(ExtraKey_External).(string); v != ExternalAsm {
+		t.Errorf("Sum.External = %v", sum.GetExtra(ExtraKey_External))
+	}
+	if v, _ := sum.GetExtra(ExtraKey_ExternalFile).(string); v != "asm/sum_"+runtime.GOARCH+".s" {
+		t.Errorf("Sum.ExternalFile = %v", sum.GetExtra(ExtraKey_ExternalFile))
+	}
+
+	impl := repo.GetFunction(NewIdentity("a.b/link", "a.b/link/asm", "impl"))
+	if impl == nil || len(impl.FunctionCalls) != 1 || impl.FunctionCalls[0].Identity != NewIdentity("a.b/link", "a.b/link/lib", "Impl") {
+		t.Errorf("impl = %+v", impl)
+	}
+	if impl.GetExtra(ExtraKey_External) != nil {
+		t.Errorf("linkname pulled function should not be external: %v", impl.Extra)
+	}
+	nanotime := repo.GetFunction(NewIdentity("a.b/link", "a.b/link/asm", "nanotime"))
+	if nanotime == nil || len(nanotime.FunctionCalls) != 1 || nanotime.FunctionCalls[0].Identity != NewIdentity("", "runtime", "nanotime") {
+		t.Errorf("nanotime = %+v", nanotime)
+	}
+}
+
+func Test_goParser_Directives(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module a.b/dir\n\ngo 1.21\n",
+		"d/d.go": "package d\n\nimport _ \"embed\"\n\n" +
+			"// Hot is hot.\n//\n//go:noinline\n//go:nosplit\nfunc Hot() int { return 1 }\n\n" +
+			"//go:embed d.go\nvar src string\n\n" +
+			"var (\n\t//go:embed d.go\n\tsrc2 string\n)\n\n" +
+			"//kitex:service name=echo\ntype Echo struct{}\n\n" +
+			"// Plain has no directive, go:noinline in the text is not one\nfunc Plain() {}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	repo, err := NewParser(dir, dir, Options{}).ParseRepo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := func(name string) Identity { return NewIdentity("a.b/dir", "a.b/dir/d", name) }
+
+	if got := repo.GetFunction(id("Hot")).Annotations; !reflect.DeepEqual(got, []Annotation{{Name: "go:noinline"}, {Name: "go:nosplit"}}) {
+		t.Errorf("Hot.Annotations = %+v", got)
+	}
+	if got := repo.GetFunction(id("Plain")).Annotations; got != nil {
+		t.Errorf("Plain.Annotations = %+v", got)
+	}
+	for _, name := range []string{"src", "src2"} {
+		if got := repo.GetVar(id(name)).Annotations; !reflect.DeepEqual(got, []Annotation{{Name: "go:embed", Args: "d.go"}}) {
+			t.Errorf("%s.Annotations = %+v", name, got)
+		}
+	}
+	if got := repo.GetType(id("Echo")).Annotations; !reflect.DeepEqual(got, []Annotation{{Name: "kitex:service", Args: "name=echo"}}) {
+		t.Errorf("Echo.Annotations = %+v", got)
+	}
+}
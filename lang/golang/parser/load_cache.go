@@ -0,0 +1,152 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"fmt"
+	"go/token"
+	"strings"
+	"sync"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// loadCache caches the results of packages.Load process-wide.
+//
+// Besides the whole result of a pattern, each package loaded along with the dependencies is cached
+// by its path and module version, thus the packages shared by the modules of a repo are loaded
+// and type-checked only once, and their types.Info are shared.
+type loadCache struct {
+	mu sync.Mutex
+	// results of patterns
+	patterns map[loadKey]loadResult
+	// single packages, loaded as roots or dependencies, without test variants
+	packages map[loadKey]loadResult
+	// count of packages.Load calls and cache hits
+	loads, hits int
+}
+
+// loadKey identifies a load. conf is the load mode and build flags, which change the loaded results
+type loadKey struct {
+	// the pattern, or the path of a single package
+	path string
+	// the loading directory for a pattern, or the module version for a single package
+	where string
+	conf  string
+}
+
+type loadResult struct {
+	pkgs []*packages.Package
+	fset *token.FileSet
+}
+
+var globalLoadCache = &loadCache{
+	patterns: map[loadKey]loadResult{},
+	packages: map[loadKey]loadResult{},
+}
+
+// loadConf returns the key of the config. The tests mode is included only for patterns,
+// since single packages are only served without tests
+func loadConf(cfg *packages.Config, pattern bool) string {
+	mode := cfg.Mode
+	if !pattern {
+		mode &^= packages.NeedForTest
+	}
+	var env []string
+	for _, e := range cfg.Env {
+		if strings.HasPrefix(e, "GOFLAGS=") || strings.HasPrefix(e, "GOOS=") || strings.HasPrefix(e, "GOARCH=") || strings.HasPrefix(e, "CGO_ENABLED=") {
+			env = append(env, e)
+		}
+	}
+	return fmt.Sprintf("%d|%v|%s|%s", mode, pattern && cfg.Tests, strings.Join(cfg.BuildFlags, " "), strings.Join(env, " "))
+}
+
+// moduleVersion returns the version of the module in effect
+func moduleVersion(m *packages.Module) string {
+	if m == nil {
+		return ""
+	}
+	if m.Replace != nil {
+		return m.Replace.Version
+	}
+	return m.Version
+}
+
+// load loads the pattern under cfg.Dir, or returns the cached results.
+// version is the version of the module of the pattern if it is a single package of an external module,
+// for which the cached dependencies are reused.
+func (c *loadCache) load(cfg *packages.Config, pattern string, version string) ([]*packages.Package, *token.FileSet, error) {
+	pkey := loadKey{path: pattern, where: cfg.Dir, conf: loadConf(cfg, true)}
+	skey := loadKey{path: pattern, where: version, conf: loadConf(cfg, false)}
+	c.mu.Lock()
+	if r, ok := c.patterns[pkey]; ok {
+		c.hits++
+		c.mu.Unlock()
+		return r.pkgs, r.fset, nil
+	}
+	if r, ok := c.packages[skey]; ok && !cfg.Tests {
+		c.hits++
+		c.mu.Unlock()
+		return r.pkgs, r.fset, nil
+	}
+	c.loads++
+	c.mu.Unlock()
+
+	pkgs, err := packages.Load(cfg, pattern)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.patterns[pkey] = loadResult{pkgs: pkgs, fset: cfg.Fset}
+	conf := skey.conf
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		// only the packages with the syntax and types info can be parsed
+		if len(pkg.Syntax) == 0 || pkg.TypesInfo == nil || IsTestPackage(pkg.ID) || strings.Contains(pkg.ID, " [") {
+			return
+		}
+		key := loadKey{path: pkg.ID, where: moduleVersion(pkg.Module), conf: conf}
+		if _, ok := c.packages[key]; !ok {
+			c.packages[key] = loadResult{pkgs: []*packages.Package{pkg}, fset: cfg.Fset}
+		}
+	})
+	return pkgs, cfg.Fset, nil
+}
+
+// stats returns the count of packages.Load calls and cache hits
+func (c *loadCache) stats() (loads, hits int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.loads, c.hits
+}
+
+// invalidateLocal drops the results of the patterns and the packages without module versions,
+// i.e. those of the local modules which may have been edited. The packages of versioned modules are kept
+func (c *loadCache) invalidateLocal() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.patterns = map[loadKey]loadResult{}
+	for key := range c.packages {
+		if key.where == "" {
+			delete(c.packages, key)
+		}
+	}
+}
+
+// InvalidateLoadCache drops the cached packages of the local modules, thus the next parsing sees their changes
+func InvalidateLoadCache() {
+	globalLoadCache.invalidateLocal()
+}
@@ -0,0 +1,74 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/cloudwego/abcoder/lang/uniast"
+)
+
+func Test_loadCache(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":   "module a.b/cache\n\ngo 1.21\n",
+		"a/a.go":   "package a\n\nimport \"a.b/cache/b\"\n\nfunc A() int { return b.B() }\n",
+		"a/a_z.go": "package a\n\nfunc Z() int { return A() }\n",
+		"b/b.go":   "package b\n\nfunc B() int { return 1 }\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the packages are loaded one by one, and b is served by the dependencies of a
+	opts := Options{LoadByPackages: true, ReferCodeDepth: 1}
+	loads, hits := globalLoadCache.stats()
+	first, err := NewParser(dir, dir, opts).ParseRepo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	loads1, hits1 := globalLoadCache.stats()
+	if loads1 == loads || hits1 == hits {
+		t.Errorf("loads = %d -> %d, hits = %d -> %d", loads, loads1, hits, hits1)
+	}
+
+	// the second parsing is served by the cache
+	second, err := NewParser(dir, dir, opts).ParseRepo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	loads2, hits2 := globalLoadCache.stats()
+	if loads2 != loads1 || hits2 <= hits1 {
+		t.Errorf("loads = %d -> %d, hits = %d -> %d", loads1, loads2, hits1, hits2)
+	}
+	for _, id := range []Identity{
+		NewIdentity("a.b/cache", "a.b/cache/a", "A"),
+		NewIdentity("a.b/cache", "a.b/cache/a", "Z"),
+		NewIdentity("a.b/cache", "a.b/cache/b", "B"),
+	} {
+		f1, f2 := first.GetFunction(id), second.GetFunction(id)
+		if f1 == nil || f2 == nil || f1.Content != f2.Content {
+			t.Errorf("function %s = %v, %v", id, f1, f2)
+		}
+	}
+}
@@ -18,6 +18,8 @@ import (
 	"fmt"
 	"os"
 	"regexp"
+
+	"github.com/cloudwego/abcoder/lang/progress"
 )
 
 type Options struct {
@@ -27,6 +29,33 @@ type Options struct {
 	NeedTest       bool
 	LoadByPackages bool
 	BuildFlags     []string
+	// Tags is the build tag set to parse with, like [linux, amd64, netgo].
+	// GOOS and GOARCH values are set by env, others are passed by -tags
+	Tags []string
+	// Progress receives the progress of parsed modules, can be nil
+	Progress progress.Reporter
+	// OnlyPkgs restricts the parsing to these packages, like `a/b/c` or `a/b/...` for the subtree.
+	// Their direct dependencies in other packages of the repo are collected as well
+	OnlyPkgs []string
+	// OnlyDirs restricts the parsing to the packages under these dirs relative to the repo
+	OnlyDirs []string
+	// CallGraph is the algorithm (CallGraphCHA or CallGraphRTA) to resolve the targets of dynamic calls by SSA,
+	// which are added to Function.MethodCalls. Disabled if empty
+	CallGraph string
+	// ClosureMinLines parses the function literals spanning these lines at least as child functions of the enclosing ones,
+	// see Function.ParentFunction. Disabled if 0
+	ClosureMinLines int
+	// FetchSources downloads the sources of the external modules missing in the local caches from GOPROXY,
+	// thus their symbols can be referred (see ReferCodeDepth). The nodes are tagged by the versioned module path
+	FetchSources bool
+	// StdModPath is the module which the std packages are referred by, like `std@go1.24.5` of the shared std AST
+	// (see uniast.AsStdLib). The dependencies on the std packages are ignored if empty
+	StdModPath string
+}
+
+// partial tells if only a subset of the packages are parsed
+func (o Options) partial() bool {
+	return len(o.OnlyPkgs) > 0 || len(o.OnlyDirs) > 0
 }
 
 // type Option func(options *Options)
@@ -17,6 +17,7 @@ package parser
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"go/ast"
@@ -28,9 +29,13 @@ import (
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 
+	"github.com/cloudwego/abcoder/lang/depsrc"
 	"github.com/cloudwego/abcoder/lang/log"
+	"github.com/cloudwego/abcoder/lang/progress"
 	. "github.com/cloudwego/abcoder/lang/uniast"
 )
 
@@ -49,6 +54,8 @@ type GoParser struct {
 	exclues     []*regexp.Regexp
 	cgoPkgs     map[string]bool // CGO packages
 	workDirs    map[string]bool // directories that are in go.work scope
+	gopath      bool            // the repo has no go.mod and is parsed in GOPATH mode
+	fetcher     *depsrc.Fetcher // fetches the sources of the missing external modules, nil if disabled
 }
 
 type moduleInfo struct {
@@ -88,6 +95,10 @@ func newGoParser(name string, homePageDir string, opts Options) *GoParser {
 	if opts.Excludes != nil {
 		p.exclues = compileExcludes(opts.Excludes)
 	}
+	if opts.FetchSources {
+		p.fetcher = &depsrc.Fetcher{}
+	}
+	opts.OnlyDirs = normalizeOnlyDirs(abs, opts.OnlyDirs)
 
 	if err := p.collectGoMods(p.homePageDir); err != nil {
 		panic(err)
@@ -128,10 +139,16 @@ func (p *GoParser) collectGoMods(startDir string) error {
 	fmt.Printf("go work effective dirs: %v\n", p.workDirs)
 	deps := map[string]string{}
 	var cgoPkgs map[string]bool
+	// the std lib is parsed without the cmd module and the modules of the test data or the generators (_asm, _gen...)
+	std := isStdDir(startDir)
 	err = filepath.Walk(startDir, func(path string, info fs.FileInfo, err error) error {
 		if info.IsDir() && info.Name() == "vendor" {
 			return filepath.SkipDir
 		}
+		if info.IsDir() && std && path != startDir &&
+			(info.Name() == "cmd" || info.Name() == "testdata" || strings.HasPrefix(info.Name(), "_")) {
+			return filepath.SkipDir
+		}
 		if err != nil || !strings.HasSuffix(path, "go.mod") {
 			return nil
 		}
@@ -146,6 +163,7 @@ func (p *GoParser) collectGoMods(startDir string) error {
 			return fmt.Errorf("module path %v is not in the repo", path)
 		}
 		p.repo.Modules[name] = newModule(name, rel)
+		p.repo.Modules[name].Build = getBuildConfig(path, rel)
 		p.modules = append(p.modules, newModuleInfo(name, rel, name))
 
 		deps, cgoPkgs, err = getDeps(filepath.Dir(path), p.homePageDir, p.workDirs)
@@ -170,6 +188,19 @@ func (p *GoParser) collectGoMods(startDir string) error {
 		return err
 	}
 
+	if len(p.repo.Modules) == 0 {
+		// no go.mod, try GOPATH mode
+		if name := gopathImportPath(startDir); name != "" {
+			log.Info("no go.mod found, parse %s in GOPATH mode as %s\n", startDir, name)
+			p.gopath = true
+			p.repo.Modules[name] = newModule(name, ".")
+			p.modules = append(p.modules, newModuleInfo(name, ".", name))
+			for k, v := range gopathVendorDeps(startDir, name) {
+				p.repo.Modules[name].Dependencies[k] = v
+				p.modules = append(p.modules, newModuleInfo(k, "", v))
+			}
+		}
+	}
 	return nil
 }
 
@@ -199,33 +230,39 @@ func getDeps(dir string, homePageDir string, workDirs map[string]bool) (a map[st
 		return nil, cgoPkgs, fmt.Errorf("failed to get absolute path: %w", err)
 	}
 
-	inWorkSpace := false
-	for workDir := range workDirs {
-		if absDir == workDir || strings.HasPrefix(absDir, workDir+string(filepath.Separator)) {
-			inWorkSpace = true
-			break
-		}
+	inWorkSpace := inWorkspace(absDir, workDirs)
+
+	vendored := vendorMode(absDir)
+	if vendored {
+		log.Info("parse module %s with the vendored dependencies\n", dir)
 	}
 
-	cmd := exec.Command("go", "mod", "tidy", "-e")
-	cmd.Dir = dir
-	cmd.Env = append(os.Environ(), "GONOSUMDB=*", "GOTOOLCHAIN=local")
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "failed to execute 'go mod tidy', err: %v, output: %s, remove go.sum file reexecute\n", err, string(output))
-		os.Remove(filepath.Join(dir, "go.sum"))
+	var cmd *exec.Cmd
+	var output []byte
+	if !vendored {
+		// tidy needs the network, and makes the vendored dependencies inconsistent
 		cmd = exec.Command("go", "mod", "tidy", "-e")
 		cmd.Dir = dir
-		cmd.Env = append(os.Environ(), "GOSUMDB=off", "GOTOOLCHAIN=local")
+		cmd.Env = append(os.Environ(), "GONOSUMDB=*", "GOTOOLCHAIN=local")
 		output, err = cmd.CombinedOutput()
 		if err != nil {
-			return nil, cgoPkgs, fmt.Errorf("failed to execute 'go mod tidy', err: %v, output: %s", err, string(output))
+			fmt.Fprintf(os.Stderr, "failed to execute 'go mod tidy', err: %v, output: %s, remove go.sum file reexecute\n", err, string(output))
+			os.Remove(filepath.Join(dir, "go.sum"))
+			cmd = exec.Command("go", "mod", "tidy", "-e")
+			cmd.Dir = dir
+			cmd.Env = append(os.Environ(), "GOSUMDB=off", "GOTOOLCHAIN=local")
+			output, err = cmd.CombinedOutput()
+			if err != nil {
+				return nil, cgoPkgs, fmt.Errorf("failed to execute 'go mod tidy', err: %v, output: %s", err, string(output))
+			}
 		}
 	}
 	if hasNoDeps(filepath.Join(dir, "go.mod")) {
 		return map[string]string{}, cgoPkgs, nil
 	}
-	if inWorkSpace {
+	if vendored {
+		cmd = exec.Command("go", "list", "-e", "-json", "-mod=vendor", "all")
+	} else if inWorkSpace {
 		cmd = exec.Command("go", "list", "-e", "-json", "all")
 	} else {
 		cmd = exec.Command("go", "list", "-e", "-json", "-mod=mod", "all")
@@ -264,50 +301,69 @@ func getDeps(dir string, homePageDir string, workDirs map[string]bool) (a map[st
 			cgoPkgs[module.Path] = true
 		}
 
-		if module.Replace != nil {
-			if strings.HasPrefix(module.Replace.Path, "./") ||
-				strings.HasPrefix(module.Replace.Path, "../") ||
-				strings.HasPrefix(module.Replace.Path, "/") {
-				// local replace: only treat as a parseable module when the
-				// target lives inside the repo root. Out-of-repo replace
-				// targets cannot be walked by collectGoMods, so skip them
-				// here to avoid downstream nil-module lookups.
-				replaceAbs := module.Replace.Path
-				if !filepath.IsAbs(replaceAbs) {
-					replaceAbs = filepath.Join(dir, replaceAbs)
-				}
-				replaceAbs = filepath.Clean(replaceAbs)
-
-				rel, relErr := filepath.Rel(homePageDir, replaceAbs)
-				if relErr == nil && !strings.HasPrefix(rel, "..") {
-					deps[module.Path] = module.Path
-				}
-			} else {
-				deps[module.Path] = module.Replace.Path + "@" + module.Replace.Version
-			}
-		} else {
-			if module.Version != "" {
-				deps[module.Path] = module.Path + "@" + module.Version
-			} else {
-				// If no version, it's a local package. So we use local commit as version
-				commit, err := getCommitHash(dir)
-				if err != nil {
-					deps[module.Path] = module.Path
-				} else {
-					deps[module.Path] = module.Path + "@" + commit
-				}
+		v, ok := depPath(module.Path, module.Version, module.Replace, dir, homePageDir)
+		if !ok {
+			continue
+		}
+		if module.Replace == nil && module.Version == "" {
+			// If no version, it's a local package. So we use local commit as version
+			if commit, err := getCommitHash(dir); err == nil {
+				v = module.Path + "@" + commit
 			}
 		}
+		deps[module.Path] = v
+	}
+	if vendored {
+		vdeps, err := readVendorModules(absDir, homePageDir)
+		if err != nil {
+			return nil, cgoPkgs, err
+		}
+		// vendor/modules.txt is authoritative, even for the modules whose packages failed to list
+		for k, v := range vdeps {
+			deps[k] = v
+		}
 	}
 	return deps, cgoPkgs, nil
 }
 
+// inWorkspace tells if the dir is in the scope of go.work
+func inWorkspace(absDir string, workDirs map[string]bool) bool {
+	for workDir := range workDirs {
+		if absDir == workDir || strings.HasPrefix(absDir, workDir+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
 // ParseRepo parse the entiry repo from homePageDir recursively until end
 func (p *GoParser) ParseRepo() (Repository, error) {
+	return p.ParseRepoContext(context.Background())
+}
+
+// ParseRepoContext parses modules one by one until ctx is done.
+// Once ctx is done, it stops at the next module and returns the modules parsed so far along with ctx.Err(),
+// thus the caller can still serialize the collected symbols.
+func (p *GoParser) ParseRepoContext(ctx context.Context) (Repository, error) {
+	total := 0
+	for _, lib := range p.modules {
+		if !strings.Contains(lib.path, "@") && p.repo.Modules[lib.name] != nil {
+			total++
+		}
+	}
+	tracker := progress.NewTracker(p.opts.Progress, progress.PhaseModule, total)
+	defer tracker.Finish()
 	for _, lib := range p.modules {
 		if strings.Contains(lib.path, "@") {
 			continue
 		}
+		if err := ctx.Err(); err != nil {
+			log.Error("parsing interrupted before module %s: %v\n", lib.name, err)
+			p.attachLoadErrors()
+			p.associateStructWithMethods()
+			p.associateImplements()
+			return p.getRepo(), err
+		}
 		mod := p.repo.Modules[lib.name]
 		if mod == nil {
 			// Out-of-repo local replace target — collectGoMods didn't
@@ -317,41 +373,59 @@ func (p *GoParser) ParseRepo() (Repository, error) {
 		if err := p.ParseModule(mod, filepath.Join(p.homePageDir, mod.Dir)); err != nil {
 			return p.getRepo(), err
 		}
+		tracker.Add(lib.name)
 	}
+	p.attachLoadErrors()
 	p.associateStructWithMethods()
 	p.associateImplements()
-	fmt.Fprintf(os.Stderr, "total call packages.Load %d times\n", loadCount)
+	loads, hits := globalLoadCache.stats()
+	fmt.Fprintf(os.Stderr, "total call packages.Load %d times, %d loads served by the cache\n", loads, hits)
 	return p.getRepo(), nil
 }
 
 func (p *GoParser) ParseModule(mod *Module, dir string) (err error) {
-	// run go mod tidy before parse
-	cmd := exec.Command("go", "mod", "tidy")
-	cmd.Dir = dir
-	cmd.Env = append(os.Environ(), "GOTOOLCHAIN=local")
-	buf := bytes.NewBuffer(nil)
-	cmd.Stderr = buf
-	cmd.Stdout = buf
-	go func() {
-		sc := bufio.NewScanner(buf)
-		// scan and print
-		for sc.Scan() {
-			fmt.Fprintln(os.Stderr, sc.Text())
-		}
-	}()
-	fmt.Fprintf(os.Stderr, "running go mod tidy in %s ...\n", dir)
-	if err := cmd.Run(); err != nil {
-		fmt.Fprintf(os.Stderr, "run go mod tidy failed in %s: %v\n", dir, buf.String())
+	patterns := []string{"./..."}
+	if p.opts.partial() {
+		if patterns = p.loadPatterns(mod); len(patterns) == 0 {
+			return nil
+		}
+	}
+
+	// the vendored dependencies are not parsed as the codes of the module
+	skipVendor := p.gopath || vendorMode(dir)
+	// never tidy the std lib in GOROOT
+	if !skipVendor && mod.Name != StdModule {
+		// run go mod tidy before parse
+		cmd := exec.Command("go", "mod", "tidy")
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GOTOOLCHAIN=local")
+		buf := bytes.NewBuffer(nil)
+		cmd.Stderr = buf
+		cmd.Stdout = buf
+		go func() {
+			sc := bufio.NewScanner(buf)
+			// scan and print
+			for sc.Scan() {
+				fmt.Fprintln(os.Stderr, sc.Text())
+			}
+		}()
+		fmt.Fprintf(os.Stderr, "running go mod tidy in %s ...\n", dir)
+		if err := cmd.Run(); err != nil {
+			fmt.Fprintf(os.Stderr, "run go mod tidy failed in %s: %v\n", dir, buf.String())
+		}
 	}
 
 	filepath.Walk(dir, func(path string, info fs.FileInfo, e error) error {
-		if info != nil && info.IsDir() && filepath.Base(path) == ".git" {
+		if info != nil && info.IsDir() && (filepath.Base(path) == ".git" || skipVendor && path == filepath.Join(dir, "vendor")) {
 			return filepath.SkipDir
 		}
 		if e != nil || info.IsDir() {
 			return nil
 		}
 		rel, _ := filepath.Rel(p.homePageDir, path)
+		if p.opts.partial() && !p.selectedFile(mod, rel) {
+			return nil
+		}
 		mod.Files[rel] = NewFile(rel)
 		return nil
 	})
@@ -367,7 +441,11 @@ func (p *GoParser) ParseModule(mod *Module, dir string) (err error) {
 					return nil
 				}
 			}
-			if err := p.parsePackage(p.pkgPathFromABS(path)); err != nil {
+			pkgPath := p.pkgPathFromABS(path)
+			if p.opts.partial() && !p.selected(pkgPath) {
+				return nil
+			}
+			if err := p.parsePackage(pkgPath); err != nil {
 				errs = append(errs, err)
 			}
 			return nil
@@ -377,10 +455,71 @@ func (p *GoParser) ParseModule(mod *Module, dir string) (err error) {
 		}
 		return nil
 	} else {
-		return p.loadPackages(mod, dir, "./...")
+		for _, pattern := range patterns {
+			if err := p.loadPackages(mod, dir, pattern); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// attachLoadErrors converts the positioned packages.Load errors of modules into file diagnostics
+func (p *GoParser) attachLoadErrors() {
+	for _, mod := range p.repo.Modules {
+		for _, e := range mod.LoadErrors {
+			file, line, col := splitErrorPos(e.Pos)
+			if file == "" {
+				continue
+			}
+			if filepath.IsAbs(file) {
+				if rel, err := filepath.Rel(p.homePageDir, file); err == nil {
+					file = rel
+				}
+			}
+			f := mod.Files[file]
+			if f == nil {
+				continue
+			}
+			d := Diagnostic{
+				Severity: SeverityError,
+				Message:  e.Msg,
+				Source:   "go/packages",
+				Line:     line,
+				Column:   col,
+			}
+			if !slices.Contains(f.Diagnostics, d) {
+				f.Diagnostics = append(f.Diagnostics, d)
+			}
+		}
 	}
 }
 
+// splitErrorPos splits packages.Error.Pos like `file:line:col` or `file:line`
+func splitErrorPos(pos string) (file string, line, col int) {
+	if pos == "" || pos == "-" {
+		return "", 0, 0
+	}
+	parts := strings.Split(pos, ":")
+	nums := []int{}
+	for len(parts) > 1 && len(nums) < 2 {
+		n, err := strconv.Atoi(parts[len(parts)-1])
+		if err != nil {
+			break
+		}
+		nums = append([]int{n}, nums...)
+		parts = parts[:len(parts)-1]
+	}
+	file = strings.Join(parts, ":")
+	if len(nums) > 0 {
+		line = nums[0]
+	}
+	if len(nums) > 1 {
+		col = nums[1]
+	}
+	return
+}
+
 // getRepo return currently parsed golang AST
 // Notice: To get completely parsed repo, you'd better call goParser.ParseRepo() before this
 func (p *GoParser) getRepo() Repository {
@@ -641,9 +780,31 @@ func (p *GoParser) getModuleFromPkg(pkg PkgPath) (name string, dir string) {
 			dir = m.dir
 		}
 	}
+	if name == "" {
+		// the std packages (including the internal ones) are not prefixed by the module name
+		for _, m := range p.modules {
+			if m.name == StdModule && m.dir != "" {
+				return m.name, m.dir
+			}
+		}
+	}
 	return
 }
 
+// moduleVersion returns the version of the module which the package belongs to, empty for the modules in the repo
+func (p *GoParser) moduleVersion(pkg PkgPath) string {
+	var name, path string
+	for _, m := range p.modules {
+		if strings.HasPrefix(pkg, m.name) && len(m.name) > len(name) {
+			name, path = m.name, m.path
+		}
+	}
+	if idx := strings.LastIndex(path, "@"); idx >= 0 {
+		return path[idx+1:]
+	}
+	return ""
+}
+
 // path is absolute path
 func (p *GoParser) getModuleFromPath(path string) (name string, dir string, rel string) {
 	for _, m := range p.modules {
@@ -0,0 +1,108 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"path/filepath"
+	"strings"
+
+	. "github.com/cloudwego/abcoder/lang/uniast"
+)
+
+// normalizeOnlyDirs makes Options.OnlyDirs relative to the repo
+func normalizeOnlyDirs(homePageDir string, dirs []string) []string {
+	ret := make([]string, 0, len(dirs))
+	for _, d := range dirs {
+		if filepath.IsAbs(d) {
+			if rel, err := filepath.Rel(homePageDir, d); err == nil {
+				d = rel
+			}
+		}
+		ret = append(ret, filepath.Clean(d))
+	}
+	return ret
+}
+
+// loadPatterns returns the packages.Load patterns of the selected packages in the module,
+// empty if none of them is in the module
+func (p *GoParser) loadPatterns(mod *Module) (pats []string) {
+	for _, pkg := range p.opts.OnlyPkgs {
+		if name, _ := p.getModuleFromPkg(strings.TrimSuffix(pkg, "/...")); name == mod.Name {
+			pats = append(pats, pkg)
+		}
+	}
+	for _, d := range p.opts.OnlyDirs {
+		if rel, ok := subPath(mod.Dir, d); ok {
+			// the dir is in the module
+			pats = append(pats, "./"+filepath.ToSlash(filepath.Join(rel, "...")))
+		} else if _, ok := subPath(d, mod.Dir); ok {
+			// the module is in the dir
+			pats = append(pats, "./...")
+		}
+	}
+	return pats
+}
+
+// selected tells if the package is selected by Options.OnlyPkgs or Options.OnlyDirs.
+// The test variants of a package, like `a/b [a/b.test]` and `a/b_test [a/b.test]`, are regarded as the package itself
+func (p *GoParser) selected(pkg PkgPath) bool {
+	if i := strings.Index(pkg, " ["); i >= 0 {
+		pkg = strings.TrimSuffix(pkg[:i], "_test")
+	}
+	for _, only := range p.opts.OnlyPkgs {
+		if pkg == only {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(only, "/..."); ok && (pkg == prefix || strings.HasPrefix(pkg, prefix+"/")) {
+			return true
+		}
+	}
+	if len(p.opts.OnlyDirs) == 0 {
+		return false
+	}
+	name, dir := p.getModuleFromPkg(pkg)
+	if name == "" || dir == "" {
+		return false
+	}
+	rel := filepath.Join(dir, strings.TrimPrefix(pkg, name))
+	for _, d := range p.opts.OnlyDirs {
+		if _, ok := subPath(d, rel); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// selectedFile tells if the file relative to the repo is in a selected package of the module
+func (p *GoParser) selectedFile(mod *Module, path string) bool {
+	rel, ok := subPath(mod.Dir, filepath.Dir(path))
+	if !ok {
+		return false
+	}
+	pkg := mod.Name
+	if rel != "." {
+		pkg += "/" + filepath.ToSlash(rel)
+	}
+	return p.selected(pkg)
+}
+
+// subPath returns the relative path of path to dir, if path is dir itself or under it
+func subPath(dir, path string) (string, bool) {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return rel, true
+}
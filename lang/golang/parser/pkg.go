@@ -21,6 +21,7 @@ import (
 	"go/types"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -53,12 +54,25 @@ func (p *GoParser) parseImports(fset *token.FileSet, file []byte, mod *Module, i
 
 		// Fix: module name may also be like this?
 		if isSysPkg(importPath) {
-			// Ignoring golang standard libraries（like net/http）
-			sysImports[importAlias] = importPath
+			switch std := p.stdModPath(mod); std {
+			case "":
+				// Ignoring golang standard libraries（like net/http）
+				sysImports[importAlias] = importPath
+			case mod.Name:
+				projectImports[importAlias] = importPath
+			default:
+				thirdPartyImports[importAlias] = [2]string{std, importPath}
+			}
 		} else {
 			match, path := matchMod(importPath, mod.Dependencies)
+			if match == "" && p.gopath {
+				// the vendored packages are imported by the path under vendor
+				if match, path = matchMod(mod.Name+"/vendor/"+importPath, mod.Dependencies); match != "" {
+					importPath = mod.Name + "/vendor/" + importPath
+				}
+			}
 			if match == "" {
-				if !strings.HasPrefix(importPath, mod.Name) {
+				if !strings.HasPrefix(importPath, mod.Name) && mod.Name != StdModule {
 					fmt.Fprintf(os.Stderr, "package %s not found mod", importPath)
 				}
 				projectImports[importAlias] = importPath
@@ -131,6 +145,43 @@ func (p *GoParser) associateImplements() {
 	}
 }
 
+// collectUsedInterfaces registers the interfaces of other packages used by pkg, including the std and third-party ones
+// which are never parsed (like io.Reader), thus associateImplements can link the internal types to them too
+func (p *GoParser) collectUsedInterfaces(mod *Module, pkg *packages.Package) {
+	if pkg.TypesInfo == nil {
+		return
+	}
+	for _, obj := range pkg.TypesInfo.Uses {
+		tn, ok := obj.(*types.TypeName)
+		if !ok || tn.Pkg() == nil || tn.Pkg() == pkg.Types || tn.IsAlias() {
+			continue
+		}
+		iface, ok := tn.Type().Underlying().(*types.Interface)
+		if !ok || iface.Empty() {
+			continue
+		}
+		if _, ok := p.interfaces[iface]; ok {
+			continue
+		}
+		p.interfaces[iface] = p.externalIdentity(mod, tn.Pkg().Path(), tn.Name())
+	}
+}
+
+// externalIdentity returns the identity of a node defined out of the package being parsed,
+// the same as the one referenced by the dependencies, see fileContext.getTypeinfo
+func (p *GoParser) externalIdentity(mod *Module, pkgPath PkgPath, name string) Identity {
+	if isSysPkg(pkgPath) {
+		return NewIdentity(p.stdModPath(mod), pkgPath, name)
+	}
+	if m, _ := p.getModuleFromPkg(pkgPath); m != "" {
+		return NewIdentity(m, pkgPath, name)
+	}
+	if m, _ := matchMod(pkgPath, mod.Dependencies); m != "" {
+		return NewIdentity(m, pkgPath, name)
+	}
+	return Identity{PkgPath: pkgPath, Name: name}
+}
+
 func (p *GoParser) ParsePackage(pkgPath PkgPath) (Repository, error) {
 	if err := p.parsePackage(pkgPath); err != nil {
 		return Repository{}, err
@@ -163,59 +214,54 @@ func (p *GoParser) parsePackage(pkgPath PkgPath) (err error) {
 	return p.loadPackages(lib, filepath.Join(p.homePageDir, lib.Dir), pkgPath)
 }
 
-var loadCount = 0
-
 func (p *GoParser) loadPackages(mod *Module, dir string, pkgPath PkgPath) (err error) {
 	if mm := p.repo.Modules[mod.Name]; mm != nil && (*mm).Packages[pkgPath] != nil {
 		return nil
 	}
 	fmt.Fprintf(os.Stderr, "[loadPackages] mod: %s, dir: %s, pkgPath: %s\n", mod.Name, dir, pkgPath)
-	fset := token.NewFileSet()
-	loadCount++
 
-	baseOpts := packages.NeedFiles | packages.NeedSyntax | packages.NeedTypes | packages.NeedTypesInfo | packages.NeedImports
-	if p.opts.ReferCodeDepth != 0 {
+	// the tests of external modules are not parsed, thus their packages can be served by the cached dependencies
+	needTest := p.opts.NeedTest && !mod.IsExternal()
+	baseOpts := packages.NeedFiles | packages.NeedSyntax | packages.NeedTypes | packages.NeedTypesInfo | packages.NeedImports | packages.NeedModule
+	if p.opts.ReferCodeDepth != 0 || p.opts.partial() {
 		baseOpts |= packages.NeedDeps
 	}
-	if p.opts.NeedTest {
+	if needTest {
 		baseOpts |= packages.NeedForTest
 	}
 
-	cfg := &packages.Config{
-		Mode:       baseOpts,
-		Fset:       fset,
-		Dir:        dir,
-		Env:        append(os.Environ(), "GOSUMDB=off"),
-		BuildFlags: p.opts.BuildFlags,
-	}
-
-	if p.opts.NeedTest {
-		cfg.Tests = true
-	}
-
 	hasCGO := false
 	if len(p.cgoPkgs) > 0 {
 		hasCGO = true
+		baseOpts |= packages.NeedCompiledGoFiles
 	}
 
-	var pkgs []*packages.Package
+	tagEnv, tagFlags := tagsEnv(p.opts.Tags)
+	if p.gopath {
+		tagEnv = append(tagEnv, "GO111MODULE=off")
+	} else if goFlagsMod() == "" && vendorMode(dir) && !inWorkspace(dir, p.workDirs) {
+		tagFlags = append(tagFlags, "-mod=vendor")
+	}
+	cfg := &packages.Config{
+		Mode:       baseOpts,
+		Fset:       token.NewFileSet(),
+		Dir:        dir,
+		Env:        append(append(os.Environ(), "GOSUMDB=off"), tagEnv...),
+		BuildFlags: append(slices.Clip(p.opts.BuildFlags), tagFlags...),
+		Tests:      needTest,
+	}
 
-	if hasCGO {
-		baseOpts |= packages.NeedCompiledGoFiles
-		cfg.Mode = baseOpts
-		pkgs, err = packages.Load(cfg, pkgPath)
-		if err != nil {
+	pkgs, fset, err := globalLoadCache.load(cfg, pkgPath, p.moduleVersion(pkgPath))
+	if err != nil {
+		if hasCGO {
 			return fmt.Errorf("load path '%s' with CGO failed: %v", dir, err)
 		}
-	} else {
-		pkgs, err = packages.Load(cfg, pkgPath)
-		if err != nil {
-			return fmt.Errorf("load path '%s' failed: %v", dir, err)
-		}
+		return fmt.Errorf("load path '%s' failed: %v", dir, err)
 	}
 
 	fmt.Fprintf(os.Stderr, "[loadPackages] mod: %s, dir: %s, pkgPath: %s, hasCGO: %v\n", mod.Name, dir, pkgPath, hasCGO)
 
+	var parsed []*packages.Package
 	for _, pkg := range pkgs {
 		// The package may have been pre-parsed by referCodes for cross-module
 		// references (only Functions populated, no File-level Package/Imports).
@@ -246,6 +292,11 @@ func (p *GoParser) loadPackages(mod *Module, dir string, pkgPath PkgPath) (err e
 					fmt.Fprintf(os.Stderr, "filename is empty, pkg: %s\n", pkg.ID)
 					continue
 				}
+				// cgo files not detected by the dependencies are parsed from the build cache too,
+				// and their positions are adjusted to the original file by line directives
+				if tf := fset.File(file.Pos()); tf != nil && !strings.HasSuffix(tf.Name(), ".go") {
+					filePath = tf.Name()
+				}
 			}
 			var skip bool
 			for _, exclude := range p.exclues {
@@ -269,6 +320,8 @@ func (p *GoParser) loadPackages(mod *Module, dir string, pkgPath PkgPath) (err e
 				pkgTypeInfo:    pkg.TypesInfo,
 				deps:           pkg.Imports,
 				collectComment: p.opts.CollectComment,
+				otherFiles:     pkg.OtherFiles,
+				stdMod:         p.stdModPath(mod),
 			}
 			imports, err := p.parseImports(ctx.fset, ctx.bs, mod, file.Imports)
 			if err != nil {
@@ -284,6 +337,11 @@ func (p *GoParser) loadPackages(mod *Module, dir string, pkgPath PkgPath) (err e
 			if f.Package == "" {
 				f.Package = pkg.ID
 				f.Imports = imports.Origins
+				f.BuildConstraint = buildConstraint(file)
+				f.IsTest = isTestFile(relpath)
+				if len(p.opts.Tags) > 0 {
+					f.Variants = []string{strings.Join(p.opts.Tags, ",")}
+				}
 			}
 			// Skip duplicate function body parsing when package was pre-parsed.
 			if alreadyParsed {
@@ -296,6 +354,7 @@ func (p *GoParser) loadPackages(mod *Module, dir string, pkgPath PkgPath) (err e
 		if alreadyParsed {
 			continue
 		}
+		parsed = append(parsed, pkg)
 		if obj := mod.Packages[pkg.ID]; obj != nil {
 			// obj.Dependencies = make([]PkgPath, 0, len(pkg.Imports))
 			// for _, imp := range pkg.Imports {
@@ -314,6 +373,16 @@ func (p *GoParser) loadPackages(mod *Module, dir string, pkgPath PkgPath) (err e
 		}
 		mod.LoadErrors = append(mod.LoadErrors, pkg.Errors...)
 	}
+	for _, pkg := range parsed {
+		p.linkIndirectCalls(mod, pkg)
+		p.collectUsedInterfaces(mod, pkg)
+		collectInitOrder(mod, pkg)
+	}
+	if p.opts.CallGraph != "" && len(parsed) > 0 {
+		if err := p.linkDynamicCalls(mod, parsed); err != nil {
+			return err
+		}
+	}
 	return
 }
 
@@ -20,6 +20,8 @@ import (
 	"go/parser"
 	"go/token"
 	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
 
 	"github.com/cloudwego/abcoder/lang/testutils"
@@ -107,7 +109,7 @@ func Test_goParser_ParseDirs(t *testing.T) {
 			if err != nil {
 				t.Fatal(err)
 			}
-			if err := os.WriteFile("golang.json", out, 0644); err != nil {
+			if err := os.WriteFile(filepath.Join(t.TempDir(), "golang.json"), out, 0644); err != nil {
 				t.Fatal(err)
 			}
 		})
@@ -149,6 +151,101 @@ func Test_goParser_GlobalFuncVar_IsInvoked(t *testing.T) {
 	}
 }
 
+func Test_goParser_StructFields(t *testing.T) {
+	dir := t.TempDir()
+	src := `package model
+
+import "time"
+
+type Base struct{}
+
+type User struct {
+	*Base
+	// ID is the primary key
+	ID         int64     ` + "`json:\"id\" gorm:\"column:user_id\"`" + `
+	Name, Nick string    // display names
+	Created    time.Time ` + "`json:\"created,omitempty\"`" + `
+}
+`
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module a.b/model\n\ngo 1.21\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "model.go"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	p := NewParser(dir, dir, Options{CollectComment: true})
+	repo, err := p.ParseRepo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := repo.GetType(NewIdentity("a.b/model", "a.b/model", "User"))
+	if user == nil {
+		t.Fatal("type User not found")
+	}
+	base := NewIdentity("a.b/model", "a.b/model", "Base")
+	tm := Identity{PkgPath: "time", Name: "Time"}
+	want := []Field{
+		{Name: "Base", Type: "*Base", TypeId: &base, Embedded: true, Line: 8},
+		{Name: "ID", Type: "int64", Tag: `json:"id" gorm:"column:user_id"`, Doc: "ID is the primary key", Line: 10},
+		{Name: "Name", Type: "string", Doc: "display names", Line: 11},
+		{Name: "Nick", Type: "string", Doc: "display names", Line: 11},
+		{Name: "Created", Type: "time.Time", TypeId: &tm, Tag: `json:"created,omitempty"`, Line: 12},
+	}
+	if !reflect.DeepEqual(user.Fields, want) {
+		t.Errorf("Fields = %+v, want %+v", user.Fields, want)
+	}
+	if f := user.FieldByTag("gorm", "column:user_id"); f == nil || f.Name != "ID" {
+		t.Errorf("FieldByTag(gorm) = %+v, want ID", f)
+	}
+	if f := user.FieldByTag("json", "created"); f == nil || f.Name != "Created" {
+		t.Errorf("FieldByTag(json) = %+v, want Created", f)
+	}
+}
+
+func Test_goParser_InterfaceEmbeddedMethods(t *testing.T) {
+	dir := t.TempDir()
+	src := `package rw
+
+import "io"
+
+type Reader interface {
+	Read() string
+}
+
+type ReadCloser interface {
+	Reader
+	io.Closer
+	Flush() error
+}
+`
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module a.b/rw\n\ngo 1.21\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "rw.go"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	repo, err := NewParser(dir, dir, Options{}).ParseRepo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc := repo.GetType(NewIdentity("a.b/rw", "a.b/rw", "ReadCloser"))
+	if rc == nil {
+		t.Fatal("type ReadCloser not found")
+	}
+	want := map[string]Identity{
+		"Flush": NewIdentity("a.b/rw", "a.b/rw", "ReadCloser.Flush"),
+		"Read":  NewIdentity("a.b/rw", "a.b/rw", "Reader.Read"),
+		"Close": {PkgPath: "io", Name: "Closer.Close"},
+	}
+	if !reflect.DeepEqual(rc.Methods, want) {
+		t.Errorf("Methods = %v, want %v", rc.Methods, want)
+	}
+	flush := repo.GetFunction(want["Flush"])
+	if flush == nil || flush.Receiver == nil || flush.Receiver.Type != rc.Identity {
+		t.Errorf("receiver of Flush = %+v, want %v", flush, rc.Identity)
+	}
+}
+
 func findDep(deps []Dependency, id Identity) *Dependency {
 	for i := range deps {
 		if deps[i].Identity == id {
@@ -269,3 +366,94 @@ func Test_matchMod(t *testing.T) {
 		})
 	}
 }
+
+func Test_goParser_TestLinks(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":  "module a.b/calc\n\ngo 1.21\n",
+		"calc.go": "package calc\n\ntype Adder struct{}\n\nfunc (Adder) Add(a, b int) int { return a + b }\n\nfunc Sum(xs ...int) int {\n\ts := 0\n\tfor _, x := range xs {\n\t\ts = Adder{}.Add(s, x)\n\t}\n\treturn s\n}\n",
+		"calc_test.go": "package calc\n\nimport \"testing\"\n\nfunc TestSum(t *testing.T) { check(t, Sum(1, 2), 3) }\n\n" +
+			"func TestAdder_Add(t *testing.T) { check(t, Adder{}.Add(1, 1), 2) }\n\n" +
+			"func check(t *testing.T, got, want int) {\n\tif got != want {\n\t\tt.Fatal(got)\n\t}\n}\n",
+		"ext_test.go": "package calc_test\n\nimport (\n\t\"testing\"\n\n\t\"a.b/calc\"\n)\n\nfunc BenchmarkSum(b *testing.B) { calc.Sum(1) }\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	repo, err := NewParser(dir, dir, Options{NeedTest: true}).ParseRepo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mod := repo.Modules["a.b/calc"]
+	if !mod.Files["calc_test.go"].IsTest || mod.Files["calc.go"].IsTest {
+		t.Errorf("IsTest of files are wrong")
+	}
+
+	links := map[string][]string{}
+	for _, name := range []string{"Sum", "Adder.Add"} {
+		for _, l := range repo.TestsOf(NewIdentity("a.b/calc", "a.b/calc", name)) {
+			links[name] = append(links[name], l.Test.Name)
+		}
+	}
+	want := map[string][]string{
+		"Sum":       {"TestSum", "BenchmarkSum"},
+		"Adder.Add": {"TestAdder_Add"},
+	}
+	if !reflect.DeepEqual(links, want) {
+		t.Errorf("tests = %v, want %v", links, want)
+	}
+	for _, pkg := range mod.Packages {
+		if fn := pkg.Functions["check"]; fn != nil && fn.IsTest {
+			t.Error("test helper check() should not be a test")
+		}
+	}
+}
+
+func Test_goParser_Partial(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":         "module a.b/mono\n\ngo 1.21\n",
+		"svc/a/a.go":     "package a\n\nimport \"a.b/mono/lib\"\n\nfunc Serve() int { return lib.Helper() }\n",
+		"svc/b/b.go":     "package b\n\nfunc Other() int { return 2 }\n",
+		"lib/lib.go":     "package lib\n\nfunc Helper() int { return 1 }\n\nfunc Unused() int { return 3 }\n",
+		"lib/lib_doc.md": "lib\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, opts := range map[string]Options{
+		"pkg": {OnlyPkgs: []string{"a.b/mono/svc/a"}},
+		"dir": {OnlyDirs: []string{"svc/a"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			repo, err := NewParser(dir, dir, opts).ParseRepo()
+			if err != nil {
+				t.Fatal(err)
+			}
+			mod := repo.Modules["a.b/mono"]
+			if repo.GetFunction(NewIdentity("a.b/mono", "a.b/mono/svc/a", "Serve")) == nil {
+				t.Fatal("selected function Serve is not parsed")
+			}
+			if repo.GetFunction(NewIdentity("a.b/mono", "a.b/mono/lib", "Helper")) == nil {
+				t.Error("direct dependency Helper is not collected")
+			}
+			if repo.GetFunction(NewIdentity("a.b/mono", "a.b/mono/lib", "Unused")) != nil {
+				t.Error("unused function of unselected package should not be parsed")
+			}
+			if mod.Packages["a.b/mono/svc/b"] != nil || mod.Files["svc/b/b.go"] != nil {
+				t.Error("unselected package svc/b should not be parsed")
+			}
+			if mod.Files["svc/a/a.go"] == nil || mod.Files["lib/lib.go"] == nil || mod.Files["lib/lib_doc.md"] != nil {
+				t.Errorf("files = %v", mod.Files)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	. "github.com/cloudwego/abcoder/lang/uniast"
+)
+
+// Toolchain returns the source dir of the std lib (GOROOT/src) and the version of the go toolchain, like go1.24.5
+func Toolchain() (src string, version string, err error) {
+	out, err := exec.Command("go", "env", "GOROOT", "GOVERSION").Output()
+	if err != nil {
+		return "", "", fmt.Errorf("go env: %w", err)
+	}
+	lines := strings.Fields(string(out))
+	if len(lines) != 2 {
+		return "", "", fmt.Errorf("unexpected output of go env: %q", out)
+	}
+	return filepath.Join(lines[0], "src"), lines[1], nil
+}
+
+// stdModPath returns the module which the std packages are referred by from the module, empty if they are ignored.
+// They are in the module itself when parsing the std lib, see Options.StdModPath
+func (p *GoParser) stdModPath(mod *Module) string {
+	if mod.Name == StdModule {
+		return mod.Name
+	}
+	return p.opts.StdModPath
+}
+
+// isStdDir tells if the dir is the root of the std lib (GOROOT/src)
+func isStdDir(dir string) bool {
+	name, err := getModuleName(filepath.Join(dir, "go.mod"))
+	return err == nil && name == StdModule
+}
@@ -27,6 +27,7 @@ import (
 	"runtime"
 	"strings"
 	"sync"
+	"unicode"
 
 	"github.com/Knetic/govaluate"
 	. "github.com/cloudwego/abcoder/lang/uniast"
@@ -41,6 +42,21 @@ func shouldIgnoreFile(path string) bool {
 	return !strings.Contains(path, ".go") || strings.Contains(path, "_test.go")
 }
 
+func isTestFile(path string) bool {
+	return strings.HasSuffix(path, "_test.go")
+}
+
+// isTestFunc tells if the function name is a test, benchmark, fuzz test or example recognized by `go test`
+func isTestFunc(name string) bool {
+	for _, prefix := range []string{"Test", "Benchmark", "Fuzz", "Example"} {
+		if rest, ok := strings.CutPrefix(name, prefix); ok {
+			// `Testing` is not a test
+			return rest == "" || !unicode.IsLower(rune(rest[0]))
+		}
+	}
+	return false
+}
+
 type cache map[interface{}]bool
 
 func (c cache) Visited(val interface{}) bool {
@@ -181,6 +197,43 @@ func getModuleName(modFilePath string) (string, error) {
 	return modfile.ModulePath(content), nil
 }
 
+// getBuildConfig reads the build configuration of the module from its go.mod,
+// returns nil if the file can't be parsed
+func getBuildConfig(modFilePath string, rel string) *BuildConfig {
+	content, err := os.ReadFile(modFilePath)
+	if err != nil {
+		return nil
+	}
+	modf, err := modfile.Parse(modFilePath, content, nil)
+	if err != nil {
+		return nil
+	}
+	ret := &BuildConfig{Manifest: filepath.Join(rel, filepath.Base(modFilePath))}
+	if modf.Go != nil {
+		ret.LanguageVersion = modf.Go.Version
+	}
+	if modf.Toolchain != nil {
+		ret.Toolchain = modf.Toolchain.Name
+	}
+	for _, r := range modf.Replace {
+		ret.Replaces = append(ret.Replaces, Replace{
+			Old: modVersionString(r.Old.Path, r.Old.Version),
+			New: modVersionString(r.New.Path, r.New.Version),
+		})
+	}
+	for _, e := range modf.Exclude {
+		ret.Excludes = append(ret.Excludes, modVersionString(e.Mod.Path, e.Mod.Version))
+	}
+	return ret
+}
+
+func modVersionString(path, version string) string {
+	if version == "" {
+		return path
+	}
+	return path + "@" + version
+}
+
 func isGoBuiltins(name string) bool {
 	switch name {
 	case "append", "cap", "close", "complex", "copy", "delete", "imag", "len", "make", "new", "panic", "print", "println", "real", "recover":
@@ -318,6 +371,17 @@ func isUpperCase(c byte) bool {
 	return c >= 'A' && c <= 'Z'
 }
 
+// visibility returns the visibility of a (method) name: exported names are public, others are package-scoped
+func visibility(name string) Visibility {
+	if ind := strings.LastIndexByte(name, '.'); ind != -1 && ind+1 < len(name) {
+		name = name[ind+1:]
+	}
+	if isUpperCase(name[0]) {
+		return VisibilityPublic
+	}
+	return VisibilityPackage
+}
+
 var commitHashCache sync.Map
 
 func getCommitHash(dir string) (string, error) {
@@ -28,6 +28,8 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/cloudwego/abcoder/lang/uniast"
 )
 
 func getTypeForTest(t *testing.T, src, name string) types.Type {
@@ -306,3 +308,70 @@ func Test_getCommitHash(t *testing.T) {
 	require.True(t, ok)
 	require.Equal(t, hash1, cached)
 }
+
+func Test_splitErrorPos(t *testing.T) {
+	tests := []struct {
+		pos       string
+		file      string
+		line, col int
+	}{
+		{"/a/b.go:12:5", "/a/b.go", 12, 5},
+		{"/a/b.go:12", "/a/b.go", 12, 0},
+		{"C:/a/b.go:3:1", "C:/a/b.go", 3, 1},
+		{"-", "", 0, 0},
+		{"", "", 0, 0},
+	}
+	for _, tt := range tests {
+		file, line, col := splitErrorPos(tt.pos)
+		assert.Equal(t, tt.file, file, tt.pos)
+		assert.Equal(t, tt.line, line, tt.pos)
+		assert.Equal(t, tt.col, col, tt.pos)
+	}
+}
+
+func TestGoParser_LoadErrorDiagnostics(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(dir+"/go.mod", []byte("module a.b/broken\n\ngo 1.21\n"), 0644))
+	require.NoError(t, os.WriteFile(dir+"/a.go", []byte("package broken\n\nfunc A() int {\n\treturn \"a\"\n}\n"), 0644))
+
+	p := NewParser(dir, dir, Options{})
+	repo, err := p.ParseRepo()
+	require.NoError(t, err)
+	f := repo.Modules["a.b/broken"].Files["a.go"]
+	require.NotNil(t, f)
+	require.Len(t, f.Diagnostics, 1)
+	assert.Equal(t, uniast.SeverityError, f.Diagnostics[0].Severity)
+	assert.Equal(t, 4, f.Diagnostics[0].Line)
+	assert.Equal(t, 9, f.Diagnostics[0].Column)
+}
+
+func Test_getBuildConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := dir + "/go.mod"
+	require.NoError(t, os.WriteFile(path, []byte(`module example.com/a
+
+go 1.22
+
+toolchain go1.22.4
+
+require example.com/b v1.0.0
+
+replace example.com/b v1.0.0 => example.com/c v1.1.0
+
+replace example.com/d => ../d
+
+exclude example.com/e v0.1.0
+`), 0644))
+	got := getBuildConfig(path, "a")
+	assert.Equal(t, &uniast.BuildConfig{
+		Manifest:        "a/go.mod",
+		LanguageVersion: "1.22",
+		Toolchain:       "go1.22.4",
+		Replaces: []uniast.Replace{
+			{Old: "example.com/b@v1.0.0", New: "example.com/c@v1.1.0"},
+			{Old: "example.com/d", New: "../d"},
+		},
+		Excludes: []string{"example.com/e@v0.1.0"},
+	}, got)
+	assert.Nil(t, getBuildConfig(dir+"/missing.mod", "."))
+}
@@ -0,0 +1,133 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"go/ast"
+	"go/build/constraint"
+	"strings"
+
+	. "github.com/cloudwego/abcoder/lang/uniast"
+)
+
+// ExtraKey_BuildVariants records where a node is defined under each build tag set,
+// if it is defined in different files among the tag sets. The value is map[tags]FileLine
+const ExtraKey_BuildVariants = "BuildVariants"
+
+// copied from go/build/syslist.go
+var (
+	knownOS = map[string]bool{
+		"aix": true, "android": true, "darwin": true, "dragonfly": true, "freebsd": true,
+		"hurd": true, "illumos": true, "ios": true, "js": true, "linux": true, "nacl": true,
+		"netbsd": true, "openbsd": true, "plan9": true, "solaris": true, "wasip1": true,
+		"windows": true, "zos": true,
+	}
+	knownArch = map[string]bool{
+		"386": true, "amd64": true, "amd64p32": true, "arm": true, "armbe": true, "arm64": true,
+		"arm64be": true, "loong64": true, "mips": true, "mipsle": true, "mips64": true,
+		"mips64le": true, "mips64p32": true, "mips64p32le": true, "ppc": true, "ppc64": true,
+		"ppc64le": true, "riscv": true, "riscv64": true, "s390": true, "s390x": true,
+		"sparc": true, "sparc64": true, "wasm": true,
+	}
+)
+
+// tagsEnv splits a build tag set into GOOS/GOARCH envs and the -tags flag,
+// since GOOS and GOARCH must be set by env to exclude the files of the host platform
+func tagsEnv(tags []string) (env []string, flags []string) {
+	var others []string
+	for _, tag := range tags {
+		switch {
+		case tag == "":
+		case knownOS[tag]:
+			env = append(env, "GOOS="+tag)
+		case knownArch[tag]:
+			env = append(env, "GOARCH="+tag)
+		default:
+			others = append(others, tag)
+		}
+	}
+	if len(others) > 0 {
+		flags = append(flags, "-tags="+strings.Join(others, ","))
+	}
+	return
+}
+
+// buildConstraint returns the expression of `//go:build` line of the file, or empty if none
+func buildConstraint(f *ast.File) string {
+	for _, cg := range f.Comments {
+		if cg.Pos() >= f.Package {
+			break
+		}
+		for _, c := range cg.List {
+			if !constraint.IsGoBuild(c.Text) {
+				continue
+			}
+			if expr, err := constraint.Parse(c.Text); err == nil {
+				return expr.String()
+			}
+		}
+	}
+	return ""
+}
+
+// MergeVariants merges the repos parsed under different build tag sets into the first one.
+// Files are marked with the tag sets under which they are compiled.
+// If a node is defined in different files among the tag sets, the first one is kept,
+// and all the definitions are recorded into its ExtraKey_BuildVariants.
+func MergeVariants(variants []string, repos []Repository) Repository {
+	dst := repos[0]
+	// the tag set where each node of dst comes from, default to variants[0]
+	origin := map[Identity]string{}
+	for i := 1; i < len(repos); i++ {
+		for name, src := range repos[i].Modules {
+			mod := dst.Modules[name]
+			if mod == nil {
+				dst.Modules[name] = src
+				continue
+			}
+			mergeModule(mod, src, variants[0], variants[i], origin)
+		}
+	}
+	return dst
+}
+
+func mergeModule(dst, src *Module, first, variant string, origin map[Identity]string) {
+	MergeModule(dst, src, func(d *MergedNode, s MergedNode) bool {
+		if d == nil {
+			origin[s.Identity] = variant
+		} else if d.File != s.File {
+			recordVariant(d.Node.(extraNode), d.Identity, d.FileLine, s.FileLine, first, variant, origin)
+		}
+		return false
+	})
+}
+
+type extraNode interface {
+	GetExtra(key string) any
+	SetExtra(key string, value any)
+}
+
+func recordVariant(node extraNode, id Identity, kept, fl FileLine, first, variant string, origin map[Identity]string) {
+	vs, _ := node.GetExtra(ExtraKey_BuildVariants).(map[string]FileLine)
+	if vs == nil {
+		from, ok := origin[id]
+		if !ok {
+			from = first
+		}
+		vs = map[string]FileLine{from: kept}
+		node.SetExtra(ExtraKey_BuildVariants, vs)
+	}
+	vs[variant] = fl
+}
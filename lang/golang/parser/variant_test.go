@@ -0,0 +1,89 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	. "github.com/cloudwego/abcoder/lang/uniast"
+)
+
+func Test_tagsEnv(t *testing.T) {
+	env, flags := tagsEnv([]string{"linux", "arm64", "netgo", "", "integration"})
+	if want := []string{"GOOS=linux", "GOARCH=arm64"}; !reflect.DeepEqual(env, want) {
+		t.Errorf("env = %v, want %v", env, want)
+	}
+	if want := []string{"-tags=netgo,integration"}; !reflect.DeepEqual(flags, want) {
+		t.Errorf("flags = %v, want %v", flags, want)
+	}
+}
+
+func Test_goParser_BuildVariants(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":       "module a.b/sys\n\ngo 1.21\n",
+		"sys.go":       "package sys\n\nfunc Name() string { return name() }\n",
+		"sys_linux.go": "//go:build linux\n\npackage sys\n\nfunc name() string { return \"linux\" }\n",
+		"sys_darwin.go": "//go:build darwin && !ios\n\npackage sys\n\nfunc name() string { return \"darwin\" }\n\n" +
+			"func Darwin() {}\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	variants := []string{"linux,amd64", "darwin,arm64"}
+	var repos []Repository
+	for _, tags := range variants {
+		repo, err := NewParser(dir, dir, Options{Tags: strings.Split(tags, ",")}).ParseRepo()
+		if err != nil {
+			t.Fatal(err)
+		}
+		repos = append(repos, repo)
+	}
+
+	linux := repos[0].Modules["a.b/sys"]
+	if f := linux.Files["sys_linux.go"]; f == nil || f.BuildConstraint != "linux" || !reflect.DeepEqual(f.Variants, []string{"linux,amd64"}) {
+		t.Errorf("linux file = %+v", f)
+	}
+	if f := linux.Files["sys_darwin.go"]; f == nil || f.Package != "" {
+		t.Errorf("darwin file should not be compiled under linux: %+v", f)
+	}
+
+	repo := MergeVariants(variants, repos)
+	mod := repo.Modules["a.b/sys"]
+	if f := mod.Files["sys.go"]; !reflect.DeepEqual(f.Variants, variants) {
+		t.Errorf("variants of sys.go = %v, want %v", f.Variants, variants)
+	}
+	if f := mod.Files["sys_darwin.go"]; f.Package != "a.b/sys" || f.BuildConstraint != "darwin && !ios" || !reflect.DeepEqual(f.Variants, []string{"darwin,arm64"}) {
+		t.Errorf("darwin file = %+v", f)
+	}
+	if repo.GetFunction(NewIdentity("a.b/sys", "a.b/sys", "Darwin")) == nil {
+		t.Error("function Darwin of darwin variant is not merged")
+	}
+	name := repo.GetFunction(NewIdentity("a.b/sys", "a.b/sys", "name"))
+	if name == nil || name.File != "sys_linux.go" {
+		t.Fatalf("name() = %+v, want the one of the first variant", name)
+	}
+	vs, _ := name.GetExtra(ExtraKey_BuildVariants).(map[string]FileLine)
+	if len(vs) != 2 || vs["linux,amd64"].File != "sys_linux.go" || vs["darwin,arm64"].File != "sys_darwin.go" {
+		t.Errorf("build variants of name() = %+v", vs)
+	}
+}
@@ -0,0 +1,178 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/build"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// goFlagsMod returns the -mod flag given by GOFLAGS, like `vendor`, `mod` or `readonly`
+func goFlagsMod() string {
+	for _, f := range strings.Fields(os.Getenv("GOFLAGS")) {
+		f = strings.TrimPrefix(f, "-")
+		if v, ok := strings.CutPrefix(f, "-mod="); ok {
+			return v
+		} else if v, ok := strings.CutPrefix(f, "mod="); ok {
+			return v
+		}
+	}
+	return ""
+}
+
+// vendorMode tells if the module in dir builds with its vendored dependencies,
+// i.e. GOFLAGS=-mod=vendor, or vendor/modules.txt exists and GOFLAGS doesn't give another -mod as the go command does.
+// The dependencies are resolved by vendor/modules.txt instead of `go mod tidy` and `go list -mod=mod`,
+// thus the repo is parsed offline
+func vendorMode(dir string) bool {
+	if mod := goFlagsMod(); mod != "" {
+		return mod == "vendor"
+	}
+	_, err := os.Stat(filepath.Join(dir, "vendor", "modules.txt"))
+	return err == nil
+}
+
+// readVendorModules returns the module path => path@version of the modules vendored in dir, see parseVendorModules
+func readVendorModules(dir string, homePageDir string) (map[string]string, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "vendor", "modules.txt"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vendor/modules.txt, run 'go mod vendor' first: %w", err)
+	}
+	return parseVendorModules(data, dir, homePageDir), nil
+}
+
+// parseVendorModules parses vendor/modules.txt, whose module lines are like
+//
+//	# github.com/pkg/errors v0.9.1
+//	# golang.org/x/net v0.1.0 => golang.org/x/net v0.2.0
+//	# example.com/local v0.0.0 => ../local
+//
+// the replacements are resolved as `go list` ones, see depPath
+func parseVendorModules(data []byte, dir string, homePageDir string) map[string]string {
+	deps := map[string]string{}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		line, ok := strings.CutPrefix(sc.Text(), "# ")
+		if !ok {
+			// `## explicit` and the package lines
+			continue
+		}
+		mod, rep, replaced := strings.Cut(line, " => ")
+		fs := strings.Fields(mod)
+		if len(fs) == 0 {
+			continue
+		}
+		path, version := fs[0], ""
+		if len(fs) > 1 {
+			version = fs[1]
+		}
+		var r *replace
+		if replaced {
+			rs := strings.Fields(rep)
+			if len(rs) == 0 {
+				continue
+			}
+			r = &replace{Path: rs[0]}
+			if len(rs) > 1 {
+				r.Version = rs[1]
+			}
+		}
+		if v, ok := depPath(path, version, r, dir, homePageDir); ok {
+			deps[path] = v
+		}
+	}
+	return deps
+}
+
+// depPath returns the path@version identifying the dependency module.
+// A module replaced by a local dir is identified by its path if the dir is in the repo, otherwise ok is false
+// since it can't be parsed.
+// The version is empty for a module in the workspace, which is left to the caller
+func depPath(path string, version string, rep *replace, dir string, homePageDir string) (ret string, ok bool) {
+	if rep == nil {
+		if version == "" {
+			return path, true
+		}
+		return path + "@" + version, true
+	}
+	if strings.HasPrefix(rep.Path, "./") || strings.HasPrefix(rep.Path, "../") || strings.HasPrefix(rep.Path, "/") {
+		// local replace: only treat as a parseable module when the
+		// target lives inside the repo root. Out-of-repo replace
+		// targets cannot be walked by collectGoMods, so skip them
+		// here to avoid downstream nil-module lookups.
+		replaceAbs := rep.Path
+		if !filepath.IsAbs(replaceAbs) {
+			replaceAbs = filepath.Join(dir, replaceAbs)
+		}
+		replaceAbs = filepath.Clean(replaceAbs)
+		rel, err := filepath.Rel(homePageDir, replaceAbs)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			return "", false
+		}
+		return path, true
+	}
+	return rep.Path + "@" + rep.Version, true
+}
+
+// gopathImportPath returns the import path of dir under the src of a GOPATH, empty if it is not in any GOPATH
+func gopathImportPath(dir string) string {
+	for _, gp := range filepath.SplitList(build.Default.GOPATH) {
+		if gp == "" {
+			continue
+		}
+		src := filepath.Join(gp, "src")
+		if real, err := filepath.EvalSymlinks(src); err == nil {
+			src = real
+		}
+		if real, err := filepath.EvalSymlinks(dir); err == nil {
+			dir = real
+		}
+		rel, err := filepath.Rel(src, dir)
+		if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		return filepath.ToSlash(rel)
+	}
+	return ""
+}
+
+// gopathVendorDeps returns the packages vendored in the GOPATH project dir named by importPath.
+// Without a manifest to tell the modules, every top-most vendored dir containing Go files is taken as a dependency,
+// whose import path is prefixed by the vendor dir as the go command sees it, e.g.
+// `example.com/proj/vendor/github.com/pkg/errors` => `github.com/pkg/errors`
+func gopathVendorDeps(dir string, importPath string) map[string]string {
+	deps := map[string]string{}
+	vendor := filepath.Join(dir, "vendor")
+	_ = filepath.WalkDir(vendor, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() || path == vendor {
+			return nil
+		}
+		files, _ := filepath.Glob(filepath.Join(path, "*.go"))
+		if len(files) == 0 {
+			return nil
+		}
+		rel, _ := filepath.Rel(vendor, path)
+		rel = filepath.ToSlash(rel)
+		deps[importPath+"/vendor/"+rel] = rel
+		return filepath.SkipDir
+	})
+	return deps
+}
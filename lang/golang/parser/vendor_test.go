@@ -0,0 +1,166 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"go/build"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	. "github.com/cloudwego/abcoder/lang/uniast"
+)
+
+func Test_parseVendorModules(t *testing.T) {
+	home := t.TempDir()
+	dir := filepath.Join(home, "svc")
+	data := "# github.com/pkg/errors v0.9.1\n" +
+		"## explicit\n" +
+		"github.com/pkg/errors\n" +
+		"# golang.org/x/net v0.1.0 => golang.org/x/net v0.2.0\n" +
+		"golang.org/x/net/context\n" +
+		"# a.b/common v0.0.0 => ../common\n" +
+		"a.b/common\n" +
+		"# a.b/outside v0.0.0 => ../../outside\n" +
+		"# a.b/any => github.com/fork/any v1.0.0\n"
+	got := parseVendorModules([]byte(data), dir, home)
+	want := map[string]string{
+		"github.com/pkg/errors": "github.com/pkg/errors@v0.9.1",
+		"golang.org/x/net":      "golang.org/x/net@v0.2.0",
+		"a.b/common":            "a.b/common",
+		"a.b/any":               "github.com/fork/any@v1.0.0",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseVendorModules() = %v, want %v", got, want)
+	}
+}
+
+func Test_vendorMode(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("GOFLAGS", "")
+	if vendorMode(dir) {
+		t.Error("vendorMode() = true without vendor/modules.txt")
+	}
+	t.Setenv("GOFLAGS", "-mod=vendor")
+	if !vendorMode(dir) {
+		t.Error("vendorMode() = false with GOFLAGS=-mod=vendor")
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "vendor"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "vendor", "modules.txt"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GOFLAGS", "-mod=mod -trimpath")
+	if vendorMode(dir) {
+		t.Error("vendorMode() = true with GOFLAGS=-mod=mod")
+	}
+	t.Setenv("GOFLAGS", "")
+	if !vendorMode(dir) {
+		t.Error("vendorMode() = false with vendor/modules.txt")
+	}
+}
+
+func Test_goParser_Vendor(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":                           "module a.b/svc\n\ngo 1.21\n\nrequire example.com/dep v1.2.0\n",
+		"svc.go":                           "package svc\n\nimport \"example.com/dep\"\n\nfunc Run() int { return dep.Answer() }\n",
+		"vendor/modules.txt":               "# example.com/dep v1.2.0\n## explicit\nexample.com/dep\n",
+		"vendor/example.com/dep/go.mod":    "module example.com/dep\n",
+		"vendor/example.com/dep/answer.go": "package dep\n\nfunc Answer() int { return 42 }\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the dependency can't be downloaded, it must be resolved from vendor
+	t.Setenv("GOFLAGS", "")
+	t.Setenv("GOPROXY", "off")
+
+	repo, err := NewParser(dir, dir, Options{}).ParseRepo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mod := repo.Modules["a.b/svc"]
+	if mod == nil {
+		t.Fatal("module a.b/svc not found")
+	}
+	if got := mod.Dependencies["example.com/dep"]; got != "example.com/dep@v1.2.0" {
+		t.Errorf("dependency example.com/dep = %q", got)
+	}
+	for file := range mod.Files {
+		if filepath.Dir(file) != "." {
+			t.Errorf("vendored file %s is taken as the module's", file)
+		}
+	}
+	run := repo.GetFunction(NewIdentity("a.b/svc", "a.b/svc", "Run"))
+	if run == nil {
+		t.Fatal("function Run not found")
+	}
+	want := NewIdentity("example.com/dep@v1.2.0", "example.com/dep", "Answer")
+	if len(run.FunctionCalls) != 1 || run.FunctionCalls[0].Identity != want {
+		t.Errorf("calls of Run = %v, want %v", run.FunctionCalls, want)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "go.sum")); err == nil {
+		t.Error("go mod tidy is run on the vendored module")
+	}
+}
+
+func Test_goParser_GOPATH(t *testing.T) {
+	gopath := t.TempDir()
+	old := build.Default.GOPATH
+	build.Default.GOPATH = gopath
+	defer func() { build.Default.GOPATH = old }()
+
+	dir := filepath.Join(gopath, "src", "a.b", "legacy")
+	files := map[string]string{
+		"legacy.go":                          "package legacy\n\nimport \"github.com/pkg/errs\"\n\nfunc Check() error { return errs.New() }\n",
+		"vendor/github.com/pkg/errs/errs.go": "package errs\n\nfunc New() error { return nil }\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("GOPATH", gopath)
+
+	repo, err := NewParser(dir, dir, Options{}).ParseRepo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mod := repo.Modules["a.b/legacy"]
+	if mod == nil {
+		t.Fatal("module a.b/legacy not found")
+	}
+	check := repo.GetFunction(NewIdentity("a.b/legacy", "a.b/legacy", "Check"))
+	if check == nil {
+		t.Fatal("function Check not found")
+	}
+	want := NewIdentity("github.com/pkg/errs", "a.b/legacy/vendor/github.com/pkg/errs", "New")
+	if len(check.FunctionCalls) != 1 || check.FunctionCalls[0].Identity != want {
+		t.Errorf("calls of Check = %v, want %v", check.FunctionCalls, want)
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/cloudwego/abcoder/lang/uniast"
+)
+
+func Test_goParser_Visibility(t *testing.T) {
+	dir := t.TempDir()
+	src := `package vis
+
+const Max, min = 10, 1
+
+type Public struct{}
+
+type private struct{}
+
+func (Public) Get() int { return min }
+
+func (Public) set() {}
+
+func helper() {}
+`
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module a.b/vis\n\ngo 1.21\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "vis.go"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	repo, err := NewParser(dir, dir, Options{}).ParseRepo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := func(name string) Identity { return NewIdentity("a.b/vis", "a.b/vis", name) }
+	for name, want := range map[string]Visibility{
+		"Max":        VisibilityPublic,
+		"min":        VisibilityPackage,
+		"Public":     VisibilityPublic,
+		"private":    VisibilityPackage,
+		"Public.Get": VisibilityPublic,
+		"Public.set": VisibilityPackage,
+		"helper":     VisibilityPackage,
+	} {
+		var got Visibility
+		var exported bool
+		if f := repo.GetFunction(id(name)); f != nil {
+			got, exported = f.Visibility, f.Exported
+		} else if ty := repo.GetType(id(name)); ty != nil {
+			got, exported = ty.Visibility, ty.Exported
+		} else if v := repo.GetVar(id(name)); v != nil {
+			got, exported = v.Visibility, v.IsExported
+		} else {
+			t.Errorf("%s not found", name)
+			continue
+		}
+		if got != want || exported != want.IsExported() {
+			t.Errorf("%s: Visibility = %q, Exported = %v, want %q", name, got, exported, want)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package writer
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+
+	"github.com/cloudwego/abcoder/lang/uniast"
+)
+
+// AlignSpans aligns the offsets and contents of the nodes in src, the original codes of a file, in place.
+// The parser synthesizes the contents of the specs in declaration groups, like `type ` of `type ( A int )`,
+// the doc of the group, or the values of `iota` constants, which are not in the codes of the specs,
+// and doesn't count the keywords of single declarations. After aligning, the content of an unchanged node
+// equals src[StartOffset:EndOffset], thus it can be patched without touching the codes around.
+// NOTICE: the docs of the specs in groups are kept as they are in src.
+func (w *Writer) AlignSpans(src []byte, fls []uniast.FileLine, contents []string) error {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return err
+	}
+	off := func(p token.Pos) int { return fset.Position(p).Offset }
+	type decl struct {
+		start, doc int // doc is the start of the doc, -1 if none
+		spec       bool
+	}
+	// end offset => the declaration ending there
+	decls := map[int]decl{}
+	add := func(end int, start int, doc *ast.CommentGroup, spec bool) {
+		d := decl{start: start, doc: -1, spec: spec}
+		if doc != nil {
+			d.doc = off(doc.Pos())
+		}
+		decls[end] = d
+	}
+	for _, d := range f.Decls {
+		switch d := d.(type) {
+		case *ast.FuncDecl:
+			add(off(d.End()), off(d.Pos()), d.Doc, false)
+		case *ast.GenDecl:
+			if !d.Lparen.IsValid() {
+				add(off(d.End()), off(d.Pos()), d.Doc, false)
+				continue
+			}
+			for _, s := range d.Specs {
+				add(off(s.End()), off(s.Pos()), nil, true)
+			}
+		}
+	}
+
+	for i, fl := range fls {
+		d, ok := decls[fl.EndOffset]
+		if !ok {
+			continue
+		}
+		content := contents[i]
+		if !d.spec {
+			fls[i].StartOffset = d.start
+			if d.doc >= 0 && isComment(content) {
+				fls[i].StartOffset = d.doc
+			}
+			continue
+		}
+		fls[i].StartOffset = d.start
+		content = trimDoc(content)
+		for _, kw := range []string{"type ", "var ", "const "} {
+			if strings.HasPrefix(content, kw) {
+				// the doc of the spec may follow the keyword
+				content = trimDoc(content[len(kw):])
+				break
+			}
+		}
+		// the value of an implicit `iota` constant, like `B = 1` of `const ( A = iota; B )`
+		orig := string(src[d.start:fl.EndOffset])
+		if i := strings.LastIndex(content, " = "); i >= 0 && !strings.Contains(orig, "=") && content[:i] == orig {
+			content = orig
+		}
+		contents[i] = content
+	}
+	return nil
+}
+
+func isComment(content string) bool {
+	content = strings.TrimSpace(content)
+	return strings.HasPrefix(content, "//") || strings.HasPrefix(content, "/*")
+}
+
+// trimDoc trims the leading comment lines of the content
+func trimDoc(content string) string {
+	for isComment(content) {
+		content = strings.TrimSpace(content)
+		if strings.HasPrefix(content, "/*") {
+			i := strings.Index(content, "*/")
+			if i < 0 {
+				return content
+			}
+			content = content[i+2:]
+			continue
+		}
+		i := strings.IndexByte(content, '\n')
+		if i < 0 {
+			return ""
+		}
+		content = content[i+1:]
+	}
+	return strings.TrimLeft(content, " \t\n")
+}
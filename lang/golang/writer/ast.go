@@ -17,9 +17,19 @@
 package writer
 
 import (
+	"bytes"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"path"
+	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/cloudwego/abcoder/lang/uniast"
+	"github.com/cloudwego/abcoder/lang/utils"
+	"golang.org/x/tools/go/ast/astutil"
 )
 
 func writeImport(sb *strings.Builder, impts []uniast.Import) {
@@ -72,3 +82,108 @@ func mergeImports(priors []uniast.Import, subs []uniast.Import) (ret []uniast.Im
 	}
 	return
 }
+
+var (
+	verSuffixRegex = regexp.MustCompile(`/v\d+$`)
+	identRegex     = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// guessPkgName guesses the package name of an unaliased import path from its last element.
+// It returns false if the element is not an identifier (e.g. `gopkg.in/yaml.v3`, `go-redis`).
+// NOTICE: the declared package name may still differ from the guess, like `jsoniter` of `github.com/json-iterator/go`
+func guessPkgName(impt string) (string, bool) {
+	name := path.Base(verSuffixRegex.ReplaceAllString(impt, ""))
+	if !identRegex.MatchString(name) {
+		return "", false
+	}
+	return name, true
+}
+
+// PackageName returns the name to refer to the package by an unaliased import, see guessPkgName.
+// The last element of the path is returned if it is not an identifier
+func PackageName(pkgPath string) string {
+	if name, ok := guessPkgName(pkgPath); ok {
+		return name
+	}
+	return path.Base(pkgPath)
+}
+
+// removeUnusedImports removes the imports which are not referenced by any selector in the file.
+// Blank, dot and cgo imports are always kept.
+// Since the declared name of an unaliased import is only guessed from its path,
+// unaliased imports are removed only if every selector root of the file is explained by a named import,
+// otherwise the unexplained root may be the real name of one of them.
+func removeUnusedImports(file []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", file, parser.ParseComments)
+	if err != nil {
+		return nil, utils.WrapError(err, "fail parse file")
+	}
+
+	// collect unresolved top-level identifiers of selectors, like `fmt` of `fmt.Println`
+	used := map[string]bool{}
+	ast.Inspect(f, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if id, ok := sel.X.(*ast.Ident); ok && id.Obj == nil {
+				used[id.Name] = true
+			}
+		}
+		return true
+	})
+
+	type candidate struct {
+		alias, path, name string
+	}
+	var aliased, unaliased []candidate
+	explained := map[string]bool{}
+	certain := true
+	for _, imp := range f.Imports {
+		impt, err := strconv.Unquote(imp.Path.Value)
+		if err != nil || impt == "C" {
+			continue
+		}
+		if imp.Name != nil {
+			explained[imp.Name.Name] = true
+			aliased = append(aliased, candidate{alias: imp.Name.Name, path: impt, name: imp.Name.Name})
+			continue
+		}
+		name, ok := guessPkgName(impt)
+		if !ok {
+			// never know its name, keep it
+			continue
+		}
+		explained[name] = true
+		unaliased = append(unaliased, candidate{path: impt, name: name})
+	}
+	for name := range used {
+		if !explained[name] {
+			// may be a package-level variable declared in other files, or the real name of an unaliased import
+			certain = false
+			break
+		}
+	}
+
+	remove := make([]candidate, 0, len(aliased)+len(unaliased))
+	remove = append(remove, aliased...)
+	if certain {
+		remove = append(remove, unaliased...)
+	}
+	deleted := false
+	for _, c := range remove {
+		if c.name == "_" || c.name == "." || used[c.name] {
+			continue
+		}
+		if astutil.DeleteNamedImport(fset, f, c.alias, c.path) {
+			deleted = true
+		}
+	}
+	if !deleted {
+		return file, nil
+	}
+
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, f); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
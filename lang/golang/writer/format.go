@@ -0,0 +1,79 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package writer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/tools/imports"
+
+	"github.com/cloudwego/abcoder/lang/utils"
+)
+
+// localPrefixMu guards imports.LocalPrefix, which is a global option of goimports
+var localPrefixMu sync.Mutex
+
+// WrittenFiles returns the absolute paths of the go files written by WriteModule, sorted
+func (w *Writer) WrittenFiles() []string {
+	files := make([]string, 0, len(w.written))
+	for f := range w.written {
+		files = append(files, f)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// Format formats the go files in place in the configured style:
+// the imports are grouped by Options.LocalPrefixes, then Options.Formatters run on them in order
+func (w *Writer) Format(ctx context.Context, files []string) error {
+	if len(w.LocalPrefixes) > 0 {
+		for _, f := range files {
+			if err := groupLocalImports(f, w.LocalPrefixes); err != nil {
+				return err
+			}
+		}
+	}
+	for _, cmd := range w.Formatters {
+		if err := utils.FormatFiles(ctx, cmd, files); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// groupLocalImports sorts the imports of the file like `goimports -local`, putting the ones with the prefixes
+// into a group after the third-party ones. The imports are never added or removed
+func groupLocalImports(file string, prefixes []string) error {
+	src, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	localPrefixMu.Lock()
+	imports.LocalPrefix = strings.Join(prefixes, ",")
+	out, err := imports.Process(file, src, &imports.Options{Comments: true, TabIndent: true, TabWidth: 8, FormatOnly: true})
+	imports.LocalPrefix = ""
+	localPrefixMu.Unlock()
+	if err != nil {
+		return fmt.Errorf("group imports of %s failed: %v", file, err)
+	}
+	return os.WriteFile(file, out, 0644)
+}
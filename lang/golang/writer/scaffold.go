@@ -0,0 +1,67 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package writer
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/cloudwego/abcoder/lang/uniast"
+)
+
+// ScaffoldDir is the directory under the output, where the placeholder modules of external dependencies are written.
+// It starts with `_`, thus is ignored by `./...` patterns.
+const ScaffoldDir = "_external"
+
+// writeScaffolds writes the loaded external modules which mod depends on as placeholder modules,
+// returns module name => replace path relative to modDir
+func (w *Writer) writeScaffolds(repo *uniast.Repository, mod *uniast.Module, outDir string, modDir string) (map[string]string, error) {
+	ret := map[string]string{}
+	for _, ext := range repo.Modules {
+		if !ext.IsExternal() || ext.Language != uniast.Golang || len(ext.Packages) == 0 {
+			continue
+		}
+		if _, ok := mod.Dependencies[ext.Name]; !ok {
+			continue
+		}
+		dir := filepath.Join(outDir, ScaffoldDir, filepath.FromSlash(ext.Name))
+		sw := NewWriter(Options{CompilerPath: w.CompilerPath})
+		sw.stub = true
+		if err := sw.writeModule(repo, ext, dir, nil); err != nil {
+			return nil, fmt.Errorf("write placeholder module %s failed: %v", ext.Name, err)
+		}
+		rel, err := filepath.Rel(modDir, dir)
+		if err != nil {
+			return nil, err
+		}
+		rel = filepath.ToSlash(rel)
+		if !strings.HasPrefix(rel, "../") {
+			rel = "./" + rel
+		}
+		ret[ext.Name] = rel
+	}
+	return ret, nil
+}
+
+// stubFunction keeps the signature of the function, and replaces its body with a panic
+func stubFunction(f *uniast.Function) string {
+	if f.Signature == "" || !strings.HasPrefix(strings.TrimSpace(f.Signature), "func") {
+		return f.Content
+	}
+	return f.Signature + " {\n\tpanic(\"abcoder: placeholder of external function\")\n}"
+}
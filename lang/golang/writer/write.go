@@ -41,11 +41,28 @@ type Options struct {
 	// RepoDir   string
 	// OutDir    string
 	CompilerPath string
+	// ScaffoldExternal writes the loaded external dependencies as placeholder modules,
+	// and replaces them in go.mod, thus the output compiles without fetching them
+	ScaffoldExternal bool
+	// LocalPrefixes groups the imports with the prefixes after the third-party ones like `goimports -local`, see Format
+	LocalPrefixes []string
+	// Formatters are the commands formatting the written files in order, with the file paths appended, e.g. `gofumpt -w`
+	Formatters []string
 }
 
 type Writer struct {
 	Options
 	visited map[string]map[string]*fileNode
+	// stub writes function bodies as panics, for placeholder modules
+	stub bool
+	// absolute path of written file => the nodes in it, see LocateNode
+	written map[string][]writtenNode
+}
+
+// writtenNode is the lines of a written file occupied by a node
+type writtenNode struct {
+	id        uniast.Identity
+	line, end int
 }
 
 type fileNode struct {
@@ -54,6 +71,7 @@ type fileNode struct {
 }
 
 type chunk struct {
+	id    uniast.Identity
 	codes string
 	line  int
 }
@@ -67,6 +85,7 @@ func NewWriter(opts Options) *Writer {
 	return &Writer{
 		Options: opts,
 		visited: make(map[string]map[string]*fileNode),
+		written: make(map[string][]writtenNode),
 	}
 }
 
@@ -103,13 +122,25 @@ func (w *Writer) WriteModule(repo *uniast.Repository, modPath string, outDir str
 	if mod == nil {
 		return fmt.Errorf("module %s not found", modPath)
 	}
+	outdir := filepath.Join(outDir, mod.Dir)
+	var scaffolds map[string]string
+	if w.ScaffoldExternal {
+		var err error
+		if scaffolds, err = w.writeScaffolds(repo, mod, outDir, outdir); err != nil {
+			return err
+		}
+	}
+	return w.writeModule(repo, mod, outdir, scaffolds)
+}
+
+// writeModule writes the module to outdir, and replaces the scaffolded dependencies in go.mod
+func (w *Writer) writeModule(repo *uniast.Repository, mod *uniast.Module, outdir string, scaffolds map[string]string) error {
 	for _, pkg := range mod.Packages {
 		if err := w.appendPackage(repo, pkg); err != nil {
 			return fmt.Errorf("write package %s failed: %v", pkg.PkgPath, err)
 		}
 	}
 
-	outdir := filepath.Join(outDir, mod.Dir)
 	for dir, pkg := range w.visited {
 		// sanitize the package path
 		cleanDir := sanitizePkgPath(dir)
@@ -142,11 +173,19 @@ func (w *Writer) WriteModule(repo *uniast.Repository, modPath string, outDir str
 			sort.SliceStable(f.chunks, func(i, j int) bool {
 				return f.chunks[i].line < f.chunks[j].line
 			})
+			fpath = filepath.Join(pkgDir, fpath)
+			var nodes []writtenNode
+			line := strings.Count(sb.String(), "\n") + 1
 			for _, c := range f.chunks {
+				end := line + strings.Count(c.codes, "\n")
+				nodes = append(nodes, writtenNode{id: c.id, line: line, end: end})
 				sb.WriteString(c.codes)
 				sb.WriteString("\n\n")
+				line = end + 2
+			}
+			if abs, err := filepath.Abs(fpath); err == nil {
+				w.written[abs] = nodes
 			}
-			fpath = filepath.Join(pkgDir, fpath)
 			if err := os.WriteFile(fpath, []byte(sb.String()), 0644); err != nil {
 				return fmt.Errorf("write file %s failed: %v", fpath, err)
 			}
@@ -172,7 +211,11 @@ func (w *Writer) WriteModule(repo *uniast.Repository, modPath string, outDir str
 			bs.WriteString("\t")
 			bs.WriteString(name)
 			sp := strings.Split(dep, "@")
-			if len(sp) == 2 {
+			if path, ok := scaffolds[name]; ok {
+				bs.WriteString(" ")
+				bs.WriteString(localVersion)
+				replaces[name] = path
+			} else if len(sp) == 2 {
 				if sp[1] == "" {
 					bs.WriteString(" ")
 					bs.WriteString(localVersion)
@@ -199,6 +242,11 @@ func (w *Writer) WriteModule(repo *uniast.Repository, modPath string, outDir str
 		return fmt.Errorf("write go.mod failed: %v", err)
 	}
 
+	// placeholder modules can't be tidied without their dependencies
+	if w.stub {
+		return nil
+	}
+
 	// go mod tidy
 	cmd := exec.Command(w.Options.CompilerPath, "mod", "tidy")
 	cmd.Dir = outdir
@@ -208,6 +256,28 @@ func (w *Writer) WriteModule(repo *uniast.Repository, modPath string, outDir str
 	return nil
 }
 
+// LocateNode finds the written node whose codes cover file:line,
+// and returns the line in the content of the node, starting from 1.
+// file is the path of a written file, relative to the working directory if not absolute
+func (w *Writer) LocateNode(file string, line int) (*uniast.Identity, int) {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return nil, 0
+	}
+	for _, n := range w.written[abs] {
+		if line >= n.line && line <= n.end {
+			id := n.id
+			return &id, line - n.line + 1
+		}
+	}
+	return nil, 0
+}
+
+// CompileCommand returns the command which compiles the written module in its dir
+func (w *Writer) CompileCommand() string {
+	return w.CompilerPath + " build ./..."
+}
+
 var goVersionRegex = regexp.MustCompile(`go(\d+\.\d+(\.\d+)?)`)
 
 func (w *Writer) GetGoVersion() (string, error) {
@@ -236,8 +306,16 @@ func (w *Writer) appendPackage(repo *uniast.Repository, pkg *uniast.Package) err
 			// NOTICE: interface method and it has already been written in Interface Decl
 			continue
 		}
+		if f.ParentFunction != nil {
+			// closure, written in the content of its parent
+			continue
+		}
 		n := repo.GetNode(f.Identity)
-		if err := w.appendNode(n, pkg.PkgPath, pkg.IsMain, f.File, f.Line, f.Content); err != nil {
+		content := f.Content
+		if w.stub {
+			content = stubFunction(f)
+		}
+		if err := w.appendNode(n, pkg.PkgPath, pkg.IsMain, f.File, f.Line, content); err != nil {
 			return fmt.Errorf("append chunk for function %s failed: %v", f.Name, err)
 		}
 	}
@@ -294,6 +372,7 @@ func (w *Writer) appendNode(node *uniast.Node, pkg string, isMain bool, file str
 	}
 
 	fs.chunks = append(fs.chunks, chunk{
+		id:    node.Identity,
 		codes: src,
 		line:  line,
 	})
@@ -327,7 +406,13 @@ func (w Writer) SplitImportsAndCodes(src string) (codes string, imports []uniast
 }
 
 func (w *Writer) IdToImport(id uniast.Identity) (uniast.Import, error) {
-	return uniast.Import{Path: strconv.Quote(id.PkgPath)}, nil
+	return uniast.Import{Path: strconv.Quote(sanitizePkgPath(id.PkgPath))}, nil
+}
+
+// RemoveUnusedImports removes the imports which are no longer referenced by the file codes.
+// It is used after patching nodes, whose dependencies may have been dropped.
+func (w *Writer) RemoveUnusedImports(file []byte) ([]byte, error) {
+	return removeUnusedImports(file)
 }
 
 func (p *Writer) PatchImports(impts []uniast.Import, file []byte) ([]byte, error) {
@@ -18,8 +18,12 @@ package writer
 
 import (
 	"bytes"
+	"context"
 	"os"
+	"os/exec"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/cloudwego/abcoder/lang/testutils"
@@ -154,3 +158,208 @@ func TestPatcher_PatchImports(t *testing.T) {
 		})
 	}
 }
+
+func TestWriter_RemoveUnusedImports(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "remove unused",
+			src: `package a
+
+import (
+	"fmt"
+	"os"
+	_ "runtime"
+	str "strings"
+	"gopkg.in/yaml.v3"
+	"github.com/bytedance/sonic/v2"
+)
+
+func A() string {
+	fmt.Println(sonic.Version)
+	return ""
+}
+`,
+			want: `package a
+
+import (
+	"fmt"
+	"github.com/bytedance/sonic/v2"
+	"gopkg.in/yaml.v3"
+	_ "runtime"
+)
+
+func A() string {
+	fmt.Println(sonic.Version)
+	return ""
+}
+`,
+		},
+		{
+			// the package name `jsoniter` differs from the path, the unaliased imports must be kept
+			name: "package name differs from path",
+			src: `package a
+
+import (
+	"os"
+	"github.com/json-iterator/go"
+)
+
+func A() ([]byte, error) {
+	return jsoniter.Marshal(1)
+}
+`,
+			want: `package a
+
+import (
+	"os"
+	"github.com/json-iterator/go"
+)
+
+func A() ([]byte, error) {
+	return jsoniter.Marshal(1)
+}
+`,
+		},
+		{
+			name: "unused package name differs from path",
+			src: `package a
+
+import (
+	"fmt"
+	"github.com/json-iterator/go"
+)
+
+func A() {
+	fmt.Println()
+}
+`,
+			want: `package a
+
+import (
+	"fmt"
+)
+
+func A() {
+	fmt.Println()
+}
+`,
+		},
+		{
+			name:    "syntax error",
+			src:     "package a\n\nfunc A() {",
+			wantErr: true,
+		},
+	}
+	w := NewWriter(Options{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := w.RemoveUnusedImports([]byte(tt.src))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RemoveUnusedImports() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if string(got) != tt.want {
+				t.Errorf("RemoveUnusedImports() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriter_ScaffoldExternal(t *testing.T) {
+	repo := uniast.NewRepository("app")
+	app := uniast.NewModule("a.b/app", ".", uniast.Golang)
+	app.Dependencies["github.com/x/lib"] = "github.com/x/lib@v1.2.0"
+	pkg := uniast.NewPackage("a.b/app")
+	pkg.IsMain = true
+	pkg.Functions["main"] = &uniast.Function{
+		Identity: uniast.NewIdentity("a.b/app", "a.b/app", "main"),
+		FileLine: uniast.FileLine{File: "main.go", Line: 3},
+		Content:  "func main() {\n\t_ = lib.Do(lib.Config{})\n}",
+		FunctionCalls: []uniast.Dependency{
+			{Identity: uniast.NewIdentity("github.com/x/lib@v1.2.0", "github.com/x/lib", "Do")},
+		},
+		Types: []uniast.Dependency{
+			{Identity: uniast.NewIdentity("github.com/x/lib@v1.2.0", "github.com/x/lib", "Config")},
+		},
+	}
+	app.Packages[pkg.PkgPath] = pkg
+	repo.Modules[app.Name] = app
+
+	lib := uniast.NewModule("github.com/x/lib@v1.2.0", "", uniast.Golang)
+	lpkg := uniast.NewPackage("github.com/x/lib")
+	lpkg.Functions["Do"] = &uniast.Function{
+		Identity:  uniast.NewIdentity("github.com/x/lib@v1.2.0", "github.com/x/lib", "Do"),
+		FileLine:  uniast.FileLine{File: "lib.go", Line: 10},
+		Content:   "func Do(c Config) int { return helper(c.N) }",
+		Signature: "func Do(c Config) int",
+	}
+	lpkg.Types["Config"] = &uniast.Type{
+		Identity: uniast.NewIdentity("github.com/x/lib@v1.2.0", "github.com/x/lib", "Config"),
+		TypeKind: uniast.TypeKindStruct,
+		FileLine: uniast.FileLine{File: "lib.go", Line: 3},
+		Content:  "type Config struct {\n\tN int\n}",
+	}
+	lib.Packages[lpkg.PkgPath] = lpkg
+	repo.Modules["github.com/x/lib@v1.2.0"] = lib
+	if err := repo.BuildGraph(); err != nil {
+		t.Fatal(err)
+	}
+
+	out := t.TempDir()
+	w := NewWriter(Options{CompilerPath: "true", ScaffoldExternal: true})
+	if err := w.WriteModule(&repo, app.Name, out); err != nil {
+		t.Fatal(err)
+	}
+	gomod, err := os.ReadFile(filepath.Join(out, "go.mod"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(gomod), "github.com/x/lib v0.0.0") || !strings.Contains(string(gomod), "replace github.com/x/lib => ./_external/github.com/x/lib") {
+		t.Errorf("go.mod = %s", gomod)
+	}
+	stub, err := os.ReadFile(filepath.Join(out, ScaffoldDir, "github.com/x/lib", "lib.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(stub), "helper") || !strings.Contains(string(stub), "func Do(c Config) int {") {
+		t.Errorf("placeholder = %s", stub)
+	}
+
+	if _, err := exec.LookPath("go"); err != nil {
+		return
+	}
+	cmd := exec.Command("go", "build", "./...")
+	cmd.Dir = out
+	cmd.Env = append(os.Environ(), "GOPROXY=off", "GOFLAGS=-mod=mod")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Errorf("build output failed: %v\n%s", err, output)
+	}
+}
+
+func TestWriter_Format(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "a.go")
+	src := "package a\n\nimport (\n\t\"fmt\"\n\t\"github.com/org/app/util\"\n\t\"github.com/bytedance/sonic\"\n\t\"os\"\n)\n\n" +
+		"var _, _, _, _ = fmt.Println, util.X, sonic.Marshal, os.Exit\n"
+	if err := os.WriteFile(file, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	w := NewWriter(Options{LocalPrefixes: []string{"github.com/org"}})
+	if err := w.Format(context.Background(), []string{file}); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := os.ReadFile(file)
+	want := "package a\n\nimport (\n\t\"fmt\"\n\t\"os\"\n\n\t\"github.com/bytedance/sonic\"\n\n\t\"github.com/org/app/util\"\n)\n\n" +
+		"var _, _, _, _ = fmt.Println, util.X, sonic.Marshal, os.Exit\n"
+	if string(got) != want {
+		t.Errorf("grouped imports:\n%s\nwant:\n%s", got, want)
+	}
+
+	w = NewWriter(Options{Formatters: []string{"false"}})
+	if err := w.Format(context.Background(), []string{file}); err == nil {
+		t.Errorf("expect the error of the failed formatter")
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package java
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/cloudwego/abcoder/lang/uniast"
+	"github.com/cloudwego/abcoder/lang/utils"
+)
+
+var (
+	annotationNameRegex = regexp.MustCompile(`^@\s*([\w.]+)`)
+	modifierRegex       = regexp.MustCompile(`^(public|protected|private|static|final|abstract|synchronized|native|default|transient|volatile|strictfp|sealed|non-sealed)\b`)
+)
+
+// Annotations parses the annotations at the head of a declaration, like `@Service` or `@RequestMapping("/api")`,
+// which may be mixed with the modifiers. Comments are skipped, and `@interface` ends the annotations
+func Annotations(content string) []uniast.Annotation {
+	ret, _ := parseHead(content)
+	return ret
+}
+
+// Modifiers parses the modifiers at the head of a declaration, like `public` or `static`, skipping the annotations
+func Modifiers(content string) []string {
+	_, mods := parseHead(content)
+	return mods
+}
+
+// Visibility tells the visibility by the access modifier of a declaration,
+// or returns the empty value if there is none, meaning package-private unless implied by the enclosing type
+func Visibility(content string) uniast.Visibility {
+	for _, m := range Modifiers(content) {
+		switch m {
+		case "public":
+			return uniast.VisibilityPublic
+		case "protected":
+			return uniast.VisibilityProtected
+		case "private":
+			return uniast.VisibilityPrivate
+		}
+	}
+	return ""
+}
+
+// parseHead parses the annotations and modifiers at the head of a declaration
+func parseHead(content string) (ret []uniast.Annotation, mods []string) {
+	s := content
+	for {
+		s = strings.TrimLeft(s, " \t\r\n")
+		if strings.HasPrefix(s, "//") {
+			if i := strings.IndexByte(s, '\n'); i >= 0 {
+				s = s[i+1:]
+				continue
+			}
+			break
+		}
+		if strings.HasPrefix(s, "/*") {
+			if i := strings.Index(s, "*/"); i >= 0 {
+				s = s[i+2:]
+				continue
+			}
+			break
+		}
+		if m := modifierRegex.FindString(s); m != "" {
+			mods = append(mods, m)
+			s = s[len(m):]
+			continue
+		}
+		m := annotationNameRegex.FindStringSubmatch(s)
+		if m == nil || m[1] == "interface" {
+			break
+		}
+		ann := uniast.Annotation{Name: m[1]}
+		s = s[len(m[0]):]
+		if rest := strings.TrimLeft(s, " \t\r\n"); strings.HasPrefix(rest, "(") {
+			if end := utils.MatchBracket(rest, 0); end > 0 {
+				ann.Args = strings.TrimSpace(rest[1:end])
+				s = rest[end+1:]
+			}
+		}
+		ret = append(ret, ann)
+	}
+	return ret, mods
+}
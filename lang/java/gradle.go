@@ -0,0 +1,178 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package java
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	javaparser "github.com/cloudwego/abcoder/lang/java/parser"
+)
+
+// GradleTimeout limits the time of resolving the dependencies of a Gradle project
+const GradleTimeout = 10 * time.Minute
+
+// the configuration whose dependencies are resolved, the types referenced by the sources are on it
+const gradleConfiguration = "compileClasspath"
+
+// a line of the dependency report, like
+//
+//	+--- org.slf4j:slf4j-api:1.7.36 -> 2.0.9 (*)
+//	\--- com.google.guava:guava -> 33.0.0-jre
+var gradleDependencyRegex = regexp.MustCompile(`^[| ]*[+\\]--- ([\w.\-]+):([\w.\-]+)(?::([\w.\-+]+))?(?: -> ([\w.\-+]+))?(?: \((\*|c|n)\))?\s*$`)
+
+// ResolveGradleDependencies resolves the jars of the dependencies of the Gradle build at root.
+// The Gradle tooling API needs a JVM, thus the dependency report (`gradle dependencies`) of each project is
+// parsed instead, which downloads the dependencies into the Gradle cache as well.
+// The jars are then located in the cache of $GRADLE_USER_HOME (default ~/.gradle), or the Maven local repository.
+// Projects failing to report are skipped, and the error is returned only if all of them fail.
+func ResolveGradleDependencies(ctx context.Context, root string) ([]string, error) {
+	project, err := javaparser.ParseGradleProject(root)
+	if err != nil {
+		return nil, err
+	}
+	gradle, err := gradleCommand(root)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, GradleTimeout)
+	defer cancel()
+
+	coords := map[string]bool{}
+	var errs []string
+	for _, mod := range javaparser.GetModuleStructMap(project) {
+		task := "dependencies"
+		if mod.GradlePath != ":" {
+			task = mod.GradlePath + ":dependencies"
+		}
+		cmd := exec.CommandContext(ctx, gradle, "-q", "--console=plain", task, "--configuration", gradleConfiguration)
+		cmd.Dir = root
+		out, err := cmd.Output()
+		if err != nil {
+			// e.g. the root project may not apply the java plugin
+			errs = append(errs, fmt.Sprintf("%s: %v", task, err))
+			continue
+		}
+		for _, c := range parseDependencyReport(string(out)) {
+			coords[c] = true
+		}
+	}
+	if len(coords) == 0 && len(errs) > 0 {
+		return nil, fmt.Errorf("failed to report the dependencies of %s: %s", root, strings.Join(errs, "; "))
+	}
+
+	var jars []string
+	for c := range coords {
+		if jar := findDependencyJar(c); jar != "" {
+			jars = append(jars, jar)
+		} else {
+			log.Printf("Warning: jar of %s is not found in the Gradle cache", c)
+		}
+	}
+	sort.Strings(jars)
+	return jars, nil
+}
+
+// gradleCommand returns the Gradle wrapper of the project, or gradle in PATH
+func gradleCommand(root string) (string, error) {
+	wrapper := "gradlew"
+	if runtime.GOOS == "windows" {
+		wrapper = "gradlew.bat"
+	}
+	if path := filepath.Join(root, wrapper); isExecutable(path) {
+		return path, nil
+	}
+	path, err := exec.LookPath("gradle")
+	if err != nil {
+		return "", fmt.Errorf("neither the Gradle wrapper nor gradle is found: %w", err)
+	}
+	return path, nil
+}
+
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir() && (runtime.GOOS == "windows" || info.Mode()&0111 != 0)
+}
+
+// parseDependencyReport returns the resolved coordinates (group:artifact:version) in the dependency report.
+// Project dependencies, constraints and unresolved ones are skipped, and the versions chosen by conflict
+// resolution (after `->`) win
+func parseDependencyReport(report string) []string {
+	var ret []string
+	seen := map[string]bool{}
+	for _, line := range strings.Split(report, "\n") {
+		m := gradleDependencyRegex.FindStringSubmatch(strings.TrimRight(line, "\r"))
+		if m == nil || m[5] == "c" || m[5] == "n" {
+			continue
+		}
+		version := m[3]
+		if m[4] != "" {
+			version = m[4]
+		}
+		if version == "" {
+			continue
+		}
+		c := m[1] + ":" + m[2] + ":" + version
+		if !seen[c] {
+			seen[c] = true
+			ret = append(ret, c)
+		}
+	}
+	return ret
+}
+
+// findDependencyJar finds the jar of the coordinates in the Gradle cache or the Maven local repository
+func findDependencyJar(coords string) string {
+	parts := strings.Split(coords, ":")
+	if len(parts) != 3 {
+		return ""
+	}
+	group, artifact, version := parts[0], parts[1], parts[2]
+	jar := artifact + "-" + version + ".jar"
+
+	gradleHome := os.Getenv("GRADLE_USER_HOME")
+	m2 := os.Getenv("MAVEN_M2_REPOSITORY_PATH")
+	if home, err := os.UserHomeDir(); err == nil {
+		if gradleHome == "" {
+			gradleHome = filepath.Join(home, ".gradle")
+		}
+		if m2 == "" {
+			m2 = filepath.Join(home, ".m2", "repository")
+		}
+	}
+	if gradleHome != "" {
+		// caches/modules-2/files-2.1/<group>/<artifact>/<version>/<sha1>/<jar>
+		pattern := filepath.Join(gradleHome, "caches", "modules-2", "files-2.1", group, artifact, version, "*", jar)
+		if matches, _ := filepath.Glob(pattern); len(matches) > 0 {
+			return matches[0]
+		}
+	}
+	if m2 != "" {
+		path := filepath.Join(append([]string{m2}, append(strings.Split(group, "."), artifact, version, jar)...)...)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
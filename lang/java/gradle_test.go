@@ -0,0 +1,74 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package java
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseDependencyReport(t *testing.T) {
+	report := `
+compileClasspath - Compile classpath for source set 'main'.
++--- project :core
+|    \--- org.apache.commons:commons-lang3:3.14.0
++--- org.slf4j:slf4j-api:1.7.36 -> 2.0.9
++--- com.google.guava:guava -> 33.0.0-jre
+|    +--- com.google.guava:failureaccess:1.0.2
+|    \--- org.slf4j:slf4j-api:2.0.9 (*)
++--- org.example:constraint:1.0 (c)
+\--- org.example:missing:1.0 (n)
+
+(*) - Indicates repeated occurrences of a transitive dependency subtree.
+`
+	want := []string{
+		"org.apache.commons:commons-lang3:3.14.0",
+		"org.slf4j:slf4j-api:2.0.9",
+		"com.google.guava:guava:33.0.0-jre",
+		"com.google.guava:failureaccess:1.0.2",
+	}
+	if got := parseDependencyReport(report); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseDependencyReport() = %v, want %v", got, want)
+	}
+}
+
+func TestFindDependencyJar(t *testing.T) {
+	gradleHome, m2 := t.TempDir(), t.TempDir()
+	t.Setenv("GRADLE_USER_HOME", gradleHome)
+	t.Setenv("MAVEN_M2_REPOSITORY_PATH", m2)
+
+	cached := filepath.Join(gradleHome, "caches", "modules-2", "files-2.1", "org.slf4j", "slf4j-api", "2.0.9", "7cf2726fdcfbc8610f9a71fb3ed639871f315340", "slf4j-api-2.0.9.jar")
+	local := filepath.Join(m2, "com", "google", "guava", "guava", "33.0.0-jre", "guava-33.0.0-jre.jar")
+	for _, path := range []string{cached, local} {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for coords, want := range map[string]string{
+		"org.slf4j:slf4j-api:2.0.9":         cached,
+		"com.google.guava:guava:33.0.0-jre": local,
+		"org.example:missing:1.0":           "",
+	} {
+		if got := findDependencyJar(coords); got != want {
+			t.Errorf("findDependencyJar(%s) = %s, want %s", coords, got, want)
+		}
+	}
+}
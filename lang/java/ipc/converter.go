@@ -15,6 +15,7 @@
 package ipc
 
 import (
+	"os"
 	"path/filepath"
 	"strings"
 
@@ -35,6 +36,14 @@ type Converter struct {
 	LocalClassCache     map[string]*pb.ClassInfo
 	UnknowClassCache    map[string]*pb.ClassInfo
 	ThirdPartClassCache map[string]*pb.ClassInfo
+
+	// MaxTextBytes bounds the bytes of the texts of local classes kept in LocalClassCache, 0 means unlimited.
+	// Beyond it the texts of the following classes are dropped once they arrive, and read from the source files
+	// again by Content, thus the memory does not grow with the texts of the whole repo
+	MaxTextBytes int64
+	textBytes    int64
+	// classes whose texts are dropped
+	spilled map[string]bool
 }
 
 // NewConverter creates a new converter for the given repository
@@ -52,6 +61,7 @@ func NewConverter(repoPath string, moduleName string) *Converter {
 		LocalClassCache:     make(map[string]*pb.ClassInfo),
 		UnknowClassCache:    make(map[string]*pb.ClassInfo),
 		ThirdPartClassCache: make(map[string]*pb.ClassInfo),
+		spilled:             make(map[string]bool),
 	}
 	// 确保默认 module 存在（即使只收到 progress/summary）
 	c.getOrCreateModule(moduleName)
@@ -104,12 +114,17 @@ func (c *Converter) processFileInfo(info *pb.FileInfo) error {
 	return nil
 }
 
-// processClassInfo converts ClassInfo to UniAST Type and Functions
+// processClassInfo converts ClassInfo to UniAST Type and Functions.
+// The file and package of a local class are added to the repository as soon as it arrives,
+// thus the repository holds the classes received so far if the stream breaks
 func (c *Converter) processClassInfo(info *pb.ClassInfo) error {
-	if info == nil {
+	if info == nil || info.Source == nil {
 		return nil
 	}
 
+	if info.Source.Type == pb.SourceType_SOURCE_TYPE_LOCAL {
+		c.addLocalClass(info)
+	}
 	err, _ := putCache(info, c)
 	if err != nil {
 		return err
@@ -117,6 +132,64 @@ func (c *Converter) processClassInfo(info *pb.ClassInfo) error {
 	return nil
 }
 
+// addLocalClass adds the file and package of the class, and drops its text beyond MaxTextBytes
+func (c *Converter) addLocalClass(info *pb.ClassInfo) {
+	if info.FilePath != "" {
+		file := c.getOrCreateFile(info.FilePath)
+		if info.PackageName != "" {
+			file.Package = uniast.PkgPath(info.PackageName)
+		}
+		mod := c.getOrCreateModule(c.moduleName)
+		if _, ok := mod.Packages[file.Package]; !ok && file.Package != "" {
+			mod.Packages[file.Package] = uniast.NewPackage(file.Package)
+		}
+	}
+
+	n := int64(len(info.RawText) + len(info.Content))
+	if c.MaxTextBytes <= 0 || c.textBytes+n <= c.MaxTextBytes || info.FilePath == "" || info.StartLine <= 0 {
+		c.textBytes += n
+		return
+	}
+	info.RawText, info.Content = "", ""
+	c.spilled[info.ClassName] = true
+}
+
+// Content returns the text of the class, which is read from the source file again if dropped by MaxTextBytes
+func (c *Converter) Content(info *pb.ClassInfo) string {
+	if info == nil {
+		return ""
+	}
+	if text := info.GetContent(); text != "" || !c.spilled[info.ClassName] {
+		return text
+	}
+	path := info.FilePath
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(c.repoPath, path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return sliceText(string(data), int(info.StartLine), int(info.StartColumn), int(info.EndLine), int(info.EndColumn))
+}
+
+// sliceText returns the text in the range, whose lines and columns are 1-based and the end column is inclusive
+func sliceText(text string, startLine, startCol, endLine, endCol int) string {
+	lines := strings.Split(text, "\n")
+	if startLine < 1 || startLine > len(lines) || endLine < startLine {
+		return ""
+	}
+	endLine = min(endLine, len(lines))
+	ret := append([]string(nil), lines[startLine-1:endLine]...)
+	if last := ret[len(ret)-1]; endCol > 0 && endCol < len(last) {
+		ret[len(ret)-1] = last[:endCol]
+	}
+	if startCol > 1 && startCol-1 <= len(ret[0]) {
+		ret[0] = ret[0][startCol-1:]
+	}
+	return strings.Join(ret, "\n")
+}
+
 func (c *Converter) ProcessClassDepInfo() error {
 	for _, info := range c.LocalClassCache {
 		for _, dep := range info.Dependencies {
@@ -0,0 +1,76 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ipc
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudwego/abcoder/lang/java/pb"
+)
+
+func TestConverter_MaxTextBytes(t *testing.T) {
+	repo := t.TempDir()
+	src := "package a;\n\npublic class A {\n    int x;\n}\n\nclass B {}\n"
+	if err := os.WriteFile(filepath.Join(repo, "A.java"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	classA := "public class A {\n    int x;\n}"
+	conv := NewConverter(repo, "a")
+	conv.MaxTextBytes = int64(len(classA))
+	for _, ci := range []*pb.ClassInfo{
+		{ClassName: "a.A", PackageName: "a", FilePath: "A.java", RawText: classA, StartLine: 3, StartColumn: 1, EndLine: 5, EndColumn: 1},
+		{ClassName: "a.B", PackageName: "a", FilePath: "A.java", RawText: "class B {}", StartLine: 7, StartColumn: 1, EndLine: 7, EndColumn: 10},
+	} {
+		ci.Source = &pb.SourceInfo{Type: pb.SourceType_SOURCE_TYPE_LOCAL}
+		if err := conv.ProcessResponse(&pb.AnalyzeResponse{PayloadType: pb.PAYLOAD_CLASS_INFO, Payload: ci}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	a, b := conv.LocalClassCache["a.A"], conv.LocalClassCache["a.B"]
+	if a.RawText != classA {
+		t.Errorf("The text within the bound should be kept, got %q", a.RawText)
+	}
+	if b.RawText != "" {
+		t.Errorf("The text beyond the bound should be dropped, got %q", b.RawText)
+	}
+	if got := conv.Content(b); got != "class B {}" {
+		t.Errorf("Content() = %q, want the text read from the file", got)
+	}
+	if got := conv.Content(a); got != classA {
+		t.Errorf("Content() = %q", got)
+	}
+}
+
+func TestSliceText(t *testing.T) {
+	text := "ab\n  cdef\n  gh }\n"
+	tests := []struct {
+		sl, sc, el, ec int
+		want           string
+	}{
+		{2, 3, 2, 6, "cdef"},
+		{2, 3, 3, 6, "cdef\n  gh }"},
+		{1, 1, 1, 0, "ab"},
+		{3, 1, 9, 0, "  gh }\n"},
+		{0, 1, 1, 1, ""},
+	}
+	for _, tt := range tests {
+		if got := sliceText(text, tt.sl, tt.sc, tt.el, tt.ec); got != tt.want {
+			t.Errorf("sliceText(%d:%d-%d:%d) = %q, want %q", tt.sl, tt.sc, tt.el, tt.ec, got, tt.want)
+		}
+	}
+}
@@ -16,6 +16,7 @@ package ipc
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -41,6 +42,10 @@ const (
 	DefaultReadTimeout = 5 * time.Minute
 )
 
+// ErrIncomplete tells that the response stream ended before the summary,
+// e.g. the Java process died mid-analysis. The responses received so far are still valid
+var ErrIncomplete = errors.New("the Java parser stopped before the analysis completed")
+
 // ServerConfig holds configuration for the Java Parser server
 type ServerConfig struct {
 	// JarPath is the path to the Java Parser JAR file
@@ -83,6 +88,11 @@ type JavaParserServer struct {
 	mu       sync.Mutex
 	running  bool
 	stopOnce sync.Once
+	// err is why the response stream ended early, see Err
+	err error
+	// exitErr is the exit status of the Java process, set before exited is closed
+	exitErr error
+	exited  chan struct{}
 }
 
 // NewJavaParserServer creates a new Java Parser server with the given configuration
@@ -193,11 +203,17 @@ func (s *JavaParserServer) startJavaProcess(ctx context.Context) error {
 	}
 
 	// Monitor process in background
+	cmd, exited := s.javaCmd, make(chan struct{})
+	s.exited = exited
 	go func() {
-		if err := s.javaCmd.Wait(); err != nil {
+		defer close(exited)
+		if err := cmd.Wait(); err != nil {
 			if s.config.Debug {
 				log.Printf("[JavaParserServer] Java process exited: %v", err)
 			}
+			s.mu.Lock()
+			s.exitErr = err
+			s.mu.Unlock()
 		}
 	}()
 
@@ -269,6 +285,7 @@ func (s *JavaParserServer) readResponses(ctx context.Context, responseChan chan<
 			if s.config.Debug {
 				log.Printf("[JavaParserServer] Context cancelled, stopping response reader")
 			}
+			s.setErr(ctx.Err())
 			return
 		default:
 		}
@@ -287,6 +304,7 @@ func (s *JavaParserServer) readResponses(ctx context.Context, responseChan chan<
 				if s.config.Debug {
 					log.Printf("[JavaParserServer] End of stream reached")
 				}
+				s.incomplete(errors.New("connection closed"))
 				return
 			}
 
@@ -296,6 +314,7 @@ func (s *JavaParserServer) readResponses(ctx context.Context, responseChan chan<
 			}
 
 			log.Printf("[JavaParserServer] Error reading message: %v", err)
+			s.incomplete(err)
 			return
 		}
 
@@ -336,11 +355,47 @@ func (s *JavaParserServer) readResponses(ctx context.Context, responseChan chan<
 			}
 
 		case <-ctx.Done():
+			s.setErr(ctx.Err())
 			return
 		}
 	}
 }
 
+func (s *JavaParserServer) setErr(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err == nil {
+		s.err = err
+	}
+}
+
+// incomplete records ErrIncomplete along with the exit status of the Java process,
+// which is given a moment to exit after the connection is broken
+func (s *JavaParserServer) incomplete(cause error) {
+	if s.exited != nil {
+		select {
+		case <-s.exited:
+		case <-time.After(time.Second):
+		}
+	}
+	s.mu.Lock()
+	exitErr := s.exitErr
+	s.mu.Unlock()
+	if exitErr != nil {
+		cause = fmt.Errorf("%v, the process exited with %v", cause, exitErr)
+	}
+	s.setErr(fmt.Errorf("%w: %v", ErrIncomplete, cause))
+}
+
+// Err returns why the response stream ended before the summary, nil if the analysis completed.
+// It wraps ErrIncomplete if the Java process died or the connection broke, or it is the error of the context.
+// It must be called after the response channel is closed
+func (s *JavaParserServer) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
 // Stop gracefully stops the server and cleans up resources
 func (s *JavaParserServer) Stop() {
 	s.stopOnce.Do(func() {
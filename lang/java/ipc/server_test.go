@@ -16,6 +16,7 @@ package ipc
 
 import (
 	"context"
+	"errors"
 	"net"
 	"os"
 	"strings"
@@ -330,6 +331,10 @@ func TestMockJavaConnection(t *testing.T) {
 		t.Fatal("Client timed out")
 	}
 
+	if err := server.Err(); err != nil {
+		t.Errorf("Expected complete stream, got %v", err)
+	}
+
 	// Verify responses
 	if len(responses) != 3 {
 		t.Errorf("Expected 3 responses, got %d", len(responses))
@@ -353,6 +358,67 @@ func TestMockJavaConnection(t *testing.T) {
 	}
 }
 
+func TestIncompleteStream(t *testing.T) {
+	server := NewJavaParserServer(&ServerConfig{
+		SocketDir:      os.TempDir(),
+		ConnectTimeout: 5 * time.Second,
+		ReadTimeout:    5 * time.Second,
+	})
+	if err := server.createSocketListener(); err != nil {
+		t.Fatalf("Failed to create socket: %v", err)
+	}
+	socketPath := server.GetSocketPath()
+	defer server.Stop()
+
+	// the Java side dies after sending one class
+	go func() {
+		conn, err := net.Dial("unix", socketPath)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_, _ = NewProtocolReader(conn).ReadMessage()
+		_ = NewProtocolWriter(conn).WriteResponse(&pb.AnalyzeResponse{
+			RequestId:   "test-request",
+			PayloadType: pb.PAYLOAD_CLASS_INFO,
+			Payload: &pb.ClassInfo{
+				ClassName:   "com.example.Test",
+				PackageName: "com.example",
+				FilePath:    "src/main/java/com/example/Test.java",
+				Source:      &pb.SourceInfo{Type: pb.SourceType_SOURCE_TYPE_LOCAL},
+			},
+		})
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.acceptConnection(ctx); err != nil {
+		t.Fatalf("Failed to accept connection: %v", err)
+	}
+	if err := server.sendAnalyzeRequest("/test/repo", nil); err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	responseChan := make(chan *pb.AnalyzeResponse, 10)
+	go server.readResponses(ctx, responseChan)
+
+	conv := NewConverter("/test/repo", "test")
+	for resp := range responseChan {
+		if err := conv.ProcessResponse(resp); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := server.Err(); !errors.Is(err, ErrIncomplete) {
+		t.Errorf("Expected ErrIncomplete, got %v", err)
+	}
+	// the partial result
+	if conv.LocalClassCache["com.example.Test"] == nil {
+		t.Error("The received class should be kept")
+	}
+	if mod := conv.Repository().Modules["test"]; mod.Packages["com.example"] == nil || mod.Files["src/main/java/com/example/Test.java"] == nil {
+		t.Error("The package and file of the received class should be added")
+	}
+}
+
 func TestAnalyzerConfigConversion(t *testing.T) {
 	config := &pb.AnalyzerConfig{
 		ResolveMavenDependencies: true,
@@ -23,12 +23,16 @@ import (
 	"time"
 
 	"github.com/cloudwego/abcoder/lang/java/ipc"
+	javaparser "github.com/cloudwego/abcoder/lang/java/parser"
 	"github.com/cloudwego/abcoder/lang/java/pb"
 )
 
 const (
 	MaxWaitDuration = 5 * time.Second
 
+	// DefaultMaxTextBytes bounds the texts of the local classes buffered by the converter
+	DefaultMaxTextBytes = 512 << 20
+
 	// Java Parser JAR configuration
 	javaParserVersion = "1.0.0"
 	javaParserJarName = "java-parser.jar"
@@ -51,6 +55,10 @@ type ParserConfig struct {
 	// ResolveMavenDependencies enables Maven dependency resolution
 	ResolveMavenDependencies bool
 
+	// ResolveGradleDependencies enables Gradle dependency resolution for Gradle builds,
+	// the resolved jars are passed to the analyzer as extra jars, see ResolveGradleDependencies
+	ResolveGradleDependencies bool
+
 	// M2RepositoryPath is the path to Maven local repository
 	// If empty, uses default ~/.m2/repository
 	M2RepositoryPath string
@@ -66,6 +74,9 @@ type ParserConfig struct {
 
 	// Timeout for the entire analysis
 	Timeout time.Duration
+
+	// MaxTextBytes bounds the texts of the local classes buffered while streaming, see ipc.Converter.MaxTextBytes
+	MaxTextBytes int64
 }
 
 // DefaultParserConfig returns a default parser configuration
@@ -81,9 +92,13 @@ func DefaultParserConfig() *ParserConfig {
 		Debug:                    false,
 		JarPath:                  jarPath,
 		Timeout:                  60 * time.Minute,
+		MaxTextBytes:             DefaultMaxTextBytes,
 	}
 }
 
+// ParseRepositoryByIpc analyzes the repo by the Java Parser, and converts the streamed classes as they arrive.
+// If the Java process dies mid-analysis, the converter holding the classes received so far
+// is returned along with the error wrapping ipc.ErrIncomplete
 func ParseRepositoryByIpc(ctx context.Context, repoPath string, config *ParserConfig) (*ipc.Converter, error) {
 	if config == nil {
 		config = DefaultParserConfig()
@@ -148,6 +163,14 @@ func ParseRepositoryByIpc(ctx context.Context, repoPath string, config *ParserCo
 		analyzerConfig.ExtraConfig["maven.installBeforeResolve"] = "true"
 	}
 
+	if config.ResolveGradleDependencies && javaparser.IsGradleProject(repoPath) {
+		jars, err := ResolveGradleDependencies(ctx, repoPath)
+		if err != nil {
+			log.Printf("Warning: failed to resolve Gradle dependencies: %v", err)
+		}
+		analyzerConfig.ExtraJarPaths = append(analyzerConfig.ExtraJarPaths, jars...)
+	}
+
 	if config.Debug {
 		analyzerConfig.ExtraConfig["maven.verbose"] = "true"
 	}
@@ -164,6 +187,7 @@ func ParseRepositoryByIpc(ctx context.Context, repoPath string, config *ParserCo
 	// Convert responses to UniAST
 	moduleName := filepath.Base(repoPath)
 	converter := ipc.NewConverter(repoPath, moduleName)
+	converter.MaxTextBytes = config.MaxTextBytes
 
 	for resp := range responseChan {
 		if err := converter.ProcessResponse(resp); err != nil {
@@ -175,5 +199,5 @@ func ParseRepositoryByIpc(ctx context.Context, repoPath string, config *ParserCo
 		return nil, fmt.Errorf("failed to process class dependencies: %w", err)
 	}
 
-	return converter, nil
+	return converter, server.Err()
 }
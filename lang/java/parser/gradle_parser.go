@@ -0,0 +1,199 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	gradleSettingsFiles = []string{"settings.gradle.kts", "settings.gradle"}
+	gradleBuildFiles    = []string{"build.gradle.kts", "build.gradle"}
+
+	// /* ... */ and whole-line // comments, trailing ones are kept since `//` may be in URLs
+	gradleCommentRegex = regexp.MustCompile(`(?m)/\*(?s:.*?)\*/|^\s*//.*$`)
+	// rootProject.name = "x"
+	gradleRootNameRegex = regexp.MustCompile(`rootProject\.name\s*=\s*['"]([^'"]+)['"]`)
+	// include("a", ":b:c") of Kotlin DSL, the arguments may span lines
+	gradleIncludeCallRegex = regexp.MustCompile(`\binclude\s*\(([^)]*)\)`)
+	// include 'a', ':b:c' of Groovy DSL
+	gradleIncludeRegex = regexp.MustCompile(`(?m)\binclude\s+(['"].*)$`)
+	// project(":a").projectDir = file("path")
+	gradleProjectDirRegex = regexp.MustCompile(`project\(\s*['"]([^'"]+)['"]\s*\)\.projectDir\s*=\s*file\(\s*['"]([^'"]+)['"]\s*\)`)
+	// group = "x" or version = 'x' in either DSL, including those in allprojects {}
+	gradleCoordinateRegex = regexp.MustCompile(`(?m)^\s*(group|version)\s*=\s*['"]([^'"]+)['"]`)
+	gradleQuotedRegex     = regexp.MustCompile(`['"]([^'"]+)['"]`)
+)
+
+// IsGradleProject tells if the dir is the root of a Gradle build, by its settings or build file
+func IsGradleProject(root string) bool {
+	return findFile(root, gradleSettingsFiles) != "" || findFile(root, gradleBuildFiles) != ""
+}
+
+// ParseGradleProject parses the projects of the Gradle build at root into modules, as ParseMavenProject does.
+// Both Groovy and Kotlin DSL build files are read statically without running Gradle,
+// thus only the literal declarations are recognized:
+//   - the projects included by settings.gradle(.kts), and their custom projectDir;
+//   - the group and version of build.gradle(.kts) and gradle.properties, inherited by the subprojects.
+//
+// The included projects are the submodules of the root project.
+func ParseGradleProject(root string) (*ModuleInfo, error) {
+	settingsPath := findFile(root, gradleSettingsFiles)
+	if settingsPath == "" && findFile(root, gradleBuildFiles) == "" {
+		return nil, fmt.Errorf("no Gradle settings or build file in %s", root)
+	}
+	var settings string
+	if settingsPath != "" {
+		data, err := os.ReadFile(settingsPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", settingsPath, err)
+		}
+		settings = gradleCommentRegex.ReplaceAllString(string(data), "")
+	}
+
+	name := filepath.Base(root)
+	if m := gradleRootNameRegex.FindStringSubmatch(settings); m != nil {
+		name = m[1]
+	}
+	properties := readGradleProperties(filepath.Join(root, "gradle.properties"))
+	rootModule := newGradleModule(root, name, ":", properties, nil)
+
+	dirs := map[string]string{}
+	for _, m := range gradleProjectDirRegex.FindAllStringSubmatch(settings, -1) {
+		dirs[gradleProjectPath(m[1])] = m[2]
+	}
+	for _, path := range gradleIncludes(settings) {
+		dir := filepath.Join(strings.Split(strings.TrimPrefix(path, ":"), ":")...)
+		if custom, ok := dirs[path]; ok {
+			dir = filepath.FromSlash(custom)
+		}
+		sub := newGradleModule(filepath.Join(root, dir), path[strings.LastIndexByte(path, ':')+1:], path, properties, rootModule)
+		rootModule.SubModules = append(rootModule.SubModules, sub)
+	}
+	return rootModule, nil
+}
+
+// ParseProject parses the Maven or Gradle project at root, the former is preferred if both exist
+func ParseProject(root string) (*ModuleInfo, error) {
+	pomPath := filepath.Join(root, "pom.xml")
+	if _, err := os.Stat(pomPath); err == nil || !IsGradleProject(root) {
+		return ParseMavenProject(pomPath)
+	}
+	return ParseGradleProject(root)
+}
+
+func newGradleModule(dir, name, projectPath string, properties map[string]string, parent *ModuleInfo) *ModuleInfo {
+	groupID, version := properties["group"], properties["version"]
+	if parent != nil {
+		groupID, version = parent.GroupID, parent.Version
+	}
+	if path := findFile(dir, gradleBuildFiles); path != "" {
+		if data, err := os.ReadFile(path); err == nil {
+			text := gradleCommentRegex.ReplaceAllString(string(data), "")
+			for _, m := range gradleCoordinateRegex.FindAllStringSubmatch(text, -1) {
+				if m[1] == "group" {
+					groupID = m[2]
+				} else {
+					version = m[2]
+				}
+			}
+		}
+	}
+	groupID = resolveProperty(groupID, properties)
+	version = resolveProperty(version, properties)
+	return &ModuleInfo{
+		ArtifactID:     name,
+		GroupID:        groupID,
+		Version:        version,
+		Coordinates:    fmt.Sprintf("%s:%s:%s", groupID, name, version),
+		Path:           dir,
+		SourcePath:     filepath.Join(dir, "src", "main", "java"),
+		TestSourcePath: filepath.Join(dir, "src", "test", "java"),
+		TargetPath:     filepath.Join(dir, "build"),
+		SubModules:     []*ModuleInfo{},
+		Properties:     properties,
+		GradlePath:     projectPath,
+	}
+}
+
+// gradleIncludes returns the paths of the included projects, like `:a:b`
+func gradleIncludes(settings string) []string {
+	var ret []string
+	seen := map[string]bool{}
+	add := func(args string) {
+		for _, m := range gradleQuotedRegex.FindAllStringSubmatch(args, -1) {
+			if path := gradleProjectPath(m[1]); !seen[path] {
+				seen[path] = true
+				ret = append(ret, path)
+			}
+		}
+	}
+	for _, m := range gradleIncludeCallRegex.FindAllStringSubmatch(settings, -1) {
+		add(m[1])
+	}
+	for _, m := range gradleIncludeRegex.FindAllStringSubmatch(settings, -1) {
+		add(m[1])
+	}
+	return ret
+}
+
+// gradleProjectPath normalizes the project path to be absolute, `a:b` => `:a:b`
+func gradleProjectPath(path string) string {
+	if !strings.HasPrefix(path, ":") {
+		return ":" + path
+	}
+	return path
+}
+
+// readGradleProperties reads the key=value lines of gradle.properties, missing file is ignored
+func readGradleProperties(path string) map[string]string {
+	ret := map[string]string{}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ret
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == '!' {
+			continue
+		}
+		k, v, ok := strings.Cut(line, "=")
+		if !ok {
+			k, v, ok = strings.Cut(line, ":")
+		}
+		if ok {
+			ret[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+	return ret
+}
+
+// findFile returns the first of the files existing in the dir
+func findFile(dir string, names []string) string {
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
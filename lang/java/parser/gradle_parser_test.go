@@ -0,0 +1,58 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestParseGradleProject(t *testing.T) {
+	root, _ := filepath.Abs("../../../testdata/java/6_gradle")
+	if !IsGradleProject(root) {
+		t.Fatal("expect a Gradle project")
+	}
+
+	rootModule, err := ParseProject(root)
+	if err != nil {
+		t.Fatalf("Error parsing root project: %v", err)
+	}
+	if rootModule.Coordinates != "com.example:my-gradle-app:1.2.0" || rootModule.GradlePath != ":" {
+		t.Errorf("unexpected root project %s (%s)", rootModule.Coordinates, rootModule.GradlePath)
+	}
+
+	want := []struct {
+		coords, path, dir string
+	}{
+		{"com.example:app:1.2.0", ":app", "app"},
+		{"com.example:core:2.0.0", ":core", "libs/core"},
+	}
+	if len(rootModule.SubModules) != len(want) {
+		t.Fatalf("Expected %d submodules, but got %d", len(want), len(rootModule.SubModules))
+	}
+	for i, w := range want {
+		sub := rootModule.SubModules[i]
+		if sub.Coordinates != w.coords || sub.GradlePath != w.path || sub.Path != filepath.Join(root, w.dir) {
+			t.Errorf("unexpected submodule %s (%s) at %s", sub.Coordinates, sub.GradlePath, sub.Path)
+		}
+		if sub.SourcePath != filepath.Join(root, w.dir, "src", "main", "java") {
+			t.Errorf("unexpected source path %s", sub.SourcePath)
+		}
+	}
+
+	if _, err := ParseGradleProject(t.TempDir()); err == nil {
+		t.Error("expect error without Gradle files")
+	}
+}
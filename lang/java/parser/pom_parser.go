@@ -35,6 +35,8 @@ type ModuleInfo struct {
 	TargetPath     string
 	SubModules     []*ModuleInfo
 	Properties     map[string]string
+	// GradlePath is the path of the Gradle project like `:app`, empty for Maven modules
+	GradlePath string
 }
 
 // ParseMavenProject recursively parses a module and its submodules.
@@ -15,7 +15,6 @@
 package java
 
 import (
-	"path/filepath"
 	"strings"
 
 	javaparser "github.com/cloudwego/abcoder/lang/java/parser"
@@ -43,8 +42,7 @@ type JavaPkg struct {
 }
 
 func NewJavaSpec(reop string) *JavaSpec {
-	rootPomPath := filepath.Join(reop, "pom.xml")
-	rootModule, err := javaparser.ParseMavenProject(rootPomPath)
+	rootModule, err := javaparser.ParseProject(reop)
 	if err != nil {
 		return &JavaSpec{
 			repo:      reop,
@@ -257,6 +255,18 @@ func (c *JavaSpec) IsPublicSymbol(sym lsp.DocumentSymbol) bool {
 	return strings.Contains(symbolText, "public")
 }
 
+// Visibility tells the visibility by the access modifier of the symbol.
+// Symbols without one are package-private, unless IsPublicSymbol finds them public (like interface methods)
+func (c *JavaSpec) Visibility(sym lsp.DocumentSymbol) uniast.Visibility {
+	if v := Visibility(sym.Text); v != "" {
+		return v
+	}
+	if c.IsPublicSymbol(sym) {
+		return uniast.VisibilityPublic
+	}
+	return uniast.VisibilityPackage
+}
+
 func (c *JavaSpec) HasImplSymbol() bool {
 	// For Java `implements` and `extends`
 	return false
@@ -0,0 +1,81 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lang
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/cloudwego/abcoder/lang/uniast"
+	"golang.org/x/mod/module"
+)
+
+// dependencyDir returns the source dir of the dependency in the repo at dir, empty if not found locally.
+// Go modules are looked up in the vendor dir and the module cache, rust crates in the cargo registry
+func dependencyDir(dir string, dep uniast.ExternalDependency) string {
+	switch dep.Language {
+	case uniast.Golang:
+		if d := filepath.Join(dir, "vendor", filepath.FromSlash(dep.Name)); isDir(d) {
+			return d
+		}
+		if dep.Version == "" {
+			return ""
+		}
+		cache := goModCache()
+		if cache == "" {
+			return ""
+		}
+		path, err := module.EscapePath(dep.Name)
+		if err != nil {
+			return ""
+		}
+		if d := filepath.Join(cache, filepath.FromSlash(path)+"@"+dep.Version); isDir(d) {
+			return d
+		}
+	case uniast.Rust:
+		home, err := os.UserHomeDir()
+		if err != nil || dep.Version == "" {
+			return ""
+		}
+		cargo := os.Getenv("CARGO_HOME")
+		if cargo == "" {
+			cargo = filepath.Join(home, ".cargo")
+		}
+		ds, _ := filepath.Glob(filepath.Join(cargo, "registry", "src", "*", dep.Name+"-"+dep.Version))
+		if len(ds) > 0 {
+			return ds[0]
+		}
+	}
+	return ""
+}
+
+// goModCache returns GOMODCACHE, empty if go is not installed
+func goModCache() string {
+	if d := os.Getenv("GOMODCACHE"); d != "" {
+		return d
+	}
+	out, err := exec.Command("go", "env", "GOMODCACHE").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
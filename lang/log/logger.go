@@ -12,70 +12,373 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
+// Package log is the leveled logger of abcoder.
+//
+// Records are scoped by the packages logging them, like `lang/collect` or `lang/lsp`,
+// whose levels can be set separately by SetLevels, e.g. "info,collect=debug,lsp=warn".
+// They are written to stderr as text by default, or as JSON lines by SetFormat(FormatJSON),
+// with the key-value fields given to Infow, Debugw and so on.
 package log
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
-	"log"
+	"io"
 	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type LogLevel uint8
 
 const (
 	ErrorLevel LogLevel = 1
-	InfoLevel  LogLevel = 2
-	DebugLevel LogLevel = 3
+	WarnLevel  LogLevel = 2
+	InfoLevel  LogLevel = 3
+	DebugLevel LogLevel = 4
 )
 
+func (l LogLevel) String() string {
+	switch l {
+	case ErrorLevel:
+		return "error"
+	case WarnLevel:
+		return "warn"
+	case InfoLevel:
+		return "info"
+	case DebugLevel:
+		return "debug"
+	default:
+		return "level(" + strconv.Itoa(int(l)) + ")"
+	}
+}
+
+// ParseLevel parses the level name: error, warn (warning), info or debug
+func ParseLevel(name string) (LogLevel, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "error":
+		return ErrorLevel, nil
+	case "warn", "warning":
+		return WarnLevel, nil
+	case "info":
+		return InfoLevel, nil
+	case "debug":
+		return DebugLevel, nil
+	default:
+		return 0, fmt.Errorf("unknown log level: %s", name)
+	}
+}
+
+// Format is the output format of records
+type Format string
+
+const (
+	// FormatText writes the records like `[INFO]15:04:05 collect.go:12: message key=value`
+	FormatText Format = "text"
+	// FormatJSON writes the records as JSON lines of time, level, scope, caller, msg and the fields
+	FormatJSON Format = "json"
+)
+
+// modulePrefix is trimmed from the package paths to make the scopes
+const modulePrefix = "github.com/cloudwego/abcoder/"
+
+// scopeAliases are the subsystem names accepted by SetLevels besides the package paths
+var scopeAliases = map[string]string{
+	"collector": "lang/collect",
+}
+
+type scopeLevel struct {
+	scope string
+	level LogLevel
+}
+
 var (
-	errlogger   = log.New(os.Stderr, "[ERROR]", log.Ltime|log.Lshortfile)
-	infologger  = log.New(os.Stderr, "[INFO]", log.Ltime|log.Lshortfile)
-	debuglogger = log.New(os.Stderr, "[DEBUG]", log.Ltime|log.Lshortfile)
+	logLevel atomic.Uint32
+	// the max level of the default one and the scoped ones, to skip the disabled records quickly
+	maxLevel atomic.Uint32
+	format   atomic.Value
+
+	levelsMu sync.RWMutex
+	// scoped levels, the longest scope first
+	scopeLevels []scopeLevel
+	// scope => level, reset when the levels change
+	levelCache sync.Map
+	// pc => scope of the function
+	scopeCache sync.Map
+
+	outMu  sync.Mutex
+	output io.Writer = os.Stderr
 )
 
-var logLevel LogLevel = ErrorLevel
+func init() {
+	logLevel.Store(uint32(ErrorLevel))
+	maxLevel.Store(uint32(ErrorLevel))
+	format.Store(FormatText)
+}
 
+// SetLogLevel sets the level of the scopes without their own levels
 func SetLogLevel(level LogLevel) {
-	logLevel = level
+	levelsMu.Lock()
+	defer levelsMu.Unlock()
+	logLevel.Store(uint32(level))
+	resetLevelsLocked()
 }
 
-func Info(s string, args ...interface{}) {
-	if logLevel < InfoLevel {
-		return
+// SetLevels sets the levels by the spec, which is comma-separated `level` for the default one
+// or `scope=level` for a scope, e.g. "info,collect=debug,lsp=warn".
+// A scope matches the package paths ending with it, like `parser` matches both `lang/golang/parser`
+// and `lang/java/parser`, and the longest matching scope wins. The scopes not in the spec keep their levels
+func SetLevels(spec string) error {
+	levelsMu.Lock()
+	defer levelsMu.Unlock()
+	for _, part := range strings.Split(spec, ",") {
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+		scope, name, ok := strings.Cut(part, "=")
+		if !ok {
+			name, scope = scope, ""
+		}
+		level, err := ParseLevel(name)
+		if err != nil {
+			return err
+		}
+		if scope = strings.Trim(strings.TrimSpace(scope), "/"); scope == "" {
+			logLevel.Store(uint32(level))
+			continue
+		}
+		if alias, ok := scopeAliases[scope]; ok {
+			scope = alias
+		}
+		replaced := false
+		for i := range scopeLevels {
+			if scopeLevels[i].scope == scope {
+				scopeLevels[i].level, replaced = level, true
+			}
+		}
+		if !replaced {
+			scopeLevels = append(scopeLevels, scopeLevel{scope: scope, level: level})
+		}
 	}
-	s = fmt.Sprintf(s, args...)
-	infologger.Output(2, s)
+	sort.SliceStable(scopeLevels, func(i, j int) bool { return len(scopeLevels[i].scope) > len(scopeLevels[j].scope) })
+	resetLevelsLocked()
+	return nil
 }
 
-func Info_skip(calldepth int, s string, args ...interface{}) {
-	if logLevel < InfoLevel {
+func resetLevelsLocked() {
+	max := logLevel.Load()
+	for _, sl := range scopeLevels {
+		if uint32(sl.level) > max {
+			max = uint32(sl.level)
+		}
+	}
+	maxLevel.Store(max)
+	levelCache.Range(func(k, _ any) bool {
+		levelCache.Delete(k)
+		return true
+	})
+}
+
+// SetFormat sets the output format
+func SetFormat(f Format) error {
+	switch f {
+	case FormatText, FormatJSON:
+		format.Store(f)
+		return nil
+	default:
+		return fmt.Errorf("unknown log format: %s", f)
+	}
+}
+
+// SetOutput sets the writer of the records, stderr by default
+func SetOutput(w io.Writer) {
+	outMu.Lock()
+	defer outMu.Unlock()
+	output = w
+}
+
+// levelOf returns the level of the scope
+func levelOf(scope string) LogLevel {
+	if l, ok := levelCache.Load(scope); ok {
+		return l.(LogLevel)
+	}
+	levelsMu.RLock()
+	level := LogLevel(logLevel.Load())
+	for _, sl := range scopeLevels {
+		if scope == sl.scope || strings.HasSuffix(scope, "/"+sl.scope) {
+			level = sl.level
+			break
+		}
+	}
+	levelsMu.RUnlock()
+	levelCache.Store(scope, level)
+	return level
+}
+
+// caller returns the pc of the caller, skip is the number of frames above the caller of caller
+func caller(skip int) uintptr {
+	var pcs [1]uintptr
+	if runtime.Callers(skip+3, pcs[:]) == 0 {
+		return 0
+	}
+	return pcs[0]
+}
+
+// scopeOf returns the package path of the function at pc, without the module prefix
+func scopeOf(pc uintptr) string {
+	if s, ok := scopeCache.Load(pc); ok {
+		return s.(string)
+	}
+	var scope string
+	if fn := runtime.FuncForPC(pc); fn != nil {
+		// like github.com/cloudwego/abcoder/lang/collect.(*Collector).Export
+		name := fn.Name()
+		slash := strings.LastIndexByte(name, '/')
+		if dot := strings.IndexByte(name[slash+1:], '.'); dot >= 0 {
+			name = name[:slash+1+dot]
+		}
+		scope = strings.TrimPrefix(name, modulePrefix)
+	}
+	scopeCache.Store(pc, scope)
+	return scope
+}
+
+// Enabled tells if the records of the level are written for the caller,
+// skip is the number of frames above the caller of Enabled
+func Enabled(level LogLevel, skip int) bool {
+	if uint32(level) > maxLevel.Load() {
+		return false
+	}
+	if uint32(level) <= logLevel.Load() && !hasScopeLevels() {
+		return true
+	}
+	return level <= levelOf(scopeOf(caller(skip)))
+}
+
+func hasScopeLevels() bool {
+	levelsMu.RLock()
+	defer levelsMu.RUnlock()
+	return len(scopeLevels) > 0
+}
+
+// Output writes the record if the level is enabled for the caller,
+// skip is the number of frames above the caller of Output, kv are the key-value fields
+func Output(level LogLevel, skip int, msg string, kv ...any) {
+	if uint32(level) > maxLevel.Load() {
 		return
 	}
-	s = fmt.Sprintf(s, args...)
-	infologger.Output(2+calldepth, s)
+	pc := caller(skip)
+	scope := scopeOf(pc)
+	if level > levelOf(scope) {
+		return
+	}
+	file, line := "???", 0
+	if pc != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+		file, line = filepath.Base(frame.File), frame.Line
+	}
+	msg = strings.TrimRight(msg, "\n")
+	now := time.Now()
+
+	var buf bytes.Buffer
+	if format.Load().(Format) == FormatJSON {
+		rec := map[string]any{}
+		for i := 0; i+1 < len(kv); i += 2 {
+			rec[fmt.Sprint(kv[i])] = fieldValue(kv[i+1])
+		}
+		rec["time"] = now.Format(time.RFC3339Nano)
+		rec["level"] = level.String()
+		rec["scope"] = scope
+		rec["caller"] = file + ":" + strconv.Itoa(line)
+		rec["msg"] = msg
+		js, err := json.Marshal(rec)
+		if err != nil {
+			js, _ = json.Marshal(map[string]any{"time": rec["time"], "level": rec["level"], "scope": scope, "caller": rec["caller"], "msg": msg, "error": err.Error()})
+		}
+		buf.Write(js)
+	} else {
+		buf.WriteString("[" + strings.ToUpper(level.String()) + "]")
+		buf.WriteString(now.Format("15:04:05 "))
+		buf.WriteString(file + ":" + strconv.Itoa(line) + ": ")
+		buf.WriteString(msg)
+		for i := 0; i+1 < len(kv); i += 2 {
+			v := fmt.Sprint(fieldValue(kv[i+1]))
+			if strings.ContainsAny(v, " \t\n\"=") {
+				v = strconv.Quote(v)
+			}
+			fmt.Fprintf(&buf, " %v=%s", kv[i], v)
+		}
+	}
+	buf.WriteByte('\n')
+	outMu.Lock()
+	defer outMu.Unlock()
+	_, _ = output.Write(buf.Bytes())
+}
+
+// fieldValue converts the values which are not marshaled well
+func fieldValue(v any) any {
+	switch x := v.(type) {
+	case error:
+		return x.Error()
+	case time.Duration:
+		return x.String()
+	case fmt.Stringer:
+		return x.String()
+	default:
+		return v
+	}
+}
+
+func Info(s string, args ...interface{}) {
+	Output(InfoLevel, 1, fmt.Sprintf(s, args...))
+}
+
+func Info_skip(calldepth int, s string, args ...interface{}) {
+	Output(InfoLevel, 1+calldepth, fmt.Sprintf(s, args...))
 }
 
 func Debug(s string, args ...interface{}) {
-	if logLevel < DebugLevel {
+	if uint32(DebugLevel) > maxLevel.Load() {
 		return
 	}
-	s = fmt.Sprintf(s, args...)
-	debuglogger.Output(2, s)
+	Output(DebugLevel, 1, fmt.Sprintf(s, args...))
+}
+
+func Warn(s string, args ...interface{}) {
+	Output(WarnLevel, 1, fmt.Sprintf(s, args...))
 }
 
 func Error(s string, args ...interface{}) {
-	if logLevel < ErrorLevel {
-		return
-	}
-	s = fmt.Sprintf(s, args...)
-	errlogger.Output(2, s)
+	Output(ErrorLevel, 1, fmt.Sprintf(s, args...))
 }
 
 func Error_skip(calldepth int, s string, args ...interface{}) {
-	if logLevel < ErrorLevel {
-		return
-	}
-	s = fmt.Sprintf(s, args...)
-	errlogger.Output(2+calldepth, s)
+	Output(ErrorLevel, 1+calldepth, fmt.Sprintf(s, args...))
+}
+
+// Infow writes the message with the key-value fields, like Infow("parsed", "files", 10, "cost", time.Second)
+func Infow(msg string, kv ...any) {
+	Output(InfoLevel, 1, msg, kv...)
+}
+
+// Debugw is the Debug level Infow
+func Debugw(msg string, kv ...any) {
+	Output(DebugLevel, 1, msg, kv...)
+}
+
+// Warnw is the Warn level Infow
+func Warnw(msg string, kv ...any) {
+	Output(WarnLevel, 1, msg, kv...)
+}
+
+// Errorw is the Error level Infow
+func Errorw(msg string, kv ...any) {
+	Output(ErrorLevel, 1, msg, kv...)
 }
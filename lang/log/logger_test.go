@@ -0,0 +1,99 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+func resetLog(t *testing.T) *bytes.Buffer {
+	var buf bytes.Buffer
+	SetOutput(&buf)
+	t.Cleanup(func() {
+		SetOutput(os.Stderr)
+		levelsMu.Lock()
+		scopeLevels = nil
+		levelsMu.Unlock()
+		SetLogLevel(ErrorLevel)
+		_ = SetFormat(FormatText)
+	})
+	levelsMu.Lock()
+	scopeLevels = nil
+	levelsMu.Unlock()
+	SetLogLevel(ErrorLevel)
+	return &buf
+}
+
+func TestSetLevels(t *testing.T) {
+	buf := resetLog(t)
+	if err := SetLevels("warn,collector=debug,lsp=error"); err != nil {
+		t.Fatal(err)
+	}
+	if got := levelOf("lang/collect"); got != DebugLevel {
+		t.Errorf("collect level = %v", got)
+	}
+	if got := levelOf("lang/lsp"); got != ErrorLevel {
+		t.Errorf("lsp level = %v", got)
+	}
+	if got := levelOf("lang/golang/parser"); got != WarnLevel {
+		t.Errorf("parser level = %v", got)
+	}
+
+	Info("hidden")
+	Warn("shown %d", 1)
+	if err := SetLevels("log=debug"); err != nil {
+		t.Fatal(err)
+	}
+	Debugw("fields", "n", 2, "msg", "a b")
+	out := buf.String()
+	if strings.Contains(out, "hidden") {
+		t.Errorf("info should be disabled: %s", out)
+	}
+	if !strings.Contains(out, "[WARN]") || !strings.Contains(out, "logger_test.go") || !strings.Contains(out, "shown 1\n") {
+		t.Errorf("unexpected warn record: %s", out)
+	}
+	if !strings.Contains(out, `fields n=2 msg="a b"`) {
+		t.Errorf("unexpected debug record: %s", out)
+	}
+
+	if err := SetLevels("verbose"); err == nil {
+		t.Error("expect error for unknown level")
+	}
+}
+
+func TestFormatJSON(t *testing.T) {
+	buf := resetLog(t)
+	SetLogLevel(InfoLevel)
+	if err := SetFormat(FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	Infow("parsed\n", "files", 3, "err", bytes.ErrTooLarge)
+	var rec map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatalf("invalid record %q: %v", buf.String(), err)
+	}
+	if rec["level"] != "info" || rec["scope"] != "lang/log" || rec["msg"] != "parsed" ||
+		rec["files"] != float64(3) || rec["err"] != bytes.ErrTooLarge.Error() ||
+		!strings.HasPrefix(rec["caller"].(string), "logger_test.go:") {
+		t.Errorf("unexpected record: %v", rec)
+	}
+	if err := SetFormat("xml"); err == nil {
+		t.Error("expect error for unknown format")
+	}
+}
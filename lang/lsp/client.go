@@ -25,6 +25,7 @@ import (
 	"os/exec"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	retry "github.com/avast/retry-go/v4"
@@ -57,6 +58,10 @@ type LSPClient struct {
 
 	ClientOptions
 	LspOptions map[string]string
+	// adjuster fixes up the document symbols before they are cached, see SetSymbolAdjuster
+	adjuster SymbolAdjuster
+	// wsIndex completes the weak document symbols, see completeSymbols
+	wsIndex workspaceIndex
 
 	// --- restart resilience: clangd can segfault (e.g. in typeParents on
 	// pathological template typeHierarchy), which closes the jsonrpc2 conn
@@ -67,26 +72,91 @@ type LSPClient struct {
 	connMu      sync.RWMutex
 	gen         uint64
 	repoURI     DocumentURI
-	autoRestart bool // respawn the server on connection loss (C++ only)
+	autoRestart bool // respawn the server on connection loss or hang
 	restartMu   sync.Mutex
 	restarts    int
+	// server is the stdio of the live server process, killed when it hangs, or the connection to the remote server
+	server io.ReadWriteCloser
+	closed atomic.Bool
 }
 
+// SetSymbolAdjuster sets the adjuster of the document symbols reported by the server.
+// It must be called before any DocumentSymbols request
+func (cli *LSPClient) SetSymbolAdjuster(adj SymbolAdjuster) {
+	cli.adjuster = adj
+}
+
+const (
+	// DefaultRequestTimeout is how long a request waits for the response by default
+	DefaultRequestTimeout = 5 * time.Minute
+	// DefaultMaxRestarts is the default limit of restarts of the server
+	DefaultMaxRestarts = 10
+	// DefaultMaxRetries is the default limit of retries of a request failed by a crash or hang
+	DefaultMaxRetries = 2
+)
+
+// ErrServerHung means the server didn't respond a request within ClientOptions.RequestTimeout
+var ErrServerHung = errors.New("LSP server hung")
+
 type ClientOptions struct {
+	// Server is the executable of the server with its args, or the address of a running server
+	// like `tcp://host:port` or `ws://host:port/path`, see IsRemoteServer
 	Server string
+	// RemoteRoot is the path of the repo on the remote server if it differs from the local one, e.g. in a devcontainer.
+	// The URIs under the repo are mapped between the local and remote paths
+	RemoteRoot string
 	uniast.Language
 	Verbose               bool
 	InitializationOptions interface{}
+	// RequestTimeout is how long a request waits for the response, beyond which the server is taken as hung and restarted.
+	// DefaultRequestTimeout if 0, never times out if negative
+	RequestTimeout time.Duration
+	// MaxRestarts limits the restarts of the crashed or hung server during the client's life.
+	// DefaultMaxRestarts if 0, never restarts if negative
+	MaxRestarts int
+	// MaxRetries limits the retries of a request failed by a crash or hang, after the server restarts.
+	// DefaultMaxRetries if 0 (none for C++, since clangd crashes on the same request again), never retries if negative
+	MaxRetries int
+	// TraceDir records the messages exchanged with the server into a trace file under the dir if set,
+	// which can be replayed by the Server `replay://<trace file>`, see NewReplayServer
+	TraceDir string
+	// WorkspaceSymbolFallback completes every documentSymbol result by workspace/symbol, for the servers reporting sparse document symbols.
+	// Otherwise only the results of the servers without documentSymbol, or empty for non-blank files, are completed
+	WorkspaceSymbolFallback bool
+}
+
+func (o ClientOptions) requestTimeout() time.Duration {
+	if o.RequestTimeout == 0 {
+		return DefaultRequestTimeout
+	}
+	return o.RequestTimeout
+}
+
+func (o ClientOptions) maxRestarts() int {
+	if o.MaxRestarts == 0 {
+		return DefaultMaxRestarts
+	}
+	return max(o.MaxRestarts, 0)
+}
+
+func (o ClientOptions) maxRetries() int {
+	if o.MaxRetries == 0 {
+		if o.Language == uniast.Cpp {
+			return 0
+		}
+		return DefaultMaxRetries
+	}
+	return max(o.MaxRetries, 0)
 }
 
 func NewLSPClient(repo string, openfile string, wait time.Duration, opts ClientOptions) (*LSPClient, error) {
-	// launch golang LSP server
-	svr, err := startLSPSever(opts.Server, opts)
+	// launch the LSP server, or connect to the remote one
+	svr, err := connectLSPServer(opts)
 	if err != nil {
 		return nil, err
 	}
 
-	cli, err := initLSPClient(context.Background(), svr, NewURI(repo), opts.Verbose, opts.Language, opts.InitializationOptions)
+	cli, err := initLSPClient(context.Background(), svr, NewURI(repo), opts)
 	if err != nil {
 		return nil, err
 	}
@@ -97,11 +167,11 @@ func NewLSPClient(repo string, openfile string, wait time.Duration, opts ClientO
 	cli.provider = GetProvider(opts.Language)
 	cli.Verbose = opts.Verbose
 
-	// restart resilience: remember how to respawn, enable for C++ (the only
-	// language whose server is known to crash mid-parse). gen starts at 1.
+	// restart resilience: remember how to respawn. gen starts at 1.
 	cli.repoURI = NewURI(repo)
-	cli.autoRestart = opts.Language == uniast.Cpp
+	cli.autoRestart = opts.maxRestarts() > 0
 	cli.gen = 1
+	cli.watch(cli.Conn, cli.gen)
 
 	if openfile != "" {
 		_, err := cli.DidOpen(context.Background(), NewURI(openfile))
@@ -115,7 +185,19 @@ func NewLSPClient(repo string, openfile string, wait time.Duration, opts ClientO
 	return cli, nil
 }
 
+// Diagnostics returns the latest diagnostics published by the server for the document
+func (c *LSPClient) Diagnostics(uri DocumentURI) []uniast.Diagnostic {
+	c.connMu.RLock()
+	h := c.lspHandler
+	c.connMu.RUnlock()
+	if h == nil {
+		return nil
+	}
+	return h.diagnostics.Get(uri)
+}
+
 func (c *LSPClient) Close() error {
+	c.closed.Store(true)
 	c.connMu.RLock()
 	conn := c.Conn
 	h := c.lspHandler
@@ -166,14 +248,31 @@ func IsConnClosed(err error) bool {
 		strings.Contains(s, "use of closed network connection")
 }
 
+// watch restarts the server once the connection of the generation is lost, e.g. the server exits,
+// thus the crash is detected even if no request is in flight
+func (cli *LSPClient) watch(conn *jsonrpc2.Conn, gen uint64) {
+	if conn == nil || !cli.autoRestart {
+		return
+	}
+	go func() {
+		<-conn.DisconnectNotify()
+		if cli.closed.Load() {
+			return
+		}
+		cli.maybeRestart(gen)
+	}()
+}
+
 // maybeRestart respawns the LSP server iff it hasn't already been restarted
 // since the caller captured observedGen. Safe to call from many goroutines
 // that all observed the same dead connection — only the first wins; the rest
-// see a bumped generation and return. After a restart, every cached document
-// is marked not-opened so the next DidOpen re-notifies the fresh server.
-func (cli *LSPClient) maybeRestart(observedGen uint64) {
-	if !cli.autoRestart {
-		return
+// see a bumped generation and return. The documents opened on the old server
+// are opened on the fresh one before it takes over, others are marked
+// not-opened so the next DidOpen notifies it.
+// It returns true if a server newer than observedGen is live, thus the failed request can be retried
+func (cli *LSPClient) maybeRestart(observedGen uint64) bool {
+	if !cli.autoRestart || cli.closed.Load() {
+		return false
 	}
 	cli.restartMu.Lock()
 	defer cli.restartMu.Unlock()
@@ -181,24 +280,43 @@ func (cli *LSPClient) maybeRestart(observedGen uint64) {
 	cur := cli.gen
 	cli.connMu.RUnlock()
 	if cur != observedGen {
-		return // already restarted by another goroutine
+		return true // already restarted by another goroutine
+	}
+	if cli.restarts >= cli.maxRestarts() {
+		log.Error("LSP server connection lost, and the restarts reach the limit %d\n", cli.maxRestarts())
+		return false
 	}
 	log.Error("LSP server connection lost; restarting (restart #%d)...", cli.restarts+1)
-	svr, err := startLSPSever(cli.Server, cli.ClientOptions)
+	// kill the old server in case it hung
+	cli.connMu.RLock()
+	oldSvr := cli.server
+	cli.connMu.RUnlock()
+	if r, ok := oldSvr.(rwc); ok {
+		r.kill()
+	}
+	cli.restarts++
+	svr, err := connectLSPServer(cli.ClientOptions)
 	if err != nil {
 		log.Error("LSP restart: failed to start server: %v", err)
-		return
+		return false
 	}
-	newcli, err := initLSPClient(context.Background(), svr, cli.repoURI, cli.Verbose, cli.Language, cli.InitializationOptions)
+	newcli, err := initLSPClient(context.Background(), svr, cli.repoURI, cli.ClientOptions)
 	if err != nil {
 		log.Error("LSP restart: failed to init server: %v", err)
-		return
+		_ = svr.Close()
+		return false
 	}
+	reopened := cli.reopenFiles(newcli.Conn)
 	cli.connMu.Lock()
 	oldConn := cli.Conn
 	oldH := cli.lspHandler
+	if oldH != nil {
+		// keep the diagnostics published by the crashed server
+		newcli.lspHandler.diagnostics.Merge(oldH.diagnostics)
+	}
 	cli.Conn = newcli.Conn
 	cli.lspHandler = newcli.lspHandler
+	cli.server = newcli.server
 	if len(newcli.tokenTypes) > 0 {
 		cli.tokenTypes = newcli.tokenTypes
 	}
@@ -208,34 +326,48 @@ func (cli *LSPClient) maybeRestart(observedGen uint64) {
 	cli.gen++
 	newGen := cli.gen
 	cli.connMu.Unlock()
-	cli.restarts++
-	cli.resetServerOpened()
 	if oldH != nil {
 		oldH.Close()
 	}
 	if oldConn != nil {
 		_ = oldConn.Close()
 	}
-	log.Error("LSP server restarted (gen=%d).", newGen)
+	cli.watch(newcli.Conn, newGen)
+	log.Error("LSP server restarted (gen=%d), %d documents reopened.", newGen, reopened)
+	return true
 }
 
-// resetServerOpened marks every cached document as not-yet-opened on the
-// (new) server so DidOpen re-sends textDocument/didOpen after a restart.
-func (cli *LSPClient) resetServerOpened() {
+// reopenFiles sends textDocument/didOpen of the documents opened on the old server
+// to the new one, through conn since the client still uses the old one.
+// Documents failed to reopen are marked not-opened, thus the next DidOpen retries
+func (cli *LSPClient) reopenFiles(conn *jsonrpc2.Conn) int {
 	cli.filesMu.RLock()
 	fs := make([]*TextDocumentItem, 0, len(cli.files))
 	for _, f := range cli.files {
 		fs = append(fs, f)
 	}
 	cli.filesMu.RUnlock()
+	n := 0
 	for _, f := range fs {
 		if f == nil || f.Mu == nil {
 			continue
 		}
 		f.Mu.Lock()
-		f.ServerOpened = false
+		opened := f.ServerOpened
+		params := DidOpenTextDocumentParams{TextDocument: *f}
 		f.Mu.Unlock()
+		if !opened {
+			continue
+		}
+		if err := conn.Notify(context.Background(), "textDocument/didOpen", params); err != nil {
+			f.Mu.Lock()
+			f.ServerOpened = false
+			f.Mu.Unlock()
+			continue
+		}
+		n++
 	}
+	return n
 }
 
 // Extra wrapper around jsonrpc2 that retries transient RPC failures.
@@ -245,20 +377,50 @@ func (cli *LSPClient) resetServerOpened() {
 // brief pause. We retry up to 3 attempts with a fixed 50ms gap and skip
 // retry for terminal errors: MethodNotFound (-32601, server doesn't
 // implement the endpoint) and context cancellation (caller bailed).
+//
+// If the server crashes or hangs (no response within RequestTimeout),
+// it is restarted and the request is retried on the fresh server up to
+// MaxRetries times.
 func (cli *LSPClient) Call(ctx context.Context, method string, params, result any, opts ...jsonrpc2.CallOption) error {
-	conn, gen := cli.curConn()
-	var raw json.RawMessage
-	err := conn.Call(ctx, method, params, &raw)
-	if err != nil && IsConnClosed(err) {
+	for attempt := 0; ; attempt++ {
+		conn, gen := cli.curConn()
+		raw, err := cli.call(ctx, conn, method, params)
+		if err == nil {
+			return json.Unmarshal(raw, result)
+		}
+		if !IsConnClosed(err) && !errors.Is(err, ErrServerHung) {
+			return err
+		}
 		// The server crashed (e.g. clangd segfault in typeParents on a
-		// pathological template typeHierarchy). Retrying on the dead conn
-		// is pointless; respawn it so subsequent symbols keep working, and
-		// surface the error so THIS call is skipped (C++ base collection
-		// falls back to collectCppBasesViaAST).
-		cli.maybeRestart(gen)
-		return err
+		// pathological template typeHierarchy) or hung. Retrying on the
+		// dead conn is pointless; respawn it so subsequent symbols keep
+		// working. For C++ the error is surfaced by default so THIS call
+		// is skipped (C++ base collection falls back to collectCppBasesViaAST).
+		if !cli.maybeRestart(gen) || attempt >= cli.maxRetries() || ctx.Err() != nil {
+			return err
+		}
+		log.Warn("retry %s after the LSP server restarted (%d/%d)\n", method, attempt+1, cli.maxRetries())
 	}
-	if err != nil && shouldRetryRPC(err) {
+}
+
+// call sends the request on conn with the transient retries, the raw result is returned
+func (cli *LSPClient) call(ctx context.Context, conn *jsonrpc2.Conn, method string, params any) (json.RawMessage, error) {
+	if timeout := cli.requestTimeout(); timeout > 0 {
+		tctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		raw, err := cli.callRetry(tctx, conn, method, params)
+		if err != nil && ctx.Err() == nil && errors.Is(tctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("%w: no response of %s in %s", ErrServerHung, method, timeout)
+		}
+		return raw, err
+	}
+	return cli.callRetry(ctx, conn, method, params)
+}
+
+func (cli *LSPClient) callRetry(ctx context.Context, conn *jsonrpc2.Conn, method string, params any) (json.RawMessage, error) {
+	var raw json.RawMessage
+	err := conn.Call(ctx, method, params, &raw)
+	if err != nil && !IsConnClosed(err) && shouldRetryRPC(err) {
 		raw = nil
 		err = retry.Do(
 			func() error {
@@ -270,17 +432,10 @@ func (cli *LSPClient) Call(ctx context.Context, method string, params, result an
 			retry.Delay(50*time.Millisecond),
 			retry.DelayType(retry.FixedDelay),
 			retry.LastErrorOnly(true),
-			retry.RetryIf(shouldRetryRPC),
+			retry.RetryIf(func(err error) bool { return shouldRetryRPC(err) && !IsConnClosed(err) }),
 		)
-		if err != nil && IsConnClosed(err) {
-			cli.maybeRestart(gen)
-			return err
-		}
-	}
-	if err != nil {
-		return err
 	}
-	return json.Unmarshal(raw, result)
+	return raw, err
 }
 
 // shouldRetryRPC reports whether err is worth retrying. Terminal cases:
@@ -337,11 +492,19 @@ func (c *LSPClient) InitFiles() {
 	}
 }
 
-func initLSPClient(ctx context.Context, svr io.ReadWriteCloser, dir DocumentURI, verbose bool, language uniast.Language, InitializationOptions interface{}) (*LSPClient, error) {
+func initLSPClient(ctx context.Context, svr io.ReadWriteCloser, dir DocumentURI, opts ClientOptions) (*LSPClient, error) {
+	verbose, language := opts.Verbose, opts.Language
 	h := newLSPHandler()
-	stream := jsonrpc2.NewBufferedStream(svr, jsonrpc2.VSCodeObjectCodec{})
+	stream := newObjectStream(svr, dir, opts.RemoteRoot)
+	if opts.TraceDir != "" {
+		traced, err := newTracedStream(stream, dir, opts.TraceDir, string(language))
+		if err != nil {
+			return nil, fmt.Errorf("failed to trace the LSP messages: %v", err)
+		}
+		stream = traced
+	}
 	conn := jsonrpc2.NewConn(ctx, stream, h)
-	cli := &LSPClient{Conn: conn, lspHandler: h}
+	cli := &LSPClient{Conn: conn, lspHandler: h, server: svr}
 
 	// Initialize the LSP server
 	trace := "off"
@@ -371,7 +534,11 @@ func initLSPClient(ctx context.Context, svr io.ReadWriteCloser, dir DocumentURI,
 		Capabilities:          cs,
 		Trace:                 lsp.Trace(trace),
 		ClientInfo:            lsp.ClientInfo{Name: "vscode"},
-		InitializationOptions: InitializationOptions,
+		InitializationOptions: opts.InitializationOptions,
+	}
+	if IsRemoteServer(opts.Server) {
+		// the server would exit if it watches the pid, which is not on its host
+		initParams.ProcessID = 0
 	}
 
 	var initResult initializeResult
@@ -388,10 +555,8 @@ func initLSPClient(ctx context.Context, svr io.ReadWriteCloser, dir DocumentURI,
 	if !ok || !definitionProvider {
 		return nil, fmt.Errorf("server did not provide Definition")
 	}
-	typeDefinitionProvider, ok := vs["typeDefinitionProvider"].(bool)
-	if !ok || !typeDefinitionProvider {
-		return nil, fmt.Errorf("server did not provide TypeDefinition")
-	}
+	// TypeDefinition is optional: the collector never asks it, and the servers of
+	// dynamic languages like solargraph don't provide it
 
 	documentSymbolProvider, ok := vs["documentSymbolProvider"].(bool)
 	if !ok || !documentSymbolProvider {
@@ -452,6 +617,13 @@ func (rwc rwc) Close() error {
 	return rwc.cmd.Wait()
 }
 
+// kill kills the server process, which may hang
+func (rwc rwc) kill() {
+	if rwc.cmd != nil && rwc.cmd.Process != nil {
+		_ = rwc.cmd.Process.Kill()
+	}
+}
+
 // start a LSP process and return its io
 func startLSPSever(path string, opts ClientOptions) (io.ReadWriteCloser, error) {
 
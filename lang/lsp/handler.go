@@ -17,26 +17,31 @@ package lsp
 import (
 	"container/list"
 	"context"
+	"encoding/json"
 	"sync"
 	"time"
 
 	"github.com/cloudwego/abcoder/lang/log"
+	"github.com/cloudwego/abcoder/lang/uniast"
+	lsp "github.com/sourcegraph/go-lsp"
 	"github.com/sourcegraph/jsonrpc2"
 )
 
 type lspHandler struct {
-	notify  chan *jsonrpc2.Request
-	mutex   *sync.RWMutex
-	history *list.List
-	close   chan struct{}
+	notify      chan *jsonrpc2.Request
+	mutex       *sync.RWMutex
+	history     *list.List
+	close       chan struct{}
+	diagnostics *diagnosticStore
 }
 
 func newLSPHandler() *lspHandler {
 	ret := &lspHandler{
-		notify:  make(chan *jsonrpc2.Request, 1),
-		history: list.New(),
-		mutex:   &sync.RWMutex{},
-		close:   make(chan struct{}),
+		notify:      make(chan *jsonrpc2.Request, 1),
+		history:     list.New(),
+		mutex:       &sync.RWMutex{},
+		close:       make(chan struct{}),
+		diagnostics: newDiagnosticStore(),
 	}
 	// ticker to clean history
 	go func() {
@@ -129,6 +134,14 @@ func (h *lspHandler) handleNotification(ctx context.Context, conn *jsonrpc2.Conn
 	case "textDocument/publishDiagnostics":
 		// This notification is sent from the server to the client to signal results of validation runs.
 		log.Debug("Received publishDiagnostics notification:\n%s\n", string(*req.Params))
+		if req.Params != nil {
+			var params publishDiagnosticsParams
+			if err := json.Unmarshal(*req.Params, &params); err != nil {
+				log.Error("unmarshal publishDiagnostics failed: %v\n", err)
+				return
+			}
+			h.diagnostics.Set(params.URI, params.Diagnostics)
+		}
 		return
 	// exit
 	case "exit":
@@ -145,3 +158,88 @@ func (h *lspHandler) handleNotification(ctx context.Context, conn *jsonrpc2.Conn
 func (h *lspHandler) Close() {
 	close(h.close)
 }
+
+// publishDiagnosticsParams is lsp.PublishDiagnosticsParams without `code`,
+// which can be either number or string among servers
+type publishDiagnosticsParams struct {
+	URI         DocumentURI     `json:"uri"`
+	Diagnostics []lspDiagnostic `json:"diagnostics"`
+}
+
+type lspDiagnostic struct {
+	Range    lsp.Range `json:"range"`
+	Severity int       `json:"severity,omitempty"`
+	Source   string    `json:"source,omitempty"`
+	Message  string    `json:"message"`
+}
+
+// diagnosticStore keeps the latest diagnostics published for each document.
+// It is shared by handlers across server restarts.
+type diagnosticStore struct {
+	mu    sync.RWMutex
+	files map[DocumentURI][]lspDiagnostic
+}
+
+func newDiagnosticStore() *diagnosticStore {
+	return &diagnosticStore{files: map[DocumentURI][]lspDiagnostic{}}
+}
+
+// Set replaces the diagnostics of uri, as each publish carries the full set of the document
+func (s *diagnosticStore) Set(uri DocumentURI, ds []lspDiagnostic) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(ds) == 0 {
+		delete(s.files, uri)
+		return
+	}
+	s.files[uri] = ds
+}
+
+// Merge copies the documents of other which are not published in s yet
+func (s *diagnosticStore) Merge(other *diagnosticStore) {
+	other.mu.RLock()
+	defer other.mu.RUnlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for uri, ds := range other.files {
+		if _, ok := s.files[uri]; !ok {
+			s.files[uri] = ds
+		}
+	}
+}
+
+func (s *diagnosticStore) Get(uri DocumentURI) []uniast.Diagnostic {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ds := s.files[uri]
+	if len(ds) == 0 {
+		return nil
+	}
+	ret := make([]uniast.Diagnostic, 0, len(ds))
+	for _, d := range ds {
+		ret = append(ret, uniast.Diagnostic{
+			Severity:  severityOf(d.Severity),
+			Message:   d.Message,
+			Source:    d.Source,
+			Line:      d.Range.Start.Line + 1,
+			Column:    d.Range.Start.Character + 1,
+			EndLine:   d.Range.End.Line + 1,
+			EndColumn: d.Range.End.Character + 1,
+		})
+	}
+	return ret
+}
+
+func severityOf(sev int) uniast.DiagnosticSeverity {
+	switch lsp.DiagnosticSeverity(sev) {
+	case lsp.Warning:
+		return uniast.SeverityWarning
+	case lsp.Information:
+		return uniast.SeverityInformation
+	case lsp.Hint:
+		return uniast.SeverityHint
+	default:
+		// the client decides when omitted, regard it as error
+		return uniast.SeverityError
+	}
+}
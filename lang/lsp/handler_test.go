@@ -0,0 +1,68 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/cloudwego/abcoder/lang/uniast"
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+func TestLSPHandler_PublishDiagnostics(t *testing.T) {
+	h := newLSPHandler()
+	defer h.Close()
+	uri := NewURI("/repo/src/main.rs")
+	publish := func(params string) {
+		raw := json.RawMessage(params)
+		h.handleNotification(context.Background(), nil, &jsonrpc2.Request{
+			Method: "textDocument/publishDiagnostics",
+			Notif:  true,
+			Params: &raw,
+		})
+	}
+
+	// `code` may be a number
+	publish(`{"uri":"file:///repo/src/main.rs","diagnostics":[
+		{"range":{"start":{"line":1,"character":4},"end":{"line":1,"character":9}},"severity":1,"code":425,"source":"rustc","message":"cannot find value"},
+		{"range":{"start":{"line":3,"character":0},"end":{"line":3,"character":2}},"severity":2,"message":"unused variable"}
+	]}`)
+	want := []uniast.Diagnostic{
+		{Severity: uniast.SeverityError, Message: "cannot find value", Source: "rustc", Line: 2, Column: 5, EndLine: 2, EndColumn: 10},
+		{Severity: uniast.SeverityWarning, Message: "unused variable", Line: 4, Column: 1, EndLine: 4, EndColumn: 3},
+	}
+	if got := h.diagnostics.Get(uri); !reflect.DeepEqual(got, want) {
+		t.Errorf("diagnostics = %#v, want %#v", got, want)
+	}
+
+	// a restarted server keeps the published diagnostics
+	h2 := newLSPHandler()
+	defer h2.Close()
+	h2.diagnostics.Merge(h.diagnostics)
+	if got := h2.diagnostics.Get(uri); !reflect.DeepEqual(got, want) {
+		t.Errorf("merged diagnostics = %#v, want %#v", got, want)
+	}
+
+	// an empty publish clears the document
+	publish(`{"uri":"file:///repo/src/main.rs","diagnostics":[]}`)
+	if got := h.diagnostics.Get(uri); got != nil {
+		t.Errorf("diagnostics should be cleared, got %#v", got)
+	}
+}
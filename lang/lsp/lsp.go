@@ -19,6 +19,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -148,6 +150,35 @@ func (l Location) MarshalText() ([]byte, error) {
 	return []byte(l.String()), nil
 }
 
+var locationStringRegex = regexp.MustCompile(`^(.*):(\d+):(\d+)-(\d+):(\d+)$`)
+
+// UnmarshalJSON accepts both the LSP object and the inline string written by MarshalJSON
+func (l *Location) UnmarshalJSON(data []byte) error {
+	if len(data) == 0 || data[0] != '"' {
+		return json.Unmarshal(data, (*_Location)(l))
+	}
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return err
+	}
+	m := locationStringRegex.FindStringSubmatch(str)
+	if m == nil {
+		return fmt.Errorf("invalid location: %s", str)
+	}
+	var nums [4]int
+	for i := range nums {
+		n, err := strconv.Atoi(m[i+2])
+		if err != nil {
+			return fmt.Errorf("invalid location: %s", str)
+		}
+		nums[i] = n
+	}
+	l.URI = DocumentURI(m[1])
+	l.Range.Start.Line, l.Range.Start.Character = nums[0], nums[1]
+	l.Range.End.Line, l.Range.End.Character = nums[2], nums[3]
+	return nil
+}
+
 func (a Location) Include(b Location) bool {
 	if a == b {
 		return true
@@ -216,6 +247,8 @@ type DocumentSymbol struct {
 	Tokens   []Token           `json:"tokens"`
 	Node     *sitter.Node      `json:"-"`
 	Role     SymbolRole        `json:"-"`
+	// Generated tells the symbol is synthesized from the expansion of a macro, whose Text is the expanded codes
+	Generated bool `json:"generated,omitempty"`
 
 	// Older LSPs might return SymbolInformation[] which have `Location`.
 	// Newer LSPs return DocumentSymbol[] which have `Range` and `SelectionRange`.
@@ -178,10 +178,17 @@ func (cli *LSPClient) DocumentSymbols(ctx context.Context, file DocumentURI) (ma
 			TextDocument: lsp.TextDocumentIdentifier{URI: uri},
 		}
 		var resp []*DocumentSymbol
-		if err := cli.Call(ctx, "textDocument/documentSymbol", req, &resp); err != nil {
+		err := cli.Call(ctx, "textDocument/documentSymbol", req, &resp)
+		if err != nil && !IsJSONRPCMethodNotFound(err) {
 			return nil, err
 		}
 		respFlatten := flattenDocumentSymbols(resp, file)
+		if cli.weakSymbols(f, respFlatten, err) {
+			respFlatten = cli.completeSymbols(ctx, f, respFlatten)
+		}
+		if cli.adjuster != nil {
+			cli.adjuster.AdjustSymbols(respFlatten)
+		}
 		built := make(map[Range]*DocumentSymbol, len(respFlatten))
 		for i := range respFlatten {
 			s := respFlatten[i]
@@ -326,6 +333,12 @@ func (cli *LSPClient) SemanticTokens(ctx context.Context, id Location) ([]Token,
 		}
 	}
 
+	if len(cli.tokenTypes) == 0 {
+		// the server provides no semantic tokens, like solargraph,
+		// thus the symbols are collected without dependencies
+		return nil, nil
+	}
+
 	uri := lsp.DocumentURI(id.URI)
 	req := DocumentRange{
 		TextDocument: lsp.TextDocumentIdentifier{
@@ -429,6 +442,29 @@ func (cli *LSPClient) TypeDefinition(ctx context.Context, uri DocumentURI, pos P
 	return resp, nil
 }
 
+// ExpandedMacro is the result of `rust-analyzer/expandMacro`
+type ExpandedMacro struct {
+	Name      string `json:"name"`
+	Expansion string `json:"expansion"`
+}
+
+// ExpandMacro expands the macro call at the position, which is a rust-analyzer extension.
+// It returns nil if there is no macro call at the position
+func (cli *LSPClient) ExpandMacro(ctx context.Context, uri DocumentURI, pos Position) (*ExpandedMacro, error) {
+	if _, err := cli.DidOpen(ctx, uri); err != nil {
+		return nil, err
+	}
+	req := lsp.TextDocumentPositionParams{
+		TextDocument: lsp.TextDocumentIdentifier{URI: lsp.DocumentURI(uri)},
+		Position:     lsp.Position(pos),
+	}
+	var resp *ExpandedMacro
+	if err := cli.Call(ctx, "rust-analyzer/expandMacro", req, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
 // ensureLocalFile returns the cached TextDocumentItem for uri, reading and
 // caching the file if necessary. Unlike DidOpen it does NOT send a didOpen
 // notification — use this for read-only helpers (Locate, Line, ...) that
@@ -478,8 +514,8 @@ func (cli *LSPClient) Locate(id Location) (string, error) {
 		id.Range.End.Line < 0 || id.Range.End.Line >= len(f.LineCounts) {
 		return "", nil
 	}
-	start := f.LineCounts[id.Range.Start.Line] + id.Range.Start.Character
-	end := f.LineCounts[id.Range.End.Line] + id.Range.End.Character
+	start := PositionToByteOffset(text, f.LineCounts, id.Range.Start)
+	end := PositionToByteOffset(text, f.LineCounts, id.Range.End)
 	if start < 0 || end > len(text) || start > end {
 		return "", nil
 	}
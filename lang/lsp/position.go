@@ -0,0 +1,86 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lsp
+
+import (
+	"sort"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// LSP positions count characters in UTF-16 code units by default, while uniast offsets are in bytes.
+// The helpers below convert between them. Out of range inputs are clamped to the text.
+
+// UTF16ToByteOffset converts the UTF-16 offset in the text to the byte offset
+func UTF16ToByteOffset(text string, units int) int {
+	if units <= 0 {
+		return 0
+	}
+	n := 0
+	for i, r := range text {
+		if n >= units {
+			return i
+		}
+		n += utf16.RuneLen(r)
+	}
+	return len(text)
+}
+
+// ByteToUTF16Offset converts the byte offset in the text to the UTF-16 offset
+func ByteToUTF16Offset(text string, offset int) int {
+	if offset > len(text) {
+		offset = len(text)
+	}
+	n := 0
+	for i := 0; i < offset; {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if i+size > offset {
+			break
+		}
+		n += utf16.RuneLen(r)
+		i += size
+	}
+	return n
+}
+
+// lineText returns the l-th line in the text, without the line break
+func lineText(text string, lines []int, l int) string {
+	end := len(text)
+	if l+1 < len(lines) {
+		end = lines[l+1] - 1
+	}
+	if end < lines[l] {
+		end = lines[l]
+	}
+	return text[lines[l]:end]
+}
+
+// PositionToByteOffset converts the LSP position to the byte offset in the text,
+// returns -1 if the line is out of the text
+func PositionToByteOffset(text string, lines []int, pos Position) int {
+	if pos.Line < 0 || pos.Line >= len(lines) || pos.Character < 0 {
+		return -1
+	}
+	return lines[pos.Line] + UTF16ToByteOffset(lineText(text, lines, pos.Line), pos.Character)
+}
+
+// ByteOffsetToPosition converts the byte offset in the text to the LSP position
+func ByteOffsetToPosition(text string, lines []int, offset int) Position {
+	l := sort.SearchInts(lines, offset+1) - 1
+	if l < 0 {
+		l = 0
+	}
+	return Position{Line: l, Character: ByteToUTF16Offset(lineText(text, lines, l), offset-lines[l])}
+}
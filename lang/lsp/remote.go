@@ -0,0 +1,157 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lsp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/sourcegraph/jsonrpc2"
+	"golang.org/x/net/websocket"
+)
+
+// dialTimeout is how long connecting to a remote server waits
+const dialTimeout = 30 * time.Second
+
+// IsRemoteServer tells if the server is the address of a running server instead of an executable,
+// like `tcp://host:port` or `ws://host:port/path` (`wss://` as well)
+func IsRemoteServer(server string) bool {
+	for _, scheme := range []string{"tcp://", "ws://", "wss://"} {
+		if strings.HasPrefix(server, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// connectLSPServer connects to the remote server, replays a trace, or starts a local one
+func connectLSPServer(opts ClientOptions) (io.ReadWriteCloser, error) {
+	if IsReplayServer(opts.Server) {
+		return replayLSPServer(opts.Server)
+	}
+	if IsRemoteServer(opts.Server) {
+		return dialLSPServer(opts.Server)
+	}
+	return startLSPSever(opts.Server, opts)
+}
+
+// dialLSPServer connects to a running server by the address, see IsRemoteServer
+func dialLSPServer(addr string) (io.ReadWriteCloser, error) {
+	u, err := url.Parse(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid LSP server address %s: %v", addr, err)
+	}
+	switch u.Scheme {
+	case "tcp":
+		conn, err := net.DialTimeout("tcp", u.Host, dialTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to connect LSP server %s: %v", addr, err)
+		}
+		return conn, nil
+	default:
+		cfg, err := websocket.NewConfig(addr, "http://localhost/")
+		if err != nil {
+			return nil, fmt.Errorf("invalid LSP server address %s: %v", addr, err)
+		}
+		cfg.Dialer = &net.Dialer{Timeout: dialTimeout}
+		conn, err := websocket.DialConfig(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to connect LSP server %s: %v", addr, err)
+		}
+		return conn, nil
+	}
+}
+
+// newObjectStream frames the messages exchanged with the server.
+// Over WebSocket every message is a text frame of the JSON, otherwise it is prefixed by the Content-Length header.
+// If the server sees the repo at remoteRoot, the URIs under dir are mapped between the local and remote paths
+func newObjectStream(svr io.ReadWriteCloser, dir DocumentURI, remoteRoot string) jsonrpc2.ObjectStream {
+	var stream jsonrpc2.ObjectStream
+	if ws, ok := svr.(*websocket.Conn); ok {
+		stream = wsObjectStream{ws}
+	} else {
+		stream = jsonrpc2.NewBufferedStream(svr, jsonrpc2.VSCodeObjectCodec{})
+	}
+	if remoteRoot == "" {
+		return stream
+	}
+	return newMappedStream(stream, dir, NewRemoteURI(remoteRoot))
+}
+
+// NewRemoteURI returns the URI of the path on the server, which is not resolved on the local file system like NewURI
+func NewRemoteURI(path string) DocumentURI {
+	path = strings.TrimSuffix(strings.ReplaceAll(path, "\\", "/"), "/")
+	if !strings.HasPrefix(path, "/") {
+		// windows drive
+		path = "/" + path
+	}
+	return DocumentURI("file://" + path)
+}
+
+type wsObjectStream struct {
+	conn *websocket.Conn
+}
+
+func (s wsObjectStream) WriteObject(obj interface{}) error {
+	return websocket.JSON.Send(s.conn, obj)
+}
+
+func (s wsObjectStream) ReadObject(v interface{}) error {
+	return websocket.JSON.Receive(s.conn, v)
+}
+
+func (s wsObjectStream) Close() error {
+	return s.conn.Close()
+}
+
+// mappedStream rewrites the URIs in the messages, the local ones for the server and the remote ones for the client.
+// Only the URIs under the roots are rewritten, thus those of the dependencies on the server (e.g. the cargo registry)
+// are kept and can't be read locally
+type mappedStream struct {
+	jsonrpc2.ObjectStream
+	toServer *strings.Replacer
+	toClient *strings.Replacer
+}
+
+func newMappedStream(stream jsonrpc2.ObjectStream, local, remote DocumentURI) mappedStream {
+	// match the whole path segment, thus /repo doesn't map /repo2
+	l, r := string(local), string(remote)
+	return mappedStream{
+		ObjectStream: stream,
+		toServer:     strings.NewReplacer(l+"/", r+"/", l+`"`, r+`"`),
+		toClient:     strings.NewReplacer(r+"/", l+"/", r+`"`, l+`"`),
+	}
+}
+
+func (s mappedStream) WriteObject(obj interface{}) error {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	return s.ObjectStream.WriteObject(json.RawMessage(s.toServer.Replace(string(data))))
+}
+
+func (s mappedStream) ReadObject(v interface{}) error {
+	var raw json.RawMessage
+	if err := s.ObjectStream.ReadObject(&raw); err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(s.toClient.Replace(string(raw))), v)
+}
@@ -0,0 +1,139 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/abcoder/lang/uniast"
+	"github.com/sourcegraph/jsonrpc2"
+	"golang.org/x/net/websocket"
+)
+
+// remoteHandler serves as a server which sees the repo at /workspace
+func remoteHandler(t *testing.T) jsonrpc2.Handler {
+	return jsonrpc2.HandlerWithError(func(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (any, error) {
+		switch req.Method {
+		case "initialize":
+			var params initializeParams
+			if err := json.Unmarshal(*req.Params, &params); err != nil {
+				return nil, err
+			}
+			if params.RootURI != "file:///workspace" || params.ProcessID != 0 {
+				t.Errorf("unexpected initialize params: %+v", params)
+			}
+			return map[string]any{"capabilities": map[string]any{
+				"definitionProvider":     true,
+				"typeDefinitionProvider": true,
+				"documentSymbolProvider": true,
+				"referencesProvider":     true,
+			}}, nil
+		case "textDocument/definition":
+			var params TextDocumentPositionParams
+			if err := json.Unmarshal(*req.Params, &params); err != nil {
+				return nil, err
+			}
+			if params.TextDocument.URI != "file:///workspace/src/main.rs" {
+				t.Errorf("unexpected document: %s", params.TextDocument.URI)
+			}
+			return []Location{
+				{URI: "file:///workspace/src/lib.rs"},
+				{URI: "file:///workspace2/lib.rs"},
+				{URI: "file:///root/.cargo/registry/serde/lib.rs"},
+			}, nil
+		}
+		return nil, nil
+	})
+}
+
+func testRemoteClient(t *testing.T, server string) {
+	dir := t.TempDir()
+	cli, err := NewLSPClient(dir, "", 0, ClientOptions{
+		Server:      server,
+		RemoteRoot:  "/workspace/",
+		Language:    uniast.Rust,
+		MaxRestarts: -1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	root := NewURI(dir)
+	var locs []Location
+	err = cli.Call(context.Background(), "textDocument/definition", TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{URI: NewURI(filepath.Join(dir, "src", "main.rs"))},
+	}, &locs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []DocumentURI{root + "/src/lib.rs", "file:///workspace2/lib.rs", "file:///root/.cargo/registry/serde/lib.rs"}
+	if len(locs) != len(want) {
+		t.Fatalf("got %d locations, want %d", len(locs), len(want))
+	}
+	for i, loc := range locs {
+		if loc.URI != want[i] {
+			t.Errorf("location %d: got %s, want %s", i, loc.URI, want[i])
+		}
+	}
+}
+
+func TestLSPClient_RemoteTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		rpc := jsonrpc2.NewConn(context.Background(), jsonrpc2.NewBufferedStream(conn, jsonrpc2.VSCodeObjectCodec{}), remoteHandler(t))
+		<-rpc.DisconnectNotify()
+	}()
+	testRemoteClient(t, "tcp://"+ln.Addr().String())
+}
+
+func TestLSPClient_RemoteWebSocket(t *testing.T) {
+	svr := httptest.NewServer(websocket.Handler(func(ws *websocket.Conn) {
+		rpc := jsonrpc2.NewConn(context.Background(), wsObjectStream{ws}, remoteHandler(t))
+		<-rpc.DisconnectNotify()
+	}))
+	defer svr.Close()
+	testRemoteClient(t, "ws://"+strings.TrimPrefix(svr.URL, "http://")+"/lsp")
+}
+
+func TestIsRemoteServer(t *testing.T) {
+	for server, want := range map[string]bool{
+		"tcp://localhost:9257":    true,
+		"ws://devcontainer/lsp":   true,
+		"wss://devcontainer/lsp":  true,
+		"rust-analyzer":           false,
+		"/usr/bin/clangd --log=v": false,
+	} {
+		if got := IsRemoteServer(server); got != want {
+			t.Errorf("IsRemoteServer(%q) = %v, want %v", server, got, want)
+		}
+	}
+}
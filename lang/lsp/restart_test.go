@@ -0,0 +1,146 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/abcoder/lang/uniast"
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+// fakeServerEnv makes the test binary run as a fake LSP server, whose value is the dir of its states
+const fakeServerEnv = "ABCODER_FAKE_LSP"
+
+func TestMain(m *testing.M) {
+	if dir := os.Getenv(fakeServerEnv); dir != "" {
+		runFakeServer(dir)
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+type stdio struct{}
+
+func (stdio) Read(p []byte) (int, error)  { return os.Stdin.Read(p) }
+func (stdio) Write(p []byte) (int, error) { return os.Stdout.Write(p) }
+func (stdio) Close() error                { return os.Stdin.Close() }
+
+// runFakeServer serves until stdin is closed. `test/crash` and `test/hang` crash or hang the first server only,
+// which is marked by the files under dir
+func runFakeServer(dir string) {
+	var mu sync.Mutex
+	var opened []string
+	once := func(name string) bool {
+		f, err := os.OpenFile(filepath.Join(dir, name), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err != nil {
+			return false
+		}
+		f.Close()
+		return true
+	}
+	handler := jsonrpc2.HandlerWithError(func(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (any, error) {
+		switch req.Method {
+		case "initialize":
+			return map[string]any{"capabilities": map[string]any{
+				"definitionProvider":     true,
+				"typeDefinitionProvider": true,
+				"documentSymbolProvider": true,
+				"referencesProvider":     true,
+			}}, nil
+		case "textDocument/didOpen":
+			var params DidOpenTextDocumentParams
+			if err := json.Unmarshal(*req.Params, &params); err == nil {
+				mu.Lock()
+				opened = append(opened, string(params.TextDocument.URI))
+				mu.Unlock()
+			}
+		case "test/opened":
+			mu.Lock()
+			defer mu.Unlock()
+			return opened, nil
+		case "test/crash":
+			if once("crashed") {
+				os.Exit(1)
+			}
+		case "test/hang":
+			if once("hung") {
+				select {}
+			}
+		}
+		return "ok", nil
+	})
+	conn := jsonrpc2.NewConn(context.Background(), jsonrpc2.NewBufferedStream(stdio{}, jsonrpc2.VSCodeObjectCodec{}), handler)
+	<-conn.DisconnectNotify()
+}
+
+func TestLSPClient_Restart(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(fakeServerEnv, dir)
+	cli, err := NewLSPClient(dir, "", 0, ClientOptions{
+		Server:         os.Args[0],
+		Language:       uniast.Rust,
+		RequestTimeout: time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	file := filepath.Join(dir, "lib.rs")
+	if err := os.WriteFile(file, []byte("fn main() {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cli.DidOpen(context.Background(), NewURI(file)); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	var ret string
+	// the in-flight request is retried on the restarted server
+	if err := cli.Call(ctx, "test/crash", nil, &ret); err != nil || ret != "ok" {
+		t.Fatalf("call after crash = %q, %v", ret, err)
+	}
+	var opened []string
+	if err := cli.Call(ctx, "test/opened", nil, &opened); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Contains(opened, string(NewURI(file))) {
+		t.Fatalf("opened documents are not reopened: %v", opened)
+	}
+
+	if err := cli.Call(ctx, "test/hang", nil, &ret); err != nil || ret != "ok" {
+		t.Fatalf("call after hang = %q, %v", ret, err)
+	}
+	if cli.restarts != 2 {
+		t.Fatalf("restarts = %d, want 2", cli.restarts)
+	}
+
+	// no more restarts beyond the limit
+	cli.MaxRestarts = -1
+	os.Remove(filepath.Join(dir, "crashed"))
+	if err := cli.Call(ctx, "test/crash", nil, &ret); !IsConnClosed(err) {
+		t.Fatalf("call after crash without restarts = %v", err)
+	}
+}
@@ -70,3 +70,17 @@ type LanguageSpec interface {
 	// some language may allow local symbols inside another symbol
 	ProtectedSymbolKinds() []SymbolKind
 }
+
+// SymbolAdjuster is optionally implemented by a LanguageSpec whose server reports symbols
+// that need to be fixed up before collecting, like the companion objects of scala
+type SymbolAdjuster interface {
+	// AdjustSymbols rewrites the flattened document symbols of a file in place
+	AdjustSymbols(syms []*DocumentSymbol)
+}
+
+// VisibilitySpec is optionally implemented by a LanguageSpec which can tell visibilities finer than IsPublicSymbol,
+// otherwise public symbols are collected as uniast.VisibilityPublic and others as uniast.VisibilityPrivate
+type VisibilitySpec interface {
+	// Visibility returns the visibility of an entity symbol
+	Visibility(sym DocumentSymbol) uniast.Visibility
+}
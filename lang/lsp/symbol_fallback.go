@@ -0,0 +1,182 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lsp
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/cloudwego/abcoder/lang/log"
+	"github.com/cloudwego/abcoder/lang/uniast"
+)
+
+// workspaceIndex is the workspace/symbol results grouped by document, loaded once when a documentSymbol result is weak
+type workspaceIndex struct {
+	once  sync.Once
+	byURI map[DocumentURI][]DocumentSymbol
+}
+
+// weakSymbols tells if the documentSymbol result of the file should be completed by workspace/symbol:
+// the server doesn't support documentSymbol, or reports nothing for a non-blank file.
+// With ClientOptions.WorkspaceSymbolFallback, every result is completed
+func (cli *LSPClient) weakSymbols(f *TextDocumentItem, syms []*DocumentSymbol, err error) bool {
+	if err != nil || cli.WorkspaceSymbolFallback {
+		return true
+	}
+	return len(syms) == 0 && strings.TrimSpace(f.Text) != ""
+}
+
+// workspaceSymbols returns the workspace symbols of the file, by querying all symbols of the workspace once
+func (cli *LSPClient) workspaceSymbols(ctx context.Context, file DocumentURI) []DocumentSymbol {
+	cli.wsIndex.once.Do(func() {
+		syms, err := cli.WorkspaceSymbols(ctx, "")
+		if err != nil {
+			log.Error("workspace/symbol fallback is unavailable: %v\n", err)
+			return
+		}
+		log.Info("documentSymbol of the server is weak, fallback to %d workspace symbols\n", len(syms))
+		cli.wsIndex.byURI = make(map[DocumentURI][]DocumentSymbol)
+		for _, s := range syms {
+			cli.wsIndex.byURI[s.Location.URI] = append(cli.wsIndex.byURI[s.Location.URI], s)
+		}
+	})
+	return cli.wsIndex.byURI[file]
+}
+
+// completeSymbols adds the workspace symbols of the file which are missing from syms.
+// Their ranges are reconstructed from the texts if the server only locates the names
+func (cli *LSPClient) completeSymbols(ctx context.Context, f *TextDocumentItem, syms []*DocumentSymbol) []*DocumentSymbol {
+	for _, ws := range cli.workspaceSymbols(ctx, f.URI) {
+		start := ws.Location.Range.Start
+		covered := false
+		for _, s := range syms {
+			if s.Name == ws.Name && !start.Less(s.Location.Range.Start) && !s.Location.Range.End.Less(start) {
+				covered = true
+				break
+			}
+		}
+		if covered {
+			continue
+		}
+		sym := ws
+		if ws.Location.Range.Start.Line == ws.Location.Range.End.Line {
+			name := ws.Location.Range
+			sym.SelectionRange = &name
+			sym.Location.Range = reconstructRange(f.Text, f.LineCounts, name.Start, cli.Language == uniast.Python)
+		}
+		syms = append(syms, &sym)
+	}
+	return syms
+}
+
+// reconstructRange returns the range of the whole symbol whose name starts at pos.
+// The symbol starts at the first non-blank character of the line. For indentation blocks (Python),
+// it ends at the last line of the body indented deeper than the line, or at the line itself if it opens no block.
+// Otherwise it ends at the `;` or the `}` closing the first `{`, by matching the brackets outside strings and comments
+func reconstructRange(text string, lines []int, pos Position, indentBlocks bool) Range {
+	begin := PositionToByteOffset(text, lines, pos)
+	if begin < 0 {
+		return Range{Start: pos, End: pos}
+	}
+	line := lineText(text, lines, pos.Line)
+	indent := len(line) - len(strings.TrimLeft(line, " \t"))
+	start := Position{Line: pos.Line, Character: ByteToUTF16Offset(line, indent)}
+	var end int
+	if indentBlocks {
+		end = indentBlockEnd(text, lines, pos.Line, indent)
+	} else {
+		end = bracketBlockEnd(text, begin)
+	}
+	return Range{Start: start, End: ByteOffsetToPosition(text, lines, end)}
+}
+
+// indentBlockEnd returns the end offset of the statement at the line, including its body indented deeper than indent
+func indentBlockEnd(text string, lines []int, l int, indent int) int {
+	// the header may span several lines within the brackets
+	depth := 0
+	for ; l < len(lines); l++ {
+		code := lineText(text, lines, l)
+		if i := strings.Index(code, "#"); i >= 0 {
+			code = code[:i]
+		}
+		depth += strings.Count(code, "(") + strings.Count(code, "[") + strings.Count(code, "{") -
+			strings.Count(code, ")") - strings.Count(code, "]") - strings.Count(code, "}")
+		if depth > 0 {
+			continue
+		}
+		if !strings.HasSuffix(strings.TrimSpace(code), ":") {
+			return lines[l] + len(lineText(text, lines, l))
+		}
+		break
+	}
+	if l >= len(lines) {
+		return len(text)
+	}
+	last := l
+	for b := l + 1; b < len(lines); b++ {
+		body := lineText(text, lines, b)
+		if strings.TrimSpace(body) == "" {
+			continue
+		}
+		if len(body)-len(strings.TrimLeft(body, " \t")) <= indent {
+			break
+		}
+		last = b
+	}
+	return lines[last] + len(lineText(text, lines, last))
+}
+
+// bracketBlockEnd returns the end offset of the declaration from begin, which ends at `;` or the `}` closing the first `{`
+func bracketBlockEnd(text string, begin int) int {
+	depth := 0
+	for i := begin; i < len(text); i++ {
+		switch c := text[i]; c {
+		case '"', '\'', '`':
+			// skip the string or char literal
+			for i++; i < len(text) && text[i] != c; i++ {
+				if text[i] == '\\' && c != '`' {
+					i++
+				}
+			}
+		case '/':
+			if i+1 < len(text) && text[i+1] == '/' {
+				for i < len(text) && text[i] != '\n' {
+					i++
+				}
+			} else if i+1 < len(text) && text[i+1] == '*' {
+				if j := strings.Index(text[i+2:], "*/"); j >= 0 {
+					i += j + 3
+				} else {
+					i = len(text)
+				}
+			}
+		case '(', '[', '{':
+			depth++
+		case ')', ']':
+			depth--
+		case '}':
+			depth--
+			if depth <= 0 {
+				return i + 1
+			}
+		case ';':
+			if depth <= 0 {
+				return i + 1
+			}
+		}
+	}
+	return len(text)
+}
@@ -0,0 +1,152 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/cloudwego/abcoder/lang/uniast"
+	"github.com/cloudwego/abcoder/lang/utils"
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+func TestReconstructRange(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		pos    Position
+		python bool
+		want   string
+	}{
+		{
+			name: "function",
+			text: "// f does\nint f(int a) {\n  if (a) { return \"}\"[0]; }\n  return 0; // }\n}\nint g;\n",
+			pos:  Position{Line: 1, Character: 4},
+			want: "int f(int a) {\n  if (a) { return \"}\"[0]; }\n  return 0; // }\n}",
+		},
+		{
+			name: "declaration",
+			text: "struct A {\n  int x;\n};\nint g = f(1);\n",
+			pos:  Position{Line: 3, Character: 4},
+			want: "int g = f(1);",
+		},
+		{
+			name:   "python def",
+			text:   "class A:\n    def f(self,\n          a):\n        if a:\n\n            return 1  # :\n        return 0\n\n    x = 1\n",
+			pos:    Position{Line: 1, Character: 8},
+			python: true,
+			want:   "def f(self,\n          a):\n        if a:\n\n            return 1  # :\n        return 0",
+		},
+		{
+			name:   "python var",
+			text:   "X = {\n  'a': 1,\n}\nY = 2\n",
+			pos:    Position{Line: 0, Character: 0},
+			python: true,
+			want:   "X = {\n  'a': 1,\n}",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := utils.CountLines(tt.text)
+			r := reconstructRange(tt.text, lines, tt.pos, tt.python)
+			got := tt.text[PositionToByteOffset(tt.text, lines, r.Start):PositionToByteOffset(tt.text, lines, r.End)]
+			if got != tt.want {
+				t.Errorf("reconstructRange() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// weakSymbolHandler serves as a server which reports no document symbols,
+// and locates only the names of the workspace symbols
+func weakSymbolHandler(file DocumentURI) jsonrpc2.Handler {
+	return jsonrpc2.HandlerWithError(func(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (any, error) {
+		switch req.Method {
+		case "initialize":
+			return map[string]any{"capabilities": map[string]any{
+				"definitionProvider":     true,
+				"documentSymbolProvider": true,
+				"referencesProvider":     true,
+			}}, nil
+		case "textDocument/documentSymbol":
+			return []any{}, nil
+		case "workspace/symbol":
+			return []SymbolInformation{
+				{Name: "A", Kind: 5, Location: Location{URI: file, Range: Range{Start: Position{Line: 0, Character: 6}, End: Position{Line: 0, Character: 7}}}},
+				{Name: "f", Kind: 6, Location: Location{URI: file, Range: Range{Start: Position{Line: 1, Character: 8}, End: Position{Line: 1, Character: 9}}}},
+				{Name: "other", Kind: 12, Location: Location{URI: file + "x", Range: Range{}}},
+			}, nil
+		}
+		return nil, nil
+	})
+}
+
+func TestLSPClient_WorkspaceSymbolFallback(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.py")
+	if err := os.WriteFile(path, []byte("class A:\n    def f(self):\n        return 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		rpc := jsonrpc2.NewConn(context.Background(), jsonrpc2.NewBufferedStream(conn, jsonrpc2.VSCodeObjectCodec{}), weakSymbolHandler(NewURI(path)))
+		<-rpc.DisconnectNotify()
+	}()
+	cli, err := NewLSPClient(dir, "", 0, ClientOptions{
+		Server:      "tcp://" + ln.Addr().String(),
+		Language:    uniast.Python,
+		MaxRestarts: -1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	syms, err := cli.DocumentSymbols(context.Background(), NewURI(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, s := range syms {
+		text, err := cli.Locate(s.Location)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.SelectionRange == nil {
+			t.Errorf("symbol %s should keep the name range", s.Name)
+		}
+		bs, _ := json.Marshal(s.Name + ": " + text)
+		got = append(got, string(bs))
+	}
+	sort.Strings(got)
+	want := []string{`"A: class A:\n    def f(self):\n        return 1"`, `"f: def f(self):\n        return 1"`}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("symbols = %v, want %v", got, want)
+	}
+}
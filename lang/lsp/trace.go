@@ -0,0 +1,309 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cloudwego/abcoder/lang/log"
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+// TraceRoot replaces the path of the repo in the traces, thus a trace can be replayed on another repo path
+const TraceRoot = "$ROOT"
+
+// replayScheme is the scheme of ClientOptions.Server to replay a trace instead of running a server, see NewReplayServer
+const replayScheme = "replay://"
+
+const (
+	// TraceSend is the direction of the messages from the client to the server
+	TraceSend = "send"
+	// TraceRecv is the direction of the messages from the server to the client
+	TraceRecv = "recv"
+)
+
+// TraceEntry is a JSON-RPC message exchanged with the server, a line of the trace file
+type TraceEntry struct {
+	Time time.Time `json:"time"`
+	// Dir is TraceSend or TraceRecv
+	Dir     string          `json:"dir"`
+	Message json.RawMessage `json:"message"`
+}
+
+// traceMessage is the fields of a JSON-RPC message to match the requests and the responses
+type traceMessage struct {
+	ID     *jsonrpc2.ID     `json:"id,omitempty"`
+	Method string           `json:"method,omitempty"`
+	Params *json.RawMessage `json:"params,omitempty"`
+}
+
+var traceSeq atomic.Int64
+
+// tracedStream records the messages into a trace file, in which the repo path is replaced by TraceRoot
+type tracedStream struct {
+	jsonrpc2.ObjectStream
+	mu       sync.Mutex
+	file     *os.File
+	w        *bufio.Writer
+	sanitize *strings.Replacer
+}
+
+// newTracedStream creates the trace file `<language>-<time>-<seq>.jsonl` under dir, one for each connection to the server
+func newTracedStream(stream jsonrpc2.ObjectStream, root DocumentURI, dir string, language string) (*tracedStream, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	name := fmt.Sprintf("%s-%s-%d.jsonl", language, time.Now().Format("20060102-150405"), traceSeq.Add(1))
+	f, err := os.Create(filepath.Join(dir, name))
+	if err != nil {
+		return nil, err
+	}
+	log.Info("tracing the LSP messages into %s\n", f.Name())
+	// match the whole path segment like mappedStream
+	path := strings.TrimPrefix(string(root), "file://")
+	return &tracedStream{
+		ObjectStream: stream,
+		file:         f,
+		w:            bufio.NewWriter(f),
+		sanitize:     strings.NewReplacer(path+"/", TraceRoot+"/", path+`"`, TraceRoot+`"`),
+	}, nil
+}
+
+func (s *tracedStream) record(dir string, data []byte) {
+	bs, err := json.Marshal(TraceEntry{Time: time.Now(), Dir: dir, Message: json.RawMessage(s.sanitize.Replace(string(data)))})
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.w == nil {
+		return
+	}
+	s.w.Write(bs)
+	s.w.WriteByte('\n')
+	// flush each message, thus the trace is complete even if the parser crashes
+	s.w.Flush()
+}
+
+func (s *tracedStream) WriteObject(obj interface{}) error {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	s.record(TraceSend, data)
+	return s.ObjectStream.WriteObject(json.RawMessage(data))
+}
+
+func (s *tracedStream) ReadObject(v interface{}) error {
+	var raw json.RawMessage
+	if err := s.ObjectStream.ReadObject(&raw); err != nil {
+		return err
+	}
+	s.record(TraceRecv, raw)
+	return json.Unmarshal(raw, v)
+}
+
+func (s *tracedStream) Close() error {
+	s.mu.Lock()
+	if s.w != nil {
+		s.w.Flush()
+		s.file.Close()
+		s.w = nil
+	}
+	s.mu.Unlock()
+	return s.ObjectStream.Close()
+}
+
+// ReadTrace reads the entries of the trace file
+func ReadTrace(path string) ([]TraceEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var ret []TraceEntry
+	for i, line := range strings.Split(string(data), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		var e TraceEntry
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			return nil, fmt.Errorf("invalid trace entry at %s:%d: %v", path, i+1, err)
+		}
+		ret = append(ret, e)
+	}
+	return ret, nil
+}
+
+// IsReplayServer tells if the server is a trace to replay like `replay:///path/to/trace.jsonl`, see NewReplayServer
+func IsReplayServer(server string) bool {
+	return strings.HasPrefix(server, replayScheme)
+}
+
+// replayServer serves as the server recorded by the trace, see NewReplayServer
+type replayServer struct {
+	entries []TraceEntry
+	used    []bool
+	root    *strings.Replacer
+	stream  jsonrpc2.ObjectStream
+}
+
+// NewReplayServer starts to serve as the server recorded by the trace, the returned conn is for the client.
+// Thus the bugs can be reproduced without the repo and the server.
+// The requests and notifications of the client are matched with the recorded ones by method and params,
+// or by method in the recorded order if the params differ (e.g. the pid of initialize).
+// A matched request is answered by the recorded response with the id of the client, after the messages
+// the server sent between the recorded request and the next client message (e.g. diagnostics).
+// TraceRoot is replaced by the root of the client, which is told by initialize.
+// The requests without a recorded match fail, and the responses of the client are ignored
+func NewReplayServer(entries []TraceEntry) net.Conn {
+	client, server := net.Pipe()
+	s := &replayServer{
+		entries: entries,
+		used:    make([]bool, len(entries)),
+		root:    strings.NewReplacer(),
+		stream:  jsonrpc2.NewBufferedStream(server, jsonrpc2.VSCodeObjectCodec{}),
+	}
+	go s.serve()
+	return client
+}
+
+// replayLSPServer replays the trace file of the server like `replay:///path/to/trace.jsonl`
+func replayLSPServer(server string) (net.Conn, error) {
+	entries, err := ReadTrace(strings.TrimPrefix(server, replayScheme))
+	if err != nil {
+		return nil, err
+	}
+	return NewReplayServer(entries), nil
+}
+
+func (s *replayServer) serve() {
+	defer s.stream.Close()
+	for {
+		var raw json.RawMessage
+		if err := s.stream.ReadObject(&raw); err != nil {
+			return
+		}
+		var msg traceMessage
+		if err := json.Unmarshal(raw, &msg); err != nil || msg.Method == "" {
+			// responses of the client
+			continue
+		}
+		if msg.Method == "initialize" {
+			var params struct {
+				RootURI string `json:"rootUri"`
+			}
+			if msg.Params != nil && json.Unmarshal(*msg.Params, &params) == nil && params.RootURI != "" {
+				path := strings.TrimPrefix(params.RootURI, "file://")
+				s.root = strings.NewReplacer(TraceRoot, path)
+			}
+		}
+		i := s.match(msg)
+		if i < 0 {
+			if msg.ID != nil {
+				s.reply(*msg.ID, nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInternalError, Message: "no recorded response of " + msg.Method})
+			}
+			continue
+		}
+		s.used[i] = true
+		// the messages sent by the server before the next client message
+		for j := i + 1; j < len(s.entries) && s.entries[j].Dir == TraceRecv; j++ {
+			var m traceMessage
+			if json.Unmarshal(s.entries[j].Message, &m) == nil && m.Method != "" {
+				s.send(s.entries[j].Message)
+			}
+		}
+		if msg.ID != nil {
+			s.respond(i, *msg.ID)
+		}
+	}
+}
+
+// match returns the index of the recorded client message matching msg, -1 if none
+func (s *replayServer) match(msg traceMessage) int {
+	byMethod := -1
+	for i, e := range s.entries {
+		if s.used[i] || e.Dir != TraceSend {
+			continue
+		}
+		var m traceMessage
+		if json.Unmarshal([]byte(s.root.Replace(string(e.Message))), &m) != nil || m.Method != msg.Method || (m.ID == nil) != (msg.ID == nil) {
+			continue
+		}
+		if equalJSON(m.Params, msg.Params) {
+			return i
+		}
+		if byMethod < 0 {
+			byMethod = i
+		}
+	}
+	return byMethod
+}
+
+// respond sends the recorded response of the i-th entry with the id of the client
+func (s *replayServer) respond(i int, id jsonrpc2.ID) {
+	var req traceMessage
+	_ = json.Unmarshal(s.entries[i].Message, &req)
+	for _, e := range s.entries[i+1:] {
+		if e.Dir != TraceRecv {
+			continue
+		}
+		var resp struct {
+			ID     *jsonrpc2.ID     `json:"id"`
+			Method string           `json:"method"`
+			Result *json.RawMessage `json:"result"`
+			Error  *jsonrpc2.Error  `json:"error"`
+		}
+		if json.Unmarshal([]byte(s.root.Replace(string(e.Message))), &resp) != nil || resp.Method != "" || resp.ID == nil || *resp.ID != *req.ID {
+			continue
+		}
+		s.reply(id, resp.Result, resp.Error)
+		return
+	}
+	s.reply(id, nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInternalError, Message: "no recorded response of " + req.Method})
+}
+
+func (s *replayServer) reply(id jsonrpc2.ID, result *json.RawMessage, rerr *jsonrpc2.Error) {
+	resp := &jsonrpc2.Response{ID: id, Result: result, Error: rerr}
+	if result == nil && rerr == nil {
+		null := json.RawMessage("null")
+		resp.Result = &null
+	}
+	_ = s.stream.WriteObject(resp)
+}
+
+func (s *replayServer) send(msg json.RawMessage) {
+	_ = s.stream.WriteObject(json.RawMessage(s.root.Replace(string(msg))))
+}
+
+func equalJSON(a, b *json.RawMessage) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	var va, vb interface{}
+	if json.Unmarshal(*a, &va) != nil || json.Unmarshal(*b, &vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
@@ -0,0 +1,145 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/abcoder/lang/uniast"
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+// traceHandler serves as a server which publishes the diagnostics of the document on definition,
+// and locates the definition at lib.rs of the same dir
+func traceHandler() jsonrpc2.Handler {
+	return jsonrpc2.HandlerWithError(func(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (any, error) {
+		switch req.Method {
+		case "initialize":
+			return map[string]any{"capabilities": map[string]any{
+				"definitionProvider":     true,
+				"documentSymbolProvider": true,
+				"referencesProvider":     true,
+			}}, nil
+		case "textDocument/definition":
+			var params TextDocumentPositionParams
+			if err := json.Unmarshal(*req.Params, &params); err != nil {
+				return nil, err
+			}
+			uri := params.TextDocument.URI
+			_ = conn.Notify(ctx, "textDocument/publishDiagnostics", map[string]any{"uri": uri, "diagnostics": []any{
+				map[string]any{"range": map[string]any{"start": map[string]any{"line": 0, "character": 0}, "end": map[string]any{"line": 0, "character": 1}}, "severity": 1, "message": "broken"},
+			}})
+			return []Location{{URI: uri[:strings.LastIndex(string(uri), "/")] + "/lib.rs"}}, nil
+		}
+		return nil, nil
+	})
+}
+
+func definition(t *testing.T, cli *LSPClient, dir string) []Location {
+	var locs []Location
+	err := cli.Call(context.Background(), "textDocument/definition", TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{URI: NewURI(filepath.Join(dir, "src", "main.rs"))},
+	}, &locs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return locs
+}
+
+func TestLSPClient_TraceReplay(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		rpc := jsonrpc2.NewConn(context.Background(), jsonrpc2.NewBufferedStream(conn, jsonrpc2.VSCodeObjectCodec{}), traceHandler())
+		<-rpc.DisconnectNotify()
+	}()
+
+	// record
+	dir, traces := t.TempDir(), t.TempDir()
+	cli, err := NewLSPClient(dir, "", 0, ClientOptions{
+		Server:      "tcp://" + ln.Addr().String(),
+		Language:    uniast.Rust,
+		MaxRestarts: -1,
+		TraceDir:    traces,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if locs := definition(t, cli, dir); len(locs) != 1 || locs[0].URI != NewURI(dir)+"/src/lib.rs" {
+		t.Fatalf("locations = %v", locs)
+	}
+	cli.Close()
+
+	files, _ := filepath.Glob(filepath.Join(traces, "rust-*.jsonl"))
+	if len(files) != 1 {
+		t.Fatalf("trace files = %v", files)
+	}
+	entries, err := ReadTrace(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(files[0])
+	if len(entries) < 5 || strings.Contains(string(data), dir) || !strings.Contains(string(data), "file://"+TraceRoot+"/src/main.rs") {
+		t.Fatalf("the trace should record the messages without the repo path:\n%s", data)
+	}
+
+	// replay on another repo path, without the server
+	other := t.TempDir()
+	cli, err = NewLSPClient(other, "", 0, ClientOptions{
+		Server:      "replay://" + files[0],
+		Language:    uniast.Rust,
+		MaxRestarts: -1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+	if locs := definition(t, cli, other); len(locs) != 1 || locs[0].URI != NewURI(other)+"/src/lib.rs" {
+		t.Fatalf("replayed locations = %v", locs)
+	}
+	main := NewURI(filepath.Join(other, "src", "main.rs"))
+	for i := 0; i < 100 && len(cli.Diagnostics(main)) == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if diags := cli.Diagnostics(main); len(diags) != 1 || diags[0].Message != "broken" {
+		t.Errorf("replayed diagnostics = %v", diags)
+	}
+
+	// the requests not recorded fail
+	var locs []Location
+	if err := cli.Call(context.Background(), "textDocument/references", nil, &locs); err == nil || !strings.Contains(err.Error(), "no recorded response") {
+		t.Errorf("unrecorded request: %v", err)
+	}
+}
+
+func TestIsReplayServer(t *testing.T) {
+	if !IsReplayServer("replay:///tmp/rust.jsonl") || IsReplayServer("rust-analyzer") || IsRemoteServer("replay:///tmp/rust.jsonl") {
+		t.Error("replay:// is a trace to replay")
+	}
+}
@@ -48,7 +48,12 @@ func GetDistance(text string, start Position, pos Position) int {
 	if l < 0 || l >= len(*lines) {
 		return -1
 	}
-	return (*lines)[l] + pos.Character - start.Character
+	// the first line of text starts at start.Character, while the others start at 0
+	char := pos.Character
+	if l == 0 {
+		char -= start.Character
+	}
+	return (*lines)[l] + UTF16ToByteOffset(lineText(text, *lines, l), char)
 }
 
 // calculate the relative index of a position to a text
@@ -83,7 +88,7 @@ func PositionOffset(file_uri string, text string, pos Position) int {
 		return -1
 	}
 	lines := utils.CountLinesCached(file_uri, text)
-	return RelativePostionWithLines(*lines, Position{Line: 0, Character: 0}, pos)
+	return PositionToByteOffset(text, *lines, pos)
 }
 
 // FindSingle finds the single char's left token index in a text
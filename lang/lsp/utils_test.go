@@ -69,6 +69,12 @@ func TestPositionOffset(t *testing.T) {
 			pos:      Position{Line: 0, Character: 0},
 			expected: 0,
 		},
+		{
+			name:     "Non-ASCII text, position after multi-byte characters",
+			text:     "// 你好\nlet s = \"😀\"; x",
+			pos:      Position{Line: 1, Character: 13},
+			expected: 25,
+		},
 	}
 
 	for _, tt := range tests {
@@ -80,3 +86,29 @@ func TestPositionOffset(t *testing.T) {
 		})
 	}
 }
+
+func TestUTF16Offsets(t *testing.T) {
+	text := "a你😀b"
+	for units, want := range map[int]int{0: 0, 1: 1, 2: 4, 4: 8, 5: 9, 10: 9} {
+		if got := UTF16ToByteOffset(text, units); got != want {
+			t.Errorf("UTF16ToByteOffset(%d) = %d, want %d", units, got, want)
+		}
+	}
+	for offset, want := range map[int]int{0: 0, 1: 1, 4: 2, 8: 4, 9: 5, 2: 1} {
+		if got := ByteToUTF16Offset(text, offset); got != want {
+			t.Errorf("ByteToUTF16Offset(%d) = %d, want %d", offset, got, want)
+		}
+	}
+
+	text = "ab\n你😀c\n"
+	lines := []int{0, 3, 12}
+	for _, pos := range []Position{{Line: 0, Character: 1}, {Line: 1, Character: 0}, {Line: 1, Character: 3}, {Line: 1, Character: 4}} {
+		offset := PositionToByteOffset(text, lines, pos)
+		if got := ByteOffsetToPosition(text, lines, offset); got != pos {
+			t.Errorf("ByteOffsetToPosition(PositionToByteOffset(%v) = %d) = %v", pos, offset, got)
+		}
+	}
+	if got := PositionToByteOffset(text, lines, Position{Line: 1, Character: 4}); got != 11 {
+		t.Errorf("PositionToByteOffset() = %d, want 11", got)
+	}
+}
@@ -23,26 +23,46 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/cloudwego/abcoder/lang/collect"
 	"github.com/cloudwego/abcoder/lang/cpp"
 	"github.com/cloudwego/abcoder/lang/cxx"
+	"github.com/cloudwego/abcoder/lang/depsrc"
+	"github.com/cloudwego/abcoder/lang/external"
 	"github.com/cloudwego/abcoder/lang/golang/parser"
 	"github.com/cloudwego/abcoder/lang/java/pb"
 	"github.com/cloudwego/abcoder/lang/log"
 	"github.com/cloudwego/abcoder/lang/lsp"
+	"github.com/cloudwego/abcoder/lang/php"
+	"github.com/cloudwego/abcoder/lang/progress"
 	"github.com/cloudwego/abcoder/lang/python"
 	"github.com/cloudwego/abcoder/lang/register"
+	"github.com/cloudwego/abcoder/lang/ruby"
 	"github.com/cloudwego/abcoder/lang/rust"
+	"github.com/cloudwego/abcoder/lang/scala"
+	"github.com/cloudwego/abcoder/lang/ts"
 	"github.com/cloudwego/abcoder/lang/uniast"
 	"github.com/cloudwego/abcoder/version"
 )
 
 // ParseOptions is the options for parsing the repo.
 type ParseOptions struct {
-	// LSP sever executable path
+	// LSP sever executable path, or the address of a running server like tcp://host:port, see lsp.IsRemoteServer
 	LSP string
+	// LSPRemoteRoot is the path of the repo seen by the remote LSP server, if it differs from the local one
+	LSPRemoteRoot string
+	// LSPRequestTimeout, LSPMaxRestarts and LSPMaxRetries control the restarts of the crashed or hung LSP server,
+	// see lsp.ClientOptions
+	LSPRequestTimeout time.Duration
+	LSPMaxRestarts    int
+	LSPMaxRetries     int
+	// LSPTraceDir records the messages exchanged with the LSP server into trace files under the dir, see lsp.ClientOptions
+	LSPTraceDir string
+	// LSPWorkspaceSymbols completes the document symbols by workspace/symbol, for the servers reporting sparse ones
+	LSPWorkspaceSymbols bool
 	// Language of the repo
 	Verbose bool
 	collect.CollectOption
@@ -53,9 +73,56 @@ type ParseOptions struct {
 
 	DisableBuildGraph bool
 
+	// FailOnError fails the parsing if any error diagnostic is reported on the codes
+	FailOnError bool
+
+	// Dedup collapses the identical nodes of the external modules and vendored or generated dirs, see uniast.Dedup
+	Dedup bool
+
+	// Blame records the primary authors and the last modified times of the nodes by git blame, see uniast.AnnotateOwners
+	Blame bool
+
+	// Comments collects the free-floating comments and the TODOs into the files, see uniast.CollectComments
+	Comments bool
+
+	// DetectLicenses detects the licenses of the third-party dependencies by their local sources, see uniast.DetectLicenses
+	DetectLicenses bool
+
+	// Std parses the std lib of the toolchain of the language instead of the repo at the uri,
+	// into the shared std AST referred by the repos parsed with LinkStd, see uniast.AsStdLib. Go and rust only
+	Std bool
+
+	// LinkStd refers the std symbols to the shared std AST of the toolchain by the versioned module paths,
+	// instead of ignoring or collecting them, see uniast.LinkStd. Go and rust only
+	LinkStd bool
+
+	// ExternalParser is the executable of an out-of-tree parser, see package external.
+	// Languages without builtin parsers are parsed by the external parsers even if it is empty
+	ExternalParser string
+
 	// TS options
 	// tsconfig string
 	TSParseOptions
+
+	// Rust options
+	RustParseOptions
+}
+
+type RustParseOptions struct {
+	// cargo features to enable, thus the cfg-gated codes are collected
+	Features []string
+	// enable all cargo features
+	AllFeatures bool
+	// disable the default cargo feature
+	NoDefaultFeatures bool
+}
+
+func (o RustParseOptions) features() rust.Features {
+	return rust.Features{
+		Features:          o.Features,
+		AllFeatures:       o.AllFeatures,
+		NoDefaultFeatures: o.NoDefaultFeatures,
+	}
 }
 
 type TSParseOptions struct {
@@ -65,51 +132,118 @@ type TSParseOptions struct {
 	TSSrcDir []string
 }
 
-func Parse(ctx context.Context, uri string, args ParseOptions) ([]byte, error) {
-	if !filepath.IsAbs(uri) {
-		uri, _ = filepath.Abs(uri)
+func (o ParseOptions) externalOptions() external.Options {
+	return external.Options{
+		ParserPath:         o.ExternalParser,
+		Excludes:           o.Excludes,
+		OnlyDirs:           o.OnlyDirs,
+		LoadExternalSymbol: o.LoadExternalSymbol,
+		NeedTest:           !o.NotNeedTest,
+		NeedComment:        !o.NoNeedComment,
+		Progress:           o.Progress,
 	}
-	l, lspPath, err := checkLSP(args.Language, args.LSP, args)
+}
+
+// useExternalParser tells if the repo is parsed by an external parser
+func (o ParseOptions) useExternalParser() bool {
+	return o.ExternalParser != "" || uniast.NewLanguage(string(o.Language)) == uniast.Unknown
+}
+
+func (o ParseOptions) tsOptions() ts.Options {
+	return ts.Options{
+		TSConfig: o.TSConfig,
+		SrcDirs:  o.TSSrcDir,
+		Excludes: o.Excludes,
+		RepoID:   o.RepoID,
+		Progress: o.Progress,
+	}
+}
+
+func Parse(ctx context.Context, uri string, args ParseOptions) ([]byte, error) {
+	repo, err := ParseRepo(ctx, uri, args)
 	if err != nil {
 		return nil, err
 	}
-	openfile, opentime, err := checkRepoPath(uri, l)
+	out, err := json.Marshal(repo)
 	if err != nil {
+		log.Error("Failed to marshal repository: %v\n", err)
 		return nil, err
 	}
+	return out, nil
+}
 
-	var client = &lsp.LSPClient{ClientOptions: lsp.ClientOptions{Language: args.Language, Verbose: args.Verbose}, LspOptions: args.LspOptions}
-	if lspPath != "" {
-		// Initialize the LSP client
-		log.Info("start initialize LSP server %s...\n", lspPath)
-		register.RegisterProviders()
-		var err error
-		client, err = lsp.NewLSPClient(uri, openfile, opentime, lsp.ClientOptions{
-			Server:                lspPath,
-			Language:              l,
-			Verbose:               args.Verbose,
-			InitializationOptions: args.LspOptions,
-		})
+// ParseRepo parses the repo and returns the in-memory AST.
+// If ctx is canceled while collecting, a non-nil repo holding the symbols collected so far
+// may be returned along with ctx.Err(), so that the caller can still serialize it.
+func ParseRepo(ctx context.Context, uri string, args ParseOptions) (*uniast.Repository, error) {
+	var stdVersion string
+	if args.Std || args.LinkStd {
+		src, version, err := stdSources(args.Language)
 		if err != nil {
-			log.Error("failed to initialize LSP server: %v\n", err)
 			return nil, err
 		}
-		client.LspOptions = args.LspOptions
-
-		log.Info("end initialize LSP server")
+		if args.Std {
+			log.Info("parsing the std lib %s under %s...\n", version, src)
+			uri, stdVersion = src, version
+		} else {
+			// the std symbols of rust are collected to be linked, see linkStd
+			args.StdVersion, args.NeedStdSymbol = version, true
+		}
+	}
+	if !filepath.IsAbs(uri) {
+		uri, _ = filepath.Abs(uri)
 	}
 
-	repo, err := collectSymbol(ctx, client, uri, args.CollectOption)
-	if err != nil {
+	var repo *uniast.Repository
+	var err error
+	if args.Language == uniast.Unknown && args.ExternalParser == "" {
+		repo, err = parseDetected(ctx, uri, args)
+	} else {
+		repo, err = parseLanguage(ctx, uri, args)
+	}
+	if err != nil && (repo == nil || ctx.Err() == nil) {
 		log.Error("Failed to collect symbols: %v\n", err)
 		return nil, err
 	}
+	interrupted := err
+	repo.FilterKinds(args.Kinds())
+	repo.HashNodes()
+	if stdVersion != "" {
+		if err := repo.AsStdLib(args.Language, stdVersion); err != nil {
+			return nil, err
+		}
+	} else if args.LinkStd && args.Language == uniast.Rust {
+		dropped, err := repo.LinkStd(args.StdVersion, rust.StdModule)
+		if err != nil {
+			return nil, err
+		}
+		log.Info("linked the std lib %s, dropped the collected modules %v\n", args.StdVersion, dropped)
+	}
+	if args.Dedup {
+		n := repo.Dedup()
+		log.Info("dedup %d nodes of external modules and vendored or generated dirs\n", n)
+	}
+
+	if args.FailOnError {
+		if errs := repo.Diagnostics(uniast.SeverityError); len(errs) > 0 {
+			files := make([]string, 0, len(errs))
+			for f, ds := range errs {
+				files = append(files, f)
+				for _, d := range ds {
+					log.Error("%s:%d:%d: %s\n", f, d.Line, d.Column, d.Message)
+				}
+			}
+			sort.Strings(files)
+			return nil, fmt.Errorf("found errors in %d files: %s", len(files), strings.Join(files, ", "))
+		}
+	}
 
 	if !args.DisableBuildGraph {
 		if err = repo.BuildGraph(); err != nil {
 			return nil, err
 		}
 	}
+	repo.ComputeMetrics()
 
 	log.Info("all symbols collected, start writing to stdout...\n")
 
@@ -119,23 +253,171 @@ func Parse(ctx context.Context, uri string, args ParseOptions) ([]byte, error) {
 
 	repo.ASTVersion = uniast.Version
 	repo.ToolVersion = version.Version
+	repo.VCS = readVCS(uri)
+	if args.Blame && repo.VCS != nil {
+		log.Info("blaming the files for the owners of nodes...\n")
+		repo.AnnotateOwners(func(file string) ([]uniast.BlameLine, error) {
+			return blameFile(uri, file)
+		})
+	}
+	if args.Comments {
+		log.Info("collecting the comments of the files...\n")
+		repo.CollectComments(func(file string) ([]byte, error) {
+			return os.ReadFile(filepath.Join(uri, file))
+		})
+	}
+	readBuildConfigs(uri, repo)
+	repo.Dependencies = repo.ExternalDependencies()
+	if args.DetectLicenses {
+		log.Info("detecting the licenses of dependencies...\n")
+		repo.DetectLicenses(func(dep uniast.ExternalDependency) string {
+			return dependencyDir(uri, dep)
+		})
+	}
+	if args.Progress != nil && interrupted == nil {
+		args.Progress(progress.Event{Phase: progress.PhaseDone})
+	}
+	return repo, interrupted
+}
 
-	out, err := json.Marshal(repo)
+// stdSources returns the source dir of the std lib and the version of the toolchain of the language
+func stdSources(language uniast.Language) (src string, version string, err error) {
+	switch language {
+	case uniast.Golang:
+		return parser.Toolchain()
+	case uniast.Rust:
+		return rust.Toolchain()
+	default:
+		return "", "", fmt.Errorf("the std lib of %s is not supported", language)
+	}
+}
+
+// readBuildConfigs reads the build configurations of the internal modules from their manifests,
+// unless the parser has already done so
+func readBuildConfigs(uri string, repo *uniast.Repository) {
+	for _, mod := range repo.InternalModules() {
+		if mod.Build != nil {
+			continue
+		}
+		switch mod.Language {
+		case uniast.Rust:
+			mod.Build = rust.ReadBuildConfig(uri, mod.Dir)
+		case uniast.Python:
+			mod.Build = python.ReadBuildConfig(uri, mod.Dir)
+		}
+	}
+}
+
+// parseLanguage parses the repo by the parser of args.Language
+func parseLanguage(ctx context.Context, uri string, args ParseOptions) (*uniast.Repository, error) {
+	if args.useExternalParser() {
+		return external.ParseRepo(ctx, args.Language, uri, args.externalOptions())
+	} else if args.Language == uniast.TypeScript {
+		// TS is parsed by the abcoder-ts-parser subprocess instead of LSP
+		return ts.ParseRepo(ctx, uri, args.tsOptions())
+	}
+	return collectRepo(ctx, uri, args)
+}
+
+// parseDetected parses the repo by the parsers of its languages, see DetectLanguages.
+// The repos of multiple languages are merged into one
+func parseDetected(ctx context.Context, uri string, args ParseOptions) (*uniast.Repository, error) {
+	langs, err := DetectLanguages(uri)
 	if err != nil {
-		log.Error("Failed to marshal repository: %v\n", err)
 		return nil, err
 	}
-	return out, nil
+	var repo *uniast.Repository
+	for _, l := range langs {
+		log.Info("detected language %s of %s\n", l, uri)
+		args.Language = l
+		r, err := parseLanguage(ctx, uri, args)
+		if r != nil {
+			if repo == nil {
+				repo = r
+			} else if merr := uniast.Merge(repo, r, uniast.MergeOptions{DisableBuildGraph: true}); merr != nil {
+				return nil, fmt.Errorf("merge the %s codes: %w", l, merr)
+			}
+		}
+		if err != nil {
+			if ctx.Err() == nil {
+				err = fmt.Errorf("parse the %s codes: %w", l, err)
+			}
+			return repo, err
+		}
+	}
+	return repo, nil
 }
 
-func checkRepoPath(repoPath string, language uniast.Language) (openfile string, wait time.Duration, err error) {
+// collectRepo collects the symbols of the repo by the LSP or the Go parser
+func collectRepo(ctx context.Context, uri string, args ParseOptions) (*uniast.Repository, error) {
+	l, lspPath, err := checkLSP(args.Language, args.LSP, args)
+	if err != nil {
+		return nil, err
+	}
+	openfile, opentime, err := checkRepoPath(uri, l, args)
+	if err != nil {
+		return nil, err
+	}
+
+	var client = &lsp.LSPClient{ClientOptions: lsp.ClientOptions{Language: args.Language, Verbose: args.Verbose}, LspOptions: args.LspOptions}
+	if lspPath != "" {
+		// Initialize the LSP client
+		log.Info("start initialize LSP server %s...\n", lspPath)
+		register.RegisterProviders()
+		var initOpts interface{} = args.LspOptions
+		if l == uniast.Rust && !args.features().IsEmpty() {
+			initOpts = args.features().InitializationOptions(args.LspOptions)
+		}
+		var err error
+		client, err = lsp.NewLSPClient(uri, openfile, opentime, lsp.ClientOptions{
+			Server:                  lspPath,
+			RemoteRoot:              args.LSPRemoteRoot,
+			Language:                l,
+			Verbose:                 args.Verbose,
+			InitializationOptions:   initOpts,
+			RequestTimeout:          args.LSPRequestTimeout,
+			MaxRestarts:             args.LSPMaxRestarts,
+			MaxRetries:              args.LSPMaxRetries,
+			TraceDir:                args.LSPTraceDir,
+			WorkspaceSymbolFallback: args.LSPWorkspaceSymbols,
+		})
+		if err != nil {
+			log.Error("failed to initialize LSP server: %v\n", err)
+			return nil, err
+		}
+		client.LspOptions = args.LspOptions
+
+		log.Info("end initialize LSP server")
+	}
+
+	return collectSymbol(ctx, client, uri, args.CollectOption)
+}
+
+// fetchCrates unpacks the crates locked by Cargo.lock into the cargo registry if missing,
+// where rust-analyzer loads the external symbols from
+func fetchCrates(repoPath string) {
+	lockfile := filepath.Join(repoPath, "Cargo.lock")
+	if _, err := os.Stat(lockfile); err != nil {
+		return
+	}
+	log.Info("fetching the sources of the locked crates...\n")
+	n, err := (&depsrc.Fetcher{}).FetchCrates(context.Background(), lockfile)
+	if err != nil {
+		log.Error("failed to fetch crates (%d ready): %v\n", n, err)
+	}
+}
+
+func checkRepoPath(repoPath string, language uniast.Language, args ParseOptions) (openfile string, wait time.Duration, err error) {
 	if _, err := os.Stat(repoPath); os.IsNotExist(err) {
 		return "", 0, fmt.Errorf("repository not found: %s", repoPath)
 	}
 	switch language {
 	case uniast.Rust:
+		if args.FetchSources && args.LoadExternalSymbol {
+			fetchCrates(repoPath)
+		}
 		// NOTICE: open the Cargo.toml file is required for Rust projects
-		openfile, wait = rust.CheckRepo(repoPath)
+		openfile, wait = rust.CheckRepoFeatures(repoPath, args.features())
 	case uniast.Cxx:
 		openfile, wait = cxx.CheckRepo(repoPath)
 	case uniast.Cpp:
@@ -144,6 +426,12 @@ func checkRepoPath(repoPath string, language uniast.Language) (openfile string,
 		openfile, wait = python.CheckRepo(repoPath)
 	case uniast.Java:
 		openfile, wait = pb.CheckRepo(repoPath)
+	case uniast.Scala:
+		openfile, wait = scala.CheckRepo(repoPath)
+	case uniast.PHP:
+		openfile, wait = php.CheckRepo(repoPath)
+	case uniast.Ruby:
+		openfile, wait = ruby.CheckRepo(repoPath)
 	default:
 		openfile = ""
 		wait = 0
@@ -171,10 +459,16 @@ func checkLSP(language uniast.Language, lspPath string, args ParseOptions) (l un
 			l, s = python.GetDefaultLSP()
 		case uniast.Java:
 			l, s = pb.GetDefaultLSP(args.LspOptions)
+		case uniast.Scala:
+			l, s = scala.GetDefaultLSP()
+		case uniast.PHP:
+			l, s = php.GetDefaultLSP()
+		case uniast.Ruby:
+			l, s = ruby.GetDefaultLSP()
 		case uniast.Golang:
 			if _, err := exec.LookPath("go"); err != nil {
 				if _, err := os.Stat(lspPath); os.IsNotExist(err) {
-					log.Error("Go compiler not found, please make it excutable!\n", lspPath)
+					log.Error("Go compiler not found, please make it excutable: %s\n", lspPath)
 					return uniast.Unknown, "", err
 				}
 			}
@@ -190,7 +484,7 @@ func collectSymbol(ctx context.Context, cli *lsp.LSPClient, repoPath string, opt
 	if opts.Language == uniast.Golang {
 		repo, err = callGoParser(ctx, repoPath, opts)
 		if err != nil {
-			return nil, err
+			return repo, err
 		}
 	} else {
 		collector := collect.NewCollector(repoPath, cli)
@@ -230,10 +524,52 @@ func callGoParser(ctx context.Context, repoPath string, opts collect.CollectOpti
 		goopts.LoadByPackages = true
 	}
 	goopts.Excludes = opts.Excludes
+	goopts.OnlyPkgs = opts.OnlyPkgs
+	goopts.OnlyDirs = opts.OnlyDirs
 	goopts.BuildFlags = opts.BuildFlags
+	goopts.Progress = opts.Progress
+	goopts.CallGraph = opts.GoCallGraph
+	goopts.ClosureMinLines = opts.GoClosureMinLines
+	goopts.FetchSources = opts.FetchSources
+	if opts.StdVersion != "" {
+		goopts.StdModPath = uniast.StdModPath(uniast.StdModule, opts.StdVersion)
+	}
+	if len(opts.GoTags) <= 1 {
+		if len(opts.GoTags) == 1 {
+			goopts.Tags = strings.Split(opts.GoTags[0], ",")
+		}
+		return parseGoVariant(ctx, repoPath, goopts)
+	}
+
+	// parse each tag set and merge the variants
+	repos := make([]uniast.Repository, 0, len(opts.GoTags))
+	for _, tags := range opts.GoTags {
+		log.Info("parsing go codes with build tags '%s'...\n", tags)
+		goopts.Tags = strings.Split(tags, ",")
+		repo, err := parseGoVariant(ctx, repoPath, goopts)
+		if err != nil {
+			if repo == nil {
+				return nil, err
+			}
+			// interrupted, merge the collected variants
+			repos = append(repos, *repo)
+			merged := parser.MergeVariants(opts.GoTags[:len(repos)], repos)
+			return &merged, err
+		}
+		repos = append(repos, *repo)
+	}
+	repo := parser.MergeVariants(opts.GoTags, repos)
+	return &repo, nil
+}
+
+func parseGoVariant(ctx context.Context, repoPath string, goopts parser.Options) (*uniast.Repository, error) {
 	p := parser.NewParser(repoPath, repoPath, goopts)
-	repo, err := p.ParseRepo()
+	repo, err := p.ParseRepoContext(ctx)
 	if err != nil {
+		if ctx.Err() != nil {
+			// interrupted, return the collected modules
+			return &repo, err
+		}
 		return nil, err
 	}
 	return &repo, nil
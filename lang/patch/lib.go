@@ -121,8 +121,13 @@ next_dep:
 		return fmt.Errorf("unsupported language %s writer", mod.Language)
 	}
 
-	for _, dep := range patch.AddedDeps {
-		impt, err := w.IdToImport(dep)
+	// compute required imports from the cross-package dependencies of the node,
+	// the unused ones will be removed when flushing
+	for _, dep := range node.Dependencies {
+		if dep.PkgPath == "" || dep.PkgPath == patch.Id.PkgPath {
+			continue
+		}
+		impt, err := w.IdToImport(dep.Identity)
 		if err != nil {
 			return fmt.Errorf("convert identity %s to import failed: %v", dep.Full(), err)
 		}
@@ -179,7 +184,8 @@ func (p *Patcher) Flush() error {
 
 		var offset int
 		for _, n := range ns {
-			if n.StartOffset >= len(data) {
+			// the offsets are of the original file, which is shifted by the previous patches
+			if n.StartOffset >= len(data)-offset {
 				data = append(append(data, '\n'), []byte(n.Codes)...)
 				continue
 			}
@@ -203,6 +209,10 @@ func (p *Patcher) Flush() error {
 			if err != nil {
 				return fmt.Errorf("patch imports failed: %v", err)
 			}
+			data, err = writer.RemoveUnusedImports(data)
+			if err != nil {
+				return fmt.Errorf("remove unused imports failed: %v", err)
+			}
 			if err := utils.MustWriteFile(filepath.Join(p.OutDir, fpath), data); err != nil {
 				return fmt.Errorf("write file %s failed: %v", fpath, err)
 			}
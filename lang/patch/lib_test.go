@@ -17,6 +17,9 @@
 package patch
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/cloudwego/abcoder/lang/testutils"
@@ -112,3 +115,249 @@ func TestPatcher(t *testing.T) {
 
 	// TODO: check patching work as expected
 }
+
+func TestPatcher_Imports(t *testing.T) {
+	src := `package a
+
+import (
+	"fmt"
+	"os"
+)
+
+func Old() {
+	fmt.Println(os.Args)
+}
+`
+	repoDir := t.TempDir()
+	outDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(repoDir, "a"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repoDir, "a/a.go"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	modPath := "example.com/demo"
+	pkgPath := modPath + "/a"
+	repo := uniast.NewRepository(modPath)
+	mod := uniast.NewModule(modPath, ".", uniast.Golang)
+	repo.SetModule(modPath, mod)
+	mod.Files["a/a.go"] = uniast.NewFile("a/a.go")
+	pkg := uniast.NewPackage(pkgPath)
+	mod.Packages[pkgPath] = pkg
+	start := strings.Index(src, "func Old")
+	pkg.Functions["Old"] = &uniast.Function{
+		Identity: uniast.NewIdentity(modPath, pkgPath, "Old"),
+		FileLine: uniast.FileLine{File: "a/a.go", Line: 8, StartOffset: start, EndOffset: len(src) - 1},
+		Content:  src[start : len(src)-1],
+	}
+	if err := repo.BuildGraph(); err != nil {
+		t.Fatal(err)
+	}
+
+	patcher := NewPatcher(&repo, Options{
+		RepoDir:         repoDir,
+		OutDir:          outDir,
+		DefaultLanguage: uniast.Golang,
+	})
+	// the new codes call another package and no longer use fmt and os
+	if err := patcher.Patch(Patch{
+		Id:    uniast.NewIdentity(modPath, pkgPath, "Old"),
+		Codes: "func Old() {\n\tb.Hello()\n}",
+		File:  "a/a.go",
+		Type:  uniast.FUNC,
+		AddedDeps: []uniast.Identity{
+			uniast.NewIdentity(modPath, modPath+"/b", "Hello"),
+		},
+	}); err != nil {
+		t.Fatalf("failed to patch: %v", err)
+	}
+	if err := patcher.Flush(); err != nil {
+		t.Fatalf("failed to flush: %v", err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(outDir, "a/a.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `package a
+
+import (
+	"example.com/demo/b"
+)
+
+func Old() {
+	b.Hello()
+}
+`
+	if string(got) != want {
+		t.Errorf("patched file = %s, want %s", got, want)
+	}
+}
+
+func TestPatcher_Move(t *testing.T) {
+	files := map[string]string{
+		"a/a.go": `package a
+
+import "fmt"
+
+func Helper() string { return "x" }
+
+func Old() {
+	fmt.Println(Helper())
+}
+
+func Caller() {
+	Old()
+}
+`,
+		"b/b.go": `package b
+
+import "example.com/demo/a"
+
+func Use() {
+	a.Old()
+}
+`,
+	}
+	repoDir := t.TempDir()
+	outDir := t.TempDir()
+	modPath := "example.com/demo"
+	repo := uniast.NewRepository(modPath)
+	mod := uniast.NewModule(modPath, ".", uniast.Golang)
+	repo.SetModule(modPath, mod)
+	id := func(pkg, name string) uniast.Identity { return uniast.NewIdentity(modPath, modPath+"/"+pkg, name) }
+	for path, src := range files {
+		if err := os.MkdirAll(filepath.Join(repoDir, filepath.Dir(path)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(repoDir, path), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+		mod.Files[path] = uniast.NewFile(path)
+		pkg := modPath + "/" + filepath.Dir(path)
+		mod.Packages[pkg] = uniast.NewPackage(pkg)
+	}
+	// the node of the codes in the file, and the file line of the n-th occurrence of the name in it
+	node := func(path, code string) (uniast.FileLine, string) {
+		src := files[path]
+		start := strings.Index(src, code)
+		end := start + strings.Index(src[start:], "\n}") + 2
+		return uniast.FileLine{File: path, Line: strings.Count(src[:start], "\n") + 1, StartOffset: start, EndOffset: end}, src[start:end]
+	}
+	ref := func(path, name string, n int) uniast.FileLine {
+		src := files[path]
+		off := -1
+		for ; n >= 0; n-- {
+			off += 1 + strings.Index(src[off+1:], name)
+		}
+		return uniast.FileLine{File: path, Line: strings.Count(src[:off], "\n") + 1, StartOffset: off, EndOffset: off + len(name)}
+	}
+	fn := func(pkg, name string, fl uniast.FileLine, content string, calls ...uniast.Dependency) {
+		mod.Packages[modPath+"/"+pkg].Functions[name] = &uniast.Function{Identity: id(pkg, name), FileLine: fl, Content: content, Exported: true, FunctionCalls: calls}
+	}
+	fl, c := node("a/a.go", "func Helper")
+	fn("a", "Helper", fl, c)
+	fl, c = node("a/a.go", "func Old")
+	fn("a", "Old", fl, c,
+		uniast.NewDependency(uniast.NewIdentity("", "fmt", "Println"), ref("a/a.go", "Println", 0)),
+		uniast.NewDependency(id("a", "Helper"), ref("a/a.go", "Helper", 1)))
+	fl, c = node("a/a.go", "func Caller")
+	fn("a", "Caller", fl, c, uniast.NewDependency(id("a", "Old"), ref("a/a.go", "Old", 1)))
+	fl, c = node("b/b.go", "func Use")
+	fn("b", "Use", fl, c, uniast.NewDependency(id("a", "Old"), ref("b/b.go", "Old", 0)))
+	if err := repo.BuildGraph(); err != nil {
+		t.Fatal(err)
+	}
+
+	patcher := NewPatcher(&repo, Options{
+		RepoDir:         repoDir,
+		OutDir:          outDir,
+		DefaultLanguage: uniast.Golang,
+	})
+	if err := patcher.Move(Move{Id: id("a", "Old"), PkgPath: modPath + "/c", File: "c/c.go"}); err != nil {
+		t.Fatalf("failed to move: %v", err)
+	}
+	if err := patcher.Flush(); err != nil {
+		t.Fatalf("failed to flush: %v", err)
+	}
+	if repo.GetFunction(id("a", "Old")) != nil || repo.GetFunction(id("c", "Old")) == nil {
+		t.Error("the node is not moved")
+	}
+	if got := repo.GetFunction(id("b", "Use")).FunctionCalls[0].Identity; got != id("c", "Old") {
+		t.Errorf("dependency of Use = %v", got)
+	}
+
+	want := map[string]string{
+		"a/a.go": `package a
+
+import (
+	"example.com/demo/c"
+)
+
+func Helper() string { return "x" }
+
+func Caller() {
+	c.Old()
+}
+`,
+		"b/b.go": `package b
+
+import (
+	"example.com/demo/c"
+)
+
+func Use() {
+	c.Old()
+}
+`,
+		"c/c.go": `package c
+
+import (
+	"fmt"
+	"example.com/demo/a"
+)
+
+func Old() {
+	fmt.Println(a.Helper())
+}`,
+	}
+	for path, w := range want {
+		got, err := os.ReadFile(filepath.Join(outDir, path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != w {
+			t.Errorf("%s = %s, want %s", path, got, w)
+		}
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	src := "package a\n\nfunc F() {\n\tg := func() { println(1) }\n\tg()\n}\n\nvar   V = 1\n"
+	span := func(text, content string) Span {
+		i := strings.Index(src, text)
+		return Span{Start: i, End: i + len(text), Content: content}
+	}
+	f := "func F() {\n\tg := func() { println(1) }\n\tg()\n}"
+	closure := "func() { println(1) }"
+
+	got, n := Rewrite([]byte(src), []Span{span(f, f), span(closure, closure), span("var   V = 1", "var   V = 1")})
+	if string(got) != src || n != 0 {
+		t.Errorf("unchanged: %d %q", n, got)
+	}
+
+	// the closure changes inside its unchanged parent, the formatting of V is kept
+	got, n = Rewrite([]byte(src), []Span{span(f, f), span(closure, "func() { println(2) }"), span("var   V = 1", "var   V = 2"),
+		{Content: "func G() {}"}})
+	want := "package a\n\nfunc F() {\n\tg := func() { println(2) }\n\tg()\n}\n\nvar   V = 2\n\nfunc G() {}\n"
+	if string(got) != want || n != 3 {
+		t.Errorf("changed: %d %q", n, got)
+	}
+
+	// the changed parent wins over its closures
+	got, _ = Rewrite([]byte(src), []Span{span(closure, "func() { println(2) }"), span(f, "func F() {}")})
+	if want := "package a\n\nfunc F() {}\n\nvar   V = 1\n"; string(got) != want {
+		t.Errorf("nested: %q", got)
+	}
+}
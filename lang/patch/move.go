@@ -0,0 +1,279 @@
+// Copyright 2025 ByteDance Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package patch
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/cloudwego/abcoder/lang/golang/writer"
+	"github.com/cloudwego/abcoder/lang/uniast"
+)
+
+// Move relocates a node to another package
+type Move struct {
+	Id uniast.Identity
+	// ModPath is the module of the destination package, the module of the node if empty
+	ModPath uniast.ModPath
+	PkgPath uniast.PkgPath
+	// File is the destination file, it is created if not exists
+	File string
+}
+
+// edit replaces the codes in [start, end) of a node
+type edit struct {
+	start, end int
+	text       string
+}
+
+// movedNode is the snapshot of a node before moving
+type movedNode struct {
+	uniast.FileLine
+	typ     uniast.NodeType
+	content string
+}
+
+// Move moves the node to the package as uniast.Repository.MoveNode does, and patches the codes:
+// the node (with the methods of a type) is removed from its file and appended to the destination file,
+// the references between it and the nodes of the source or destination package are qualified or unqualified,
+// and the references from other packages are qualified by the destination package. The imports are adjusted on Flush.
+// Nothing is changed if any reference can't be located by the offsets, or an unexported node would be referred across packages
+func (p *Patcher) Move(m Move) error {
+	if m.ModPath == "" {
+		m.ModPath = m.Id.ModPath
+	}
+	if m.File == "" {
+		return fmt.Errorf("destination file of %s is required", m.Id.Full())
+	}
+	mod := p.repo.GetModule(m.ModPath)
+	if mod == nil {
+		return fmt.Errorf("module %s not found", m.ModPath)
+	}
+	if p.getLangWriter(mod.Language) == nil {
+		return fmt.Errorf("unsupported language %s writer", mod.Language)
+	}
+	if len(p.repo.Graph) == 0 {
+		if err := p.repo.BuildGraph(); err != nil {
+			return err
+		}
+	}
+
+	// the nodes to move, with the methods of a type
+	moved := map[uniast.Identity]bool{m.Id: true}
+	if t := p.repo.GetType(m.Id); t != nil {
+		for _, id := range t.Methods {
+			if id.ModPath == m.Id.ModPath && id.PkgPath == m.Id.PkgPath {
+				moved[id] = true
+			}
+		}
+	}
+	pkgOf := func(id uniast.Identity) uniast.PkgPath {
+		if moved[id] {
+			return m.PkgPath
+		}
+		return id.PkgPath
+	}
+
+	// rewrite the qualifiers of the references before moving, since the offsets of the moved nodes are dropped then
+	edits := map[uniast.Identity][]edit{}
+	var err error
+	p.forEachNode(func(id uniast.Identity, fl uniast.FileLine, content string, deps []uniast.Dependency) {
+		for _, dep := range deps {
+			if err != nil {
+				return
+			}
+			if moved[id] == moved[dep.Identity] {
+				continue
+			}
+			if moved[id] && dep.PkgPath != m.Id.PkgPath && dep.PkgPath != m.PkgPath {
+				// the qualifier of another package is kept
+				continue
+			}
+			qual := ""
+			if pkgOf(id) != pkgOf(dep.Identity) {
+				if !p.isExported(dep.Identity) {
+					err = fmt.Errorf("unexported %s can't be referred by %s after moving", dep.Full(), id.Full())
+					return
+				}
+				qual = writer.PackageName(pkgOf(dep.Identity)) + "."
+			}
+			e, ok := qualify(content, fl, dep, qual)
+			if !ok {
+				err = fmt.Errorf("reference to %s in %s can't be located", dep.Full(), id.Full())
+				return
+			}
+			edits[id] = append(edits[id], e)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	olds := map[uniast.Identity]movedNode{}
+	for id := range moved {
+		n := p.repo.GetNode(id)
+		if n == nil {
+			return fmt.Errorf("node %s not found", id.Full())
+		}
+		olds[id] = movedNode{FileLine: n.FileLine(), typ: n.Type, content: p.content(id)}
+	}
+	to, err := p.repo.MoveNode(m.Id, m.ModPath, m.PkgPath, m.File)
+	if err != nil {
+		return err
+	}
+
+	// remove the moved nodes from their files, and append them to the destination file in the order of the codes
+	var ids []uniast.Identity
+	for id := range moved {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return olds[ids[i]].StartOffset < olds[ids[j]].StartOffset })
+	for _, id := range ids {
+		old := olds[id]
+		src := p.repo.GetModule(id.ModPath)
+		f := src.GetFile(old.File)
+		if f == nil {
+			f = uniast.NewFile(old.File)
+			src.CreateFile(old.File, f)
+		}
+		if err := p.patch(PatchNode{
+			Identity: id,
+			FileLine: old.FileLine,
+			File:     f,
+		}); err != nil {
+			return err
+		}
+		if err := p.Patch(Patch{
+			Id:    to[id],
+			Codes: applyEdits(old.content, edits[id]),
+			File:  m.File,
+			Type:  old.typ,
+		}); err != nil {
+			return err
+		}
+	}
+
+	// patch the references in place
+	for id, es := range edits {
+		if moved[id] {
+			continue
+		}
+		n := p.repo.GetNode(id)
+		if n == nil {
+			return fmt.Errorf("node %s not found", id.Full())
+		}
+		if err := p.Patch(Patch{
+			Id:    id,
+			Codes: applyEdits(p.content(id), es),
+			File:  n.FileLine().File,
+			Type:  n.Type,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// forEachNode visits the nodes of the internal modules with their dependencies,
+// except the method calls whose qualifiers are the receivers
+func (p *Patcher) forEachNode(visit func(id uniast.Identity, fl uniast.FileLine, content string, deps []uniast.Dependency)) {
+	for _, mod := range p.repo.InternalModules() {
+		for _, pkg := range mod.Packages {
+			for _, fn := range pkg.Functions {
+				var deps []uniast.Dependency
+				for _, ds := range [][]uniast.Dependency{fn.Params, fn.Results, fn.FunctionCalls, fn.Types, fn.GlobalVars} {
+					deps = append(deps, ds...)
+				}
+				visit(fn.Identity, fn.FileLine, fn.Content, deps)
+			}
+			for _, t := range pkg.Types {
+				visit(t.Identity, t.FileLine, t.Content, append(append([]uniast.Dependency{}, t.SubStruct...), t.InlineStruct...))
+			}
+			for _, v := range pkg.Vars {
+				visit(v.Identity, v.FileLine, v.Content, v.Dependencies)
+			}
+		}
+	}
+}
+
+func (p *Patcher) content(id uniast.Identity) string {
+	if fn := p.repo.GetFunction(id); fn != nil {
+		return fn.Content
+	} else if t := p.repo.GetType(id); t != nil {
+		return t.Content
+	} else if v := p.repo.GetVar(id); v != nil {
+		return v.Content
+	}
+	return ""
+}
+
+func (p *Patcher) isExported(id uniast.Identity) bool {
+	if fn := p.repo.GetFunction(id); fn != nil {
+		return fn.Exported
+	} else if t := p.repo.GetType(id); t != nil {
+		return t.Exported
+	} else if v := p.repo.GetVar(id); v != nil {
+		return v.IsExported
+	}
+	// external nodes are always referred across packages
+	return true
+}
+
+// qualify returns the edit which replaces the qualifier of the reference to the dependency with qual,
+// like `a.Foo` to `b.Foo` or `Foo`. The reference is the last name of the dependency in its span
+func qualify(content string, node uniast.FileLine, dep uniast.Dependency, qual string) (edit, bool) {
+	start, end := dep.StartOffset-node.StartOffset, dep.EndOffset-node.StartOffset
+	if dep.File != node.File || start < 0 || end > len(content) || start >= end {
+		return edit{}, false
+	}
+	name := dep.Name
+	if i := strings.LastIndexByte(name, '.'); i >= 0 {
+		name = name[i+1:]
+	}
+	off := strings.LastIndex(content[start:end], name)
+	if off < 0 {
+		return edit{}, false
+	}
+	off += start
+	// the qualifier like `a.` before the name
+	q := off
+	if q > 0 && content[q-1] == '.' {
+		q--
+		for q > 0 && isIdentByte(content[q-1]) {
+			q--
+		}
+	}
+	return edit{start: q, end: off, text: qual}, true
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c >= 0x80 || unicode.IsLetter(rune(c)) || unicode.IsDigit(rune(c))
+}
+
+// applyEdits applies the edits from the last one, the duplicated ones are skipped
+func applyEdits(content string, es []edit) string {
+	sort.Slice(es, func(i, j int) bool { return es[i].start > es[j].start })
+	last := -1
+	for _, e := range es {
+		if e.start == last {
+			continue
+		}
+		last = e.start
+		content = content[:e.start] + e.text + content[e.end:]
+	}
+	return content
+}
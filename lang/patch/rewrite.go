@@ -0,0 +1,102 @@
+// Copyright 2025 ByteDance Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package patch
+
+import (
+	"bytes"
+	"sort"
+)
+
+// Span is a node of a file: its range [Start, End) in the original codes and its current content.
+// A span with an empty range (Start == End == 0) is a new node.
+type Span struct {
+	Start, End int
+	Content    string
+}
+
+// Rewrite writes the contents of the spans onto src, the original codes of a file, with the minimal edits,
+// and returns the new codes and the number of changed spans.
+// The codes out of the spans and the unchanged spans are kept byte by byte, and a changed span only
+// replaces the codes between its common prefix and common suffix with the original codes,
+// thus diffs reflect only the genuinely changed codes.
+// Of the overlapping spans, the first changed one in the order of (Start, -End) wins,
+// like a function over the closures in it. New spans are appended to the end.
+func Rewrite(src []byte, spans []Span) ([]byte, int) {
+	var es []edit
+	var added []string
+	sorted := make([]Span, 0, len(spans))
+	for _, s := range spans {
+		if s.Start == 0 && s.End == 0 {
+			if s.Content != "" {
+				added = append(added, s.Content)
+			}
+			continue
+		}
+		if s.Start < 0 || s.End > len(src) || s.Start > s.End {
+			continue
+		}
+		sorted = append(sorted, s)
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Start != sorted[j].Start {
+			return sorted[i].Start < sorted[j].Start
+		}
+		return sorted[i].End > sorted[j].End
+	})
+	last := 0
+	for _, s := range sorted {
+		if s.Start < last {
+			// overlaps a changed span
+			continue
+		}
+		old, new := string(src[s.Start:s.End]), s.Content
+		if old == new {
+			continue
+		}
+		p, q := commonPrefix(old, new), 0
+		for q < len(old)-p && q < len(new)-p && old[len(old)-1-q] == new[len(new)-1-q] {
+			q++
+		}
+		es = append(es, edit{start: s.Start + p, end: s.End - q, text: new[p : len(new)-q]})
+		last = s.End
+	}
+
+	var buf bytes.Buffer
+	buf.Grow(len(src))
+	at := 0
+	for _, e := range es {
+		buf.Write(src[at:e.start])
+		buf.WriteString(e.text)
+		at = e.end
+	}
+	buf.Write(src[at:])
+	for _, c := range added {
+		if buf.Len() > 0 && !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+			buf.WriteByte('\n')
+		}
+		buf.WriteByte('\n')
+		buf.WriteString(c)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), len(es) + len(added)
+}
+
+func commonPrefix(a, b string) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
@@ -0,0 +1,59 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package php
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/cloudwego/abcoder/lang/log"
+	"github.com/cloudwego/abcoder/lang/uniast"
+	"github.com/cloudwego/abcoder/lang/utils"
+)
+
+const MaxWaitDuration = 5 * time.Minute
+
+// InstallLanguageServer finds intelephense, or phpactor as the fallback
+func InstallLanguageServer() (string, error) {
+	if _, err := exec.LookPath("intelephense"); err == nil {
+		return "intelephense --stdio", nil
+	}
+	if _, err := exec.LookPath("phpactor"); err == nil {
+		return "phpactor language-server", nil
+	}
+	return "", fmt.Errorf("please install intelephense (`npm install -g intelephense`) or phpactor manually. See https://intelephense.com/ or https://phpactor.readthedocs.io/")
+}
+
+func GetDefaultLSP() (lang uniast.Language, name string) {
+	name, err := InstallLanguageServer()
+	if err != nil {
+		log.Error("Failed to find the PHP language server: %v\n", err)
+		os.Exit(1)
+	}
+	return uniast.PHP, name
+}
+
+func CheckRepo(repo string) (string, time.Duration) {
+	openfile := ""
+	// NOTICE: wait for the server to index the workspace based on code files
+	_, size := utils.CountFiles(repo, ".php", "vendor/")
+	wait := 2*time.Second + time.Second*time.Duration(size/1024)
+	if wait > MaxWaitDuration {
+		wait = MaxWaitDuration
+	}
+	return openfile, wait
+}
@@ -0,0 +1,608 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package php
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	lsp "github.com/cloudwego/abcoder/lang/lsp"
+	"github.com/cloudwego/abcoder/lang/uniast"
+	"github.com/cloudwego/abcoder/lang/utils"
+)
+
+// semantic-token type names.
+const (
+	tokClass         = "class"
+	tokInterface     = "interface"
+	tokEnum          = "enum"
+	tokEnumMember    = "enumMember"
+	tokType          = "type"
+	tokTypeParameter = "typeParameter"
+	tokFunction      = "function"
+	tokMethod        = "method"
+	tokVariable      = "variable"
+	tokProperty      = "property"
+	tokNamespace     = "namespace"
+	tokComment       = "comment"
+)
+
+// semantic-token modifier names.
+const (
+	modDeclaration    = "declaration"
+	modDefinition     = "definition"
+	modReadonly       = "readonly"
+	modDefaultLibrary = "defaultLibrary"
+)
+
+// GlobalNamespace is the package of the files without namespace declarations
+const GlobalNamespace = `\`
+
+// StdModule is the module of the builtin stubs of the language servers
+const StdModule = "php"
+
+var (
+	namespaceRegex = regexp.MustCompile(`(?m)^\s*namespace\s+([\w\\]+)\s*[;{]`)
+	useRegex       = regexp.MustCompile(`(?m)^use\s+(?:(?:function|const)\s+)?([^;]+);`)
+	aliasRegex     = regexp.MustCompile(`^(.+?)\s+as\s+(\w+)$`)
+	modifierRegex  = regexp.MustCompile(`^(public|protected|private|static|final|abstract|readonly)\b`)
+	traitUseRegex  = regexp.MustCompile(`^use\s+([\w\\]+(?:\s*,\s*[\w\\]+)*)\s*[;{]`)
+)
+
+type PhpSpec struct {
+	repo string
+	// module dir => module name, by the composer.json files of the repo
+	mods map[string]string
+
+	vendorOnce sync.Once
+	versions   map[string]string // vendor package => version, by vendor/composer/installed.json
+
+	nsMu sync.Mutex
+	nss  map[string]string // file path => namespace
+}
+
+func NewPhpSpec() *PhpSpec {
+	return &PhpSpec{
+		mods: map[string]string{},
+		nss:  map[string]string{},
+	}
+}
+
+func (c *PhpSpec) ProtectedSymbolKinds() []lsp.SymbolKind {
+	// methods and constants are nested inside the ranges of classes
+	return []lsp.SymbolKind{lsp.SKFunction, lsp.SKMethod, lsp.SKVariable, lsp.SKConstant}
+}
+
+type composerJSON struct {
+	Name string `json:"name"`
+}
+
+// WorkSpace takes each composer.json outside vendor/ as a module named by its `name`,
+// e.g. the path repositories of a monolith under packages/, or the directory if unnamed
+func (c *PhpSpec) WorkSpace(root string) (map[string]string, error) {
+	absPath, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path: %w", err)
+	}
+	c.repo = absPath
+	rets := map[string]string{}
+	err = filepath.WalkDir(absPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != absPath && (d.Name() == "vendor" || d.Name() == "node_modules" || strings.HasPrefix(d.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Name() != "composer.json" {
+			return nil
+		}
+		dir := filepath.Dir(path)
+		name := filepath.Base(dir)
+		var cj composerJSON
+		if data, err := os.ReadFile(path); err == nil && json.Unmarshal(data, &cj) == nil && cj.Name != "" {
+			name = cj.Name
+		}
+		rets[name] = dir
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(rets) == 0 {
+		rets[filepath.Base(absPath)] = absPath
+	}
+	for mod, dir := range rets {
+		c.mods[dir] = mod
+	}
+	return rets, nil
+}
+
+// NameSpace returns the module of the longest module dir containing the file, and the namespace declared in the file.
+// Packages under vendor/ are named like `monolog/monolog@3.5.0`
+func (c *PhpSpec) NameSpace(path string, file *uniast.File) (string, string, error) {
+	if mod, ok := c.vendorModule(path); ok {
+		return mod, c.fileNamespace(path), nil
+	}
+	mod, dir := "", ""
+	for d, m := range c.mods {
+		if hasPathPrefix(path, d) && len(d) > len(dir) {
+			mod, dir = m, d
+		}
+	}
+	if mod != "" {
+		return mod, c.fileNamespace(path), nil
+	}
+	if isStub(path) {
+		return StdModule, c.fileNamespace(path), nil
+	}
+	return "external", c.fileNamespace(path), nil
+}
+
+// isStub tells if the file is a builtin stub shipped with intelephense or phpactor
+func isStub(path string) bool {
+	p := filepath.ToSlash(path)
+	return strings.Contains(p, "/phpstorm-stubs/") || strings.Contains(p, "/intelephense/") && strings.Contains(p, "/stub")
+}
+
+func (c *PhpSpec) vendorModule(path string) (string, bool) {
+	i := strings.Index(filepath.ToSlash(path), "/vendor/")
+	if i < 0 {
+		return "", false
+	}
+	parts := strings.SplitN(filepath.ToSlash(path)[i+len("/vendor/"):], "/", 3)
+	if len(parts) < 3 {
+		return "", false
+	}
+	name := parts[0] + "/" + parts[1]
+	c.vendorOnce.Do(func() {
+		c.versions = readInstalled(filepath.Join(path[:i], "vendor", "composer", "installed.json"))
+	})
+	if v := c.versions[name]; v != "" {
+		return name + "@" + v, true
+	}
+	return name, true
+}
+
+// readInstalled reads the versions of the packages installed by composer,
+// which are listed in `packages` since composer 2, or at the top level before
+func readInstalled(path string) map[string]string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	type pkg struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+	var v2 struct {
+		Packages []pkg `json:"packages"`
+	}
+	var pkgs []pkg
+	if err := json.Unmarshal(data, &v2); err == nil {
+		pkgs = v2.Packages
+	} else if err := json.Unmarshal(data, &pkgs); err != nil {
+		return nil
+	}
+	ret := make(map[string]string, len(pkgs))
+	for _, p := range pkgs {
+		ret[p.Name] = strings.TrimPrefix(p.Version, "v")
+	}
+	return ret
+}
+
+func hasPathPrefix(p, root string) bool {
+	if !strings.HasPrefix(p, root) {
+		return false
+	}
+	return len(p) == len(root) || p[len(root)] == filepath.Separator
+}
+
+// fileNamespace reads the namespace of the file once and caches it
+func (c *PhpSpec) fileNamespace(path string) string {
+	c.nsMu.Lock()
+	ns, ok := c.nss[path]
+	c.nsMu.Unlock()
+	if ok {
+		return ns
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		ns = GlobalNamespace
+	} else {
+		ns = NamespaceOf(string(data))
+	}
+	c.nsMu.Lock()
+	c.nss[path] = ns
+	c.nsMu.Unlock()
+	return ns
+}
+
+// NamespaceOf returns the first namespace declared in a file, like `App\Http\Controllers`.
+// It returns GlobalNamespace if there is none
+func NamespaceOf(content string) string {
+	if m := namespaceRegex.FindStringSubmatch(content); m != nil {
+		return strings.Trim(m[1], `\`)
+	}
+	return GlobalNamespace
+}
+
+func (c *PhpSpec) ShouldSkip(path string) bool {
+	if !strings.HasSuffix(path, ".php") {
+		return true
+	}
+	p := filepath.ToSlash(path)
+	for _, dir := range []string{"/vendor/", "/node_modules/", "/storage/", "/bootstrap/cache/", "/var/cache/"} {
+		if strings.Contains(p, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// FileImports collects the top-level use declarations, and expands the grouped ones like `use App\{A, B as C}`
+func (c *PhpSpec) FileImports(content []byte) ([]uniast.Import, error) {
+	res := []uniast.Import{}
+	for _, m := range useRegex.FindAllStringSubmatch(string(content), -1) {
+		clause := strings.TrimSpace(m[1])
+		if l := strings.IndexByte(clause, '{'); l >= 0 {
+			prefix := strings.TrimSpace(clause[:l])
+			r := strings.LastIndexByte(clause, '}')
+			if r < l {
+				continue
+			}
+			for _, item := range strings.Split(clause[l+1:r], ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					res = append(res, newImport(prefix+item))
+				}
+			}
+			continue
+		}
+		for _, item := range strings.Split(clause, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				res = append(res, newImport(item))
+			}
+		}
+	}
+	return res, nil
+}
+
+func newImport(item string) uniast.Import {
+	item = strings.TrimPrefix(item, `\`)
+	if m := aliasRegex.FindStringSubmatch(item); m != nil {
+		alias := m[2]
+		return uniast.Import{Path: strings.TrimSpace(m[1]), Alias: &alias}
+	}
+	return uniast.Import{Path: item}
+}
+
+func (c *PhpSpec) IsDocToken(tok lsp.Token) bool {
+	return tok.Type == tokComment
+}
+
+func (c *PhpSpec) DeclareTokenOfSymbol(sym lsp.DocumentSymbol) int {
+	for i, t := range sym.Tokens {
+		if c.IsDocToken(t) {
+			continue
+		}
+		for _, m := range t.Modifiers {
+			if m == modDeclaration || m == modDefinition {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func (c *PhpSpec) IsEntityToken(tok lsp.Token) bool {
+	for _, m := range tok.Modifiers {
+		if m == modDeclaration || m == modDefinition {
+			return false
+		}
+	}
+	switch tok.Type {
+	case tokClass, tokInterface, tokEnum, tokType, tokFunction, tokMethod, tokProperty:
+		return true
+	}
+	return false
+}
+
+func (c *PhpSpec) IsStdToken(tok lsp.Token) bool {
+	for _, m := range tok.Modifiers {
+		if m == modDefaultLibrary {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *PhpSpec) TokenKind(tok lsp.Token) lsp.SymbolKind {
+	switch tok.Type {
+	case tokClass, tokType:
+		// traits are reported as types
+		return lsp.SKClass
+	case tokInterface:
+		return lsp.SKInterface
+	case tokEnum:
+		return lsp.SKEnum
+	case tokEnumMember:
+		return lsp.SKEnumMember
+	case tokTypeParameter:
+		return lsp.SKTypeParameter
+	case tokFunction:
+		return lsp.SKFunction
+	case tokMethod:
+		return lsp.SKMethod
+	case tokNamespace:
+		return lsp.SKNamespace
+	case tokProperty, tokVariable:
+		for _, m := range tok.Modifiers {
+			if m == modReadonly {
+				return lsp.SKConstant
+			}
+		}
+		return lsp.SKVariable
+	}
+	return lsp.SKUnknown
+}
+
+// IsMainFunction always returns false, since PHP scripts run from the top
+func (c *PhpSpec) IsMainFunction(sym lsp.DocumentSymbol) bool {
+	return false
+}
+
+func (c *PhpSpec) IsEntitySymbol(sym lsp.DocumentSymbol) bool {
+	typ := sym.Kind
+	return typ == lsp.SKMethod || typ == lsp.SKFunction || typ == lsp.SKClass || typ == lsp.SKInterface || typ == lsp.SKEnum ||
+		typ == lsp.SKConstant || typ == lsp.SKVariable
+}
+
+// IsPublicSymbol tells if the symbol is neither private nor protected. Members are public without modifiers
+func (c *PhpSpec) IsPublicSymbol(sym lsp.DocumentSymbol) bool {
+	s := skipAttributes(stripComments(sym.Text))
+	for {
+		s = strings.TrimLeft(s, " \t\r\n")
+		m := modifierRegex.FindString(s)
+		if m == "" {
+			return true
+		}
+		if m == "private" || m == "protected" {
+			return false
+		}
+		s = s[len(m):]
+	}
+}
+
+func (c *PhpSpec) HasImplSymbol() bool {
+	// `extends`, `implements` and trait uses are in the class declarations, not impl blocks
+	return false
+}
+
+func (c *PhpSpec) ImplSymbol(sym lsp.DocumentSymbol) (int, int, int) {
+	return -1, -1, -1
+}
+
+// FunctionSymbol splits the tokens of `function name(A $a, B $b): C`.
+// The receiver is the enclosing class, which is not in the signature
+func (c *PhpSpec) FunctionSymbol(sym lsp.DocumentSymbol) (int, []int, []int, []int) {
+	if sym.Kind != lsp.SKFunction && sym.Kind != lsp.SKMethod {
+		return -1, nil, nil, nil
+	}
+	name := c.DeclareTokenOfSymbol(sym)
+	if name < 0 {
+		return -1, nil, nil, nil
+	}
+	lines := utils.CountLines(sym.Text)
+	offset := func(i int) int {
+		return lsp.RelativePostionWithLines(lines, sym.Location.Range.Start, sym.Tokens[i].Location.Range.Start)
+	}
+
+	text := sym.Text
+	pos := offset(name) + len(sym.Tokens[name].Text)
+	if pos < 0 || pos > len(text) {
+		return -1, nil, nil, nil
+	}
+	paramStart, paramEnd := pos, pos
+	if l := strings.IndexByte(text[pos:], '('); l >= 0 {
+		if r := utils.MatchBracket(text, pos+l); r > 0 {
+			paramStart, paramEnd = pos+l, r
+		}
+	}
+	outStart, outEnd := paramEnd, paramEnd
+	if rest := strings.TrimLeft(text[paramEnd+1:], " \t\r\n"); strings.HasPrefix(rest, ":") {
+		outStart = len(text) - len(rest)
+		outEnd = len(text)
+		if i := strings.IndexAny(text[outStart:], "{;"); i >= 0 {
+			outEnd = outStart + i
+		}
+	}
+
+	var inputs, outputs []int
+	for i := name + 1; i < len(sym.Tokens); i++ {
+		off := offset(i)
+		if off >= outEnd {
+			break
+		}
+		if !c.IsEntityToken(sym.Tokens[i]) {
+			continue
+		}
+		switch {
+		case off > paramStart && off < paramEnd:
+			inputs = append(inputs, i)
+		case off > outStart:
+			outputs = append(outputs, i)
+		}
+	}
+	return -1, nil, inputs, outputs
+}
+
+func (c *PhpSpec) GetUnloadedSymbol(from lsp.Token, define lsp.Location) (string, error) {
+	return "", nil
+}
+
+// AdjustSymbols takes traits as classes, since their methods are copied into the classes using them
+func (c *PhpSpec) AdjustSymbols(syms []*lsp.DocumentSymbol) {
+	for _, s := range syms {
+		if s.Kind == lsp.SKClass || s.Kind == lsp.SKInterface || s.Kind == lsp.SKStruct || s.Kind == lsp.SKModule {
+			if strings.HasPrefix(declaration(s.Text), "trait ") {
+				s.Kind = lsp.SKClass
+			}
+		}
+	}
+}
+
+// declaration returns the text of a declaration from its keyword, skipping the comments, attributes and modifiers
+func declaration(text string) string {
+	s := skipAttributes(stripComments(text))
+	for {
+		s = strings.TrimLeft(s, " \t\r\n")
+		m := modifierRegex.FindString(s)
+		if m == "" {
+			return s
+		}
+		s = s[len(m):]
+	}
+}
+
+// UsedTraits returns the traits used by a class, like `use HasFactory, Notifiable;` in its body
+func UsedTraits(content string) []string {
+	l := strings.IndexByte(content, '{')
+	if l < 0 {
+		return nil
+	}
+	r := utils.MatchBracket(content, l)
+	if r < 0 {
+		r = len(content)
+	}
+	var ret []string
+	body := content[l+1 : r]
+	for depth, i := 0, 0; i < len(body); i++ {
+		switch body[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case 'u':
+			if depth != 0 || i > 0 && isIdentByte(body[i-1]) {
+				continue
+			}
+			if m := traitUseRegex.FindStringSubmatch(body[i:]); m != nil {
+				for _, t := range strings.Split(m[1], ",") {
+					t = strings.TrimSpace(t)
+					if j := strings.LastIndexByte(t, '\\'); j >= 0 {
+						t = t[j+1:]
+					}
+					ret = append(ret, t)
+				}
+			}
+		}
+	}
+	return ret
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c == '$' || c == '\\' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// Annotations parses the attributes of PHP 8 at the head of a declaration, like `#[Route('/api', methods: ['GET'])]`,
+// each of which may group several attributes like `#[A, B(1)]`
+func Annotations(content string) []uniast.Annotation {
+	var ret []uniast.Annotation
+	s := stripComments(content)
+	for {
+		s = strings.TrimLeft(s, " \t\r\n")
+		if !strings.HasPrefix(s, "#[") {
+			break
+		}
+		end := utils.MatchBracket(s, 1)
+		if end < 0 {
+			break
+		}
+		for _, attr := range splitTopLevel(s[2:end]) {
+			ann := uniast.ParseAnnotation(strings.TrimPrefix(attr, `\`))
+			ret = append(ret, ann)
+		}
+		s = stripComments(s[end+1:])
+	}
+	return ret
+}
+
+// skipAttributes drops the leading attributes of a declaration
+func skipAttributes(s string) string {
+	for {
+		s = strings.TrimLeft(s, " \t\r\n")
+		if !strings.HasPrefix(s, "#[") {
+			return s
+		}
+		end := utils.MatchBracket(s, 1)
+		if end < 0 {
+			return s
+		}
+		s = stripComments(s[end+1:])
+	}
+}
+
+// splitTopLevel splits s by the commas outside the brackets
+func splitTopLevel(s string) []string {
+	var ret []string
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(', '[', '{':
+			if end := utils.MatchBracket(s, i); end > 0 {
+				i = end
+			}
+		case ',':
+			if p := strings.TrimSpace(s[start:i]); p != "" {
+				ret = append(ret, p)
+			}
+			start = i + 1
+		}
+	}
+	if p := strings.TrimSpace(s[start:]); p != "" {
+		ret = append(ret, p)
+	}
+	return ret
+}
+
+// stripComments drops the leading comments of a declaration
+func stripComments(s string) string {
+	for {
+		s = strings.TrimLeft(s, " \t\r\n")
+		if strings.HasPrefix(s, "//") || strings.HasPrefix(s, "#") && !strings.HasPrefix(s, "#[") {
+			i := strings.IndexByte(s, '\n')
+			if i < 0 {
+				return ""
+			}
+			s = s[i+1:]
+		} else if strings.HasPrefix(s, "/*") {
+			i := strings.Index(s, "*/")
+			if i < 0 {
+				return ""
+			}
+			s = s[i+2:]
+		} else {
+			return s
+		}
+	}
+}
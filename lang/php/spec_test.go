@@ -0,0 +1,151 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package php
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	lsp "github.com/cloudwego/abcoder/lang/lsp"
+	"github.com/cloudwego/abcoder/lang/uniast"
+)
+
+func TestPhpSpec_WorkSpace(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"composer.json":                         `{"name": "acme/app"}`,
+		"app/Http/UserController.php":           "<?php\n\nnamespace App\\Http;\n\nclass UserController {}\n",
+		"packages/billing/composer.json":        `{"name": "acme/billing"}`,
+		"packages/billing/src/Invoice.php":      "<?php\nnamespace Acme\\Billing {\nclass Invoice {}\n}\n",
+		"scripts/run.php":                       "<?php\necho 1;\n",
+		"vendor/monolog/monolog/composer.json":  `{"name": "monolog/monolog"}`,
+		"vendor/monolog/monolog/src/Logger.php": "<?php\nnamespace Monolog;\nclass Logger {}\n",
+		"vendor/composer/installed.json":        `{"packages": [{"name": "monolog/monolog", "version": "v3.5.0"}]}`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	spec := NewPhpSpec()
+	mods, err := spec.WorkSpace(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"acme/app": dir, "acme/billing": filepath.Join(dir, "packages/billing")}
+	if !reflect.DeepEqual(mods, want) {
+		t.Fatalf("WorkSpace() = %v, want %v", mods, want)
+	}
+
+	for _, tt := range []struct {
+		path, mod, pkg string
+	}{
+		{"app/Http/UserController.php", "acme/app", `App\Http`},
+		{"packages/billing/src/Invoice.php", "acme/billing", `Acme\Billing`},
+		{"scripts/run.php", "acme/app", GlobalNamespace},
+		{"vendor/monolog/monolog/src/Logger.php", "monolog/monolog@3.5.0", "Monolog"},
+	} {
+		mod, pkg, err := spec.NameSpace(filepath.Join(dir, tt.path), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mod != tt.mod || pkg != tt.pkg {
+			t.Errorf("NameSpace(%s) = %s, %s, want %s, %s", tt.path, mod, pkg, tt.mod, tt.pkg)
+		}
+	}
+}
+
+func TestPhpSpec_FileImports(t *testing.T) {
+	content := `<?php
+namespace App;
+
+use Illuminate\Support\Str;
+use App\Models\{User, Post as Article};
+use function Foo\bar;
+
+class A {
+    use HasFactory;
+}
+`
+	got, err := NewPhpSpec().FileImports([]byte(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	alias := "Article"
+	want := []uniast.Import{
+		{Path: `Illuminate\Support\Str`},
+		{Path: `App\Models\User`},
+		{Path: `App\Models\Post`, Alias: &alias},
+		{Path: `Foo\bar`},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FileImports() = %v, want %v", got, want)
+	}
+}
+
+func TestAnnotations(t *testing.T) {
+	content := `/** doc */
+#[Route('/users', methods: ['GET'])]
+#[\Deprecated, Cache(ttl: 60)]
+public function index() {}`
+	want := []uniast.Annotation{
+		{Name: "Route", Args: `'/users', methods: ['GET']`},
+		{Name: "Deprecated"},
+		{Name: "Cache", Args: "ttl: 60"},
+	}
+	if got := Annotations(content); !reflect.DeepEqual(got, want) {
+		t.Errorf("Annotations() = %v, want %v", got, want)
+	}
+}
+
+func TestUsedTraits(t *testing.T) {
+	content := `final class User extends Model implements Auth
+{
+    use HasFactory, \Illuminate\Notifications\Notifiable;
+    use SoftDeletes {
+        restore as protected;
+    }
+
+    public function posts()
+    {
+        return array_map(function ($p) use ($x) { return $p; }, []);
+    }
+}`
+	want := []string{"HasFactory", "Notifiable", "SoftDeletes"}
+	if got := UsedTraits(content); !reflect.DeepEqual(got, want) {
+		t.Errorf("UsedTraits() = %v, want %v", got, want)
+	}
+}
+
+func TestPhpSpec_IsPublicSymbol(t *testing.T) {
+	spec := NewPhpSpec()
+	for text, want := range map[string]bool{
+		"function foo() {}":                      true,
+		"#[Pure] private static function a() {}": false,
+		"protected readonly string $name;":       false,
+		"final public function b(): int {}":      true,
+	} {
+		if got := spec.IsPublicSymbol(lsp.DocumentSymbol{Text: text}); got != want {
+			t.Errorf("IsPublicSymbol(%q) = %v, want %v", text, got, want)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package progress reports the progress of parsing as structured events,
+// so that wrappers and IDE plugins can render progress bars.
+package progress
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+type Phase string
+
+const (
+	PhaseScan   Phase = "scan"   // scan files and their symbols
+	PhaseSymbol Phase = "symbol" // collect signatures of symbols
+	PhaseDeps   Phase = "deps"   // collect dependencies of symbols
+	PhaseExport Phase = "export" // export symbols to UniAST
+	PhaseModule Phase = "module" // load and parse modules (Go and TypeScript only)
+	PhaseDone   Phase = "done"   // the whole parsing is finished
+)
+
+// Event is a progress snapshot of a phase
+type Event struct {
+	Phase Phase  `json:"phase"`
+	Done  int    `json:"done"`
+	Total int    `json:"total,omitempty"` // 0 means unknown
+	Item  string `json:"item,omitempty"`  // the last finished item, like file path or symbol name
+	// time elapsed since the phase started
+	ElapsedMs int64 `json:"elapsed_ms"`
+	// estimated time to finish the phase, 0 if unknown
+	EtaMs int64 `json:"eta_ms,omitempty"`
+}
+
+// Reporter receives the progress events. It is called serially by a Tracker.
+type Reporter func(Event)
+
+// JSONLines returns a Reporter which writes each event as a JSON line onto w
+func JSONLines(w io.Writer) Reporter {
+	var mu sync.Mutex
+	enc := json.NewEncoder(w)
+	return func(e Event) {
+		mu.Lock()
+		_ = enc.Encode(e)
+		mu.Unlock()
+	}
+}
+
+// minInterval throttles the events of a phase, except the first and the last ones
+const minInterval = 100 * time.Millisecond
+
+// Tracker counts the finished items of a phase, and reports them with ETA.
+// It is safe for concurrent use, and a nil Tracker does nothing.
+type Tracker struct {
+	report Reporter
+	phase  Phase
+	total  int
+	start  time.Time
+
+	mu       sync.Mutex
+	done     int
+	last     time.Time
+	finished bool // the last event has been reported
+}
+
+// NewTracker starts a phase of total items (0 if unknown) and reports it.
+// Returns nil if report is nil.
+func NewTracker(report Reporter, phase Phase, total int) *Tracker {
+	if report == nil {
+		return nil
+	}
+	now := time.Now()
+	t := &Tracker{
+		report: report,
+		phase:  phase,
+		total:  total,
+		start:  now,
+		last:   now,
+	}
+	report(Event{Phase: phase, Total: total})
+	return t
+}
+
+// Add marks one item as finished
+func (t *Tracker) Add(item string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.done++
+	now := time.Now()
+	if t.done != t.total && now.Sub(t.last) < minInterval {
+		return
+	}
+	t.last = now
+	t.finished = t.done == t.total
+	t.report(t.event(now, item))
+}
+
+// Finish marks the phase as finished and reports it,
+// unless the last event has already been reported by Add
+func (t *Tracker) Finish() {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.finished {
+		return
+	}
+	t.finished = true
+	// some items may be skipped, or the total is unknown
+	if t.done < t.total {
+		t.done = t.total
+	}
+	t.total = t.done
+	t.report(t.event(time.Now(), ""))
+}
+
+func (t *Tracker) event(now time.Time, item string) Event {
+	e := Event{
+		Phase:     t.phase,
+		Done:      t.done,
+		Total:     t.total,
+		Item:      item,
+		ElapsedMs: now.Sub(t.start).Milliseconds(),
+	}
+	if t.done > 0 && t.total > t.done {
+		e.EtaMs = e.ElapsedMs * int64(t.total-t.done) / int64(t.done)
+	}
+	return e
+}
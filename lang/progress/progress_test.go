@@ -0,0 +1,96 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package progress
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestTracker(t *testing.T) {
+	var events []Event
+	report := func(e Event) { events = append(events, e) }
+
+	tr := NewTracker(report, PhaseScan, 100)
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tr.Add("a.rs")
+		}()
+	}
+	wg.Wait()
+	tr.Finish()
+
+	// the start event, throttled events, and the last one
+	if len(events) < 2 || len(events) > 100 {
+		t.Fatalf("got %d events", len(events))
+	}
+	if e := events[0]; e.Phase != PhaseScan || e.Done != 0 || e.Total != 100 {
+		t.Errorf("first event = %+v", e)
+	}
+	last := events[len(events)-1]
+	if last.Done != 100 || last.Total != 100 || last.EtaMs != 0 {
+		t.Errorf("last event = %+v", last)
+	}
+	for i := 1; i < len(events); i++ {
+		if events[i].Done < events[i-1].Done {
+			t.Errorf("events out of order: %+v after %+v", events[i], events[i-1])
+		}
+	}
+}
+
+func TestTracker_Finish(t *testing.T) {
+	var events []Event
+	tr := NewTracker(func(e Event) { events = append(events, e) }, PhaseDeps, 3)
+	tr.Add("a") // throttled
+	tr.Finish()
+	tr.Finish()
+	if len(events) != 2 {
+		t.Fatalf("events = %+v, want start and finish", events)
+	}
+	if e := events[1]; e.Done != 3 || e.Total != 3 {
+		t.Errorf("finish event = %+v", e)
+	}
+
+	// nil tracker does nothing
+	var nt *Tracker = NewTracker(nil, PhaseDeps, 3)
+	nt.Add("a")
+	nt.Finish()
+}
+
+func TestJSONLines(t *testing.T) {
+	var buf bytes.Buffer
+	report := JSONLines(&buf)
+	report(Event{Phase: PhaseExport, Done: 1, Total: 4, Item: "main.rs", ElapsedMs: 10, EtaMs: 30})
+	report(Event{Phase: PhaseDone})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("lines = %q", lines)
+	}
+	want := `{"phase":"export","done":1,"total":4,"item":"main.rs","elapsed_ms":10,"eta_ms":30}`
+	if lines[0] != want {
+		t.Errorf("line = %s, want %s", lines[0], want)
+	}
+	var e Event
+	if err := json.Unmarshal([]byte(lines[1]), &e); err != nil || e.Phase != PhaseDone {
+		t.Errorf("line = %s, err = %v", lines[1], err)
+	}
+}
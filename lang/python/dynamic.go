@@ -0,0 +1,197 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package python
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/cloudwego/abcoder/lang/uniast"
+	"github.com/cloudwego/abcoder/lang/utils"
+)
+
+// DynamicImport is a module imported by `importlib.import_module` or `__import__` with a literal name,
+// which is invisible to the LSP
+type DynamicImport struct {
+	// Module is the absolute import path of the module, relative names are resolved
+	Module string
+	// Offset is the byte offset of the call in the text
+	Offset int
+	// Text is the call expression
+	Text string
+}
+
+// import_module("name"[, package]) or __import__("name", ...)
+var dynamicImportRegex = regexp.MustCompile(`\b(import_module|__import__)\(\s*['"]([\w.]+)['"]\s*(?:,\s*(?:package\s*=\s*)?([\w.]+|'[\w.]*'|"[\w.]*"))?[^)]*\)`)
+
+// DynamicImports finds the dynamic imports in the codes of the module pkgPath.
+// isPackage tells if the module is the `__init__.py` of a package, which is the anchor of relative names.
+// Relative names whose anchor is not a literal, `__name__`, `__package__` nor `__spec__.parent` are skipped
+func DynamicImports(text string, pkgPath string, isPackage bool) []DynamicImport {
+	var ret []DynamicImport
+	for _, m := range dynamicImportRegex.FindAllStringSubmatchIndex(text, -1) {
+		name := text[m[4]:m[5]]
+		if strings.HasPrefix(name, ".") {
+			if text[m[2]:m[3]] != "import_module" || m[6] < 0 {
+				continue
+			}
+			var anchor string
+			switch arg := text[m[6]:m[7]]; arg {
+			case "__name__":
+				anchor = pkgPath
+			case "__package__", "__spec__.parent":
+				anchor = pkgPath
+				if !isPackage {
+					anchor = parentModule(pkgPath)
+				}
+			default:
+				if arg[0] != '\'' && arg[0] != '"' {
+					continue
+				}
+				anchor = arg[1 : len(arg)-1]
+			}
+			if name = resolveRelative(name, anchor); name == "" {
+				continue
+			}
+		}
+		ret = append(ret, DynamicImport{
+			Module: name,
+			Offset: m[0],
+			Text:   text[m[0]:m[1]],
+		})
+	}
+	return ret
+}
+
+func parentModule(module string) string {
+	if i := strings.LastIndexByte(module, '.'); i >= 0 {
+		return module[:i]
+	}
+	return ""
+}
+
+// resolveRelative resolves the relative module name like `..a.b` against the package, returns empty if beyond the top
+func resolveRelative(name string, pkg string) string {
+	rest := strings.TrimLeft(name, ".")
+	for level := len(name) - len(rest); level > 1; level-- {
+		if pkg == "" {
+			return ""
+		}
+		pkg = parentModule(pkg)
+	}
+	if pkg == "" {
+		return rest
+	}
+	if rest == "" {
+		return pkg
+	}
+	return pkg + "." + rest
+}
+
+// ModuleName returns the module (distribution) which provides the import path.
+// Import paths under the source dirs of the project belong to it,
+// others are taken as external, named by the distributions as NameSpace does
+func (c *PythonSpec) ModuleName(importPath string) string {
+	rel := filepath.FromSlash(strings.ReplaceAll(importPath, ".", "/"))
+	for _, dir := range c.srcDirs {
+		for _, path := range []string{rel + ".py", filepath.Join(rel, "__init__.py"), rel} {
+			if _, err := os.Stat(filepath.Join(dir, path)); err == nil {
+				return c.topModuleName
+			}
+		}
+	}
+	return c.externalModule(importPath)
+}
+
+// DecoratorStart returns the first line (0-based) of the decorators above the line of a def or class,
+// or -1 if it is not decorated.
+// Decorators may span multiple lines within parentheses, and comments may be interleaved
+func DecoratorStart(lines []string, line int) int {
+	start := -1
+	for i := line - 1; i >= 0; i-- {
+		trimmed := strings.TrimSpace(lines[i])
+		if trimmed == "" {
+			break
+		}
+		if trimmed[0] != '@' {
+			continue
+		}
+		if !isDecoratorBlock(lines[i:line]) {
+			break
+		}
+		start = i
+	}
+	return start
+}
+
+// Decorators parses the decorators in the lines, like `@app.route("/")`, see DecoratorStart
+func Decorators(lines []string) []uniast.Annotation {
+	var ret []uniast.Annotation
+	text := strings.Join(lines, "\n")
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '#':
+			if end := strings.IndexByte(text[i:], '\n'); end >= 0 {
+				i += end
+			} else {
+				i = len(text)
+			}
+		case '@':
+			end := i + 1
+			for end < len(text) && (text[end] == '.' || text[end] == '_' || isAlnum(text[end])) {
+				end++
+			}
+			ann := uniast.Annotation{Name: text[i+1 : end]}
+			if end < len(text) && text[end] == '(' {
+				if close := utils.MatchBracket(text, end); close > 0 {
+					ann.Args = strings.TrimSpace(text[end+1 : close])
+					end = close
+				}
+			}
+			ret = append(ret, ann)
+			i = end
+		}
+	}
+	return ret
+}
+
+func isAlnum(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// isDecoratorBlock tells if the lines are a sequence of decorators
+func isDecoratorBlock(lines []string) bool {
+	depth := 0
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if depth == 0 && trimmed != "" && trimmed[0] != '@' && trimmed[0] != '#' {
+			return false
+		}
+		for _, r := range trimmed {
+			if r == '#' {
+				break
+			}
+			switch r {
+			case '(', '[', '{':
+				depth++
+			case ')', ']', '}':
+				depth--
+			}
+		}
+	}
+	return depth == 0
+}
@@ -0,0 +1,119 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package python
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/abcoder/lang/uniast"
+)
+
+func TestDynamicImports(t *testing.T) {
+	text := `import importlib
+
+def load(name):
+    a = importlib.import_module("pkg.plugins.a")
+    b = import_module('.b', __name__)
+    c = importlib.import_module("..c", package=__package__)
+    d = importlib.import_module("..d", "x.y")
+    e = __import__("json", globals(), locals(), [], 0)
+    f = importlib.import_module(name)
+    g = importlib.import_module(".g", package=name)
+    return a, b, c, d, e, f, g
+`
+	got := DynamicImports(text, "pkg.loader", false)
+	var modules []string
+	for _, imp := range got {
+		modules = append(modules, imp.Module)
+		if !strings.HasPrefix(text[imp.Offset:], imp.Text) {
+			t.Errorf("offset of %s = %d", imp.Text, imp.Offset)
+		}
+	}
+	want := []string{"pkg.plugins.a", "pkg.loader.b", "c", "x.d", "json"}
+	if !reflect.DeepEqual(modules, want) {
+		t.Errorf("DynamicImports() = %v, want %v", modules, want)
+	}
+
+	// __getattr__ lazy imports in __init__.py of a package
+	text = "def __getattr__(name):\n    return getattr(importlib.import_module('.lazy', __package__), name)\n"
+	got = DynamicImports(text, "pkg", true)
+	if len(got) != 1 || got[0].Module != "pkg.lazy" {
+		t.Errorf("DynamicImports() = %+v", got)
+	}
+}
+
+func TestDecoratorStart(t *testing.T) {
+	lines := strings.Split(`import functools
+
+@functools.lru_cache(
+    maxsize=None,  # (
+)
+# comment
+@staticmethod
+def f():
+    pass
+def g():
+    pass
+
+@decorator
+def h():
+    pass`, "\n")
+	for line, want := range map[int]int{7: 2, 9: -1, 13: 12, 0: -1} {
+		if got := DecoratorStart(lines, line); got != want {
+			t.Errorf("DecoratorStart(%d) = %d, want %d", line, got, want)
+		}
+	}
+}
+
+func TestPythonSpec_ModuleName(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"src/foo/__init__.py": "",
+		"src/foo/bar.py":      "",
+		"src/ns/sub/mod.py":   "",
+	})
+	c := &PythonSpec{}
+	if _, err := c.WorkSpace(dir); err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]string{
+		"foo":        "current",
+		"foo.bar":    "current",
+		"ns.sub":     "current",
+		"requests.a": "requests",
+	} {
+		if got := c.ModuleName(path); got != want {
+			t.Errorf("ModuleName(%s) = %s, want %s", path, got, want)
+		}
+	}
+}
+
+func TestDecorators(t *testing.T) {
+	lines := strings.Split(`@app.route("/users/<id>", methods=["GET"])
+# comment @fake
+@login_required
+@functools.lru_cache(
+    maxsize=None,
+)`, "\n")
+	want := []uniast.Annotation{
+		{Name: "app.route", Args: `"/users/<id>", methods=["GET"]`},
+		{Name: "login_required"},
+		{Name: "functools.lru_cache", Args: "maxsize=None,"},
+	}
+	if got := Decorators(lines); !reflect.DeepEqual(got, want) {
+		t.Errorf("Decorators() = %+v, want %+v", got, want)
+	}
+}
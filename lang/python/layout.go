@@ -0,0 +1,212 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package python
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// projectLayout tells how the files of a project map to import paths
+type projectLayout struct {
+	// Name is the distribution name declared by pyproject.toml or setup.cfg, empty if not declared
+	Name string
+	// SrcDirs are the dirs (relative to the project) which are put in sys.path when the project is installed,
+	// thus the import path of a file is its path relative to the dir. The longest ones come first.
+	// The project dir itself (`.`) is always the last one
+	SrcDirs []string
+}
+
+// pyproject is the part of pyproject.toml concerning the layout
+type pyproject struct {
+	Project struct {
+		Name string `toml:"name"`
+	} `toml:"project"`
+	Tool struct {
+		Setuptools struct {
+			PackageDir map[string]string `toml:"package-dir"`
+			Packages   struct {
+				Find struct {
+					Where []string `toml:"where"`
+				} `toml:"find"`
+			} `toml:"packages"`
+		} `toml:"setuptools"`
+		Poetry struct {
+			Name     string `toml:"name"`
+			Packages []struct {
+				Include string `toml:"include"`
+				From    string `toml:"from"`
+			} `toml:"packages"`
+		} `toml:"poetry"`
+		Hatch struct {
+			Build struct {
+				Targets struct {
+					Wheel struct {
+						Packages []string `toml:"packages"`
+					} `toml:"wheel"`
+				} `toml:"targets"`
+			} `toml:"build"`
+		} `toml:"hatch"`
+		PDM struct {
+			Build struct {
+				PackageDir string `toml:"package-dir"`
+			} `toml:"build"`
+		} `toml:"pdm"`
+	} `toml:"tool"`
+}
+
+// readProjectLayout reads the layout of the project from pyproject.toml and setup.cfg.
+// If none of them declares the source dirs, `src` is taken as the source dir by convention if it is not a package itself.
+// sysPaths under the project (e.g. PYTHONPATH or .pth files of editable installs) are source dirs as well
+func readProjectLayout(root string, sysPaths []string) projectLayout {
+	var ret projectLayout
+	dirs := map[string]bool{}
+	addDir := func(dir string) {
+		dir = filepath.Clean(filepath.FromSlash(strings.TrimSpace(dir)))
+		if dir == "" || filepath.IsAbs(dir) || strings.HasPrefix(dir, "..") {
+			return
+		}
+		dirs[dir] = true
+	}
+
+	if data, err := os.ReadFile(filepath.Join(root, "pyproject.toml")); err == nil {
+		var proj pyproject
+		// a malformed pyproject.toml is tolerated, the layout falls back to the conventions
+		_ = toml.Unmarshal(data, &proj)
+		ret.Name = proj.Project.Name
+		if ret.Name == "" {
+			ret.Name = proj.Tool.Poetry.Name
+		}
+		if dir, ok := proj.Tool.Setuptools.PackageDir[""]; ok {
+			addDir(dir)
+		}
+		for _, dir := range proj.Tool.Setuptools.Packages.Find.Where {
+			addDir(dir)
+		}
+		for _, pkg := range proj.Tool.Poetry.Packages {
+			if pkg.From != "" {
+				addDir(pkg.From)
+			}
+		}
+		for _, pkg := range proj.Tool.Hatch.Build.Targets.Wheel.Packages {
+			// packages = ["src/foo"] installs foo at the top level
+			addDir(filepath.Dir(filepath.FromSlash(pkg)))
+		}
+		if dir := proj.Tool.PDM.Build.PackageDir; dir != "" {
+			addDir(dir)
+		}
+	}
+
+	if data, err := os.ReadFile(filepath.Join(root, "setup.cfg")); err == nil {
+		cfg := parseSetupCfg(data)
+		if ret.Name == "" {
+			ret.Name = cfg["metadata"]["name"]
+		}
+		// package_dir =
+		//     = src
+		for _, line := range strings.Split(cfg["options"]["package_dir"], "\n") {
+			if k, v, ok := strings.Cut(line, "="); ok && strings.TrimSpace(k) == "" {
+				addDir(v)
+			}
+		}
+		if where := cfg["options.packages.find"]["where"]; where != "" {
+			addDir(where)
+		}
+	}
+
+	if len(dirs) == 0 {
+		if info, err := os.Stat(filepath.Join(root, "src")); err == nil && info.IsDir() {
+			if _, err := os.Stat(filepath.Join(root, "src", "__init__.py")); err != nil {
+				dirs["src"] = true
+			}
+		}
+	}
+
+	for _, sp := range sysPaths {
+		if !filepath.IsAbs(sp) {
+			continue
+		}
+		if rel, err := filepath.Rel(root, sp); err == nil && rel != "." {
+			addDir(rel)
+		}
+	}
+
+	delete(dirs, ".")
+	for dir := range dirs {
+		ret.SrcDirs = append(ret.SrcDirs, dir)
+	}
+	sort.Slice(ret.SrcDirs, func(i, j int) bool {
+		if len(ret.SrcDirs[i]) != len(ret.SrcDirs[j]) {
+			return len(ret.SrcDirs[i]) > len(ret.SrcDirs[j])
+		}
+		return ret.SrcDirs[i] < ret.SrcDirs[j]
+	})
+	ret.SrcDirs = append(ret.SrcDirs, ".")
+	return ret
+}
+
+// parseSetupCfg parses the ini file as section => key => value.
+// Indented lines continue the value of the previous key
+func parseSetupCfg(data []byte) map[string]map[string]string {
+	ret := map[string]map[string]string{}
+	section, key := "", ""
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || trimmed[0] == '#' || trimmed[0] == ';' {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			section, key = strings.TrimSpace(trimmed[1:len(trimmed)-1]), ""
+			continue
+		}
+		if ret[section] == nil {
+			ret[section] = map[string]string{}
+		}
+		if line[0] == ' ' || line[0] == '\t' {
+			if key != "" {
+				ret[section][key] = strings.TrimLeft(ret[section][key]+"\n"+trimmed, "\n")
+			}
+			continue
+		}
+		k, v, ok := strings.Cut(trimmed, "=")
+		if !ok {
+			k, v, ok = strings.Cut(trimmed, ":")
+		}
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(k)
+		ret[section][key] = strings.TrimSpace(v)
+	}
+	return ret
+}
+
+// importPath returns the import path of the file relative to the source dir,
+// `__init__.py` of a package is imported as the package
+func importPath(rel string) string {
+	rel = strings.TrimSuffix(rel, ".py")
+	if dir, base := filepath.Split(rel); base == "__init__" && dir != "" {
+		rel = filepath.Clean(dir)
+	}
+	return strings.ReplaceAll(rel, string(os.PathSeparator), ".")
+}
@@ -0,0 +1,138 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package python
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func Test_readProjectLayout(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		sysPaths []string
+		want     projectLayout
+	}{
+		{
+			name:  "flat",
+			files: map[string]string{"pkg/__init__.py": ""},
+			want:  projectLayout{SrcDirs: []string{"."}},
+		},
+		{
+			name:  "src by convention",
+			files: map[string]string{"src/pkg/__init__.py": ""},
+			want:  projectLayout{SrcDirs: []string{"src", "."}},
+		},
+		{
+			name:  "src as a package",
+			files: map[string]string{"src/__init__.py": ""},
+			want:  projectLayout{SrcDirs: []string{"."}},
+		},
+		{
+			name: "setuptools",
+			files: map[string]string{"pyproject.toml": "[project]\nname = \"foo-bar\"\n\n" +
+				"[tool.setuptools.packages.find]\nwhere = [\"lib\"]\n"},
+			want: projectLayout{Name: "foo-bar", SrcDirs: []string{"lib", "."}},
+		},
+		{
+			name: "poetry",
+			files: map[string]string{"pyproject.toml": "[tool.poetry]\nname = \"foo\"\n" +
+				"packages = [{ include = \"foo\", from = \"source\" }]\n"},
+			want: projectLayout{Name: "foo", SrcDirs: []string{"source", "."}},
+		},
+		{
+			name:  "hatch",
+			files: map[string]string{"pyproject.toml": "[tool.hatch.build.targets.wheel]\npackages = [\"python/foo\"]\n"},
+			want:  projectLayout{SrcDirs: []string{"python", "."}},
+		},
+		{
+			name: "setup.cfg",
+			files: map[string]string{"setup.cfg": "[metadata]\nname = foo\n\n[options]\npackage_dir =\n    = lib\n" +
+				"packages = find_namespace:\n"},
+			want: projectLayout{Name: "foo", SrcDirs: []string{"lib", "."}},
+		},
+		{
+			name:     "sys.path",
+			files:    map[string]string{"pkg/__init__.py": ""},
+			sysPaths: []string{"vendor/libs", "/not/in/project"},
+			want:     projectLayout{SrcDirs: []string{"vendor/libs", "."}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeFiles(t, tt.files)
+			var sysPaths []string
+			for _, sp := range tt.sysPaths {
+				if !filepath.IsAbs(sp) {
+					sp = filepath.Join(dir, sp)
+				}
+				sysPaths = append(sysPaths, sp)
+			}
+			if got := readProjectLayout(dir, sysPaths); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readProjectLayout() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPythonSpec_NameSpace(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"pyproject.toml":          "[project]\nname = \"foo\"\n",
+		"src/foo/__init__.py":     "",
+		"src/foo/bar.py":          "",
+		"src/ns/sub/mod.py":       "",
+		"tests/test_bar.py":       "",
+		"__init__.py":             "",
+		"src/foo/sub/__init__.py": "",
+	})
+	c := &PythonSpec{}
+	mods, err := c.WorkSpace(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mods["foo"] == "" {
+		t.Fatalf("WorkSpace() = %v", mods)
+	}
+	for file, want := range map[string]string{
+		"src/foo/__init__.py":     "foo",
+		"src/foo/bar.py":          "foo.bar",
+		"src/foo/sub/__init__.py": "foo.sub",
+		"src/ns/sub/mod.py":       "ns.sub.mod",
+		"tests/test_bar.py":       "tests.test_bar",
+		"__init__.py":             "__init__",
+	} {
+		mod, pkg, err := c.NameSpace(filepath.Join(c.topModulePath, file), nil)
+		if err != nil || mod != "foo" || pkg != want {
+			t.Errorf("NameSpace(%s) = %s, %s, %v, want foo, %s", file, mod, pkg, err, want)
+		}
+	}
+}
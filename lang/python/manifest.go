@@ -0,0 +1,71 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package python
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/cloudwego/abcoder/lang/uniast"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// pyprojectBuild is the part of pyproject.toml concerning the build configuration
+type pyprojectBuild struct {
+	Project struct {
+		RequiresPython       string              `toml:"requires-python"`
+		OptionalDependencies map[string][]string `toml:"optional-dependencies"`
+	} `toml:"project"`
+	Tool struct {
+		Poetry struct {
+			// Dependencies maps the names to version constraints or tables, `python` is the required python version
+			Dependencies map[string]interface{} `toml:"dependencies"`
+			Extras       map[string][]string    `toml:"extras"`
+		} `toml:"poetry"`
+	} `toml:"tool"`
+}
+
+// ReadBuildConfig reads the build configuration of the project from the pyproject.toml under dir.
+// dir is relative to root. Returns nil if the manifest is not found or can't be parsed
+func ReadBuildConfig(root string, dir string) *uniast.BuildConfig {
+	manifest := filepath.Join(dir, "pyproject.toml")
+	data, err := os.ReadFile(filepath.Join(root, manifest))
+	if err != nil {
+		return nil
+	}
+	var p pyprojectBuild
+	if err := toml.Unmarshal(data, &p); err != nil {
+		return nil
+	}
+	ret := &uniast.BuildConfig{Manifest: manifest, LanguageVersion: p.Project.RequiresPython}
+	if ret.LanguageVersion == "" {
+		ret.LanguageVersion, _ = p.Tool.Poetry.Dependencies["python"].(string)
+	}
+	for name, reqs := range p.Project.OptionalDependencies {
+		if ret.Extras == nil {
+			ret.Extras = map[string][]string{}
+		}
+		ret.Extras[name] = reqs
+	}
+	for name, pkgs := range p.Tool.Poetry.Extras {
+		if ret.Extras == nil {
+			ret.Extras = map[string][]string{}
+		}
+		if _, ok := ret.Extras[name]; !ok {
+			ret.Extras[name] = pkgs
+		}
+	}
+	return ret
+}
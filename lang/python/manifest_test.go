@@ -0,0 +1,64 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package python
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/cloudwego/abcoder/lang/uniast"
+)
+
+func TestReadBuildConfig(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  *uniast.BuildConfig
+	}{
+		{
+			name: "pep 621",
+			files: map[string]string{"pyproject.toml": "[project]\nname = \"foo\"\nrequires-python = \">=3.9\"\n\n" +
+				"[project.optional-dependencies]\nhttp = [\"requests>=2\"]\n"},
+			want: &uniast.BuildConfig{
+				Manifest:        "pyproject.toml",
+				LanguageVersion: ">=3.9",
+				Extras:          map[string][]string{"http": {"requests>=2"}},
+			},
+		},
+		{
+			name: "poetry",
+			files: map[string]string{"pyproject.toml": "[tool.poetry]\nname = \"foo\"\n\n" +
+				"[tool.poetry.dependencies]\npython = \"^3.10\"\nrequests = { version = \"^2\", optional = true }\n\n" +
+				"[tool.poetry.extras]\nhttp = [\"requests\"]\n"},
+			want: &uniast.BuildConfig{
+				Manifest:        "pyproject.toml",
+				LanguageVersion: "^3.10",
+				Extras:          map[string][]string{"http": {"requests"}},
+			},
+		},
+		{
+			name:  "no pyproject",
+			files: map[string]string{"setup.py": ""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := writeFiles(t, tt.files)
+			if got := ReadBuildConfig(root, "."); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReadBuildConfig() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
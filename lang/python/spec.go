@@ -17,10 +17,8 @@ package python
 import (
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"regexp"
-	"sort"
 	"strings"
 
 	"github.com/cloudwego/abcoder/lang/log"
@@ -32,7 +30,13 @@ type PythonSpec struct {
 	repo          string
 	topModuleName string
 	topModulePath string
-	sysPaths      []string
+	// absolute source dirs of the project, see projectLayout.SrcDirs
+	srcDirs  []string
+	sysPaths []string
+	// the interpreter whose sys.path is used, see Interpreter
+	python string
+	// top-level modules => third-party distributions, read lazily from sysPaths
+	dists map[string]distribution
 }
 
 func (c *PythonSpec) ProtectedSymbolKinds() []lsp.SymbolKind {
@@ -40,19 +44,46 @@ func (c *PythonSpec) ProtectedSymbolKinds() []lsp.SymbolKind {
 }
 
 func NewPythonSpec() *PythonSpec {
-	cmd := exec.Command("python", "-c", "import sys ; print('\\n'.join(sys.path))")
-	output, err := cmd.Output()
+	sysPaths, err := sysPathsOf(DefaultInterpreter)
 	if err != nil {
 		log.Error("Failed to get sys.path: %v\n", err)
 		return nil
 	}
-	sysPaths := strings.Split(string(output), "\n")
-	// Match more specific paths first
-	sort.Slice(sysPaths, func(i, j int) bool {
-		return len(sysPaths[i]) > len(sysPaths[j])
-	})
 	log.Info("PythonSpec: using sysPaths %+v\n", sysPaths)
-	return &PythonSpec{sysPaths: sysPaths}
+	return &PythonSpec{sysPaths: sysPaths, python: DefaultInterpreter}
+}
+
+// Interpreter returns the python interpreter whose sys.path is used
+func (c *PythonSpec) Interpreter() string {
+	return c.python
+}
+
+// SetInterpreter uses the sys.path of the interpreter, e.g. the one of a virtualenv,
+// thus the symbols of the third-party packages installed in it are resolved
+func (c *PythonSpec) SetInterpreter(python string) error {
+	if python == c.python {
+		return nil
+	}
+	sysPaths, err := sysPathsOf(python)
+	if err != nil {
+		return fmt.Errorf("get sys.path of %s failed: %v", python, err)
+	}
+	log.Info("PythonSpec: using interpreter %s, sysPaths %+v\n", python, sysPaths)
+	c.python, c.sysPaths, c.dists = python, sysPaths, nil
+	return nil
+}
+
+// externalModule returns the module of the external import path,
+// which is the distribution providing its top-level module like `requests@2.31.0`, or the top-level module if unknown
+func (c *PythonSpec) externalModule(importPath string) string {
+	if c.dists == nil {
+		c.dists = readDistributions(c.sysPaths)
+	}
+	top, _, _ := strings.Cut(importPath, ".")
+	if dist, ok := c.dists[top]; ok {
+		return dist.Module()
+	}
+	return top
 }
 
 func (c *PythonSpec) WorkSpace(root string) (map[string]string, error) {
@@ -82,25 +113,41 @@ func (c *PythonSpec) WorkSpace(root string) (map[string]string, error) {
 	}
 
 	c.topModulePath = absPath
-	// TODO: find a way to infer the module (project) name.
-	c.topModuleName = "current"
+	layout := readProjectLayout(absPath, c.sysPaths)
+	c.topModuleName = layout.Name
+	if c.topModuleName == "" {
+		c.topModuleName = "current"
+	}
+	c.srcDirs = c.srcDirs[:0]
+	for _, dir := range layout.SrcDirs {
+		c.srcDirs = append(c.srcDirs, filepath.Join(absPath, dir))
+	}
+	log.Info("PythonSpec: module %s, source dirs %v\n", c.topModuleName, layout.SrcDirs)
 	rets[c.topModuleName] = c.topModulePath
 	return rets, nil
 }
 
+// hasPathPrefix tells if path is dir or under dir
+func hasPathPrefix(path, dir string) bool {
+	return path == dir || strings.HasPrefix(path, dir+string(os.PathSeparator))
+}
+
 // returns: modName, pkgPath, error
 func (c *PythonSpec) NameSpace(path string, file *uniast.File) (string, string, error) {
 	if strings.HasPrefix(path, c.topModulePath) {
-		// internal module
-		modName := c.topModuleName
-		relPath, err := filepath.Rel(c.topModulePath, path)
+		// internal module, the pkgPath is the import path from the innermost source dir
+		srcDir := c.topModulePath
+		for _, dir := range c.srcDirs {
+			if hasPathPrefix(path, dir) {
+				srcDir = dir
+				break
+			}
+		}
+		relPath, err := filepath.Rel(srcDir, path)
 		if err != nil {
 			return "", "", err
 		}
-		// todo: handle __init__.py
-		relPath = strings.TrimSuffix(relPath, ".py")
-		pkgPath := strings.ReplaceAll(relPath, string(os.PathSeparator), ".")
-		return modName, pkgPath, nil
+		return c.topModuleName, importPath(relPath), nil
 	}
 
 	for _, sysPath := range c.sysPaths {
@@ -109,14 +156,8 @@ func (c *PythonSpec) NameSpace(path string, file *uniast.File) (string, string,
 			if err != nil {
 				return "", "", err
 			}
-			relPath = strings.TrimSuffix(relPath, ".py")
-			pkgPath := strings.ReplaceAll(relPath, string(os.PathSeparator), ".")
-			modPath := strings.Split(pkgPath, ".")
-			if len(modPath) >= 1 {
-				modName := modPath[0]
-				return modName, pkgPath, nil
-			}
-			panic(fmt.Sprintf("Malformed Namespace %s, pkgPath %s", path, pkgPath))
+			pkgPath := importPath(relPath)
+			return c.externalModule(pkgPath), pkgPath, nil
 		}
 	}
 	log.Error("Namespace not found for path: %s\n", path)
@@ -222,6 +263,18 @@ func (c *PythonSpec) IsPublicSymbol(sym lsp.DocumentSymbol) bool {
 	return true
 }
 
+// Visibility tells the visibility by the naming convention:
+// `_name` is internal and `__name` (mangled in classes) is private, while dunder names like `__init__` are public
+func (c *PythonSpec) Visibility(sym lsp.DocumentSymbol) uniast.Visibility {
+	if c.IsPublicSymbol(sym) {
+		return uniast.VisibilityPublic
+	}
+	if strings.HasPrefix(sym.Name, "__") {
+		return uniast.VisibilityPrivate
+	}
+	return uniast.VisibilityInternal
+}
+
 func (c *PythonSpec) HasImplSymbol() bool {
 	return true
 }
@@ -402,6 +455,8 @@ func (c *PythonSpec) FileImports(content []byte) ([]uniast.Import, error) {
 		`(?m)^from\s+(.*?)\s+import\s+([^()\n]*)$`,
 		// Matches: from <anything> import ( <anything> ) where <anything> can span multiple lines
 		`(?m)^from\s+(.*?)\s+import\s+\(([\s\S]*?)\)$`,
+		// Matches: importlib.import_module("<literal>", ...) or __import__("<literal>", ...) anywhere
+		dynamicImportRegex.String(),
 	}
 
 	res := []uniast.Import{}
@@ -0,0 +1,197 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package python
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/cloudwego/abcoder/lang/log"
+)
+
+// DefaultInterpreter is the python interpreter used if no virtualenv is found
+const DefaultInterpreter = "python"
+
+// Interpreter returns the python interpreter of the env, which is either an interpreter or a virtualenv dir.
+// If env is empty, the activated virtualenv ($VIRTUAL_ENV) or the `.venv` / `venv` of the repo is used,
+// otherwise DefaultInterpreter
+func Interpreter(env string, repo string) string {
+	if env == "" {
+		env = os.Getenv("VIRTUAL_ENV")
+	}
+	if env == "" && repo != "" {
+		for _, dir := range []string{".venv", "venv"} {
+			if _, err := os.Stat(filepath.Join(repo, dir, "pyvenv.cfg")); err == nil {
+				env = filepath.Join(repo, dir)
+				break
+			}
+		}
+	}
+	if env == "" {
+		return DefaultInterpreter
+	}
+	if info, err := os.Stat(env); err == nil && info.IsDir() {
+		if runtime.GOOS == "windows" {
+			return filepath.Join(env, "Scripts", "python.exe")
+		}
+		return filepath.Join(env, "bin", "python")
+	}
+	return env
+}
+
+// sysPathsOf returns the sys.path of the interpreter, the more specific paths come first
+func sysPathsOf(python string) ([]string, error) {
+	output, err := exec.Command(python, "-c", "import sys ; print('\\n'.join(sys.path))").Output()
+	if err != nil {
+		return nil, err
+	}
+	sysPaths := strings.Split(string(output), "\n")
+	// Match more specific paths first
+	sort.Slice(sysPaths, func(i, j int) bool {
+		return len(sysPaths[i]) > len(sysPaths[j])
+	})
+	return sysPaths, nil
+}
+
+// distribution is an installed third-party package
+type distribution struct {
+	Name    string
+	Version string
+}
+
+// Module returns the module name of the distribution, like `requests@2.31.0`
+func (d distribution) Module() string {
+	if d.Version == "" {
+		return d.Name
+	}
+	return d.Name + "@" + d.Version
+}
+
+// readDistributions reads the `*.dist-info` and `*.egg-info` dirs under the site dirs,
+// and returns the top-level modules => the distributions which provide them.
+// The top-level modules are listed by top_level.txt, or guessed from RECORD if absent
+func readDistributions(dirs []string) map[string]distribution {
+	ret := map[string]distribution{}
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			var meta string
+			switch {
+			case strings.HasSuffix(e.Name(), ".dist-info"):
+				meta = "METADATA"
+			case strings.HasSuffix(e.Name(), ".egg-info"):
+				meta = "PKG-INFO"
+			default:
+				continue
+			}
+			info := filepath.Join(dir, e.Name())
+			data, err := os.ReadFile(filepath.Join(info, meta))
+			if err != nil {
+				continue
+			}
+			dist := parseMetadata(data)
+			if dist.Name == "" {
+				continue
+			}
+			for _, top := range topLevelModules(info) {
+				// the first site dir wins, as python imports
+				if _, ok := ret[top]; !ok {
+					ret[top] = dist
+				}
+			}
+		}
+	}
+	return ret
+}
+
+// parseMetadata reads the name and version from the headers of METADATA or PKG-INFO
+func parseMetadata(data []byte) distribution {
+	var ret distribution
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			// the description follows the headers
+			break
+		}
+		k, v, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		switch strings.TrimSpace(k) {
+		case "Name":
+			ret.Name = strings.TrimSpace(v)
+		case "Version":
+			ret.Version = strings.TrimSpace(v)
+		}
+	}
+	return ret
+}
+
+// topLevelModules returns the top-level modules installed by the distribution of the info dir
+func topLevelModules(info string) []string {
+	var ret []string
+	if data, err := os.ReadFile(filepath.Join(info, "top_level.txt")); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				ret = append(ret, strings.ReplaceAll(line, "/", "."))
+			}
+		}
+		return ret
+	}
+	f, err := os.Open(filepath.Join(info, "RECORD"))
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		log.Error("read RECORD of %s failed: %v\n", info, err)
+	}
+	seen := map[string]bool{}
+	for _, rec := range records {
+		if len(rec) == 0 {
+			continue
+		}
+		top, _, _ := strings.Cut(filepath.ToSlash(rec[0]), "/")
+		if strings.HasSuffix(top, ".py") {
+			top = strings.TrimSuffix(top, ".py")
+		} else if !strings.Contains(rec[0], "/") {
+			// data files like `xx.pth`
+			continue
+		}
+		if top == "" || top == ".." || top == "__pycache__" || strings.ContainsAny(top, ".-") {
+			continue
+		}
+		if !seen[top] {
+			seen[top] = true
+			ret = append(ret, top)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,82 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package python
+
+import (
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func Test_readDistributions(t *testing.T) {
+	site := writeFiles(t, map[string]string{
+		"requests-2.31.0.dist-info/METADATA":      "Metadata-Version: 2.1\nName: requests\nVersion: 2.31.0\n\nName: not a header\n",
+		"requests-2.31.0.dist-info/top_level.txt": "requests\n",
+		"PyYAML-6.0.1.dist-info/METADATA":         "Name: PyYAML\nVersion: 6.0.1\n",
+		"PyYAML-6.0.1.dist-info/top_level.txt":    "_yaml\nyaml\n",
+		// no top_level.txt, guessed from RECORD
+		"attrs-23.1.0.dist-info/METADATA": "Name: attrs\nVersion: 23.1.0\n",
+		"attrs-23.1.0.dist-info/RECORD": "attr/__init__.py,sha256=x,100\nattrs/__init__.py,sha256=x,100\n" +
+			"attrs-23.1.0.dist-info/METADATA,,\n../../bin/attrs,,\nattrs.pth,,\n",
+		"six-1.16.0.egg-info/PKG-INFO":      "Name: six\nVersion: 1.16.0\n",
+		"six-1.16.0.egg-info/top_level.txt": "six\n",
+		"broken.dist-info/top_level.txt":    "broken\n",
+	})
+	got := readDistributions([]string{"", site})
+	want := map[string]distribution{
+		"requests": {"requests", "2.31.0"},
+		"_yaml":    {"PyYAML", "6.0.1"},
+		"yaml":     {"PyYAML", "6.0.1"},
+		"attr":     {"attrs", "23.1.0"},
+		"attrs":    {"attrs", "23.1.0"},
+		"six":      {"six", "1.16.0"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("readDistributions() = %v, want %v", got, want)
+	}
+
+	spec := &PythonSpec{sysPaths: []string{site}}
+	if got := spec.externalModule("yaml.loader"); got != "PyYAML@6.0.1" {
+		t.Errorf("externalModule(yaml.loader) = %s", got)
+	}
+	if got := spec.externalModule("unknown.x"); got != "unknown" {
+		t.Errorf("externalModule(unknown.x) = %s", got)
+	}
+}
+
+func TestInterpreter(t *testing.T) {
+	t.Setenv("VIRTUAL_ENV", "")
+	repo := writeFiles(t, map[string]string{".venv/pyvenv.cfg": "home = /usr/bin\n"})
+	bin := func(env string) string {
+		if runtime.GOOS == "windows" {
+			return filepath.Join(env, "Scripts", "python.exe")
+		}
+		return filepath.Join(env, "bin", "python")
+	}
+	if got := Interpreter("", repo); got != bin(filepath.Join(repo, ".venv")) {
+		t.Errorf("Interpreter() of the repo = %s", got)
+	}
+	if got := Interpreter("/usr/bin/python3", repo); got != "/usr/bin/python3" {
+		t.Errorf("Interpreter() of the interpreter = %s", got)
+	}
+	if got := Interpreter("", t.TempDir()); got != DefaultInterpreter {
+		t.Errorf("Interpreter() of no env = %s", got)
+	}
+	t.Setenv("VIRTUAL_ENV", repo)
+	if got := Interpreter("", t.TempDir()); got != bin(repo) {
+		t.Errorf("Interpreter() of the activated env = %s", got)
+	}
+}
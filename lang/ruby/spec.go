@@ -0,0 +1,415 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ruby
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	lsp "github.com/cloudwego/abcoder/lang/lsp"
+	"github.com/cloudwego/abcoder/lang/uniast"
+)
+
+// semantic-token type names.
+const (
+	tokClass     = "class"
+	tokNamespace = "namespace"
+	tokMethod    = "method"
+	tokFunction  = "function"
+	tokVariable  = "variable"
+	tokProperty  = "property"
+	tokComment   = "comment"
+)
+
+// semantic-token modifier names.
+const (
+	modDeclaration    = "declaration"
+	modDefinition     = "definition"
+	modReadonly       = "readonly"
+	modDefaultLibrary = "defaultLibrary"
+)
+
+// StdModule is the module of the standard library
+const StdModule = "ruby"
+
+var (
+	gemspecNameRegex = regexp.MustCompile(`\.name\s*=\s*["']([^"']+)["']`)
+	gemPathRegex     = regexp.MustCompile(`/gems/([^/]+?)-(\d[^/-]*)/(.+)$`)
+	stdPathRegex     = regexp.MustCompile(`/lib/ruby/\d+\.\d+\.\d+/(.+)$`)
+	requireRegex     = regexp.MustCompile(`(?m)^\s*(require|require_relative)\s*\(?\s*['"]([^'"]+)['"]`)
+	visibilityRegex  = regexp.MustCompile(`^\s*(private|protected|public)\s*(?:#.*)?$`)
+	visibleNameRegex = regexp.MustCompile(`^\s*(private|protected)\s+((?::\w+[?!=]?\s*,?\s*)+)$`)
+	defRegex         = regexp.MustCompile(`^\s*(?:(private|protected)\s+)?def\s+(self\.)?`)
+)
+
+type RubySpec struct {
+	repo string
+	// module dir => module name, by the gemspecs of the repo
+	mods map[string]string
+
+	mu sync.Mutex
+	// hidden are the private and protected methods, which are only known from the whole class body
+	hidden map[lsp.Location]bool
+}
+
+func NewRubySpec() *RubySpec {
+	return &RubySpec{
+		mods:   map[string]string{},
+		hidden: map[lsp.Location]bool{},
+	}
+}
+
+func (c *RubySpec) ProtectedSymbolKinds() []lsp.SymbolKind {
+	// methods and constants are nested inside the ranges of classes and modules
+	return []lsp.SymbolKind{lsp.SKFunction, lsp.SKMethod, lsp.SKVariable, lsp.SKConstant}
+}
+
+// WorkSpace takes each gemspec outside vendor/ as a module named by its `spec.name`, e.g. the engines of a Rails app.
+// The root is named by its gemspec, or the directory for an app bundled by a Gemfile
+func (c *RubySpec) WorkSpace(root string) (map[string]string, error) {
+	absPath, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path: %w", err)
+	}
+	c.repo = absPath
+	rets := map[string]string{}
+	rootNamed := false
+	err = filepath.WalkDir(absPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != absPath && (d.Name() == "vendor" || d.Name() == "node_modules" || d.Name() == "tmp" || strings.HasPrefix(d.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(d.Name(), ".gemspec") {
+			return nil
+		}
+		dir := filepath.Dir(path)
+		name := strings.TrimSuffix(d.Name(), ".gemspec")
+		if data, err := os.ReadFile(path); err == nil {
+			if m := gemspecNameRegex.FindSubmatch(data); m != nil {
+				name = string(m[1])
+			}
+		}
+		rets[name] = dir
+		if dir == absPath {
+			rootNamed = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !rootNamed {
+		rets[filepath.Base(absPath)] = absPath
+	}
+	for mod, dir := range rets {
+		c.mods[dir] = mod
+	}
+	return rets, nil
+}
+
+// NameSpace returns the module of the longest module dir containing the file, and its require path as the package,
+// like `acme/billing/invoice` for lib/acme/billing/invoice.rb or `app/models/user` for the autoloaded files of Rails.
+// Installed gems are named like `rails@7.1.3`, and the standard library is StdModule
+func (c *RubySpec) NameSpace(path string, file *uniast.File) (string, string, error) {
+	p := filepath.ToSlash(path)
+	mod, dir := "", ""
+	for d, m := range c.mods {
+		if hasPathPrefix(path, d) && len(d) > len(dir) {
+			mod, dir = m, d
+		}
+	}
+	if mod != "" && !strings.Contains(p[len(dir):], "/vendor/") {
+		rel, _ := filepath.Rel(dir, path)
+		return mod, RequirePath(filepath.ToSlash(rel)), nil
+	}
+	if m := gemPathRegex.FindStringSubmatch(p); m != nil {
+		return m[1] + "@" + m[2], RequirePath(m[3]), nil
+	}
+	if m := stdPathRegex.FindStringSubmatch(p); m != nil {
+		return StdModule, RequirePath(m[1]), nil
+	}
+	return "external", RequirePath(filepath.Base(p)), nil
+}
+
+// RequirePath converts a path relative to the root of a gem to its require path, which drops `lib/` and `.rb`
+func RequirePath(rel string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(rel, "lib/"), ".rb")
+}
+
+func hasPathPrefix(p, root string) bool {
+	if !strings.HasPrefix(p, root) {
+		return false
+	}
+	return len(p) == len(root) || p[len(root)] == filepath.Separator
+}
+
+func (c *RubySpec) ShouldSkip(path string) bool {
+	if !strings.HasSuffix(path, ".rb") && !strings.HasSuffix(path, ".rake") {
+		return true
+	}
+	p := filepath.ToSlash(path)
+	for _, dir := range []string{"/vendor/", "/node_modules/", "/tmp/", "/.bundle/"} {
+		if strings.Contains(p, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// FileImports collects the required paths. The paths of require_relative are kept relative to the file, like `./invoice`
+func (c *RubySpec) FileImports(content []byte) ([]uniast.Import, error) {
+	res := []uniast.Import{}
+	for _, m := range requireRegex.FindAllStringSubmatch(string(content), -1) {
+		path := m[2]
+		if m[1] == "require_relative" && !strings.HasPrefix(path, ".") {
+			path = "./" + path
+		}
+		res = append(res, uniast.Import{Path: path})
+	}
+	return res, nil
+}
+
+func (c *RubySpec) IsDocToken(tok lsp.Token) bool {
+	return tok.Type == tokComment
+}
+
+func (c *RubySpec) DeclareTokenOfSymbol(sym lsp.DocumentSymbol) int {
+	for i, t := range sym.Tokens {
+		if c.IsDocToken(t) {
+			continue
+		}
+		for _, m := range t.Modifiers {
+			if m == modDeclaration || m == modDefinition {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func (c *RubySpec) IsEntityToken(tok lsp.Token) bool {
+	for _, m := range tok.Modifiers {
+		if m == modDeclaration || m == modDefinition {
+			return false
+		}
+	}
+	switch tok.Type {
+	case tokClass, tokNamespace, tokMethod, tokFunction:
+		return true
+	}
+	return false
+}
+
+func (c *RubySpec) IsStdToken(tok lsp.Token) bool {
+	for _, m := range tok.Modifiers {
+		if m == modDefaultLibrary {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *RubySpec) TokenKind(tok lsp.Token) lsp.SymbolKind {
+	switch tok.Type {
+	case tokClass, tokNamespace:
+		// modules are mixed into classes
+		return lsp.SKClass
+	case tokMethod:
+		return lsp.SKMethod
+	case tokFunction:
+		return lsp.SKFunction
+	case tokVariable, tokProperty:
+		for _, m := range tok.Modifiers {
+			if m == modReadonly {
+				return lsp.SKConstant
+			}
+		}
+		return lsp.SKVariable
+	}
+	return lsp.SKUnknown
+}
+
+// IsMainFunction always returns false, since ruby scripts run from the top
+func (c *RubySpec) IsMainFunction(sym lsp.DocumentSymbol) bool {
+	return false
+}
+
+func (c *RubySpec) IsEntitySymbol(sym lsp.DocumentSymbol) bool {
+	typ := sym.Kind
+	return typ == lsp.SKMethod || typ == lsp.SKFunction || typ == lsp.SKClass || typ == lsp.SKConstant
+}
+
+// IsPublicSymbol tells if the method is neither private nor protected,
+// by `private def foo` or the visibility sections marked by AdjustSymbols
+func (c *RubySpec) IsPublicSymbol(sym lsp.DocumentSymbol) bool {
+	if m := defRegex.FindStringSubmatch(sym.Text); m != nil && m[1] != "" {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return !c.hidden[sym.Location]
+}
+
+func (c *RubySpec) HasImplSymbol() bool {
+	// mixins are in the class bodies, not impl blocks
+	return false
+}
+
+func (c *RubySpec) ImplSymbol(sym lsp.DocumentSymbol) (int, int, int) {
+	return -1, -1, -1
+}
+
+// FunctionSymbol returns nothing, since ruby signatures have no types.
+// The receiver is the enclosing class or module
+func (c *RubySpec) FunctionSymbol(sym lsp.DocumentSymbol) (int, []int, []int, []int) {
+	return -1, nil, nil, nil
+}
+
+func (c *RubySpec) GetUnloadedSymbol(from lsp.Token, define lsp.Location) (string, error) {
+	return "", nil
+}
+
+// AdjustSymbols makes the flat symbols of solargraph fit the UniAST:
+//   - constructors are methods;
+//   - modules defining methods are mixins, thus taken as classes, while the others only
+//     namespace the classes inside, and are dropped to not duplicate the whole file as a type;
+//   - methods under a bare `private` or `protected` of their class, or listed like `private :foo`, are hidden.
+func (c *RubySpec) AdjustSymbols(syms []*lsp.DocumentSymbol) {
+	if len(syms) == 0 {
+		return
+	}
+	for _, s := range syms {
+		if s.Kind == lsp.SKConstructor {
+			s.Kind = lsp.SKMethod
+		}
+	}
+	owner := func(sym *lsp.DocumentSymbol) *lsp.DocumentSymbol {
+		var ret *lsp.DocumentSymbol
+		for _, s := range syms {
+			if s == sym || (s.Kind != lsp.SKClass && s.Kind != lsp.SKModule) || !s.Location.Range.Include(sym.Location.Range) {
+				continue
+			}
+			if ret == nil || ret.Location.Range.Include(s.Location.Range) {
+				ret = s
+			}
+		}
+		return ret
+	}
+	mixins := map[*lsp.DocumentSymbol]bool{}
+	for _, s := range syms {
+		if s.Kind == lsp.SKMethod || s.Kind == lsp.SKFunction {
+			if o := owner(s); o != nil {
+				mixins[o] = true
+			}
+		}
+	}
+	for _, s := range syms {
+		if s.Kind == lsp.SKModule {
+			if mixins[s] {
+				s.Kind = lsp.SKClass
+			} else {
+				s.Kind = lsp.SKNamespace
+			}
+		}
+	}
+
+	data, err := os.ReadFile(syms[0].Location.URI.File())
+	if err != nil {
+		return
+	}
+	hidden := hiddenMethods(strings.Split(string(data), "\n"), syms, owner)
+	c.mu.Lock()
+	for _, s := range hidden {
+		c.hidden[s.Location] = true
+	}
+	c.mu.Unlock()
+}
+
+// hiddenMethods returns the methods hidden by the visibility sections or lists of their classes
+func hiddenMethods(lines []string, syms []*lsp.DocumentSymbol, owner func(*lsp.DocumentSymbol) *lsp.DocumentSymbol) []*lsp.DocumentSymbol {
+	type mark struct {
+		line   int
+		hidden bool
+	}
+	sections := map[*lsp.DocumentSymbol][]mark{}
+	listed := map[*lsp.DocumentSymbol]map[string]bool{}
+	for i, line := range lines {
+		m := visibilityRegex.FindStringSubmatch(line)
+		n := visibleNameRegex.FindStringSubmatch(line)
+		if m == nil && n == nil {
+			continue
+		}
+		pos := &lsp.DocumentSymbol{Location: lsp.Location{Range: lsp.Range{
+			Start: lsp.Position{Line: i, Character: len(line) - len(strings.TrimLeft(line, " \t"))},
+			End:   lsp.Position{Line: i, Character: len(line)},
+		}}}
+		o := owner(pos)
+		if o == nil {
+			continue
+		}
+		if m != nil {
+			sections[o] = append(sections[o], mark{i, m[1] != "public"})
+			continue
+		}
+		if listed[o] == nil {
+			listed[o] = map[string]bool{}
+		}
+		for _, name := range strings.Split(n[2], ",") {
+			listed[o][strings.TrimPrefix(strings.TrimSpace(name), ":")] = true
+		}
+	}
+
+	var ret []*lsp.DocumentSymbol
+	for _, s := range syms {
+		if s.Kind != lsp.SKMethod && s.Kind != lsp.SKFunction {
+			continue
+		}
+		o := owner(s)
+		if o == nil {
+			continue
+		}
+		if listed[o][s.Name] {
+			ret = append(ret, s)
+			continue
+		}
+		// sections don't hide the singleton methods
+		start := s.Location.Range.Start.Line
+		if start < len(lines) {
+			if m := defRegex.FindStringSubmatch(lines[start]); m != nil && m[2] != "" {
+				continue
+			}
+		}
+		hidden := false
+		for _, mk := range sections[o] {
+			if mk.line < start {
+				hidden = mk.hidden
+			}
+		}
+		if hidden {
+			ret = append(ret, s)
+		}
+	}
+	return ret
+}
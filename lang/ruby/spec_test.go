@@ -0,0 +1,150 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ruby
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	lsp "github.com/cloudwego/abcoder/lang/lsp"
+	"github.com/cloudwego/abcoder/lang/uniast"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestRubySpec_WorkSpace(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"Gemfile":                             "source 'https://rubygems.org'\n",
+		"app/models/user.rb":                  "class User; end\n",
+		"engines/billing/billing.gemspec":     "Gem::Specification.new do |spec|\n  spec.name = \"acme-billing\"\nend\n",
+		"engines/billing/lib/acme/billing.rb": "module Acme; end\n",
+		"vendor/bundle/x.gemspec":             "",
+	})
+	spec := NewRubySpec()
+	mods, err := spec.WorkSpace(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{filepath.Base(dir): dir, "acme-billing": filepath.Join(dir, "engines/billing")}
+	if !reflect.DeepEqual(mods, want) {
+		t.Fatalf("WorkSpace() = %v, want %v", mods, want)
+	}
+
+	for _, tt := range []struct {
+		path, mod, pkg string
+	}{
+		{filepath.Join(dir, "app/models/user.rb"), filepath.Base(dir), "app/models/user"},
+		{filepath.Join(dir, "engines/billing/lib/acme/billing.rb"), "acme-billing", "acme/billing"},
+		{"/usr/lib/ruby/gems/3.2.0/gems/activesupport-7.1.3/lib/active_support/concern.rb", "activesupport@7.1.3", "active_support/concern"},
+		{"/usr/lib/ruby/3.2.0/set.rb", StdModule, "set"},
+	} {
+		mod, pkg, err := spec.NameSpace(tt.path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mod != tt.mod || pkg != tt.pkg {
+			t.Errorf("NameSpace(%s) = %s, %s, want %s, %s", tt.path, mod, pkg, tt.mod, tt.pkg)
+		}
+	}
+}
+
+func TestRubySpec_FileImports(t *testing.T) {
+	content := `require "json"
+require_relative 'billing/invoice'
+require_relative "../support"
+  require('set')
+# require "commented"
+`
+	got, err := NewRubySpec().FileImports([]byte(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []uniast.Import{{Path: "json"}, {Path: "./billing/invoice"}, {Path: "../support"}, {Path: "set"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FileImports() = %v, want %v", got, want)
+	}
+}
+
+func TestRubySpec_AdjustSymbols(t *testing.T) {
+	content := `module Acme
+  module Helpers
+    def helper; end
+  end
+
+  class User
+    def self.find; end
+    def name; end
+
+    private
+
+    def self.build; end
+    def secret; end
+
+    public
+
+    def open; end
+    def closed; end
+    private :closed
+  end
+end
+`
+	dir := writeFiles(t, map[string]string{"user.rb": content})
+	uri := lsp.NewURI(filepath.Join(dir, "user.rb"))
+	sym := func(name string, kind lsp.SymbolKind, start, end int) *lsp.DocumentSymbol {
+		return &lsp.DocumentSymbol{Name: name, Kind: kind, Location: lsp.Location{URI: uri, Range: lsp.Range{
+			Start: lsp.Position{Line: start}, End: lsp.Position{Line: end, Character: 5},
+		}}}
+	}
+	syms := []*lsp.DocumentSymbol{
+		sym("Acme", lsp.SKModule, 0, 20),
+		sym("Helpers", lsp.SKModule, 1, 3),
+		sym("helper", lsp.SKMethod, 2, 2),
+		sym("User", lsp.SKClass, 5, 19),
+		sym("find", lsp.SKMethod, 6, 6),
+		sym("name", lsp.SKMethod, 7, 7),
+		sym("build", lsp.SKMethod, 11, 11),
+		sym("secret", lsp.SKMethod, 12, 12),
+		sym("open", lsp.SKMethod, 16, 16),
+		sym("closed", lsp.SKMethod, 17, 17),
+	}
+	spec := NewRubySpec()
+	spec.AdjustSymbols(syms)
+	if syms[0].Kind != lsp.SKNamespace || syms[1].Kind != lsp.SKClass {
+		t.Errorf("kinds of modules = %v, %v", syms[0].Kind, syms[1].Kind)
+	}
+	want := map[string]bool{"helper": true, "find": true, "name": true, "build": true, "secret": false, "open": true, "closed": false}
+	for _, s := range syms[2:] {
+		if s.Kind != lsp.SKMethod {
+			continue
+		}
+		if got := spec.IsPublicSymbol(*s); got != want[s.Name] {
+			t.Errorf("IsPublicSymbol(%s) = %v, want %v", s.Name, got, want[s.Name])
+		}
+	}
+}
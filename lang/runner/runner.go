@@ -0,0 +1,216 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package runner executes build/test commands on the written codes,
+// and maps the reported diagnostics back to UniAST nodes.
+package runner
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/abcoder/lang/log"
+	"github.com/cloudwego/abcoder/lang/uniast"
+)
+
+const defaultTimeout = 5 * time.Minute
+
+// Command is a build or test command line, like `go build ./...`.
+// It is split by spaces and executed without shell.
+type Command string
+
+// DefaultCommands returns the default build/test commands of a language
+func DefaultCommands(lang uniast.Language) []Command {
+	switch lang {
+	case uniast.Golang:
+		return []Command{"go build ./...", "go test ./..."}
+	case uniast.Rust:
+		return []Command{"cargo check"}
+	case uniast.Python:
+		return []Command{"pytest"}
+	default:
+		return nil
+	}
+}
+
+type Options struct {
+	// Dir is the directory where commands run, usually the written output dir
+	Dir string
+	// Commands to execute in order. If empty, use DefaultCommands of the repo language
+	Commands []Command
+	// Timeout of each command, default 5 minutes
+	Timeout time.Duration
+}
+
+// Diagnostic is a file:line error reported by a command
+type Diagnostic struct {
+	File    string           `json:"file"`
+	Line    int              `json:"line"`
+	Column  int              `json:"column,omitempty"`
+	Message string           `json:"message"`
+	Node    *uniast.Identity `json:"node,omitempty"` // the ast node where the error locates
+}
+
+// Result is the result of executing one command
+type Result struct {
+	Command     Command      `json:"command"`
+	Success     bool         `json:"success"`
+	Output      string       `json:"output,omitempty"`
+	Diagnostics []Diagnostic `json:"diagnostics,omitempty"`
+}
+
+type Runner struct {
+	opts Options
+	repo *uniast.Repository
+}
+
+// NewRunner creates a runner. repo can be nil, then diagnostics won't be mapped onto nodes.
+func NewRunner(repo *uniast.Repository, opts Options) *Runner {
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+	if len(opts.Commands) == 0 && repo != nil {
+		// pick the language of the first module by name, to be stable on multi-language repos
+		mods := repo.InternalModules()
+		sort.Slice(mods, func(i, j int) bool {
+			return mods[i].Name < mods[j].Name
+		})
+		for _, mod := range mods {
+			opts.Commands = DefaultCommands(mod.Language)
+			if len(opts.Commands) > 0 {
+				break
+			}
+		}
+	}
+	return &Runner{
+		opts: opts,
+		repo: repo,
+	}
+}
+
+// Run executes all commands in order, and stops at the first failed one.
+func (r *Runner) Run(ctx context.Context) ([]Result, error) {
+	if len(r.opts.Commands) == 0 {
+		return nil, fmt.Errorf("no command to run")
+	}
+	ret := make([]Result, 0, len(r.opts.Commands))
+	for _, cmd := range r.opts.Commands {
+		res, err := r.RunCommand(ctx, cmd)
+		if err != nil {
+			return ret, err
+		}
+		ret = append(ret, *res)
+		if !res.Success {
+			break
+		}
+	}
+	return ret, nil
+}
+
+// RunCommand executes one command and collects its diagnostics.
+// Non-zero exit of the command is reported by Result.Success instead of error.
+func (r *Runner) RunCommand(ctx context.Context, command Command) (*Result, error) {
+	args := strings.Fields(string(command))
+	if len(args) == 0 {
+		return nil, fmt.Errorf("empty command")
+	}
+	ctx, cancel := context.WithTimeout(ctx, r.opts.Timeout)
+	defer cancel()
+
+	log.Info("run command '%s' in %s", command, r.opts.Dir)
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = r.opts.Dir
+	var buf bytes.Buffer
+	cmd.Stdout = &buf
+	cmd.Stderr = &buf
+	err := cmd.Run()
+	if _, ok := err.(*exec.ExitError); err != nil && !ok {
+		return nil, fmt.Errorf("execute '%s' failed: %v", command, err)
+	}
+
+	res := &Result{
+		Command: command,
+		Success: err == nil,
+		Output:  buf.String(),
+	}
+	res.Diagnostics = ParseDiagnostics(res.Output)
+	for i := range res.Diagnostics {
+		d := &res.Diagnostics[i]
+		d.File = r.relPath(d.File)
+		d.Node = r.LocateNode(d.File, d.Line)
+	}
+	return res, nil
+}
+
+func (r *Runner) relPath(file string) string {
+	if filepath.IsAbs(file) && r.opts.Dir != "" {
+		dir, _ := filepath.Abs(r.opts.Dir)
+		if rel, err := filepath.Rel(dir, file); err == nil {
+			file = rel
+		}
+	}
+	return filepath.Clean(file)
+}
+
+// LocateNode finds the node whose codes cover file:line.
+// file is relative to the repo root
+func (r *Runner) LocateNode(file string, line int) *uniast.Identity {
+	if r.repo == nil {
+		return nil
+	}
+	n := r.repo.NodeAt(file, line, 0)
+	if n == nil {
+		return nil
+	}
+	id := n.Identity
+	return &id
+}
+
+var (
+	// go, gcc, pytest style: `path/to/file.go:12:5: message`
+	fileLineRegex = regexp.MustCompile(`^\s*([^\s:]+\.[A-Za-z0-9]+):(\d+)(?::(\d+))?:?\s*(.*)$`)
+	// rustc style: `  --> src/main.rs:12:5`, the message is on the previous `error` line
+	arrowRegex = regexp.MustCompile(`^\s*-->\s*([^\s:]+):(\d+):(\d+)`)
+)
+
+// ParseDiagnostics extracts file:line diagnostics from the output of build/test commands
+func ParseDiagnostics(output string) []Diagnostic {
+	var ret []Diagnostic
+	var lastMsg string
+	for _, line := range strings.Split(output, "\n") {
+		if strings.HasPrefix(line, "error") || strings.HasPrefix(line, "warning") {
+			lastMsg = strings.TrimSpace(line)
+		}
+		if m := arrowRegex.FindStringSubmatch(line); m != nil {
+			l, _ := strconv.Atoi(m[2])
+			c, _ := strconv.Atoi(m[3])
+			ret = append(ret, Diagnostic{File: m[1], Line: l, Column: c, Message: lastMsg})
+			continue
+		}
+		if m := fileLineRegex.FindStringSubmatch(line); m != nil {
+			l, _ := strconv.Atoi(m[2])
+			c, _ := strconv.Atoi(m[3])
+			ret = append(ret, Diagnostic{File: m[1], Line: l, Column: c, Message: strings.TrimSpace(m[4])})
+		}
+	}
+	return ret
+}
@@ -0,0 +1,170 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/cloudwego/abcoder/lang/uniast"
+)
+
+func TestParseDiagnostics(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []Diagnostic
+	}{
+		{
+			name: "go build",
+			output: `# example.com/demo/a
+a/a.go:9:2: undefined: b.Hello
+a/a.go:12:10: cannot use x (variable of type int) as string value in return statement
+`,
+			want: []Diagnostic{
+				{File: "a/a.go", Line: 9, Column: 2, Message: "undefined: b.Hello"},
+				{File: "a/a.go", Line: 12, Column: 10, Message: "cannot use x (variable of type int) as string value in return statement"},
+			},
+		},
+		{
+			name: "rustc",
+			output: `    Checking demo v0.1.0 (/tmp/demo)
+error[E0425]: cannot find value ` + "`x`" + ` in this scope
+ --> src/main.rs:2:20
+  |
+2 |     println!("{}", x);
+  |                    ^ not found in this scope
+
+error: could not compile ` + "`demo`" + ` (bin "demo") due to 1 previous error
+`,
+			want: []Diagnostic{
+				{File: "src/main.rs", Line: 2, Column: 20, Message: "error[E0425]: cannot find value `x` in this scope"},
+			},
+		},
+		{
+			name: "pytest",
+			output: `=================================== FAILURES ===================================
+___________________________________ test_add ___________________________________
+
+    def test_add():
+>       assert add(1, 2) == 4
+E       assert 3 == 4
+
+tests/test_calc.py:5: AssertionError
+=========================== short test summary info ============================
+FAILED tests/test_calc.py::test_add - assert 3 == 4
+`,
+			want: []Diagnostic{
+				{File: "tests/test_calc.py", Line: 5, Message: "AssertionError"},
+			},
+		},
+		{
+			name:   "success",
+			output: "ok  \texample.com/demo/a\t0.003s\n",
+			want:   nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseDiagnostics(tt.output); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseDiagnostics() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+const demoMod = "example.com/demo"
+
+func newDemoRepo() *uniast.Repository {
+	repo := uniast.NewRepository(demoMod)
+	mod := uniast.NewModule(demoMod, ".", uniast.Golang)
+	repo.SetModule(demoMod, mod)
+	pkg := uniast.NewPackage(demoMod + "/a")
+	mod.Packages[pkg.PkgPath] = pkg
+	pkg.Types["T"] = &uniast.Type{
+		Identity: uniast.NewIdentity(demoMod, pkg.PkgPath, "T"),
+		FileLine: uniast.FileLine{File: "a/a.go", Line: 3},
+		Content:  "type T struct {\n\tA int\n}",
+	}
+	pkg.Functions["T.Get"] = &uniast.Function{
+		Identity: uniast.NewIdentity(demoMod, pkg.PkgPath, "T.Get"),
+		FileLine: uniast.FileLine{File: "a/a.go", Line: 7},
+		Content:  "func (t T) Get() string {\n\treturn t.A\n}",
+	}
+	return &repo
+}
+
+func TestRunner_LocateNode(t *testing.T) {
+	r := NewRunner(newDemoRepo(), Options{})
+	tests := []struct {
+		name string
+		file string
+		line int
+		want *uniast.Identity
+	}{
+		{"type", "a/a.go", 4, &uniast.Identity{ModPath: demoMod, PkgPath: demoMod + "/a", Name: "T"}},
+		{"func body", "a/a.go", 8, &uniast.Identity{ModPath: demoMod, PkgPath: demoMod + "/a", Name: "T.Get"}},
+		{"between nodes", "a/a.go", 6, nil},
+		{"other file", "a/b.go", 8, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.LocateNode(tt.file, tt.line); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LocateNode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunner_Run(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/demo\n\ngo 1.21\n",
+		"a/a.go": "package a\n\ntype T struct {\n\tA int\n}\n\nfunc (t T) Get() string {\n\treturn t.A\n}\n",
+	}
+	for name, content := range files {
+		fpath := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(fpath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fpath, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := NewRunner(newDemoRepo(), Options{Dir: dir})
+	if !reflect.DeepEqual(r.opts.Commands, DefaultCommands(uniast.Golang)) {
+		t.Fatalf("default commands = %v, want those of go", r.opts.Commands)
+	}
+	results, err := r.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	// stop at the failed `go build`
+	if len(results) != 1 || results[0].Success {
+		t.Fatalf("Run() = %#v, want one failed result", results)
+	}
+	ds := results[0].Diagnostics
+	if len(ds) != 1 {
+		t.Fatalf("diagnostics = %#v, want one", ds)
+	}
+	want := uniast.NewIdentity(demoMod, demoMod+"/a", "T.Get")
+	if ds[0].File != "a/a.go" || ds[0].Line != 8 || ds[0].Node == nil || *ds[0].Node != want {
+		t.Errorf("diagnostic = %#v, want a/a.go:8 on %v", ds[0], want)
+	}
+}
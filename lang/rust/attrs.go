@@ -0,0 +1,92 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rust
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/cloudwego/abcoder/lang/uniast"
+	"github.com/cloudwego/abcoder/lang/utils"
+)
+
+// Attributes parses the outer attributes at the head of an item, like `#[derive(Debug)]` or `#[tokio::main]`,
+// since the range of rust-analyzer symbols covers them. Doc comments are skipped
+func Attributes(content string) []uniast.Annotation {
+	ret, _ := splitAttributes(content)
+	return ret
+}
+
+// splitAttributes parses the attributes at the head of an item, and returns the rest of the item
+func splitAttributes(content string) ([]uniast.Annotation, string) {
+	var ret []uniast.Annotation
+	s := content
+	for {
+		s = strings.TrimLeft(s, " \t\r\n")
+		if strings.HasPrefix(s, "//") {
+			if i := strings.IndexByte(s, '\n'); i >= 0 {
+				s = s[i+1:]
+				continue
+			}
+			break
+		}
+		if strings.HasPrefix(s, "/*") {
+			if i := strings.Index(s, "*/"); i >= 0 {
+				s = s[i+2:]
+				continue
+			}
+			break
+		}
+		if !strings.HasPrefix(s, "#[") {
+			break
+		}
+		end := utils.MatchBracket(s, 1)
+		if end < 0 {
+			break
+		}
+		ret = append(ret, uniast.ParseAnnotation(s[2:end]))
+		s = s[end+1:]
+	}
+	return ret, s
+}
+
+// Visibility parses the visibility qualifier of an item, after its attributes:
+// `pub` is public, `pub(crate)` is internal, `pub(super)` and `pub(in path)` are package-scoped,
+// and `pub(self)` or no qualifier is private
+func Visibility(content string) uniast.Visibility {
+	_, s := splitAttributes(content)
+	s = strings.TrimLeft(s, " \t\r\n")
+	if !strings.HasPrefix(s, "pub") {
+		return uniast.VisibilityPrivate
+	}
+	s = s[3:]
+	if rest := strings.TrimLeft(s, " \t\r\n"); strings.HasPrefix(rest, "(") {
+		if end := utils.MatchBracket(rest, 0); end > 0 {
+			switch scope := strings.TrimSpace(rest[1:end]); {
+			case scope == "crate":
+				return uniast.VisibilityInternal
+			case scope == "self":
+				return uniast.VisibilityPrivate
+			default:
+				return uniast.VisibilityPackage
+			}
+		}
+	}
+	if s != "" && (s[0] == '_' || unicode.IsLetter(rune(s[0])) || unicode.IsDigit(rune(s[0]))) {
+		// an identifier like `pub_fn`, not the keyword
+		return uniast.VisibilityPrivate
+	}
+	return uniast.VisibilityPublic
+}
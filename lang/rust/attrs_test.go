@@ -0,0 +1,64 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rust
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/cloudwego/abcoder/lang/uniast"
+)
+
+func TestAttributes(t *testing.T) {
+	content := `/// A point, see #[derive] in docs
+#[derive(Debug, Clone)]
+#[serde(rename = "pt")]
+/* block */ #[cfg_attr(test, derive(Default))]
+#[doc = "x"]
+#[inline]
+pub struct Point {
+    #[serde(skip)]
+    x: i32,
+}`
+	want := []uniast.Annotation{
+		{Name: "derive", Args: "Debug, Clone"},
+		{Name: "serde", Args: `rename = "pt"`},
+		{Name: "cfg_attr", Args: "test, derive(Default)"},
+		{Name: "doc", Args: `"x"`},
+		{Name: "inline"},
+	}
+	if got := Attributes(content); !reflect.DeepEqual(got, want) {
+		t.Errorf("Attributes() = %+v, want %+v", got, want)
+	}
+	if got := Attributes("fn main() {}"); got != nil {
+		t.Errorf("Attributes() = %+v, want nil", got)
+	}
+}
+
+func TestVisibility(t *testing.T) {
+	for content, want := range map[string]uniast.Visibility{
+		"fn f() {}":     uniast.VisibilityPrivate,
+		"pub fn f() {}": uniast.VisibilityPublic,
+		"/// doc\n#[inline]\npub(crate) fn f() {}": uniast.VisibilityInternal,
+		"pub (super) struct S;":                    uniast.VisibilityPackage,
+		"pub(in crate::a) const C: i32 = 1;":       uniast.VisibilityPackage,
+		"pub(self) static S: i32 = 1;":             uniast.VisibilityPrivate,
+		"pub_fn!();":                               uniast.VisibilityPrivate,
+	} {
+		if got := Visibility(content); got != want {
+			t.Errorf("Visibility(%q) = %q, want %q", content, got, want)
+		}
+	}
+}
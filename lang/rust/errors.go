@@ -0,0 +1,84 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rust
+
+import "strings"
+
+// ResultError parses the return type of a function signature, like `fn get(&self) -> Result<Value, StoreError>`,
+// and returns the error type of the Result, which is empty for the aliases like `io::Result<T>`.
+// ok is false if the function does not return a Result
+func ResultError(signature string) (errType string, ok bool) {
+	ret := returnType(signature)
+	name, args, _ := strings.Cut(ret, "<")
+	if i := strings.LastIndex(name, "::"); i >= 0 {
+		name = name[i+2:]
+	}
+	if strings.TrimSpace(name) != "Result" || !strings.HasSuffix(ret, ">") {
+		return "", false
+	}
+	params := splitGenerics(args[:len(args)-1])
+	if len(params) < 2 {
+		return "", true
+	}
+	return strings.TrimSpace(params[1]), true
+}
+
+// returnType returns the type after the top-level `->` of the signature, before the where clause or the body.
+// The attributes and the doc comments at the head are skipped
+func returnType(signature string) string {
+	_, signature = splitAttributes(signature)
+	depth := 0
+	for i := 0; i < len(signature); i++ {
+		switch c := signature[i]; {
+		case c == '-' && depth == 0 && strings.HasPrefix(signature[i:], "->"):
+			ret := signature[i+2:]
+			if j := strings.Index(ret, " where "); j >= 0 {
+				ret = ret[:j]
+			}
+			if j := strings.IndexByte(ret, '{'); j >= 0 {
+				ret = ret[:j]
+			}
+			return strings.TrimSpace(ret)
+		case c == '(' || c == '[' || c == '<':
+			depth++
+		case c == ')' || c == ']' || (c == '>' && (i == 0 || signature[i-1] != '-')):
+			depth--
+		}
+	}
+	return ""
+}
+
+// splitGenerics splits the generic arguments by the top-level commas
+func splitGenerics(args string) []string {
+	var ret []string
+	depth, start := 0, 0
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case '(', '[', '<':
+			depth++
+		case ')', ']', '>':
+			depth--
+		case ',':
+			if depth == 0 {
+				ret = append(ret, args[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if strings.TrimSpace(args[start:]) != "" {
+		ret = append(ret, args[start:])
+	}
+	return ret
+}
@@ -0,0 +1,38 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rust
+
+import "testing"
+
+func TestResultError(t *testing.T) {
+	tests := []struct {
+		sig    string
+		want   string
+		wantOk bool
+	}{
+		{"pub fn get(&self, key: &str) -> Result<Value, StoreError>", "StoreError", true},
+		{"fn parse<'a>(s: &'a str) -> std::result::Result<Vec<(u8, u8)>, Box<dyn Error>> where T: Clone", "Box<dyn Error>", true},
+		{"fn read(&mut self) -> io::Result<usize>", "", true},
+		{"fn apply(f: impl Fn(u8) -> Result<u8, E>) -> u8", "", false},
+		{"/// Fails -> never\n#[inline]\nfn run()", "", false},
+		{"async fn fetch(url: Url) -> anyhow::Result<Bytes> {", "", true},
+	}
+	for _, tt := range tests {
+		got, ok := ResultError(tt.sig)
+		if got != tt.want || ok != tt.wantOk {
+			t.Errorf("ResultError(%q) = %q, %v, want %q, %v", tt.sig, got, ok, tt.want, tt.wantOk)
+		}
+	}
+}
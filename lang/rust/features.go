@@ -0,0 +1,66 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rust
+
+import "strings"
+
+// Features is the cargo feature set to parse the workspace with.
+// rust-analyzer hides the cfg-gated items of disabled features, thus they must be enabled explicitly.
+type Features struct {
+	// Features to enable, like `serde`, `tokio/full`
+	Features []string
+	// AllFeatures enables all features of all crates
+	AllFeatures bool
+	// NoDefaultFeatures disables the `default` feature
+	NoDefaultFeatures bool
+}
+
+func (f Features) IsEmpty() bool {
+	return len(f.Features) == 0 && !f.AllFeatures && !f.NoDefaultFeatures
+}
+
+// CargoArgs returns the feature arguments of cargo commands
+func (f Features) CargoArgs() []string {
+	var args []string
+	if f.AllFeatures {
+		args = append(args, "--all-features")
+	} else if len(f.Features) > 0 {
+		args = append(args, "--features", strings.Join(f.Features, ","))
+	}
+	if f.NoDefaultFeatures {
+		args = append(args, "--no-default-features")
+	}
+	return args
+}
+
+// InitializationOptions returns the rust-analyzer initialization options of the features,
+// with the extra options merged on the top level
+func (f Features) InitializationOptions(extra map[string]string) map[string]interface{} {
+	ret := make(map[string]interface{}, len(extra)+1)
+	for k, v := range extra {
+		ret[k] = v
+	}
+	cargo := map[string]interface{}{}
+	if f.AllFeatures {
+		cargo["features"] = "all"
+	} else if len(f.Features) > 0 {
+		cargo["features"] = f.Features
+	}
+	if f.NoDefaultFeatures {
+		cargo["noDefaultFeatures"] = true
+	}
+	ret["cargo"] = cargo
+	return ret
+}
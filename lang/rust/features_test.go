@@ -0,0 +1,61 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rust
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestFeatures(t *testing.T) {
+	tests := []struct {
+		name     string
+		features Features
+		args     []string
+		init     string
+	}{
+		{
+			name: "default",
+			init: `{"cargo":{},"java_parser":"ipc"}`,
+		},
+		{
+			name:     "features",
+			features: Features{Features: []string{"serde", "tokio/full"}, NoDefaultFeatures: true},
+			args:     []string{"--features", "serde,tokio/full", "--no-default-features"},
+			init:     `{"cargo":{"features":["serde","tokio/full"],"noDefaultFeatures":true},"java_parser":"ipc"}`,
+		},
+		{
+			name:     "all features",
+			features: Features{Features: []string{"serde"}, AllFeatures: true},
+			args:     []string{"--all-features"},
+			init:     `{"cargo":{"features":"all"},"java_parser":"ipc"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.features.CargoArgs(); !reflect.DeepEqual(got, tt.args) {
+				t.Errorf("CargoArgs() = %v, want %v", got, tt.args)
+			}
+			init, err := json.Marshal(tt.features.InitializationOptions(map[string]string{"java_parser": "ipc"}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(init) != tt.init {
+				t.Errorf("InitializationOptions() = %s, want %s", init, tt.init)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rust
+
+import (
+	"context"
+
+	"github.com/cloudwego/abcoder/lang/lsp"
+	sitter "github.com/smacker/go-tree-sitter"
+	tsrust "github.com/smacker/go-tree-sitter/rust"
+)
+
+// MacroItem is an item generated by a macro, like the `static ref` of `lazy_static!`
+type MacroItem struct {
+	// Name of the item. Methods in impls are named like `Type.method`, or `Trait<Type>.method` for trait impls
+	Name string
+	Kind lsp.SymbolKind
+	// Text is the codes of the item in the expansion
+	Text string
+}
+
+var macroItemKinds = map[string]lsp.SymbolKind{
+	"function_item": lsp.SKFunction,
+	"struct_item":   lsp.SKStruct,
+	"union_item":    lsp.SKStruct,
+	"enum_item":     lsp.SKEnum,
+	"trait_item":    lsp.SKInterface,
+	"type_item":     lsp.SKTypeParameter,
+	"static_item":   lsp.SKVariable,
+	"const_item":    lsp.SKConstant,
+}
+
+// MacroItems parses the items in the expansion of a macro, which is got by `rust-analyzer/expandMacro`.
+// The items in the nested modules are flattened, and the methods in impls are returned as lsp.SKMethod
+func MacroItems(expansion string) []MacroItem {
+	parser := sitter.NewParser()
+	parser.SetLanguage(tsrust.GetLanguage())
+	src := []byte(expansion)
+	tree, err := parser.ParseCtx(context.Background(), nil, src)
+	if err != nil {
+		return nil
+	}
+	defer tree.Close()
+	var ret []MacroItem
+	var walk func(n *sitter.Node)
+	walk = func(n *sitter.Node) {
+		for i := 0; i < int(n.NamedChildCount()); i++ {
+			item := n.NamedChild(i)
+			switch typ := item.Type(); typ {
+			case "mod_item":
+				if body := item.ChildByFieldName("body"); body != nil {
+					walk(body)
+				}
+			case "impl_item":
+				recv := typeName(item.ChildByFieldName("type"), src)
+				if trait := typeName(item.ChildByFieldName("trait"), src); trait != "" {
+					recv = trait + "<" + recv + ">"
+				}
+				body := item.ChildByFieldName("body")
+				if recv == "" || body == nil {
+					continue
+				}
+				for j := 0; j < int(body.NamedChildCount()); j++ {
+					fn := body.NamedChild(j)
+					if name := fn.ChildByFieldName("name"); fn.Type() == "function_item" && name != nil {
+						ret = append(ret, MacroItem{Name: recv + "." + name.Content(src), Kind: lsp.SKMethod, Text: fn.Content(src)})
+					}
+				}
+			default:
+				kind, ok := macroItemKinds[typ]
+				name := item.ChildByFieldName("name")
+				if !ok || name == nil {
+					continue
+				}
+				ret = append(ret, MacroItem{Name: name.Content(src), Kind: kind, Text: item.Content(src)})
+			}
+		}
+	}
+	walk(tree.RootNode())
+	return ret
+}
+
+// typeName returns the name of the type without the path and generics, like `Foo` of `crate::a::Foo<T>`
+func typeName(n *sitter.Node, src []byte) string {
+	for n != nil {
+		switch n.Type() {
+		case "generic_type":
+			n = n.ChildByFieldName("type")
+		case "scoped_type_identifier":
+			n = n.ChildByFieldName("name")
+		case "type_identifier", "primitive_type":
+			return n.Content(src)
+		default:
+			return ""
+		}
+	}
+	return ""
+}
@@ -0,0 +1,66 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rust
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/cloudwego/abcoder/lang/lsp"
+)
+
+func TestMacroItems(t *testing.T) {
+	// the expansion of `lazy_static! { pub static ref CONFIG: Config = Config::load(); }`
+	expansion := `#[allow(missing_copy_implementations)]
+#[allow(non_camel_case_types)]
+#[allow(dead_code)]
+pub struct CONFIG {
+    __private_field: (),
+}
+#[doc(hidden)]
+pub static CONFIG: CONFIG = CONFIG { __private_field: () };
+impl ::lazy_static::__Deref for CONFIG {
+    type Target = Config;
+    fn deref(&self) -> &Config {
+        fn __static_ref_initialize() -> Config { Config::load() }
+        __static_ref_initialize()
+    }
+}
+impl<T> Wrapper<T> {
+    pub fn get(&self) -> &T { &self.0 }
+}
+pub mod greeter_client {
+    pub struct GreeterClient<T> { inner: T }
+    pub const NAME: &str = "greeter";
+}`
+	var got []string
+	for _, it := range MacroItems(expansion) {
+		got = append(got, it.Name+":"+it.Kind.String())
+	}
+	want := []string{
+		"CONFIG:" + lsp.SKStruct.String(),
+		"CONFIG:" + lsp.SKVariable.String(),
+		"__Deref<CONFIG>.deref:" + lsp.SKMethod.String(),
+		"Wrapper.get:" + lsp.SKMethod.String(),
+		"GreeterClient:" + lsp.SKStruct.String(),
+		"NAME:" + lsp.SKConstant.String(),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MacroItems() = %v, want %v", got, want)
+	}
+	if items := MacroItems(expansion); items[3].Text != "pub fn get(&self) -> &T { &self.0 }" {
+		t.Errorf("text of Wrapper.get = %q", items[3].Text)
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rust
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/cloudwego/abcoder/lang/uniast"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// cargoManifest is the part of Cargo.toml concerning the build configuration
+type cargoManifest struct {
+	Package struct {
+		// Edition and RustVersion are tables like `{ workspace = true }` if inherited from the workspace
+		Edition     interface{} `toml:"edition"`
+		RustVersion interface{} `toml:"rust-version"`
+	} `toml:"package"`
+	Features map[string][]string `toml:"features"`
+}
+
+// ReadBuildConfig reads the build configuration of the crate from its Cargo.toml.
+// dir is the dir of the module relative to root, which may be the `src` dir of the crate.
+// Returns nil if the manifest is not found or can't be parsed
+func ReadBuildConfig(root string, dir string) *uniast.BuildConfig {
+	for _, d := range []string{dir, filepath.Dir(dir)} {
+		manifest := filepath.Join(d, "Cargo.toml")
+		data, err := os.ReadFile(filepath.Join(root, manifest))
+		if err != nil {
+			continue
+		}
+		var m cargoManifest
+		if err := toml.Unmarshal(data, &m); err != nil {
+			return nil
+		}
+		ret := &uniast.BuildConfig{Manifest: manifest, Features: m.Features}
+		// the versions inherited from the workspace are left empty
+		ret.Edition, _ = m.Package.Edition.(string)
+		ret.LanguageVersion, _ = m.Package.RustVersion.(string)
+		return ret
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rust
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/cloudwego/abcoder/lang/uniast"
+)
+
+func TestReadBuildConfig(t *testing.T) {
+	root := t.TempDir()
+	write := func(path, content string) {
+		if err := os.MkdirAll(filepath.Join(root, filepath.Dir(path)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, path), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a/Cargo.toml", "[package]\nname = \"a\"\nedition = \"2021\"\nrust-version = \"1.70\"\n\n"+
+		"[features]\ndefault = [\"std\"]\nstd = []\nserde = [\"dep:serde\"]\n")
+	write("a/src/lib.rs", "")
+	write("b/Cargo.toml", "[package]\nname = \"b\"\nedition.workspace = true\n")
+
+	tests := []struct {
+		name string
+		dir  string
+		want *uniast.BuildConfig
+	}{
+		{
+			name: "src dir",
+			dir:  "a/src",
+			want: &uniast.BuildConfig{
+				Manifest:        "a/Cargo.toml",
+				LanguageVersion: "1.70",
+				Edition:         "2021",
+				Features:        map[string][]string{"default": {"std"}, "std": {}, "serde": {"dep:serde"}},
+			},
+		},
+		{
+			name: "inherited edition",
+			dir:  "b",
+			want: &uniast.BuildConfig{Manifest: "b/Cargo.toml"},
+		},
+		{
+			name: "no manifest",
+			dir:  "c/src",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ReadBuildConfig(root, tt.dir); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReadBuildConfig() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
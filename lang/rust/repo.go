@@ -42,6 +42,13 @@ func InstallLanguageServer() (string, error) {
 }
 
 func CheckRepo(repo string) (string, time.Duration) {
+	return CheckRepoFeatures(repo, Features{})
+}
+
+// CheckRepoFeatures compiles the repo with the features if there is no compiling cache,
+// and returns the file to open and the time to wait for rust-analyzer
+func CheckRepoFeatures(repo string, features Features) (string, time.Duration) {
+	build := append([]string{"build"}, features.CargoArgs()...)
 	// NOTICE: open the Cargo.toml file is required for Rust projects
 	openfile := utils.FirstFile(repo, ".rs", filepath.Join(repo, "target"))
 
@@ -49,7 +56,7 @@ func CheckRepo(repo string) (string, time.Duration) {
 	if _, err := os.Stat(filepath.Join(repo, "target")); os.IsNotExist(err) {
 		log.Info("Compiling cache not found, run `cargo build` to compile the project first...\n")
 		// compile with the default version first
-		if err := RunCmdInDir(repo, "cargo", "build"); err == nil {
+		if err := RunCmdInDir(repo, "cargo", build...); err == nil {
 			goto next
 		}
 		// update the toolchain and recompile
@@ -59,16 +66,16 @@ func CheckRepo(repo string) (string, time.Duration) {
 			os.Exit(1)
 		}
 		log.Info("Recompile the project for second time...\n")
-		if err := RunCmdInDir(repo, "cargo", "build"); err == nil {
+		if err := RunCmdInDir(repo, "cargo", build...); err == nil {
 			goto next
 		}
-		log.Error("Failed to compile the project, update the rust toolchain to the last commit date\n", err)
+		log.Error("Failed to compile the project, update the rust toolchain to the last commit date: %v\n", err)
 		if err := UpdateToolChain(repo, -1); err != nil {
 			log.Error("Failed to update the rust toolchain: %v\n", err)
 			os.Exit(1)
 		}
 		log.Info("Recompile the project for third time...\n")
-		if err := RunCmdInDir(repo, "cargo", "build"); err == nil {
+		if err := RunCmdInDir(repo, "cargo", build...); err == nil {
 			goto next
 		}
 		log.Error("Failed to compile the project, please check the project\n")
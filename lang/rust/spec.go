@@ -157,6 +157,15 @@ func (c *RustSpec) IsPublicSymbol(sym lsp.DocumentSymbol) bool {
 	return false
 }
 
+// Visibility tells the visibility by the qualifier of the item,
+// items without one but marked public by the server (like the methods of trait impls) are public too
+func (c *RustSpec) Visibility(sym lsp.DocumentSymbol) uniast.Visibility {
+	if v := Visibility(sym.Text); v != uniast.VisibilityPrivate || !c.IsPublicSymbol(sym) {
+		return v
+	}
+	return uniast.VisibilityPublic
+}
+
 func (c *RustSpec) IsMainFunction(sym lsp.DocumentSymbol) bool {
 	return sym.Kind == lsp.SKFunction && sym.Name == "main"
 }
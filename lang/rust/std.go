@@ -0,0 +1,62 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rust
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/cloudwego/abcoder/lang/uniast"
+)
+
+// StdCrates are the crates of the std lib, under lib/rustlib/src/rust/library of the sysroot
+var StdCrates = []string{"std", "core", "alloc", "proc_macro", "test"}
+
+// Toolchain returns the source dir of the std lib and the version of rustc, like 1.80.0.
+// The sources are installed by `rustup component add rust-src`
+func Toolchain() (src string, version string, err error) {
+	out, err := exec.Command("rustc", "--print", "sysroot").Output()
+	if err != nil {
+		return "", "", fmt.Errorf("rustc --print sysroot: %w", err)
+	}
+	src = filepath.Join(strings.TrimSpace(string(out)), "lib", "rustlib", "src", "rust", "library")
+	if _, err := os.Stat(src); err != nil {
+		return "", "", fmt.Errorf("the sources of the std lib are not found, install them by `rustup component add rust-src`: %w", err)
+	}
+	// rustc 1.80.0 (051478957 2024-07-21)
+	out, err = exec.Command("rustc", "--version").Output()
+	if err != nil {
+		return "", "", fmt.Errorf("rustc --version: %w", err)
+	}
+	fields := strings.Fields(string(out))
+	if len(fields) < 2 {
+		return "", "", fmt.Errorf("unexpected output of rustc --version: %q", out)
+	}
+	return src, fields[1], nil
+}
+
+// StdModule returns the std crate of the identity collected with the std symbols, empty if it is not of the std lib.
+// The items of std are in the module std, while those of the other std crates are in no module, see RustSpec.NameSpace
+func StdModule(id uniast.Identity) uniast.ModPath {
+	crate, _, _ := strings.Cut(id.PkgPath, "::")
+	if !slices.Contains(StdCrates, crate) || (id.ModPath != "" && id.ModPath != crate) {
+		return ""
+	}
+	return crate
+}
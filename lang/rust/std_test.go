@@ -0,0 +1,40 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rust
+
+import (
+	"testing"
+
+	"github.com/cloudwego/abcoder/lang/uniast"
+)
+
+func TestStdModule(t *testing.T) {
+	tests := []struct {
+		id   uniast.Identity
+		want uniast.ModPath
+	}{
+		{uniast.NewIdentity("std", "std::collections", "HashMap"), "std"},
+		{uniast.NewIdentity("", "core::fmt", "Debug"), "core"},
+		{uniast.NewIdentity("", "alloc::vec", "Vec"), "alloc"},
+		{uniast.NewIdentity("app", "std::collections", "HashMap"), ""},
+		{uniast.NewIdentity("serde@1.0.0", "serde::de", "Deserialize"), ""},
+		{uniast.NewIdentity("std@1.80.0", "std::collections", "HashMap"), ""},
+	}
+	for _, tt := range tests {
+		if got := StdModule(tt.id); got != tt.want {
+			t.Errorf("StdModule(%v) = %q, want %q", tt.id, got, tt.want)
+		}
+	}
+}
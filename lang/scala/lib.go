@@ -0,0 +1,57 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scala
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/cloudwego/abcoder/lang/log"
+	"github.com/cloudwego/abcoder/lang/uniast"
+	"github.com/cloudwego/abcoder/lang/utils"
+)
+
+// MaxWaitDuration limits the waiting for metals to import the build, which compiles the whole project by bloop
+const MaxWaitDuration = 10 * time.Minute
+
+func InstallLanguageServer() (string, error) {
+	if path, err := exec.LookPath("metals"); err == nil {
+		return path, nil
+	}
+	return "", fmt.Errorf("please install metals manually, e.g. `cs install metals`. See https://scalameta.org/metals/docs/editors/overview")
+}
+
+func GetDefaultLSP() (lang uniast.Language, name string) {
+	name, err := InstallLanguageServer()
+	if err != nil {
+		log.Error("Failed to find metals: %v\n", err)
+		os.Exit(1)
+	}
+	return uniast.Scala, name
+}
+
+func CheckRepo(repo string) (string, time.Duration) {
+	openfile := ""
+	// NOTICE: metals imports the build and compiles all the sources before answering,
+	// thus wait based on the size of code files
+	_, size := utils.CountFiles(repo, ".scala", "target/")
+	wait := 10*time.Second + time.Second*time.Duration(size/1024)
+	if wait > MaxWaitDuration {
+		wait = MaxWaitDuration
+	}
+	return openfile, wait
+}
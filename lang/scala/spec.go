@@ -0,0 +1,555 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scala
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	lsp "github.com/cloudwego/abcoder/lang/lsp"
+	"github.com/cloudwego/abcoder/lang/uniast"
+	"github.com/cloudwego/abcoder/lang/utils"
+)
+
+// metals semantic-token type names.
+const (
+	tokClass         = "class"
+	tokInterface     = "interface"
+	tokEnum          = "enum"
+	tokEnumMember    = "enumMember"
+	tokStruct        = "struct"
+	tokType          = "type"
+	tokTypeParameter = "typeParameter"
+	tokFunction      = "function"
+	tokMethod        = "method"
+	tokVariable      = "variable"
+	tokProperty      = "property"
+	tokNamespace     = "namespace"
+	tokComment       = "comment"
+)
+
+// metals semantic-token modifier names.
+const (
+	modDeclaration    = "declaration"
+	modDefinition     = "definition"
+	modReadonly       = "readonly"
+	modDefaultLibrary = "defaultLibrary"
+)
+
+// RootPackage is the package of the files without package clauses
+const RootPackage = "_root_"
+
+// dependencyDir is where metals extracts the sources jars of the dependencies
+const dependencyDir = ".metals/readonly/dependencies/"
+
+var (
+	sbtNameRegex    = regexp.MustCompile(`(?m)^\s*(?:ThisBuild\s*/\s*)?name\s*:=\s*"([^"]+)"`)
+	sbtProjectRegex = regexp.MustCompile(`lazy\s+val\s+(\w+)\s*=\s*\(?\s*project\s*(?:\.in\s*\(|in\s+)\s*file\(\s*"([^"]+)"\s*\)`)
+	packageRegex    = regexp.MustCompile(`^package\s+([\w.` + "`" + `]+)\s*(?:;|\{)?\s*$`)
+	importRegex     = regexp.MustCompile(`(?m)^\s*import\s+(.+)$`)
+	jarVersionRegex = regexp.MustCompile(`^(.+?)-(\d[\w.\-]*)$`)
+	modifierRegex   = regexp.MustCompile(`^(private|protected|override|final|sealed|abstract|lazy|implicit|given|inline|opaque|transparent|open|infix|case)\b(\s*\[[^\]]*\])?`)
+	annotationRegex = regexp.MustCompile(`^@\s*([\w.]+)`)
+)
+
+type ScalaSpec struct {
+	repo string
+	// module dir => module name
+	mods map[string]string
+
+	pkgMu sync.Mutex
+	pkgs  map[string]string // file path => package
+}
+
+func NewScalaSpec() *ScalaSpec {
+	return &ScalaSpec{
+		mods: map[string]string{},
+		pkgs: map[string]string{},
+	}
+}
+
+func (c *ScalaSpec) ProtectedSymbolKinds() []lsp.SymbolKind {
+	// members are nested inside the ranges of classes and objects
+	return []lsp.SymbolKind{lsp.SKFunction, lsp.SKMethod, lsp.SKVariable, lsp.SKConstant}
+}
+
+// WorkSpace takes the sbt sub-projects declared like `lazy val core = project.in(file("core"))` as modules,
+// and the root project is named by its `name := "..."` setting or the directory
+func (c *ScalaSpec) WorkSpace(root string) (map[string]string, error) {
+	absPath, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path: %w", err)
+	}
+	c.repo = absPath
+	name := filepath.Base(absPath)
+	rets := map[string]string{}
+	if data, err := os.ReadFile(filepath.Join(absPath, "build.sbt")); err == nil {
+		for _, m := range sbtProjectRegex.FindAllStringSubmatch(string(data), -1) {
+			dir := filepath.Join(absPath, m[2])
+			if dir == absPath {
+				name = m[1]
+				continue
+			}
+			rets[m[1]] = dir
+		}
+		if len(rets) == 0 {
+			if m := sbtNameRegex.FindStringSubmatch(string(data)); m != nil {
+				name = m[1]
+			}
+		}
+	}
+	rets[name] = absPath
+	for mod, dir := range rets {
+		c.mods[dir] = mod
+	}
+	return rets, nil
+}
+
+// NameSpace returns the module of the longest module dir containing the file, and the package by its package clauses.
+// Dependencies extracted by metals are named by their jars, like `cats-core_2.13@2.9.0`
+func (c *ScalaSpec) NameSpace(path string, file *uniast.File) (string, string, error) {
+	if i := strings.Index(path, dependencyDir); i >= 0 {
+		jar, _, _ := strings.Cut(path[i+len(dependencyDir):], "/")
+		return jarModule(jar), c.filePackage(path), nil
+	}
+	mod, dir := "", ""
+	for d, m := range c.mods {
+		if hasPathPrefix(path, d) && len(d) > len(dir) {
+			mod, dir = m, d
+		}
+	}
+	if mod == "" {
+		return "external", c.filePackage(path), nil
+	}
+	return mod, c.filePackage(path), nil
+}
+
+// jarModule converts a sources jar name like `cats-core_2.13-2.9.0-sources.jar` to `cats-core_2.13@2.9.0`
+func jarModule(jar string) string {
+	name := strings.TrimSuffix(strings.TrimSuffix(jar, ".jar"), "-sources")
+	if name == "src.zip" {
+		return "jdk"
+	}
+	if m := jarVersionRegex.FindStringSubmatch(name); m != nil {
+		return m[1] + "@" + m[2]
+	}
+	return name
+}
+
+func hasPathPrefix(p, root string) bool {
+	if !strings.HasPrefix(p, root) {
+		return false
+	}
+	return len(p) == len(root) || p[len(root)] == filepath.Separator
+}
+
+// filePackage reads the package of the file once and caches it
+func (c *ScalaSpec) filePackage(path string) string {
+	c.pkgMu.Lock()
+	pkg, ok := c.pkgs[path]
+	c.pkgMu.Unlock()
+	if ok {
+		return pkg
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		pkg = RootPackage
+	} else {
+		pkg = PackageOf(string(data))
+	}
+	c.pkgMu.Lock()
+	c.pkgs[path] = pkg
+	c.pkgMu.Unlock()
+	return pkg
+}
+
+// PackageOf joins the leading package clauses of a file, like `package a.b` followed by `package c` as `a.b.c`.
+// It returns RootPackage if there is none
+func PackageOf(content string) string {
+	var pkgs []string
+	inComment := false
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if inComment {
+			if i := strings.Index(line, "*/"); i >= 0 {
+				inComment = false
+				line = strings.TrimSpace(line[i+2:])
+			} else {
+				continue
+			}
+		}
+		if strings.HasPrefix(line, "/*") {
+			if i := strings.Index(line, "*/"); i >= 0 {
+				line = strings.TrimSpace(line[i+2:])
+			} else {
+				inComment = true
+				continue
+			}
+		}
+		if line == "" || strings.HasPrefix(line, "//") {
+			continue
+		}
+		m := packageRegex.FindStringSubmatch(line)
+		if m == nil {
+			break
+		}
+		pkgs = append(pkgs, strings.ReplaceAll(m[1], "`", ""))
+	}
+	if len(pkgs) == 0 {
+		return RootPackage
+	}
+	return strings.Join(pkgs, ".")
+}
+
+func (c *ScalaSpec) ShouldSkip(path string) bool {
+	if !strings.HasSuffix(path, ".scala") && !strings.HasSuffix(path, ".sc") {
+		return true
+	}
+	if strings.Contains(path, dependencyDir) {
+		return false
+	}
+	for _, dir := range []string{"target", ".metals", ".bloop", ".bsp", "project"} {
+		if strings.Contains(path, string(filepath.Separator)+dir+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// FileImports collects the import clauses, each of which may import several selectors like `a.b.{C, D => E}`
+func (c *ScalaSpec) FileImports(content []byte) ([]uniast.Import, error) {
+	res := []uniast.Import{}
+	for _, m := range importRegex.FindAllStringSubmatch(string(content), -1) {
+		clause := m[1]
+		if i := strings.Index(clause, "//"); i >= 0 {
+			clause = clause[:i]
+		}
+		for _, path := range splitTopLevel(strings.TrimRight(strings.TrimSpace(clause), ";")) {
+			res = append(res, uniast.Import{Path: path})
+		}
+	}
+	return res, nil
+}
+
+// splitTopLevel splits s by the commas outside the braces
+func splitTopLevel(s string) []string {
+	var ret []string
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				if p := strings.TrimSpace(s[start:i]); p != "" {
+					ret = append(ret, p)
+				}
+				start = i + 1
+			}
+		}
+	}
+	if p := strings.TrimSpace(s[start:]); p != "" {
+		ret = append(ret, p)
+	}
+	return ret
+}
+
+func (c *ScalaSpec) IsDocToken(tok lsp.Token) bool {
+	return tok.Type == tokComment
+}
+
+func (c *ScalaSpec) DeclareTokenOfSymbol(sym lsp.DocumentSymbol) int {
+	for i, t := range sym.Tokens {
+		if c.IsDocToken(t) {
+			continue
+		}
+		for _, m := range t.Modifiers {
+			if m == modDeclaration || m == modDefinition {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func (c *ScalaSpec) IsEntityToken(tok lsp.Token) bool {
+	for _, m := range tok.Modifiers {
+		if m == modDeclaration || m == modDefinition {
+			return false
+		}
+	}
+	switch tok.Type {
+	case tokClass, tokInterface, tokEnum, tokStruct, tokType, tokFunction, tokMethod, tokVariable, tokProperty:
+		return true
+	}
+	return false
+}
+
+func (c *ScalaSpec) IsStdToken(tok lsp.Token) bool {
+	for _, m := range tok.Modifiers {
+		if m == modDefaultLibrary {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *ScalaSpec) TokenKind(tok lsp.Token) lsp.SymbolKind {
+	switch tok.Type {
+	case tokClass:
+		return lsp.SKClass
+	case tokInterface:
+		return lsp.SKInterface
+	case tokEnum:
+		return lsp.SKEnum
+	case tokEnumMember:
+		return lsp.SKEnumMember
+	case tokStruct:
+		return lsp.SKStruct
+	case tokType:
+		return lsp.SKTypeParameter
+	case tokTypeParameter:
+		return lsp.SKTypeParameter
+	case tokFunction:
+		return lsp.SKFunction
+	case tokMethod:
+		return lsp.SKMethod
+	case tokNamespace:
+		return lsp.SKNamespace
+	case tokVariable, tokProperty:
+		for _, m := range tok.Modifiers {
+			if m == modReadonly {
+				return lsp.SKConstant
+			}
+		}
+		return lsp.SKVariable
+	}
+	return lsp.SKUnknown
+}
+
+// IsMainFunction tells `def main(args: Array[String])` of objects and `@main def run()` of scala 3
+func (c *ScalaSpec) IsMainFunction(sym lsp.DocumentSymbol) bool {
+	if sym.Kind != lsp.SKMethod && sym.Kind != lsp.SKFunction {
+		return false
+	}
+	if sym.Name == "main" {
+		return true
+	}
+	for _, a := range Annotations(sym.Text) {
+		if a.Name == "main" {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *ScalaSpec) IsEntitySymbol(sym lsp.DocumentSymbol) bool {
+	typ := sym.Kind
+	return typ == lsp.SKMethod || typ == lsp.SKFunction || typ == lsp.SKClass || typ == lsp.SKInterface || typ == lsp.SKEnum ||
+		typ == lsp.SKStruct || typ == lsp.SKConstant || typ == lsp.SKVariable || typ == lsp.SKTypeParameter
+}
+
+// IsPublicSymbol tells if the symbol is neither private nor protected, which are the only non-public access of scala
+func (c *ScalaSpec) IsPublicSymbol(sym lsp.DocumentSymbol) bool {
+	s := stripComments(sym.Text)
+	for {
+		s = strings.TrimLeft(s, " \t\r\n")
+		if m := annotationRegex.FindString(s); m != "" {
+			s = s[len(m):]
+			if rest := strings.TrimLeft(s, " \t"); strings.HasPrefix(rest, "(") {
+				if end := utils.MatchBracket(rest, 0); end > 0 {
+					s = rest[end+1:]
+				}
+			}
+			continue
+		}
+		m := modifierRegex.FindStringSubmatch(s)
+		if m == nil {
+			return true
+		}
+		if m[1] == "private" || m[1] == "protected" {
+			return false
+		}
+		s = s[len(m[0]):]
+	}
+}
+
+func (c *ScalaSpec) HasImplSymbol() bool {
+	// `extends` and `with` are collected from the type hierarchy, not impl blocks
+	return false
+}
+
+func (c *ScalaSpec) ImplSymbol(sym lsp.DocumentSymbol) (int, int, int) {
+	return -1, -1, -1
+}
+
+// FunctionSymbol splits the tokens of `def name[T](in: A)(using ctx: B): Out = ...`.
+// The receiver is the enclosing class or object, which is not in the signature
+func (c *ScalaSpec) FunctionSymbol(sym lsp.DocumentSymbol) (int, []int, []int, []int) {
+	if sym.Kind != lsp.SKFunction && sym.Kind != lsp.SKMethod {
+		return -1, nil, nil, nil
+	}
+	name := c.DeclareTokenOfSymbol(sym)
+	if name < 0 {
+		return -1, nil, nil, nil
+	}
+	lines := utils.CountLines(sym.Text)
+	offset := func(i int) int {
+		return lsp.RelativePostionWithLines(lines, sym.Location.Range.Start, sym.Tokens[i].Location.Range.Start)
+	}
+
+	// [typeParams) [params) [outputs) in the text
+	text := sym.Text
+	pos := offset(name) + len(sym.Tokens[name].Text)
+	if pos < 0 || pos > len(text) {
+		return -1, nil, nil, nil
+	}
+	skipSpaces := func() {
+		for pos < len(text) && strings.IndexByte(" \t\r\n", text[pos]) >= 0 {
+			pos++
+		}
+	}
+	typeStart, typeEnd := pos, pos
+	skipSpaces()
+	if pos < len(text) && text[pos] == '[' {
+		if end := utils.MatchBracket(text, pos); end > 0 {
+			typeStart, typeEnd, pos = pos, end, end+1
+		}
+	}
+	paramStart, paramEnd := pos, pos
+	for {
+		skipSpaces()
+		if pos >= len(text) || text[pos] != '(' {
+			break
+		}
+		end := utils.MatchBracket(text, pos)
+		if end < 0 {
+			break
+		}
+		paramEnd, pos = end, end+1
+	}
+	outStart, outEnd := pos, pos
+	skipSpaces()
+	if pos < len(text) && text[pos] == ':' {
+		outStart = pos
+		outEnd = len(text)
+		if i := strings.IndexAny(text[pos:], "={"); i >= 0 {
+			outEnd = pos + i
+		}
+	}
+
+	var typeParams, inputs, outputs []int
+	for i := name + 1; i < len(sym.Tokens); i++ {
+		off := offset(i)
+		if off >= outEnd {
+			break
+		}
+		if !c.IsEntityToken(sym.Tokens[i]) {
+			continue
+		}
+		switch {
+		case off > typeStart && off < typeEnd:
+			typeParams = append(typeParams, i)
+		case off > paramStart && off < paramEnd:
+			inputs = append(inputs, i)
+		case off > outStart:
+			outputs = append(outputs, i)
+		}
+	}
+	return -1, typeParams, inputs, outputs
+}
+
+func (c *ScalaSpec) GetUnloadedSymbol(from lsp.Token, define lsp.Location) (string, error) {
+	return "", nil
+}
+
+// AdjustSymbols makes the symbols of metals fit the UniAST: objects are taken as classes,
+// and a companion object is renamed to `Name$` like its JVM class, to be distinguished from its class or trait
+func (c *ScalaSpec) AdjustSymbols(syms []*lsp.DocumentSymbol) {
+	types := map[string]bool{}
+	for _, s := range syms {
+		if s.Kind == lsp.SKClass || s.Kind == lsp.SKInterface || s.Kind == lsp.SKEnum || s.Kind == lsp.SKStruct {
+			types[s.Name] = true
+		}
+	}
+	for _, s := range syms {
+		if s.Kind != lsp.SKModule && s.Kind != lsp.SKObject {
+			continue
+		}
+		s.Kind = lsp.SKClass
+		if types[s.Name] {
+			s.Name += "$"
+		}
+	}
+}
+
+// Annotations parses the annotations at the head of a definition, like `@tailrec` or `@deprecated("use bar", "1.0")`.
+// The contextual modifiers `implicit` and `given` are taken as annotations too, since they change how the definition is used
+func Annotations(content string) []uniast.Annotation {
+	var ret []uniast.Annotation
+	s := stripComments(content)
+	for {
+		s = strings.TrimLeft(s, " \t\r\n")
+		if m := modifierRegex.FindStringSubmatch(s); m != nil {
+			if m[1] == "implicit" || m[1] == "given" {
+				ret = append(ret, uniast.Annotation{Name: m[1]})
+			}
+			s = s[len(m[0]):]
+			continue
+		}
+		m := annotationRegex.FindStringSubmatch(s)
+		if m == nil {
+			break
+		}
+		ann := uniast.Annotation{Name: m[1]}
+		s = s[len(m[0]):]
+		if rest := strings.TrimLeft(s, " \t"); strings.HasPrefix(rest, "(") {
+			if end := utils.MatchBracket(rest, 0); end > 0 {
+				ann.Args = strings.TrimSpace(rest[1:end])
+				s = rest[end+1:]
+			}
+		}
+		ret = append(ret, ann)
+	}
+	return ret
+}
+
+// stripComments drops the leading comments of a definition
+func stripComments(s string) string {
+	for {
+		s = strings.TrimLeft(s, " \t\r\n")
+		if strings.HasPrefix(s, "//") {
+			i := strings.IndexByte(s, '\n')
+			if i < 0 {
+				return ""
+			}
+			s = s[i+1:]
+		} else if strings.HasPrefix(s, "/*") {
+			i := strings.Index(s, "*/")
+			if i < 0 {
+				return ""
+			}
+			s = s[i+2:]
+		} else {
+			return s
+		}
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scala
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	lsp "github.com/cloudwego/abcoder/lang/lsp"
+	"github.com/cloudwego/abcoder/lang/uniast"
+)
+
+func TestPackageOf(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"package a.b\n\nclass A", "a.b"},
+		{"/* license\n */\n// doc\npackage a.b\npackage c\n\nimport x.y\npackage d", "a.b.c"},
+		{"package `type`.x;\nobject A", "type.x"},
+		{"package object util", RootPackage},
+		{"object Main", RootPackage},
+	}
+	for _, tt := range tests {
+		if got := PackageOf(tt.content); got != tt.want {
+			t.Errorf("PackageOf(%q) = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestScalaSpec_WorkSpace(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"build.sbt": `ThisBuild / scalaVersion := "2.13.12"
+lazy val root = (project in file(".")).aggregate(core)
+lazy val core = project.in(file("modules/core")).settings(name := "core")
+`,
+		"modules/core/src/main/scala/a/A.scala": "package a\nclass A\n",
+		"src/main/scala/Main.scala":             "object Main\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	spec := NewScalaSpec()
+	mods, err := spec.WorkSpace(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"root": dir, "core": filepath.Join(dir, "modules/core")}
+	if !reflect.DeepEqual(mods, want) {
+		t.Fatalf("WorkSpace() = %v, want %v", mods, want)
+	}
+
+	for _, tt := range []struct {
+		path, mod, pkg string
+	}{
+		{"modules/core/src/main/scala/a/A.scala", "core", "a"},
+		{"src/main/scala/Main.scala", "root", RootPackage},
+		{".metals/readonly/dependencies/cats-core_2.13-2.9.0-sources.jar/cats/Functor.scala", "cats-core_2.13@2.9.0", RootPackage},
+	} {
+		mod, pkg, err := spec.NameSpace(filepath.Join(dir, tt.path), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mod != tt.mod || pkg != tt.pkg {
+			t.Errorf("NameSpace(%s) = %s, %s, want %s, %s", tt.path, mod, pkg, tt.mod, tt.pkg)
+		}
+	}
+}
+
+func TestScalaSpec_FileImports(t *testing.T) {
+	content := `package a
+
+import scala.collection.mutable
+import cats.{Functor, Monad => M}, cats.syntax.all._ // syntax
+  import b.c.given
+`
+	got, err := NewScalaSpec().FileImports([]byte(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []uniast.Import{
+		{Path: "scala.collection.mutable"},
+		{Path: "cats.{Functor, Monad => M}"},
+		{Path: "cats.syntax.all._"},
+		{Path: "b.c.given"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FileImports() = %v, want %v", got, want)
+	}
+}
+
+func TestAnnotations(t *testing.T) {
+	content := `/** doc */
+@deprecated("use bar", "1.0")
+@inline final implicit def foo(x: Int): String = x.toString`
+	want := []uniast.Annotation{
+		{Name: "deprecated", Args: `"use bar", "1.0"`},
+		{Name: "inline"},
+		{Name: "implicit"},
+	}
+	if got := Annotations(content); !reflect.DeepEqual(got, want) {
+		t.Errorf("Annotations() = %v, want %v", got, want)
+	}
+}
+
+func TestScalaSpec_IsPublicSymbol(t *testing.T) {
+	spec := NewScalaSpec()
+	for text, want := range map[string]bool{
+		"def foo = 1":                           true,
+		"@tailrec private def loop(n: Int) = n": false,
+		"override protected[pkg] val x = 1":     false,
+		"case class A(x: Int)":                  true,
+	} {
+		if got := spec.IsPublicSymbol(lsp.DocumentSymbol{Text: text}); got != want {
+			t.Errorf("IsPublicSymbol(%q) = %v, want %v", text, got, want)
+		}
+	}
+}
+
+func TestScalaSpec_AdjustSymbols(t *testing.T) {
+	syms := []*lsp.DocumentSymbol{
+		{Name: "User", Kind: lsp.SKClass},
+		{Name: "User", Kind: lsp.SKModule},
+		{Name: "Main", Kind: lsp.SKModule},
+		{Name: "apply", Kind: lsp.SKMethod},
+	}
+	NewScalaSpec().AdjustSymbols(syms)
+	var got []string
+	for _, s := range syms {
+		got = append(got, s.Name+":"+s.Kind.String())
+	}
+	want := []string{"User:" + lsp.SKClass.String(), "User$:" + lsp.SKClass.String(), "Main:" + lsp.SKClass.String(), "apply:" + lsp.SKMethod.String()}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("AdjustSymbols() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,687 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scip
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/cloudwego/abcoder/lang/log"
+	"github.com/cloudwego/abcoder/lang/uniast"
+	"github.com/cloudwego/abcoder/lang/utils"
+)
+
+// Options are the options of Convert
+type Options struct {
+	// RepoDir is the dir of the indexed sources, to read the texts of the documents which are not embedded in the index.
+	// Default to the project root of the index
+	RepoDir string
+	// RepoID is the name of the repo, and of the module whose symbols have no package name.
+	// Default to the base name of RepoDir
+	RepoID string
+}
+
+// Import loads the index file and converts it, see LoadIndex and Convert
+func Import(path string, opts Options) (*uniast.Repository, error) {
+	idx, err := LoadIndex(path)
+	if err != nil {
+		return nil, err
+	}
+	return Convert(idx, opts)
+}
+
+// Convert converts the index to a repository.
+//   - The packages (manager, name, version) of the symbols defined in the index are the internal modules,
+//     and the others are the external modules named `name@version`, whose nodes have no content.
+//   - The namespace descriptors of a symbol make the package path (the dir of the file if there is none),
+//     and the rest make the name, like `Foo.Bar` of `Foo#Bar().`.
+//   - Methods and types are functions and types, terms are vars, or fields if their parent is a type.
+//     Locals, parameters and type parameters are omitted.
+//   - The references inside the enclosing range of a definition are its dependencies.
+//     Definitions without enclosing ranges (not reported by some indexers) have no dependencies.
+func Convert(idx *Index, opts Options) (*uniast.Repository, error) {
+	root := opts.RepoDir
+	if root == "" {
+		root = uriPath(idx.ProjectRoot)
+	}
+	if root != "" && !filepath.IsAbs(root) {
+		root, _ = filepath.Abs(root)
+	}
+	id := opts.RepoID
+	if id == "" {
+		if id = filepath.Base(root); root == "" || id == string(filepath.Separator) {
+			id = "repo"
+		}
+	}
+	repo := uniast.NewRepository(id)
+	if root != "" {
+		repo.Path = root
+	}
+	c := &converter{
+		id:    id,
+		repo:  &repo,
+		infos: map[string]*SymbolInformation{},
+		defs:  map[string]*definition{},
+		nodes: map[string]*node{},
+	}
+	for _, info := range idx.ExternalSymbols {
+		c.infos[info.Symbol] = info
+	}
+	for _, d := range idx.Documents {
+		doc := newDocument(d, root)
+		c.docs = append(c.docs, doc)
+		for _, info := range d.Symbols {
+			c.infos[info.Symbol] = info
+		}
+		for _, occ := range d.Occurrences {
+			if occ.Roles&RoleDefinition == 0 || IsLocalSymbol(occ.Symbol) || c.defs[occ.Symbol] != nil {
+				continue
+			}
+			sym, err := ParseSymbol(occ.Symbol)
+			if err != nil {
+				log.Debug("skip the definition of %s: %v\n", occ.Symbol, err)
+				continue
+			}
+			c.defs[occ.Symbol] = &definition{doc: doc, occ: occ, sym: sym}
+		}
+	}
+	if len(c.defs) == 0 {
+		return nil, fmt.Errorf("no definition found in the index")
+	}
+
+	c.createModules()
+	// types first, since the methods and fields are attached to them
+	for _, k := range []nodeKind{kindType, kindFunction, kindVar, kindField} {
+		for _, doc := range c.docs {
+			for _, occ := range doc.Occurrences {
+				if def := c.defs[occ.Symbol]; def != nil && def.occ == occ {
+					if n := c.node(occ.Symbol); n != nil && n.kind == k {
+						c.define(def, n)
+					}
+				}
+			}
+		}
+	}
+	c.createFiles()
+	for _, doc := range c.docs {
+		c.collectReferences(doc)
+	}
+	c.collectImplements()
+	return c.repo, nil
+}
+
+type nodeKind int
+
+const (
+	kindNone nodeKind = iota
+	kindType
+	kindFunction
+	kindVar
+	kindField
+)
+
+// node is the converted node of a symbol
+type node struct {
+	id   uniast.Identity
+	kind nodeKind
+	// owner is the symbol of the type which declares the method or field
+	owner string
+	// method tells the function is a method
+	method   bool
+	internal bool
+}
+
+type definition struct {
+	doc *document
+	occ *Occurrence
+	sym Symbol
+}
+
+type converter struct {
+	id    string
+	repo  *uniast.Repository
+	docs  []*document
+	infos map[string]*SymbolInformation
+	// symbol => the first definition in the index
+	defs map[string]*definition
+	// symbol => converted node, nil if the symbol makes no node
+	nodes map[string]*node
+	// package of internal symbols => module name
+	modules map[Package]string
+}
+
+// createModules creates the internal modules, whose dir is the common dir of the documents defining their symbols
+func (c *converter) createModules() {
+	dirs := map[string]string{}
+	langs := map[string]uniast.Language{}
+	c.modules = map[Package]string{}
+	for _, doc := range c.docs {
+		for _, occ := range doc.Occurrences {
+			def := c.defs[occ.Symbol]
+			if def == nil || def.occ != occ {
+				continue
+			}
+			pkg := def.sym.Package
+			name := pkg.Name
+			if name == "" {
+				name = c.id
+			}
+			c.modules[pkg] = name
+			if doc.module == "" {
+				doc.module = name
+			}
+			doc.isTest = doc.isTest || occ.Roles&RoleTest != 0
+			dir := path.Dir(doc.RelativePath)
+			if prev, ok := dirs[name]; ok {
+				dir = commonDir(prev, dir)
+			}
+			dirs[name] = dir
+			if _, ok := langs[name]; !ok {
+				langs[name] = language(doc.Language)
+			}
+		}
+	}
+	for name, dir := range dirs {
+		mod := uniast.NewModule(name, filepath.FromSlash(dir), langs[name])
+		c.repo.SetModule(name, mod)
+	}
+}
+
+// createFiles adds the documents to the modules of their first definitions
+func (c *converter) createFiles() {
+	for _, doc := range c.docs {
+		if doc.module == "" {
+			continue
+		}
+		f := uniast.NewFile(doc.RelativePath)
+		f.IsTest = doc.isTest
+		for _, occ := range doc.Occurrences {
+			if def := c.defs[occ.Symbol]; def != nil && def.occ == occ {
+				if n := c.node(occ.Symbol); n != nil && n.id.ModPath == doc.module {
+					f.Package = n.id.PkgPath
+					break
+				}
+			}
+		}
+		c.repo.Modules[doc.module].Files[doc.RelativePath] = f
+	}
+}
+
+// language converts the language name of SCIP, the languages without builtin parsers keep their names
+func language(name string) uniast.Language {
+	if lang := uniast.NewLanguage(name); lang != uniast.Unknown {
+		return lang
+	}
+	return uniast.Language(strings.ToLower(name))
+}
+
+func commonDir(a, b string) string {
+	for a != b {
+		if len(a) > len(b) {
+			a = path.Dir(a)
+		} else if len(b) > len(a) {
+			b = path.Dir(b)
+		} else {
+			a, b = path.Dir(a), path.Dir(b)
+		}
+	}
+	return a
+}
+
+// node converts the symbol to a node, creating the external module if the symbol is not defined in the index
+func (c *converter) node(symbol string) *node {
+	if n, ok := c.nodes[symbol]; ok {
+		return n
+	}
+	c.nodes[symbol] = nil
+	if IsLocalSymbol(symbol) {
+		return nil
+	}
+	def := c.defs[symbol]
+	var sym Symbol
+	if def != nil {
+		sym = def.sym
+	} else {
+		var err error
+		if sym, err = ParseSymbol(symbol); err != nil {
+			return nil
+		}
+	}
+	info := c.infos[symbol]
+	n := &node{internal: def != nil}
+	last := len(sym.Descriptors) - 1
+	var parent *Descriptor
+	if last > 0 {
+		parent = &sym.Descriptors[last-1]
+	}
+	switch sym.Descriptors[last].Suffix {
+	case SuffixType:
+		n.kind = kindType
+	case SuffixMethod, SuffixMacro:
+		n.kind = kindFunction
+		if parent != nil && parent.Suffix == SuffixType {
+			n.method = true
+			n.owner = Symbol{Scheme: sym.Scheme, Package: sym.Package, Descriptors: sym.Descriptors[:last]}.String()
+		}
+	case SuffixTerm:
+		n.kind = kindVar
+		if info != nil && (info.Kind == KindFunction || info.Kind == KindMethod) {
+			n.kind = kindFunction
+		} else if parent != nil && parent.Suffix == SuffixType {
+			n.kind = kindField
+			n.owner = Symbol{Scheme: sym.Scheme, Package: sym.Package, Descriptors: sym.Descriptors[:last]}.String()
+		}
+	default:
+		return nil
+	}
+
+	var names, namespaces []string
+	for _, d := range sym.Descriptors {
+		switch {
+		case d.Suffix == SuffixNamespace && len(names) == 0:
+			namespaces = append(namespaces, d.Name)
+		case d.Suffix == SuffixTypeParameter || d.Suffix == SuffixParameter || d.Suffix == SuffixMeta:
+		default:
+			names = append(names, d.Name)
+		}
+	}
+	mod := c.modules[sym.Package]
+	if !n.internal {
+		mod = sym.Package.Name
+		if mod == "" {
+			mod = sym.Scheme
+		}
+		if sym.Package.Version != "" {
+			mod += "@" + sym.Package.Version
+		}
+	}
+	pkg := strings.Join(namespaces, "/")
+	if pkg == "" {
+		if n.internal && path.Dir(def.doc.RelativePath) != "." {
+			pkg = path.Dir(def.doc.RelativePath)
+		} else {
+			pkg = uniast.ModPathName(mod)
+		}
+	}
+	n.id = uniast.NewIdentity(mod, pkg, strings.Join(names, "."))
+	if !n.internal {
+		c.createExternal(n, info)
+	}
+	c.nodes[symbol] = n
+	return n
+}
+
+// createExternal creates the node of the external symbol, without content
+func (c *converter) createExternal(n *node, info *SymbolInformation) {
+	if n.kind == kindField {
+		return
+	}
+	if c.repo.Modules[n.id.ModPath] == nil {
+		c.repo.SetModule(n.id.ModPath, uniast.NewModule(n.id.ModPath, "", uniast.Unknown))
+	}
+	vis := visibility(uniast.Unknown, n.id.Name)
+	switch n.kind {
+	case kindType:
+		c.repo.SetType(n.id, &uniast.Type{Identity: n.id, Exported: vis.IsExported(), Visibility: vis, TypeKind: typeKind(info)})
+	case kindFunction:
+		f := &uniast.Function{Identity: n.id, Exported: vis.IsExported(), Visibility: vis, IsMethod: n.method}
+		if info != nil {
+			f.Signature = info.Signature
+		}
+		c.repo.SetFunction(n.id, f)
+	case kindVar:
+		c.repo.SetVar(n.id, &uniast.Var{Identity: n.id, IsExported: vis.IsExported(), Visibility: vis, IsConst: info != nil && info.Kind == KindConstant})
+	}
+}
+
+func typeKind(info *SymbolInformation) uniast.TypeKind {
+	if info == nil {
+		return uniast.TypeKindStruct
+	}
+	switch info.Kind {
+	case KindInterface, KindTrait, KindProtocol:
+		return uniast.TypeKindInterface
+	case KindEnum:
+		return uniast.TypeKindEnum
+	case KindTypeAlias, KindType:
+		return uniast.TypeKindTypedef
+	default:
+		return uniast.TypeKindStruct
+	}
+}
+
+// visibility tells the visibility of the name by the common conventions, which is not recorded by SCIP:
+// names starting with a lowercase letter (in Go) are package-scoped, `_name` is internal and `__name` is private
+func visibility(lang uniast.Language, name string) uniast.Visibility {
+	name = name[strings.LastIndexByte(name, '.')+1:]
+	r, _ := utf8.DecodeRuneInString(name)
+	switch {
+	case lang == uniast.Golang && !unicode.IsUpper(r):
+		return uniast.VisibilityPackage
+	case r == utf8.RuneError:
+		return uniast.VisibilityPrivate
+	case strings.HasPrefix(name, "__") && !strings.HasSuffix(name, "__"):
+		return uniast.VisibilityPrivate
+	case r == '_' && !strings.HasSuffix(name, "__"):
+		return uniast.VisibilityInternal
+	}
+	return uniast.VisibilityPublic
+}
+
+// define fills the node defined at def into the module
+func (c *converter) define(def *definition, n *node) {
+	fl, content := def.doc.fileLine(def.occ)
+	info := c.infos[def.occ.Symbol]
+	vis := visibility(language(def.doc.Language), n.id.Name)
+	switch n.kind {
+	case kindType:
+		c.repo.SetType(n.id, &uniast.Type{
+			Identity:   n.id,
+			FileLine:   fl,
+			Content:    content,
+			Exported:   vis.IsExported(),
+			Visibility: vis,
+			TypeKind:   typeKind(info),
+		})
+	case kindFunction:
+		f := &uniast.Function{
+			Identity:   n.id,
+			FileLine:   fl,
+			Content:    content,
+			Exported:   vis.IsExported(),
+			Visibility: vis,
+			IsMethod:   n.method,
+			IsTest:     def.occ.Roles&RoleTest != 0,
+		}
+		if info != nil {
+			f.Signature = info.Signature
+			f.IsInterfaceMethod = info.Kind == KindAbstractMethod || info.Kind == KindMethodSpecification
+		}
+		if n.method {
+			if owner := c.node(n.owner); owner != nil && owner.kind == kindType {
+				f.Receiver = &uniast.Receiver{Type: owner.id}
+				if t := c.repo.GetType(owner.id); t != nil {
+					if t.Methods == nil {
+						t.Methods = map[string]uniast.Identity{}
+					}
+					t.Methods[n.id.Name[len(owner.id.Name)+1:]] = n.id
+				}
+			}
+		}
+		c.repo.SetFunction(n.id, f)
+	case kindVar:
+		c.repo.SetVar(n.id, &uniast.Var{
+			Identity:   n.id,
+			FileLine:   fl,
+			Content:    content,
+			IsExported: vis.IsExported(),
+			Visibility: vis,
+			IsConst:    info != nil && info.Kind == KindConstant,
+		})
+	case kindField:
+		owner := c.node(n.owner)
+		if owner == nil || owner.kind != kindType {
+			return
+		}
+		if t := c.repo.GetType(owner.id); t != nil {
+			field := uniast.Field{Name: n.id.Name[len(owner.id.Name)+1:], Line: fl.Line}
+			if info != nil {
+				field.Type = info.Signature
+				field.Doc = strings.Join(info.Documentation, "\n")
+			}
+			t.Fields = append(t.Fields, field)
+		}
+	}
+}
+
+// scope is the enclosing range of a definition
+type scope struct {
+	symbol     string
+	start, end position
+}
+
+type position struct {
+	line, char int32
+}
+
+func (p position) less(o position) bool {
+	return p.line < o.line || p.line == o.line && p.char < o.char
+}
+
+func rangeOf(r []int32) (start, end position, ok bool) {
+	switch len(r) {
+	case 3:
+		return position{r[0], r[1]}, position{r[0], r[2]}, true
+	case 4:
+		return position{r[0], r[1]}, position{r[2], r[3]}, true
+	default:
+		return start, end, false
+	}
+}
+
+// collectReferences adds the references in the document to the innermost definitions enclosing them
+func (c *converter) collectReferences(doc *document) {
+	var scopes []scope
+	for _, occ := range doc.Occurrences {
+		if def := c.defs[occ.Symbol]; def == nil || def.occ != occ || c.node(occ.Symbol) == nil {
+			continue
+		}
+		if start, end, ok := rangeOf(occ.EnclosingRange); ok {
+			scopes = append(scopes, scope{symbol: occ.Symbol, start: start, end: end})
+		}
+	}
+	if len(scopes) == 0 {
+		return
+	}
+	sort.SliceStable(scopes, func(i, j int) bool {
+		if scopes[i].start != scopes[j].start {
+			return scopes[i].start.less(scopes[j].start)
+		}
+		return scopes[j].end.less(scopes[i].end)
+	})
+	for _, occ := range doc.Occurrences {
+		if occ.Roles&RoleDefinition != 0 || IsLocalSymbol(occ.Symbol) {
+			continue
+		}
+		pos, _, ok := rangeOf(occ.Range)
+		if !ok {
+			continue
+		}
+		// the innermost scope is the last one starting before pos which encloses it
+		i := sort.Search(len(scopes), func(i int) bool { return pos.less(scopes[i].start) }) - 1
+		for ; i >= 0; i-- {
+			if pos.less(scopes[i].end) {
+				break
+			}
+		}
+		if i < 0 || scopes[i].symbol == occ.Symbol {
+			continue
+		}
+		target := c.node(occ.Symbol)
+		if target == nil || target.kind == kindField {
+			continue
+		}
+		fl, _ := doc.fileLine(occ)
+		c.addDependency(c.nodes[scopes[i].symbol], target, uniast.NewDependency(target.id, fl))
+	}
+}
+
+func (c *converter) addDependency(from, to *node, dep uniast.Dependency) {
+	switch from.kind {
+	case kindFunction:
+		f := c.repo.GetFunction(from.id)
+		if f == nil {
+			return
+		}
+		switch to.kind {
+		case kindFunction:
+			if to.method {
+				f.MethodCalls = uniast.InsertDependency(f.MethodCalls, dep)
+			} else {
+				f.FunctionCalls = uniast.InsertDependency(f.FunctionCalls, dep)
+			}
+		case kindType:
+			f.Types = uniast.InsertDependency(f.Types, dep)
+		case kindVar:
+			f.GlobalVars = uniast.InsertDependency(f.GlobalVars, dep)
+		}
+	case kindType:
+		if t := c.repo.GetType(from.id); t != nil && to.kind == kindType {
+			t.SubStruct = uniast.InsertDependency(t.SubStruct, dep)
+		}
+	case kindVar:
+		if v := c.repo.GetVar(from.id); v != nil {
+			v.Dependencies = uniast.InsertDependency(v.Dependencies, dep)
+		}
+	}
+}
+
+// collectImplements converts the implementation relationships among types
+func (c *converter) collectImplements() {
+	symbols := make([]string, 0, len(c.defs))
+	for symbol := range c.defs {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	for _, symbol := range symbols {
+		n, info := c.nodes[symbol], c.infos[symbol]
+		if n == nil || n.kind != kindType || info == nil {
+			continue
+		}
+		t := c.repo.GetType(n.id)
+		if t == nil {
+			continue
+		}
+		for _, rel := range info.Relationships {
+			if !rel.IsImplementation {
+				continue
+			}
+			if iface := c.node(rel.Symbol); iface != nil && iface.kind == kindType {
+				t.Implements = uniast.Append(t.Implements, iface.id)
+			}
+		}
+	}
+}
+
+// document is a document with its text
+type document struct {
+	*Document
+	// start offsets of the lines, nil if the text is unavailable
+	lines []int
+	// the module of the first definition in the document
+	module string
+	// if any definition in the document is a test
+	isTest bool
+}
+
+func newDocument(d *Document, root string) *document {
+	doc := &document{Document: d}
+	if d.Text == "" && root != "" {
+		if bs, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(d.RelativePath))); err == nil {
+			d.Text = string(bs)
+		} else {
+			log.Debug("read the text of %s failed: %v\n", d.RelativePath, err)
+		}
+	}
+	if d.Text != "" {
+		doc.lines = utils.CountLines(d.Text)
+	}
+	return doc
+}
+
+// offset converts the position to the byte offset in the text, -1 if the text is unavailable
+func (d *document) offset(p position) int {
+	if d.lines == nil || p.line < 0 {
+		return -1
+	}
+	if int(p.line) >= len(d.lines) {
+		return len(d.Text)
+	}
+	start, end := d.lines[p.line], len(d.Text)
+	if int(p.line)+1 < len(d.lines) {
+		end = d.lines[p.line+1]
+	}
+	line := d.Text[start:end]
+	if d.PositionEncoding == PositionUTF8 {
+		return start + min(int(p.char), len(line))
+	}
+	units := int(p.char)
+	for i, r := range line {
+		if units <= 0 {
+			return start + i
+		}
+		if d.PositionEncoding == PositionUTF32 {
+			units--
+		} else if n := utf16.RuneLen(r); n > 0 {
+			units -= n
+		} else {
+			units--
+		}
+	}
+	return end
+}
+
+// fileLine returns the location and the text of the definition or reference.
+// The text is the enclosing range, or the whole lines of the range if there is no enclosing range
+func (d *document) fileLine(occ *Occurrence) (uniast.FileLine, string) {
+	start, end, ok := rangeOf(occ.EnclosingRange)
+	enclosing := ok
+	if !ok {
+		if start, end, ok = rangeOf(occ.Range); !ok {
+			return uniast.FileLine{File: d.RelativePath}, ""
+		}
+	}
+	fl := uniast.FileLine{
+		File:    d.RelativePath,
+		Line:    int(start.line) + 1,
+		EndLine: int(end.line) + 1,
+	}
+	s, e := d.offset(start), d.offset(end)
+	if s < 0 || e < s {
+		return fl, ""
+	}
+	fl.StartOffset, fl.EndOffset = s, e
+	if occ.Roles&RoleDefinition == 0 {
+		return fl, ""
+	}
+	if !enclosing {
+		s = d.lines[start.line]
+		if e = strings.IndexByte(d.Text[e:], '\n'); e >= 0 {
+			e += fl.EndOffset
+		} else {
+			e = len(d.Text)
+		}
+	}
+	return fl, d.Text[s:e]
+}
+
+// uriPath converts the file URI to the path, or returns the string itself if it is not a URI
+func uriPath(uri string) string {
+	if !strings.HasPrefix(uri, "file://") {
+		return uri
+	}
+	if u, err := url.Parse(uri); err == nil {
+		return filepath.FromSlash(u.Path)
+	}
+	return strings.TrimPrefix(uri, "file://")
+}
@@ -0,0 +1,340 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package scip imports the SCIP and LSIF indexes produced by the Sourcegraph indexers (scip-go, scip-typescript,
+// scip-java, scip-python, lsif-node...) as UniAST repositories, which gives the languages without builtin parsers
+// to the MCP tools and the agents.
+//
+// The definitions are converted to functions, types and vars by the descriptors of their symbols,
+// and the references within the enclosing ranges of the definitions are converted to dependencies.
+// See https://github.com/sourcegraph/scip/blob/main/scip.proto for the index format.
+package scip
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// SymbolRole is the bitset of roles of an occurrence
+type SymbolRole int32
+
+const (
+	RoleDefinition        SymbolRole = 0x1
+	RoleImport            SymbolRole = 0x2
+	RoleWriteAccess       SymbolRole = 0x4
+	RoleReadAccess        SymbolRole = 0x8
+	RoleGenerated         SymbolRole = 0x10
+	RoleTest              SymbolRole = 0x20
+	RoleForwardDefinition SymbolRole = 0x40
+)
+
+// PositionEncoding tells how the characters of ranges count
+type PositionEncoding int32
+
+const (
+	// PositionUnspecified is taken as PositionUTF16, as LSP and LSIF do
+	PositionUnspecified PositionEncoding = 0
+	PositionUTF8        PositionEncoding = 1
+	PositionUTF16       PositionEncoding = 2
+	PositionUTF32       PositionEncoding = 3
+)
+
+// Kind is the kind of a symbol, only the values used by the conversion are listed
+type Kind int32
+
+const (
+	KindUnspecified         Kind = 0
+	KindAbstractMethod      Kind = 66
+	KindClass               Kind = 7
+	KindConstant            Kind = 8
+	KindConstructor         Kind = 9
+	KindEnum                Kind = 11
+	KindEnumMember          Kind = 12
+	KindField               Kind = 15
+	KindFunction            Kind = 17
+	KindInterface           Kind = 21
+	KindMacro               Kind = 25
+	KindMethod              Kind = 26
+	KindMethodSpecification Kind = 67
+	KindNamespace           Kind = 30
+	KindPackage             Kind = 35
+	KindProperty            Kind = 41
+	KindProtocol            Kind = 42
+	KindStaticMethod        Kind = 80
+	KindStaticVariable      Kind = 82
+	KindStruct              Kind = 49
+	KindTrait               Kind = 53
+	KindType                Kind = 54
+	KindTypeAlias           Kind = 55
+	KindTypeParameter       Kind = 58
+	KindUnion               Kind = 59
+	KindVariable            Kind = 61
+)
+
+// Index is a SCIP index, or an LSIF dump converted to the same shape
+type Index struct {
+	// ProjectRoot is the URI of the indexed dir, like `file:///home/me/repo`
+	ProjectRoot string
+	// Tool is the name of the indexer
+	Tool      string
+	Documents []*Document
+	// ExternalSymbols are the symbols defined outside the index but referenced by it
+	ExternalSymbols []*SymbolInformation
+}
+
+// Document is an indexed source file
+type Document struct {
+	// Language is the language name given by the indexer, like `go` or `TypeScript`
+	Language string
+	// RelativePath is the slash-separated path relative to the project root
+	RelativePath string
+	Occurrences  []*Occurrence
+	Symbols      []*SymbolInformation
+	// Text is the content of the file, empty unless the indexer embeds it
+	Text             string
+	PositionEncoding PositionEncoding
+}
+
+// Occurrence is a definition or reference of a symbol in a document
+type Occurrence struct {
+	// Range is [startLine, startCharacter, endLine, endCharacter] or [startLine, startCharacter, endCharacter], 0-based
+	Range  []int32
+	Symbol string
+	Roles  SymbolRole
+	// EnclosingRange is the range of the whole definition (like the body of a function), in the same form as Range
+	EnclosingRange []int32
+}
+
+// SymbolInformation is the metadata of a symbol
+type SymbolInformation struct {
+	Symbol        string
+	Documentation []string
+	Relationships []Relationship
+	Kind          Kind
+	DisplayName   string
+	// Signature is the text of the signature documentation, like `func Foo(a int) error`
+	Signature       string
+	EnclosingSymbol string
+}
+
+// Relationship relates a symbol to another one, like a type to the interface it implements
+type Relationship struct {
+	Symbol           string
+	IsReference      bool
+	IsImplementation bool
+	IsTypeDefinition bool
+	IsDefinition     bool
+}
+
+// LoadIndex reads the index file, which is either a SCIP index (protobuf) or an LSIF dump (JSON lines)
+func LoadIndex(path string) (*Index, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+		return DecodeLSIF(bytes.NewReader(data))
+	}
+	idx, err := DecodeSCIP(data)
+	if err != nil {
+		return nil, fmt.Errorf("decode SCIP index %s: %w", path, err)
+	}
+	return idx, nil
+}
+
+// DecodeSCIP decodes the protobuf message of a SCIP index
+func DecodeSCIP(data []byte) (*Index, error) {
+	idx := &Index{}
+	err := walk(data, func(num protowire.Number, typ protowire.Type, v uint64, bs []byte) error {
+		switch num {
+		case 1: // metadata
+			return walk(bs, func(num protowire.Number, typ protowire.Type, v uint64, bs []byte) error {
+				switch num {
+				case 2: // tool_info
+					return walk(bs, func(num protowire.Number, typ protowire.Type, v uint64, bs []byte) error {
+						if num == 1 {
+							idx.Tool = string(bs)
+						}
+						return nil
+					})
+				case 3:
+					idx.ProjectRoot = string(bs)
+				}
+				return nil
+			})
+		case 2:
+			doc, err := decodeDocument(bs)
+			if err != nil {
+				return err
+			}
+			idx.Documents = append(idx.Documents, doc)
+		case 3:
+			info, err := decodeSymbolInformation(bs)
+			if err != nil {
+				return err
+			}
+			idx.ExternalSymbols = append(idx.ExternalSymbols, info)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return idx, nil
+}
+
+func decodeDocument(data []byte) (*Document, error) {
+	doc := &Document{}
+	err := walk(data, func(num protowire.Number, typ protowire.Type, v uint64, bs []byte) error {
+		switch num {
+		case 1:
+			doc.RelativePath = string(bs)
+		case 2:
+			occ, err := decodeOccurrence(bs)
+			if err != nil {
+				return err
+			}
+			doc.Occurrences = append(doc.Occurrences, occ)
+		case 3:
+			info, err := decodeSymbolInformation(bs)
+			if err != nil {
+				return err
+			}
+			doc.Symbols = append(doc.Symbols, info)
+		case 4:
+			doc.Language = string(bs)
+		case 5:
+			doc.Text = string(bs)
+		case 6:
+			doc.PositionEncoding = PositionEncoding(v)
+		}
+		return nil
+	})
+	return doc, err
+}
+
+func decodeOccurrence(data []byte) (*Occurrence, error) {
+	occ := &Occurrence{}
+	err := walk(data, func(num protowire.Number, typ protowire.Type, v uint64, bs []byte) (err error) {
+		switch num {
+		case 1:
+			occ.Range, err = appendInt32s(occ.Range, typ, v, bs)
+		case 2:
+			occ.Symbol = string(bs)
+		case 3:
+			occ.Roles = SymbolRole(v)
+		case 7:
+			occ.EnclosingRange, err = appendInt32s(occ.EnclosingRange, typ, v, bs)
+		}
+		return err
+	})
+	return occ, err
+}
+
+func decodeSymbolInformation(data []byte) (*SymbolInformation, error) {
+	info := &SymbolInformation{}
+	err := walk(data, func(num protowire.Number, typ protowire.Type, v uint64, bs []byte) error {
+		switch num {
+		case 1:
+			info.Symbol = string(bs)
+		case 3:
+			info.Documentation = append(info.Documentation, string(bs))
+		case 4:
+			var rel Relationship
+			err := walk(bs, func(num protowire.Number, typ protowire.Type, v uint64, bs []byte) error {
+				switch num {
+				case 1:
+					rel.Symbol = string(bs)
+				case 2:
+					rel.IsReference = v != 0
+				case 3:
+					rel.IsImplementation = v != 0
+				case 4:
+					rel.IsTypeDefinition = v != 0
+				case 5:
+					rel.IsDefinition = v != 0
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			info.Relationships = append(info.Relationships, rel)
+		case 5:
+			info.Kind = Kind(v)
+		case 6:
+			info.DisplayName = string(bs)
+		case 7: // signature_documentation is a Document, only its text matters
+			return walk(bs, func(num protowire.Number, typ protowire.Type, v uint64, bs []byte) error {
+				if num == 5 {
+					info.Signature = string(bs)
+				}
+				return nil
+			})
+		case 8:
+			info.EnclosingSymbol = string(bs)
+		}
+		return nil
+	})
+	return info, err
+}
+
+// walk calls fn on each field of the message.
+// v is the value of varint fields, and bs is the payload of length-delimited fields
+func walk(data []byte, fn func(num protowire.Number, typ protowire.Type, v uint64, bs []byte) error) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+		var v uint64
+		var bs []byte
+		switch typ {
+		case protowire.VarintType:
+			v, n = protowire.ConsumeVarint(data)
+		case protowire.BytesType:
+			bs, n = protowire.ConsumeBytes(data)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, data)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+		if err := fn(num, typ, v, bs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendInt32s appends a repeated int32 field, which is either packed or not
+func appendInt32s(dst []int32, typ protowire.Type, v uint64, bs []byte) ([]int32, error) {
+	if typ == protowire.VarintType {
+		return append(dst, int32(v)), nil
+	}
+	for len(bs) > 0 {
+		v, n := protowire.ConsumeVarint(bs)
+		if n < 0 {
+			return dst, protowire.ParseError(n)
+		}
+		dst = append(dst, int32(v))
+		bs = bs[n:]
+	}
+	return dst, nil
+}
@@ -0,0 +1,315 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scip
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/cloudwego/abcoder/lang/lsp"
+)
+
+// lsifID is the id of a vertex, which is either a number or a string
+type lsifID string
+
+func (id *lsifID) UnmarshalJSON(data []byte) error {
+	*id = lsifID(bytes.Trim(data, `"`))
+	return nil
+}
+
+type lsifPosition struct {
+	Line      int32 `json:"line"`
+	Character int32 `json:"character"`
+}
+
+// lsifElement is a vertex or an edge of an LSIF dump, only the used properties are decoded
+type lsifElement struct {
+	ID    lsifID `json:"id"`
+	Type  string `json:"type"`
+	Label string `json:"label"`
+
+	// metaData
+	ProjectRoot string `json:"projectRoot"`
+	ToolInfo    struct {
+		Name string `json:"name"`
+	} `json:"toolInfo"`
+	// document
+	URI        string `json:"uri"`
+	LanguageID string `json:"languageId"`
+	Contents   string `json:"contents"`
+	// range
+	Start lsifPosition `json:"start"`
+	End   lsifPosition `json:"end"`
+	Tag   *struct {
+		Type      string         `json:"type"`
+		Text      string         `json:"text"`
+		Kind      lsp.SymbolKind `json:"kind"`
+		FullRange *struct {
+			Start lsifPosition `json:"start"`
+			End   lsifPosition `json:"end"`
+		} `json:"fullRange"`
+	} `json:"tag"`
+
+	// edges
+	OutV lsifID   `json:"outV"`
+	InV  lsifID   `json:"inV"`
+	InVs []lsifID `json:"inVs"`
+}
+
+type lsifDump struct {
+	root      string
+	tool      string
+	documents []*lsifElement
+	ranges    map[lsifID]*lsifElement
+	// document => ranges
+	contains map[lsifID][]lsifID
+	// range or result set => result set
+	next map[lsifID]lsifID
+	// range or result set => definition result
+	definition map[lsifID]lsifID
+}
+
+// DecodeLSIF decodes the LSIF dump, in JSON lines or a JSON array, to the shape of a SCIP index.
+//
+// LSIF has no qualified names, thus the symbols are made of the definition ranges tagged with symbol kinds
+// (see the `tag` property of range vertices), like `lsif . . . `src/a.ts`/Foo#bar().`, whose namespace is
+// the path of the document, and whose parents are the tagged definitions enclosing them.
+// The untagged definitions and the ones inside functions are taken as locals.
+// Monikers are ignored, so the references to external packages are not kept
+func DecodeLSIF(r io.Reader) (*Index, error) {
+	dump := &lsifDump{
+		ranges:     map[lsifID]*lsifElement{},
+		contains:   map[lsifID][]lsifID{},
+		next:       map[lsifID]lsifID{},
+		definition: map[lsifID]lsifID{},
+	}
+	br := bufio.NewReader(r)
+	dec := json.NewDecoder(br)
+	if first, err := peekNonSpace(br); err != nil {
+		return nil, err
+	} else if first == '[' {
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+	}
+	for dec.More() {
+		var e lsifElement
+		if err := dec.Decode(&e); err != nil {
+			return nil, fmt.Errorf("decode LSIF element: %w", err)
+		}
+		dump.add(&e)
+	}
+	return dump.index(), nil
+}
+
+func peekNonSpace(br *bufio.Reader) (byte, error) {
+	for {
+		c, err := br.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if c != ' ' && c != '\t' && c != '\r' && c != '\n' {
+			return c, br.UnreadByte()
+		}
+	}
+}
+
+func (d *lsifDump) add(e *lsifElement) {
+	switch e.Label {
+	case "metaData":
+		d.root, d.tool = e.ProjectRoot, e.ToolInfo.Name
+	case "document":
+		d.documents = append(d.documents, e)
+	case "range":
+		d.ranges[e.ID] = e
+	case "contains":
+		d.contains[e.OutV] = append(d.contains[e.OutV], e.InVs...)
+	case "next":
+		d.next[e.OutV] = e.InV
+	case "textDocument/definition":
+		d.definition[e.OutV] = e.InV
+	}
+}
+
+// definitionOf follows the next edges of the range to its definition result
+func (d *lsifDump) definitionOf(id lsifID) (lsifID, bool) {
+	for i := 0; i < 100; i++ {
+		if def, ok := d.definition[id]; ok {
+			return def, true
+		}
+		next, ok := d.next[id]
+		if !ok {
+			break
+		}
+		id = next
+	}
+	return "", false
+}
+
+// lsifDescriptor returns the descriptor suffix and the SCIP kind of the LSP symbol kind, false if it makes no symbol
+func lsifDescriptor(kind lsp.SymbolKind) (Suffix, Kind, bool) {
+	switch kind {
+	case lsp.SKModule, lsp.SKNamespace, lsp.SKPackage:
+		return SuffixNamespace, KindNamespace, true
+	case lsp.SKClass:
+		return SuffixType, KindClass, true
+	case lsp.SKInterface:
+		return SuffixType, KindInterface, true
+	case lsp.SKEnum:
+		return SuffixType, KindEnum, true
+	case lsp.SKStruct:
+		return SuffixType, KindStruct, true
+	case lsp.SKMethod:
+		return SuffixMethod, KindMethod, true
+	case lsp.SKConstructor:
+		return SuffixMethod, KindConstructor, true
+	case lsp.SKFunction:
+		return SuffixMethod, KindFunction, true
+	case lsp.SKField:
+		return SuffixTerm, KindField, true
+	case lsp.SKProperty:
+		return SuffixTerm, KindProperty, true
+	case lsp.SKEnumMember:
+		return SuffixTerm, KindEnumMember, true
+	case lsp.SKVariable:
+		return SuffixTerm, KindVariable, true
+	case lsp.SKConstant:
+		return SuffixTerm, KindConstant, true
+	default:
+		return 0, 0, false
+	}
+}
+
+// lsifDefinition is a tagged definition range
+type lsifDefinition struct {
+	rng        *lsifElement
+	start, end position
+	suffix     Suffix
+	kind       Kind
+	symbol     string
+}
+
+func (d *lsifDump) index() *Index {
+	idx := &Index{ProjectRoot: d.root, Tool: d.tool}
+	root := strings.TrimSuffix(d.root, "/") + "/"
+	// definition result => symbol
+	symbols := map[lsifID]string{}
+	// range => the definition it makes
+	defs := map[lsifID]*lsifDefinition{}
+	for _, e := range d.documents {
+		doc := &Document{
+			Language:         e.LanguageID,
+			RelativePath:     strings.TrimPrefix(e.URI, root),
+			PositionEncoding: PositionUTF16,
+		}
+		if e.Contents != "" {
+			if bs, err := base64.StdEncoding.DecodeString(e.Contents); err == nil {
+				doc.Text = string(bs)
+			}
+		}
+		idx.Documents = append(idx.Documents, doc)
+
+		var tagged []*lsifDefinition
+		for _, id := range d.contains[e.ID] {
+			r := d.ranges[id]
+			if r == nil || r.Tag == nil || r.Tag.Type != "definition" || r.Tag.FullRange == nil {
+				continue
+			}
+			suffix, kind, ok := lsifDescriptor(r.Tag.Kind)
+			if !ok {
+				continue
+			}
+			tagged = append(tagged, &lsifDefinition{
+				rng:    r,
+				start:  position{r.Tag.FullRange.Start.Line, r.Tag.FullRange.Start.Character},
+				end:    position{r.Tag.FullRange.End.Line, r.Tag.FullRange.End.Character},
+				suffix: suffix,
+				kind:   kind,
+			})
+		}
+		sort.SliceStable(tagged, func(i, j int) bool {
+			if tagged[i].start != tagged[j].start {
+				return tagged[i].start.less(tagged[j].start)
+			}
+			return tagged[j].end.less(tagged[i].end)
+		})
+		// the enclosing definitions of the current one, outermost first
+		var stack []*lsifDefinition
+		var descs []Descriptor
+		for _, def := range tagged {
+			for len(stack) > 0 && !def.start.less(stack[len(stack)-1].end) {
+				stack = stack[:len(stack)-1]
+				descs = descs[:len(descs)-1]
+			}
+			local := false
+			if len(stack) > 0 {
+				local = stack[len(stack)-1].symbol == "" || stack[len(stack)-1].suffix == SuffixMethod || stack[len(stack)-1].suffix == SuffixTerm
+			}
+			desc := Descriptor{Name: def.rng.Tag.Text, Suffix: def.suffix}
+			stack = append(stack, def)
+			descs = append(descs, desc)
+			if local {
+				continue
+			}
+			sym := Symbol{Scheme: "lsif", Descriptors: append([]Descriptor{{Name: doc.RelativePath, Suffix: SuffixNamespace}}, descs...)}
+			def.symbol = sym.String()
+			if result, ok := d.definitionOf(def.rng.ID); ok {
+				if _, dup := symbols[result]; !dup {
+					symbols[result] = def.symbol
+					defs[def.rng.ID] = def
+				}
+			}
+		}
+	}
+
+	for i, e := range d.documents {
+		doc := idx.Documents[i]
+		for _, id := range d.contains[e.ID] {
+			r := d.ranges[id]
+			if r == nil {
+				continue
+			}
+			result, ok := d.definitionOf(id)
+			if !ok {
+				continue
+			}
+			symbol, ok := symbols[result]
+			if !ok {
+				continue
+			}
+			occ := &Occurrence{
+				Range:  []int32{r.Start.Line, r.Start.Character, r.End.Line, r.End.Character},
+				Symbol: symbol,
+			}
+			if def := defs[id]; def != nil {
+				occ.Roles = RoleDefinition
+				occ.EnclosingRange = []int32{def.start.line, def.start.char, def.end.line, def.end.char}
+				doc.Symbols = append(doc.Symbols, &SymbolInformation{
+					Symbol:      symbol,
+					Kind:        def.kind,
+					DisplayName: r.Tag.Text,
+				})
+			}
+			doc.Occurrences = append(doc.Occurrences, occ)
+		}
+	}
+	return idx
+}
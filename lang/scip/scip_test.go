@@ -0,0 +1,366 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scip
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/abcoder/lang/uniast"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestParseSymbol(t *testing.T) {
+	tests := []struct {
+		symbol string
+		want   Symbol
+	}{
+		{
+			symbol: "scip-go gomod example.com/m v1.0.0 `example.com/m/pkg`/Foo#Get().",
+			want: Symbol{
+				Scheme:  "scip-go",
+				Package: Package{Manager: "gomod", Name: "example.com/m", Version: "v1.0.0"},
+				Descriptors: []Descriptor{
+					{Name: "example.com/m/pkg", Suffix: SuffixNamespace},
+					{Name: "Foo", Suffix: SuffixType},
+					{Name: "Get", Suffix: SuffixMethod},
+				},
+			},
+		},
+		{
+			symbol: "scip-java maven . . com/a/`B``C`#run(+1).(x)",
+			want: Symbol{
+				Scheme:  "scip-java",
+				Package: Package{Manager: "maven"},
+				Descriptors: []Descriptor{
+					{Name: "com", Suffix: SuffixNamespace},
+					{Name: "a", Suffix: SuffixNamespace},
+					{Name: "B`C", Suffix: SuffixType},
+					{Name: "run", Disambiguator: "+1", Suffix: SuffixMethod},
+					{Name: "x", Suffix: SuffixParameter},
+				},
+			},
+		},
+		{
+			symbol: "my  scheme . . . Map#[K]",
+			want: Symbol{
+				Scheme: "my scheme",
+				Descriptors: []Descriptor{
+					{Name: "Map", Suffix: SuffixType},
+					{Name: "K", Suffix: SuffixTypeParameter},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		got, err := ParseSymbol(tt.symbol)
+		if err != nil {
+			t.Fatalf("ParseSymbol(%q) error: %v", tt.symbol, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseSymbol(%q) = %+v, want %+v", tt.symbol, got, tt.want)
+		}
+		if s := got.String(); s != tt.symbol {
+			t.Errorf("String() = %q, want %q", s, tt.symbol)
+		}
+	}
+	for _, s := range []string{"local 1", "a b c", "a b c d Foo", "a b c d `Foo#"} {
+		if _, err := ParseSymbol(s); err == nil {
+			t.Errorf("ParseSymbol(%q) should fail", s)
+		}
+	}
+}
+
+const goText = `package pkg
+
+type Foo struct {
+	Bar int
+}
+
+func (f *Foo) Get() int {
+	return f.Bar + Max
+}
+
+const Max = 10
+
+func New() *Foo {
+	fmt.Println()
+	return &Foo{}
+}
+`
+
+const goPrefix = "scip-go gomod example.com/m v1.0.0 `example.com/m/pkg`/"
+
+// encodeIndex encodes the index as SCIP protobuf, the counterpart of DecodeSCIP
+func encodeIndex(idx *Index) []byte {
+	message := func(b []byte, num protowire.Number, m []byte) []byte {
+		b = protowire.AppendTag(b, num, protowire.BytesType)
+		return protowire.AppendBytes(b, m)
+	}
+	str := func(b []byte, num protowire.Number, s string) []byte {
+		if s == "" {
+			return b
+		}
+		return message(b, num, []byte(s))
+	}
+	varint := func(b []byte, num protowire.Number, v int32) []byte {
+		if v == 0 {
+			return b
+		}
+		b = protowire.AppendTag(b, num, protowire.VarintType)
+		return protowire.AppendVarint(b, uint64(v))
+	}
+	packed := func(b []byte, num protowire.Number, vs []int32) []byte {
+		var p []byte
+		for _, v := range vs {
+			p = protowire.AppendVarint(p, uint64(v))
+		}
+		return message(b, num, p)
+	}
+	info := func(info *SymbolInformation) []byte {
+		var b []byte
+		b = str(b, 1, info.Symbol)
+		for _, rel := range info.Relationships {
+			var r []byte
+			r = str(r, 1, rel.Symbol)
+			if rel.IsImplementation {
+				r = varint(r, 3, 1)
+			}
+			b = message(b, 4, r)
+		}
+		b = varint(b, 5, int32(info.Kind))
+		if info.Signature != "" {
+			b = message(b, 7, str(nil, 5, info.Signature))
+		}
+		return b
+	}
+
+	var b []byte
+	b = message(b, 1, str(nil, 3, idx.ProjectRoot))
+	for _, doc := range idx.Documents {
+		var d []byte
+		d = str(d, 1, doc.RelativePath)
+		for _, occ := range doc.Occurrences {
+			var o []byte
+			o = packed(o, 1, occ.Range)
+			o = str(o, 2, occ.Symbol)
+			o = varint(o, 3, int32(occ.Roles))
+			if len(occ.EnclosingRange) > 0 {
+				o = packed(o, 7, occ.EnclosingRange)
+			}
+			d = message(d, 2, o)
+		}
+		for _, s := range doc.Symbols {
+			d = message(d, 3, info(s))
+		}
+		d = str(d, 4, doc.Language)
+		d = str(d, 5, doc.Text)
+		b = message(b, 2, d)
+	}
+	for _, s := range idx.ExternalSymbols {
+		b = message(b, 3, info(s))
+	}
+	return b
+}
+
+func testIndex() *Index {
+	return &Index{
+		ProjectRoot: "file:///tmp/m",
+		Documents: []*Document{{
+			Language:     "go",
+			RelativePath: "pkg/a.go",
+			Text:         goText,
+			Occurrences: []*Occurrence{
+				{Range: []int32{2, 5, 8}, Symbol: goPrefix + "Foo#", Roles: RoleDefinition, EnclosingRange: []int32{2, 0, 4, 1}},
+				{Range: []int32{3, 1, 4}, Symbol: goPrefix + "Foo#Bar.", Roles: RoleDefinition},
+				{Range: []int32{6, 6, 7}, Symbol: "local 0", Roles: RoleDefinition},
+				{Range: []int32{6, 9, 12}, Symbol: goPrefix + "Foo#"},
+				{Range: []int32{6, 14, 17}, Symbol: goPrefix + "Foo#Get().", Roles: RoleDefinition, EnclosingRange: []int32{6, 0, 8, 1}},
+				{Range: []int32{7, 8, 9}, Symbol: "local 0"},
+				{Range: []int32{7, 10, 13}, Symbol: goPrefix + "Foo#Bar."},
+				{Range: []int32{7, 16, 19}, Symbol: goPrefix + "Max."},
+				{Range: []int32{10, 6, 9}, Symbol: goPrefix + "Max.", Roles: RoleDefinition, EnclosingRange: []int32{10, 0, 10, 14}},
+				{Range: []int32{12, 5, 8}, Symbol: goPrefix + "New().", Roles: RoleDefinition, EnclosingRange: []int32{12, 0, 15, 1}},
+				{Range: []int32{12, 12, 15}, Symbol: goPrefix + "Foo#"},
+				{Range: []int32{13, 1, 4}, Symbol: "scip-go gomod std go1.22 `fmt`/"},
+				{Range: []int32{13, 5, 12}, Symbol: "scip-go gomod std go1.22 `fmt`/Println()."},
+				{Range: []int32{14, 9, 12}, Symbol: goPrefix + "Foo#"},
+			},
+			Symbols: []*SymbolInformation{
+				{Symbol: goPrefix + "Foo#", Kind: KindStruct, Relationships: []Relationship{{Symbol: "scip-go gomod std go1.22 `fmt`/Stringer#", IsImplementation: true}}},
+				{Symbol: goPrefix + "Foo#Bar.", Kind: KindField, Signature: "int"},
+				{Symbol: goPrefix + "Foo#Get().", Kind: KindMethod, Signature: "func (f *Foo) Get() int"},
+				{Symbol: goPrefix + "Max.", Kind: KindConstant},
+			},
+		}},
+		ExternalSymbols: []*SymbolInformation{
+			{Symbol: "scip-go gomod std go1.22 `fmt`/Println().", Kind: KindFunction, Signature: "func Println(a ...any) (n int, err error)"},
+			{Symbol: "scip-go gomod std go1.22 `fmt`/Stringer#", Kind: KindInterface},
+		},
+	}
+}
+
+func TestDecodeSCIP(t *testing.T) {
+	want := testIndex()
+	got, err := DecodeSCIP(encodeIndex(want))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DecodeSCIP() = %+v, want %+v", got, want)
+	}
+}
+
+func TestConvert(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "index.scip")
+	if err := os.WriteFile(path, encodeIndex(testIndex()), 0644); err != nil {
+		t.Fatal(err)
+	}
+	repo, err := Import(path, Options{RepoID: "m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repo.Name != "m" || repo.Path != "/tmp/m" {
+		t.Errorf("repo = %s at %s", repo.Name, repo.Path)
+	}
+	mod := repo.Modules["example.com/m"]
+	if mod == nil || mod.Dir != "pkg" || mod.Language != uniast.Golang {
+		t.Fatalf("internal module = %+v", mod)
+	}
+	if f := mod.Files["pkg/a.go"]; f == nil || f.Package != "example.com/m/pkg" {
+		t.Errorf("file = %+v", f)
+	}
+	ext := repo.Modules["std@go1.22"]
+	if ext == nil || !ext.IsExternal() {
+		t.Fatalf("external module = %+v", ext)
+	}
+
+	id := func(mod, name string) uniast.Identity {
+		if mod == "std@go1.22" {
+			return uniast.NewIdentity(mod, "fmt", name)
+		}
+		return uniast.NewIdentity(mod, "example.com/m/pkg", name)
+	}
+	foo := repo.GetType(id("example.com/m", "Foo"))
+	if foo == nil {
+		t.Fatal("type Foo not found")
+	}
+	if foo.Content != "type Foo struct {\n\tBar int\n}" || foo.Line != 3 || foo.EndLine != 5 || !strings.HasPrefix(goText[foo.StartOffset:], "type Foo") {
+		t.Errorf("Foo = %q at %+v", foo.Content, foo.FileLine)
+	}
+	if len(foo.Fields) != 1 || foo.Fields[0].Name != "Bar" || foo.Fields[0].Type != "int" || foo.Fields[0].Line != 4 {
+		t.Errorf("Foo.Fields = %+v", foo.Fields)
+	}
+	if foo.Methods["Get"] != id("example.com/m", "Foo.Get") {
+		t.Errorf("Foo.Methods = %+v", foo.Methods)
+	}
+	if !reflect.DeepEqual(foo.Implements, []uniast.Identity{id("std@go1.22", "Stringer")}) {
+		t.Errorf("Foo.Implements = %+v", foo.Implements)
+	}
+	if s := repo.GetType(id("std@go1.22", "Stringer")); s == nil || s.TypeKind != uniast.TypeKindInterface {
+		t.Errorf("Stringer = %+v", s)
+	}
+
+	get := repo.GetFunction(id("example.com/m", "Foo.Get"))
+	if get == nil || !get.IsMethod || get.Receiver == nil || get.Receiver.Type != foo.Identity || get.Signature != "func (f *Foo) Get() int" {
+		t.Fatalf("Foo.Get = %+v", get)
+	}
+	if len(get.GlobalVars) != 1 || get.GlobalVars[0].Identity != id("example.com/m", "Max") || get.GlobalVars[0].Line != 8 {
+		t.Errorf("Foo.Get.GlobalVars = %+v", get.GlobalVars)
+	}
+	if len(get.Types) != 1 || get.Types[0].Identity != foo.Identity {
+		t.Errorf("Foo.Get.Types = %+v", get.Types)
+	}
+
+	max := repo.GetVar(id("example.com/m", "Max"))
+	if max == nil || !max.IsConst || !max.IsExported || max.Visibility != uniast.VisibilityPublic || max.Content != "const Max = 10" {
+		t.Errorf("Max = %+v", max)
+	}
+
+	fn := repo.GetFunction(id("example.com/m", "New"))
+	if fn == nil || fn.IsMethod || !fn.Exported {
+		t.Fatalf("New = %+v", fn)
+	}
+	if len(fn.Types) != 1 || fn.Types[0].Identity != foo.Identity || fn.Types[0].Line != 13 {
+		t.Errorf("New.Types = %+v", fn.Types)
+	}
+	if len(fn.FunctionCalls) != 1 || fn.FunctionCalls[0].Identity != id("std@go1.22", "Println") {
+		t.Errorf("New.FunctionCalls = %+v", fn.FunctionCalls)
+	}
+	if p := repo.GetFunction(id("std@go1.22", "Println")); p == nil || p.Signature != "func Println(a ...any) (n int, err error)" {
+		t.Errorf("Println = %+v", p)
+	}
+	if err := repo.BuildGraph(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+const testLSIF = `{"id":1,"type":"vertex","label":"metaData","version":"0.4.3","projectRoot":"file:///tmp/ts","positionEncoding":"utf-16","toolInfo":{"name":"lsif-tsc"}}
+{"id":2,"type":"vertex","label":"document","uri":"file:///tmp/ts/src/a.ts","languageId":"typescript","contents":"ZXhwb3J0IGNsYXNzIEEgewogIHJ1bigpOiBudW1iZXIgeyByZXR1cm4gaGVscGVyKCk7IH0KfQpleHBvcnQgZnVuY3Rpb24gaGVscGVyKCk6IG51bWJlciB7IHJldHVybiAxOyB9Cg=="}
+{"id":3,"type":"vertex","label":"resultSet"}
+{"id":4,"type":"vertex","label":"range","start":{"line":0,"character":13},"end":{"line":0,"character":14},"tag":{"type":"definition","text":"A","kind":5,"fullRange":{"start":{"line":0,"character":0},"end":{"line":2,"character":1}}}}
+{"id":5,"type":"edge","label":"next","outV":4,"inV":3}
+{"id":6,"type":"vertex","label":"definitionResult"}
+{"id":7,"type":"edge","label":"textDocument/definition","outV":3,"inV":6}
+{"id":8,"type":"edge","label":"item","outV":6,"inVs":[4],"document":2}
+{"id":9,"type":"vertex","label":"resultSet"}
+{"id":10,"type":"vertex","label":"range","start":{"line":1,"character":2},"end":{"line":1,"character":5},"tag":{"type":"definition","text":"run","kind":6,"fullRange":{"start":{"line":1,"character":2},"end":{"line":1,"character":36}}}}
+{"id":11,"type":"edge","label":"next","outV":10,"inV":9}
+{"id":12,"type":"vertex","label":"definitionResult"}
+{"id":13,"type":"edge","label":"textDocument/definition","outV":9,"inV":12}
+{"id":14,"type":"vertex","label":"resultSet"}
+{"id":15,"type":"vertex","label":"range","start":{"line":3,"character":16},"end":{"line":3,"character":22},"tag":{"type":"definition","text":"helper","kind":12,"fullRange":{"start":{"line":3,"character":0},"end":{"line":3,"character":46}}}}
+{"id":16,"type":"edge","label":"next","outV":15,"inV":14}
+{"id":17,"type":"vertex","label":"definitionResult"}
+{"id":18,"type":"edge","label":"textDocument/definition","outV":14,"inV":17}
+{"id":19,"type":"vertex","label":"range","start":{"line":1,"character":25},"end":{"line":1,"character":31},"tag":{"type":"reference","text":"helper"}}
+{"id":20,"type":"edge","label":"next","outV":19,"inV":14}
+{"id":21,"type":"edge","label":"contains","outV":2,"inVs":[4,10,15,19]}
+`
+
+func TestDecodeLSIF(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "dump.lsif")
+	if err := os.WriteFile(path, []byte(testLSIF), 0644); err != nil {
+		t.Fatal(err)
+	}
+	repo, err := Import(path, Options{RepoID: "ts"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mod := repo.Modules["ts"]
+	if mod == nil || mod.Language != uniast.TypeScript || mod.Dir != "src" {
+		t.Fatalf("module = %+v", mod)
+	}
+	id := func(name string) uniast.Identity { return uniast.NewIdentity("ts", "src/a.ts", name) }
+	a := repo.GetType(id("A"))
+	if a == nil || a.Methods["run"] != id("A.run") || !strings.HasPrefix(a.Content, "export class A {") {
+		t.Fatalf("A = %+v", a)
+	}
+	run := repo.GetFunction(id("A.run"))
+	if run == nil || !run.IsMethod || run.Content != "run(): number { return helper(); }" {
+		t.Fatalf("A.run = %+v", run)
+	}
+	if len(run.FunctionCalls) != 1 || run.FunctionCalls[0].Identity != id("helper") || run.FunctionCalls[0].Line != 2 {
+		t.Errorf("A.run.FunctionCalls = %+v", run.FunctionCalls)
+	}
+	if helper := repo.GetFunction(id("helper")); helper == nil || helper.Line != 4 {
+		t.Errorf("helper = %+v", helper)
+	}
+}
@@ -0,0 +1,235 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scip
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Suffix is the kind of a descriptor
+type Suffix byte
+
+const (
+	SuffixNamespace     Suffix = '/'
+	SuffixType          Suffix = '#'
+	SuffixTerm          Suffix = '.'
+	SuffixMethod        Suffix = '('
+	SuffixTypeParameter Suffix = '['
+	SuffixParameter     Suffix = ')'
+	SuffixMeta          Suffix = ':'
+	SuffixMacro         Suffix = '!'
+)
+
+// Descriptor is a component of the fully qualified name of a symbol
+type Descriptor struct {
+	Name string
+	// Disambiguator tells the overloads of methods apart
+	Disambiguator string
+	Suffix        Suffix
+}
+
+// Package is the package which defines a symbol, the placeholder `.` of the fields is kept as empty
+type Package struct {
+	Manager string
+	Name    string
+	Version string
+}
+
+// Symbol is a parsed global symbol, like `scip-go gomod github.com/a/b v1.0.0 `github.com/a/b/c`/Foo#Bar().`
+type Symbol struct {
+	Scheme      string
+	Package     Package
+	Descriptors []Descriptor
+}
+
+// IsLocalSymbol tells if the symbol is local to a document, like `local 3`
+func IsLocalSymbol(sym string) bool {
+	return strings.HasPrefix(sym, "local ")
+}
+
+// ParseSymbol parses the global symbol, see the grammar in scip.proto
+func ParseSymbol(sym string) (Symbol, error) {
+	var ret Symbol
+	if IsLocalSymbol(sym) {
+		return ret, fmt.Errorf("local symbol: %s", sym)
+	}
+	rest := sym
+	var fields [4]string
+	for i := range fields {
+		var ok bool
+		if fields[i], rest, ok = cutSpace(rest); !ok {
+			return ret, fmt.Errorf("invalid symbol: %s", sym)
+		}
+	}
+	ret.Scheme = fields[0]
+	ret.Package = Package{Manager: placeholder(fields[1]), Name: placeholder(fields[2]), Version: placeholder(fields[3])}
+	for rest != "" {
+		d, n, err := parseDescriptor(rest)
+		if err != nil {
+			return ret, fmt.Errorf("invalid symbol %s: %w", sym, err)
+		}
+		ret.Descriptors = append(ret.Descriptors, d)
+		rest = rest[n:]
+	}
+	if len(ret.Descriptors) == 0 {
+		return ret, fmt.Errorf("invalid symbol without descriptors: %s", sym)
+	}
+	return ret, nil
+}
+
+// cutSpace cuts the field ending with a single space, double spaces are escaped spaces
+func cutSpace(s string) (field, rest string, ok bool) {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != ' ' {
+			sb.WriteByte(s[i])
+			continue
+		}
+		if i+1 < len(s) && s[i+1] == ' ' {
+			sb.WriteByte(' ')
+			i++
+			continue
+		}
+		return sb.String(), s[i+1:], true
+	}
+	return "", "", false
+}
+
+func placeholder(s string) string {
+	if s == "." {
+		return ""
+	}
+	return s
+}
+
+// parseDescriptor parses the leading descriptor of s, returns the number of bytes consumed
+func parseDescriptor(s string) (Descriptor, int, error) {
+	var d Descriptor
+	switch s[0] {
+	case '[':
+		name, n, err := parseName(s[1:])
+		if err != nil {
+			return d, 0, err
+		}
+		if 1+n >= len(s) || s[1+n] != ']' {
+			return d, 0, fmt.Errorf("unclosed type parameter: %s", s)
+		}
+		d.Name, d.Suffix = name, SuffixTypeParameter
+		return d, n + 2, nil
+	case '(':
+		name, n, err := parseName(s[1:])
+		if err != nil {
+			return d, 0, err
+		}
+		if 1+n >= len(s) || s[1+n] != ')' {
+			return d, 0, fmt.Errorf("unclosed parameter: %s", s)
+		}
+		d.Name, d.Suffix = name, SuffixParameter
+		return d, n + 2, nil
+	}
+	name, n, err := parseName(s)
+	if err != nil {
+		return d, 0, err
+	}
+	if n >= len(s) {
+		return d, 0, fmt.Errorf("missing descriptor suffix: %s", s)
+	}
+	d.Name = name
+	switch c := s[n]; c {
+	case '/', '#', '.', ':', '!':
+		d.Suffix = Suffix(c)
+		return d, n + 1, nil
+	case '(':
+		end := strings.Index(s[n:], ").")
+		if end < 0 {
+			return d, 0, fmt.Errorf("unclosed method: %s", s)
+		}
+		d.Disambiguator, d.Suffix = s[n+1:n+end], SuffixMethod
+		return d, n + end + 2, nil
+	default:
+		return d, 0, fmt.Errorf("unknown descriptor suffix %q: %s", c, s)
+	}
+}
+
+// parseName parses the leading simple or backtick-escaped identifier
+func parseName(s string) (string, int, error) {
+	if s != "" && s[0] == '`' {
+		var sb strings.Builder
+		for i := 1; i < len(s); i++ {
+			if s[i] != '`' {
+				sb.WriteByte(s[i])
+				continue
+			}
+			if i+1 < len(s) && s[i+1] == '`' {
+				sb.WriteByte('`')
+				i++
+				continue
+			}
+			return sb.String(), i + 1, nil
+		}
+		return "", 0, fmt.Errorf("unclosed escaped identifier: %s", s)
+	}
+	i := 0
+	for i < len(s) && isIdentChar(s[i]) {
+		i++
+	}
+	if i == 0 {
+		return "", 0, fmt.Errorf("empty identifier: %s", s)
+	}
+	return s[:i], i, nil
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c == '+' || c == '-' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// String formats the symbol back, escaping the names as needed
+func (s Symbol) String() string {
+	var sb strings.Builder
+	for _, f := range []string{s.Scheme, s.Package.Manager, s.Package.Name, s.Package.Version} {
+		if f == "" {
+			f = "."
+		}
+		sb.WriteString(strings.ReplaceAll(f, " ", "  "))
+		sb.WriteByte(' ')
+	}
+	for _, d := range s.Descriptors {
+		switch d.Suffix {
+		case SuffixTypeParameter:
+			sb.WriteString("[" + escapeName(d.Name) + "]")
+		case SuffixParameter:
+			sb.WriteString("(" + escapeName(d.Name) + ")")
+		case SuffixMethod:
+			sb.WriteString(escapeName(d.Name) + "(" + d.Disambiguator + ").")
+		default:
+			sb.WriteString(escapeName(d.Name))
+			sb.WriteByte(byte(d.Suffix))
+		}
+	}
+	return sb.String()
+}
+
+func escapeName(name string) string {
+	for i := 0; i < len(name); i++ {
+		if !isIdentChar(name[i]) {
+			return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+		}
+	}
+	if name == "" {
+		return "``"
+	}
+	return name
+}
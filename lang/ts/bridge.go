@@ -0,0 +1,210 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ts bridges the TypeScript parser `abcoder-ts-parser`, which runs as a node subprocess
+// and talks with abcoder through length-prefixed JSON messages over a Unix Domain Socket.
+package ts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/cloudwego/abcoder/lang/log"
+	"github.com/cloudwego/abcoder/lang/progress"
+	"github.com/cloudwego/abcoder/lang/uniast"
+	"github.com/cloudwego/abcoder/version"
+	"github.com/google/uuid"
+)
+
+const (
+	// ParserName is the npm package and the executable of the parser
+	ParserName = "abcoder-ts-parser"
+
+	// DefaultConnectTimeout is the timeout for the parser to connect
+	DefaultConnectTimeout = 30 * time.Second
+)
+
+type Options struct {
+	// ParserPath is the parser executable, searched in PATH and installed by npm if empty
+	ParserPath string
+	// tsconfig path
+	TSConfig string
+	// srcDir paths
+	SrcDirs []string
+	// files or directories to skip, relative to the repo if not absolute
+	Excludes []string
+	// RepoID overrides the repository id
+	RepoID string
+	// Progress receives the count of parsed modules, can be nil
+	Progress progress.Reporter
+	// ConnectTimeout is the timeout for the parser to connect, DefaultConnectTimeout if zero
+	ConnectTimeout time.Duration
+}
+
+// ParseRepo parses the TypeScript repo by the parser subprocess, and returns the decoded repository
+func ParseRepo(ctx context.Context, repoPath string, opts Options) (*uniast.Repository, error) {
+	parser, err := findParser(opts.ParserPath)
+	if err != nil {
+		return nil, err
+	}
+	timeout := opts.ConnectTimeout
+	if timeout == 0 {
+		timeout = DefaultConnectTimeout
+	}
+
+	sock := filepath.Join(os.TempDir(), fmt.Sprintf("ts-parser-%s.sock", uuid.New().String()[:8]))
+	ln, err := net.ListenUnix("unix", &net.UnixAddr{Name: sock, Net: "unix"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Unix socket listener: %w", err)
+	}
+	defer func() {
+		ln.Close()
+		os.Remove(sock)
+	}()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, parser, "serve", "--uds", sock)
+	cmd.Env = append(os.Environ(),
+		"NODE_OPTIONS=--max-old-space-size=65536",
+		"ABCODER_TOOL_VERSION="+version.Version,
+		"ABCODER_AST_VERSION="+uniast.Version,
+	)
+	// keep stdout clean for the AST output
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	log.Info("start %s serving on %s\n", parser, sock)
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", ParserName, err)
+	}
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+	defer func() {
+		cancel()
+		<-exited
+	}()
+
+	if err := ln.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, err
+	}
+	conn, err := ln.AcceptUnix()
+	if err != nil {
+		select {
+		case err := <-exited:
+			return nil, fmt.Errorf("%s exited before connecting: %v", ParserName, err)
+		default:
+			return nil, fmt.Errorf("failed to accept connection: %w", err)
+		}
+	}
+	defer conn.Close()
+	// unblock the reading once canceled
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	return request(ctx, conn, repoPath, opts)
+}
+
+// request sends the parse request, and reads the responses until the result or an error
+func request(ctx context.Context, conn net.Conn, repoPath string, opts Options) (*uniast.Repository, error) {
+	reqID := uuid.New().String()
+	req := ParseRequest{
+		RepoPath: repoPath,
+		TSConfig: opts.TSConfig,
+		SrcDirs:  opts.SrcDirs,
+		Excludes: opts.Excludes,
+		RepoID:   opts.RepoID,
+	}
+	if err := WriteMessage(conn, TypeParseRequest, reqID, req); err != nil {
+		return nil, fmt.Errorf("failed to write parse request: %w", err)
+	}
+
+	var tracker *progress.Tracker
+	defer func() { tracker.Finish() }()
+	done := 0
+	var result bytes.Buffer
+	for {
+		msg, err := ReadMessage(conn)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, fmt.Errorf("failed to read response: %w", err)
+		}
+		if msg.RequestId != reqID {
+			continue
+		}
+		switch msg.Type {
+		case TypeProgress:
+			var p Progress
+			if err := json.Unmarshal(msg.Payload, &p); err != nil {
+				return nil, fmt.Errorf("failed to decode progress: %w", err)
+			}
+			if tracker == nil {
+				tracker = progress.NewTracker(opts.Progress, progress.PhaseModule, p.Total)
+			}
+			for ; done < p.Done; done++ {
+				tracker.Add(p.Item)
+			}
+		case TypeResultChunk:
+			var chunk string
+			if err := json.Unmarshal(msg.Payload, &chunk); err != nil {
+				return nil, fmt.Errorf("failed to decode result chunk: %w", err)
+			}
+			result.WriteString(chunk)
+		case TypeResultEnd:
+			var repo uniast.Repository
+			if err := json.Unmarshal(result.Bytes(), &repo); err != nil {
+				return nil, fmt.Errorf("failed to decode repository: %w", err)
+			}
+			normalizeOffsets(repoPath, &repo)
+			return &repo, nil
+		case TypeError:
+			var e ErrorInfo
+			_ = json.Unmarshal(msg.Payload, &e)
+			return nil, fmt.Errorf("%s failed: %s", ParserName, e.Message)
+		default:
+			log.Debug("unknown message type from %s: %s\n", ParserName, msg.Type)
+		}
+	}
+}
+
+// findParser returns the parser executable, installs it by npm if not found
+func findParser(path string) (string, error) {
+	if path != "" {
+		return path, nil
+	}
+	path, err := exec.LookPath(ParserName)
+	if err == nil {
+		return path, nil
+	}
+	log.Info("%s not found, installing...\n", ParserName)
+	cmd := exec.Command("npm", "install", "-g", ParserName)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("failed to install %s: %v", ParserName, err)
+	}
+	path, err = exec.LookPath(ParserName)
+	if err != nil {
+		return "", fmt.Errorf("failed to find %s after installation: %v", ParserName, err)
+	}
+	return path, nil
+}
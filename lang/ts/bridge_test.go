@@ -0,0 +1,148 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudwego/abcoder/lang/progress"
+	"github.com/cloudwego/abcoder/lang/uniast"
+)
+
+func TestProtocolRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	req := ParseRequest{RepoPath: "/repo", Excludes: []string{"dist"}, RepoID: "x"}
+	if err := WriteMessage(&buf, TypeParseRequest, "1", req); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteMessage(&buf, TypeResultEnd, "1", nil); err != nil {
+		t.Fatal(err)
+	}
+	msg, err := ReadMessage(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got ParseRequest
+	if err := json.Unmarshal(msg.Payload, &got); err != nil || msg.Type != TypeParseRequest || got.RepoID != "x" || got.Excludes[0] != "dist" {
+		t.Errorf("message = %+v, payload %+v, err %v", msg, got, err)
+	}
+	if msg, err := ReadMessage(&buf); err != nil || msg.Type != TypeResultEnd || msg.Payload != nil {
+		t.Errorf("message = %+v, err %v", msg, err)
+	}
+	if _, err := ReadMessage(&buf); err != io.EOF {
+		t.Errorf("expect EOF, got %v", err)
+	}
+}
+
+// TestMain runs the test binary as a fake parser if asked
+func TestMain(m *testing.M) {
+	if os.Getenv("ABCODER_FAKE_TS_PARSER") == "1" {
+		fakeParser()
+		return
+	}
+	os.Exit(m.Run())
+}
+
+// fakeParser serves like `abcoder-ts-parser serve --uds <socket>`
+func fakeParser() {
+	conn, err := net.Dial("unix", os.Args[len(os.Args)-1])
+	if err != nil {
+		os.Exit(1)
+	}
+	defer conn.Close()
+	msg, err := ReadMessage(conn)
+	if err != nil {
+		os.Exit(2)
+	}
+	var req ParseRequest
+	_ = json.Unmarshal(msg.Payload, &req)
+	if req.RepoPath == "fail" {
+		_ = WriteMessage(conn, TypeError, msg.RequestId, ErrorInfo{Message: "boom"})
+		return
+	}
+	for i := 1; i <= 2; i++ {
+		_ = WriteMessage(conn, TypeProgress, msg.RequestId, Progress{Done: i, Total: 2, Item: "m"})
+	}
+	repo := uniast.NewRepository(req.RepoID)
+	bs, _ := json.Marshal(repo)
+	half := len(bs) / 2
+	_ = WriteMessage(conn, TypeResultChunk, msg.RequestId, string(bs[:half]))
+	_ = WriteMessage(conn, TypeResultChunk, msg.RequestId, string(bs[half:]))
+	_ = WriteMessage(conn, TypeResultEnd, msg.RequestId, nil)
+}
+
+func TestParseRepo(t *testing.T) {
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("ABCODER_FAKE_TS_PARSER", "1")
+
+	var events []progress.Event
+	repo, err := ParseRepo(context.Background(), filepath.Join("a", "b"), Options{
+		ParserPath: exe,
+		RepoID:     "myrepo",
+		Progress:   func(e progress.Event) { events = append(events, e) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repo.Name != "myrepo" {
+		t.Errorf("repo = %+v", repo)
+	}
+	if len(events) == 0 || events[len(events)-1].Done != 2 || events[len(events)-1].Phase != progress.PhaseModule {
+		t.Errorf("events = %+v", events)
+	}
+
+	if _, err := ParseRepo(context.Background(), "fail", Options{ParserPath: exe}); err == nil || err.Error() != ParserName+" failed: boom" {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestNormalizeOffsets(t *testing.T) {
+	dir := t.TempDir()
+	// `const s = "😀";` takes 16 UTF-16 code units but 18 bytes
+	text := "const s = \"😀\";\nexport function f() {\n  return s;\n}\n"
+	if err := os.WriteFile(filepath.Join(dir, "a.ts"), []byte(text), 0644); err != nil {
+		t.Fatal(err)
+	}
+	repo := uniast.NewRepository("r")
+	mod := uniast.NewModule("m", ".", uniast.TypeScript)
+	pkg := uniast.NewPackage("p")
+	pkg.Functions["f"] = &uniast.Function{
+		FileLine: uniast.FileLine{File: "a.ts", Line: 2, StartOffset: 16, EndOffset: 51},
+		FunctionCalls: []uniast.Dependency{
+			{FileLine: uniast.FileLine{File: "a.ts", Line: 3, StartOffset: 47, EndOffset: 48}},
+		},
+	}
+	mod.Packages["p"] = pkg
+	repo.Modules["m"] = mod
+
+	normalizeOffsets(dir, &repo)
+	f := pkg.Functions["f"]
+	if got := text[f.StartOffset:f.EndOffset]; got != "export function f() {\n  return s;\n}" || f.EndLine != 4 {
+		t.Errorf("function = %q, end line %d", got, f.EndLine)
+	}
+	if dep := f.FunctionCalls[0]; text[dep.StartOffset:dep.EndOffset] != "s" || dep.EndLine != 3 {
+		t.Errorf("dependency = %+v", dep)
+	}
+}
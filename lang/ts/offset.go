@@ -0,0 +1,119 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ts
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"unicode/utf16"
+
+	"github.com/cloudwego/abcoder/lang/uniast"
+	"github.com/cloudwego/abcoder/lang/utils"
+)
+
+// fileOffsets converts the offsets of a file
+type fileOffsets struct {
+	// utf16 offset => byte offset, nil if the file is ASCII only
+	bytes []int
+	// start byte offsets of lines
+	lines []int
+}
+
+func newFileOffsets(text string) *fileOffsets {
+	ret := &fileOffsets{lines: utils.CountLines(text)}
+	for i := 0; i < len(text); i++ {
+		if text[i] >= 0x80 {
+			ret.bytes = make([]int, 0, len(text)+1)
+			for i, r := range text {
+				for n := utf16.RuneLen(r); n > 0; n-- {
+					ret.bytes = append(ret.bytes, i)
+				}
+			}
+			ret.bytes = append(ret.bytes, len(text))
+			break
+		}
+	}
+	return ret
+}
+
+func (f *fileOffsets) byteOffset(units int) int {
+	if f.bytes == nil || units < 0 {
+		return units
+	}
+	if units >= len(f.bytes) {
+		return f.bytes[len(f.bytes)-1]
+	}
+	return f.bytes[units]
+}
+
+func (f *fileOffsets) normalize(fl *uniast.FileLine) {
+	fl.StartOffset = f.byteOffset(fl.StartOffset)
+	fl.EndOffset = f.byteOffset(fl.EndOffset)
+	if fl.EndLine == 0 && fl.EndOffset > 0 {
+		fl.EndLine = sort.SearchInts(f.lines, fl.EndOffset)
+	}
+}
+
+// normalizeOffsets converts the offsets reported by the parser, which count UTF-16 code units of JS strings,
+// to byte offsets as the other parsers do, and fills the end lines
+func normalizeOffsets(repoPath string, repo *uniast.Repository) {
+	files := map[string]*fileOffsets{}
+	normalize := func(fl *uniast.FileLine) {
+		if fl.File == "" {
+			return
+		}
+		f, ok := files[fl.File]
+		if !ok {
+			if bs, err := os.ReadFile(filepath.Join(repoPath, fl.File)); err == nil {
+				f = newFileOffsets(string(bs))
+			}
+			files[fl.File] = f
+		}
+		if f != nil {
+			f.normalize(fl)
+		}
+	}
+	deps := func(ds []uniast.Dependency) {
+		for i := range ds {
+			normalize(&ds[i].FileLine)
+		}
+	}
+	for _, mod := range repo.Modules {
+		if mod.IsExternal() {
+			continue
+		}
+		for _, pkg := range mod.Packages {
+			for _, f := range pkg.Functions {
+				normalize(&f.FileLine)
+				deps(f.Params)
+				deps(f.Results)
+				deps(f.FunctionCalls)
+				deps(f.MethodCalls)
+				deps(f.Types)
+				deps(f.GlobalVars)
+			}
+			for _, t := range pkg.Types {
+				normalize(&t.FileLine)
+				deps(t.SubStruct)
+				deps(t.InlineStruct)
+			}
+			for _, v := range pkg.Vars {
+				normalize(&v.FileLine)
+				deps(v.Dependencies)
+			}
+		}
+	}
+}
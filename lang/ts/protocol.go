@@ -0,0 +1,117 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ts
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// message types, see ts-parser/src/ipc/protocol.ts
+const (
+	TypeParseRequest = "parse_request"
+	TypeProgress     = "progress"
+	TypeResultChunk  = "result_chunk"
+	TypeResultEnd    = "result_end"
+	TypeError        = "error"
+)
+
+// MaxMessageSize is the maximum allowed message size (64MB).
+// The repository JSON is split into chunks by the parser, so it is not limited by this.
+const MaxMessageSize = 64 * 1024 * 1024
+
+var ErrMessageTooLarge = errors.New("message size exceeds maximum allowed")
+
+// Message is a length-prefixed JSON message.
+//
+// Wire format:
+//   - 4 bytes: message length (big-endian uint32)
+//   - bytes: JSON message body
+type Message struct {
+	Type      string          `json:"type"`
+	RequestId string          `json:"requestId,omitempty"`
+	Payload   json.RawMessage `json:"payload,omitempty"`
+}
+
+type ParseRequest struct {
+	RepoPath string   `json:"repoPath"`
+	TSConfig string   `json:"tsconfig,omitempty"`
+	SrcDirs  []string `json:"srcDirs,omitempty"`
+	// path prefixes of the files to skip, relative to RepoPath if not absolute
+	Excludes []string `json:"excludes,omitempty"`
+	RepoID   string   `json:"repoId,omitempty"`
+}
+
+// Progress is the count of parsed modules
+type Progress struct {
+	Done  int    `json:"done"`
+	Total int    `json:"total"`
+	Item  string `json:"item,omitempty"`
+}
+
+type ErrorInfo struct {
+	Message string `json:"message"`
+}
+
+// WriteMessage writes a message with the payload encoded as JSON
+func WriteMessage(w io.Writer, typ, requestId string, payload any) error {
+	msg := Message{Type: typ, RequestId: requestId}
+	if payload != nil {
+		bs, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("failed to marshal %s payload: %w", typ, err)
+		}
+		msg.Payload = bs
+	}
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+	if len(body) > MaxMessageSize {
+		return fmt.Errorf("%w: %d bytes (max: %d)", ErrMessageTooLarge, len(body), MaxMessageSize)
+	}
+	buf := make([]byte, 4+len(body))
+	binary.BigEndian.PutUint32(buf, uint32(len(body)))
+	copy(buf[4:], body)
+	_, err = w.Write(buf)
+	return err
+}
+
+// ReadMessage reads a single message. Returns io.EOF when the stream ends normally.
+func ReadMessage(r io.Reader) (*Message, error) {
+	var head [4]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("failed to read message length: %w", err)
+	}
+	length := binary.BigEndian.Uint32(head[:])
+	if length > MaxMessageSize {
+		return nil, fmt.Errorf("%w: %d bytes (max: %d)", ErrMessageTooLarge, length, MaxMessageSize)
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, fmt.Errorf("failed to read message body: %w", err)
+	}
+	var msg Message
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal JSON message: %w", err)
+	}
+	return &msg, nil
+}
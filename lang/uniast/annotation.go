@@ -0,0 +1,108 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package uniast
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/cloudwego/abcoder/lang/utils"
+)
+
+// Annotation is the metadata attached to a declaration in the source, which frameworks usually rely on:
+// a Go directive (`//go:noinline`), a Rust attribute (`#[derive(Debug)]`),
+// a Java annotation (`@Service`) or a Python decorator (`@app.route("/")`)
+type Annotation struct {
+	// Name is the name without the sigil, like `go:noinline`, `derive`, `Service` or `app.route`
+	Name string
+	// Args is the raw text of the arguments without the enclosing brackets, like `Debug, Clone` or `"/"`
+	Args string `json:",omitempty"`
+}
+
+// ParseAnnotation parses the annotation text without the sigil, which is like `name`, `name(args)` or `name = value`
+func ParseAnnotation(text string) Annotation {
+	text = strings.TrimSpace(text)
+	end := strings.IndexAny(text, "(=[{ \t\n")
+	if end < 0 {
+		return Annotation{Name: text}
+	}
+	ret := Annotation{Name: text[:end]}
+	rest := strings.TrimSpace(text[end:])
+	if rest == "" {
+		return ret
+	}
+	switch rest[0] {
+	case '=':
+		ret.Args = strings.TrimSpace(rest[1:])
+	case '(', '[', '{':
+		if close := utils.MatchBracket(rest, 0); close > 0 {
+			ret.Args = strings.TrimSpace(rest[1:close])
+		} else {
+			ret.Args = strings.TrimSpace(rest[1:])
+		}
+	default:
+		ret.Args = rest
+	}
+	return ret
+}
+
+// GetAnnotation returns the first annotation of the name, nil if not found
+func GetAnnotation(anns []Annotation, name string) *Annotation {
+	for i := range anns {
+		if anns[i].Name == name {
+			return &anns[i]
+		}
+	}
+	return nil
+}
+
+// AnnotatedNode is a node with the queried annotation
+type AnnotatedNode struct {
+	Identity
+	Type       NodeType
+	File       string
+	Line       int
+	Annotation Annotation
+}
+
+// FindAnnotated returns the nodes of the internal modules which have the annotation of the name,
+// like the HTTP handlers with `app.route`. The nodes are sorted by their identities
+func (r *Repository) FindAnnotated(name string) []AnnotatedNode {
+	ret := []AnnotatedNode{}
+	add := func(id Identity, typ NodeType, fl FileLine, anns []Annotation) {
+		if ann := GetAnnotation(anns, name); ann != nil {
+			ret = append(ret, AnnotatedNode{Identity: id, Type: typ, File: fl.File, Line: fl.Line, Annotation: *ann})
+		}
+	}
+	for _, mod := range r.InternalModules() {
+		for _, pkg := range mod.Packages {
+			for _, fn := range pkg.Functions {
+				add(fn.Identity, FUNC, fn.FileLine, fn.Annotations)
+			}
+			for _, t := range pkg.Types {
+				add(t.Identity, TYPE, t.FileLine, t.Annotations)
+			}
+			for _, v := range pkg.Vars {
+				add(v.Identity, VAR, v.FileLine, v.Annotations)
+			}
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Full() < ret[j].Full()
+	})
+	return ret
+}
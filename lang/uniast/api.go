@@ -0,0 +1,238 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uniast
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// APIEntry is an exported function, type or var of a module, with its signature but without the body.
+// Locations are left out, thus the entries of two versions only differ by the API changes
+type APIEntry struct {
+	Identity
+	Type      NodeType
+	Signature string
+}
+
+// PublicAPI returns the API surface of an internal module ordered by identities: the exported functions, types and vars,
+// and the exported methods of the exported types. The tests, the main packages and the internal packages of go are skipped.
+// Function signatures are the heads before the bodies, and types are their declarations, with only the exported fields for go structs
+func (r *Repository) PublicAPI(mod ModPath) ([]APIEntry, error) {
+	m := r.Modules[mod]
+	if m == nil {
+		return nil, fmt.Errorf("module %s not found", mod)
+	}
+	if m.IsExternal() {
+		return nil, fmt.Errorf("module %s is external", mod)
+	}
+	var ret []APIEntry
+	for path, pkg := range m.Packages {
+		if pkg.IsTest || pkg.IsMain || m.Language == Golang && isGoInternal(path) {
+			continue
+		}
+		for _, fn := range pkg.Functions {
+			if !fn.Exported || fn.IsTest || fn.ParentFunction != nil {
+				continue
+			}
+			if fn.Receiver != nil {
+				if t := r.GetType(fn.Receiver.Type); t != nil && !t.Exported {
+					continue
+				}
+			}
+			sig := fn.Signature
+			if sig == "" {
+				sig = declarationHead(fn.Content)
+			}
+			ret = append(ret, APIEntry{Identity: fn.Identity, Type: FUNC, Signature: sig})
+		}
+		for _, t := range pkg.Types {
+			if !t.Exported {
+				continue
+			}
+			ret = append(ret, APIEntry{Identity: t.Identity, Type: TYPE, Signature: typeSignature(m.Language, t)})
+		}
+		for _, v := range pkg.Vars {
+			if !v.IsExported {
+				continue
+			}
+			ret = append(ret, APIEntry{Identity: v.Identity, Type: VAR, Signature: declarationHead(v.Content)})
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Full() < ret[j].Full() })
+	return ret, nil
+}
+
+func isGoInternal(path PkgPath) bool {
+	return strings.HasSuffix(path, "/internal") || strings.Contains(path, "/internal/")
+}
+
+// typeSignature returns the declaration of a type: go structs keep their exported fields,
+// other go types are whole, and the classes of the other languages are cut before their bodies
+func typeSignature(lang Language, t *Type) string {
+	if lang != Golang {
+		return declarationHead(t.Content)
+	}
+	if t.TypeKind != TypeKindStruct || len(t.Fields) == 0 {
+		return strings.TrimSpace(t.Content)
+	}
+	open := strings.Index(t.Content, "struct")
+	if open < 0 {
+		return strings.TrimSpace(t.Content)
+	}
+	var sb strings.Builder
+	sb.WriteString(t.Content[:open])
+	sb.WriteString("struct {\n")
+	for _, f := range t.Fields {
+		name := f.Name
+		if f.Embedded {
+			// the type name may be qualified or a pointer
+			name = f.Type[strings.LastIndexAny(f.Type, ".*")+1:]
+		}
+		if r, _ := utf8.DecodeRuneInString(name); !unicode.IsUpper(r) {
+			continue
+		}
+		sb.WriteString("\t")
+		if f.Embedded {
+			sb.WriteString(f.Type)
+		} else {
+			sb.WriteString(f.Name + " " + f.Type)
+		}
+		if f.Tag != "" {
+			sb.WriteString(" `" + f.Tag + "`")
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("}")
+	return sb.String()
+}
+
+// declarationHead returns the content before the body of a declaration, which is the first `{` outside the brackets
+// and the type literals of go, or the `:` ending a line for python. Leading comments are dropped
+func declarationHead(content string) string {
+	content = strings.TrimSpace(content)
+	for strings.HasPrefix(content, "//") || strings.HasPrefix(content, "#") && !strings.HasPrefix(content, "#[") {
+		i := strings.IndexByte(content, '\n')
+		if i < 0 {
+			return ""
+		}
+		content = strings.TrimSpace(content[i+1:])
+	}
+	if strings.HasPrefix(content, "/*") {
+		if i := strings.Index(content, "*/"); i >= 0 {
+			content = strings.TrimSpace(content[i+2:])
+		}
+	}
+	depth := 0
+	var quote byte
+	for i := 0; i < len(content); i++ {
+		c := content[i]
+		if quote != 0 {
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch c {
+		case '"', '\'', '`':
+			quote = c
+		case '(', '[':
+			depth++
+		case ')', ']':
+			depth--
+		case '{':
+			if depth != 0 {
+				continue
+			}
+			head := strings.TrimRight(content[:i], " \t")
+			if strings.HasSuffix(head, "struct") || strings.HasSuffix(head, "interface") {
+				// a type literal of go, like `struct{}` in the results
+				if end := matchBrace(content, i); end > 0 {
+					i = end
+					continue
+				}
+			}
+			return strings.TrimSpace(content[:i])
+		case ':':
+			if depth == 0 && (i+1 == len(content) || content[i+1] == '\n' || content[i+1] == '\r') {
+				return strings.TrimSpace(content[:i+1])
+			}
+		}
+	}
+	return content
+}
+
+// matchBrace returns the index of the `}` closing the `{` at s[i], or -1
+func matchBrace(s string, i int) int {
+	depth := 0
+	for j := i; j < len(s); j++ {
+		switch s[j] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return j
+			}
+		}
+	}
+	return -1
+}
+
+// APIDiff is the difference between the API surfaces of two versions, see DiffAPI
+type APIDiff struct {
+	Added   []APIEntry  `json:",omitempty"`
+	Removed []APIEntry  `json:",omitempty"`
+	Changed []APIChange `json:",omitempty"`
+}
+
+// APIChange is an entry whose signature changed
+type APIChange struct {
+	Old APIEntry
+	New APIEntry
+}
+
+// DiffAPI compares the API surfaces of the old and the new versions. Entries are matched by their packages
+// and names regardless of the module paths, which may contain the versions
+func DiffAPI(old, new []APIEntry) APIDiff {
+	key := func(e APIEntry) string { return e.PkgPath + "#" + e.Name }
+	olds := make(map[string]APIEntry, len(old))
+	for _, e := range old {
+		olds[key(e)] = e
+	}
+	var ret APIDiff
+	seen := make(map[string]bool, len(new))
+	for _, e := range new {
+		k := key(e)
+		seen[k] = true
+		o, ok := olds[k]
+		if !ok {
+			ret.Added = append(ret.Added, e)
+		} else if o.Signature != e.Signature || o.Type != e.Type {
+			ret.Changed = append(ret.Changed, APIChange{Old: o, New: e})
+		}
+	}
+	for _, e := range old {
+		if !seen[key(e)] {
+			ret.Removed = append(ret.Removed, e)
+		}
+	}
+	return ret
+}
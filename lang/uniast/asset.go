@@ -0,0 +1,62 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package uniast
+
+import (
+	"sort"
+	"strings"
+)
+
+// Asset is a static file shipped inside the binary, like the ones embedded by `//go:embed`
+type Asset struct {
+	File    string // path relative to the repo, like FileLine.File
+	Pattern string // the pattern of the directive matching the file
+}
+
+// AssetUse is an asset file with the vars embedding it
+type AssetUse struct {
+	File string
+	Vars []Identity
+}
+
+// Assets returns the asset files of the internal modules sorted by paths, with the vars embedding them,
+// which tells what ships inside the binaries and what to update once an asset is renamed.
+// Only the files under the prefix are returned if it is not empty
+func (r *Repository) Assets(prefix string) []AssetUse {
+	uses := map[string][]Identity{}
+	for _, mod := range r.Modules {
+		if mod.IsExternal() {
+			continue
+		}
+		for _, pkg := range mod.Packages {
+			for _, v := range pkg.Vars {
+				for _, a := range v.Assets {
+					if strings.HasPrefix(a.File, prefix) {
+						uses[a.File] = append(uses[a.File], v.Identity)
+					}
+				}
+			}
+		}
+	}
+	ret := make([]AssetUse, 0, len(uses))
+	for file, vars := range uses {
+		sort.Slice(vars, func(i, j int) bool { return vars[i].Full() < vars[j].Full() })
+		ret = append(ret, AssetUse{File: file, Vars: vars})
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].File < ret[j].File })
+	return ret
+}
@@ -20,6 +20,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"path/filepath"
+	"reflect"
 	"strconv"
 	"strings"
 
@@ -39,6 +40,9 @@ const (
 	Unknown    Language = ""
 	Kotlin     Language = "kotlin"
 	Cpp        Language = "cpp"
+	Scala      Language = "scala"
+	PHP        Language = "php"
+	Ruby       Language = "ruby"
 )
 
 func (l Language) String() string {
@@ -81,6 +85,12 @@ func NewLanguage(lang string) (l Language) {
 		return Java
 	case "kotlin":
 		return Kotlin
+	case "scala":
+		return Scala
+	case "php":
+		return PHP
+	case "ruby", "rb":
+		return Ruby
 	default:
 		return Unknown
 	}
@@ -96,8 +106,27 @@ type Repository struct {
 	ASTVersion  string             // uniast version
 	ToolVersion string             // abcoder version
 	Path        string             // repo absolute path
+	VCS         *VCS               `json:",omitempty"` // version control state of the sources at parse time, nil if unknown
 	Modules     map[string]*Module // module name => module
 	Graph       NodeGraph          // node id => node
+	// Dependencies are the third-party dependencies of the internal modules, see ExternalDependencies
+	Dependencies []ExternalDependency `json:",omitempty"`
+
+	spans *spanIndex              // source position => node, see NodeAt
+	index *nodeIndex              // memoized lookups of nodes, see GetNode
+	tests map[Identity][]TestLink // memoized links of the tests by node, see TestsOf
+}
+
+// VCS tells which snapshot of the sources the AST describes
+type VCS struct {
+	// Type is the version control system, like `git`
+	Type string
+	// Commit is the hash of the checked out commit
+	Commit string
+	// Branch is the checked out branch, empty if detached
+	Branch string `json:",omitempty"`
+	// Dirty tells if there are uncommitted changes, thus the AST may differ from Commit
+	Dirty bool `json:",omitempty"`
 }
 
 func (r Repository) ID() string {
@@ -127,9 +156,56 @@ func NewRepository(name string) Repository {
 }
 
 type File struct {
-	Path    string
-	Imports []Import `json:",omitempty"`
-	Package PkgPath  `json:",omitempty"`
+	Path        string
+	Imports     []Import     `json:",omitempty"`
+	Package     PkgPath      `json:",omitempty"`
+	Diagnostics []Diagnostic `json:",omitempty"` // problems reported by the compiler or LSP while parsing
+	// build constraint of the file, like `linux && amd64` of `//go:build linux && amd64`
+	BuildConstraint string `json:",omitempty"`
+	// build tag sets under which the file is compiled, like `linux,amd64`.
+	// Only set when the repo is parsed under explicit tag sets.
+	Variants []string `json:",omitempty"`
+	// If is a test file, like `xx_test.go` or `tests/xx.rs`
+	IsTest bool `json:",omitempty"`
+	// Comments are the free-floating comments and the TODOs of the file, see Repository.CollectComments
+	Comments []Comment `json:",omitempty"`
+}
+
+type DiagnosticSeverity string
+
+const (
+	SeverityError       DiagnosticSeverity = "error"
+	SeverityWarning     DiagnosticSeverity = "warning"
+	SeverityInformation DiagnosticSeverity = "information"
+	SeverityHint        DiagnosticSeverity = "hint"
+)
+
+// Diagnostic is a problem of the source codes, like a syntax error.
+// Lines and columns are 1-based, zero means unknown.
+type Diagnostic struct {
+	Severity  DiagnosticSeverity
+	Message   string
+	Source    string `json:",omitempty"` // who reports it, like `rust-analyzer` or `go/packages`
+	Line      int
+	Column    int `json:",omitempty"`
+	EndLine   int `json:",omitempty"`
+	EndColumn int `json:",omitempty"`
+}
+
+// Diagnostics returns all the diagnostics of internal files whose severity is sev, keyed by file path.
+// Empty sev means all severities.
+func (r Repository) Diagnostics(sev DiagnosticSeverity) map[string][]Diagnostic {
+	ret := map[string][]Diagnostic{}
+	for _, mod := range r.InternalModules() {
+		for path, f := range mod.Files {
+			for _, d := range f.Diagnostics {
+				if sev == "" || d.Severity == sev {
+					ret[path] = append(ret[path], d)
+				}
+			}
+		}
+	}
+	return ret
 }
 
 type Import struct {
@@ -201,6 +277,7 @@ type Module struct {
 	Dir          string               // relative path to repo
 	Packages     map[PkgPath]*Package // pkage import path => Package
 	Dependencies map[string]string    `json:",omitempty"`              // module name => module_path@version
+	Build        *BuildConfig         `json:",omitempty"`              // build configuration declared by the manifest, nil if unknown
 	Files        map[string]*File     `json:",omitempty"`              // relative path => file info
 	LoadErrors   []packages.Error     `json:"load_errors,omitempty"`   // packages.Load error
 	CompressData *string              `json:"compress_data,omitempty"` // module compress info
@@ -225,41 +302,9 @@ func (m Module) GetFile(path string) *File {
 	return m.Files[path]
 }
 
-func (r Repository) GetFileNodes(path string) []*Node {
-	var ret []*Node
-	file, mod := r.GetFile(path)
-	if file == nil || file.Package == "" {
-		return ret
-	}
-	pkg := mod.Packages[file.Package]
-	if pkg == nil {
-		return ret
-	}
-	for _, v := range pkg.Functions {
-		if v.File == path {
-			n := r.GetNode(v.Identity)
-			if n != nil {
-				ret = append(ret, n)
-			}
-		}
-	}
-	for _, v := range pkg.Types {
-		if v.File == path {
-			n := r.GetNode(v.Identity)
-			if n != nil {
-				ret = append(ret, n)
-			}
-		}
-	}
-	for _, v := range pkg.Vars {
-		if v.File == path {
-			n := r.GetNode(v.Identity)
-			if n != nil {
-				ret = append(ret, n)
-			}
-		}
-	}
-	return ret
+// GetFileNodes returns the nodes defined in the file sorted by line, path is relative to the repository
+func (r *Repository) GetFileNodes(path string) []*Node {
+	return r.nodeIndex().files[path]
 }
 
 func (m Module) IsExternal() bool {
@@ -304,6 +349,10 @@ type Package struct {
 	Types        map[string]*Type     // type name => type define
 	Vars         map[string]*Var      // var name => var define
 	CompressData *string              `json:"compress_data,omitempty"` // package compress info
+	// InitOrder is the sequence of the nodes run on the initialization of the package:
+	// the vars with initializers in their dependency order, then the init functions by files and declarations.
+	// Only set for go, and what they touch are their dependencies
+	InitOrder []Identity `json:",omitempty"`
 }
 
 func NewPackage(pkgPath PkgPath) *Package {
@@ -431,6 +480,7 @@ func (p *Repository) SetFunction(id Identity, f *Function) *Function {
 		lib.Packages[id.PkgPath] = pp
 	}
 	if pp.Functions[id.Name] == nil {
+		p.invalidateIndex()
 		pp.Functions[id.Name] = f
 	}
 	if id.Name == "main" {
@@ -444,6 +494,7 @@ func (p *Repository) SetModule(path string, mod *Module) {
 		p.Modules = map[string]*Module{}
 	}
 	p.Modules[path] = mod
+	p.invalidateIndex()
 }
 
 func (p Repository) GetType(id Identity) *Type {
@@ -468,6 +519,7 @@ func (p *Repository) SetType(id Identity, f *Type) *Type {
 		lib.Packages[id.PkgPath] = pp
 	}
 	if pp.Types[id.Name] == nil {
+		p.invalidateIndex()
 		pp.Types[id.Name] = f
 	}
 	return pp.Types[id.Name]
@@ -495,6 +547,7 @@ func (p *Repository) SetVar(id Identity, v *Var) *Var {
 		lib.Packages[id.PkgPath] = pp
 	}
 	if pp.Vars[id.Name] == nil {
+		p.invalidateIndex()
 		pp.Vars[id.Name] = v
 	}
 	return pp.Vars[id.Name]
@@ -514,10 +567,15 @@ func (p Repository) GetFile(fp string) (*File, *Module) {
 
 // Function holds the information about a function
 type Function struct {
-	Exported bool
+	Exported   bool
+	Visibility Visibility `json:",omitempty"` // language-specific visibility, Exported is true iff it is public
 
 	IsMethod          bool // If the function is a method
 	IsInterfaceMethod bool // If is a empty interface method stub
+	IsDefaultImpl     bool `json:",omitempty"` // If is a default method body of a trait, inherited by impls unless overridden
+	IsTest            bool `json:",omitempty"` // If is a test case, like `func TestXxx(t *testing.T)` or `#[test] fn xxx()`
+	Generated         bool `json:",omitempty"` // If is generated by a macro, whose content is the expanded codes rather than those in the file
+	TakesContext      bool `json:",omitempty"` // If takes a context.Context parameter (go only), see ContextIssues
 	Identity               // unique identity in a repo
 	FileLine
 	Content string // Content of the function, including functiion signature and body
@@ -537,6 +595,18 @@ type Function struct {
 	Types      []Dependency `json:",omitempty"` // types used in the function
 	GlobalVars []Dependency `json:",omitempty"` // global vars used in the function
 
+	Annotations []Annotation `json:",omitempty"` // directives, attributes, annotations or decorators of the function
+	Errors      *Errors      `json:",omitempty"` // the errors the function returns and originates (go and rust), see ErrorOrigins
+
+	// ParentFunction is the function (or var) whose body defines this closure, nil for the declared functions.
+	// Only the significant closures are parsed as functions, others are left in the content of the parent
+	ParentFunction *Identity `json:",omitempty"`
+
+	Hash    string     `json:",omitempty"` // content hash, see HashNodes
+	Aliases []Identity `json:",omitempty"` // identities of the duplicates collapsed into this node, see Dedup
+	Owners  *Ownership `json:",omitempty"` // primary authors by git blame, see AnnotateOwners
+	Metrics *Metrics   `json:",omitempty"` // size and complexity of the function, see ComputeMetrics
+
 	// func llm compress result
 	CompressData *string `json:"compress_data,omitempty"`
 
@@ -547,6 +617,11 @@ type Dependency struct {
 	Identity
 	FileLine `json:",omitempty"`
 	Extra    *ExtraInfo `json:",omitempty"`
+	// Dynamic tells the dependency is a possible target of a dynamic call (through an interface value or a function value),
+	// which is resolved by the call graph analysis, or a module imported dynamically (Name is empty then)
+	Dynamic bool `json:",omitempty"`
+	// FreshContext is the new root context passed to the call instead of the caller's one, like `context.Background` (go only)
+	FreshContext string `json:",omitempty"`
 }
 
 func (d Dependency) Id() Identity {
@@ -563,6 +638,9 @@ func NewDependency(id Identity, fl FileLine) Dependency {
 type Receiver struct {
 	IsPointer bool
 	Type      Identity
+	// the trait/interface which declares the method, for trait impls and default methods.
+	// e.g. `impl Display for Foo { fn fmt() }` => Type: Foo, Interface: Display
+	Interface *Identity `json:",omitempty"`
 }
 
 // FileLine represents a filename and line number
@@ -572,10 +650,13 @@ type FileLine struct {
 	// NOTICE: start line. line number start from 1
 	Line int
 
-	// start offset in file
+	// end line, line number start from 1. zero if unknown
+	EndLine int `json:",omitempty"`
+
+	// start byte offset in file
 	StartOffset int
 
-	// end offset in file
+	// end byte offset in file
 	EndOffset int
 }
 
@@ -615,7 +696,8 @@ func (t *TypeKind) UnmarshalJSON(data []byte) error {
 
 // Type holds the information about a struct
 type Type struct {
-	Exported bool // if the struct is exported
+	Exported   bool       // if the struct is exported
+	Visibility Visibility `json:",omitempty"` // language-specific visibility, Exported is true iff it is public
 
 	TypeKind TypeKind // type Kind: Struct / Interface / Typedef
 
@@ -629,12 +711,22 @@ type Type struct {
 	// inherit field type
 	InlineStruct []Dependency `json:",omitempty"`
 
+	// fields of the struct in declaration order
+	Fields []Field `json:",omitempty"`
+
 	// methods defined on the Struct, not including inlined type's method
 	Methods map[string]Identity `json:",omitempty"`
 
 	// Implemented interfaces
 	Implements []Identity `json:",omitempty"`
 
+	Annotations []Annotation `json:",omitempty"` // directives, attributes, annotations or decorators of the type
+	Generated   bool         `json:",omitempty"` // if generated by a macro, see Function.Generated
+
+	Hash    string     `json:",omitempty"` // content hash, see HashNodes
+	Aliases []Identity `json:",omitempty"` // identities of the duplicates collapsed into this node, see Dedup
+	Owners  *Ownership `json:",omitempty"` // primary authors by git blame, see AnnotateOwners
+
 	// functions defined in fields, key is type name, val is the function Signature
 	// FieldFunctions map[string]string
 
@@ -644,8 +736,40 @@ type Type struct {
 	Extra *ExtraInfo `json:",omitempty"`
 }
 
+// Field is a field of a struct type
+type Field struct {
+	Name     string    // field name, or the type name for embedded field
+	Type     string    // type expression of the field, like `map[string]*Foo`
+	TypeId   *Identity `json:",omitempty"` // the named type of the field, if any
+	Tag      string    `json:",omitempty"` // raw tag without quotes, like `json:"name,omitempty"`
+	Doc      string    `json:",omitempty"` // doc and line comments of the field
+	Embedded bool      `json:",omitempty"` // if the field is embedded (inherited)
+	Line     int       `json:",omitempty"` // line of the field in file
+}
+
+// TagValue returns the first comma-separated part of the tag key, like `name` of `json:"name,omitempty"`
+func (f Field) TagValue(key string) string {
+	v := reflect.StructTag(f.Tag).Get(key)
+	if i := strings.IndexByte(v, ','); i >= 0 {
+		v = v[:i]
+	}
+	return v
+}
+
+// FieldByTag returns the first field whose TagValue of key equals value,
+// e.g. FieldByTag("json", "id") or FieldByTag("gorm", "column:id")
+func (t Type) FieldByTag(key, value string) *Field {
+	for i := range t.Fields {
+		if t.Fields[i].TagValue(key) == value {
+			return &t.Fields[i]
+		}
+	}
+	return nil
+}
+
 type Var struct {
 	IsExported bool
+	Visibility Visibility `json:",omitempty"` // language-specific visibility, IsExported is true iff it is public
 
 	IsConst   bool
 	IsPointer bool // if its Type is a pointer type
@@ -657,6 +781,14 @@ type Var struct {
 	// Groups means the var is a group of vars, like Enum in Go
 	Groups []Identity `json:",omitempty"`
 
+	Annotations []Annotation `json:",omitempty"` // directives, attributes or annotations of the var
+	Generated   bool         `json:",omitempty"` // if generated by a macro, see Function.Generated
+	Assets      []Asset      `json:",omitempty"` // files embedded into the var by `//go:embed` (go only)
+
+	Hash    string     `json:",omitempty"` // content hash, see HashNodes
+	Aliases []Identity `json:",omitempty"` // identities of the duplicates collapsed into this node, see Dedup
+	Owners  *Ownership `json:",omitempty"` // primary authors by git blame, see AnnotateOwners
+
 	CompressData *string `json:"compress_data,omitempty"`
 
 	// extra data
@@ -17,9 +17,18 @@
 package uniast
 
 import (
+	"encoding/csv"
 	"encoding/json"
+	"encoding/xml"
+	"fmt"
 	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/cloudwego/abcoder/lang/testutils"
 )
@@ -36,13 +45,50 @@ func TestRepository_BuildGraph(t *testing.T) {
 	if js, err := json.Marshal(r); err != nil {
 		t.Fatalf("failed to marshal repo: %v", err)
 	} else {
-		astFileWithGraph := testutils.GetTestDataRoot() + "/asts/localsession_g.json"
+		astFileWithGraph := filepath.Join(t.TempDir(), "localsession_g.json")
 		if err := os.WriteFile(astFileWithGraph, js, 0644); err != nil {
 			t.Fatalf("failed to write repo with graph: %v", err)
 		}
 	}
 }
 
+func TestLoadRepo_Partial(t *testing.T) {
+	dir := t.TempDir()
+	complete := filepath.Join(dir, "complete.json")
+	if err := os.WriteFile(complete+".partial", []byte(`{"id":`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		path    string
+		wantErr string
+	}{
+		{
+			name:    "partial path",
+			path:    complete + ".partial",
+			wantErr: "incomplete output",
+		},
+		{
+			name:    "missing file with partial sibling",
+			path:    complete,
+			wantErr: "not completely written",
+		},
+		{
+			name:    "missing file",
+			path:    filepath.Join(dir, "missing.json"),
+			wantErr: "no such file",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadRepo(tt.path)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("LoadRepo() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 // TestRepository_BuildGraph_Deterministic ensures BuildGraph yields a byte-stable
 // JSON repeatedly. Relation slices (References, Dependencies, etc.) are filled
 // via map iteration, so without an explicit canonical sort each run produced a
@@ -73,6 +119,107 @@ func TestRepository_BuildGraph_Deterministic(t *testing.T) {
 	}
 }
 
+func TestRepository_TestsOf(t *testing.T) {
+	const mod, pkg, testPkg = "a.b/calc", "a.b/calc", "a.b/calc [a.b/calc.test]"
+	r := NewRepository("calc")
+	m := NewModule(mod, ".", Golang)
+	r.Modules[mod] = m
+	m.Files["calc.go"] = &File{Path: "calc.go", Package: pkg}
+	m.Files["calc_test.go"] = &File{Path: "calc_test.go", Package: testPkg, IsTest: true}
+
+	call := func(pkg, name string) Dependency {
+		return NewDependency(NewIdentity(mod, pkg, name), FileLine{})
+	}
+	fn := func(pkg, name, file string, isTest bool, calls ...Dependency) *Function {
+		f := &Function{Identity: NewIdentity(mod, pkg, name), FileLine: FileLine{File: file}, IsTest: isTest, FunctionCalls: calls}
+		r.SetFunction(f.Identity, f)
+		return f
+	}
+	fn(pkg, "Sum", "calc.go", false, call(pkg, "Adder.Add"))
+	fn(pkg, "Adder.Add", "calc.go", false)
+	r.SetType(NewIdentity(mod, pkg, "Adder"), &Type{Identity: NewIdentity(mod, pkg, "Adder")})
+	// the test variant duplicates the product functions
+	fn(testPkg, "Sum", "calc.go", false, call(pkg, "Adder.Add"))
+	fn(testPkg, "check", "calc_test.go", false, call(pkg, "Sum"))
+	fn(testPkg, "TestSum", "calc_test.go", true, call(pkg, "check"))
+	fn(testPkg, "TestAdder_Add", "calc_test.go", true)
+	fn(testPkg, "TestAccumulate", "calc_test.go", true, call(pkg, "Adder.Add"))
+	m.Packages[testPkg].IsTest = true
+
+	tests := []struct {
+		node string
+		want map[string][]TestLinkKind
+	}{
+		{"Sum", map[string][]TestLinkKind{"TestSum": {TestLinkName, TestLinkCall}}},
+		{"Adder.Add", map[string][]TestLinkKind{"TestAdder_Add": {TestLinkName}, "TestAccumulate": {TestLinkCall}}},
+		{"Adder", nil},
+		{"check", nil},
+	}
+	for _, tt := range tests {
+		got := map[string][]TestLinkKind{}
+		for _, l := range r.TestsOf(NewIdentity(mod, pkg, tt.node)) {
+			if l.Test.PkgPath != testPkg {
+				t.Errorf("test %v is not in the test package", l.Test)
+			}
+			got[l.Test.Name] = l.Kinds
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("TestsOf(%s) = %v, want %v", tt.node, got, tt.want)
+			continue
+		}
+		for name, kinds := range tt.want {
+			if strings.Join(toStrings(got[name]), ",") != strings.Join(toStrings(kinds), ",") {
+				t.Errorf("TestsOf(%s)[%s] = %v, want %v", tt.node, name, got[name], kinds)
+			}
+		}
+	}
+
+	// the memoized links are dropped by the new tests
+	fn(testPkg, "TestAdder", "calc_test.go", true)
+	if got := r.TestsOf(NewIdentity(mod, pkg, "Adder")); len(got) != 1 || got[0].Test.Name != "TestAdder" {
+		t.Errorf("TestsOf(Adder) = %v, want TestAdder", got)
+	}
+}
+
+func TestRepository_Stats(t *testing.T) {
+	r, err := LoadRepo(testutils.GetTestAstFile("localsession"))
+	if err != nil {
+		t.Fatalf("failed to load repo: %v", err)
+	}
+	stats := r.Stats(3)
+	if stats.Modules == 0 || stats.Packages == 0 || stats.Files == 0 || stats.LOC == 0 {
+		t.Fatalf("stats = %+v", stats)
+	}
+	if stats.Nodes["function"] == 0 || stats.Nodes["struct"] == 0 {
+		t.Errorf("node counts = %v", stats.Nodes)
+	}
+	if len(stats.LargestFiles) != 3 || len(stats.LargestFunctions) != 3 || len(stats.MostReferenced) != 3 {
+		t.Fatalf("rankings are not limited to top 3: %+v", stats)
+	}
+	for i := 1; i < 3; i++ {
+		if stats.LargestFiles[i].LOC > stats.LargestFiles[i-1].LOC ||
+			stats.LargestFunctions[i].Count > stats.LargestFunctions[i-1].Count ||
+			stats.MostReferenced[i].Count > stats.MostReferenced[i-1].Count {
+			t.Errorf("rankings are not sorted: %+v", stats)
+		}
+	}
+	largest := r.GetFunction(stats.LargestFunctions[0].Identity)
+	if largest == nil || countLines(largest.Content) != stats.LargestFunctions[0].Count {
+		t.Errorf("largest function = %+v", stats.LargestFunctions[0])
+	}
+	if len(stats.FanIn) == 0 || len(stats.FanOut) == 0 {
+		t.Errorf("fan-in = %v, fan-out = %v", stats.FanIn, stats.FanOut)
+	}
+}
+
+func toStrings(kinds []TestLinkKind) []string {
+	ret := make([]string, len(kinds))
+	for i, k := range kinds {
+		ret[i] = string(k)
+	}
+	return ret
+}
+
 func BenchmarkRepository_BuildGraph(b *testing.B) {
 	astFile := testutils.GetTestAstFile("large_ast")
 	r, err := LoadRepo(astFile)
@@ -86,3 +233,1373 @@ func BenchmarkRepository_BuildGraph(b *testing.B) {
 		}
 	}
 }
+
+func BenchmarkRepository_GetFileNodes(b *testing.B) {
+	astFile := testutils.GetTestAstFile("large_ast")
+	r, err := LoadRepo(astFile)
+	if err != nil {
+		b.Fatalf("failed to load repo: %v", err)
+	}
+	var files []string
+	for _, mod := range r.InternalModules() {
+		for path := range mod.Files {
+			files = append(files, path)
+		}
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r.GetFileNodes(files[i%len(files)])
+	}
+}
+
+func TestRepository_NodeIndex(t *testing.T) {
+	repo := NewRepository("index")
+	repo.Modules["m"] = NewModule("m", ".", Golang)
+	a, b, c := NewIdentity("m", "m/p", "A"), NewIdentity("m", "m/p", "B"), NewIdentity("m", "m/q", "C")
+	repo.SetFunction(a, &Function{Identity: a, FileLine: FileLine{File: "p/p.go", Line: 9}})
+	repo.SetType(b, &Type{Identity: b, FileLine: FileLine{File: "p/p.go", Line: 2}})
+
+	// the graph is built lazily
+	if n := repo.GetNode(a); n == nil || n.Type != FUNC {
+		t.Fatalf("node A = %+v", n)
+	}
+	var names []string
+	for _, n := range repo.GetFileNodes("p/p.go") {
+		names = append(names, n.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"B", "A"}) {
+		t.Errorf("file nodes = %v, want sorted by line", names)
+	}
+	if nodes := repo.GetPackageNodes("m", "m/p"); len(nodes) != 2 || nodes[0].Name != "A" {
+		t.Errorf("package nodes = %v", nodes)
+	}
+
+	// the mutations drop the memoized lookups
+	repo.SetVar(c, &Var{Identity: c, FileLine: FileLine{File: "q/q.go", Line: 1}})
+	if err := repo.BuildGraph(); err != nil {
+		t.Fatal(err)
+	}
+	if n := repo.GetNode(c); n == nil || len(repo.GetFileNodes("q/q.go")) != 1 {
+		t.Errorf("node C is not indexed")
+	}
+	d := NewIdentity("m", "m/q", "D")
+	repo.SetNode(d, UNKNOWN)
+	if repo.GetNode(d) == nil {
+		t.Errorf("node D is not indexed")
+	}
+
+	// concurrent readers build the graph and the index once
+	repo.Graph = nil
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if repo.GetNode(a) == nil {
+				t.Error("node A not found")
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestMerge(t *testing.T) {
+	const mod = "a.b/mono"
+	newRepo := func(dir string, fns map[string]string) *Repository {
+		r := NewRepository("mono")
+		r.Modules[mod] = NewModule(mod, dir, Golang)
+		for name, content := range fns {
+			pkg, fn, _ := strings.Cut(name, "#")
+			id := NewIdentity(mod, pkg, fn)
+			r.SetFunction(id, &Function{Identity: id, FileLine: FileLine{File: fn + ".go", Line: 1}, Content: content})
+		}
+		return &r
+	}
+	call := func(r *Repository, from, to string) {
+		pkg, name, _ := strings.Cut(from, "#")
+		f := r.Modules[mod].Packages[pkg].Functions[name]
+		pkg, name, _ = strings.Cut(to, "#")
+		f.FunctionCalls = append(f.FunctionCalls, NewDependency(NewIdentity(mod, pkg, name), FileLine{}))
+	}
+
+	// two partial parses sharing the dependency lib#Helper
+	a := newRepo(".", map[string]string{"mono/a#Serve": "func Serve() {}", "mono/lib#Helper": "func Helper() {}"})
+	call(a, "mono/a#Serve", "mono/lib#Helper")
+	b := newRepo(".", map[string]string{"mono/b#Other": "func Other() {}", "mono/lib#Helper": "func Helper() {}", "mono/lib#Stub": ""})
+	call(b, "mono/b#Other", "mono/lib#Helper")
+	if err := Merge(a, b, MergeOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	helper := a.GetNode(NewIdentity(mod, "mono/lib", "Helper"))
+	if helper == nil || len(helper.References) != 2 {
+		t.Fatalf("Helper = %+v", helper)
+	}
+	if len(a.Modules[mod].Packages) != 3 {
+		t.Errorf("packages = %v", a.Modules[mod].Packages)
+	}
+
+	// collisions
+	dst := func() *Repository {
+		return newRepo(".", map[string]string{"mono/a#F": "func F() { 1 }", "mono/a#Stub": ""})
+	}
+	src := func() *Repository {
+		return newRepo(".", map[string]string{"mono/a#F": "func F() { 2 }", "mono/a#Stub": "func Stub() {}"})
+	}
+	content := func(r *Repository, name string) string {
+		return r.Modules[mod].Packages["mono/a"].Functions[name].Content
+	}
+	d := dst()
+	if err := Merge(d, src(), MergeOptions{}); err != nil || content(d, "F") != "func F() { 1 }" || content(d, "Stub") != "func Stub() {}" {
+		t.Errorf("MergeKeepDst: err %v, F %q, Stub %q", err, content(d, "F"), content(d, "Stub"))
+	}
+	d = dst()
+	if err := Merge(d, src(), MergeOptions{Conflict: MergeKeepSrc}); err != nil || content(d, "F") != "func F() { 2 }" {
+		t.Errorf("MergeKeepSrc: err %v, F %q", err, content(d, "F"))
+	}
+	if err := Merge(dst(), src(), MergeOptions{Conflict: MergeFail}); err == nil || !strings.Contains(err.Error(), "a.b/mono?mono/a#F") {
+		t.Errorf("MergeFail: err %v", err)
+	}
+
+	// an internal module replaces the external one
+	ext := newRepo("", map[string]string{"mono/a#F": "func F() { ext }"})
+	if err := Merge(ext, dst(), MergeOptions{}); err != nil || ext.Modules[mod].IsExternal() || content(ext, "F") != "func F() { 1 }" {
+		t.Errorf("external: err %v, F %q", err, content(ext, "F"))
+	}
+
+	// the nodes of a package decoded with null maps
+	var null Repository
+	if err := json.Unmarshal([]byte(`{"Modules":{"a.b/mono":{"Dir":".","Language":"go","Packages":{"mono/a":{"PkgPath":"mono/a","Functions":null,"Types":null,"Vars":null}}}}}`), &null); err != nil {
+		t.Fatal(err)
+	}
+	if err := Merge(&null, dst(), MergeOptions{}); err != nil || content(&null, "F") != "func F() { 1 }" {
+		t.Errorf("null maps: err %v", err)
+	}
+}
+
+func TestRepository_DetectCycles(t *testing.T) {
+	repo := NewRepository("cycles")
+	mod := NewModule("m", ".", Golang)
+	repo.Modules["m"] = mod
+	funcs := map[string][]string{
+		// a <-> b through F and G
+		"m/a.F": {"m/b.G"},
+		"m/b.G": {"m/a.F", "m/c.H"},
+		// direct recursion is not a cycle
+		"m/c.H": {"m/c.H", "m/c.I"},
+		// I -> J -> K -> I
+		"m/c.I": {"m/c.J"},
+		"m/c.J": {"m/c.K"},
+		"m/c.K": {"m/c.I", "x/y.Z"},
+	}
+	id := func(s string) Identity {
+		i := strings.LastIndex(s, ".")
+		return NewIdentity("m", s[:i], s[i+1:])
+	}
+	for name, calls := range funcs {
+		fid := id(name)
+		pkg := mod.Packages[fid.PkgPath]
+		if pkg == nil {
+			pkg = NewPackage(fid.PkgPath)
+			mod.Packages[fid.PkgPath] = pkg
+		}
+		f := &Function{Identity: fid}
+		for _, c := range calls {
+			f.FunctionCalls = append(f.FunctionCalls, NewDependency(id(c), FileLine{}))
+		}
+		pkg.Functions[fid.Name] = f
+	}
+	if err := repo.BuildGraph(); err != nil {
+		t.Fatal(err)
+	}
+
+	cycles := repo.DetectCycles()
+	wantPkgs := [][]PackageID{{{"m", "m/a"}, {"m", "m/b"}}}
+	if !reflect.DeepEqual(cycles.Packages, wantPkgs) {
+		t.Errorf("package cycles = %v, want %v", cycles.Packages, wantPkgs)
+	}
+	wantNodes := [][]Identity{
+		{id("m/c.I"), id("m/c.J"), id("m/c.K")},
+		{id("m/a.F"), id("m/b.G")},
+	}
+	if !reflect.DeepEqual(cycles.Nodes, wantNodes) {
+		t.Errorf("node cycles = %v, want %v", cycles.Nodes, wantNodes)
+	}
+}
+
+func TestRepository_FilterKinds(t *testing.T) {
+	newRepo := func() *Repository {
+		repo := NewRepository("kinds")
+		mod := NewModule("m", ".", Golang)
+		repo.Modules["m"] = mod
+		pkg := NewPackage("m/a")
+		mod.Packages["m/a"] = pkg
+		id := func(name string) Identity { return NewIdentity("m", "m/a", name) }
+		pkg.Types["T"] = &Type{Identity: id("T"), TypeKind: TypeKindStruct, Methods: map[string]Identity{"M": id("T.M")}}
+		pkg.Vars["V"] = &Var{Identity: id("V"), Type: &Identity{ModPath: "m", PkgPath: "m/a", Name: "T"}}
+		pkg.Vars["C"] = &Var{Identity: id("C"), IsConst: true}
+		pkg.Functions["T.M"] = &Function{Identity: id("T.M"), IsMethod: true, Receiver: &Receiver{Type: id("T")}}
+		pkg.Functions["F"] = &Function{
+			Identity:      id("F"),
+			FunctionCalls: []Dependency{NewDependency(id("G"), FileLine{})},
+			MethodCalls:   []Dependency{NewDependency(id("T.M"), FileLine{})},
+			Types:         []Dependency{NewDependency(id("T"), FileLine{})},
+			GlobalVars:    []Dependency{NewDependency(id("V"), FileLine{}), NewDependency(id("C"), FileLine{})},
+		}
+		pkg.Functions["G"] = &Function{Identity: id("G")}
+		return &repo
+	}
+	names := func(repo *Repository) []string {
+		var ret []string
+		for _, fn := range repo.Modules["m"].Packages["m/a"].Functions {
+			ret = append(ret, fn.Name)
+		}
+		for _, t := range repo.Modules["m"].Packages["m/a"].Types {
+			ret = append(ret, t.Name)
+		}
+		for _, v := range repo.Modules["m"].Packages["m/a"].Vars {
+			ret = append(ret, v.Name)
+		}
+		sort.Strings(ret)
+		return ret
+	}
+
+	for _, tt := range []struct {
+		filter KindFilter
+		want   []string
+	}{
+		{KindFilter{}, []string{"C", "F", "G", "T", "T.M", "V"}},
+		{KindFilter{Include: []string{"function", "method"}}, []string{"F", "G", "T.M"}},
+		{KindFilter{Exclude: []string{"var", "const"}}, []string{"F", "G", "T", "T.M"}},
+		{KindFilter{Exclude: []string{"struct", "const"}}, []string{"F", "G", "T.M", "V"}},
+	} {
+		repo := newRepo()
+		repo.FilterKinds(tt.filter)
+		if got := names(repo); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("FilterKinds(%+v) = %v, want %v", tt.filter, got, tt.want)
+		}
+	}
+
+	repo := newRepo()
+	repo.FilterKinds(KindFilter{Include: []string{"function", "method"}})
+	f := repo.GetFunction(NewIdentity("m", "m/a", "F"))
+	if len(f.FunctionCalls) != 1 || len(f.MethodCalls) != 1 || len(f.Types) != 0 || len(f.GlobalVars) != 0 {
+		t.Errorf("dependencies of F = %+v", f)
+	}
+	if err := repo.BuildGraph(); err != nil {
+		t.Fatal(err)
+	}
+	if n := repo.GetNode(NewIdentity("m", "m/a", "V")); n != nil {
+		t.Errorf("removed var is in the graph: %+v", n)
+	}
+
+	if err := (KindFilter{Include: []string{"func"}}).Validate(); err == nil {
+		t.Error("unknown kind should be invalid")
+	}
+}
+
+func TestParseAnnotation(t *testing.T) {
+	for _, tt := range []struct {
+		text string
+		want Annotation
+	}{
+		{"Service", Annotation{Name: "Service"}},
+		{"derive(Debug, Clone)", Annotation{Name: "derive", Args: "Debug, Clone"}},
+		{`app.route("/a(b)", methods=["GET"])`, Annotation{Name: "app.route", Args: `"/a(b)", methods=["GET"]`}},
+		{`path = "a.rs"`, Annotation{Name: "path", Args: `"a.rs"`}},
+		{"go:embed d.go", Annotation{Name: "go:embed", Args: "d.go"}},
+		{"cfg(test", Annotation{Name: "cfg", Args: "test"}},
+	} {
+		if got := ParseAnnotation(tt.text); got != tt.want {
+			t.Errorf("ParseAnnotation(%q) = %+v, want %+v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestRepository_FindAnnotated(t *testing.T) {
+	repo := NewRepository("annotated")
+	mod := NewModule("m", ".", Python)
+	repo.Modules["m"] = mod
+	pkg := NewPackage("m.a")
+	mod.Packages["m.a"] = pkg
+	id := func(name string) Identity { return NewIdentity("m", "m.a", name) }
+	pkg.Functions["index"] = &Function{Identity: id("index"), FileLine: FileLine{File: "a.py", Line: 3},
+		Annotations: []Annotation{{Name: "login_required"}, {Name: "app.route", Args: `"/"`}}}
+	pkg.Functions["about"] = &Function{Identity: id("about"), Annotations: []Annotation{{Name: "app.route", Args: `"/about"`}}}
+	pkg.Functions["helper"] = &Function{Identity: id("helper")}
+	pkg.Types["Model"] = &Type{Identity: id("Model"), Annotations: []Annotation{{Name: "dataclass"}}}
+
+	got := repo.FindAnnotated("app.route")
+	if len(got) != 2 || got[0].Name != "about" || got[1].Name != "index" {
+		t.Fatalf("FindAnnotated(app.route) = %+v", got)
+	}
+	if got[1].Type != FUNC || got[1].File != "a.py" || got[1].Line != 3 || got[1].Annotation.Args != `"/"` {
+		t.Errorf("FindAnnotated(app.route)[1] = %+v", got[1])
+	}
+	if got := repo.FindAnnotated("dataclass"); len(got) != 1 || got[0].Type != TYPE {
+		t.Errorf("FindAnnotated(dataclass) = %+v", got)
+	}
+	if got := repo.FindAnnotated("unknown"); len(got) != 0 {
+		t.Errorf("FindAnnotated(unknown) = %+v", got)
+	}
+}
+
+func TestRepository_ReferencesOf(t *testing.T) {
+	const mod = "m"
+	repo := NewRepository("refs")
+	m := NewModule(mod, ".", Golang)
+	repo.Modules[mod] = m
+	id := func(pkg, name string) Identity { return NewIdentity(mod, pkg, name) }
+	dep := func(id Identity, line int) Dependency { return NewDependency(id, FileLine{Line: line}) }
+	fn := func(f *Function) { repo.SetFunction(f.Identity, f) }
+	typ := func(t *Type) { repo.SetType(t.Identity, t) }
+
+	// a.Reader is implemented by a.File, b.Alias and b.Named are typedefs of a.File
+	typ(&Type{Identity: id("m/a", "Reader"), TypeKind: TypeKindInterface, Methods: map[string]Identity{"Read": id("m/a", "Reader.Read")}})
+	fn(&Function{Identity: id("m/a", "Reader.Read"), IsMethod: true, IsInterfaceMethod: true, Receiver: &Receiver{Type: id("m/a", "Reader")}})
+	typ(&Type{Identity: id("m/a", "File"), TypeKind: TypeKindStruct, Implements: []Identity{id("m/a", "Reader")},
+		Methods: map[string]Identity{"Read": id("m/a", "File.Read")}})
+	fn(&Function{Identity: id("m/a", "File.Read"), FileLine: FileLine{File: "a/file.go", Line: 5}, IsMethod: true, Receiver: &Receiver{Type: id("m/a", "File")}})
+	typ(&Type{Identity: id("m/b", "Alias"), TypeKind: TypeKindTypedef, InlineStruct: []Dependency{dep(id("m/a", "File"), 0)}})
+	typ(&Type{Identity: id("m/b", "Named"), TypeKind: TypeKindTypedef, SubStruct: []Dependency{dep(id("m/b", "Alias"), 0)}})
+
+	fn(&Function{Identity: id("m/a", "Open"), FileLine: FileLine{File: "a/file.go", Line: 10},
+		Results: []Dependency{dep(id("m/a", "File"), 10)}})
+	fn(&Function{Identity: id("m/b", "Use"), FileLine: FileLine{File: "b/use.go", Line: 3},
+		Types: []Dependency{dep(id("m/b", "Named"), 4)}, MethodCalls: []Dependency{dep(id("m/a", "File.Read"), 5)}})
+	fn(&Function{Identity: id("m/b", "Copy"), FileLine: FileLine{File: "b/copy.go", Line: 20},
+		MethodCalls: []Dependency{dep(id("m/a", "Reader.Read"), 22)}})
+	if err := repo.BuildGraph(); err != nil {
+		t.Fatal(err)
+	}
+
+	str := func(refs []Reference) []string {
+		var ret []string
+		for _, ref := range refs {
+			s := fmt.Sprintf("%s %s %s:%d", ref.Node.Name, ref.Kind, ref.File, ref.Line)
+			if ref.Via != nil {
+				s += " via " + ref.Via.Name
+			}
+			ret = append(ret, s)
+		}
+		return ret
+	}
+	if got, want := str(repo.ReferencesOf(id("m/a", "File"))), []string{
+		"File.Read direct a/file.go:5",
+		"Open direct a/file.go:10",
+		"Named typedef :0 via Alias",
+		"Use typedef b/use.go:4 via Named",
+	}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ReferencesOf(File) = %q, want %q", got, want)
+	}
+	if got, want := str(repo.ReferencesOf(id("m/a", "File.Read"))), []string{
+		"Copy interface b/copy.go:22 via Reader.Read",
+		"Use direct b/use.go:5",
+	}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ReferencesOf(File.Read) = %q, want %q", got, want)
+	}
+}
+
+func TestRepository_Dedup(t *testing.T) {
+	const mod = "m"
+	repo := NewRepository("dedup")
+	repo.Modules[mod] = NewModule(mod, ".", Golang)
+	id := func(pkg, name string) Identity { return NewIdentity(mod, pkg, name) }
+	gen := func(pkg, dir string, reqContent string) {
+		req := id(pkg, "Req")
+		repo.SetType(req, &Type{Identity: req, FileLine: FileLine{File: dir + "/req.go"}, Content: reqContent, TypeKind: TypeKindStruct,
+			Methods: map[string]Identity{"Get": id(pkg, "Req.Get")}})
+		repo.SetFunction(id(pkg, "Req.Get"), &Function{Identity: id(pkg, "Req.Get"), FileLine: FileLine{File: dir + "/req.go"},
+			Content: "func (r *Req) Get() int { return r.N }", IsMethod: true, Receiver: &Receiver{Type: req}})
+		repo.SetVar(id(pkg, "Version"), &Var{Identity: id(pkg, "Version"), FileLine: FileLine{File: dir + "/req.go"}, Content: "var Version = 1"})
+	}
+	gen("m/a/kitex_gen/echo", "a/kitex_gen/echo", "type Req struct { N int }")
+	gen("m/b/kitex_gen/echo", "b/kitex_gen/echo", "type Req struct { N int }")
+	gen("m/c/kitex_gen/echo", "c/kitex_gen/echo", "type Req struct { N int64 }")
+	// same codes out of the generated dirs are kept
+	repo.SetVar(id("m/d", "Version"), &Var{Identity: id("m/d", "Version"), FileLine: FileLine{File: "d/d.go"}, Content: "var Version = 1"})
+	repo.SetFunction(id("m/svc", "Handle"), &Function{
+		Identity:    id("m/svc", "Handle"),
+		FileLine:    FileLine{File: "svc/handle.go"},
+		Types:       []Dependency{NewDependency(id("m/a/kitex_gen/echo", "Req"), FileLine{}), NewDependency(id("m/b/kitex_gen/echo", "Req"), FileLine{})},
+		MethodCalls: []Dependency{NewDependency(id("m/b/kitex_gen/echo", "Req.Get"), FileLine{})},
+	})
+	repo.HashNodes()
+
+	// b's Req, Req.Get and Version, c's Version
+	if n := repo.Dedup(); n != 4 {
+		t.Errorf("Dedup() = %d, want 4", n)
+	}
+	a, b := "m/a/kitex_gen/echo", "m/b/kitex_gen/echo"
+	if repo.GetType(id(b, "Req")) != nil || repo.GetFunction(id(b, "Req.Get")) != nil || repo.GetVar(id(b, "Version")) != nil {
+		t.Error("the duplicates are not removed")
+	}
+	if got := repo.GetType(id(a, "Req")).Aliases; !reflect.DeepEqual(got, []Identity{id(b, "Req")}) {
+		t.Errorf("aliases of Req = %v", got)
+	}
+	if got := repo.GetFunction(id(a, "Req.Get")).Aliases; !reflect.DeepEqual(got, []Identity{id(b, "Req.Get")}) {
+		t.Errorf("aliases of Req.Get = %v", got)
+	}
+	if repo.GetType(id("m/c/kitex_gen/echo", "Req")) == nil || repo.GetVar(id("m/d", "Version")) == nil {
+		t.Error("the different or non-generated nodes are removed")
+	}
+	// c's Version is identical to a's
+	if got := repo.GetVar(id(a, "Version")).Aliases; len(got) != 2 {
+		t.Errorf("aliases of Version = %v", got)
+	}
+
+	h := repo.GetFunction(id("m/svc", "Handle"))
+	if len(h.Types) != 1 || h.Types[0].Identity != id(a, "Req") || len(h.MethodCalls) != 1 || h.MethodCalls[0].Identity != id(a, "Req.Get") {
+		t.Errorf("dependencies of Handle = %+v %+v", h.Types, h.MethodCalls)
+	}
+	if err := repo.BuildGraph(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRepository_ExportGraph(t *testing.T) {
+	repo := NewRepository("export")
+	repo.Modules["m"] = NewModule("m", ".", Golang)
+	repo.Modules["ext"] = NewModule("ext", "", Golang)
+	a, b, c := NewIdentity("m", "m/a", "A"), NewIdentity("m", "m/a", "B"), NewIdentity("m", "m/b", "C")
+	ext := NewIdentity("ext", "ext", "X")
+	repo.SetFunction(a, &Function{Identity: a, FileLine: FileLine{File: "a/a.go", Line: 3},
+		FunctionCalls: []Dependency{NewDependency(b, FileLine{}), NewDependency(c, FileLine{}), NewDependency(ext, FileLine{})}})
+	repo.SetFunction(b, &Function{Identity: b, FunctionCalls: []Dependency{NewDependency(c, FileLine{})}})
+	repo.SetType(c, &Type{Identity: c, Implements: []Identity{ext}})
+	if err := repo.BuildGraph(); err != nil {
+		t.Fatal(err)
+	}
+
+	g := repo.ExportGraph(ExportOptions{})
+	var ids []string
+	for _, v := range g.Vertices {
+		ids = append(ids, v.ID)
+	}
+	if want := []string{a.Full(), b.Full(), c.Full()}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("vertices = %v, want %v", ids, want)
+	}
+	if g.Vertices[0].File != "a/a.go" || g.Vertices[0].Line != 3 || g.Vertices[2].Kind != "TYPE" {
+		t.Errorf("unexpected vertices %+v", g.Vertices)
+	}
+	if len(g.Edges) != 3 {
+		t.Errorf("edges = %+v", g.Edges)
+	}
+
+	g = repo.ExportGraph(ExportOptions{Granularity: GranularityPackage, External: true})
+	var edges []string
+	for _, e := range g.Edges {
+		edges = append(edges, fmt.Sprintf("%s->%s %s %d", e.From, e.To, e.Kind, e.Count))
+	}
+	want := []string{
+		"m?m/a->ext?ext Dependency 1",
+		"m?m/a->m?m/b Dependency 2",
+		"m?m/b->ext?ext Implement 1",
+	}
+	if !reflect.DeepEqual(edges, want) {
+		t.Errorf("package edges = %v, want %v", edges, want)
+	}
+	if len(g.Vertices) != 3 || !g.Vertices[0].External || g.Vertices[0].Kind != PackageKind {
+		t.Errorf("package vertices = %+v", g.Vertices)
+	}
+
+	var dot strings.Builder
+	if err := g.WriteDOT(&dot); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(dot.String(), `"m?m/a" -> "m?m/b" [label="2", weight=2];`) || !strings.Contains(dot.String(), `"ext?ext" [label="ext", shape=folder, style=dashed];`) {
+		t.Errorf("unexpected DOT:\n%s", dot.String())
+	}
+
+	var graphml strings.Builder
+	if err := g.WriteGraphML(&graphml); err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Graph struct {
+			Nodes []struct {
+				ID string `xml:"id,attr"`
+			} `xml:"node"`
+			Edges []struct {
+				Source string `xml:"source,attr"`
+			} `xml:"edge"`
+		} `xml:"graph"`
+	}
+	if err := xml.Unmarshal([]byte(graphml.String()), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Graph.Nodes) != 3 || len(doc.Graph.Edges) != 3 {
+		t.Errorf("unexpected GraphML:\n%s", graphml.String())
+	}
+
+	var nodes, rels strings.Builder
+	if err := g.WriteNeo4j(&nodes, &rels); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(strings.NewReader(rels.String())).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 4 || !reflect.DeepEqual(records[3], []string{"m?m/b", "ext?ext", "IMPLEMENT", "1"}) {
+		t.Errorf("unexpected relationships %v", records)
+	}
+	if !strings.Contains(nodes.String(), "ext?ext,ext,ext,ext,,,PACKAGE;External\n") {
+		t.Errorf("unexpected nodes:\n%s", nodes.String())
+	}
+}
+
+func TestRepository_Diagrams(t *testing.T) {
+	repo := NewRepository("diagram")
+	repo.Modules["m"] = NewModule("m", ".", Golang)
+	repo.Modules["ext"] = NewModule("ext", "", Golang)
+	serve, parse, save := NewIdentity("m", "m/api", "Serve"), NewIdentity("m", "m/api", "parse"), NewIdentity("m", "m/store", "Store.Save")
+	ext := NewIdentity("ext", "ext/sql", "Exec")
+	repo.SetFunction(serve, &Function{Identity: serve, FunctionCalls: []Dependency{
+		NewDependency(save, FileLine{Line: 5}), NewDependency(parse, FileLine{Line: 3}), NewDependency(parse, FileLine{Line: 7})}})
+	repo.SetFunction(parse, &Function{Identity: parse, FunctionCalls: []Dependency{NewDependency(serve, FileLine{Line: 12})}})
+	repo.SetFunction(save, &Function{Identity: save, MethodCalls: []Dependency{NewDependency(ext, FileLine{Line: 20})}})
+	if err := repo.BuildGraph(); err != nil {
+		t.Fatal(err)
+	}
+
+	g := repo.ExportGraph(ExportOptions{Granularity: GranularityPackage})
+	var flow strings.Builder
+	if err := g.Relabel(map[string]string{"m?m/store": "Storage"}).WriteMermaid(&flow); err != nil {
+		t.Fatal(err)
+	}
+	want := "flowchart LR\n  n0[[\"m/api\"]]\n  n1[[\"Storage\"]]\n  n0 --> n1\n"
+	if flow.String() != want {
+		t.Errorf("mermaid flowchart:\n%s\nwant:\n%s", flow.String(), want)
+	}
+	if g.Vertices[1].Name != "m/store" {
+		t.Error("Relabel changes the original graph")
+	}
+	if g := repo.ExportGraph(ExportOptions{PkgPrefix: "m/store"}); len(g.Vertices) != 1 || len(g.Edges) != 0 {
+		t.Errorf("graph of m/store = %+v", g)
+	}
+
+	if _, err := repo.CallSequence(NewIdentity("m", "m/api", "Unknown"), SequenceOptions{}); err == nil {
+		t.Error("expect an error for unknown entry")
+	}
+	seq, err := repo.CallSequence(serve, SequenceOptions{External: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var calls []string
+	for _, c := range seq.Calls {
+		calls = append(calls, fmt.Sprintf("%d->%d %s %d", c.From, c.To, c.Name, c.Depth))
+	}
+	if want := []string{"0->0 parse 1", "0->0 Serve 2", "0->1 Store.Save 1", "1->2 Exec 2"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+	var sd strings.Builder
+	if err := seq.Relabel(map[string]string{save.Full(): "save the order"}).WriteMermaid(&sd); err != nil {
+		t.Fatal(err)
+	}
+	want = `sequenceDiagram
+  participant p0 as m/api
+  participant p1 as m/store
+  participant p2 as ext/sql
+  Note over p0: Serve
+  p0->>p0: parse
+  activate p0
+  p0->>p0: Serve
+  deactivate p0
+  p0->>p1: save the order
+  activate p1
+  p1->>p2: Exec
+  deactivate p1
+`
+	if sd.String() != want {
+		t.Errorf("mermaid sequence:\n%s\nwant:\n%s", sd.String(), want)
+	}
+	if seq, _ := repo.CallSequence(serve, SequenceOptions{MaxDepth: 1}); len(seq.Calls) != 2 || len(seq.Participants) != 2 {
+		t.Errorf("calls of depth 1 = %+v", seq.Calls)
+	}
+}
+
+func TestComplexity(t *testing.T) {
+	tests := []struct {
+		name    string
+		lang    Language
+		content string
+		want    int
+	}{
+		{"empty", Golang, "", 0},
+		{"straight", Golang, "func f() { return }", 1},
+		{"go branches", Golang, "func f(a, b bool) {\n\tif a && b {\n\t} else if a || b {\n\t}\n\tfor {\n\t\tswitch {\n\t\tcase a:\n\t\tdefault:\n\t\t}\n\t}\n}", 7},
+		{"comments and strings", Golang, "func f() {\n\t// if a && b\n\t/* for */\n\ts := \"if || case\"\n\tr := `for`\n\t_ = iffy\n}", 1},
+		{"python", Python, "def f(a, b):\n    # if\n    if a and b:\n        pass\n    elif a or b:\n        pass\n    s = 'if'\n", 5},
+		{"ternary", TypeScript, "function f(a) { return a?.b ?? (a ? 1 : 2) }", 3},
+		{"ruby", Ruby, "def f(a)\n  # if\n  return 1 if a.empty? && b\n  x unless a\nend", 4},
+		{"rust match", Rust, "fn f<'a>(x: &'a str) { match x { \"a\" => 1, _ => 2 } }", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Complexity(tt.lang, tt.content); got != tt.want {
+				t.Errorf("Complexity() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepository_ComputeMetrics(t *testing.T) {
+	r := NewRepository("a")
+	mod := NewModule("a", ".", Golang)
+	pkg := NewPackage("a/p")
+	callee := NewIdentity("a", "a/p", "callee")
+	pkg.Functions["callee"] = &Function{
+		Identity: callee,
+		Content:  "func callee(x int) int {\n\tif x > 0 {\n\t\treturn x\n\t}\n\treturn -x\n}",
+	}
+	pkg.Functions["caller"] = &Function{
+		Identity:      NewIdentity("a", "a/p", "caller"),
+		Content:       "func caller() int {\n\treturn callee(1) + callee(2)\n}",
+		FunctionCalls: []Dependency{{Identity: callee}, {Identity: callee}},
+	}
+	mod.Packages[pkg.PkgPath] = pkg
+	r.Modules[mod.Name] = mod
+	if err := r.BuildGraph(); err != nil {
+		t.Fatal(err)
+	}
+	r.ComputeMetrics()
+
+	if got, want := *pkg.Functions["callee"].Metrics, (Metrics{LOC: 6, Complexity: 2, FanIn: 1}); got != want {
+		t.Errorf("callee metrics = %+v, want %+v", got, want)
+	}
+	if got, want := *pkg.Functions["caller"].Metrics, (Metrics{LOC: 3, Complexity: 1, FanOut: 1}); got != want {
+		t.Errorf("caller metrics = %+v, want %+v", got, want)
+	}
+}
+
+func TestRepository_AnnotateOwners(t *testing.T) {
+	r := NewRepository("a")
+	mod := NewModule("a", ".", Golang)
+	pkg := NewPackage("a/p")
+	pkg.Functions["f"] = &Function{
+		Identity: NewIdentity("a", "a/p", "f"),
+		FileLine: FileLine{File: "p/f.go", Line: 3},
+		Content:  "func f() {\n\tg()\n\tg()\n}",
+	}
+	pkg.Vars["v"] = &Var{
+		Identity: NewIdentity("a", "a/p", "v"),
+		FileLine: FileLine{File: "p/f.go", Line: 8},
+		Content:  "var v = 1",
+	}
+	mod.Packages[pkg.PkgPath] = pkg
+	r.Modules[mod.Name] = mod
+
+	day := func(d int) time.Time { return time.Date(2025, 1, d, 0, 0, 0, 0, time.UTC) }
+	alice := BlameLine{Name: "alice", Email: "alice@example.com", Time: day(1)}
+	bob := BlameLine{Name: "bob", Email: "bob@example.com", Time: day(2)}
+	blamed := 0
+	r.AnnotateOwners(func(file string) ([]BlameLine, error) {
+		blamed++
+		if file != "p/f.go" {
+			return nil, fmt.Errorf("unexpected file %s", file)
+		}
+		// the last line of f and v are uncommitted
+		return []BlameLine{alice, alice, alice, bob, alice, {}, alice, {}}, nil
+	})
+	if blamed != 1 {
+		t.Errorf("blamed %d times", blamed)
+	}
+	want := &Ownership{
+		Authors:      []Owner{{Name: "alice", Email: "alice@example.com", Lines: 2}, {Name: "bob", Email: "bob@example.com", Lines: 1}},
+		LastModified: day(2),
+	}
+	if got := pkg.Functions["f"].Owners; !reflect.DeepEqual(got, want) {
+		t.Errorf("owners of f = %+v, want %+v", got, want)
+	}
+	if got := pkg.Vars["v"].Owners; got != nil {
+		t.Errorf("owners of uncommitted v = %+v", got)
+	}
+}
+
+func TestRepository_CollectComments(t *testing.T) {
+	r := NewRepository("a")
+	mod := NewModule("a", ".", Golang)
+	pkg := NewPackage("a/p")
+	id := func(name string) Identity { return NewIdentity("a", "a/p", name) }
+	pkg.Functions["f"] = &Function{Identity: id("f"), FileLine: FileLine{File: "p/f.go", Line: 8}, Content: "// f does\nfunc f() {\n\t// TODO(alice): handle errors\n\ts := \"// not a comment\"\n\t_ = s\n}"}
+	pkg.Vars["v"] = &Var{Identity: id("v"), FileLine: FileLine{File: "p/f.go", Line: 17}, Content: "var v = '/'"}
+	pkg.Functions["g"] = &Function{Identity: id("g"), FileLine: FileLine{File: "p/g.py", Line: 4, EndLine: 6}}
+	mod.Packages[pkg.PkgPath] = pkg
+	for _, f := range []string{"p/f.go", "p/g.py", "README.md"} {
+		mod.Files[f] = NewFile(f)
+	}
+	r.Modules[mod.Name] = mod
+
+	files := map[string]string{
+		"p/f.go": "// Copyright 2025 Foo\n// Licensed under MIT\n\npackage p\n\n// ===== Handlers =====\n\n" + pkg.Functions["f"].Content +
+			"\n\n/* FIXME: v is racy */\n\nvar v = '/'\n\n// trailing notes\n",
+		"p/g.py": "# TODO: split the module\nimport os\n\ndef g():\n    \"\"\"# not a comment\"\"\"\n    return \"#\"  # XXX(bob) magic\n",
+	}
+	r.CollectComments(func(file string) ([]byte, error) {
+		if content, ok := files[file]; ok {
+			return []byte(content), nil
+		}
+		return nil, fmt.Errorf("unexpected file %s", file)
+	})
+	f, v, g := id("f"), id("v"), id("g")
+	want := []Comment{
+		{Kind: CommentLicense, Text: "// Copyright 2025 Foo\n// Licensed under MIT", Line: 1, EndLine: 2},
+		{Kind: CommentBanner, Text: "// ===== Handlers =====", Line: 6, EndLine: 6, Node: &f},
+		{Kind: CommentTodo, Text: "// TODO(alice): handle errors", Line: 10, EndLine: 10, Node: &f},
+		{Kind: CommentTodo, Text: "/* FIXME: v is racy */", Line: 15, EndLine: 15, Node: &v},
+		{Text: "// trailing notes", Line: 19, EndLine: 19},
+	}
+	if got := mod.Files["p/f.go"].Comments; !reflect.DeepEqual(got, want) {
+		t.Errorf("comments of f.go = %+v, want %+v", got, want)
+	}
+	want = []Comment{
+		{Kind: CommentTodo, Text: "# TODO: split the module", Line: 1, EndLine: 1, Node: &g},
+		{Kind: CommentTodo, Text: "# XXX(bob) magic", Line: 6, EndLine: 6, Node: &g},
+	}
+	if got := mod.Files["p/g.py"].Comments; !reflect.DeepEqual(got, want) {
+		t.Errorf("comments of g.py = %+v, want %+v", got, want)
+	}
+
+	todos := []Todo{
+		{Marker: "TODO", Owner: "alice", Message: "handle errors", File: "p/f.go", Line: 10, Node: &f},
+		{Marker: "FIXME", Message: "v is racy", File: "p/f.go", Line: 15, Node: &v},
+		{Marker: "TODO", Message: "split the module", File: "p/g.py", Line: 1, Node: &g},
+		{Marker: "XXX", Owner: "bob", Message: "magic", File: "p/g.py", Line: 6, Node: &g},
+	}
+	if got := r.Todos(""); !reflect.DeepEqual(got, todos) {
+		t.Errorf("todos = %+v, want %+v", got, todos)
+	}
+	if got := r.Todos("FIXME"); len(got) != 1 || got[0].Line != 15 {
+		t.Errorf("FIXMEs = %+v", got)
+	}
+}
+
+func TestRepository_UnreachableNodes(t *testing.T) {
+	r := NewRepository("a")
+	mod := NewModule("a", ".", Golang)
+	pkg := NewPackage("a/p")
+	id := func(name string) Identity { return NewIdentity("a", "a/p", name) }
+	fn := func(name string, calls ...string) *Function {
+		f := &Function{Identity: id(name), Exported: name[0] >= 'A' && name[0] <= 'Z'}
+		for _, c := range calls {
+			f.FunctionCalls = append(f.FunctionCalls, Dependency{Identity: id(c)})
+		}
+		pkg.Functions[name] = f
+		return f
+	}
+	method := func(recv, name string, calls ...string) *Function {
+		f := fn(recv+"."+name, calls...)
+		f.IsMethod, f.Exported = true, name[0] >= 'A' && name[0] <= 'Z'
+		f.Receiver = &Receiver{Type: id(recv)}
+		return f
+	}
+	fn("main", "run", "Shape")
+	fn("run", "Shape.Area")
+	fn("init", "setup")
+	fn("setup")
+	fn("dead", "deadToo")
+	fn("deadToo")
+	fn("TestRun", "run").IsTest = true
+	// interface Shape is implemented by Square, whose Area is reached through Shape.Area
+	method("Shape", "Area").IsInterfaceMethod = true
+	pkg.Types["Shape"] = &Type{Identity: id("Shape"), TypeKind: "interface", Methods: map[string]Identity{"Area": id("Shape.Area")}}
+	method("Square", "Area", "helper")
+	method("Square", "unused")
+	fn("helper")
+	pkg.Types["Square"] = &Type{Identity: id("Square"), Exported: true, Implements: []Identity{id("Shape")}, Methods: map[string]Identity{"Area": id("Square.Area"), "unused": id("Square.unused")}}
+	// Printer implements the external fmt.Stringer, thus String is reached with Printer
+	method("Printer", "String")
+	pkg.Types["Printer"] = &Type{Identity: id("Printer"), Exported: true, Implements: []Identity{NewIdentity("", "fmt", "Stringer")}, Methods: map[string]Identity{"String": id("Printer.String")}}
+	pkg.Vars["Version"] = &Var{Identity: id("Version"), IsExported: true}
+	mod.Packages[pkg.PkgPath] = pkg
+	r.Modules[mod.Name] = mod
+	if err := r.BuildGraph(); err != nil {
+		t.Fatal(err)
+	}
+
+	names := func(ids []Identity) []string {
+		var ret []string
+		for _, id := range ids {
+			ret = append(ret, id.Name)
+		}
+		return ret
+	}
+	got := names(r.UnreachableNodes(r.Entrypoints(false)))
+	want := []string{"Printer", "Printer.String", "Square.unused", "Version", "dead", "deadToo"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unreachable = %v, want %v", got, want)
+	}
+
+	got = names(r.UnreachableNodes(r.Entrypoints(true)))
+	want = []string{"Square.unused", "dead", "deadToo"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unreachable with api = %v, want %v", got, want)
+	}
+}
+
+func TestRepository_RenameNode(t *testing.T) {
+	const file = "p/a.go"
+	text := "package p\n\n// Server serves\ntype Server struct{ n int }\n\nfunc (s *Server) Start() int { return s.n }\n\nfunc NewServer() *Server { return &Server{} }\n\nfunc run() int { s := NewServer(); return s.Start() }\n"
+	r := NewRepository("a")
+	mod := NewModule("a", ".", Golang)
+	pkg := NewPackage("a/p")
+	mod.Packages[pkg.PkgPath] = pkg
+	r.Modules[mod.Name] = mod
+	id := func(name string) Identity { return NewIdentity("a", "a/p", name) }
+	// the file line of the n-th occurrence of the codes
+	fl := func(code string, n int) FileLine {
+		off := -1
+		for ; n >= 0; n-- {
+			off += 1 + strings.Index(text[off+1:], code)
+		}
+		return FileLine{File: file, Line: strings.Count(text[:off], "\n") + 1, StartOffset: off, EndOffset: off + len(code)}
+	}
+	node := func(code string) (FileLine, string) {
+		start := strings.Index(text, code)
+		end := start + strings.Index(text[start:], "\n")
+		f := FileLine{File: file, Line: strings.Count(text[:start], "\n") + 1, StartOffset: start, EndOffset: end}
+		return f, text[start:end]
+	}
+	f, c := node("// Server serves")
+	f.EndOffset = strings.Index(text, "int }") + 5
+	pkg.Types["Server"] = &Type{Identity: id("Server"), FileLine: f, Content: text[f.StartOffset:f.EndOffset], TypeKind: TypeKindStruct,
+		Methods: map[string]Identity{"Start": id("Server.Start")}}
+	f, c = node("func (s *Server) Start")
+	pkg.Functions["Server.Start"] = &Function{Identity: id("Server.Start"), FileLine: f, Content: c, IsMethod: true, Receiver: &Receiver{Type: id("Server")},
+		Signature: "func (s *Server) Start() int"}
+	f, c = node("func NewServer")
+	pkg.Functions["NewServer"] = &Function{Identity: id("NewServer"), FileLine: f, Content: c,
+		Results: []Dependency{NewDependency(id("Server"), fl("*Server", 1))},
+		Types:   []Dependency{NewDependency(id("Server"), fl("Server{", 1))}}
+	f, c = node("func run")
+	pkg.Functions["run"] = &Function{Identity: id("run"), FileLine: f, Content: c,
+		FunctionCalls: []Dependency{NewDependency(id("NewServer"), fl("NewServer", 1))},
+		MethodCalls:   []Dependency{NewDependency(id("Server.Start"), fl("s.Start", 0))}}
+	r.HashNodes()
+	if err := r.BuildGraph(); err != nil {
+		t.Fatal(err)
+	}
+
+	ret, err := r.RenameNode(id("Server"), "Host")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ret.Renamed, map[Identity]Identity{id("Server"): id("Host"), id("Server.Start"): id("Host.Start")}) {
+		t.Errorf("renamed = %v", ret.Renamed)
+	}
+	if !reflect.DeepEqual(ret.Nodes, []Identity{id("Host"), id("Host.Start"), id("NewServer")}) || !reflect.DeepEqual(ret.Files, []string{file}) {
+		t.Errorf("nodes = %v, files = %v", ret.Nodes, ret.Files)
+	}
+	if r.GetType(id("Server")) != nil || r.GetFunction(id("Server.Start")) != nil {
+		t.Error("the old nodes are kept")
+	}
+	host, start := r.GetType(id("Host")), r.GetFunction(id("Host.Start"))
+	if host == nil || start == nil {
+		t.Fatal("the renamed nodes are not found")
+	}
+	if host.Content != "// Host serves\ntype Host struct{ n int }" || host.Methods["Start"] != id("Host.Start") || host.Hash != ContentHash(host.Content) {
+		t.Errorf("type = %+v", host)
+	}
+	if start.Content != "func (s *Host) Start() int { return s.n }" || start.Receiver.Type != id("Host") || start.Signature != "func (s *Host) Start() int" {
+		t.Errorf("method = %+v", start)
+	}
+	newServer := r.GetFunction(id("NewServer"))
+	if newServer.Content != "func NewServer() *Host { return &Host{} }" || newServer.Results[0].Identity != id("Host") {
+		t.Errorf("NewServer = %+v", newServer)
+	}
+	if r.GetNode(id("Host")) == nil || r.GetNode(id("Server")) != nil {
+		t.Error("the graph is not rebuilt")
+	}
+
+	// the offsets are shifted as the codes are written back
+	text = strings.NewReplacer("Server serves", "Host serves", "type Server", "type Host", "*Server", "*Host", "&Server", "&Host").Replace(text)
+	for _, fn := range []*Function{start, newServer, r.GetFunction(id("run"))} {
+		if got := text[fn.StartOffset:fn.EndOffset]; got != fn.Content {
+			t.Errorf("codes of %s at the offsets = %q", fn.Name, got)
+		}
+	}
+	if d := r.GetFunction(id("run")).MethodCalls[0]; text[d.StartOffset:d.EndOffset] != "s.Start" || d.Identity != id("Host.Start") {
+		t.Errorf("method call = %+v", d)
+	}
+
+	// the method call is rewritten
+	ret, err = r.RenameNode(id("Host.Start"), "Run")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := r.GetFunction(id("run")).Content; got != "func run() int { s := NewServer(); return s.Run() }" {
+		t.Errorf("run = %q", got)
+	}
+	if r.GetFunction(id("Host.Run")) == nil || host.Methods["Run"] != id("Host.Run") || len(host.Methods) != 1 {
+		t.Errorf("methods = %v", host.Methods)
+	}
+	if !reflect.DeepEqual(ret.Nodes, []Identity{id("Host.Run"), id("run")}) {
+		t.Errorf("nodes = %v", ret.Nodes)
+	}
+
+	for _, tt := range []struct {
+		id   Identity
+		name string
+	}{
+		{id("NewServer"), "run"},
+		{id("NewServer"), "a.b"},
+		{id("Nothing"), "Something"},
+		{NewIdentity("fmt", "fmt", "Println"), "Print"},
+	} {
+		if _, err := r.RenameNode(tt.id, tt.name); err == nil {
+			t.Errorf("RenameNode(%s, %s) succeeded", tt.id.Full(), tt.name)
+		}
+	}
+}
+
+func TestRepository_ExternalDependencies(t *testing.T) {
+	repo := NewRepository("github.com/a/svc")
+	mod := NewModule("github.com/a/svc", ".", Golang)
+	mod.Dependencies = map[string]string{
+		"github.com/a/svc":     "github.com/a/svc",
+		"github.com/a/lib":     "./lib",
+		"github.com/b/sonic":   "github.com/b/sonic@v1.2.0",
+		"github.com/c/errors":  "github.com/c/errors@v0.9.1",
+		"github.com/a/svc/api": "github.com/a/svc/api@v0.1.0",
+	}
+	pkg := NewPackage("github.com/a/svc/handler")
+	pkg.Functions["Handle"] = &Function{
+		Identity:      NewIdentity("github.com/a/svc", "github.com/a/svc/handler", "Handle"),
+		FunctionCalls: []Dependency{NewDependency(NewIdentity("github.com/d/log@v1.0.0", "github.com/d/log", "Info"), FileLine{})},
+	}
+	mod.Packages[pkg.PkgPath] = pkg
+	repo.Modules[mod.Name] = mod
+	api := NewModule("github.com/a/svc/api", "api", Golang)
+	api.Dependencies = map[string]string{"github.com/b/sonic": "github.com/b/sonic@v1.2.0"}
+	repo.Modules[api.Name] = api
+	repo.Modules["github.com/d/log@v1.0.0"] = NewModule("github.com/d/log@v1.0.0", "", Golang)
+	repo.Modules["fmt"] = NewModule("fmt", "", Golang)
+
+	got := repo.ExternalDependencies()
+	want := []ExternalDependency{
+		{Name: "github.com/b/sonic", Version: "v1.2.0", Language: Golang, Dependents: []string{"github.com/a/svc", "github.com/a/svc/api"}},
+		{Name: "github.com/c/errors", Version: "v0.9.1", Language: Golang, Dependents: []string{"github.com/a/svc"}},
+		{Name: "github.com/d/log", Version: "v1.0.0", Language: Golang, Module: "github.com/d/log@v1.0.0", Dependents: []string{"github.com/a/svc"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ExternalDependencies() = %+v, want %+v", got, want)
+	}
+}
+
+func TestDetectLicense(t *testing.T) {
+	mit := "MIT License\n\nPermission is hereby granted, free of charge, to any person obtaining a copy"
+	apache := "Apache License\nVersion 2.0, January 2004\n\nLicensed under the Apache License, Version 2.0"
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{"mit", map[string]string{"LICENSE": mit}, "MIT"},
+		{"dual", map[string]string{"LICENSE-MIT": mit, "LICENSE-APACHE": apache}, "Apache-2.0 OR MIT"},
+		{"bsd", map[string]string{"COPYING.txt": "Redistributions of source code must retain the above copyright notice.\nNeither the name of the copyright holder"}, "BSD-3-Clause"},
+		{"unknown", map[string]string{"LICENSE": "all rights reserved"}, ""},
+		{"none", map[string]string{"README.md": mit}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := DetectLicense(dir); got != tt.want {
+				t.Errorf("DetectLicense() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepository_NodeAt(t *testing.T) {
+	dir := t.TempDir()
+	src := "package a\n\nvar V = 1\n\nfunc F() {\n\tg := func() { println() }\n\t_ = g\n}\n\ntype T struct {\n\tA int\n}\n"
+	if err := os.MkdirAll(filepath.Join(dir, "a"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "a", "a.go"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fl := func(text string, line, endLine int) FileLine {
+		off := strings.Index(src, text)
+		return FileLine{File: "a/a.go", Line: line, EndLine: endLine, StartOffset: off, EndOffset: off + len(text)}
+	}
+
+	repo := NewRepository("spans")
+	repo.Path = dir
+	mod := NewModule("m", ".", Golang)
+	repo.Modules["m"] = mod
+	id := func(name string) Identity { return NewIdentity("m", "m/a", name) }
+	fn := src[strings.Index(src, "func F"):strings.Index(src, "\n\ntype")]
+	closure := "func() { println() }"
+	repo.SetVar(id("V"), &Var{Identity: id("V"), FileLine: fl("var V = 1", 3, 3), Content: "var V = 1"})
+	repo.SetFunction(id("F"), &Function{Identity: id("F"), FileLine: fl(fn, 5, 8), Content: fn})
+	repo.SetFunction(id("F.func1"), &Function{Identity: id("F.func1"), FileLine: fl(closure, 6, 6), Content: closure, ParentFunction: &Identity{ModPath: "m", PkgPath: "m/a", Name: "F"}})
+	// EndLine is unknown in the ASTs of old versions
+	typ := "type T struct {\n\tA int\n}"
+	tfl := fl(typ, 10, 0)
+	repo.SetType(id("T"), &Type{Identity: id("T"), FileLine: tfl, Content: typ, TypeKind: TypeKindStruct})
+	if err := repo.BuildGraph(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		file      string
+		line, col int
+		want      string
+	}{
+		{"a/a.go", 3, 0, "V"},
+		{"a/a.go", 5, 0, "F"},
+		{"a/a.go", 6, 0, "F.func1"},
+		{"a/a.go", 6, 7, "F.func1"},
+		{"a/a.go", 6, 2, "F"}, // `g` is outside of the closure
+		{"./a/a.go", 8, 0, "F"},
+		{filepath.Join(dir, "a", "a.go"), 7, 1, "F"},
+		{"a/a.go", 11, 0, "T"},
+		{"/build/ci/src/spans/a/a.go", 6, 0, "F.func1"},
+		{"a/a.go", 4, 0, ""},
+		{"a/a.go", 13, 0, ""},
+		{"a/b.go", 3, 0, ""},
+	} {
+		var got string
+		if n := repo.NodeAt(tt.file, tt.line, tt.col); n != nil {
+			got = n.Identity.Name
+		}
+		if got != tt.want {
+			t.Errorf("NodeAt(%s, %d, %d) = %q, want %q", tt.file, tt.line, tt.col, got, tt.want)
+		}
+	}
+}
+
+func TestRepository_PublicAPI(t *testing.T) {
+	r := NewRepository("a")
+	mod := NewModule("a", ".", Golang)
+	pkg := NewPackage("a/p")
+	id := func(name string) Identity { return NewIdentity("a", "a/p", name) }
+	pkg.Types["Server"] = &Type{
+		Exported: true,
+		TypeKind: TypeKindStruct,
+		Identity: id("Server"),
+		Content:  "type Server struct {\n\tAddr string `json:\"addr\"`\n\tmu sync.Mutex\n\t*Base\n}",
+		Fields: []Field{
+			{Name: "Addr", Type: "string", Tag: `json:"addr"`},
+			{Name: "mu", Type: "sync.Mutex"},
+			{Name: "Base", Type: "*Base", Embedded: true},
+		},
+	}
+	pkg.Types["conn"] = &Type{Identity: id("conn"), Content: "type conn struct{}"}
+	pkg.Functions["Server.Serve"] = &Function{
+		Exported: true,
+		IsMethod: true,
+		Identity: id("Server.Serve"),
+		Content:  "// Serve serves\nfunc (s *Server) Serve(opts struct{ N int }) (err error) {\n\treturn nil\n}",
+		Receiver: &Receiver{Type: id("Server")},
+	}
+	pkg.Functions["conn.Close"] = &Function{Exported: true, IsMethod: true, Identity: id("conn.Close"), Receiver: &Receiver{Type: id("conn")}}
+	pkg.Functions["New"] = &Function{Exported: true, Identity: id("New"), Content: "func New() *Server { return nil }", Signature: "func New() *Server"}
+	pkg.Functions["TestNew"] = &Function{Exported: true, IsTest: true, Identity: id("TestNew")}
+	pkg.Vars["Default"] = &Var{IsExported: true, Identity: id("Default"), Content: "var Default = map[string]int{\"a\": 1}"}
+	pkg.Vars["count"] = &Var{Identity: id("count"), Content: "var count int"}
+	internal := NewPackage("a/internal/q")
+	internal.Functions["Q"] = &Function{Exported: true, Identity: NewIdentity("a", "a/internal/q", "Q")}
+	mod.Packages[pkg.PkgPath] = pkg
+	mod.Packages[internal.PkgPath] = internal
+	r.Modules[mod.Name] = mod
+
+	got, err := r.PublicAPI("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []APIEntry{
+		{Identity: id("Default"), Type: VAR, Signature: "var Default = map[string]int"},
+		{Identity: id("New"), Type: FUNC, Signature: "func New() *Server"},
+		{Identity: id("Server"), Type: TYPE, Signature: "type Server struct {\n\tAddr string `json:\"addr\"`\n\t*Base\n}"},
+		{Identity: id("Server.Serve"), Type: FUNC, Signature: "func (s *Server) Serve(opts struct{ N int }) (err error)"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("PublicAPI() = %+v, want %+v", got, want)
+	}
+	if _, err := r.PublicAPI("b"); err == nil {
+		t.Error("PublicAPI() of a missing module should fail")
+	}
+
+	next := append([]APIEntry{}, got[1:]...)
+	next[0].Signature = "func New(addr string) *Server"
+	next = append(next, APIEntry{Identity: id("Shutdown"), Type: FUNC, Signature: "func Shutdown()"})
+	diff := DiffAPI(got, next)
+	if len(diff.Added) != 1 || diff.Added[0].Name != "Shutdown" || len(diff.Removed) != 1 || diff.Removed[0].Name != "Default" ||
+		len(diff.Changed) != 1 || diff.Changed[0].New.Signature != "func New(addr string) *Server" {
+		t.Errorf("DiffAPI() = %+v", diff)
+	}
+}
+
+func TestRepository_Slim(t *testing.T) {
+	r := NewRepository("a")
+	mod := NewModule("a", ".", Golang)
+	pkg := NewPackage("a/p")
+	fn := &Function{
+		Exported: true,
+		Identity: NewIdentity("a", "a/p", "Serve"),
+		Content:  "// Serve serves\n// forever\nfunc Serve(addr string) error {\n\treturn nil\n}",
+		FunctionCalls: []Dependency{
+			{Identity: NewIdentity("b@v1.0.0", "b/q", "Q")},
+		},
+	}
+	pkg.Functions["Serve"] = fn
+	pkg.Types["S"] = &Type{Identity: NewIdentity("a", "a/p", "S"), Content: "type S struct {\n\tn int\n}"}
+	mod.Packages["a/p"] = pkg
+	r.Modules["a"] = mod
+	ext := NewModule("b@v1.0.0", "", Golang)
+	ext.Packages["b/q"] = NewPackage("b/q")
+	ext.Packages["b/q"].Functions["Q"] = &Function{Identity: NewIdentity("b@v1.0.0", "b/q", "Q"), Content: "func Q() {}"}
+	r.Modules["b@v1.0.0"] = ext
+	jmod := NewModule("j", "j", Java)
+	jpkg := NewPackage("j.p")
+	jpkg.Types["C"] = &Type{Identity: NewIdentity("j", "j.p", "C"), Content: "/**\n * C does\n */\n@Deprecated\npublic class C {\n\tint n;\n}"}
+	jmod.Packages["j.p"] = jpkg
+	r.Modules["j"] = jmod
+	if err := r.BuildGraph(); err != nil {
+		t.Fatal(err)
+	}
+
+	if r.Slim(SlimOptions{}) != &r {
+		t.Error("empty options should keep the repository")
+	}
+	s := r.Slim(SlimOptions{StripContent: true, Internal: true})
+	if len(s.Modules) != 2 || s.Modules["b@v1.0.0"] != nil {
+		t.Errorf("modules = %v", s.Modules)
+	}
+	got := s.Modules["a"].Packages["a/p"].Functions["Serve"]
+	if got.Content != "// Serve serves\n// forever\nfunc Serve(addr string) error" || got.Signature != "func Serve(addr string) error" || len(got.FunctionCalls) != 1 {
+		t.Errorf("stripped function = %+v", got)
+	}
+	if got := s.Modules["a"].Packages["a/p"].Types["S"].Content; got != "type S struct {\n\tn int\n}" {
+		t.Errorf("go type content = %q", got)
+	}
+	if got := s.Modules["j"].Packages["j.p"].Types["C"].Content; got != "/**\n * C does\n */\n@Deprecated\npublic class C" {
+		t.Errorf("java type content = %q", got)
+	}
+	if s.Graph["b@v1.0.0?b/q#Q"] != nil || s.Graph["a?a/p#Serve"] == nil || len(s.Graph["a?a/p#Serve"].Dependencies) != 1 {
+		t.Errorf("graph = %v", s.Graph)
+	}
+	// the original is untouched
+	if fn.Content == got.Content || fn.Signature != "" || len(r.Modules) != 3 || r.Graph["b@v1.0.0?b/q#Q"] == nil {
+		t.Error("the repository is modified")
+	}
+
+	s = r.Slim(SlimOptions{Modules: []string{"b"}})
+	if len(s.Modules) != 1 || s.Modules["b@v1.0.0"].Packages["b/q"].Functions["Q"].Content != "func Q() {}" || len(s.Graph) != 1 {
+		t.Errorf("modules = %v, graph = %v", s.Modules, s.Graph)
+	}
+}
+
+func TestRepository_Lint(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "a.go"), []byte("package p\n\nfunc A() {}\n"), 0o644)
+	r := NewRepository("a")
+	r.Path = dir
+	mod := NewModule("a", ".", Golang)
+	pkg := NewPackage("a/p")
+	id := func(name string) Identity { return NewIdentity("a", "a/p", name) }
+	pkg.Functions["A"] = &Function{
+		Identity:      id("A"),
+		FileLine:      FileLine{File: "a.go", Line: 3, StartOffset: 11, EndOffset: 22},
+		FunctionCalls: []Dependency{{Identity: id("Missing")}, {Identity: NewIdentity("b@v1.0.0", "b", "B")}},
+	}
+	pkg.Functions["B"] = &Function{Identity: id("B"), FileLine: FileLine{File: "a.go", Line: 9, StartOffset: 30, EndOffset: 20}}
+	pkg.Types["A"] = &Type{Identity: id("A"), FileLine: FileLine{File: "a.go", Line: 3}}
+	pkg.Vars["v"] = &Var{Identity: id("w")}
+	mod.Packages["a/p"] = pkg
+	mod.Packages["a/q"] = NewPackage("a/r")
+	mod.Files["a.go"] = &File{Path: "a.go", Package: "a/p"}
+	mod.Files["x.go"] = &File{Path: "x.go", Package: "a/x"}
+	r.Modules["a"] = mod
+	r.Modules["b@v1.0.0"] = NewModule("b@v1.0.0", "", Golang)
+
+	issues, err := r.Lint(LintOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, issue := range issues {
+		node := ""
+		if issue.Node != nil {
+			node = issue.Node.Name
+		}
+		got = append(got, string(issue.Rule)+" "+issue.File+" "+node)
+	}
+	want := []string{
+		"dangling-dependency a.go A",
+		"duplicate-identity  w",
+		"duplicate-identity a.go A",
+		"empty-fileline  w",
+		"offset-out-of-range a.go B",
+		"offset-out-of-range a.go B",
+		"orphan-package  ",
+		"orphan-package x.go ",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Lint() = %q, want %q", got, want)
+	}
+
+	issues, _ = r.Lint(LintOptions{Rules: []LintRule{LintEmptyFileLine}})
+	if len(issues) != 1 || issues[0].Rule != LintEmptyFileLine {
+		t.Errorf("Lint(empty-fileline) = %+v", issues)
+	}
+	if _, err := r.Lint(LintOptions{Rules: []LintRule{"unknown"}}); err == nil {
+		t.Error("expect error of unknown rule")
+	}
+}
+
+func TestRepository_ContextIssues(t *testing.T) {
+	r := NewRepository("a")
+	mod := NewModule("a", ".", Golang)
+	pkg := NewPackage("a/p")
+	id := func(name string) Identity { return NewIdentity("a", "a/p", name) }
+	call := func(name string, line int) Dependency {
+		return Dependency{Identity: id(name), FileLine: FileLine{File: "p.go", Line: line}}
+	}
+	// parsed by the former versions without TakesContext
+	pkg.Functions["Query"] = &Function{Identity: id("Query"), Signature: "func(ctx context.Context, q string) error"}
+	pkg.Functions["New"] = &Function{Identity: id("New"), Signature: "func() context.Context"}
+	pkg.Functions["Serve"] = &Function{Identity: id("Serve"), TakesContext: true, FunctionCalls: []Dependency{call("Serve.func1", 2), call("helper", 3)}}
+	pkg.Functions["Serve.func1"] = &Function{Identity: id("Serve.func1"), ParentFunction: &Identity{ModPath: "a", PkgPath: "a/p", Name: "Serve"}, FunctionCalls: []Dependency{call("Query", 4)}}
+	pkg.Functions["helper"] = &Function{Identity: id("helper"), FunctionCalls: []Dependency{call("Query", 7), call("New", 8)}}
+	pkg.Functions["cron"] = &Function{Identity: id("cron"), FunctionCalls: []Dependency{call("Query", 10)}}
+	pkg.Functions["TestServe"] = &Function{Identity: id("TestServe"), IsTest: true, TakesContext: true, FunctionCalls: []Dependency{call("helper", 12)}}
+	mod.Packages["a/p"] = pkg
+	r.Modules["a"] = mod
+
+	issues := r.ContextIssues(ContextOptions{})
+	if len(issues) != 1 || issues[0].Kind != ContextMissing || issues[0].Caller.Name != "helper" || issues[0].Callee.Name != "Query" || issues[0].Line != 7 {
+		t.Errorf("ContextIssues() = %+v", issues)
+	}
+	if issues := r.ContextIssues(ContextOptions{Entries: []Identity{id("cron")}}); len(issues) != 1 || issues[0].Caller.Name != "cron" {
+		t.Errorf("ContextIssues(cron) = %+v", issues)
+	}
+}
+
+func TestRepository_ErrorOrigins(t *testing.T) {
+	r := NewRepository("a")
+	mod := NewModule("a", ".", Rust)
+	pkg := NewPackage("a::store")
+	id := func(name string) Identity { return NewIdentity("a", "a::store", name) }
+	call := func(name string) Dependency { return NewDependency(id(name), FileLine{}) }
+	storeError := id("StoreError")
+	pkg.Functions["open"] = &Function{Identity: id("open"), FileLine: FileLine{File: "src/store.rs", Line: 3},
+		Errors: &Errors{Returns: true, Type: &storeError}}
+	pkg.Functions["load"] = &Function{Identity: id("load"), FileLine: FileLine{File: "src/store.rs", Line: 10},
+		Errors:        &Errors{Returns: true, Sites: []ErrorSite{{Kind: ErrorTyped, Error: &storeError, FileLine: FileLine{File: "src/store.rs", Line: 12}}}},
+		FunctionCalls: []Dependency{call("open"), call("len")}}
+	pkg.Functions["len"] = &Function{Identity: id("len"), FileLine: FileLine{File: "src/store.rs", Line: 20}}
+	pkg.Functions["get"] = &Function{Identity: id("get"), FileLine: FileLine{File: "src/store.rs", Line: 30},
+		Errors: &Errors{Returns: true}, FunctionCalls: []Dependency{call("load")}}
+	mod.Packages["a::store"] = pkg
+	r.Modules["a"] = mod
+
+	origins := r.ErrorOrigins(id("get"))
+	if len(origins) != 2 ||
+		origins[0].Function.Name != "open" || origins[0].Kind != ErrorDeclared || !reflect.DeepEqual(origins[0].Via, []Identity{id("load")}) ||
+		origins[1].Function.Name != "load" || origins[1].Kind != ErrorTyped || origins[1].Line != 12 || origins[1].Via != nil {
+		t.Errorf("ErrorOrigins(get) = %+v", origins)
+	}
+	origins = r.ErrorOrigins(storeError)
+	if len(origins) != 2 || origins[0].Function.Name != "open" || origins[1].Function.Name != "load" {
+		t.Errorf("ErrorOrigins(StoreError) = %+v", origins)
+	}
+	if origins := r.ErrorOrigins(Identity{}); len(origins) != 1 || origins[0].Function.Name != "load" {
+		t.Errorf("ErrorOrigins() = %+v", origins)
+	}
+}
+
+func TestVisibility(t *testing.T) {
+	repo := NewRepository("test")
+	repo.SetModule("m", NewModule("m", "", Golang))
+	fn := &Function{Identity: NewIdentity("m", "m/p", "f"), Exported: true}
+	repo.SetFunction(fn.Identity, fn)
+	n := Node{Identity: fn.Identity, Type: FUNC, Repo: &repo}
+	if got := n.Visibility(); got != VisibilityPublic {
+		t.Errorf("Visibility() without visibility = %q, want public", got)
+	}
+	fn.SetVisibility(VisibilityProtected)
+	if fn.Exported || n.IsExported() || n.Visibility() != VisibilityProtected {
+		t.Errorf("after SetVisibility(protected): %+v", fn)
+	}
+	n.SetIsExported(false)
+	if fn.Visibility != VisibilityProtected {
+		t.Errorf("SetIsExported(false) reset the agreeing visibility to %q", fn.Visibility)
+	}
+	n.SetIsExported(true)
+	if !fn.Exported || fn.Visibility != VisibilityPublic {
+		t.Errorf("after SetIsExported(true): %+v", fn)
+	}
+}
+
+func TestRepository_AsStdLib(t *testing.T) {
+	repo := NewRepository("src")
+	repo.Modules[StdModule] = NewModule(StdModule, ".", Golang)
+	writer := NewIdentity(StdModule, "io", "Writer")
+	repo.SetType(writer, &Type{Identity: writer, FileLine: FileLine{File: "io/io.go"}, TypeKind: TypeKindInterface})
+	fprintf := NewIdentity(StdModule, "fmt", "Fprintf")
+	repo.SetFunction(fprintf, &Function{Identity: fprintf, FileLine: FileLine{File: "fmt/print.go"}, Params: []Dependency{NewDependency(writer, FileLine{})}})
+	if err := repo.BuildGraph(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repo.AsStdLib(Golang, "go1.24.5"); err != nil {
+		t.Fatal(err)
+	}
+	if repo.Name != "go-std@go1.24.5" {
+		t.Errorf("name = %s", repo.Name)
+	}
+	mod := repo.Modules["std@go1.24.5"]
+	if mod == nil || len(repo.Modules) != 1 || mod.Name != StdModule || mod.Version != "go1.24.5" {
+		t.Fatalf("modules = %v", repo.Modules)
+	}
+	writer.ModPath, fprintf.ModPath = "std@go1.24.5", "std@go1.24.5"
+	fn := repo.GetFunction(fprintf)
+	if fn == nil || fn.Identity != fprintf || fn.Params[0].Identity != writer {
+		t.Fatalf("Fprintf = %+v", fn)
+	}
+	if node := repo.GetNode(writer); node == nil || len(node.References) != 1 {
+		t.Errorf("the graph is not rebuilt: %+v", node)
+	}
+}
+
+func TestRepository_LinkStd(t *testing.T) {
+	repo := NewRepository("app")
+	repo.Modules["app"] = NewModule("app", ".", Rust)
+	// collected with the std symbols
+	repo.Modules["std"] = NewModule("std", "", Rust)
+	hashMap := NewIdentity("std", "std::collections", "HashMap")
+	repo.SetType(hashMap, &Type{Identity: hashMap, TypeKind: TypeKindStruct})
+	fmtDebug := NewIdentity("", "core::fmt", "Debug")
+	run := NewIdentity("app", "app", "run")
+	repo.SetFunction(run, &Function{Identity: run, FileLine: FileLine{File: "src/lib.rs"},
+		Types: []Dependency{NewDependency(hashMap, FileLine{}), NewDependency(fmtDebug, FileLine{})}})
+	std := func(id Identity) ModPath {
+		crate, _, _ := strings.Cut(id.PkgPath, "::")
+		if (crate == "std" || crate == "core") && (id.ModPath == "" || id.ModPath == crate) {
+			return crate
+		}
+		return ""
+	}
+
+	dropped, err := repo.LinkStd("1.80.0", std)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(dropped, []ModPath{"std"}) || repo.Modules["app"] == nil || len(repo.Modules) != 1 {
+		t.Errorf("dropped = %v, modules = %v", dropped, repo.Modules)
+	}
+	want := []Dependency{
+		NewDependency(NewIdentity("std@1.80.0", "std::collections", "HashMap"), FileLine{}),
+		NewDependency(NewIdentity("core@1.80.0", "core::fmt", "Debug"), FileLine{}),
+	}
+	if got := repo.GetFunction(run).Types; !reflect.DeepEqual(got, want) {
+		t.Errorf("types of run = %+v", got)
+	}
+}
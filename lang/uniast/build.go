@@ -0,0 +1,46 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package uniast
+
+// BuildConfig is the build configuration declared by the manifest of a module,
+// which tells how to reproduce the build environment and what constrains an upgrade
+type BuildConfig struct {
+	// Manifest is the path of the manifest relative to the repo, like `go.mod`, `Cargo.toml` or `pyproject.toml`
+	Manifest string
+	// LanguageVersion is the minimal version of the language: the `go` directive, `rust-version` or `requires-python`
+	LanguageVersion string `json:",omitempty"`
+	// Toolchain is the `toolchain` directive of go.mod
+	Toolchain string `json:",omitempty"`
+	// Edition is the rust edition of the crate
+	Edition string `json:",omitempty"`
+	// Replaces are the `replace` directives of go.mod
+	Replaces []Replace `json:",omitempty"`
+	// Excludes are the `exclude` directives of go.mod, like `module@version`
+	Excludes []string `json:",omitempty"`
+	// Features are the cargo features with the features and optional dependencies they enable
+	Features map[string][]string `json:",omitempty"`
+	// Extras are the python optional dependency groups with their requirements
+	Extras map[string][]string `json:",omitempty"`
+}
+
+// Replace is a `replace` directive of go.mod
+type Replace struct {
+	// Old is the replaced module, with the version if only the version is replaced, like `module@version`
+	Old string
+	// New is the replacement module like `module@version`, or the local dir
+	New string
+}
@@ -0,0 +1,314 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uniast
+
+import (
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// CommentKind classifies the free-floating comments
+type CommentKind string
+
+const (
+	// CommentTodo is a comment with a marker like TODO, FIXME, XXX, HACK or BUG
+	CommentTodo CommentKind = "todo"
+	// CommentLicense is the license or copyright header at the top of the file
+	CommentLicense CommentKind = "license"
+	// CommentBanner is a decorated section banner like `// ===== Handlers =====`
+	CommentBanner CommentKind = "banner"
+)
+
+// Comment is a comment block which is not a part of any node, or a TODO anywhere. See CollectComments
+type Comment struct {
+	// Kind is empty for the other comments
+	Kind CommentKind `json:",omitempty"`
+	// Text is the comment as it is in the file, including the comment markers
+	Text string
+	// Line and EndLine are the first and the last line of the block, start from 1
+	Line    int
+	EndLine int
+	// Node is the node the comment is attached to: the node containing it, or else the nearest following one.
+	// Nil if it is attached to the file, as license headers always are
+	Node *Identity `json:",omitempty"`
+}
+
+var (
+	todoMarker   = regexp.MustCompile(`\b(TODO|FIXME|XXX|HACK|BUG)\b`)
+	bannerLine   = regexp.MustCompile(`[=\-*#~/_+]{4,}`)
+	licenseWords = []string{"copyright", "license", "licence", "spdx-license-identifier"}
+)
+
+// hashCommentExts are the extensions of the source files commented by `#`, the others in commentExts by `//` and `/* */`
+var hashCommentExts = map[string]bool{".py": true, ".pyi": true, ".rb": true}
+
+var commentExts = map[string]bool{
+	".go": true, ".rs": true, ".java": true, ".kt": true, ".kts": true, ".scala": true, ".sc": true, ".php": true,
+	".ts": true, ".tsx": true, ".js": true, ".jsx": true, ".mjs": true,
+	".c": true, ".h": true, ".cc": true, ".cpp": true, ".cxx": true, ".hh": true, ".hpp": true, ".hxx": true,
+}
+
+// CollectComments collects the comments of the source files of the internal modules into File.Comments:
+// the comment blocks out of the nodes and their doc comments (license headers, section banners, free-floating notes),
+// and the TODOs everywhere. Consecutive line comments are a block.
+// read returns the content of a file relative to the repo, files which fail to read are skipped
+func (r *Repository) CollectComments(read func(file string) ([]byte, error)) {
+	// the spans of the nodes by file
+	spans := map[string][]nodeSpan{}
+	r.eachInternalNode(func(id Identity, fl FileLine, content string) {
+		if fl.File == "" || fl.Line <= 0 {
+			return
+		}
+		end := fl.EndLine
+		if end < fl.Line {
+			end = fl.Line + strings.Count(content, "\n")
+		}
+		spans[fl.File] = append(spans[fl.File], nodeSpan{id: id, line: fl.Line, end: end})
+	})
+	for _, ss := range spans {
+		sort.Slice(ss, func(i, j int) bool { return ss[i].line < ss[j].line })
+	}
+	for _, mod := range r.InternalModules() {
+		for path, f := range mod.Files {
+			ext := strings.ToLower(filepath.Ext(path))
+			if !commentExts[ext] && !hashCommentExts[ext] {
+				continue
+			}
+			bs, err := read(path)
+			if err != nil {
+				continue
+			}
+			f.Comments = fileComments(string(bs), hashCommentExts[ext], spans[path])
+		}
+	}
+}
+
+type nodeSpan struct {
+	id        Identity
+	line, end int
+}
+
+func (r *Repository) eachInternalNode(fn func(id Identity, fl FileLine, content string)) {
+	for _, mod := range r.InternalModules() {
+		for _, pkg := range mod.Packages {
+			for _, n := range pkg.Functions {
+				fn(n.Identity, n.FileLine, n.Content)
+			}
+			for _, n := range pkg.Types {
+				fn(n.Identity, n.FileLine, n.Content)
+			}
+			for _, n := range pkg.Vars {
+				fn(n.Identity, n.FileLine, n.Content)
+			}
+		}
+	}
+}
+
+// fileComments returns the comments of the text worth keeping, attached to the nodes of the spans sorted by line
+func fileComments(text string, hash bool, spans []nodeSpan) []Comment {
+	var ret []Comment
+	for _, b := range scanComments(text, hash) {
+		c := Comment{Text: text[b.start:b.end], Line: b.line, EndLine: b.endLine}
+		var inside *nodeSpan
+		for i, s := range spans {
+			// the innermost node containing the block
+			if s.line <= c.Line && c.EndLine <= s.end && (inside == nil || s.end-s.line < inside.end-inside.line) {
+				inside = &spans[i]
+			}
+		}
+		doc := false
+		for _, s := range spans {
+			if s.line == c.EndLine+1 {
+				doc = true
+			}
+		}
+		switch {
+		case todoMarker.MatchString(c.Text):
+			c.Kind = CommentTodo
+		case inside != nil || doc:
+			// a part of the node or its doc
+			continue
+		case b.start == firstCode(text) && containsAny(strings.ToLower(c.Text), licenseWords):
+			c.Kind = CommentLicense
+		case bannerLine.MatchString(strings.TrimLeft(strings.TrimSpace(c.Text), "/#*")):
+			c.Kind = CommentBanner
+		}
+		if inside != nil {
+			id := inside.id
+			c.Node = &id
+		} else if c.Kind != CommentLicense {
+			for _, s := range spans {
+				if s.line > c.EndLine {
+					id := s.id
+					c.Node = &id
+					break
+				}
+			}
+		}
+		ret = append(ret, c)
+	}
+	return ret
+}
+
+// firstCode returns the offset of the first non-blank character of the text
+func firstCode(text string) int {
+	return len(text) - len(strings.TrimLeft(text, " \t\r\n"))
+}
+
+func containsAny(s string, words []string) bool {
+	for _, w := range words {
+		if strings.Contains(s, w) {
+			return true
+		}
+	}
+	return false
+}
+
+// commentBlock is a block comment, or consecutive line comments each on its own line
+type commentBlock struct {
+	start, end    int
+	line, endLine int
+	// lineComment tells the block consists of line comments alone on their lines, which can be joined by the next one
+	lineComment bool
+}
+
+// scanComments returns the comment blocks of the text, skipping the string and char literals.
+// hash tells the comments are started by `#` (with triple-quoted strings), otherwise by `//` and `/* */`
+func scanComments(text string, hash bool) []commentBlock {
+	var ret []commentBlock
+	line := 1
+	add := func(start, end, startLine int, lineComment bool) {
+		lineStart := strings.LastIndexByte(text[:start], '\n') + 1
+		alone := strings.TrimSpace(text[lineStart:start]) == ""
+		if n := len(ret); n > 0 && lineComment && alone && ret[n-1].lineComment && ret[n-1].endLine == startLine-1 {
+			ret[n-1].end, ret[n-1].endLine = end, startLine
+			return
+		}
+		ret = append(ret, commentBlock{start: start, end: end, line: startLine, endLine: line, lineComment: lineComment && alone})
+	}
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case c == '\n':
+			line++
+		case hash && c == '#', !hash && c == '/' && i+1 < len(text) && text[i+1] == '/':
+			end := strings.IndexByte(text[i:], '\n')
+			if end < 0 {
+				end = len(text) - i
+			}
+			add(i, i+len(strings.TrimRight(text[i:i+end], "\r")), line, true)
+			i += end - 1
+		case !hash && c == '/' && i+1 < len(text) && text[i+1] == '*':
+			start, startLine := i, line
+			end := strings.Index(text[i+2:], "*/")
+			if end < 0 {
+				end = len(text)
+			} else {
+				end += i + 4
+			}
+			line += strings.Count(text[i:end], "\n")
+			add(start, end, startLine, false)
+			i = end - 1
+		case hash && (strings.HasPrefix(text[i:], `"""`) || strings.HasPrefix(text[i:], `'''`)):
+			end := strings.Index(text[i+3:], text[i:i+3])
+			if end < 0 {
+				end = len(text)
+			} else {
+				end += i + 6
+			}
+			line += strings.Count(text[i:end], "\n")
+			i = end - 1
+		case c == '"' || (c == '`' && !hash):
+			i = skipLiteral(text, i, &line)
+		case c == '\'':
+			if hash || isCharLiteral(text, i) {
+				i = skipLiteral(text, i, &line)
+			}
+		}
+	}
+	return ret
+}
+
+// skipLiteral skips the literal started at i like skipQuoted, counting the lines in it
+func skipLiteral(text string, i int, line *int) int {
+	j := min(skipQuoted(text, i), len(text)-1)
+	*line += strings.Count(text[i:j+1], "\n")
+	return j
+}
+
+// isCharLiteral tells if the quote at i starts a char literal like 'a' or '\n', rather than a lifetime like 'a in rust
+func isCharLiteral(text string, i int) bool {
+	if i+1 >= len(text) {
+		return false
+	}
+	if text[i+1] == '\\' {
+		return true
+	}
+	_, size := utf8.DecodeRuneInString(text[i+1:])
+	return i+1+size < len(text) && text[i+1+size] == '\''
+}
+
+// Todo is an actionable marker in a comment, see Todos
+type Todo struct {
+	// Marker is like TODO, FIXME, XXX, HACK or BUG
+	Marker string
+	// Owner is the name in the parentheses after the marker, like `TODO(alice)`
+	Owner   string `json:",omitempty"`
+	Message string
+	File    string
+	Line    int
+	// Node is the node the comment is attached to, nil if attached to the file
+	Node *Identity `json:",omitempty"`
+}
+
+var todoLine = regexp.MustCompile(`\b(TODO|FIXME|XXX|HACK|BUG)\b(?:\(([^)]*)\))?[:\s-]*(.*)`)
+
+// Todos returns the markers in the TODO comments of the internal modules, sorted by file and line.
+// Only the markers of the kind (like FIXME) are returned if it is not empty
+func (r Repository) Todos(marker string) []Todo {
+	ret := []Todo{}
+	seen := map[string]bool{}
+	for _, mod := range r.InternalModules() {
+		for path, f := range mod.Files {
+			if seen[path] {
+				continue
+			}
+			seen[path] = true
+			for _, c := range f.Comments {
+				if c.Kind != CommentTodo {
+					continue
+				}
+				for i, l := range strings.Split(c.Text, "\n") {
+					m := todoLine.FindStringSubmatch(l)
+					if m == nil || (marker != "" && m[1] != marker) {
+						continue
+					}
+					msg := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(m[3]), "*/"))
+					ret = append(ret, Todo{Marker: m[1], Owner: m[2], Message: msg, File: path, Line: c.Line + i, Node: c.Node})
+				}
+			}
+		}
+	}
+	sort.SliceStable(ret, func(i, j int) bool {
+		if ret[i].File != ret[j].File {
+			return ret[i].File < ret[j].File
+		}
+		return ret[i].Line < ret[j].Line
+	})
+	return ret
+}
@@ -0,0 +1,157 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package uniast
+
+import (
+	"sort"
+	"strings"
+)
+
+// ContextIssueKind is the kind of a context propagation issue
+type ContextIssueKind string
+
+const (
+	// ContextMissing: the callee takes a context, while the caller has none to pass
+	ContextMissing ContextIssueKind = "missing-context"
+	// ContextFresh: the callee is passed a new root context, like context.Background, instead of the caller's one
+	ContextFresh ContextIssueKind = "fresh-context"
+)
+
+// ContextIssue is a call breaking the propagation of the context along a request path
+type ContextIssue struct {
+	Kind   ContextIssueKind
+	Caller Identity
+	Callee Identity
+	// FileLine is where the callee is called
+	FileLine
+	// Context is the root context passed, like `context.Background`, empty if unknown
+	Context string `json:",omitempty"`
+}
+
+// ContextOptions are the options of Repository.ContextIssues
+type ContextOptions struct {
+	// Entries are the functions starting the request paths, like the handlers of the services.
+	// Every function taking a context starts a request path if empty
+	Entries []Identity
+}
+
+// ContextIssues finds the calls breaking the propagation of the context (see Function.TakesContext) along the request paths,
+// which are the internal functions called from the entries transitively: the callee taking a context is called
+// by a function without one, or passed a new root context (see Dependency.FreshContext).
+// Closures share the contexts of their parents, and the tests are skipped. The issues are sorted by the call sites
+func (r *Repository) ContextIssues(o ContextOptions) []ContextIssue {
+	var queue []*Function
+	seen := map[Identity]bool{}
+	visit := func(fn *Function) {
+		if fn != nil && !fn.IsTest && !seen[fn.Identity] {
+			seen[fn.Identity] = true
+			queue = append(queue, fn)
+		}
+	}
+	if len(o.Entries) > 0 {
+		for _, id := range o.Entries {
+			visit(r.GetFunction(id))
+		}
+	} else {
+		for _, mod := range r.InternalModules() {
+			for _, pkg := range mod.Packages {
+				for _, fn := range pkg.Functions {
+					if fn.TakesContext {
+						visit(fn)
+					}
+				}
+			}
+		}
+	}
+
+	var ret []ContextIssue
+	for len(queue) > 0 {
+		fn := queue[0]
+		queue = queue[1:]
+		hasContext := r.hasContext(fn)
+		for _, calls := range [][]Dependency{fn.FunctionCalls, fn.MethodCalls} {
+			for _, call := range calls {
+				callee := r.GetFunction(call.Identity)
+				if callee == nil {
+					continue
+				}
+				if mod := r.Modules[callee.ModPath]; mod != nil && !mod.IsExternal() {
+					visit(callee)
+				}
+				// the possible targets of dynamic calls are not called here for sure
+				if call.Dynamic || !takesContext(callee) {
+					continue
+				}
+				if !hasContext {
+					ret = append(ret, ContextIssue{Kind: ContextMissing, Caller: fn.Identity, Callee: callee.Identity, FileLine: call.FileLine, Context: call.FreshContext})
+				} else if call.FreshContext != "" {
+					ret = append(ret, ContextIssue{Kind: ContextFresh, Caller: fn.Identity, Callee: callee.Identity, FileLine: call.FileLine, Context: call.FreshContext})
+				}
+			}
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].File != ret[j].File {
+			return ret[i].File < ret[j].File
+		}
+		if ret[i].Line != ret[j].Line {
+			return ret[i].Line < ret[j].Line
+		}
+		return ret[i].Callee.Full() < ret[j].Callee.Full()
+	})
+	return ret
+}
+
+// hasContext tells if the function or the parent of the closure takes a context
+func (r *Repository) hasContext(fn *Function) bool {
+	for fn != nil {
+		if takesContext(fn) {
+			return true
+		}
+		if fn.ParentFunction == nil {
+			return false
+		}
+		fn = r.GetFunction(*fn.ParentFunction)
+	}
+	return false
+}
+
+// takesContext tells if the function takes a context, by the parameters of its signature
+// if it is not flagged, like the external ones or those parsed by the former versions
+func takesContext(fn *Function) bool {
+	if fn.TakesContext {
+		return true
+	}
+	sig := fn.Signature
+	i := strings.IndexByte(sig, '(')
+	if i < 0 {
+		return false
+	}
+	depth := 0
+	for j := i; j < len(sig); j++ {
+		switch sig[j] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return strings.Contains(sig[i:j], "context.Context")
+			}
+		}
+	}
+	return false
+}
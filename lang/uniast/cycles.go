@@ -0,0 +1,166 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package uniast
+
+import "sort"
+
+// PackageID identifies a package in a repo
+type PackageID struct {
+	ModPath ModPath
+	PkgPath PkgPath
+}
+
+// DependencyCycles are the strongly connected components with more than one member
+// in the dependency graphs of the internal modules.
+// The members of a cycle are sorted, and the larger cycles come first.
+type DependencyCycles struct {
+	// Packages are the cycles among packages, depending on each other through their nodes
+	Packages [][]PackageID
+	// Nodes are the cycles among nodes, like the mutually recursive functions
+	Nodes [][]Identity
+}
+
+// DetectCycles finds the dependency cycles among the internal packages and among the internal nodes.
+// The test variants of go packages are skipped, since they duplicate the product nodes.
+func (r *Repository) DetectCycles() DependencyCycles {
+	r.EnsureGraph()
+	internal := func(id Identity) bool {
+		mod := r.Modules[id.ModPath]
+		return mod != nil && !mod.IsExternal() && !isTestVariant(id.PkgPath)
+	}
+
+	nodes := map[Identity][]Identity{}
+	pkgs := map[PackageID][]PackageID{}
+	pkgEdges := map[[2]PackageID]bool{}
+	for _, node := range r.Graph {
+		if !internal(node.Identity) {
+			continue
+		}
+		from := PackageID{ModPath: node.ModPath, PkgPath: node.PkgPath}
+		if _, ok := pkgs[from]; !ok {
+			pkgs[from] = nil
+		}
+		deps := nodes[node.Identity]
+		for _, dep := range node.Dependencies {
+			if !internal(dep.Identity) {
+				continue
+			}
+			deps = append(deps, dep.Identity)
+			to := PackageID{ModPath: dep.ModPath, PkgPath: dep.PkgPath}
+			if to != from && !pkgEdges[[2]PackageID{from, to}] {
+				pkgEdges[[2]PackageID{from, to}] = true
+				pkgs[from] = append(pkgs[from], to)
+			}
+		}
+		nodes[node.Identity] = deps
+	}
+
+	return DependencyCycles{
+		Packages: stronglyConnected(pkgs, func(a, b PackageID) bool {
+			if a.ModPath != b.ModPath {
+				return a.ModPath < b.ModPath
+			}
+			return a.PkgPath < b.PkgPath
+		}),
+		Nodes: stronglyConnected(nodes, func(a, b Identity) bool {
+			return a.Full() < b.Full()
+		}),
+	}
+}
+
+// stronglyConnected returns the strongly connected components with more than one vertex, by Tarjan's algorithm.
+// It is iterative, thus deep graphs do not overflow the stack.
+func stronglyConnected[T comparable](graph map[T][]T, less func(a, b T) bool) [][]T {
+	// sort the vertices for the stable output
+	vertices := make([]T, 0, len(graph))
+	for v := range graph {
+		vertices = append(vertices, v)
+	}
+	sort.Slice(vertices, func(i, j int) bool { return less(vertices[i], vertices[j]) })
+
+	type state struct {
+		index, low int
+		onStack    bool
+	}
+	states := make(map[T]*state, len(graph))
+	var stack []T
+	ret := [][]T{}
+	index := 0
+
+	type frame struct {
+		v    T
+		next int // the next edge to visit
+	}
+	for _, root := range vertices {
+		if states[root] != nil {
+			continue
+		}
+		calls := []frame{{v: root}}
+		states[root] = &state{index: index, low: index, onStack: true}
+		index++
+		stack = append(stack, root)
+		for len(calls) > 0 {
+			top := &calls[len(calls)-1]
+			st := states[top.v]
+			if edges := graph[top.v]; top.next < len(edges) {
+				w := edges[top.next]
+				top.next++
+				if ws := states[w]; ws == nil {
+					states[w] = &state{index: index, low: index, onStack: true}
+					index++
+					stack = append(stack, w)
+					calls = append(calls, frame{v: w})
+				} else if ws.onStack {
+					st.low = min(st.low, ws.index)
+				}
+				continue
+			}
+			// all edges are visited, pop the frame
+			v := top.v
+			calls = calls[:len(calls)-1]
+			if len(calls) > 0 {
+				parent := states[calls[len(calls)-1].v]
+				parent.low = min(parent.low, st.low)
+			}
+			if st.low != st.index {
+				continue
+			}
+			var scc []T
+			for {
+				w := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				states[w].onStack = false
+				scc = append(scc, w)
+				if w == v {
+					break
+				}
+			}
+			if len(scc) > 1 {
+				sort.Slice(scc, func(i, j int) bool { return less(scc[i], scc[j]) })
+				ret = append(ret, scc)
+			}
+		}
+	}
+
+	sort.SliceStable(ret, func(i, j int) bool {
+		if len(ret[i]) != len(ret[j]) {
+			return len(ret[i]) > len(ret[j])
+		}
+		return less(ret[i][0], ret[j][0])
+	})
+	return ret
+}
@@ -0,0 +1,275 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package uniast
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DedupDirs are the dirs of vendored or generated codes, whose nodes are deduplicated along with the external ones
+var DedupDirs = []string{"vendor", "kitex_gen", "hertz_gen"}
+
+// ContentHash returns the hash of the codes of a node
+func ContentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:8])
+}
+
+// HashNodes fills the content hashes of all nodes
+func (r *Repository) HashNodes() {
+	for _, mod := range r.Modules {
+		for _, pkg := range mod.Packages {
+			for _, fn := range pkg.Functions {
+				fn.Hash = ContentHash(fn.Content)
+			}
+			for _, t := range pkg.Types {
+				t.Hash = ContentHash(t.Content)
+			}
+			for _, v := range pkg.Vars {
+				v.Hash = ContentHash(v.Content)
+			}
+		}
+	}
+}
+
+// inDedupDirs tells if the file is under one of DedupDirs
+func inDedupDirs(file string) bool {
+	for _, seg := range strings.Split(filepath.ToSlash(file), "/") {
+		for _, dir := range DedupDirs {
+			if seg == dir {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// dedupKey identifies the nodes of identical codes. Nodes of different packages may share it
+type dedupKey struct {
+	typ  NodeType
+	name string
+	hash string
+}
+
+// Dedup collapses the identical nodes of the external modules and DedupDirs into one canonical node,
+// like the same generated struct in several kitex_gen copies.
+// Nodes are identical if they have the same type, name and content hash (see HashNodes).
+// A type is identical only if its methods are identical too, and the methods go with their receiver type.
+// The canonical one is the least identity, which records the others as Aliases,
+// and the dependencies on the others are redirected to it. It must be called before BuildGraph.
+// It returns the count of the removed nodes.
+func (r *Repository) Dedup() int {
+	type node struct {
+		id   Identity
+		pkg  *Package
+		file string
+	}
+	isCandidate := func(mod *Module, file string) bool {
+		return mod.IsExternal() || inDedupDirs(file)
+	}
+	// the methods of types, by receiver
+	methods := map[Identity][]node{}
+	groups := map[dedupKey][]node{}
+	for _, mod := range r.Modules {
+		for _, pkg := range mod.Packages {
+			for _, fn := range pkg.Functions {
+				if fn.Receiver != nil {
+					methods[fn.Receiver.Type] = append(methods[fn.Receiver.Type], node{fn.Identity, pkg, fn.File})
+					continue
+				}
+				if fn.Hash != "" && isCandidate(mod, fn.File) {
+					key := dedupKey{FUNC, fn.Name, fn.Hash}
+					groups[key] = append(groups[key], node{fn.Identity, pkg, fn.File})
+				}
+			}
+			for _, v := range pkg.Vars {
+				if v.Hash != "" && isCandidate(mod, v.File) {
+					key := dedupKey{VAR, v.Name, v.Hash}
+					groups[key] = append(groups[key], node{v.Identity, pkg, v.File})
+				}
+			}
+		}
+	}
+	for _, mod := range r.Modules {
+		for _, pkg := range mod.Packages {
+			for _, t := range pkg.Types {
+				if t.Hash == "" || !isCandidate(mod, t.File) {
+					continue
+				}
+				hashes := []string{t.Hash}
+				for _, m := range methods[t.Identity] {
+					hashes = append(hashes, m.id.Name+":"+m.pkg.Functions[m.id.Name].Hash)
+				}
+				sort.Strings(hashes[1:])
+				key := dedupKey{TYPE, t.Name, ContentHash(strings.Join(hashes, "\n"))}
+				groups[key] = append(groups[key], node{t.Identity, pkg, t.File})
+			}
+		}
+	}
+
+	// alias => canonical
+	canonical := map[Identity]Identity{}
+	for key, nodes := range groups {
+		if len(nodes) < 2 {
+			continue
+		}
+		sort.Slice(nodes, func(i, j int) bool {
+			return nodes[i].id.Full() < nodes[j].id.Full()
+		})
+		keep := nodes[0].id
+		var aliases []Identity
+		for _, n := range nodes[1:] {
+			canonical[n.id] = keep
+			aliases = append(aliases, n.id)
+			switch key.typ {
+			case FUNC:
+				aliases = append(aliases, r.GetFunction(n.id).Aliases...)
+				delete(n.pkg.Functions, n.id.Name)
+			case VAR:
+				aliases = append(aliases, r.GetVar(n.id).Aliases...)
+				delete(n.pkg.Vars, n.id.Name)
+			case TYPE:
+				aliases = append(aliases, r.GetType(n.id).Aliases...)
+				delete(n.pkg.Types, n.id.Name)
+				// the methods go with the receiver, they have the same names as those of the canonical type
+				for _, m := range methods[n.id] {
+					for _, km := range methods[keep] {
+						if km.id.Name == m.id.Name {
+							canonical[m.id] = km.id
+							kfn := km.pkg.Functions[km.id.Name]
+							kfn.Aliases = append(kfn.Aliases, m.id)
+						}
+					}
+					delete(m.pkg.Functions, m.id.Name)
+				}
+			}
+		}
+		switch key.typ {
+		case FUNC:
+			fn := r.GetFunction(keep)
+			fn.Aliases = append(fn.Aliases, aliases...)
+		case VAR:
+			v := r.GetVar(keep)
+			v.Aliases = append(v.Aliases, aliases...)
+		case TYPE:
+			t := r.GetType(keep)
+			t.Aliases = append(t.Aliases, aliases...)
+		}
+	}
+	if len(canonical) == 0 {
+		return 0
+	}
+
+	r.redirect(canonical)
+	return len(canonical)
+}
+
+// redirect replaces the identities in the dependencies and relations of all nodes
+func (r *Repository) redirect(to map[Identity]Identity) {
+	r.redirectFunc(func(id Identity) (Identity, bool) {
+		c, ok := to[id]
+		return c, ok
+	})
+}
+
+// redirectFunc replaces the identities in the dependencies and relations of all nodes by the ones to returns
+func (r *Repository) redirectFunc(to func(Identity) (Identity, bool)) {
+	id := func(id Identity) Identity {
+		if c, ok := to(id); ok {
+			return c
+		}
+		return id
+	}
+	deps := func(ds []Dependency) []Dependency {
+		ret := ds[:0]
+		for _, d := range ds {
+			if c, ok := to(d.Identity); ok {
+				// the duplicates may be depended on together
+				if hasDependency(ret, c) {
+					continue
+				}
+				d.Identity = c
+			}
+			ret = append(ret, d)
+		}
+		return ret
+	}
+	ids := func(is []Identity) []Identity {
+		for i := range is {
+			is[i] = id(is[i])
+		}
+		return is
+	}
+	for _, mod := range r.Modules {
+		for _, pkg := range mod.Packages {
+			pkg.InitOrder = ids(pkg.InitOrder)
+			for _, fn := range pkg.Functions {
+				fn.Params = deps(fn.Params)
+				fn.Results = deps(fn.Results)
+				fn.FunctionCalls = deps(fn.FunctionCalls)
+				fn.MethodCalls = deps(fn.MethodCalls)
+				fn.Types = deps(fn.Types)
+				fn.GlobalVars = deps(fn.GlobalVars)
+				if fn.Errors != nil {
+					if fn.Errors.Type != nil {
+						tmp := id(*fn.Errors.Type)
+						fn.Errors.Type = &tmp
+					}
+					for i, site := range fn.Errors.Sites {
+						if site.Error != nil {
+							tmp := id(*site.Error)
+							fn.Errors.Sites[i].Error = &tmp
+						}
+					}
+				}
+				if fn.Receiver != nil {
+					fn.Receiver.Type = id(fn.Receiver.Type)
+					if fn.Receiver.Interface != nil {
+						tmp := id(*fn.Receiver.Interface)
+						fn.Receiver.Interface = &tmp
+					}
+				}
+			}
+			for _, t := range pkg.Types {
+				t.SubStruct = deps(t.SubStruct)
+				t.InlineStruct = deps(t.InlineStruct)
+				t.Implements = ids(t.Implements)
+				for name, m := range t.Methods {
+					t.Methods[name] = id(m)
+				}
+				for i := range t.Fields {
+					if t.Fields[i].TypeId != nil {
+						tmp := id(*t.Fields[i].TypeId)
+						t.Fields[i].TypeId = &tmp
+					}
+				}
+			}
+			for _, v := range pkg.Vars {
+				v.Dependencies = deps(v.Dependencies)
+				v.Groups = ids(v.Groups)
+				if v.Type != nil {
+					tmp := id(*v.Type)
+					v.Type = &tmp
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,220 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uniast
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ExternalDependency is a third-party module which the internal modules depend on
+type ExternalDependency struct {
+	// Name is the module path or package name, like `github.com/bytedance/sonic` or `PyYAML`
+	Name    string
+	Version string `json:",omitempty"`
+	// Language of the internal modules depending on it
+	Language Language `json:",omitempty"`
+	// License is the SPDX identifier like `MIT`, detected from the license file, see DetectLicenses
+	License string `json:",omitempty"`
+	// Module is the external module holding its loaded symbols, like `github.com/bytedance/sonic@v1.12.1`
+	Module string `json:",omitempty"`
+	// Dependents are the internal modules depending on it, in order
+	Dependents []string
+}
+
+// ExternalDependencies aggregates the third-party dependencies declared by the internal modules
+// (Module.Dependencies, like `path@version` of Go modules) and the external modules whose symbols are loaded.
+// External modules without versions, like the standard libraries, are skipped.
+// They are ordered by names and versions
+func (r *Repository) ExternalDependencies() []ExternalDependency {
+	deps := map[string]*ExternalDependency{}
+	add := func(name, version string, lang Language, dependent string) *ExternalDependency {
+		key := name + "@" + version
+		d := deps[key]
+		if d == nil {
+			d = &ExternalDependency{Name: name, Version: version, Language: lang}
+			deps[key] = d
+		}
+		if dependent != "" && !contains(d.Dependents, dependent) {
+			d.Dependents = append(d.Dependents, dependent)
+		}
+		return d
+	}
+
+	for _, mod := range r.Modules {
+		if mod.IsExternal() {
+			continue
+		}
+		for _, v := range mod.Dependencies {
+			if strings.HasPrefix(v, ".") || filepath.IsAbs(v) {
+				// replaced by a local dir
+				continue
+			}
+			name, version := splitModuleVersion(v)
+			if m := r.Modules[name]; m != nil && !m.IsExternal() {
+				continue
+			}
+			add(name, version, mod.Language, mod.Name)
+		}
+	}
+
+	// the internal modules depending on the external ones by the dependencies of their nodes
+	dependents := map[ModPath]map[string]bool{}
+	depend := func(mod string, ds []Dependency) {
+		for _, d := range ds {
+			if d.ModPath == mod {
+				continue
+			}
+			if dependents[d.ModPath] == nil {
+				dependents[d.ModPath] = map[string]bool{}
+			}
+			dependents[d.ModPath][mod] = true
+		}
+	}
+	for _, mod := range r.Modules {
+		if mod.IsExternal() {
+			continue
+		}
+		for _, pkg := range mod.Packages {
+			for _, fn := range pkg.Functions {
+				for _, ds := range [][]Dependency{fn.Params, fn.Results, fn.FunctionCalls, fn.MethodCalls, fn.Types, fn.GlobalVars} {
+					depend(mod.Name, ds)
+				}
+			}
+			for _, t := range pkg.Types {
+				depend(mod.Name, t.SubStruct)
+				depend(mod.Name, t.InlineStruct)
+			}
+			for _, v := range pkg.Vars {
+				depend(mod.Name, v.Dependencies)
+			}
+		}
+	}
+	for path, mod := range r.Modules {
+		if !mod.IsExternal() {
+			continue
+		}
+		name, version := mod.Name, mod.Version
+		if version == "" {
+			name, version = splitModuleVersion(path)
+		}
+		if version == "" {
+			continue
+		}
+		d := add(name, version, mod.Language, "")
+		d.Module = path
+		for dep := range dependents[path] {
+			if !contains(d.Dependents, dep) {
+				d.Dependents = append(d.Dependents, dep)
+			}
+		}
+	}
+
+	ret := make([]ExternalDependency, 0, len(deps))
+	for _, d := range deps {
+		sort.Strings(d.Dependents)
+		ret = append(ret, *d)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Name != ret[j].Name {
+			return ret[i].Name < ret[j].Name
+		}
+		return ret[i].Version < ret[j].Version
+	})
+	return ret
+}
+
+// splitModuleVersion splits the module like `github.com/a/b@v1.0.0` or `serde@1.0.1`
+func splitModuleVersion(mod string) (name, version string) {
+	if i := strings.LastIndexByte(mod, '@'); i > 0 {
+		return mod[:i], mod[i+1:]
+	}
+	return mod, ""
+}
+
+func contains(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// DetectLicenses fills the licenses of r.Dependencies by the license files in their source dirs.
+// dir returns the source dir of a dependency, empty if unknown
+func (r *Repository) DetectLicenses(dir func(dep ExternalDependency) string) {
+	for i, dep := range r.Dependencies {
+		if d := dir(dep); d != "" {
+			r.Dependencies[i].License = DetectLicense(d)
+		}
+	}
+}
+
+var licenseFileRegex = regexp.MustCompile(`(?i)^(LICEN[CS]E|COPYING)([-._].*)?$`)
+
+// the patterns are matched in order, thus the more specific ones come first
+var licensePatterns = []struct {
+	id      string
+	pattern *regexp.Regexp
+}{
+	{"Apache-2.0", regexp.MustCompile(`(?i)apache license,?\s+version 2\.0`)},
+	{"MPL-2.0", regexp.MustCompile(`(?i)mozilla public license,?\s+(version|v\.?)\s*2\.0`)},
+	{"AGPL-3.0", regexp.MustCompile(`(?i)gnu affero general public license\s+version 3`)},
+	{"LGPL-3.0", regexp.MustCompile(`(?i)gnu lesser general public license\s+version 3`)},
+	{"LGPL-2.1", regexp.MustCompile(`(?i)gnu lesser general public license\s+version 2\.1`)},
+	{"GPL-3.0", regexp.MustCompile(`(?i)gnu general public license\s+version 3`)},
+	{"GPL-2.0", regexp.MustCompile(`(?i)gnu general public license\s+version 2`)},
+	{"BSD-3-Clause", regexp.MustCompile(`(?is)redistributions of source code.*neither the name`)},
+	{"BSD-2-Clause", regexp.MustCompile(`(?i)redistributions of source code must retain`)},
+	{"MIT", regexp.MustCompile(`(?i)permission is hereby granted, free of charge`)},
+	{"ISC", regexp.MustCompile(`(?i)permission to use, copy, modify, and(/or)? distribute this software for any purpose`)},
+	{"Unlicense", regexp.MustCompile(`(?i)this is free and unencumbered software released into the public domain`)},
+}
+
+// DetectLicense detects the license by the license files (LICENSE, COPYING...) in the dir,
+// returns the SPDX identifiers joined by ` OR ` for multiple licenses, like `Apache-2.0 OR MIT` of many rust crates,
+// or empty if not detected
+func DetectLicense(dir string) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+	found := map[string]bool{}
+	for _, e := range entries {
+		if e.IsDir() || !licenseFileRegex.MatchString(e.Name()) {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			continue
+		}
+		for _, p := range licensePatterns {
+			if p.pattern.Match(data) {
+				found[p.id] = true
+				break
+			}
+		}
+	}
+	ids := make([]string, 0, len(found))
+	for id := range found {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return strings.Join(ids, " OR ")
+}
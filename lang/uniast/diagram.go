@@ -0,0 +1,255 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uniast
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Relabel returns a copy of the graph whose vertices are renamed by the labels keyed by vertex ids,
+// e.g. the human readable names of the packages written by an LLM
+func (g ExportGraph) Relabel(labels map[string]string) ExportGraph {
+	g.Vertices = append([]GraphVertex(nil), g.Vertices...)
+	for i, v := range g.Vertices {
+		if l, ok := labels[v.ID]; ok && l != "" {
+			g.Vertices[i].Name = l
+		}
+	}
+	return g
+}
+
+// WriteMermaid writes the graph as a mermaid flowchart, the component diagram of packages, or of nodes clustered by package.
+// The shape tells the kind, external vertices are dashed and the relations other than Dependency are dotted
+func (g ExportGraph) WriteMermaid(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "flowchart LR")
+	// mermaid ids are plain words, thus the vertices are numbered in order
+	ids := make(map[string]string, len(g.Vertices))
+	for i, v := range g.Vertices {
+		ids[v.ID] = fmt.Sprintf("n%d", i)
+	}
+	var externals []string
+	writeVertex := func(indent string, v GraphVertex) {
+		open, close := mermaidShape(v.Kind)
+		fmt.Fprintf(bw, "%s%s%s\"%s\"%s\n", indent, ids[v.ID], open, mermaidText(v.Name), close)
+		if v.External {
+			externals = append(externals, ids[v.ID])
+		}
+	}
+	cluster := 0
+	for i := 0; i < len(g.Vertices); {
+		v := g.Vertices[i]
+		if v.Kind == PackageKind {
+			writeVertex("  ", v)
+			i++
+			continue
+		}
+		// vertices are sorted by id, thus those of a package are adjacent
+		j := i
+		for j < len(g.Vertices) && g.Vertices[j].ModPath == v.ModPath && g.Vertices[j].PkgPath == v.PkgPath {
+			j++
+		}
+		fmt.Fprintf(bw, "  subgraph s%d[\"%s\"]\n", cluster, mermaidText(string(v.PkgPath)))
+		for _, v := range g.Vertices[i:j] {
+			writeVertex("    ", v)
+		}
+		fmt.Fprintln(bw, "  end")
+		cluster++
+		i = j
+	}
+
+	for _, e := range g.Edges {
+		var labels []string
+		arrow := "-->"
+		if e.Kind != DEPENDENCY {
+			labels = append(labels, string(e.Kind))
+			arrow = "-.->"
+		}
+		if e.Count > 1 {
+			labels = append(labels, fmt.Sprint(e.Count))
+		}
+		if len(labels) > 0 {
+			fmt.Fprintf(bw, "  %s %s|\"%s\"| %s\n", ids[e.From], arrow, strings.Join(labels, " "), ids[e.To])
+		} else {
+			fmt.Fprintf(bw, "  %s %s %s\n", ids[e.From], arrow, ids[e.To])
+		}
+	}
+	if len(externals) > 0 {
+		fmt.Fprintln(bw, "  classDef external stroke-dasharray: 5 5")
+		fmt.Fprintf(bw, "  class %s external\n", strings.Join(externals, ","))
+	}
+	return bw.Flush()
+}
+
+func mermaidShape(kind string) (string, string) {
+	switch kind {
+	case "FUNC":
+		return "([", "])"
+	case "TYPE":
+		return "[", "]"
+	case "VAR":
+		return "[/", "/]"
+	case PackageKind:
+		return "[[", "]]"
+	default:
+		return "(", ")"
+	}
+}
+
+// mermaidText escapes the text of a label by the entity codes of mermaid
+func mermaidText(s string) string {
+	r := strings.NewReplacer(`"`, "#quot;", ";", "#59;", "<", "#lt;", ">", "#gt;", "\n", " ")
+	return r.Replace(s)
+}
+
+// SequenceOptions are the options of CallSequence
+type SequenceOptions struct {
+	// MaxDepth limits the nesting of the calls from the entry, no limit if not positive
+	MaxDepth int
+	// External keeps the calls to the external functions, which are never expanded
+	External bool
+}
+
+// SequenceCall is a call in a sequence diagram
+type SequenceCall struct {
+	// From and To are the indexes of the participants of the caller and the callee
+	From, To int
+	// Node is Identity.Full() of the callee
+	Node string
+	Name string
+	// Depth is the nesting of the call, 1 for those called by the entry
+	Depth int
+	// Dynamic tells the callee is a possible target of a call through an interface or a function value
+	Dynamic bool `json:",omitempty"`
+}
+
+// SequenceDiagram is the calls from an entry function in order, the participants are the packages
+type SequenceDiagram struct {
+	Entry        Identity
+	Participants []GraphVertex
+	Calls        []SequenceCall
+}
+
+// CallSequence follows the calls from the entry function depth-first, in the order they appear in the callers.
+// Each function is expanded only once, thus recursions and repeated call chains are cut.
+// The calls of a function to the same callee are merged
+func (r *Repository) CallSequence(entry Identity, opts SequenceOptions) (SequenceDiagram, error) {
+	fn := r.GetFunction(entry)
+	if fn == nil {
+		return SequenceDiagram{}, fmt.Errorf("function %s not found", entry.Full())
+	}
+	ret := SequenceDiagram{Entry: entry}
+	participants := map[string]int{}
+	participant := func(id Identity, external bool) int {
+		key := string(id.ModPath) + "?" + string(id.PkgPath)
+		if i, ok := participants[key]; ok {
+			return i
+		}
+		participants[key] = len(ret.Participants)
+		ret.Participants = append(ret.Participants, GraphVertex{
+			ID:       key,
+			Name:     string(id.PkgPath),
+			Kind:     PackageKind,
+			ModPath:  id.ModPath,
+			PkgPath:  id.PkgPath,
+			External: external,
+		})
+		return participants[key]
+	}
+
+	expanded := map[string]bool{entry.Full(): true}
+	var visit func(fn *Function, from int, depth int)
+	visit = func(fn *Function, from int, depth int) {
+		if opts.MaxDepth > 0 && depth >= opts.MaxDepth {
+			return
+		}
+		calls := make([]Dependency, 0, len(fn.FunctionCalls)+len(fn.MethodCalls))
+		calls = append(append(calls, fn.FunctionCalls...), fn.MethodCalls...)
+		sort.SliceStable(calls, func(i, j int) bool {
+			return calls[i].Line < calls[j].Line
+		})
+		called := map[string]bool{}
+		for _, c := range calls {
+			key := c.Full()
+			if called[key] || c.Name == "" {
+				continue
+			}
+			called[key] = true
+			mod := r.Modules[c.ModPath]
+			internal := mod != nil && !mod.IsExternal()
+			callee := r.GetFunction(c.Identity)
+			if (internal && callee == nil) || (!internal && !opts.External) {
+				continue
+			}
+			to := participant(c.Identity, !internal)
+			ret.Calls = append(ret.Calls, SequenceCall{From: from, To: to, Node: key, Name: c.Name, Depth: depth + 1, Dynamic: c.Dynamic})
+			if internal && !expanded[key] {
+				expanded[key] = true
+				visit(callee, to, depth+1)
+			}
+		}
+	}
+	visit(fn, participant(entry, false), 0)
+	return ret, nil
+}
+
+// Relabel returns a copy of the diagram whose participants and calls are renamed by the labels,
+// keyed by the ids of the participants (`mod?pkg`) or the callees (Identity.Full())
+func (d SequenceDiagram) Relabel(labels map[string]string) SequenceDiagram {
+	d.Participants = ExportGraph{Vertices: d.Participants}.Relabel(labels).Vertices
+	d.Calls = append([]SequenceCall(nil), d.Calls...)
+	for i, c := range d.Calls {
+		if l, ok := labels[c.Node]; ok && l != "" {
+			d.Calls[i].Name = l
+		}
+	}
+	return d
+}
+
+// WriteMermaid writes the diagram as a mermaid sequence diagram.
+// A callee is activated while it calls others, and the dynamic calls are dotted
+func (d SequenceDiagram) WriteMermaid(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "sequenceDiagram")
+	for i, p := range d.Participants {
+		fmt.Fprintf(bw, "  participant p%d as %s\n", i, mermaidText(p.Name))
+	}
+	fmt.Fprintf(bw, "  Note over p0: %s\n", mermaidText(d.Entry.Name))
+	// the participants activated by the nesting calls
+	var active []int
+	for i, c := range d.Calls {
+		for len(active) >= c.Depth {
+			fmt.Fprintf(bw, "  deactivate p%d\n", active[len(active)-1])
+			active = active[:len(active)-1]
+		}
+		arrow := "->>"
+		if c.Dynamic {
+			arrow = "-->>"
+		}
+		fmt.Fprintf(bw, "  p%d%sp%d: %s\n", c.From, arrow, c.To, mermaidText(c.Name))
+		if i+1 < len(d.Calls) && d.Calls[i+1].Depth > c.Depth {
+			fmt.Fprintf(bw, "  activate p%d\n", c.To)
+			active = append(active, c.To)
+		}
+	}
+	for i := len(active) - 1; i >= 0; i-- {
+		fmt.Fprintf(bw, "  deactivate p%d\n", active[i])
+	}
+	return bw.Flush()
+}
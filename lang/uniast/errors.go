@@ -0,0 +1,153 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uniast
+
+import "sort"
+
+// ErrorKind is how a function originates an error
+type ErrorKind string
+
+const (
+	// ErrorNew: creates an error by a message, like `errors.New("...")` or `fmt.Errorf` without %w
+	ErrorNew ErrorKind = "new"
+	// ErrorWrap: wraps another error, like `fmt.Errorf("...: %w", err)` or `errors.Wrap(err, "...")`
+	ErrorWrap ErrorKind = "wrap"
+	// ErrorSentinel: returns a sentinel error var, like `return nil, io.EOF`
+	ErrorSentinel ErrorKind = "sentinel"
+	// ErrorTyped: creates a value of an error type, like `&PathError{...}`
+	ErrorTyped ErrorKind = "type"
+	// ErrorDeclared: returns the error type declared by the signature, like `Result<T, E>`.
+	// Only reported by ErrorOrigins for the functions without any site
+	ErrorDeclared ErrorKind = "declared"
+)
+
+// ErrorSite is where a function originates an error
+type ErrorSite struct {
+	Kind ErrorKind
+	// Error is the error type or the sentinel var, which is the wrapped one for ErrorWrap. Nil if unknown
+	Error *Identity `json:",omitempty"`
+	// Message is the message or the format of the error, like `open %s: %w`, if it is a literal
+	Message string `json:",omitempty"`
+	FileLine
+}
+
+// Errors is the error flow of a function, to tell where the errors it returns originate, see ErrorOrigins
+type Errors struct {
+	// Returns tells if the function returns an error: the last result implements error (go), or the result is a Result (rust)
+	Returns bool `json:",omitempty"`
+	// Type is the error type declared by the signature: the E of `Result<T, E>` (rust),
+	// or the concrete error type returned in place of `error` (go). Nil if it is not declared or unknown
+	Type *Identity `json:",omitempty"`
+	// Sites are where the function creates, wraps or returns the errors by itself in order (go only)
+	Sites []ErrorSite `json:",omitempty"`
+}
+
+// ErrorOrigin is a site originating an error, see ErrorOrigins
+type ErrorOrigin struct {
+	Function Identity
+	ErrorSite
+	// Via are the functions calling Function from the queried one in order, excluding both
+	Via []Identity `json:",omitempty"`
+}
+
+// ErrorOrigins tells where the error can originate, sorted by the sites:
+//   - for an error type or a sentinel var, the sites creating, returning or wrapping it,
+//     and the functions declaring it as the error type;
+//   - for a function, the sites of itself and of the callees returning errors transitively,
+//     through which the errors it returns can originate;
+//   - for the empty identity, all the sites of the internal functions except the tests.
+func (r *Repository) ErrorOrigins(id Identity) []ErrorOrigin {
+	var ret []ErrorOrigin
+	if fn := r.GetFunction(id); fn != nil {
+		ret = r.errorOriginsOf(fn)
+	} else {
+		for _, mod := range r.Modules {
+			if id == (Identity{}) && mod.IsExternal() {
+				continue
+			}
+			for _, pkg := range mod.Packages {
+				for _, fn := range pkg.Functions {
+					if fn.Errors == nil || (id == (Identity{}) && fn.IsTest) {
+						continue
+					}
+					found := false
+					for _, site := range fn.Errors.Sites {
+						if id == (Identity{}) || (site.Error != nil && *site.Error == id) {
+							ret = append(ret, ErrorOrigin{Function: fn.Identity, ErrorSite: site})
+							found = true
+						}
+					}
+					if !found && id != (Identity{}) && fn.Errors.Type != nil && *fn.Errors.Type == id {
+						ret = append(ret, declaredError(fn))
+					}
+				}
+			}
+		}
+	}
+	sort.SliceStable(ret, func(i, j int) bool {
+		if ret[i].File != ret[j].File {
+			return ret[i].File < ret[j].File
+		}
+		if ret[i].Line != ret[j].Line {
+			return ret[i].Line < ret[j].Line
+		}
+		return ret[i].Function.Full() < ret[j].Function.Full()
+	})
+	return ret
+}
+
+// errorOriginsOf walks the callees returning errors from the function breadth-first
+func (r *Repository) errorOriginsOf(from *Function) []ErrorOrigin {
+	var ret []ErrorOrigin
+	callers := map[Identity]Identity{}
+	seen := map[Identity]bool{from.Identity: true}
+	queue := []*Function{from}
+	for len(queue) > 0 {
+		fn := queue[0]
+		queue = queue[1:]
+		var via []Identity
+		if fn != from {
+			for c := callers[fn.Identity]; c != from.Identity; c = callers[c] {
+				via = append([]Identity{c}, via...)
+			}
+		}
+		if fn.Errors != nil {
+			for _, site := range fn.Errors.Sites {
+				ret = append(ret, ErrorOrigin{Function: fn.Identity, ErrorSite: site, Via: via})
+			}
+			if len(fn.Errors.Sites) == 0 && fn.Errors.Type != nil {
+				o := declaredError(fn)
+				o.Via = via
+				ret = append(ret, o)
+			}
+		}
+		for _, calls := range [][]Dependency{fn.FunctionCalls, fn.MethodCalls} {
+			for _, call := range calls {
+				callee := r.GetFunction(call.Identity)
+				if callee == nil || seen[callee.Identity] || callee.Errors == nil || !callee.Errors.Returns {
+					continue
+				}
+				seen[callee.Identity] = true
+				callers[callee.Identity] = fn.Identity
+				queue = append(queue, callee)
+			}
+		}
+	}
+	return ret
+}
+
+func declaredError(fn *Function) ErrorOrigin {
+	return ErrorOrigin{Function: fn.Identity, ErrorSite: ErrorSite{Kind: ErrorDeclared, Error: fn.Errors.Type, FileLine: fn.FileLine}}
+}
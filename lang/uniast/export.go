@@ -0,0 +1,376 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package uniast
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ExportFormat is the format of the exported graph
+type ExportFormat string
+
+const (
+	// ExportDOT is the Graphviz DOT language
+	ExportDOT ExportFormat = "dot"
+	// ExportGraphML is the GraphML XML format, read by Gephi, yEd, NetworkX and so on
+	ExportGraphML ExportFormat = "graphml"
+	// ExportNeo4j is the CSV files of nodes and relationships for `neo4j-admin database import`
+	ExportNeo4j ExportFormat = "neo4j"
+	// ExportMermaid is the mermaid flowchart, which can be embedded in markdown
+	ExportMermaid ExportFormat = "mermaid"
+)
+
+// ExportGranularity is what the vertices of the exported graph are
+type ExportGranularity string
+
+const (
+	// GranularityNode exports the functions, types and vars as vertices
+	GranularityNode ExportGranularity = "node"
+	// GranularityPackage exports the packages as vertices, the relations among their nodes are merged
+	GranularityPackage ExportGranularity = "package"
+)
+
+// PackageKind is the kind of the vertices of packages
+const PackageKind = "PACKAGE"
+
+type ExportOptions struct {
+	// Granularity is node level by default
+	Granularity ExportGranularity
+	// External keeps the external nodes (or packages) that the internal ones relate to
+	External bool
+	// PkgPrefix only exports the internal nodes (or packages) whose package path has the prefix, if not empty
+	PkgPrefix PkgPath
+}
+
+// GraphVertex is a node or a package in the exported graph
+type GraphVertex struct {
+	// ID is Identity.Full() of a node, or `mod?pkg` of a package
+	ID   string
+	Name string
+	// Kind is FUNC, TYPE, VAR, UNKNOWN (external node without definition) or PACKAGE
+	Kind     string
+	ModPath  ModPath
+	PkgPath  PkgPath
+	File     string `json:",omitempty"`
+	Line     int    `json:",omitempty"`
+	External bool   `json:",omitempty"`
+}
+
+// GraphEdge is a relation in the exported graph
+type GraphEdge struct {
+	From string
+	To   string
+	Kind RelationKind
+	// Count is the number of node relations merged into a package edge, 1 for node edges
+	Count int
+}
+
+// ExportGraph is the graph of a repository to export, the vertices and edges are sorted
+type ExportGraph struct {
+	Name     string
+	Vertices []GraphVertex
+	Edges    []GraphEdge
+}
+
+// ExportGraph converts the dependency graph to vertices and edges of the granularity.
+// The edges are the Dependency, Implement, Inherit and Group relations, see BuildGraph.
+// The relations within a package are dropped at the package granularity
+func (r *Repository) ExportGraph(opts ExportOptions) ExportGraph {
+	r.EnsureGraph()
+	vertices := map[string]GraphVertex{}
+	type edgeKey struct {
+		from, to string
+		kind     RelationKind
+	}
+	edges := map[edgeKey]int{}
+
+	vertexOf := func(id Identity, typ NodeType, external bool) GraphVertex {
+		if opts.Granularity == GranularityPackage {
+			return GraphVertex{
+				ID:       string(id.ModPath) + "?" + string(id.PkgPath),
+				Name:     string(id.PkgPath),
+				Kind:     PackageKind,
+				ModPath:  id.ModPath,
+				PkgPath:  id.PkgPath,
+				External: external,
+			}
+		}
+		fl := Node{Identity: id, Type: typ, Repo: r}.FileLine()
+		return GraphVertex{
+			ID:       id.Full(),
+			Name:     id.Name,
+			Kind:     typ.String(),
+			ModPath:  id.ModPath,
+			PkgPath:  id.PkgPath,
+			File:     fl.File,
+			Line:     fl.Line,
+			External: external,
+		}
+	}
+
+	internal := func(id Identity) bool {
+		mod := r.Modules[id.ModPath]
+		return mod != nil && !mod.IsExternal()
+	}
+	inScope := func(id Identity) bool {
+		return strings.HasPrefix(id.PkgPath, opts.PkgPrefix)
+	}
+	for _, node := range r.Graph {
+		if !internal(node.Identity) || !inScope(node.Identity) {
+			continue
+		}
+		from := vertexOf(node.Identity, node.Type, false)
+		vertices[from.ID] = from
+		for _, rels := range [][]Relation{node.Dependencies, node.Implements, node.Inherits, node.Groups} {
+			for _, rel := range rels {
+				var to GraphVertex
+				if internal(rel.Identity) {
+					if !inScope(rel.Identity) {
+						continue
+					}
+					to = vertexOf(rel.Identity, r.nodeTypeOf(rel.Identity), false)
+				} else if opts.External {
+					to = vertexOf(rel.Identity, r.nodeTypeOf(rel.Identity), true)
+				} else {
+					continue
+				}
+				if to.ID == from.ID && opts.Granularity == GranularityPackage {
+					continue
+				}
+				if _, ok := vertices[to.ID]; !ok {
+					vertices[to.ID] = to
+				}
+				edges[edgeKey{from.ID, to.ID, rel.Kind}]++
+			}
+		}
+	}
+
+	ret := ExportGraph{Name: r.Name}
+	for _, v := range vertices {
+		ret.Vertices = append(ret.Vertices, v)
+	}
+	sort.Slice(ret.Vertices, func(i, j int) bool {
+		return ret.Vertices[i].ID < ret.Vertices[j].ID
+	})
+	for k, n := range edges {
+		ret.Edges = append(ret.Edges, GraphEdge{From: k.from, To: k.to, Kind: k.kind, Count: n})
+	}
+	sort.Slice(ret.Edges, func(i, j int) bool {
+		a, b := ret.Edges[i], ret.Edges[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		if a.To != b.To {
+			return a.To < b.To
+		}
+		return a.Kind < b.Kind
+	})
+	return ret
+}
+
+// nodeTypeOf returns the type of the node in the graph or defined in the modules, UNKNOWN if neither
+func (r *Repository) nodeTypeOf(id Identity) NodeType {
+	if n, ok := r.Graph[id.Full()]; ok {
+		return n.Type
+	}
+	if r.GetFunction(id) != nil {
+		return FUNC
+	}
+	if r.GetType(id) != nil {
+		return TYPE
+	}
+	if r.GetVar(id) != nil {
+		return VAR
+	}
+	return UNKNOWN
+}
+
+// WriteDOT writes the graph in the Graphviz DOT language.
+// Nodes are clustered by package, and the shape tells the kind
+func (g ExportGraph) WriteDOT(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "digraph %s {\n", dotQuote(g.Name))
+	fmt.Fprintln(bw, "  rankdir=LR;")
+	fmt.Fprintln(bw, "  node [fontname=\"Helvetica\"];")
+
+	writeVertex := func(indent string, v GraphVertex) {
+		attrs := []string{"label=" + dotQuote(v.Name), "shape=" + dotShape(v.Kind)}
+		if v.File != "" {
+			attrs = append(attrs, "tooltip="+dotQuote(fmt.Sprintf("%s:%d", v.File, v.Line)))
+		}
+		if v.External {
+			attrs = append(attrs, "style=dashed")
+		}
+		fmt.Fprintf(bw, "%s%s [%s];\n", indent, dotQuote(v.ID), strings.Join(attrs, ", "))
+	}
+	cluster := 0
+	for i := 0; i < len(g.Vertices); {
+		v := g.Vertices[i]
+		if v.Kind == PackageKind {
+			writeVertex("  ", v)
+			i++
+			continue
+		}
+		// vertices are sorted by id, thus those of a package are adjacent
+		j := i
+		for j < len(g.Vertices) && g.Vertices[j].ModPath == v.ModPath && g.Vertices[j].PkgPath == v.PkgPath {
+			j++
+		}
+		fmt.Fprintf(bw, "  subgraph cluster_%d {\n", cluster)
+		fmt.Fprintf(bw, "    label=%s;\n", dotQuote(string(v.PkgPath)))
+		for _, v := range g.Vertices[i:j] {
+			writeVertex("    ", v)
+		}
+		fmt.Fprintln(bw, "  }")
+		cluster++
+		i = j
+	}
+
+	for _, e := range g.Edges {
+		var attrs, labels []string
+		if e.Kind != DEPENDENCY {
+			labels = append(labels, string(e.Kind))
+			attrs = append(attrs, "style=dashed")
+		}
+		if e.Count > 1 {
+			labels = append(labels, strconv.Itoa(e.Count))
+			attrs = append(attrs, "weight="+strconv.Itoa(e.Count))
+		}
+		if len(labels) > 0 {
+			attrs = append([]string{"label=" + dotQuote(strings.Join(labels, " "))}, attrs...)
+		}
+		if len(attrs) > 0 {
+			fmt.Fprintf(bw, "  %s -> %s [%s];\n", dotQuote(e.From), dotQuote(e.To), strings.Join(attrs, ", "))
+		} else {
+			fmt.Fprintf(bw, "  %s -> %s;\n", dotQuote(e.From), dotQuote(e.To))
+		}
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+func dotQuote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	return `"` + r.Replace(s) + `"`
+}
+
+func dotShape(kind string) string {
+	switch kind {
+	case "FUNC":
+		return "ellipse"
+	case "TYPE":
+		return "box"
+	case "VAR":
+		return "note"
+	case PackageKind:
+		return "folder"
+	default:
+		return "plaintext"
+	}
+}
+
+// WriteGraphML writes the graph in GraphML, the fields of vertices and edges are the data of them
+func (g ExportGraph) WriteGraphML(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, `<?xml version="1.0" encoding="UTF-8"?>`)
+	fmt.Fprintln(bw, `<graphml xmlns="http://graphml.graphdrawing.org/xmlns">`)
+	for _, k := range [][3]string{
+		{"name", "node", "string"},
+		{"kind", "node", "string"},
+		{"module", "node", "string"},
+		{"package", "node", "string"},
+		{"file", "node", "string"},
+		{"line", "node", "int"},
+		{"external", "node", "boolean"},
+		{"relation", "edge", "string"},
+		{"count", "edge", "int"},
+	} {
+		fmt.Fprintf(bw, "  <key id=%q for=%q attr.name=%q attr.type=%q/>\n", k[0], k[1], k[0], k[2])
+	}
+	fmt.Fprintf(bw, "  <graph id=\"%s\" edgedefault=\"directed\">\n", xmlEscape(g.Name))
+	data := func(key, value string) {
+		if value != "" {
+			fmt.Fprintf(bw, "      <data key=%q>%s</data>\n", key, xmlEscape(value))
+		}
+	}
+	for _, v := range g.Vertices {
+		fmt.Fprintf(bw, "    <node id=\"%s\">\n", xmlEscape(v.ID))
+		data("name", v.Name)
+		data("kind", v.Kind)
+		data("module", string(v.ModPath))
+		data("package", string(v.PkgPath))
+		data("file", v.File)
+		if v.Line > 0 {
+			data("line", strconv.Itoa(v.Line))
+		}
+		data("external", strconv.FormatBool(v.External))
+		fmt.Fprintln(bw, "    </node>")
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(bw, "    <edge source=\"%s\" target=\"%s\">\n", xmlEscape(e.From), xmlEscape(e.To))
+		data("relation", string(e.Kind))
+		data("count", strconv.Itoa(e.Count))
+		fmt.Fprintln(bw, "    </edge>")
+	}
+	fmt.Fprintln(bw, "  </graph>")
+	fmt.Fprintln(bw, "</graphml>")
+	return bw.Flush()
+}
+
+func xmlEscape(s string) string {
+	var sb strings.Builder
+	_ = xml.EscapeText(&sb, []byte(s))
+	return sb.String()
+}
+
+// WriteNeo4j writes the vertices and edges as the node and relationship CSV files of `neo4j-admin database import`.
+// The kind of a vertex is its label (along with External for external ones),
+// and the upper-cased kind of an edge is its type, e.g. DEPENDENCY
+func (g ExportGraph) WriteNeo4j(nodes io.Writer, relationships io.Writer) error {
+	nw := csv.NewWriter(nodes)
+	_ = nw.Write([]string{"id:ID", "name", "module", "package", "file", "line:int", ":LABEL"})
+	for _, v := range g.Vertices {
+		label := v.Kind
+		if v.External {
+			label += ";External"
+		}
+		line := ""
+		if v.Line > 0 {
+			line = strconv.Itoa(v.Line)
+		}
+		_ = nw.Write([]string{v.ID, v.Name, string(v.ModPath), string(v.PkgPath), v.File, line, label})
+	}
+	nw.Flush()
+	if err := nw.Error(); err != nil {
+		return err
+	}
+
+	rw := csv.NewWriter(relationships)
+	_ = rw.Write([]string{":START_ID", ":END_ID", ":TYPE", "count:int"})
+	for _, e := range g.Edges {
+		_ = rw.Write([]string{e.From, e.To, strings.ToUpper(string(e.Kind)), strconv.Itoa(e.Count)})
+	}
+	rw.Flush()
+	return rw.Error()
+}
@@ -0,0 +1,149 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package uniast
+
+import (
+	"fmt"
+	"slices"
+)
+
+// NodeKinds are the kinds of nodes to filter by, as counted in RepoStats.Nodes.
+// `type` covers all kinds of types
+var NodeKinds = []string{"function", "method", "test", "type", string(TypeKindStruct), string(TypeKindInterface), string(TypeKindTypedef), string(TypeKindEnum), "var", "const"}
+
+func functionKind(fn *Function) string {
+	if fn.IsTest {
+		return "test"
+	} else if fn.IsMethod || fn.Receiver != nil {
+		return "method"
+	}
+	return "function"
+}
+
+func typeKind(t *Type) string {
+	if t.TypeKind == "" {
+		return "type"
+	}
+	return string(t.TypeKind)
+}
+
+func varKind(v *Var) string {
+	if v.IsConst {
+		return "const"
+	}
+	return "var"
+}
+
+// KindFilter filters the nodes by kinds (see NodeKinds).
+// A node is kept if Include is empty or contains its kind, and Exclude does not contain its kind
+type KindFilter struct {
+	Include []string
+	Exclude []string
+}
+
+// IsEmpty tells if the filter keeps all nodes
+func (f KindFilter) IsEmpty() bool {
+	return len(f.Include) == 0 && len(f.Exclude) == 0
+}
+
+// Validate checks the kinds are known
+func (f KindFilter) Validate() error {
+	for _, kind := range append(slices.Clone(f.Include), f.Exclude...) {
+		if !slices.Contains(NodeKinds, kind) {
+			return fmt.Errorf("unknown node kind %q, must be one of %v", kind, NodeKinds)
+		}
+	}
+	return nil
+}
+
+// Keep tells if the node of the kinds is kept, a type matches both `type` and its TypeKind
+func (f KindFilter) Keep(kinds ...string) bool {
+	if len(f.Include) > 0 && !slices.ContainsFunc(kinds, func(k string) bool { return slices.Contains(f.Include, k) }) {
+		return false
+	}
+	return !slices.ContainsFunc(kinds, func(k string) bool { return slices.Contains(f.Exclude, k) })
+}
+
+// FilterKinds removes the nodes of the internal modules which the filter does not keep,
+// as well as the dependencies and relations on them. It must be called before BuildGraph.
+func (r *Repository) FilterKinds(f KindFilter) {
+	if f.IsEmpty() {
+		return
+	}
+	removed := map[Identity]bool{}
+	for _, mod := range r.InternalModules() {
+		for _, pkg := range mod.Packages {
+			for name, fn := range pkg.Functions {
+				if !f.Keep(functionKind(fn)) {
+					removed[fn.Identity] = true
+					delete(pkg.Functions, name)
+				}
+			}
+			for name, t := range pkg.Types {
+				if !f.Keep("type", typeKind(t)) {
+					removed[t.Identity] = true
+					delete(pkg.Types, name)
+				}
+			}
+			for name, v := range pkg.Vars {
+				if !f.Keep(varKind(v)) {
+					removed[v.Identity] = true
+					delete(pkg.Vars, name)
+				}
+			}
+		}
+	}
+	if len(removed) == 0 {
+		return
+	}
+
+	deps := func(ds []Dependency) []Dependency {
+		return slices.DeleteFunc(ds, func(d Dependency) bool { return removed[d.Identity] })
+	}
+	ids := func(ids []Identity) []Identity {
+		return slices.DeleteFunc(ids, func(id Identity) bool { return removed[id] })
+	}
+	for _, mod := range r.InternalModules() {
+		for _, pkg := range mod.Packages {
+			for _, fn := range pkg.Functions {
+				fn.Params = deps(fn.Params)
+				fn.Results = deps(fn.Results)
+				fn.FunctionCalls = deps(fn.FunctionCalls)
+				fn.MethodCalls = deps(fn.MethodCalls)
+				fn.Types = deps(fn.Types)
+				fn.GlobalVars = deps(fn.GlobalVars)
+			}
+			for _, t := range pkg.Types {
+				t.SubStruct = deps(t.SubStruct)
+				t.InlineStruct = deps(t.InlineStruct)
+				t.Implements = ids(t.Implements)
+				for name, m := range t.Methods {
+					if removed[m] {
+						delete(t.Methods, name)
+					}
+				}
+			}
+			for _, v := range pkg.Vars {
+				v.Dependencies = deps(v.Dependencies)
+				v.Groups = ids(v.Groups)
+				if v.Type != nil && removed[*v.Type] {
+					v.Type = nil
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,53 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package uniast
+
+import "sort"
+
+// Implementation is a row of the implements matrix: an interface with the types implementing it
+type Implementation struct {
+	Interface Identity
+	Types     []Identity
+}
+
+// GetImplementations returns the types implementing the interface sorted by ids.
+// The interface can be external (like io.Reader for go) as long as the parser records it in Type.Implements
+func (r *Repository) GetImplementations(iface Identity) []Identity {
+	return r.nodeIndex().impls[iface]
+}
+
+// GetImplementedInterfaces returns the interfaces implemented by the type sorted by ids, nil if the type is unknown
+func (r *Repository) GetImplementedInterfaces(typ Identity) []Identity {
+	t := r.GetType(typ)
+	if t == nil || len(t.Implements) == 0 {
+		return nil
+	}
+	ret := append([]Identity(nil), t.Implements...)
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Full() < ret[j].Full() })
+	return ret
+}
+
+// ImplementsMatrix returns all the implements relations of the repository grouped by interfaces, sorted by ids
+func (r *Repository) ImplementsMatrix() []Implementation {
+	impls := r.nodeIndex().impls
+	ret := make([]Implementation, 0, len(impls))
+	for iface, types := range impls {
+		ret = append(ret, Implementation{Interface: iface, Types: types})
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Interface.Full() < ret[j].Interface.Full() })
+	return ret
+}
@@ -0,0 +1,132 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uniast
+
+import (
+	"sort"
+	"sync"
+)
+
+var (
+	// graphMu serializes the lazy constructions of the graphs, see EnsureGraph
+	graphMu sync.Mutex
+	// indexMu guards Repository.index and Repository.tests, which are built lazily by concurrent readers like the MCP tools
+	indexMu sync.Mutex
+)
+
+// nodeIndex memoizes the lookups of the nodes in the graph by identity, by file and by package.
+// It is built on the first lookup and dropped by the mutations of the repository, see invalidateIndex
+type nodeIndex struct {
+	// size is len(Graph) when built, thus a replaced graph is detected
+	size  int
+	nodes map[Identity]*Node
+	// file => nodes defined in the file, sorted by line
+	files map[string][]*Node
+	// mod?pkg => nodes defined in the package, sorted by id
+	pkgs map[string][]*Node
+	// interface => types implementing it, sorted by id, see GetImplementations
+	impls map[Identity][]Identity
+}
+
+// EnsureGraph builds the graph if it is not built yet, e.g. the AST is written without the graph.
+// Unlike BuildGraph, it can be called by concurrent readers of the repository
+func (r *Repository) EnsureGraph() {
+	graphMu.Lock()
+	defer graphMu.Unlock()
+	if len(r.Graph) == 0 {
+		r.BuildGraph()
+	}
+}
+
+// invalidateIndex drops the memoized lookups, called when nodes are added or moved
+func (r *Repository) invalidateIndex() {
+	indexMu.Lock()
+	r.index = nil
+	r.tests = nil
+	indexMu.Unlock()
+}
+
+func (r *Repository) nodeIndex() *nodeIndex {
+	r.EnsureGraph()
+	indexMu.Lock()
+	defer indexMu.Unlock()
+	if r.index != nil && r.index.size == len(r.Graph) {
+		return r.index
+	}
+	idx := &nodeIndex{
+		size:  len(r.Graph),
+		nodes: make(map[Identity]*Node, len(r.Graph)),
+		files: map[string][]*Node{},
+		pkgs:  map[string][]*Node{},
+		impls: map[Identity][]Identity{},
+	}
+	for _, n := range r.Graph {
+		idx.nodes[n.Identity] = n
+	}
+	add := func(id Identity, file string) {
+		n := idx.nodes[id]
+		if n == nil {
+			return
+		}
+		key := string(id.ModPath) + "?" + string(id.PkgPath)
+		idx.pkgs[key] = append(idx.pkgs[key], n)
+		if file != "" {
+			idx.files[file] = append(idx.files[file], n)
+		}
+	}
+	for _, mod := range r.Modules {
+		if mod.IsExternal() {
+			continue
+		}
+		for _, pkg := range mod.Packages {
+			for _, f := range pkg.Functions {
+				add(f.Identity, f.File)
+			}
+			for _, t := range pkg.Types {
+				add(t.Identity, t.File)
+				for _, iface := range t.Implements {
+					idx.impls[iface] = Append(idx.impls[iface], t.Identity)
+				}
+			}
+			for _, v := range pkg.Vars {
+				add(v.Identity, v.File)
+			}
+		}
+	}
+	for _, nodes := range idx.files {
+		sort.Slice(nodes, func(i, j int) bool {
+			a, b := nodes[i].FileLine().Line, nodes[j].FileLine().Line
+			if a != b {
+				return a < b
+			}
+			return nodes[i].Identity.Full() < nodes[j].Identity.Full()
+		})
+	}
+	for _, ids := range idx.impls {
+		sort.Slice(ids, func(i, j int) bool { return ids[i].Full() < ids[j].Full() })
+	}
+	for _, nodes := range idx.pkgs {
+		sort.Slice(nodes, func(i, j int) bool {
+			return nodes[i].Identity.Full() < nodes[j].Identity.Full()
+		})
+	}
+	r.index = idx
+	return idx
+}
+
+// GetPackageNodes returns the nodes defined in the package sorted by id, nil if the package is unknown or external
+func (r *Repository) GetPackageNodes(mod ModPath, pkg PkgPath) []*Node {
+	return r.nodeIndex().pkgs[string(mod)+"?"+string(pkg)]
+}
@@ -0,0 +1,255 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package uniast
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+)
+
+// LintRule is an invariant of a well-formed AST, see Repository.Lint
+type LintRule string
+
+const (
+	// LintDanglingDependency: a dependency on a node missing in the repository, while its module is internal
+	LintDanglingDependency LintRule = "dangling-dependency"
+	// LintEmptyFileLine: an internal node without the file or the line
+	LintEmptyFileLine LintRule = "empty-fileline"
+	// LintOrphanPackage: a package, node or file not belonging to the module or package holding it
+	LintOrphanPackage LintRule = "orphan-package"
+	// LintDuplicateIdentity: an identity declared by several nodes, or differing from its key
+	LintDuplicateIdentity LintRule = "duplicate-identity"
+	// LintOffsetOutOfRange: a node spanning beyond its file, or with a reversed range
+	LintOffsetOutOfRange LintRule = "offset-out-of-range"
+)
+
+// LintRules are all the rules checked by Repository.Lint
+var LintRules = []LintRule{LintDanglingDependency, LintEmptyFileLine, LintOrphanPackage, LintDuplicateIdentity, LintOffsetOutOfRange}
+
+// LintIssue is a violation of a LintRule
+type LintIssue struct {
+	Rule    LintRule
+	Node    *Identity `json:",omitempty"` // the node violating the rule, nil for packages and files
+	File    string    `json:",omitempty"`
+	Message string
+}
+
+// LintOptions are the options of Repository.Lint
+type LintOptions struct {
+	// Rules to check, all LintRules if empty
+	Rules []LintRule
+	// SourceDir is where the files are read to check the offsets, Repository.Path if empty.
+	// The offsets of the missing files are only checked against each other
+	SourceDir string
+}
+
+// Lint checks the invariants of the internal modules, which are broken by the regressions of the parsers.
+// The issues are sorted by rules, files and nodes
+func (r *Repository) Lint(o LintOptions) ([]LintIssue, error) {
+	for _, rule := range o.Rules {
+		if !slices.Contains(LintRules, rule) {
+			return nil, fmt.Errorf("unknown lint rule %q, must be one of %v", rule, LintRules)
+		}
+	}
+	l := &linter{
+		repo:  r,
+		rules: o.Rules,
+		dir:   o.SourceDir,
+		files: map[string]*fileSize{},
+	}
+	if l.dir == "" {
+		l.dir = r.Path
+	}
+	for _, mod := range r.InternalModules() {
+		l.lintModule(mod)
+	}
+	sort.SliceStable(l.issues, func(i, j int) bool {
+		a, b := l.issues[i], l.issues[j]
+		if a.Rule != b.Rule {
+			return a.Rule < b.Rule
+		}
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if (a.Node == nil) != (b.Node == nil) {
+			return a.Node == nil
+		}
+		return a.Node != nil && a.Node.Full() < b.Node.Full()
+	})
+	return l.issues, nil
+}
+
+type linter struct {
+	repo   *Repository
+	rules  []LintRule
+	dir    string
+	files  map[string]*fileSize // nil if the file is missing
+	issues []LintIssue
+}
+
+type fileSize struct {
+	bytes int
+	lines int
+}
+
+func (l *linter) enabled(rule LintRule) bool {
+	return len(l.rules) == 0 || slices.Contains(l.rules, rule)
+}
+
+func (l *linter) report(rule LintRule, id *Identity, file string, format string, args ...any) {
+	if !l.enabled(rule) {
+		return
+	}
+	if id != nil {
+		c := *id
+		id = &c
+	}
+	l.issues = append(l.issues, LintIssue{Rule: rule, Node: id, File: file, Message: fmt.Sprintf(format, args...)})
+}
+
+func (l *linter) lintModule(mod *Module) {
+	for key, pkg := range mod.Packages {
+		if pkg.PkgPath != key {
+			l.report(LintOrphanPackage, nil, "", "package %q of module %s is keyed by %q", pkg.PkgPath, mod.Name, key)
+		}
+		declared := map[string]NodeType{}
+		node := func(id Identity, key string, typ NodeType, fl FileLine) {
+			if id.ModPath != mod.Name || id.PkgPath != pkg.PkgPath {
+				l.report(LintOrphanPackage, &id, fl.File, "node is held by package %s of module %s", pkg.PkgPath, mod.Name)
+			}
+			if id.Name != key {
+				l.report(LintDuplicateIdentity, &id, fl.File, "node is keyed by %q", key)
+			}
+			if prev, ok := declared[id.Name]; ok {
+				l.report(LintDuplicateIdentity, &id, fl.File, "identity is declared as both %s and %s", prev, typ)
+			}
+			declared[id.Name] = typ
+			l.lintFileLine(id, fl)
+		}
+		for key, fn := range pkg.Functions {
+			node(fn.Identity, key, FUNC, fn.FileLine)
+			l.lintDeps(fn.Identity, fn.FileLine.File, fn.Params, fn.Results, fn.FunctionCalls, fn.MethodCalls, fn.Types, fn.GlobalVars)
+			if fn.Receiver != nil {
+				l.lintRef(fn.Identity, fn.File, fn.Receiver.Type)
+			}
+			if fn.ParentFunction != nil {
+				l.lintRef(fn.Identity, fn.File, *fn.ParentFunction)
+			}
+		}
+		for key, t := range pkg.Types {
+			node(t.Identity, key, TYPE, t.FileLine)
+			l.lintDeps(t.Identity, t.File, t.SubStruct, t.InlineStruct)
+			for _, id := range t.Implements {
+				l.lintRef(t.Identity, t.File, id)
+			}
+			for _, id := range t.Methods {
+				l.lintRef(t.Identity, t.File, id)
+			}
+		}
+		for key, v := range pkg.Vars {
+			node(v.Identity, key, VAR, v.FileLine)
+			l.lintDeps(v.Identity, v.File, v.Dependencies)
+			if v.Type != nil {
+				l.lintRef(v.Identity, v.File, *v.Type)
+			}
+			for _, id := range v.Groups {
+				l.lintRef(v.Identity, v.File, id)
+			}
+		}
+	}
+	for path, f := range mod.Files {
+		if f.Package != "" && mod.Packages[f.Package] == nil {
+			l.report(LintOrphanPackage, nil, path, "package %s of the file is missing in module %s", f.Package, mod.Name)
+		}
+	}
+}
+
+func (l *linter) lintDeps(from Identity, file string, deps ...[]Dependency) {
+	for _, ds := range deps {
+		for _, d := range ds {
+			// the modules imported dynamically have no names
+			if d.Name != "" {
+				l.lintRef(from, file, d.Identity)
+			}
+		}
+	}
+}
+
+// lintRef checks the node referred by from exists, unless its module is external
+func (l *linter) lintRef(from Identity, file string, to Identity) {
+	if !l.enabled(LintDanglingDependency) {
+		return
+	}
+	mod := l.repo.Modules[to.ModPath]
+	if mod == nil || mod.IsExternal() {
+		return
+	}
+	if l.repo.GetFunction(to) == nil && l.repo.GetType(to) == nil && l.repo.GetVar(to) == nil {
+		l.report(LintDanglingDependency, &from, file, "dependency %s is missing", to.Full())
+	}
+}
+
+func (l *linter) lintFileLine(id Identity, fl FileLine) {
+	if fl.File == "" || fl.Line <= 0 {
+		l.report(LintEmptyFileLine, &id, fl.File, "node has no file or line")
+	}
+	if !l.enabled(LintOffsetOutOfRange) {
+		return
+	}
+	if fl.StartOffset < 0 || fl.EndOffset < fl.StartOffset {
+		l.report(LintOffsetOutOfRange, &id, fl.File, "offsets [%d, %d) are reversed", fl.StartOffset, fl.EndOffset)
+	}
+	if fl.EndLine != 0 && fl.EndLine < fl.Line {
+		l.report(LintOffsetOutOfRange, &id, fl.File, "lines [%d, %d] are reversed", fl.Line, fl.EndLine)
+	}
+	size := l.fileSize(fl.File)
+	if size == nil {
+		return
+	}
+	if fl.EndOffset > size.bytes {
+		l.report(LintOffsetOutOfRange, &id, fl.File, "end offset %d exceeds the file length %d", fl.EndOffset, size.bytes)
+	}
+	if max(fl.Line, fl.EndLine) > size.lines {
+		l.report(LintOffsetOutOfRange, &id, fl.File, "line %d exceeds the file lines %d", max(fl.Line, fl.EndLine), size.lines)
+	}
+}
+
+func (l *linter) fileSize(file string) *fileSize {
+	if file == "" {
+		return nil
+	}
+	if size, ok := l.files[file]; ok {
+		return size
+	}
+	path := file
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(l.dir, file)
+	}
+	var size *fileSize
+	if bs, err := os.ReadFile(path); err == nil {
+		size = &fileSize{bytes: len(bs), lines: bytes.Count(bs, []byte("\n"))}
+		if len(bs) > 0 && bs[len(bs)-1] != '\n' {
+			size.lines++
+		}
+	}
+	l.files[file] = size
+	return size
+}
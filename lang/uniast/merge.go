@@ -0,0 +1,224 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package uniast
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// MergeConflict decides which node is kept if both repos define the same identity differently
+type MergeConflict int
+
+const (
+	// MergeKeepDst keeps the node of dst, unless it is a stub without codes (like an external
+	// reference collected by a partial parse) while the one of src has
+	MergeKeepDst MergeConflict = iota
+	// MergeKeepSrc replaces the node of dst with the one of src
+	MergeKeepSrc
+	// MergeFail fails the merging
+	MergeFail
+)
+
+type MergeOptions struct {
+	// Conflict is the strategy for identity collisions
+	Conflict MergeConflict
+	// DisableBuildGraph skips rebuilding the graph of dst after merging
+	DisableBuildGraph bool
+}
+
+// Merge unions the modules, packages, files and nodes of src into dst, then rebuilds the graph of dst.
+//
+// Identity collisions are resolved regardless of the map order:
+//   - nodes with the same file, line and codes are the same;
+//   - an internal module replaces an external one of the same name, along with its nodes;
+//   - otherwise decided by MergeOptions.Conflict.
+//
+// src should not be used after merging, since its modules and nodes may be shared with dst.
+// If it fails (by MergeFail or modules of different languages), dst is left partially merged
+// and should be discarded.
+func Merge(dst, src *Repository, opts MergeOptions) error {
+	if dst.Name == "" {
+		dst.Name = src.Name
+	}
+	if dst.Path == "" {
+		dst.Path = src.Path
+	}
+	if dst.Modules == nil {
+		dst.Modules = map[string]*Module{}
+	}
+
+	var conflicts []string
+	for name, smod := range src.Modules {
+		dmod := dst.Modules[name]
+		if dmod == nil {
+			dst.Modules[name] = smod
+			continue
+		}
+		if !dmod.IsExternal() && !smod.IsExternal() && dmod.Language != smod.Language {
+			return fmt.Errorf("module %s is of both %s and %s", name, dmod.Language, smod.Language)
+		}
+		conflict := opts.Conflict
+		if dmod.IsExternal() && !smod.IsExternal() {
+			dmod.Dir, dmod.Language, dmod.Version = smod.Dir, smod.Language, smod.Version
+			conflict = MergeKeepSrc
+		} else if !dmod.IsExternal() && smod.IsExternal() && conflict == MergeKeepSrc {
+			conflict = MergeKeepDst
+		}
+		conflicts = append(conflicts, mergeModuleInto(dmod, smod, conflict)...)
+	}
+	if len(conflicts) > 0 {
+		sort.Strings(conflicts)
+		return fmt.Errorf("%d nodes conflict: %s", len(conflicts), strings.Join(topN(conflicts, 10), ", "))
+	}
+
+	if opts.DisableBuildGraph {
+		dst.Graph = nil
+		return nil
+	}
+	return dst.BuildGraph()
+}
+
+// mergeModuleInto merges src into dst, returns the conflicting nodes if the conflict is MergeFail
+func mergeModuleInto(dst, src *Module, conflict MergeConflict) (conflicts []string) {
+	MergeModule(dst, src, func(d *MergedNode, s MergedNode) bool {
+		switch {
+		case d == nil, s.Content == "":
+			// added, or src is a stub
+		case d.Content == "":
+			return true
+		case d.Content == s.Content && d.File == s.File && d.Line == s.Line:
+			// the same node
+		case conflict == MergeKeepSrc:
+			return true
+		case conflict == MergeFail:
+			conflicts = append(conflicts, d.Identity.Full())
+		}
+		return false
+	})
+	return conflicts
+}
+
+// MergedNode is a function, type or var being merged, see NodeMerger
+type MergedNode struct {
+	Identity
+	FileLine
+	Content string
+	// Node is the *Function, *Type or *Var
+	Node any
+}
+
+// NodeMerger resolves a node of src against the one of the same identity in dst, see MergeModule.
+// dst is nil if the node is only in src, which is added anyway; otherwise src replaces dst if it returns true
+type NodeMerger func(dst *MergedNode, src MergedNode) bool
+
+// MergeModule unions the dependencies, load errors, files and packages of src into dst,
+// and resolves the nodes defined in both by merge.
+// A file only compiled in src (see File.Package) replaces the one of dst, with the variants of both
+func MergeModule(dst, src *Module, merge NodeMerger) {
+	if dst.Version == "" {
+		dst.Version = src.Version
+	}
+	for k, v := range src.Dependencies {
+		if dst.Dependencies == nil {
+			dst.Dependencies = map[string]string{}
+		}
+		if _, ok := dst.Dependencies[k]; !ok {
+			dst.Dependencies[k] = v
+		}
+	}
+	for _, e := range src.LoadErrors {
+		if !slices.Contains(dst.LoadErrors, e) {
+			dst.LoadErrors = append(dst.LoadErrors, e)
+		}
+	}
+
+	for path, sf := range src.Files {
+		if dst.Files == nil {
+			dst.Files = map[string]*File{}
+		}
+		df := dst.Files[path]
+		if df == nil {
+			dst.Files[path] = sf
+			continue
+		}
+		if df.Package == "" && sf.Package != "" {
+			// not compiled in dst
+			df, sf = sf, df
+			dst.Files[path] = df
+		}
+		for _, d := range sf.Diagnostics {
+			if !slices.Contains(df.Diagnostics, d) {
+				df.Diagnostics = append(df.Diagnostics, d)
+			}
+		}
+		for _, v := range sf.Variants {
+			if !slices.Contains(df.Variants, v) {
+				df.Variants = append(df.Variants, v)
+			}
+		}
+	}
+
+	if dst.Packages == nil {
+		dst.Packages = map[PkgPath]*Package{}
+	}
+	for path, spkg := range src.Packages {
+		dpkg := dst.Packages[path]
+		if dpkg == nil {
+			dst.Packages[path] = spkg
+			continue
+		}
+		dpkg.IsMain = dpkg.IsMain || spkg.IsMain
+		dpkg.IsTest = dpkg.IsTest || spkg.IsTest
+		// the maps of the packages decoded from `null` are nil
+		if dpkg.Functions == nil {
+			dpkg.Functions = map[string]*Function{}
+		}
+		if dpkg.Types == nil {
+			dpkg.Types = map[string]*Type{}
+		}
+		if dpkg.Vars == nil {
+			dpkg.Vars = map[string]*Var{}
+		}
+		mergeNodes(dpkg.Functions, spkg.Functions, merge, func(f *Function) MergedNode {
+			return MergedNode{Identity: f.Identity, FileLine: f.FileLine, Content: f.Content, Node: f}
+		})
+		mergeNodes(dpkg.Types, spkg.Types, merge, func(t *Type) MergedNode {
+			return MergedNode{Identity: t.Identity, FileLine: t.FileLine, Content: t.Content, Node: t}
+		})
+		mergeNodes(dpkg.Vars, spkg.Vars, merge, func(v *Var) MergedNode {
+			return MergedNode{Identity: v.Identity, FileLine: v.FileLine, Content: v.Content, Node: v}
+		})
+	}
+}
+
+func mergeNodes[T any](dst, src map[string]*T, merge NodeMerger, info func(*T) MergedNode) {
+	for name, s := range src {
+		d := dst[name]
+		if d == nil {
+			dst[name] = s
+			merge(nil, info(s))
+			continue
+		}
+		dn := info(d)
+		if merge(&dn, info(s)) {
+			dst[name] = s
+		}
+	}
+}
@@ -0,0 +1,187 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package uniast
+
+import "strings"
+
+// Metrics are the measures of a function, to tell the candidates of refactoring
+type Metrics struct {
+	// LOC is the lines of the content
+	LOC int
+	// Complexity is the rough cyclomatic complexity, 1 plus the branch tokens like `if`, `case` and `&&`
+	Complexity int
+	// FanIn is the number of distinct functions calling it, only known with the graph (see BuildGraph)
+	FanIn int `json:",omitempty"`
+	// FanOut is the number of distinct functions and methods it calls
+	FanOut int `json:",omitempty"`
+}
+
+// branchKeywords are the keywords which add a path to the control flow of the language
+var branchKeywords = map[Language]map[string]bool{
+	Golang:     {"if": true, "for": true, "case": true},
+	Rust:       {"if": true, "for": true, "while": true, "loop": true},
+	Python:     {"if": true, "elif": true, "for": true, "while": true, "except": true, "case": true, "and": true, "or": true},
+	TypeScript: {"if": true, "for": true, "while": true, "case": true, "catch": true},
+	Java:       {"if": true, "for": true, "while": true, "case": true, "catch": true},
+	Kotlin:     {"if": true, "for": true, "while": true, "catch": true},
+	Cpp:        {"if": true, "for": true, "while": true, "case": true, "catch": true},
+	Cxx:        {"if": true, "for": true, "while": true, "case": true},
+	Scala:      {"if": true, "for": true, "while": true, "case": true},
+	PHP:        {"if": true, "elseif": true, "for": true, "foreach": true, "while": true, "case": true, "catch": true},
+	Ruby:       {"if": true, "elsif": true, "unless": true, "while": true, "until": true, "for": true, "when": true, "rescue": true, "and": true, "or": true},
+}
+
+// ComputeMetrics fills the metrics of the functions of the internal modules.
+// FanIn is counted by the graph if it is built, thus call it after BuildGraph
+func (r *Repository) ComputeMetrics() {
+	for _, mod := range r.InternalModules() {
+		for _, pkg := range mod.Packages {
+			for _, fn := range pkg.Functions {
+				m := &Metrics{
+					LOC:        countLines(fn.Content),
+					Complexity: Complexity(mod.Language, fn.Content),
+					FanOut:     countCallees(fn),
+				}
+				if node := r.Graph[fn.Identity.Full()]; node != nil {
+					m.FanIn = r.countCallers(node)
+				}
+				fn.Metrics = m
+			}
+		}
+	}
+}
+
+func countCallees(fn *Function) int {
+	seen := make(map[Identity]bool, len(fn.FunctionCalls)+len(fn.MethodCalls))
+	for _, dep := range fn.FunctionCalls {
+		seen[dep.Identity] = true
+	}
+	for _, dep := range fn.MethodCalls {
+		seen[dep.Identity] = true
+	}
+	return len(seen)
+}
+
+// countCallers counts the distinct functions referencing the node
+func (r *Repository) countCallers(node *Node) int {
+	seen := make(map[Identity]bool, len(node.References))
+	for _, ref := range node.References {
+		if n := r.Graph[ref.Identity.Full()]; n != nil && n.Type == FUNC {
+			seen[ref.Identity] = true
+		}
+	}
+	return len(seen)
+}
+
+// Complexity returns the rough cyclomatic complexity of the codes, 1 plus the branch keywords and the logical operators.
+// Comments and string literals are skipped, ternary operators are counted for the C-like languages,
+// and the arms of match for Rust
+func Complexity(lang Language, content string) int {
+	if content == "" {
+		return 0
+	}
+	keywords := branchKeywords[lang]
+	if keywords == nil {
+		keywords = branchKeywords[Java]
+	}
+	lineComment, quotes := "//", `"'`+"`"
+	switch lang {
+	case Python, Ruby:
+		lineComment, quotes = "#", `"'`
+	case Rust:
+		// 'a may be a lifetime
+		quotes = `"`
+	}
+	// `?` ends the predicate methods of ruby, like `empty?`
+	ternary := lang != Golang && lang != Rust && lang != Python && lang != Scala && lang != Ruby
+
+	ret := 1
+	for i := 0; i < len(content); i++ {
+		c := content[i]
+		switch {
+		case strings.HasPrefix(content[i:], lineComment):
+			if end := strings.IndexByte(content[i:], '\n'); end >= 0 {
+				i += end
+			} else {
+				i = len(content)
+			}
+		case lang != Python && lang != Ruby && strings.HasPrefix(content[i:], "/*"):
+			if end := strings.Index(content[i+2:], "*/"); end >= 0 {
+				i += end + 3
+			} else {
+				i = len(content)
+			}
+		case strings.IndexByte(quotes, c) >= 0:
+			i = skipQuoted(content, i)
+		case c == '=' && lang == Rust:
+			// arms of match
+			if i+1 < len(content) && content[i+1] == '>' {
+				ret++
+				i++
+			}
+		case c == '&' || c == '|':
+			if i+1 < len(content) && content[i+1] == c {
+				ret++
+				i++
+			}
+		case c == '?' && ternary:
+			// not `?.`, `??` nor `?:`
+			if i+1 < len(content) && content[i+1] != '.' && content[i+1] != '?' && content[i+1] != ':' {
+				ret++
+			} else if i+1 < len(content) && content[i+1] == '?' {
+				ret++
+				i++
+			}
+		case isIdentStart(c):
+			end := i + 1
+			for end < len(content) && (isIdentStart(content[end]) || content[end] >= '0' && content[end] <= '9') {
+				end++
+			}
+			if (i == 0 || !isIdentByte(content[i-1])) && keywords[content[i:end]] {
+				ret++
+			}
+			i = end - 1
+		}
+	}
+	return ret
+}
+
+// skipQuoted returns the index of the closing quote of the literal starting at i, or the end of the content
+func skipQuoted(content string, i int) int {
+	q := content[i]
+	for j := i + 1; j < len(content); j++ {
+		switch content[j] {
+		case '\\':
+			j++
+		case q:
+			return j
+		case '\n':
+			if q != '`' && q != '"' {
+				return j
+			}
+		}
+	}
+	return len(content)
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isIdentByte(c byte) bool {
+	return isIdentStart(c) || c >= '0' && c <= '9' || c == '.' || c == '$'
+}
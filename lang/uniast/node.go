@@ -20,16 +20,14 @@ import (
 	"strings"
 )
 
+// GetNode returns the node of the id in the graph, which is built if not yet.
+// The lookups are memoized by identity, see nodeIndex
 func (r *Repository) GetNode(id Identity) *Node {
-	key := id.Full()
-	if r.Graph == nil || len(r.Graph) == 0 {
-		r.BuildGraph()
-	}
-	node, ok := r.Graph[key]
-	if !ok {
-		return nil
+	if node := r.nodeIndex().nodes[id]; node != nil {
+		return node
 	}
-	return node
+	// the nodes added to the graph directly are not indexed
+	return r.Graph[id.Full()]
 }
 
 func (r *Repository) GetPackage(mod ModPath, pkg PkgPath) *Package {
@@ -59,6 +57,7 @@ func (r *Repository) SetNode(id Identity, typ NodeType) *Node {
 			Repo:     r,
 		}
 		r.Graph[key] = node
+		r.invalidateIndex()
 	}
 	node.Repo = r
 	switch typ {
@@ -130,6 +129,7 @@ func (r *Repository) AddRelation(node *Node, dep Identity, depFl FileLine, kinds
 			Repo:     r,
 		}
 		r.Graph[key] = nd
+		r.invalidateIndex()
 	}
 	for _, kind := range kinds {
 		if kind == DEPENDENCY {
@@ -171,6 +171,8 @@ func (r *Repository) BuildGraph() error {
 		}
 	}
 	r.Graph = make(map[string]*Node, totalNodes)
+	r.spans = nil
+	r.invalidateIndex()
 	for _, mod := range r.Modules {
 		if mod.IsExternal() {
 			continue
@@ -576,24 +578,47 @@ func (n Node) SetFileLine(file FileLine) {
 	}
 }
 
+// SetIsExported sets the exported flag of the node, and resets its visibility if they disagree
 func (n Node) SetIsExported(isExported bool) {
 	if n.Repo == nil {
 		return
 	}
 	switch n.Type {
+	case FUNC:
+		if f := n.Repo.GetFunction(n.Identity); f != nil && f.GetVisibility().IsExported() != isExported {
+			f.SetVisibility(Visibility("").Or(isExported))
+		}
+	case TYPE:
+		if f := n.Repo.GetType(n.Identity); f != nil && f.GetVisibility().IsExported() != isExported {
+			f.SetVisibility(Visibility("").Or(isExported))
+		}
+	case VAR:
+		if f := n.Repo.GetVar(n.Identity); f != nil && f.GetVisibility().IsExported() != isExported {
+			f.SetVisibility(Visibility("").Or(isExported))
+		}
+	}
+}
+
+// Visibility returns the visibility of the node, see Visibility.Or for the nodes without one
+func (n Node) Visibility() Visibility {
+	if n.Repo == nil {
+		return ""
+	}
+	switch n.Type {
 	case FUNC:
 		if f := n.Repo.GetFunction(n.Identity); f != nil {
-			f.Exported = isExported
+			return f.GetVisibility()
 		}
 	case TYPE:
 		if f := n.Repo.GetType(n.Identity); f != nil {
-			f.Exported = isExported
+			return f.GetVisibility()
 		}
 	case VAR:
 		if f := n.Repo.GetVar(n.Identity); f != nil {
-			f.IsExported = isExported
+			return f.GetVisibility()
 		}
 	}
+	return ""
 }
 
 func (n Node) IsExported() bool {
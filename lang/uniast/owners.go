@@ -0,0 +1,118 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uniast
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// MaxOwners is the max count of the primary authors recorded for a node
+const MaxOwners = 3
+
+// Owner is an author of the lines of a node
+type Owner struct {
+	Name  string
+	Email string `json:",omitempty"`
+	// Lines is the count of the lines last modified by the author
+	Lines int
+}
+
+// Ownership tells who wrote a node, see AnnotateOwners
+type Ownership struct {
+	// Authors are the primary authors, who last modified the most lines of the node, at most MaxOwners
+	Authors []Owner
+	// LastModified is the latest author time of the lines
+	LastModified time.Time
+}
+
+// BlameLine is the last modification of a line, as reported by `git blame`
+type BlameLine struct {
+	Name  string
+	Email string
+	Time  time.Time
+}
+
+// AnnotateOwners fills the ownership of the nodes of the internal modules by the blame of their lines.
+// blame returns the modifications of the lines (the first is line 1) of a file relative to the repo,
+// where uncommitted lines are zero BlameLine. Files which fail to blame are skipped.
+func (r *Repository) AnnotateOwners(blame func(file string) ([]BlameLine, error)) {
+	files := map[string][]BlameLine{}
+	ownership := func(fl FileLine, content string) *Ownership {
+		if fl.File == "" || fl.Line <= 0 {
+			return nil
+		}
+		lines, ok := files[fl.File]
+		if !ok {
+			lines, _ = blame(fl.File)
+			files[fl.File] = lines
+		}
+		end := fl.EndLine
+		if end < fl.Line {
+			end = fl.Line + strings.Count(content, "\n")
+		}
+		return newOwnership(lines, fl.Line, end)
+	}
+	for _, mod := range r.InternalModules() {
+		for _, pkg := range mod.Packages {
+			for _, fn := range pkg.Functions {
+				fn.Owners = ownership(fn.FileLine, fn.Content)
+			}
+			for _, t := range pkg.Types {
+				t.Owners = ownership(t.FileLine, t.Content)
+			}
+			for _, v := range pkg.Vars {
+				v.Owners = ownership(v.FileLine, v.Content)
+			}
+		}
+	}
+}
+
+// newOwnership sums the blame of the lines [start, end], nil if none of them is committed
+func newOwnership(lines []BlameLine, start, end int) *Ownership {
+	ret := &Ownership{}
+	index := map[string]int{}
+	for i := start; i <= end && i <= len(lines); i++ {
+		l := lines[i-1]
+		if l.Name == "" && l.Email == "" {
+			continue
+		}
+		key := l.Email
+		if key == "" {
+			key = l.Name
+		}
+		idx, ok := index[key]
+		if !ok {
+			idx = len(ret.Authors)
+			index[key] = idx
+			ret.Authors = append(ret.Authors, Owner{Name: l.Name, Email: l.Email})
+		}
+		ret.Authors[idx].Lines++
+		if l.Time.After(ret.LastModified) {
+			ret.LastModified = l.Time
+		}
+	}
+	if len(ret.Authors) == 0 {
+		return nil
+	}
+	sort.SliceStable(ret.Authors, func(i, j int) bool {
+		return ret.Authors[i].Lines > ret.Authors[j].Lines
+	})
+	if len(ret.Authors) > MaxOwners {
+		ret.Authors = ret.Authors[:MaxOwners]
+	}
+	return ret
+}
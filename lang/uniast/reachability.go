@@ -0,0 +1,164 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uniast
+
+import (
+	"sort"
+	"strings"
+)
+
+// Entrypoints returns the roots of the reachability analysis in the internal modules:
+// the `main` functions, the tests, and the exported functions, types and vars if api is true,
+// which are the public API of a library. See UnreachableNodes
+func (r *Repository) Entrypoints(api bool) []Identity {
+	var ret []Identity
+	for _, mod := range r.InternalModules() {
+		for _, pkg := range mod.Packages {
+			for _, fn := range pkg.Functions {
+				if fn.IsTest || (!fn.IsMethod && fn.Name == "main") || (api && fn.Exported) {
+					ret = append(ret, fn.Identity)
+				}
+			}
+			if !api {
+				continue
+			}
+			for _, t := range pkg.Types {
+				if t.Exported {
+					ret = append(ret, t.Identity)
+				}
+			}
+			for _, v := range pkg.Vars {
+				if v.IsExported {
+					ret = append(ret, v.Identity)
+				}
+			}
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Full() < ret[j].Full() })
+	return ret
+}
+
+// isInitFunc tells if the function runs on initialization, like `init` of go, which may be suffixed for duplicates
+func isInitFunc(fn *Function) bool {
+	return !fn.IsMethod && (fn.Name == "init" || strings.HasPrefix(fn.Name, "init_"))
+}
+
+// UnreachableNodes returns the internal nodes which are never reached from the entrypoints (see Entrypoints),
+// walking the dependencies and the embedded types in the graph. The init functions are always reached.
+// Interface implementations are accounted for conservatively:
+//   - reaching an interface method reaches the methods of the same name of all types implementing the interface;
+//   - reaching a type which implements an external interface reaches all its exported methods,
+//     since they may be called through the interface by the external codes.
+//
+// The test variants of go packages are skipped, since they duplicate the product nodes.
+// The nodes are sorted by their identities.
+func (r *Repository) UnreachableNodes(entrypoints []Identity) []Identity {
+	r.EnsureGraph()
+	internal := func(id Identity) bool {
+		mod := r.Modules[id.ModPath]
+		return mod != nil && !mod.IsExternal()
+	}
+
+	// interface => the types implementing it
+	implementors := map[Identity][]*Type{}
+	var roots []Identity
+	roots = append(roots, entrypoints...)
+	for _, mod := range r.InternalModules() {
+		for _, pkg := range mod.Packages {
+			for _, fn := range pkg.Functions {
+				if isInitFunc(fn) {
+					roots = append(roots, fn.Identity)
+				}
+			}
+			for _, t := range pkg.Types {
+				for _, iface := range t.Implements {
+					implementors[iface] = append(implementors[iface], t)
+				}
+			}
+		}
+	}
+
+	reached := map[Identity]bool{}
+	queue := roots
+	reach := func(id Identity) {
+		if !reached[id] {
+			reached[id] = true
+			queue = append(queue, id)
+		}
+	}
+	for _, id := range roots {
+		reached[id] = true
+	}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if node := r.Graph[id.Full()]; node != nil {
+			for _, rel := range node.Dependencies {
+				reach(rel.Identity)
+			}
+			for _, rel := range node.Inherits {
+				reach(rel.Identity)
+			}
+		}
+
+		if fn := r.GetFunction(id); fn != nil && fn.Receiver != nil {
+			// an interface method reaches the implementations
+			method := id.Name[strings.LastIndexByte(id.Name, '.')+1:]
+			for _, t := range implementors[fn.Receiver.Type] {
+				if m, ok := t.Methods[method]; ok {
+					reach(m)
+				}
+			}
+		}
+		if t := r.GetType(id); t != nil {
+			for _, iface := range t.Implements {
+				if internal(iface) && r.GetType(iface) != nil {
+					continue
+				}
+				for _, m := range t.Methods {
+					if fn := r.GetFunction(m); fn != nil && fn.Exported {
+						reach(m)
+					}
+				}
+			}
+		}
+	}
+
+	var ret []Identity
+	for _, mod := range r.InternalModules() {
+		for path, pkg := range mod.Packages {
+			if isTestVariant(path) {
+				continue
+			}
+			for _, fn := range pkg.Functions {
+				if !reached[fn.Identity] {
+					ret = append(ret, fn.Identity)
+				}
+			}
+			for _, t := range pkg.Types {
+				if !reached[t.Identity] {
+					ret = append(ret, t.Identity)
+				}
+			}
+			for _, v := range pkg.Vars {
+				if !reached[v.Identity] {
+					ret = append(ret, v.Identity)
+				}
+			}
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Full() < ret[j].Full() })
+	return ret
+}
@@ -0,0 +1,152 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package uniast
+
+import (
+	"sort"
+	"strings"
+)
+
+// ReferenceKind tells how a node refers to the target node
+type ReferenceKind string
+
+const (
+	// the node depends on the target itself
+	ReferenceDirect ReferenceKind = "direct"
+	// the node depends on a typedef of the target type, like `type Alias = Target` or `type Named Target`
+	ReferenceTypedef ReferenceKind = "typedef"
+	// the node depends on an interface method which the target method implements, like calls through the interface
+	ReferenceInterface ReferenceKind = "interface"
+)
+
+// Reference is a node referring to the target node
+type Reference struct {
+	// the referencing node
+	Node Identity
+	Kind ReferenceKind
+	// the typedef or interface method through which the node refers to the target, nil for direct references
+	Via *Identity `json:",omitempty"`
+	// location of the referencing token
+	File string
+	Line int
+}
+
+// ReferencesOf returns the nodes referring to the node, directly or indirectly through typedefs and interface methods.
+// It relies on the References edges of the graph, thus BuildGraph must have been called.
+// The references are sorted by the referencing nodes
+func (r *Repository) ReferencesOf(id Identity) []Reference {
+	type refKey struct {
+		node, via Identity
+		line      int
+	}
+	var ret []Reference
+	seen := map[refKey]bool{}
+	add := func(target Identity, kind ReferenceKind, via *Identity) {
+		node := r.Graph[target.Full()]
+		if node == nil {
+			return
+		}
+		for _, rel := range node.References {
+			ref := Reference{Node: rel.Identity, Kind: kind, Via: via}
+			if n := r.Graph[rel.Identity.Full()]; n != nil {
+				fl := n.FileLine()
+				ref.File, ref.Line = fl.File, fl.Line+rel.Line
+			}
+			key := refKey{node: ref.Node, line: ref.Line}
+			if via != nil {
+				key.via = *via
+			}
+			if !seen[key] {
+				seen[key] = true
+				ret = append(ret, ref)
+			}
+		}
+	}
+
+	add(id, ReferenceDirect, nil)
+
+	// typedefs of typedefs are followed as well
+	visited := map[Identity]bool{id: true}
+	for queue := []Identity{id}; len(queue) > 0; queue = queue[1:] {
+		for _, def := range r.typedefsOf(queue[0]) {
+			if visited[def] {
+				continue
+			}
+			visited[def] = true
+			via := def
+			add(def, ReferenceTypedef, &via)
+			queue = append(queue, def)
+		}
+	}
+
+	if fn := r.GetFunction(id); fn != nil && fn.IsMethod && !fn.IsInterfaceMethod && fn.Receiver != nil {
+		method := id.Name[strings.LastIndexByte(id.Name, '.')+1:]
+		if t := r.GetType(fn.Receiver.Type); t != nil {
+			for _, iface := range t.Implements {
+				it := r.GetType(iface)
+				if it == nil {
+					continue
+				}
+				if m, ok := it.Methods[method]; ok {
+					via := m
+					add(m, ReferenceInterface, &via)
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(ret, func(i, j int) bool {
+		if ret[i].Node != ret[j].Node {
+			return ret[i].Node.Full() < ret[j].Node.Full()
+		}
+		return ret[i].Line < ret[j].Line
+	})
+	return ret
+}
+
+// typedefsOf returns the typedefs defined by the type in the internal modules
+func (r *Repository) typedefsOf(id Identity) []Identity {
+	var ret []Identity
+	for _, mod := range r.InternalModules() {
+		for _, pkg := range mod.Packages {
+			for _, t := range pkg.Types {
+				if t.TypeKind != TypeKindTypedef {
+					continue
+				}
+				for _, deps := range [][]Dependency{t.SubStruct, t.InlineStruct} {
+					if hasDependency(deps, id) {
+						ret = append(ret, t.Identity)
+						break
+					}
+				}
+			}
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Full() < ret[j].Full()
+	})
+	return ret
+}
+
+func hasDependency(deps []Dependency, id Identity) bool {
+	for _, dep := range deps {
+		if dep.Identity == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,521 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uniast
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// RenameResult tells what RenameNode changed
+type RenameResult struct {
+	// Renamed maps the old identities to the new ones: the node, and the methods of a renamed type
+	Renamed map[Identity]Identity
+	// Nodes are the nodes whose codes are rewritten, by their new identities in order
+	Nodes []Identity
+	// Files are the files of the rewritten nodes in order
+	Files []string
+	// Unresolved are the references whose codes can't be located by the stored offsets, thus not rewritten
+	Unresolved []UnresolvedReference `json:",omitempty"`
+}
+
+// UnresolvedReference is a reference to the renamed node which RenameNode failed to rewrite
+type UnresolvedReference struct {
+	// Node is the referencing node
+	Node Identity
+	FileLine
+}
+
+// rename is a replacement in the codes of a node
+type rename struct {
+	// byte offset in the codes
+	offset   int
+	old, new string
+}
+
+// RenameNode renames the function, type or var of an internal module, and rewrites the references to it
+// in the codes of the dependent nodes by the offsets of the dependencies. The name in the declaration of the node
+// and its doc comments are rewritten as well. The new name is the last segment of the name, e.g. `Run` for
+// the method `Server.Start`, and the methods of a renamed type go with it.
+// The offsets of the nodes and dependencies after the rewritten codes in the same files are shifted,
+// and the graph is rebuilt if it has been built, thus the repo can be written back by the writers.
+func (r *Repository) RenameNode(id Identity, newName string) (*RenameResult, error) {
+	if !isIdentifier(newName) {
+		return nil, fmt.Errorf("invalid name %q", newName)
+	}
+	if mod := r.GetModule(id.ModPath); mod == nil || mod.IsExternal() {
+		return nil, fmt.Errorf("node %s is not in the internal modules", id.Full())
+	}
+	oldName := shortName(id.Name)
+	newID := id
+	newID.Name = strings.TrimSuffix(id.Name, oldName) + newName
+	if oldName == newName {
+		return nil, fmt.Errorf("node %s is already named %s", id.Full(), newName)
+	}
+	if r.GetFunction(newID) != nil || r.GetType(newID) != nil || r.GetVar(newID) != nil {
+		return nil, fmt.Errorf("node %s already exists", newID.Full())
+	}
+
+	ret := &RenameResult{Renamed: map[Identity]Identity{id: newID}}
+	renames := map[Identity][]rename{}
+	fn, typ, v := r.GetFunction(id), r.GetType(id), r.GetVar(id)
+	switch {
+	case fn != nil:
+		if off := declOffset(fn.Content, oldName, 0); off >= 0 {
+			renames[id] = append(docRenames(fn.Content, off, oldName, newName), rename{off, oldName, newName})
+		}
+		fn.Signature = replaceWord(fn.Signature, oldName, newName)
+	case typ != nil:
+		if off := declOffset(typ.Content, oldName, 0); off >= 0 {
+			renames[id] = append(docRenames(typ.Content, off, oldName, newName), rename{off, oldName, newName})
+		}
+		// the methods go with the type, and their receivers are rewritten
+		for _, m := range typ.Methods {
+			if !strings.HasPrefix(m.Name, id.Name+".") {
+				continue
+			}
+			mid := m
+			mid.Name = newID.Name + strings.TrimPrefix(m.Name, id.Name)
+			ret.Renamed[m] = mid
+			if mfn := r.GetFunction(m); mfn != nil {
+				if off := receiverOffset(mfn.Content, oldName, shortName(m.Name)); off >= 0 {
+					renames[m] = append(renames[m], rename{off, oldName, newName})
+				}
+				mfn.Signature = replaceWord(mfn.Signature, oldName, newName)
+			}
+		}
+	case v != nil:
+		if off := declOffset(v.Content, oldName, 0); off >= 0 {
+			renames[id] = append(docRenames(v.Content, off, oldName, newName), rename{off, oldName, newName})
+		}
+	default:
+		return nil, fmt.Errorf("node %s not found", id.Full())
+	}
+
+	// the references in the codes of the dependent nodes
+	r.forEachInternalNode(func(nid Identity, fl FileLine, content string, deps []*[]Dependency) {
+		for _, ds := range deps {
+			for _, d := range *ds {
+				if d.Identity != id {
+					continue
+				}
+				if off := refOffset(content, fl, d.FileLine, oldName); off >= 0 {
+					renames[nid] = append(renames[nid], rename{off, oldName, newName})
+				} else {
+					ret.Unresolved = append(ret.Unresolved, UnresolvedReference{Node: nid, FileLine: d.FileLine})
+				}
+			}
+		}
+	})
+
+	// apply the renames, and record the shifts of the file offsets
+	shifts := map[string][]rename{}
+	files := map[string]bool{}
+	r.forEachInternalNode(func(nid Identity, fl FileLine, content string, _ []*[]Dependency) {
+		rs := renames[nid]
+		if len(rs) == 0 {
+			return
+		}
+		// from the last one, thus the offsets of the previous ones are kept
+		sort.Slice(rs, func(i, j int) bool { return rs[i].offset > rs[j].offset })
+		last := -1
+		for _, rn := range rs {
+			if rn.offset == last {
+				// the same span may be recorded as different kinds of dependencies
+				continue
+			}
+			last = rn.offset
+			content = content[:rn.offset] + rn.new + content[rn.offset+len(rn.old):]
+			if fl.File != "" {
+				shifts[fl.File] = append(shifts[fl.File], rename{fl.StartOffset + rn.offset, rn.old, rn.new})
+			}
+		}
+		r.setContent(nid, content)
+		if to, ok := ret.Renamed[nid]; ok {
+			nid = to
+		}
+		ret.Nodes = append(ret.Nodes, nid)
+		if fl.File != "" {
+			files[fl.File] = true
+		}
+	})
+	r.shiftOffsets(shifts)
+
+	r.renameNodes(ret.Renamed)
+	r.redirect(ret.Renamed)
+	if len(r.Graph) > 0 {
+		if err := r.BuildGraph(); err != nil {
+			return ret, err
+		}
+	}
+
+	sort.Slice(ret.Nodes, func(i, j int) bool { return ret.Nodes[i].Full() < ret.Nodes[j].Full() })
+	for f := range files {
+		ret.Files = append(ret.Files, f)
+	}
+	sort.Strings(ret.Files)
+	return ret, nil
+}
+
+// MoveNode moves the function, type or var of an internal module to the package of an internal module,
+// which is created if not exists. The methods of a moved type go with it, while a method can't be moved alone.
+// The node is put in the file without offsets, since its codes are not rewritten here (see patch.Patcher.Move),
+// and the dependencies on it are redirected. It returns the old identities to the new ones
+func (r *Repository) MoveNode(id Identity, modPath ModPath, pkgPath PkgPath, file string) (map[Identity]Identity, error) {
+	if mod := r.GetModule(id.ModPath); mod == nil || mod.IsExternal() {
+		return nil, fmt.Errorf("node %s is not in the internal modules", id.Full())
+	}
+	mod := r.GetModule(modPath)
+	if mod == nil || mod.IsExternal() {
+		return nil, fmt.Errorf("module %s is not an internal module", modPath)
+	}
+	if id.ModPath == modPath && id.PkgPath == pkgPath {
+		return nil, fmt.Errorf("node %s is already in package %s", id.Full(), pkgPath)
+	}
+	moved := map[Identity]Identity{}
+	to := func(id Identity) Identity {
+		id.ModPath, id.PkgPath = modPath, pkgPath
+		return id
+	}
+	if fn := r.GetFunction(id); fn != nil {
+		if fn.Receiver != nil {
+			return nil, fmt.Errorf("method %s must be moved with its receiver type", id.Full())
+		}
+		moved[id] = to(id)
+	} else if t := r.GetType(id); t != nil {
+		moved[id] = to(id)
+		for _, m := range t.Methods {
+			if m.ModPath == id.ModPath && m.PkgPath == id.PkgPath {
+				moved[m] = to(m)
+			}
+		}
+	} else if r.GetVar(id) != nil {
+		moved[id] = to(id)
+	} else {
+		return nil, fmt.Errorf("node %s not found", id.Full())
+	}
+	for _, nid := range moved {
+		if r.GetFunction(nid) != nil || r.GetType(nid) != nil || r.GetVar(nid) != nil {
+			return nil, fmt.Errorf("node %s already exists", nid.Full())
+		}
+	}
+
+	pkg := mod.Packages[pkgPath]
+	if pkg == nil {
+		pkg = NewPackage(pkgPath)
+		mod.Packages[pkgPath] = pkg
+	}
+	if mod.GetFile(file) == nil {
+		f := NewFile(file)
+		f.Package = pkgPath
+		mod.CreateFile(file, f)
+	}
+	for from, nid := range moved {
+		old := r.GetPackage(from.ModPath, from.PkgPath)
+		if fn := old.Functions[from.Name]; fn != nil {
+			delete(old.Functions, from.Name)
+			fn.Identity, fn.FileLine = nid, FileLine{File: file}
+			pkg.Functions[nid.Name] = fn
+		} else if t := old.Types[from.Name]; t != nil {
+			delete(old.Types, from.Name)
+			t.Identity, t.FileLine = nid, FileLine{File: file}
+			pkg.Types[nid.Name] = t
+		} else if v := old.Vars[from.Name]; v != nil {
+			delete(old.Vars, from.Name)
+			v.Identity, v.FileLine = nid, FileLine{File: file}
+			pkg.Vars[nid.Name] = v
+		}
+	}
+	r.redirect(moved)
+	if len(r.Graph) > 0 {
+		if err := r.BuildGraph(); err != nil {
+			return moved, err
+		}
+	}
+	return moved, nil
+}
+
+// forEachInternalNode visits the nodes of the internal modules with their codes and dependencies
+func (r *Repository) forEachInternalNode(visit func(id Identity, fl FileLine, content string, deps []*[]Dependency)) {
+	for _, mod := range r.Modules {
+		if mod.IsExternal() {
+			continue
+		}
+		for _, pkg := range mod.Packages {
+			for _, fn := range pkg.Functions {
+				visit(fn.Identity, fn.FileLine, fn.Content, []*[]Dependency{&fn.Params, &fn.Results, &fn.FunctionCalls, &fn.MethodCalls, &fn.Types, &fn.GlobalVars})
+			}
+			for _, t := range pkg.Types {
+				visit(t.Identity, t.FileLine, t.Content, []*[]Dependency{&t.SubStruct, &t.InlineStruct})
+			}
+			for _, v := range pkg.Vars {
+				visit(v.Identity, v.FileLine, v.Content, []*[]Dependency{&v.Dependencies})
+			}
+		}
+	}
+}
+
+// setContent sets the codes of the node, and its hash if it has been hashed
+func (r *Repository) setContent(id Identity, content string) {
+	if fn := r.GetFunction(id); fn != nil {
+		fn.Content = content
+		if fn.Hash != "" {
+			fn.Hash = ContentHash(content)
+		}
+	} else if t := r.GetType(id); t != nil {
+		t.Content = content
+		if t.Hash != "" {
+			t.Hash = ContentHash(content)
+		}
+	} else if v := r.GetVar(id); v != nil {
+		v.Content = content
+		if v.Hash != "" {
+			v.Hash = ContentHash(content)
+		}
+	}
+}
+
+// shiftOffsets shifts the offsets of the nodes and dependencies in the files by the renames before them
+func (r *Repository) shiftOffsets(shifts map[string][]rename) {
+	if len(shifts) == 0 {
+		return
+	}
+	// the same rename may be recorded twice, see RenameNode
+	for file, rs := range shifts {
+		sort.Slice(rs, func(i, j int) bool { return rs[i].offset < rs[j].offset })
+		uniq := rs[:0]
+		for i, rn := range rs {
+			if i == 0 || rn.offset != rs[i-1].offset {
+				uniq = append(uniq, rn)
+			}
+		}
+		shifts[file] = uniq
+	}
+	shift := func(fl *FileLine) {
+		rs := shifts[fl.File]
+		start, end := fl.StartOffset, fl.EndOffset
+		for _, rn := range rs {
+			delta := len(rn.new) - len(rn.old)
+			if rn.offset < start {
+				fl.StartOffset += delta
+			}
+			if rn.offset < end {
+				fl.EndOffset += delta
+			}
+		}
+	}
+	r.forEachInternalNode(func(id Identity, _ FileLine, _ string, deps []*[]Dependency) {
+		if fn := r.GetFunction(id); fn != nil {
+			shift(&fn.FileLine)
+		} else if t := r.GetType(id); t != nil {
+			shift(&t.FileLine)
+		} else if v := r.GetVar(id); v != nil {
+			shift(&v.FileLine)
+		}
+		for _, ds := range deps {
+			for i := range *ds {
+				shift(&(*ds)[i].FileLine)
+			}
+		}
+	})
+}
+
+// renameNodes moves the nodes to their new identities in the packages
+func (r *Repository) renameNodes(to map[Identity]Identity) {
+	for from, id := range to {
+		pkg := r.GetPackage(from.ModPath, from.PkgPath)
+		if pkg == nil {
+			continue
+		}
+		if fn := pkg.Functions[from.Name]; fn != nil {
+			delete(pkg.Functions, from.Name)
+			fn.Identity = id
+			pkg.Functions[id.Name] = fn
+			if fn.Receiver != nil {
+				// the methods are indexed by the short names
+				if t := r.GetType(fn.Receiver.Type); t != nil && t.Methods != nil {
+					if _, ok := t.Methods[shortName(from.Name)]; ok && shortName(from.Name) != shortName(id.Name) {
+						delete(t.Methods, shortName(from.Name))
+						t.Methods[shortName(id.Name)] = from
+					}
+				}
+			}
+		} else if t := pkg.Types[from.Name]; t != nil {
+			delete(pkg.Types, from.Name)
+			t.Identity = id
+			pkg.Types[id.Name] = t
+		} else if v := pkg.Vars[from.Name]; v != nil {
+			delete(pkg.Vars, from.Name)
+			v.Identity = id
+			pkg.Vars[id.Name] = v
+		}
+	}
+}
+
+// shortName is the last segment of the name, like `Start` of the method `Server.Start`
+func shortName(name string) string {
+	if i := strings.LastIndexByte(name, '.'); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
+
+func isIdentifier(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, c := range name {
+		if !isIdentRune(c) || i == 0 && unicode.IsDigit(c) {
+			return false
+		}
+	}
+	return true
+}
+
+func isIdentRune(c rune) bool {
+	return c == '_' || unicode.IsLetter(c) || unicode.IsDigit(c)
+}
+
+// isWordAt tells if the word at the offset of s is not a part of a longer identifier
+func isWordAt(s string, off int, word string) bool {
+	if off > 0 {
+		if c, _ := utf8.DecodeLastRuneInString(s[:off]); isIdentRune(c) {
+			return false
+		}
+	}
+	if end := off + len(word); end < len(s) {
+		if c, _ := utf8.DecodeRuneInString(s[end:]); isIdentRune(c) {
+			return false
+		}
+	}
+	return true
+}
+
+// indexWord returns the offset of the first whole word in s from the offset, -1 if not found
+func indexWord(s string, word string, from int) int {
+	for from <= len(s) {
+		i := strings.Index(s[from:], word)
+		if i < 0 {
+			return -1
+		}
+		if isWordAt(s, from+i, word) {
+			return from + i
+		}
+		from += i + len(word)
+	}
+	return -1
+}
+
+// lastIndexWord returns the offset of the last whole word in s, -1 if not found
+func lastIndexWord(s string, word string) int {
+	ret := -1
+	for off := indexWord(s, word, 0); off >= 0; off = indexWord(s, word, off+len(word)) {
+		ret = off
+	}
+	return ret
+}
+
+func replaceWord(s string, old, new string) string {
+	if off := indexWord(s, old, 0); off >= 0 {
+		return s[:off] + new + s[off+len(old):]
+	}
+	return s
+}
+
+// isCommentLine tells if the line is a comment, like `// xx`, `# xx`, `/* xx` or ` * xx`
+func isCommentLine(line string) bool {
+	line = strings.TrimSpace(line)
+	return strings.HasPrefix(line, "//") || strings.HasPrefix(line, "/*") || strings.HasPrefix(line, "*") ||
+		strings.HasPrefix(line, "#") && !strings.HasPrefix(line, "#[") && !strings.HasPrefix(line, "#!")
+}
+
+// declOffset returns the offset of the first whole name out of the comment lines from the offset
+func declOffset(content string, name string, from int) int {
+	for off := indexWord(content, name, from); off >= 0; off = indexWord(content, name, off+len(name)) {
+		lineStart := strings.LastIndexByte(content[:off], '\n') + 1
+		lineEnd := strings.IndexByte(content[off:], '\n')
+		if lineEnd < 0 {
+			lineEnd = len(content) - off
+		}
+		if !isCommentLine(content[lineStart : off+lineEnd]) {
+			return off
+		}
+	}
+	return -1
+}
+
+// docRenames renames the names in the comment lines before the declaration
+func docRenames(content string, decl int, old, new string) []rename {
+	var ret []rename
+	for off := indexWord(content, old, 0); off >= 0 && off < decl; off = indexWord(content, old, off+len(old)) {
+		ret = append(ret, rename{off, old, new})
+	}
+	return ret
+}
+
+// receiverOffset returns the offset of the receiver type before the method name, like `func (s *Server) Start()`,
+// -1 if the receiver is not in the codes of the method
+func receiverOffset(content string, typeName string, method string) int {
+	name := declOffset(content, method, 0)
+	if name < 0 {
+		return -1
+	}
+	if off := declOffset(content, typeName, 0); off >= 0 && off < name {
+		return off
+	}
+	return -1
+}
+
+// refOffset returns the offset of the name referring to the dependency in the codes of the node.
+// The name is the last one in the span of the dependency, like `Foo` in `pkg.Foo`,
+// or the first one in its line if the offsets are unknown. -1 if not found
+func refOffset(content string, node FileLine, dep FileLine, name string) int {
+	if dep.File != "" && node.File != "" && dep.File != node.File {
+		return -1
+	}
+	if dep.EndOffset > dep.StartOffset {
+		start, end := dep.StartOffset-node.StartOffset, dep.EndOffset-node.StartOffset
+		if start < 0 || end > len(content) {
+			return -1
+		}
+		if off := lastIndexWord(content[start:end], name); off >= 0 {
+			return start + off
+		}
+		return -1
+	}
+	line := dep.Line - node.Line
+	if dep.Line == 0 || line < 0 {
+		return -1
+	}
+	start := 0
+	for ; line > 0; line-- {
+		i := strings.IndexByte(content[start:], '\n')
+		if i < 0 {
+			return -1
+		}
+		start += i + 1
+	}
+	end := strings.IndexByte(content[start:], '\n')
+	if end < 0 {
+		end = len(content) - start
+	}
+	if off := indexWord(content[start:start+end], name, 0); off >= 0 {
+		return start + off
+	}
+	return -1
+}
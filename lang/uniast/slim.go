@@ -0,0 +1,185 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package uniast
+
+import (
+	"slices"
+	"strings"
+)
+
+// SlimOptions selects the parts of a repository to serialize, see Repository.Slim
+type SlimOptions struct {
+	// StripContent replaces the Content of the nodes by their leading doc comments and declarations,
+	// dropping the bodies and the related codes of the relations. Function signatures, fields, hashes and all edges are kept
+	StripContent bool
+	// Modules keeps only these modules, matched by their names with or without the versions,
+	// like `github.com/a/b` for `github.com/a/b@v1.0.0`. All modules are kept if empty
+	Modules []string
+	// Internal drops the external modules
+	Internal bool
+}
+
+// IsEmpty tells if the options keep the whole repository
+func (o SlimOptions) IsEmpty() bool {
+	return !o.StripContent && len(o.Modules) == 0 && !o.Internal
+}
+
+func (o SlimOptions) keep(mod *Module) bool {
+	if o.Internal && mod.IsExternal() {
+		return false
+	}
+	if len(o.Modules) == 0 {
+		return true
+	}
+	name, _, _ := strings.Cut(mod.Name, "@")
+	return slices.Contains(o.Modules, mod.Name) || slices.Contains(o.Modules, name)
+}
+
+// Slim returns a slim copy of the repository for serializing, like the graph-only ASTs for visualization or CI checks.
+// The nodes of the dropped modules are removed from the Graph, while the relations on them are kept.
+// The repository itself is not modified, and the untouched modules and nodes are shared with the copy
+func (r *Repository) Slim(o SlimOptions) *Repository {
+	if o.IsEmpty() {
+		return r
+	}
+	ret := &Repository{
+		Name:         r.Name,
+		ASTVersion:   r.ASTVersion,
+		ToolVersion:  r.ToolVersion,
+		Path:         r.Path,
+		VCS:          r.VCS,
+		Modules:      make(map[string]*Module, len(r.Modules)),
+		Graph:        make(NodeGraph, len(r.Graph)),
+		Dependencies: r.Dependencies,
+	}
+	for name, mod := range r.Modules {
+		if !o.keep(mod) {
+			continue
+		}
+		if o.StripContent {
+			mod = stripModule(mod)
+		}
+		ret.Modules[name] = mod
+	}
+	for id, node := range r.Graph {
+		if _, ok := ret.Modules[node.ModPath]; ok {
+			if o.StripContent {
+				node = stripNode(node)
+			}
+			ret.Graph[id] = node
+		}
+	}
+	return ret
+}
+
+// stripNode returns the node without the related codes of its relations, copied if any
+func stripNode(node *Node) *Node {
+	rels := [][]Relation{node.Dependencies, node.References, node.Implements, node.Inherits, node.Groups}
+	if !slices.ContainsFunc(rels, func(rs []Relation) bool {
+		return slices.ContainsFunc(rs, func(r Relation) bool { return r.Codes != nil })
+	}) {
+		return node
+	}
+	strip := func(rs []Relation) []Relation {
+		if rs == nil {
+			return nil
+		}
+		rs = slices.Clone(rs)
+		for i := range rs {
+			rs[i].Codes = nil
+		}
+		return rs
+	}
+	n := *node
+	n.Dependencies = strip(node.Dependencies)
+	n.References = strip(node.References)
+	n.Implements = strip(node.Implements)
+	n.Inherits = strip(node.Inherits)
+	n.Groups = strip(node.Groups)
+	return &n
+}
+
+// stripModule returns a copy of the module whose nodes are stripped of their bodies
+func stripModule(mod *Module) *Module {
+	m := *mod
+	m.Packages = make(map[PkgPath]*Package, len(mod.Packages))
+	for path, pkg := range mod.Packages {
+		p := *pkg
+		p.Functions = make(map[string]*Function, len(pkg.Functions))
+		for name, fn := range pkg.Functions {
+			f := *fn
+			if f.Signature == "" {
+				f.Signature = declarationHead(fn.Content)
+			}
+			f.Content = stripContent(fn.Content, f.Signature)
+			p.Functions[name] = &f
+		}
+		p.Types = make(map[string]*Type, len(pkg.Types))
+		for name, t := range pkg.Types {
+			// go types have no bodies but the fields, which are kept
+			if mod.Language != Golang {
+				c := *t
+				c.Content = stripContent(t.Content, declarationHead(t.Content))
+				t = &c
+			}
+			p.Types[name] = t
+		}
+		p.Vars = make(map[string]*Var, len(pkg.Vars))
+		for name, v := range pkg.Vars {
+			c := *v
+			c.Content = stripContent(v.Content, declarationHead(v.Content))
+			p.Vars[name] = &c
+		}
+		m.Packages[path] = &p
+	}
+	return &m
+}
+
+// stripContent returns the leading comments of the content followed by the declaration
+func stripContent(content string, decl string) string {
+	if doc := leadingComments(content); doc != "" {
+		return doc + "\n" + decl
+	}
+	return decl
+}
+
+// leadingComments returns the comments at the start of the content, like the go doc comments or the javadocs
+func leadingComments(content string) string {
+	content = strings.TrimLeft(content, " \t\r\n")
+	end := 0
+	for end < len(content) {
+		rest := strings.TrimLeft(content[end:], " \t\r\n")
+		skip := len(content) - end - len(rest)
+		switch {
+		case strings.HasPrefix(rest, "/*"):
+			i := strings.Index(rest, "*/")
+			if i < 0 {
+				return strings.TrimSpace(content)
+			}
+			end += skip + i + 2
+		case strings.HasPrefix(rest, "//"), strings.HasPrefix(rest, "#") && !strings.HasPrefix(rest, "#["):
+			i := strings.IndexByte(rest, '\n')
+			if i < 0 {
+				return strings.TrimSpace(content)
+			}
+			end += skip + i
+		default:
+			return strings.TrimRight(content[:end], " \t\r\n")
+		}
+	}
+	return strings.TrimRight(content[:end], " \t\r\n")
+}
@@ -0,0 +1,229 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uniast
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// span is the source range of an internal node, lines are 1-based and inclusive
+type span struct {
+	start, end       int
+	startOff, endOff int
+	id               Identity
+}
+
+// spanTree is an augmented interval tree laid out on the spans sorted by start line:
+// the subtree rooted at mid of [lo, hi) is spans[lo:hi], and maxEnd[mid] is the max end line of the subtree.
+type spanTree struct {
+	spans  []span
+	maxEnd []int
+}
+
+func newSpanTree(spans []span) *spanTree {
+	sort.Slice(spans, func(i, j int) bool {
+		if spans[i].start != spans[j].start {
+			return spans[i].start < spans[j].start
+		}
+		return spans[i].end < spans[j].end
+	})
+	t := &spanTree{spans: spans, maxEnd: make([]int, len(spans))}
+	t.build(0, len(spans))
+	return t
+}
+
+func (t *spanTree) build(lo, hi int) int {
+	if lo >= hi {
+		return 0
+	}
+	mid := (lo + hi) / 2
+	m := t.spans[mid].end
+	if l := t.build(lo, mid); l > m {
+		m = l
+	}
+	if r := t.build(mid+1, hi); r > m {
+		m = r
+	}
+	t.maxEnd[mid] = m
+	return m
+}
+
+// stab calls fn with every span containing the line
+func (t *spanTree) stab(lo, hi, line int, fn func(*span)) {
+	if lo >= hi {
+		return
+	}
+	mid := (lo + hi) / 2
+	if t.maxEnd[mid] < line {
+		return
+	}
+	t.stab(lo, mid, line, fn)
+	s := &t.spans[mid]
+	if s.start > line {
+		// the right subtree starts even later
+		return
+	}
+	if line <= s.end {
+		fn(s)
+	}
+	t.stab(mid+1, hi, line, fn)
+}
+
+// spanIndex maps source positions back to the nodes, see Repository.NodeAt
+type spanIndex struct {
+	files map[string]*spanTree
+
+	mu sync.Mutex
+	// file => byte offsets of the line starts, read lazily from the sources to resolve columns
+	lines map[string][]int
+}
+
+// BuildSpanIndex (re)builds the index used by NodeAt.
+// LoadRepo builds it, call it again after modifying the nodes of a loaded repository.
+func (r *Repository) BuildSpanIndex() {
+	files := map[string][]span{}
+	add := func(id Identity, fl FileLine, content string) {
+		if fl.File == "" || fl.Line <= 0 {
+			return
+		}
+		end := fl.EndLine
+		if end < fl.Line {
+			end = fl.Line + strings.Count(content, "\n")
+		}
+		file := filepath.ToSlash(fl.File)
+		files[file] = append(files[file], span{start: fl.Line, end: end, startOff: fl.StartOffset, endOff: fl.EndOffset, id: id})
+	}
+	for _, mod := range r.Modules {
+		if mod.IsExternal() {
+			continue
+		}
+		for _, pkg := range mod.Packages {
+			for _, f := range pkg.Functions {
+				add(f.Identity, f.FileLine, f.Content)
+			}
+			for _, t := range pkg.Types {
+				add(t.Identity, t.FileLine, t.Content)
+			}
+			for _, v := range pkg.Vars {
+				add(v.Identity, v.FileLine, v.Content)
+			}
+		}
+	}
+	idx := &spanIndex{files: make(map[string]*spanTree, len(files)), lines: map[string][]int{}}
+	for file, spans := range files {
+		idx.files[file] = newSpanTree(spans)
+	}
+	r.spans = idx
+}
+
+// NodeAt returns the innermost node whose source range contains the position, or nil if none.
+// file is resolved by ResolveFile. line and col are 1-based,
+// col <= 0 means any column of the line. col is only honored when the source file is readable,
+// since the AST records byte offsets but not columns.
+//
+// It is backed by an interval tree per file, which is built by LoadRepo or lazily on the first call.
+func (r *Repository) NodeAt(file string, line, col int) *Node {
+	if r.spans == nil {
+		r.BuildSpanIndex()
+	}
+	file = r.ResolveFile(file)
+	t := r.spans.files[file]
+	if t == nil || line <= 0 {
+		return nil
+	}
+	off := -1
+	if col > 0 {
+		off = r.spans.offset(filepath.Join(r.Path, file), file, line, col)
+	}
+	var best *span
+	t.stab(0, len(t.spans), line, func(s *span) {
+		if off >= 0 && s.endOff > s.startOff && (off < s.startOff || off >= s.endOff) {
+			return
+		}
+		if best == nil || narrower(s, best) {
+			best = s
+		}
+	})
+	if best == nil {
+		return nil
+	}
+	return r.GetNode(best.id)
+}
+
+// narrower tells if a is nested in b, or the smaller one if they overlap
+func narrower(a, b *span) bool {
+	if la, lb := a.end-a.start, b.end-b.start; la != lb {
+		return la < lb
+	}
+	if la, lb := a.endOff-a.startOff, b.endOff-b.startOff; la != lb {
+		return la < lb
+	}
+	return a.id.Full() < b.id.Full()
+}
+
+// ResolveFile returns the path relative to the repository of the source file holding nodes which file refers to.
+// file is relative to the repository, absolute under Repository.Path, or ends with such a path,
+// like the paths in the stack traces of a build on another machine. The longest match wins.
+// If no file matches, the cleaned path is returned.
+func (r *Repository) ResolveFile(file string) string {
+	if r.spans == nil {
+		r.BuildSpanIndex()
+	}
+	if filepath.IsAbs(file) && filepath.IsAbs(r.Path) {
+		if rel, err := filepath.Rel(r.Path, file); err == nil && !strings.HasPrefix(rel, "..") {
+			file = rel
+		}
+	}
+	file = filepath.ToSlash(filepath.Clean(file))
+	if _, ok := r.spans.files[file]; ok {
+		return file
+	}
+	var best string
+	for f := range r.spans.files {
+		if strings.HasSuffix(file, "/"+f) && len(f) > len(best) {
+			best = f
+		}
+	}
+	if best != "" {
+		return best
+	}
+	return file
+}
+
+// offset converts the line and column to the byte offset in the file, -1 if the file is unreadable
+func (idx *spanIndex) offset(path, file string, line, col int) int {
+	idx.mu.Lock()
+	starts, ok := idx.lines[file]
+	if !ok {
+		if bs, err := os.ReadFile(path); err == nil {
+			starts = []int{0}
+			for i, b := range bs {
+				if b == '\n' {
+					starts = append(starts, i+1)
+				}
+			}
+		}
+		idx.lines[file] = starts
+	}
+	idx.mu.Unlock()
+	if line > len(starts) {
+		return -1
+	}
+	return starts[line-1] + col - 1
+}
@@ -0,0 +1,220 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package uniast
+
+import (
+	"sort"
+	"strings"
+)
+
+// RepoStats is the statistics of the internal modules of a repo
+type RepoStats struct {
+	Modules  int
+	Packages int
+	Files    int
+	// total lines of all nodes
+	LOC int
+	// node count per kind, like `function`, `method`, `test`, `struct`, `interface`, `var`, `const`
+	Nodes map[string]int
+	// files with the most lines of nodes
+	LargestFiles []FileStat
+	// functions with the most lines
+	LargestFunctions []NodeStat
+	// nodes referenced by the most other nodes
+	MostReferenced []NodeStat
+	// packages depended on by the most other packages
+	FanIn []PackageStat
+	// packages depending on the most other packages
+	FanOut []PackageStat
+}
+
+type FileStat struct {
+	Path  string
+	LOC   int
+	Nodes int
+}
+
+type NodeStat struct {
+	Identity
+	File  string
+	Count int
+}
+
+type PackageStat struct {
+	ModPath ModPath
+	PkgPath PkgPath
+	Count   int
+}
+
+// Stats computes the statistics of the repo, keeping the top n entries of each ranking
+func (r *Repository) Stats(top int) RepoStats {
+	ret := RepoStats{Nodes: map[string]int{}}
+	files := map[string]*FileStat{}
+	var funcs []NodeStat
+	countNode := func(kind, file string, content string) int {
+		loc := countLines(content)
+		ret.Nodes[kind]++
+		ret.LOC += loc
+		fs := files[file]
+		if fs == nil {
+			fs = &FileStat{Path: file}
+			files[file] = fs
+		}
+		fs.LOC += loc
+		fs.Nodes++
+		return loc
+	}
+
+	for _, mod := range r.InternalModules() {
+		ret.Modules++
+		ret.Files += len(mod.Files)
+		pkgs := map[PkgPath]bool{}
+		for path, pkg := range mod.Packages {
+			pkgs[basePkgPath(path)] = true
+			for _, fn := range pkg.Functions {
+				if isTestVariant(path) && !isTestFileOf(mod, fn.File) {
+					// the test variant duplicates the product nodes
+					continue
+				}
+				loc := countNode(functionKind(fn), fn.File, fn.Content)
+				funcs = append(funcs, NodeStat{Identity: fn.Identity, File: fn.File, Count: loc})
+			}
+			for _, t := range pkg.Types {
+				if isTestVariant(path) && !isTestFileOf(mod, t.File) {
+					continue
+				}
+				countNode(typeKind(t), t.File, t.Content)
+			}
+			for _, v := range pkg.Vars {
+				if isTestVariant(path) && !isTestFileOf(mod, v.File) {
+					continue
+				}
+				countNode(varKind(v), v.File, v.Content)
+			}
+		}
+		ret.Packages += len(pkgs)
+	}
+
+	for _, fs := range files {
+		ret.LargestFiles = append(ret.LargestFiles, *fs)
+	}
+	sort.Slice(ret.LargestFiles, func(i, j int) bool {
+		a, b := ret.LargestFiles[i], ret.LargestFiles[j]
+		if a.LOC != b.LOC {
+			return a.LOC > b.LOC
+		}
+		return a.Path < b.Path
+	})
+	ret.LargestFiles = topN(ret.LargestFiles, top)
+	ret.LargestFunctions = topN(sortNodeStats(funcs), top)
+
+	r.EnsureGraph()
+	var refs []NodeStat
+	fanIn := map[PackageStat]map[PackageStat]bool{}
+	fanOut := map[PackageStat]map[PackageStat]bool{}
+	for _, node := range r.Graph {
+		if mod := r.Modules[node.ModPath]; mod == nil || mod.IsExternal() {
+			continue
+		}
+		if len(node.References) > 0 && !isTestVariant(node.PkgPath) {
+			refs = append(refs, NodeStat{Identity: node.Identity, File: node.FileLine().File, Count: countReferrers(node)})
+		}
+		from := PackageStat{ModPath: node.ModPath, PkgPath: basePkgPath(node.PkgPath)}
+		for _, dep := range node.Dependencies {
+			to := PackageStat{ModPath: dep.ModPath, PkgPath: basePkgPath(dep.PkgPath)}
+			if to == from || r.Modules[to.ModPath] == nil || r.Modules[to.ModPath].IsExternal() {
+				continue
+			}
+			if fanOut[from] == nil {
+				fanOut[from] = map[PackageStat]bool{}
+			}
+			fanOut[from][to] = true
+			if fanIn[to] == nil {
+				fanIn[to] = map[PackageStat]bool{}
+			}
+			fanIn[to][from] = true
+		}
+	}
+	ret.MostReferenced = topN(sortNodeStats(refs), top)
+	ret.FanIn = topN(sortPackageStats(fanIn), top)
+	ret.FanOut = topN(sortPackageStats(fanOut), top)
+	return ret
+}
+
+// countReferrers counts the distinct nodes referencing the node,
+// regarding the nodes of a test variant as the same as the product ones
+func countReferrers(node *Node) int {
+	seen := make(map[Identity]bool, len(node.References))
+	for _, ref := range node.References {
+		id := ref.Identity
+		id.PkgPath = basePkgPath(id.PkgPath)
+		seen[id] = true
+	}
+	return len(seen)
+}
+
+func countLines(content string) int {
+	if content == "" {
+		return 0
+	}
+	return strings.Count(strings.TrimRight(content, "\n"), "\n") + 1
+}
+
+// isTestVariant tells if the package is a test variant of go, like `a/b [a/b.test]`
+func isTestVariant(pkg PkgPath) bool {
+	return strings.Contains(pkg, " [")
+}
+
+func isTestFileOf(mod *Module, path string) bool {
+	f := mod.Files[path]
+	return f != nil && f.IsTest
+}
+
+func sortNodeStats(stats []NodeStat) []NodeStat {
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Count != stats[j].Count {
+			return stats[i].Count > stats[j].Count
+		}
+		return stats[i].Identity.Full() < stats[j].Identity.Full()
+	})
+	return stats
+}
+
+func sortPackageStats(m map[PackageStat]map[PackageStat]bool) []PackageStat {
+	ret := make([]PackageStat, 0, len(m))
+	for p, set := range m {
+		p.Count = len(set)
+		ret = append(ret, p)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Count != ret[j].Count {
+			return ret[i].Count > ret[j].Count
+		}
+		if ret[i].ModPath != ret[j].ModPath {
+			return ret[i].ModPath < ret[j].ModPath
+		}
+		return ret[i].PkgPath < ret[j].PkgPath
+	})
+	return ret
+}
+
+func topN[T any](s []T, n int) []T {
+	if n > 0 && len(s) > n {
+		return s[:n]
+	}
+	return s
+}
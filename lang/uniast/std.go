@@ -0,0 +1,120 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uniast
+
+import "fmt"
+
+// StdModule is the module of the go std lib, named by GOROOT/src/go.mod
+const StdModule = "std"
+
+// StdModPath returns the path of the std module in the shared std AST of the toolchain version,
+// like `std@go1.24.5`, or `core@1.80.0` for the rust crate core
+func StdModPath(mod ModPath, version string) ModPath {
+	return ModPathName(mod) + "@" + version
+}
+
+// StdRepoName returns the name of the shared std AST of the language and the toolchain version, like `go-std@go1.24.5`
+func StdRepoName(lang Language, version string) string {
+	return fmt.Sprintf("%s-std@%s", lang, version)
+}
+
+// AsStdLib turns the repo parsed from the sources of a std lib into the shared std AST of the toolchain version.
+// Its modules are versioned by StdModPath, thus the repos linking the std lib refer to the nodes by the same identities
+// instead of collecting them again, see LinkStd
+func (r *Repository) AsStdLib(lang Language, version string) error {
+	to := map[ModPath]ModPath{}
+	for path, mod := range r.Modules {
+		if !mod.IsExternal() {
+			to[path] = StdModPath(path, version)
+			mod.Version = version
+		}
+	}
+	r.Name = StdRepoName(lang, version)
+	return r.renameModules(to)
+}
+
+// LinkStd refers the std nodes of the repo to the shared std AST of the toolchain version, see AsStdLib.
+// std tells the std module of an identity, empty if it is not of the std lib. The identities are redirected
+// to the versioned modules, and the std modules collected in the repo are dropped since the std AST holds their nodes.
+// It returns the dropped modules
+func (r *Repository) LinkStd(version string, std func(Identity) ModPath) ([]ModPath, error) {
+	var dropped []ModPath
+	for path, mod := range r.Modules {
+		if !mod.IsExternal() || !isStdModule(path, mod, std) {
+			continue
+		}
+		delete(r.Modules, path)
+		dropped = append(dropped, path)
+	}
+	if len(dropped) > 0 {
+		r.invalidateIndex()
+	}
+	r.redirectFunc(func(id Identity) (Identity, bool) {
+		if mod := std(id); mod != "" {
+			id.ModPath = StdModPath(mod, version)
+			return id, true
+		}
+		return id, false
+	})
+	if len(r.Graph) > 0 {
+		return dropped, r.BuildGraph()
+	}
+	return dropped, nil
+}
+
+// isStdModule tells if all packages of the module are of the std lib
+func isStdModule(path ModPath, mod *Module, std func(Identity) ModPath) bool {
+	if len(mod.Packages) == 0 {
+		return std(Identity{ModPath: path, PkgPath: path}) != ""
+	}
+	for pkg := range mod.Packages {
+		if std(Identity{ModPath: path, PkgPath: pkg}) == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// renameModules renames the modules with their nodes, and redirects the dependencies on them
+func (r *Repository) renameModules(to map[ModPath]ModPath) error {
+	for from, path := range to {
+		mod := r.Modules[from]
+		delete(r.Modules, from)
+		r.Modules[path] = mod
+		for _, pkg := range mod.Packages {
+			for _, fn := range pkg.Functions {
+				fn.ModPath = path
+			}
+			for _, t := range pkg.Types {
+				t.ModPath = path
+			}
+			for _, v := range pkg.Vars {
+				v.ModPath = path
+			}
+		}
+	}
+	r.invalidateIndex()
+	r.redirectFunc(func(id Identity) (Identity, bool) {
+		if path, ok := to[id.ModPath]; ok {
+			id.ModPath = path
+			return id, true
+		}
+		return id, false
+	})
+	if len(r.Graph) > 0 {
+		return r.BuildGraph()
+	}
+	return nil
+}
@@ -0,0 +1,268 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package uniast
+
+import (
+	"slices"
+	"sort"
+	"strings"
+)
+
+// TestLinkKind tells how a test is associated with the node under test
+type TestLinkKind string
+
+const (
+	// the test name refers to the node, like `TestFoo_Bar` => `Foo.Bar`, `test_foo` => `foo`
+	TestLinkName TestLinkKind = "name"
+	// the test calls the node, directly or through test helpers
+	TestLinkCall TestLinkKind = "call"
+)
+
+// the max depth of test helpers followed when linking by calls
+const maxTestHelperDepth = 3
+
+// TestLink associates a test function with a node it exercises
+type TestLink struct {
+	Test  Identity
+	Node  Identity
+	Kinds []TestLinkKind
+}
+
+// LinkTests associates every test function (Function.IsTest) with the non-test nodes it exercises,
+// by the naming convention of the test and the functions it calls.
+// Test helpers (non-test functions in test files) are followed but never linked.
+func (r *Repository) LinkTests() []TestLink {
+	var ret []TestLink
+	for _, mod := range r.InternalModules() {
+		for _, pkg := range mod.Packages {
+			for _, fn := range pkg.Functions {
+				if fn.IsTest {
+					ret = append(ret, r.linkTest(mod, fn)...)
+				}
+			}
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Node != ret[j].Node {
+			return ret[i].Node.Full() < ret[j].Node.Full()
+		}
+		return ret[i].Test.Full() < ret[j].Test.Full()
+	})
+	return ret
+}
+
+// TestsOf returns the links of the tests which exercise the node.
+// The links of the whole repository are memoized on the first call and dropped by its mutations, see invalidateIndex
+func (r *Repository) TestsOf(id Identity) []TestLink {
+	indexMu.Lock()
+	tests := r.tests
+	indexMu.Unlock()
+	if tests == nil {
+		tests = map[Identity][]TestLink{}
+		for _, l := range r.LinkTests() {
+			tests[l.Node] = append(tests[l.Node], l)
+		}
+		indexMu.Lock()
+		r.tests = tests
+		indexMu.Unlock()
+	}
+	return slices.Clip(tests[id])
+}
+
+func (r *Repository) linkTest(mod *Module, test *Function) []TestLink {
+	var links []TestLink
+	add := func(node Identity, kind TestLinkKind) {
+		for i := range links {
+			if links[i].Node == node {
+				links[i].Kinds = Append(links[i].Kinds, kind)
+				return
+			}
+		}
+		links = append(links, TestLink{Test: test.Identity, Node: node, Kinds: []TestLinkKind{kind}})
+	}
+
+	if node := r.testedByName(mod, test.Identity); node != nil {
+		add(*node, TestLinkName)
+	}
+
+	visited := map[Identity]bool{test.Identity: true}
+	var walk func(fn *Function, depth int)
+	walk = func(fn *Function, depth int) {
+		calls := make([]Dependency, 0, len(fn.FunctionCalls)+len(fn.MethodCalls))
+		calls = append(calls, fn.FunctionCalls...)
+		calls = append(calls, fn.MethodCalls...)
+		for _, call := range calls {
+			callee := r.resolveFunction(call.Identity)
+			if callee == nil || visited[callee.Identity] {
+				continue
+			}
+			visited[callee.Identity] = true
+			if callee.IsTest {
+				continue
+			}
+			if r.isTestCode(callee) {
+				if depth < maxTestHelperDepth {
+					walk(callee, depth+1)
+				}
+				continue
+			}
+			add(callee.Identity, TestLinkCall)
+		}
+	}
+	walk(test, 0)
+	return links
+}
+
+// resolveFunction finds the function of the id.
+// Callees declared in test files may live in the test variant of the package, like `a/b [a/b.test]` of go
+func (r *Repository) resolveFunction(id Identity) *Function {
+	if fn := r.GetFunction(id); fn != nil {
+		return fn
+	}
+	mod := r.Modules[id.ModPath]
+	if mod == nil {
+		return nil
+	}
+	for path, pkg := range mod.Packages {
+		if strings.HasPrefix(path, id.PkgPath+" [") {
+			if fn := pkg.Functions[id.Name]; fn != nil {
+				return fn
+			}
+		}
+	}
+	return nil
+}
+
+// isTestCode tells if the function is declared in a test file
+func (r *Repository) isTestCode(fn *Function) bool {
+	if fn.IsTest {
+		return true
+	}
+	mod := r.Modules[fn.ModPath]
+	if mod == nil {
+		return false
+	}
+	f := mod.Files[fn.File]
+	return f != nil && f.IsTest
+}
+
+// testedByName finds the node named by the test, in the package of the test or its parents first,
+// then in the whole module if there is only one candidate
+func (r *Repository) testedByName(mod *Module, test Identity) *Identity {
+	names := testedNames(test.Name)
+	if len(names) == 0 {
+		return nil
+	}
+	testPkg := basePkgPath(test.PkgPath)
+	var near, far []Identity
+	for path, pkg := range mod.Packages {
+		if pkg.IsTest && basePkgPath(path) != path {
+			// the test variant of a package duplicates its product nodes
+			continue
+		}
+		isNear := path == testPkg || strings.HasPrefix(testPkg, path+"::") || strings.HasPrefix(testPkg, path+".")
+		for _, name := range names {
+			var found []Identity
+			for fname, fn := range pkg.Functions {
+				if !r.isTestCode(fn) && matchTestedName(fname, name) {
+					found = append(found, fn.Identity)
+				}
+			}
+			if t := pkg.Types[name]; t != nil {
+				found = append(found, t.Identity)
+			}
+			if len(found) == 0 {
+				continue
+			}
+			if isNear {
+				near = append(near, found...)
+			} else {
+				far = append(far, found...)
+			}
+			break
+		}
+	}
+	if len(near) > 0 {
+		return pickTested(near, names)
+	}
+	if len(far) == 1 {
+		return &far[0]
+	}
+	return nil
+}
+
+// pickTested prefers the candidate matching the most specific name
+func pickTested(cands []Identity, names []string) *Identity {
+	for _, name := range names {
+		for i := range cands {
+			if matchTestedName(cands[i].Name, name) {
+				return &cands[i]
+			}
+		}
+	}
+	return &cands[0]
+}
+
+func matchTestedName(node, name string) bool {
+	if node == name {
+		return true
+	}
+	// methods of rust and python are named like `Type::method` or `Class.method`
+	if idx := strings.LastIndexAny(node, ".:"); idx >= 0 && !strings.ContainsAny(name, ".:") {
+		return node[idx+1:] == name
+	}
+	return false
+}
+
+// testedNames returns the possible names of the node under test, the more specific the former.
+// e.g. `TestFoo_Bar` => [`Foo.Bar`, `Foo_Bar`, `Foo`], `test_foo_bar` => [`foo_bar`]
+func testedNames(test string) []string {
+	if idx := strings.LastIndexAny(test, ".:"); idx >= 0 {
+		test = test[idx+1:]
+	}
+	for _, prefix := range []string{"Test", "Benchmark", "Fuzz", "Example"} {
+		if rest, ok := strings.CutPrefix(test, prefix); ok && rest != "" {
+			if rest[0] >= 'a' && rest[0] <= 'z' {
+				// like `Testify`, not a test
+				return nil
+			}
+			rest = strings.TrimPrefix(rest, "_")
+			if rest == "" {
+				return nil
+			}
+			parts := strings.SplitN(rest, "_", 3)
+			if len(parts) == 1 {
+				return []string{rest}
+			}
+			return []string{parts[0] + "." + parts[1], parts[0] + "_" + parts[1], parts[0]}
+		}
+	}
+	if rest, ok := strings.CutPrefix(test, "test_"); ok && rest != "" {
+		return []string{rest}
+	}
+	return nil
+}
+
+// basePkgPath trims the test variant suffix of the go package path,
+// like `a/b [a/b.test]` => `a/b`, `a/b_test [a/b.test]` => `a/b`
+func basePkgPath(pkg PkgPath) PkgPath {
+	idx := strings.Index(pkg, " [")
+	if idx < 0 {
+		return pkg
+	}
+	return strings.TrimSuffix(pkg[:idx], "_test")
+}
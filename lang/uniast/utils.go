@@ -17,8 +17,13 @@
 package uniast
 
 import (
+	"bufio"
 	"encoding/json"
+	"fmt"
 	"os"
+	"strings"
+
+	"github.com/cloudwego/abcoder/lang/utils"
 )
 
 func Append[T comparable](ids []T, id T) []T {
@@ -58,8 +63,14 @@ func InsertRelation(ids []Relation, id Relation) []Relation {
 }
 
 func LoadRepo(path string) (*Repository, error) {
+	if strings.HasSuffix(path, utils.PartialSuffix) {
+		return nil, fmt.Errorf("%s is an incomplete output of an interrupted run, please parse again", path)
+	}
 	bs, err := os.ReadFile(path)
 	if err != nil {
+		if _, e := os.Stat(path + utils.PartialSuffix); os.IsNotExist(err) && e == nil {
+			return nil, fmt.Errorf("%s is not completely written, found partial file %s", path, path+utils.PartialSuffix)
+		}
 		return nil, err
 	}
 	var repo Repository
@@ -67,5 +78,82 @@ func LoadRepo(path string) (*Repository, error) {
 		return nil, err
 	}
 	repo.AllNodesSetRepo()
+	repo.BuildSpanIndex()
+	return &repo, nil
+}
+
+// LoadRepoID reads the id of the repository JSON file without decoding the whole AST
+func LoadRepoID(path string) (string, error) {
+	if strings.HasSuffix(path, utils.PartialSuffix) {
+		return "", fmt.Errorf("%s is an incomplete output of an interrupted run, please parse again", path)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	dec := json.NewDecoder(bufio.NewReader(f))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return "", fmt.Errorf("%s is not a repository JSON", path)
+	}
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return "", err
+		}
+		if key == "id" {
+			var id string
+			if err := dec.Decode(&id); err != nil {
+				return "", fmt.Errorf("decode id of %s: %w", path, err)
+			}
+			return id, nil
+		}
+		// skip the value
+		var skip json.RawMessage
+		if err := dec.Decode(&skip); err != nil {
+			return "", err
+		}
+	}
+	return "", fmt.Errorf("id not found in %s", path)
+}
+
+// LoadRepoHeader reads the fields of the repository JSON file before Modules, without decoding the whole AST.
+// The fields are written before Modules by the encoder, see Repository
+func LoadRepoHeader(path string) (*Repository, error) {
+	if strings.HasSuffix(path, utils.PartialSuffix) {
+		return nil, fmt.Errorf("%s is an incomplete output of an interrupted run, please parse again", path)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	dec := json.NewDecoder(bufio.NewReader(f))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, fmt.Errorf("%s is not a repository JSON", path)
+	}
+	fields := map[string]json.RawMessage{}
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		if key == "Modules" || key == "Graph" {
+			break
+		}
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+		fields[key.(string)] = value
+	}
+	bs, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	var repo Repository
+	if err := json.Unmarshal(bs, &repo); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", path, err)
+	}
 	return &repo, nil
 }
@@ -0,0 +1,85 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package uniast
+
+// Visibility tells from where a symbol can be referred to, normalized across languages.
+// The empty value means unknown, in which case the Exported flag of the node is the only hint.
+type Visibility string
+
+const (
+	// visible everywhere: exported go names, rust `pub`, java `public`, python names without a leading `_`
+	VisibilityPublic Visibility = "public"
+	// visible to subclasses (and the package in java): java `protected`
+	VisibilityProtected Visibility = "protected"
+	// visible inside the whole module: rust `pub(crate)`, python `_name` by convention
+	VisibilityInternal Visibility = "internal"
+	// visible inside the declaring package or an enclosing module:
+	// unexported go names, java members without modifiers, rust `pub(super)` and `pub(in path)`
+	VisibilityPackage Visibility = "package"
+	// visible only to the declaring scope: java and rust private items, python `__name`
+	VisibilityPrivate Visibility = "private"
+)
+
+// Visibilities lists all the known visibilities, from the widest to the narrowest
+var Visibilities = []Visibility{VisibilityPublic, VisibilityProtected, VisibilityInternal, VisibilityPackage, VisibilityPrivate}
+
+// IsExported tells if the visibility makes a symbol visible everywhere, as the Exported flag of nodes
+func (v Visibility) IsExported() bool {
+	return v == VisibilityPublic
+}
+
+// Or returns v, or the visibility implied by exported if v is unknown,
+// which is the case of ASTs written before visibilities were recorded
+func (v Visibility) Or(exported bool) Visibility {
+	if v != "" {
+		return v
+	}
+	if exported {
+		return VisibilityPublic
+	}
+	return VisibilityPrivate
+}
+
+// GetVisibility returns the visibility of the function
+func (f *Function) GetVisibility() Visibility {
+	return f.Visibility.Or(f.Exported)
+}
+
+// SetVisibility sets the visibility of the function and the Exported flag derived from it
+func (f *Function) SetVisibility(v Visibility) {
+	f.Visibility, f.Exported = v, v.IsExported()
+}
+
+// GetVisibility returns the visibility of the type
+func (t *Type) GetVisibility() Visibility {
+	return t.Visibility.Or(t.Exported)
+}
+
+// SetVisibility sets the visibility of the type and the Exported flag derived from it
+func (t *Type) SetVisibility(v Visibility) {
+	t.Visibility, t.Exported = v, v.IsExported()
+}
+
+// GetVisibility returns the visibility of the var
+func (v *Var) GetVisibility() Visibility {
+	return v.Visibility.Or(v.IsExported)
+}
+
+// SetVisibility sets the visibility of the var and the IsExported flag derived from it
+func (v *Var) SetVisibility(vis Visibility) {
+	v.Visibility, v.IsExported = vis, vis.IsExported()
+}
@@ -32,4 +32,7 @@ type Writer interface {
 
 	// PatchImports patches the imports into file content
 	PatchImports(impts []Import, file []byte) ([]byte, error)
+
+	// RemoveUnusedImports removes the imports which are no longer referenced in file content
+	RemoveUnusedImports(file []byte) ([]byte, error)
 }
@@ -15,12 +15,28 @@
 package utils
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 )
 
+// FormatFiles runs a formatter command (like `gofumpt -w`) in place on the files, which are appended to its arguments
+func FormatFiles(ctx context.Context, command string, files []string) error {
+	args := strings.Fields(command)
+	if len(args) == 0 || len(files) == 0 {
+		return nil
+	}
+	cmd := exec.CommandContext(ctx, args[0], append(args[1:], files...)...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("format by '%s' failed: %v\n%s", command, err, out)
+	}
+	return nil
+}
+
 // count files and total size with specific subfix in a directory recursively
 func CountFiles(dir string, subfix string, skipdir string) (int, int) {
 	count := 0
@@ -65,3 +81,101 @@ func MustWriteFile(fpath string, data []byte) error {
 	}
 	return nil
 }
+
+// PartialSuffix is appended to the output path while it is being written.
+// A file carrying this suffix was left by an interrupted run and is incomplete.
+const PartialSuffix = ".partial"
+
+// default bytes written between two fsync calls
+const defaultSyncEvery = 64 << 20
+
+// AbortSafeWriter writes a file incrementally into fpath+PartialSuffix,
+// and fsyncs it every syncEvery bytes.
+// Only Commit renames the partial file to fpath as the completion marker,
+// thus an interrupted run never leaves a truncated file on fpath.
+type AbortSafeWriter struct {
+	f         *os.File
+	path      string
+	syncEvery int
+	unsynced  int
+}
+
+// NewAbortSafeWriter creates the partial file of fpath.
+// syncEvery <= 0 means the default 64MB.
+func NewAbortSafeWriter(fpath string, syncEvery int) (*AbortSafeWriter, error) {
+	if syncEvery <= 0 {
+		syncEvery = defaultSyncEvery
+	}
+	dir := filepath.Dir(fpath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("mkdir %s failed: %v", dir, err)
+	}
+	partial := fpath + PartialSuffix
+	f, err := os.OpenFile(partial, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("open file %s failed: %v", partial, err)
+	}
+	return &AbortSafeWriter{f: f, path: fpath, syncEvery: syncEvery}, nil
+}
+
+func (w *AbortSafeWriter) Write(p []byte) (int, error) {
+	var written int
+	for len(p) > 0 {
+		n := w.syncEvery - w.unsynced
+		if n > len(p) {
+			n = len(p)
+		}
+		m, err := w.f.Write(p[:n])
+		written += m
+		if err != nil {
+			return written, fmt.Errorf("write file %s failed: %v", w.f.Name(), err)
+		}
+		w.unsynced += m
+		if w.unsynced >= w.syncEvery {
+			if err := w.f.Sync(); err != nil {
+				return written, fmt.Errorf("sync file %s failed: %v", w.f.Name(), err)
+			}
+			w.unsynced = 0
+		}
+		p = p[n:]
+	}
+	return written, nil
+}
+
+// Commit flushes and closes the partial file, then renames it to the target path.
+func (w *AbortSafeWriter) Commit() error {
+	partial := w.f.Name()
+	if err := w.f.Sync(); err != nil {
+		w.f.Close()
+		return fmt.Errorf("sync file %s failed: %v", partial, err)
+	}
+	if err := w.f.Close(); err != nil {
+		return fmt.Errorf("close file %s failed: %v", partial, err)
+	}
+	if err := os.Rename(partial, w.path); err != nil {
+		return fmt.Errorf("rename %s to %s failed: %v", partial, w.path, err)
+	}
+	return nil
+}
+
+// Close closes the partial file without committing it. It is a no-op after Commit.
+func (w *AbortSafeWriter) Close() error {
+	err := w.f.Close()
+	if errors.Is(err, os.ErrClosed) {
+		return nil
+	}
+	return err
+}
+
+// WriteFileAbortSafe writes data to fpath through an AbortSafeWriter.
+func WriteFileAbortSafe(fpath string, data []byte, syncEvery int) error {
+	w, err := NewAbortSafeWriter(fpath, syncEvery)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	return w.Commit()
+}
@@ -0,0 +1,87 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFileAbortSafe(t *testing.T) {
+	dir := t.TempDir()
+	fpath := filepath.Join(dir, "sub", "ast.json")
+	data := bytes.Repeat([]byte(`{"id":"x"}`), 100)
+
+	if err := WriteFileAbortSafe(fpath, data, 64); err != nil {
+		t.Fatalf("WriteFileAbortSafe() error = %v", err)
+	}
+	got, err := os.ReadFile(fpath)
+	if err != nil {
+		t.Fatalf("read output failed: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("output mismatch, got %d bytes, want %d bytes", len(got), len(data))
+	}
+	if _, err := os.Stat(fpath + PartialSuffix); !os.IsNotExist(err) {
+		t.Errorf("partial file should be removed after completion, stat err = %v", err)
+	}
+}
+
+func TestWriteFileAbortSafe_Interrupted(t *testing.T) {
+	dir := t.TempDir()
+	fpath := filepath.Join(dir, "ast.json")
+	// a non-empty directory on the target path makes the final rename fail,
+	// just like the process is killed before completion
+	if err := os.MkdirAll(filepath.Join(fpath, "occupied"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	data := []byte(`{"id":"x"}`)
+	if err := WriteFileAbortSafe(fpath, data, 4); err == nil {
+		t.Fatalf("WriteFileAbortSafe() should fail when rename fails")
+	}
+	got, err := os.ReadFile(fpath + PartialSuffix)
+	if err != nil {
+		t.Fatalf("partial file should be left, read err = %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("partial file mismatch, got %s, want %s", got, data)
+	}
+	if info, err := os.Stat(fpath); err != nil || !info.IsDir() {
+		t.Errorf("target path should not be written, stat err = %v", err)
+	}
+}
+
+func TestAbortSafeWriter_Close(t *testing.T) {
+	fpath := filepath.Join(t.TempDir(), "ast.json")
+	w, err := NewAbortSafeWriter(fpath, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte(`{"id":`)); err != nil {
+		t.Fatal(err)
+	}
+	// closing without commit leaves only the partial file
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(fpath); !os.IsNotExist(err) {
+		t.Errorf("target should not exist before commit, stat err = %v", err)
+	}
+	if _, err := os.Stat(fpath + PartialSuffix); err != nil {
+		t.Errorf("partial file should exist, stat err = %v", err)
+	}
+}
@@ -104,3 +104,33 @@ func Contains[T comparable](s []T, v T) bool {
 	}
 	return false
 }
+
+// MatchBracket returns the index of the bracket closing the one at s[i], which is one of `(`, `[` and `{`.
+// Brackets inside string literals are skipped. Returns -1 if it is not closed
+func MatchBracket(s string, i int) int {
+	depth := 0
+	var quote byte
+	for j := i; j < len(s); j++ {
+		c := s[j]
+		if quote != 0 {
+			if c == '\\' {
+				j++
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch c {
+		case '"', '\'', '`':
+			quote = c
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+			if depth == 0 {
+				return j
+			}
+		}
+	}
+	return -1
+}
@@ -0,0 +1,94 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lang
+
+import (
+	"bufio"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/abcoder/lang/log"
+	"github.com/cloudwego/abcoder/lang/uniast"
+)
+
+// readVCS reads the git state of the repo at dir, nil if it is not in a git repository
+func readVCS(dir string) *uniast.VCS {
+	commit, err := git(dir, "rev-parse", "HEAD")
+	if err != nil {
+		log.Debug("read the git commit of %s failed: %v\n", dir, err)
+		return nil
+	}
+	vcs := &uniast.VCS{Type: "git", Commit: commit}
+	// fails if HEAD is detached
+	vcs.Branch, _ = git(dir, "symbolic-ref", "--short", "-q", "HEAD")
+	// untracked files count, since they are parsed as well
+	if status, err := git(dir, "status", "--porcelain", "--", "."); err == nil {
+		vcs.Dirty = status != ""
+	}
+	return vcs
+}
+
+// blameFile blames the lines of the file relative to dir, uncommitted lines are zero
+func blameFile(dir string, file string) ([]uniast.BlameLine, error) {
+	out, err := git(dir, "blame", "--line-porcelain", "--", file)
+	if err != nil {
+		return nil, err
+	}
+	return parseBlame(out), nil
+}
+
+// parseBlame parses the output of `git blame --line-porcelain`, where each line is led by the commit header
+// `<sha> <orig-line> <final-line> [<count>]`, followed by the commit info like `author <name>`, then the tab-prefixed content
+func parseBlame(out string) []uniast.BlameLine {
+	var ret []uniast.BlameLine
+	var cur uniast.BlameLine
+	uncommitted := false
+	sc := bufio.NewScanner(strings.NewReader(out))
+	sc.Buffer(nil, 1<<20)
+	for sc.Scan() {
+		line := sc.Text()
+		if strings.HasPrefix(line, "\t") {
+			if uncommitted {
+				cur = uniast.BlameLine{}
+			}
+			ret = append(ret, cur)
+			cur, uncommitted = uniast.BlameLine{}, false
+			continue
+		}
+		key, val, _ := strings.Cut(line, " ")
+		switch key {
+		case "author":
+			cur.Name = val
+		case "author-mail":
+			cur.Email = strings.Trim(val, "<>")
+		case "author-time":
+			if sec, err := strconv.ParseInt(val, 10, 64); err == nil {
+				cur.Time = time.Unix(sec, 0).UTC()
+			}
+		default:
+			if len(key) >= 40 && strings.Trim(key, "0") == "" {
+				uncommitted = true
+			}
+		}
+	}
+	return ret
+}
+
+func git(dir string, args ...string) (string, error) {
+	out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).Output()
+	return strings.TrimSpace(string(out)), err
+}
@@ -0,0 +1,105 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lang
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadVCS(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	dir := t.TempDir()
+	if vcs := readVCS(dir); vcs != nil {
+		t.Fatalf("not a git repository: %+v", vcs)
+	}
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	run("init", "-q", "-b", "main")
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	run("add", ".")
+	run("commit", "-q", "-m", "init")
+
+	vcs := readVCS(dir)
+	if vcs == nil || vcs.Type != "git" || len(vcs.Commit) != 40 || vcs.Branch != "main" || vcs.Dirty {
+		t.Fatalf("clean: %+v", vcs)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "new.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if vcs := readVCS(dir); vcs == nil || !vcs.Dirty {
+		t.Errorf("untracked sources should be dirty: %+v", vcs)
+	}
+	run("checkout", "-q", "--detach")
+	if vcs := readVCS(dir); vcs == nil || vcs.Branch != "" {
+		t.Errorf("detached: %+v", vcs)
+	}
+}
+
+func TestBlameFile(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	dir := t.TempDir()
+	run := func(name string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=" + name, "-c", "user.email=" + name + "@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	write := func(content string) {
+		if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	run("alice", "init", "-q")
+	write("package main\n\nfunc main() {\n}\n")
+	run("alice", "add", ".")
+	run("alice", "commit", "-q", "-m", "init")
+	write("package main\n\nfunc main() {\n\tprintln()\n}\n")
+	run("bob", "commit", "-q", "-am", "print")
+	write("package main\n\nfunc main() {\n\tprintln()\n\tprintln()\n}\n")
+
+	lines, err := blameFile(dir, "main.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 6 {
+		t.Fatalf("lines = %+v", lines)
+	}
+	if lines[0].Name != "alice" || lines[0].Email != "alice@example.com" || lines[0].Time.IsZero() {
+		t.Errorf("line 1 = %+v", lines[0])
+	}
+	if lines[3].Name != "bob" {
+		t.Errorf("line 4 = %+v", lines[3])
+	}
+	if lines[4].Name != "" || !lines[4].Time.IsZero() {
+		t.Errorf("uncommitted line 5 = %+v", lines[4])
+	}
+}
@@ -0,0 +1,308 @@
+// Copyright 2025 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lang
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/abcoder/lang/golang/parser"
+	"github.com/cloudwego/abcoder/lang/log"
+	"github.com/cloudwego/abcoder/lang/uniast"
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultWatchDebounce is how long the watcher waits for more changes before re-parsing
+const DefaultWatchDebounce = 300 * time.Millisecond
+
+// WatchOptions is the options of WatchRepo
+type WatchOptions struct {
+	// Debounce is how long to wait for more changes before re-parsing, DefaultWatchDebounce if 0
+	Debounce time.Duration
+	// OnUpdate is called with the AST after the initial parsing and every re-parsing.
+	// changed are the changed files relative to the repo, empty for the initial parsing
+	OnUpdate func(repo *uniast.Repository, changed []string) error
+}
+
+// WatchRepo parses the Go repo, then keeps the AST up to date until ctx is done:
+// the packages of the changed files are re-parsed and replaced in the AST.
+// Changes of go.mod, go.sum or go.work, and files out of the known modules, trigger a full re-parsing.
+// A failed re-parsing is logged, and the AST is kept until the next change
+func WatchRepo(ctx context.Context, uri string, args ParseOptions, wopts WatchOptions) error {
+	if args.Language != uniast.Golang {
+		return fmt.Errorf("watch mode only supports go, not %s", args.Language)
+	}
+	if len(args.OnlyPkgs) > 0 || len(args.OnlyDirs) > 0 {
+		return fmt.Errorf("watch mode can not be used with only-pkg or only-dir")
+	}
+	if !filepath.IsAbs(uri) {
+		uri, _ = filepath.Abs(uri)
+	}
+	debounce := wopts.Debounce
+	if debounce <= 0 {
+		debounce = DefaultWatchDebounce
+	}
+
+	// watch before parsing, thus the changes during the parsing are not missed
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("create watcher failed: %v", err)
+	}
+	defer watcher.Close()
+	if _, err := watchTree(watcher, uri); err != nil {
+		return err
+	}
+
+	repo, err := ParseRepo(ctx, uri, args)
+	if err != nil {
+		return err
+	}
+	if err := wopts.OnUpdate(repo, nil); err != nil {
+		return err
+	}
+
+	changed := map[string]bool{}
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			log.Error("watcher error: %v\n", err)
+		case ev, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			var files []string
+			if ev.Op&fsnotify.Create != 0 {
+				if info, err := os.Stat(ev.Name); err == nil && info.IsDir() {
+					// the files may be created before the dir is watched
+					if files, err = watchTree(watcher, ev.Name); err != nil {
+						log.Error("watch %s failed: %v\n", ev.Name, err)
+					}
+				}
+			}
+			files = append(files, ev.Name)
+			for _, file := range files {
+				if rel, err := filepath.Rel(uri, file); err == nil && watchedFile(rel) {
+					changed[rel] = true
+					timer.Reset(debounce)
+				}
+			}
+		case <-timer.C:
+			files := make([]string, 0, len(changed))
+			for f := range changed {
+				files = append(files, f)
+			}
+			sort.Strings(files)
+			changed = map[string]bool{}
+
+			start := time.Now()
+			updated, err := reparse(ctx, uri, args, repo, files)
+			if ctx.Err() != nil {
+				return nil
+			}
+			if err != nil {
+				log.Error("re-parse for the changes of %v failed, keep the last AST: %v\n", files, err)
+				continue
+			}
+			repo = updated
+			log.Info("re-parsed for the changes of %v in %v\n", files, time.Since(start))
+			if err := wopts.OnUpdate(repo, files); err != nil {
+				log.Error("update the AST failed: %v\n", err)
+			}
+		}
+	}
+}
+
+// skipWatchDir tells if the dir is never parsed, like .git, vendor and testdata
+func skipWatchDir(name string) bool {
+	return strings.HasPrefix(name, ".") || name == "vendor" || name == "testdata" || name == "node_modules"
+}
+
+// watchTree watches the dir and its sub dirs, and returns the files under them
+func watchTree(watcher *fsnotify.Watcher, root string) (files []string, err error) {
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if !d.IsDir() {
+			files = append(files, path)
+			return nil
+		}
+		if path != root && skipWatchDir(d.Name()) {
+			return filepath.SkipDir
+		}
+		if err := watcher.Add(path); err != nil {
+			return fmt.Errorf("watch %s failed: %v", path, err)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// isModuleFile tells if the file changes the modules or their dependencies
+func isModuleFile(rel string) bool {
+	switch filepath.Base(rel) {
+	case "go.mod", "go.sum", "go.work", "go.work.sum":
+		return true
+	}
+	return false
+}
+
+// watchedFile tells if the change of the file relative to the repo affects the AST
+func watchedFile(rel string) bool {
+	if rel == "." || strings.HasPrefix(rel, "..") {
+		return false
+	}
+	for _, dir := range strings.Split(filepath.Dir(rel), string(filepath.Separator)) {
+		if dir != "." && skipWatchDir(dir) {
+			return false
+		}
+	}
+	return filepath.Ext(rel) == ".go" || isModuleFile(rel)
+}
+
+// changedPackages returns the packages (import path => dir relative to the repo) of the changed files.
+// full is true if the whole repo must be re-parsed
+func changedPackages(repo *uniast.Repository, files []string) (pkgs map[string]string, full bool) {
+	pkgs = map[string]string{}
+	for _, file := range files {
+		if isModuleFile(file) {
+			return nil, true
+		}
+		dir := filepath.Dir(file)
+		var mod *uniast.Module
+		var rel string
+		for _, m := range repo.InternalModules() {
+			if m.Language != uniast.Golang {
+				continue
+			}
+			r, err := filepath.Rel(filepath.Clean(m.Dir), dir)
+			if err != nil || r == ".." || strings.HasPrefix(r, ".."+string(filepath.Separator)) {
+				continue
+			}
+			// the innermost module
+			if mod == nil || len(m.Dir) > len(mod.Dir) {
+				mod, rel = m, r
+			}
+		}
+		if mod == nil {
+			return nil, true
+		}
+		pkg := mod.Name
+		if rel != "." {
+			pkg += "/" + filepath.ToSlash(rel)
+		}
+		pkgs[pkg] = dir
+	}
+	return pkgs, false
+}
+
+// reparse re-parses the packages of the changed files and replaces them in the AST
+func reparse(ctx context.Context, uri string, args ParseOptions, repo *uniast.Repository, files []string) (*uniast.Repository, error) {
+	parser.InvalidateLoadCache()
+	pkgs, full := changedPackages(repo, files)
+	if full {
+		log.Info("the modules are changed, re-parse the whole repo\n")
+		return ParseRepo(ctx, uri, args)
+	}
+
+	// the packages whose files are all removed are dropped only
+	var only []string
+	for pkg, dir := range pkgs {
+		if hasGoFiles(filepath.Join(uri, dir)) {
+			only = append(only, pkg)
+		}
+	}
+	var partial *uniast.Repository
+	if len(only) > 0 {
+		sort.Strings(only)
+		log.Info("re-parse packages %v\n", only)
+		pargs := args
+		pargs.OnlyPkgs = only
+		// the graph is rebuilt after merging
+		pargs.DisableBuildGraph = true
+		var err error
+		if partial, err = ParseRepo(ctx, uri, pargs); err != nil {
+			return nil, err
+		}
+	}
+
+	removePackages(repo, pkgs)
+	if partial != nil {
+		// the stubs of the dependencies in the partial AST do not replace the parsed nodes
+		if err := uniast.Merge(repo, partial, uniast.MergeOptions{DisableBuildGraph: true}); err != nil {
+			return nil, err
+		}
+		repo.VCS = partial.VCS
+	} else {
+		repo.VCS = readVCS(uri)
+	}
+	if !args.DisableBuildGraph {
+		if err := repo.BuildGraph(); err != nil {
+			return nil, err
+		}
+	}
+	repo.ComputeMetrics()
+	return repo, nil
+}
+
+func hasGoFiles(dir string) bool {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+	for _, e := range entries {
+		if !e.IsDir() && filepath.Ext(e.Name()) == ".go" {
+			return true
+		}
+	}
+	return false
+}
+
+// removePackages removes the packages (import path => dir relative to the repo) and their files from the AST.
+// The test variants of a package, like `a/b [a/b.test]` and `a/b_test [a/b.test]`, are removed as well
+func removePackages(repo *uniast.Repository, pkgs map[string]string) {
+	for _, mod := range repo.InternalModules() {
+		for path := range mod.Packages {
+			pkg := string(path)
+			if i := strings.Index(pkg, " ["); i >= 0 {
+				pkg = strings.TrimSuffix(pkg[:i], "_test")
+			}
+			if _, ok := pkgs[pkg]; ok {
+				delete(mod.Packages, path)
+			}
+		}
+		for file := range mod.Files {
+			for _, dir := range pkgs {
+				if filepath.Dir(file) == dir {
+					delete(mod.Files, file)
+					break
+				}
+			}
+		}
+	}
+}
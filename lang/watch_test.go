@@ -0,0 +1,112 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lang
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/abcoder/lang/collect"
+	"github.com/cloudwego/abcoder/lang/uniast"
+)
+
+func TestWatchRepo(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("go.mod", "module a.b/watch\n\ngo 1.21\n")
+	write("a/a.go", "package a\n\nimport \"a.b/watch/b\"\n\nfunc A() int { return b.B() }\n")
+	write("b/b.go", "package b\n\nfunc B() int { return 1 }\n")
+
+	type update struct {
+		repo    *uniast.Repository
+		changed []string
+	}
+	updates := make(chan update, 4)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- WatchRepo(ctx, dir, ParseOptions{CollectOption: collect.CollectOption{Language: uniast.Golang}}, WatchOptions{
+			Debounce: 50 * time.Millisecond,
+			OnUpdate: func(repo *uniast.Repository, changed []string) error {
+				updates <- update{repo, changed}
+				return nil
+			},
+		})
+	}()
+	next := func() update {
+		t.Helper()
+		select {
+		case u := <-updates:
+			return u
+		case err := <-done:
+			t.Fatalf("watch stopped: %v", err)
+		case <-time.After(time.Minute):
+			t.Fatal("no update")
+		}
+		return update{}
+	}
+	pkgA := uniast.PkgPath("a.b/watch/a")
+	pkgB := uniast.PkgPath("a.b/watch/b")
+
+	u := next()
+	if len(u.changed) != 0 || u.repo.GetFunction(uniast.NewIdentity("a.b/watch", pkgA, "A")) == nil {
+		t.Fatalf("initial update: %v", u.changed)
+	}
+	oldB := u.repo.GetFunction(uniast.NewIdentity("a.b/watch", pkgB, "B"))
+
+	// only the package of the changed file is re-parsed
+	write("a/c.go", "package a\n\nfunc C() int { return A() }\n")
+	u = next()
+	if len(u.changed) != 1 || u.changed[0] != filepath.Join("a", "c.go") {
+		t.Errorf("changed = %v", u.changed)
+	}
+	if u.repo.GetFunction(uniast.NewIdentity("a.b/watch", pkgA, "C")) == nil {
+		t.Errorf("C should be parsed")
+	}
+	if u.repo.GetFunction(uniast.NewIdentity("a.b/watch", pkgB, "B")) != oldB {
+		t.Errorf("B should be kept")
+	}
+	if node := u.repo.GetNode(uniast.NewIdentity("a.b/watch", pkgA, "A")); node == nil || len(node.References) == 0 {
+		t.Errorf("the graph should be rebuilt: %+v", node)
+	}
+
+	// removed files are dropped
+	if err := os.Remove(filepath.Join(dir, "a", "c.go")); err != nil {
+		t.Fatal(err)
+	}
+	u = next()
+	if u.repo.GetFunction(uniast.NewIdentity("a.b/watch", pkgA, "C")) != nil || u.repo.Modules["a.b/watch"].Files[filepath.Join("a", "c.go")] != nil {
+		t.Errorf("C should be removed")
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
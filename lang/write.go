@@ -19,9 +19,17 @@ package lang
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/cloudwego/abcoder/lang/golang/writer"
+	"github.com/cloudwego/abcoder/lang/log"
+	"github.com/cloudwego/abcoder/lang/patch"
+	"github.com/cloudwego/abcoder/lang/runner"
 	"github.com/cloudwego/abcoder/lang/uniast"
+	"github.com/cloudwego/abcoder/lang/utils"
 )
 
 // Write writes the AST to the output directory.
@@ -30,10 +38,79 @@ type WriteOptions struct {
 	OutputDir string
 	// Compiler path
 	Compiler string
+	// ScaffoldExternal writes the loaded external dependencies as placeholder modules,
+	// thus the output compiles without them
+	ScaffoldExternal bool
+	// Validate compiles the written modules, and reports the errors as WriteErrors
+	Validate bool
+	// Preserve writes the source files by patching their original codes under Repository.Path
+	// with the minimal edits of the changed nodes, instead of rebuilding them from the nodes,
+	// thus the formatting and the order of the declarations are kept and diffs show only the changes.
+	// Only the source files are written, and the compile errors found by Validate are reported by files.
+	Preserve bool
+	// LocalPrefixes groups the imports of the written go files with the prefixes after the third-party ones,
+	// like `goimports -local`
+	LocalPrefixes []string
+	// Formatters are the commands formatting the written go files in order, with the file paths appended,
+	// e.g. `gofumpt -w`. The files are formatted after Validate, thus the lines of WriteErrors out of any node
+	// are of the unformatted files
+	Formatters []string
+	// RustfmtConfig is the path of a rustfmt.toml, the written rust files are formatted by rustfmt with it if set
+	RustfmtConfig string
+}
+
+// WriteError is a compile error of the written codes, mapped back to the node whose content causes it
+type WriteError struct {
+	// NodeID is the node, nil if the error is out of any node (e.g. in imports)
+	NodeID  *uniast.Identity `json:",omitempty"`
+	Message string
+	// Line is the line in the content of the node starting from 1, or the line in File if NodeID is nil
+	Line int
+	// File is the written file relative to the output directory
+	File string
+}
+
+// WriteErrors are the compile errors found by WriteOptions.Validate
+type WriteErrors []WriteError
+
+func (e WriteErrors) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d compile errors in the written codes:", len(e))
+	for _, we := range e {
+		if we.NodeID != nil {
+			fmt.Fprintf(&sb, "\n%s:%d: %s", we.NodeID.Full(), we.Line, we.Message)
+		} else {
+			fmt.Fprintf(&sb, "\n%s:%d: %s", we.File, we.Line, we.Message)
+		}
+	}
+	return sb.String()
+}
+
+// validator is implemented by the writers which can compile the written codes
+type validator interface {
+	// CompileCommand returns the command which compiles the written module in its dir
+	CompileCommand() string
+	// LocateNode finds the written node at file:line, and returns the line in its content
+	LocateNode(file string, line int) (*uniast.Identity, int)
+}
+
+// formatter is implemented by the writers which can format the written codes, see WriteOptions.Formatters
+type formatter interface {
+	// WrittenFiles returns the paths of the written source files
+	WrittenFiles() []string
+	Format(ctx context.Context, files []string) error
+}
+
+// aligner is implemented by the writers whose parsers record the offsets or contents of some nodes inexactly,
+// see writer.Writer.AlignSpans
+type aligner interface {
+	AlignSpans(src []byte, fls []uniast.FileLine, contents []string) error
 }
 
 // Write writes the AST to the output directory.
+// If args.Validate is set, the compile errors are returned as WriteErrors.
 func Write(ctx context.Context, repo *uniast.Repository, args WriteOptions) error {
+	var werrs WriteErrors
 	for mpath, m := range repo.Modules {
 		if m.IsExternal() {
 			continue
@@ -41,13 +118,194 @@ func Write(ctx context.Context, repo *uniast.Repository, args WriteOptions) erro
 		var w uniast.Writer
 		switch m.Language {
 		case uniast.Golang:
-			w = writer.NewWriter(writer.Options{CompilerPath: args.Compiler})
+			w = writer.NewWriter(writer.Options{CompilerPath: args.Compiler, ScaffoldExternal: args.ScaffoldExternal,
+				LocalPrefixes: args.LocalPrefixes, Formatters: args.Formatters})
 		default:
-			return fmt.Errorf("unsupported language: %s", m.Language)
+			// patching needs no writer
+			if !args.Preserve {
+				return fmt.Errorf("unsupported language: %s", m.Language)
+			}
 		}
-		if err := w.WriteModule(repo, mpath, args.OutputDir); err != nil {
+		var written []string
+		if args.Preserve {
+			var err error
+			if written, err = writePreserved(repo, m, w, args.OutputDir); err != nil {
+				return err
+			}
+		} else if err := w.WriteModule(repo, mpath, args.OutputDir); err != nil {
 			return err
 		}
+		if args.Validate && w != nil {
+			errs, err := validate(ctx, w, args.OutputDir, m.Dir)
+			if err != nil {
+				return err
+			}
+			werrs = append(werrs, errs...)
+		}
+		if err := format(ctx, m.Language, w, written, args); err != nil {
+			return err
+		}
+	}
+	if len(werrs) > 0 {
+		return werrs
 	}
 	return nil
 }
+
+// writePreserved writes the source files of the module by patching their original codes, see WriteOptions.Preserve.
+// The files without original codes are created by w and filled with their nodes.
+// It returns the paths of the written files
+func writePreserved(repo *uniast.Repository, mod *uniast.Module, w uniast.Writer, outDir string) ([]string, error) {
+	type fileSpans struct {
+		fls      []uniast.FileLine
+		contents []string
+	}
+	files := map[string]*fileSpans{}
+	var written []string
+	for path := range mod.Files {
+		files[path] = &fileSpans{}
+	}
+	add := func(fl uniast.FileLine, content string) {
+		if fl.File == "" {
+			return
+		}
+		fs := files[fl.File]
+		if fs == nil {
+			fs = &fileSpans{}
+			files[fl.File] = fs
+		}
+		fs.fls = append(fs.fls, fl)
+		fs.contents = append(fs.contents, content)
+	}
+	for _, pkg := range mod.Packages {
+		for _, f := range pkg.Functions {
+			// the codes are in the interface
+			if !f.IsInterfaceMethod {
+				add(f.FileLine, f.Content)
+			}
+		}
+		for _, t := range pkg.Types {
+			add(t.FileLine, t.Content)
+		}
+		for _, v := range pkg.Vars {
+			add(v.FileLine, v.Content)
+		}
+	}
+
+	for path, fs := range files {
+		fi := mod.GetFile(path)
+		if fi == nil {
+			fi = uniast.NewFile(path)
+		}
+		src, err := os.ReadFile(filepath.Join(repo.Path, path))
+		if err != nil {
+			if w == nil {
+				return nil, fmt.Errorf("read file %s failed: %v", path, err)
+			}
+			if src, err = w.CreateFile(fi, mod); err != nil {
+				return nil, fmt.Errorf("create file %s failed: %v", path, err)
+			}
+			for i := range fs.fls {
+				fs.fls[i].StartOffset, fs.fls[i].EndOffset = 0, 0
+			}
+		} else if a, ok := w.(aligner); ok {
+			if err := a.AlignSpans(src, fs.fls, fs.contents); err != nil {
+				log.Warn("not aligning the nodes of %s: %v", path, err)
+			}
+		}
+		spans := make([]patch.Span, len(fs.fls))
+		for i, fl := range fs.fls {
+			spans[i] = patch.Span{Start: fl.StartOffset, End: fl.EndOffset, Content: fs.contents[i]}
+		}
+		data, changed := patch.Rewrite(src, spans)
+		if changed > 0 && w != nil {
+			if data, err = w.PatchImports(fi.Imports, data); err != nil {
+				return nil, fmt.Errorf("patch imports of %s failed: %v", path, err)
+			}
+			if data, err = w.RemoveUnusedImports(data); err != nil {
+				return nil, fmt.Errorf("remove unused imports of %s failed: %v", path, err)
+			}
+		}
+		out := filepath.Join(outDir, path)
+		if err := utils.MustWriteFile(out, data); err != nil {
+			return nil, err
+		}
+		written = append(written, out)
+	}
+	sort.Strings(written)
+	return written, nil
+}
+
+// format formats the files of the module written in the style of WriteOptions.
+// written is nil if the files are written by w, thus w tells them
+func format(ctx context.Context, lang uniast.Language, w uniast.Writer, written []string, args WriteOptions) error {
+	switch lang {
+	case uniast.Golang:
+		f, ok := w.(formatter)
+		if !ok || len(args.LocalPrefixes)+len(args.Formatters) == 0 {
+			return nil
+		}
+		if written == nil {
+			written = f.WrittenFiles()
+		}
+		return f.Format(ctx, filterExt(written, ".go"))
+	case uniast.Rust:
+		if args.RustfmtConfig == "" {
+			return nil
+		}
+		files := filterExt(written, ".rs")
+		if len(files) == 0 {
+			return nil
+		}
+		// the config path is passed as an argument, which may contain spaces
+		return utils.FormatFiles(ctx, "rustfmt", append([]string{"--config-path", args.RustfmtConfig}, files...))
+	}
+	return nil
+}
+
+// filterExt returns the files with the extension
+func filterExt(files []string, ext string) []string {
+	var ret []string
+	for _, f := range files {
+		if filepath.Ext(f) == ext {
+			ret = append(ret, f)
+		}
+	}
+	return ret
+}
+
+// validate compiles the module written in modDir of outDir, and maps the diagnostics back to the nodes
+func validate(ctx context.Context, w uniast.Writer, outDir, modDir string) ([]WriteError, error) {
+	dir := filepath.Join(outDir, modDir)
+	v, ok := w.(validator)
+	if !ok {
+		log.Info("skip validating %s, the writer can't compile", dir)
+		return nil, nil
+	}
+	res, err := runner.NewRunner(nil, runner.Options{
+		Dir:      dir,
+		Commands: []runner.Command{runner.Command(v.CompileCommand())},
+	}).Run(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var ret []WriteError
+	for _, r := range res {
+		if r.Success {
+			continue
+		}
+		if len(r.Diagnostics) == 0 {
+			// no position is reported, like a broken go.mod
+			ret = append(ret, WriteError{Message: strings.TrimSpace(r.Output)})
+			continue
+		}
+		for _, d := range r.Diagnostics {
+			we := WriteError{Message: d.Message, Line: d.Line, File: filepath.Join(modDir, d.File)}
+			if id, line := v.LocateNode(filepath.Join(dir, d.File), d.Line); id != nil {
+				we.NodeID, we.Line = id, line
+			}
+			ret = append(ret, we)
+		}
+	}
+	return ret, nil
+}
@@ -0,0 +1,157 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lang
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/abcoder/lang/golang/parser"
+	"github.com/cloudwego/abcoder/lang/uniast"
+)
+
+func TestWrite_Validate(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go is not installed")
+	}
+	repo := uniast.NewRepository("demo")
+	mod := uniast.NewModule("example.com/demo", ".", uniast.Golang)
+	repo.Modules[mod.Name] = mod
+	pkg := uniast.NewPackage("example.com/demo/a")
+	pkg.Functions["Good"] = &uniast.Function{
+		Identity: uniast.NewIdentity(mod.Name, pkg.PkgPath, "Good"),
+		FileLine: uniast.FileLine{File: "a/a.go", Line: 3},
+		Content:  "func Good() int {\n\treturn 1\n}",
+	}
+	pkg.Functions["Bad"] = &uniast.Function{
+		Identity: uniast.NewIdentity(mod.Name, pkg.PkgPath, "Bad"),
+		FileLine: uniast.FileLine{File: "a/a.go", Line: 7},
+		Content:  "func Bad() int {\n\tx := 1\n\treturn \"x\"\n}",
+	}
+	mod.Packages[pkg.PkgPath] = pkg
+	if err := repo.BuildGraph(); err != nil {
+		t.Fatal(err)
+	}
+
+	out := t.TempDir()
+	err := Write(context.Background(), &repo, WriteOptions{OutputDir: out, Validate: true})
+	var werrs WriteErrors
+	if !errors.As(err, &werrs) {
+		t.Fatalf("Write() = %v, want WriteErrors", err)
+	}
+	bad := uniast.NewIdentity(mod.Name, pkg.PkgPath, "Bad")
+	lines := map[int]bool{}
+	for _, we := range werrs {
+		if we.NodeID == nil || *we.NodeID != bad || we.File != "a/a.go" {
+			t.Errorf("error is not mapped to Bad: %+v", we)
+			continue
+		}
+		lines[we.Line] = true
+	}
+	// `x declared and not used` and `cannot use "x"`
+	if !lines[2] || !lines[3] {
+		t.Errorf("errors = %+v", werrs)
+	}
+
+	delete(pkg.Functions, "Bad")
+	if err := Write(context.Background(), &repo, WriteOptions{OutputDir: t.TempDir(), Validate: true}); err != nil {
+		t.Errorf("Write() = %v", err)
+	}
+}
+
+func TestWrite_Preserve(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go is not installed")
+	}
+	dir := t.TempDir()
+	src := `package a
+
+import "fmt"
+
+// Kind is a kind
+type Kind int
+
+const (
+	// A is a
+	A Kind = iota
+	B
+)
+
+type (
+	// T is t
+	T struct{ N int }
+	U = int
+)
+
+var (
+	X    = 1
+	Y, Z = 2, 3
+)
+
+// Hello says hello
+func Hello(name string) {
+	greet := func() {
+		fmt.Println("hello", name)
+	}
+	greet()
+}
+
+func   Bye() { fmt.Println("bye") }
+`
+	for name, content := range map[string]string{"go.mod": "module example.com/p\n\ngo 1.21\n", "a/a.go": src} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	repo, err := parser.NewParser(dir, dir, parser.Options{CollectComment: true, ClosureMinLines: 3}).ParseRepo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo.Path = dir
+	id := func(name string) uniast.Identity { return uniast.NewIdentity("example.com/p", "example.com/p/a", name) }
+
+	// nothing changed
+	out := t.TempDir()
+	if err := Write(context.Background(), &repo, WriteOptions{OutputDir: out, Preserve: true}); err != nil {
+		t.Fatal(err)
+	}
+	if bs, _ := os.ReadFile(filepath.Join(out, "a/a.go")); string(bs) != src {
+		t.Fatalf("unchanged file is rewritten:\n%s", bs)
+	}
+
+	hello := repo.GetFunction(id("Hello"))
+	hello.Content = strings.Replace(hello.Content, `"hello"`, `"hi"`, 1)
+	u := repo.GetType(id("U"))
+	u.Content = strings.Replace(u.Content, "int", "int64", 1)
+	out = t.TempDir()
+	if err := Write(context.Background(), &repo, WriteOptions{OutputDir: out, Preserve: true}); err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Replace(strings.Replace(src, `"hello"`, `"hi"`, 1), "U = int", "U = int64", 1)
+	if bs, _ := os.ReadFile(filepath.Join(out, "a/a.go")); string(bs) != want {
+		t.Errorf("written file:\n%s\nwant:\n%s", bs, want)
+	}
+}
@@ -19,6 +19,7 @@ package agent
 import (
 	"context"
 	_ "embed"
+	"strings"
 
 	"github.com/cloudwego/abcoder/lang/log"
 	"github.com/cloudwego/abcoder/llm"
@@ -33,22 +34,54 @@ type RepoAnnalyzerOptions struct {
 	llm.ModelConfig
 	MaxSteps int    `json:"max_steps"`
 	ASTsDir  string `json:"asts_dir"`
+	// Runner enables the build/test tools if not nil
+	Runner *tool.RunnerToolsOptions `json:"runner,omitempty"`
+	// ToolCache caches the results of the AST tools if not nil
+	ToolCache *ToolCache `json:"-"`
+	// TokenBudget limits the tokens of the codes returned by get_ast_node, no limit if 0
+	TokenBudget int `json:"token_budget,omitempty"`
+	// Repos are the repos to reason across, all repos if empty
+	Repos []string `json:"repos,omitempty"`
+	// Retrieved records the nodes fetched by get_ast_node if not nil
+	Retrieved *RetrievedNodes `json:"-"`
+	// AST are the AST tools shared with the caller (e.g. to verify citations), created from ASTsDir if nil
+	AST *tool.ASTReadTools `json:"-"`
+	// Usage records the tokens and the cost of the model calls if not nil, which fail once its budget is spent
+	Usage *llm.Usage `json:"-"`
+	// ProposePatches asks the tasks to propose the code changes of their findings, see TaskReport.Proposal
+	ProposePatches bool `json:"propose_patches,omitempty"`
+}
+
+func newASTReadTools(opts RepoAnnalyzerOptions) *tool.ASTReadTools {
+	return tool.NewASTReadTools(tool.ASTReadToolsOptions{
+		RepoASTsDir: opts.ASTsDir,
+		TokenBudget: opts.TokenBudget,
+		Repos:       opts.Repos,
+	})
 }
 
 func NewRepoAnalyzer(ctx context.Context, opts RepoAnnalyzerOptions) *llm.ReactAgent {
 	log.Debug("NewRepoAnalyzer, opts: %+v", opts)
 
-	exeModel := llm.NewChatModel(opts.ModelConfig)
-	ast := tool.NewASTReadTools(tool.ASTReadToolsOptions{
-		RepoASTsDir: opts.ASTsDir,
-	})
+	exeModel := llm.NewMeteredChatModel(opts.ModelConfig, opts.Usage)
+	ast := opts.AST
+	if ast == nil {
+		ast = newASTReadTools(opts)
+	}
 
 	// AST tools
 	ts := ast.GetTools()
 	log.Debug("NewRepoAnalyzer, get AST tools: %#v", ts)
 	tcfg := compose.ToolsNodeConfig{}
 	for _, t := range ts {
-		tcfg.Tools = append(tcfg.Tools, t.(etool.BaseTool))
+		tcfg.Tools = append(tcfg.Tools, WithRetrievedNodes(WithToolCache(t.(etool.BaseTool), opts.ToolCache), opts.Retrieved))
+	}
+
+	// Build/test tools
+	if opts.Runner != nil {
+		for _, t := range tool.NewRunnerTools(ast, *opts.Runner).GetTools() {
+			tcfg.Tools = append(tcfg.Tools, t.(etool.BaseTool))
+		}
 	}
 
 	// Sequential thinking tools
@@ -62,11 +95,29 @@ func NewRepoAnalyzer(ctx context.Context, opts RepoAnnalyzerOptions) *llm.ReactA
 	}
 
 	return llm.NewReactAgent("repo-analyzer", llm.ReactAgentOptions{
-		SysPrompt: prompt.NewTextPrompt(prompt.PromptAnalyzeRepo),
+		SysPrompt: prompt.NewTextPrompt(analyzerPrompt(opts.Repos)),
 		AgentConfig: &react.AgentConfig{
 			ToolCallingModel: exeModel,
 			ToolsConfig:      tcfg,
 			MaxStep:          opts.MaxSteps,
+			// the answer is streamed in the REPL
+			StreamToolCallChecker: llm.StreamToolCallChecker(opts.ModelConfig.APIType),
 		},
 	})
 }
+
+// analyzerPrompt tells the repos to reason across, if any
+func analyzerPrompt(repos []string) string {
+	if len(repos) == 0 {
+		return prompt.PromptAnalyzeRepo
+	}
+	var sb strings.Builder
+	sb.WriteString(prompt.PromptAnalyzeRepo)
+	sb.WriteString("\n\n# Repositories\nThe question concerns these repositories together. Search symbols across them by `find_symbol_across_repos`, and tell the repo_name of each node in the answer:\n")
+	for _, repo := range repos {
+		sb.WriteString("- ")
+		sb.WriteString(repo)
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
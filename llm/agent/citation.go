@@ -0,0 +1,146 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/abcoder/llm/log"
+	"github.com/cloudwego/abcoder/llm/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+// citationsFence opens the block of the machine-readable citations at the end of an answer, see analyzer.md
+const citationsFence = "```citations"
+
+// DefaultCitationRevisions is how many times the model is asked to fix the rejected citations of an answer
+const DefaultCitationRevisions = 1
+
+// findCitations returns the range of the last citations block in the answer, from the fence to the closing one.
+// start is -1 if there is no such block
+func findCitations(answer string) (start, end int) {
+	start = strings.LastIndex(answer, citationsFence)
+	if start < 0 {
+		return -1, -1
+	}
+	body := start + len(citationsFence)
+	if i := strings.Index(answer[body:], "```"); i >= 0 {
+		return start, body + i + 3
+	}
+	return start, len(answer)
+}
+
+// ParseCitations parses the citations block of the answer, which is a JSON array of tool.Citation.
+// ok is false if there is no such block
+func ParseCitations(answer string) (cits []tool.Citation, ok bool, err error) {
+	start, end := findCitations(answer)
+	if start < 0 {
+		return nil, false, nil
+	}
+	body := strings.TrimSuffix(answer[start+len(citationsFence):end], "```")
+	if err := json.Unmarshal([]byte(strings.TrimSpace(body)), &cits); err != nil {
+		return nil, true, fmt.Errorf("the citations block is not a JSON array of citations: %v", err)
+	}
+	return cits, true, nil
+}
+
+// FormatCitations replaces the citations block of the answer with the citations, or appends one if absent
+func FormatCitations(answer string, cits []tool.Citation) string {
+	if cits == nil {
+		cits = []tool.Citation{}
+	}
+	bs, _ := json.MarshalIndent(cits, "", "  ")
+	block := citationsFence + "\n" + string(bs) + "\n```"
+	if start, end := findCitations(answer); start >= 0 {
+		return answer[:start] + block + answer[end:]
+	}
+	return strings.TrimRight(answer, "\n") + "\n\n" + block
+}
+
+// Grounder verifies the citations of the answers against the ASTs,
+// thus every node an answer is based on is traceable
+type Grounder struct {
+	ast *tool.ASTReadTools
+	// Revisions is how many times the model is asked to fix the rejected citations
+	Revisions int
+}
+
+func NewGrounder(ast *tool.ASTReadTools) *Grounder {
+	return &Grounder{ast: ast, Revisions: DefaultCitationRevisions}
+}
+
+// Verify returns the valid citations of the answer, and the reasons why the others are rejected
+func (g *Grounder) Verify(answer string) (valid []tool.Citation, rejected []string) {
+	cits, ok, err := ParseCitations(answer)
+	if !ok {
+		return nil, []string{"the answer has no citations block"}
+	}
+	if err != nil {
+		return nil, []string{err.Error()}
+	}
+	for _, c := range cits {
+		if err := g.ast.VerifyCitation(&c); err != nil {
+			rejected = append(rejected, fmt.Sprintf("%s: %v", c, err))
+			continue
+		}
+		valid = append(valid, c)
+	}
+	return valid, rejected
+}
+
+// Ground verifies the citations of the answer to msgs. While some are rejected, revise is called
+// with the rejection feedback appended to the conversation, to generate the answer again.
+// The citations block of the returned answer only keeps the valid citations, the reasons of the dropped ones are returned
+func (g *Grounder) Ground(ctx context.Context, msgs []*schema.Message, answer *schema.Message,
+	revise func(ctx context.Context, msgs []*schema.Message, rejected []string) (*schema.Message, error)) (*schema.Message, []tool.Citation, []string) {
+	valid, rejected := g.Verify(answer.Content)
+	for i := 0; i < g.Revisions && len(rejected) > 0; i++ {
+		log.Info("revise the answer for the rejected citations: %v", rejected)
+		feedback := append(append(msgs[:len(msgs):len(msgs)], answer), schema.UserMessage(citationFeedback(rejected)))
+		revised, err := revise(ctx, feedback, rejected)
+		if err != nil || revised == nil || revised.Content == "" {
+			log.Error("revise the answer failed: %v", err)
+			break
+		}
+		answer = revised
+		valid, rejected = g.Verify(answer.Content)
+	}
+	if len(rejected) > 0 {
+		log.Info("drop the rejected citations: %v", rejected)
+		if _, ok, _ := ParseCitations(answer.Content); ok {
+			grounded := *answer
+			grounded.Content = FormatCitations(answer.Content, valid)
+			answer = &grounded
+		}
+	}
+	return answer, valid, rejected
+}
+
+func citationFeedback(rejected []string) string {
+	var sb strings.Builder
+	sb.WriteString("The citations of your answer are rejected by the verification against the ASTs:\n")
+	for _, r := range rejected {
+		sb.WriteString("- ")
+		sb.WriteString(r)
+		sb.WriteString("\n")
+	}
+	sb.WriteString("Check the nodes and their file locations by the tools, then output the whole answer again, ending with a corrected `citations` block.")
+	return sb.String()
+}
@@ -0,0 +1,79 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/abcoder/llm/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestGrounder(t *testing.T) {
+	dir := t.TempDir()
+	bs, err := os.ReadFile("../../testdata/asts/localsession.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "localsession.json"), bs, 0644); err != nil {
+		t.Fatal(err)
+	}
+	g := NewGrounder(tool.NewASTReadTools(tool.ASTReadToolsOptions{RepoASTsDir: dir}))
+
+	valid := `{"mod_path": "github.com/cloudwego/localsession", "pkg_path": "github.com/cloudwego/localsession", "name": "BindSession", "file": "gls.go", "start_line": 115, "end_line": 118}`
+	missing := `{"mod_path": "github.com/cloudwego/localsession", "pkg_path": "github.com/cloudwego/localsession", "name": "NoSuchFunc", "file": "gls.go", "start_line": 1}`
+	outside := `{"mod_path": "github.com/cloudwego/localsession", "pkg_path": "github.com/cloudwego/localsession", "name": "BindSession", "file": "gls.go", "start_line": 100}`
+	answer := "BindSession binds the session.\n\n```citations\n[" + valid + ", " + missing + ", " + outside + "]\n```\n"
+
+	cits, rejected := g.Verify(answer)
+	if len(cits) != 1 || cits[0].Name != "BindSession" || !strings.HasSuffix(cits[0].RepoName, "localsession") {
+		t.Errorf("valid citations = %+v", cits)
+	}
+	if len(rejected) != 2 || !strings.Contains(rejected[0], "not found") || !strings.Contains(rejected[1], "out of node") {
+		t.Errorf("rejected = %v", rejected)
+	}
+	if _, rejected := g.Verify("no citations"); len(rejected) != 1 {
+		t.Errorf("the answer without citations should be rejected")
+	}
+
+	// the revised answer is verified again
+	msgs := []*schema.Message{schema.UserMessage("what does BindSession do?")}
+	var feedback string
+	grounded, cits, rejected := g.Ground(context.Background(), msgs, schema.AssistantMessage(answer, nil),
+		func(_ context.Context, msgs []*schema.Message, _ []string) (*schema.Message, error) {
+			feedback = msgs[len(msgs)-1].Content
+			return schema.AssistantMessage("BindSession binds it.\n```citations\n["+valid+"]\n```", nil), nil
+		})
+	if !strings.Contains(feedback, "NoSuchFunc") || len(cits) != 1 || len(rejected) != 0 || !strings.HasPrefix(grounded.Content, "BindSession binds it.") {
+		t.Errorf("grounded = %q, citations = %+v, rejected = %v, feedback = %q", grounded.Content, cits, rejected, feedback)
+	}
+
+	// the citations still rejected after the revisions are dropped
+	g.Revisions = 0
+	grounded, cits, rejected = g.Ground(context.Background(), msgs, schema.AssistantMessage(answer, nil), nil)
+	if len(rejected) != 2 {
+		t.Errorf("rejected = %v", rejected)
+	}
+	parsed, ok, err := ParseCitations(grounded.Content)
+	if !ok || err != nil || len(parsed) != 1 || parsed[0] != cits[0] || !strings.HasPrefix(grounded.Content, "BindSession binds the session.") {
+		t.Errorf("grounded = %q", grounded.Content)
+	}
+}
@@ -17,14 +17,16 @@
 package agent
 
 import (
-	"bufio"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 
 	"github.com/cloudwego/abcoder/llm"
 	"github.com/cloudwego/abcoder/llm/log"
+	"github.com/cloudwego/abcoder/llm/tool"
 	"github.com/cloudwego/eino/compose"
 	"github.com/cloudwego/eino/flow/agent"
 	"github.com/cloudwego/eino/schema"
@@ -35,28 +37,80 @@ type AgentOptions struct {
 	MaxHistories int
 	MaxSteps     int
 	Model        llm.ModelConfig
+	Runner       *tool.RunnerToolsOptions
+	// SessionDir is where the sessions are persisted, default to DefaultSessionDir()
+	SessionDir string
+	// Resume is the id of the session to continue, a new session is created if empty
+	Resume string
+	// TokenBudget limits the tokens of the codes returned by get_ast_node, no limit if 0
+	TokenBudget int
+	// Repos are the repos to reason across, like a service and its client SDK. All repos if empty
+	Repos []string
+	// Budget stops the agent once the tokens or the cost of the model calls exceed it, no limit if zero
+	Budget llm.Budget
 }
 
 type Agent struct {
 	opts      AgentOptions
 	analyzer  *llm.ReactAgent
+	ast       *tool.ASTReadTools
+	grounder  *Grounder
 	histories *Histories
+	session   *Session
+	usage     *llm.Usage
 }
 
 // run agent as a repl cmd server
-func NewAgent(opts AgentOptions) *Agent {
-	ag := NewRepoAnalyzer(context.Background(), RepoAnnalyzerOptions{
+func NewAgent(opts AgentOptions) (*Agent, error) {
+	if opts.SessionDir == "" {
+		opts.SessionDir = DefaultSessionDir()
+	}
+	session := NewSession(opts.ASTsDir)
+	if opts.Resume != "" {
+		var err error
+		if session, err = LoadSession(opts.SessionDir, opts.Resume, opts.ASTsDir); err != nil {
+			return nil, err
+		}
+	}
+
+	aopts := RepoAnnalyzerOptions{
 		ASTsDir:     opts.ASTsDir,
 		MaxSteps:    opts.MaxSteps,
 		ModelConfig: opts.Model,
-	})
+		Runner:      opts.Runner,
+		ToolCache:   session.ToolResults,
+		TokenBudget: opts.TokenBudget,
+		Repos:       opts.Repos,
+		Usage:       llm.NewUsage(opts.Budget),
+	}
+	aopts.AST = newASTReadTools(aopts)
+	ag := NewRepoAnalyzer(context.Background(), aopts)
 
 	histories := NewHistories(opts.MaxHistories)
+	for _, msg := range session.Histories {
+		histories.Add(msg)
+	}
 
 	return &Agent{
 		opts:      opts,
 		analyzer:  ag,
+		ast:       aopts.AST,
+		grounder:  NewGrounder(aopts.AST),
 		histories: histories,
+		session:   session,
+		usage:     aopts.Usage,
+	}, nil
+}
+
+// SessionID returns the id of current session, which can be resumed by `--resume`
+func (a *Agent) SessionID() string {
+	return a.session.ID
+}
+
+func (a *Agent) saveSession() {
+	a.session.Histories = a.histories.Get()
+	if err := a.session.Save(a.opts.SessionDir); err != nil {
+		log.Error("Failed to save session %s: %v\n", a.session.ID, err)
 	}
 }
 
@@ -64,20 +118,70 @@ func (a *Agent) Generate(ctx context.Context, msgs []*schema.Message) (*schema.M
 	return a.analyzer.Generate(ctx, msgs, agent.WithComposeOptions(compose.WithCallbacks(llm.CallbackHandler{})))
 }
 
-func (a *Agent) Run(ctx context.Context) {
-	fmt.Fprintf(os.Stdout, "Hello! I'm ABCoder, your coding assistant. What can I do for you today?\n")
+// Stream generates the answer like Generate, but writes its tokens to w as they arrive.
+// The partial answer is returned together with the error if the stream is broken
+func (a *Agent) Stream(ctx context.Context, msgs []*schema.Message, w io.Writer) (*schema.Message, error) {
+	return a.analyzer.StreamGenerate(ctx, msgs, func(chunk *schema.Message) {
+		fmt.Fprint(w, chunk.Content)
+	}, agent.WithComposeOptions(compose.WithCallbacks(llm.CallbackHandler{})))
+}
 
-	sc := bufio.NewScanner(os.Stdin)
+// ground verifies the citations of the streamed answer, the revised answer is streamed to w as well
+func (a *Agent) ground(ctx context.Context, msgs []*schema.Message, resp *schema.Message, w io.Writer) *schema.Message {
+	grounded, _, rejected := a.grounder.Ground(ctx, msgs, resp, func(ctx context.Context, msgs []*schema.Message, rejected []string) (*schema.Message, error) {
+		fmt.Fprintf(w, "\n(%d citations rejected, revising the answer: %s)\n\n", len(rejected), strings.Join(rejected, "; "))
+		revised, err := a.Stream(ctx, msgs, w)
+		fmt.Fprintln(w)
+		return revised, err
+	})
+	if len(rejected) > 0 {
+		fmt.Fprintf(w, "(unverified citations are dropped: %s)\n", strings.Join(rejected, "; "))
+	}
+	return grounded
+}
 
-	for sc.Scan() {
+func (a *Agent) Run(ctx context.Context) {
+	if len(a.session.Histories) > 0 {
+		fmt.Fprintf(os.Stdout, "Welcome back! Resumed session %s with %d histories.\n", a.session.ID, len(a.session.Histories))
+	} else {
+		fmt.Fprintf(os.Stdout, "Hello! I'm ABCoder, your coding assistant. What can I do for you today?\n")
+	}
+	fmt.Fprintf(os.Stdout, "(session: %s, continue it later with `--resume %s`, type /help for the commands)\n", a.session.ID, a.session.ID)
 
-		query := strings.TrimSpace(sc.Text())
+	// the usage of this run is summarized at the end
+	defer func() {
+		fmt.Fprintf(os.Stdout, "(usage: %s)\n", a.usage.Stats())
+	}()
+
+	in := newLineReader()
+	for {
+		line, err := in.ReadLine()
+		if err != nil {
+			if err != io.EOF {
+				log.Error("Failed to read input: %v\n", err)
+			}
+			break
+		}
+		query := strings.TrimSpace(line)
 		if query == "" {
 			continue
 		}
 		if query == "exit" {
 			break
 		}
+		if strings.HasPrefix(query, "/") {
+			if err := a.command(ctx, query, os.Stdout); err == errExit {
+				break
+			} else if err != nil {
+				fmt.Fprintf(os.Stdout, "error: %v\n", err)
+			}
+			continue
+		}
+
+		if err := a.usage.Exceeded(); err != nil {
+			fmt.Fprintf(os.Stdout, "stopped: %v\n", err)
+			break
+		}
 
 		// get histories
 		a.histories.Add(&schema.Message{
@@ -85,15 +189,30 @@ func (a *Agent) Run(ctx context.Context) {
 			Content: query,
 		})
 
-		resp, err := a.Generate(ctx, a.histories.Get())
+		fmt.Fprintln(os.Stdout)
+		resp, err := a.Stream(ctx, a.histories.Get(), os.Stdout)
+		fmt.Fprintln(os.Stdout)
+		if errors.Is(err, llm.ErrBudgetExceeded) {
+			// stop gracefully, keeping the partial answer if any
+			fmt.Fprintf(os.Stdout, "stopped: %v\n", err)
+			if resp != nil && resp.Content != "" {
+				a.histories.Add(resp)
+			}
+			a.saveSession()
+			break
+		}
 		if err != nil {
 			log.Error("Failed to run agent: %v\n", err)
-			continue
+			if resp == nil || resp.Content == "" {
+				continue
+			}
+			// keep the partial answer, thus it can be continued
+		} else {
+			resp = a.ground(ctx, a.histories.Get(), resp, os.Stdout)
 		}
 
 		a.histories.Add(resp)
-
-		fmt.Fprintf(os.Stdout, "\n%s\n", resp.Content)
+		a.saveSession()
 	}
 }
 
@@ -0,0 +1,221 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/cloudwego/abcoder/lang/uniast"
+	"github.com/cloudwego/abcoder/llm"
+	"github.com/cloudwego/abcoder/llm/log"
+	"github.com/cloudwego/abcoder/llm/tool"
+	etool "github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/flow/agent"
+	"github.com/cloudwego/eino/schema"
+	"gopkg.in/yaml.v3"
+)
+
+// EvalCase is a question and the nodes which should be retrieved to answer it
+type EvalCase struct {
+	Question string `yaml:"question" json:"question"`
+	// Expected are the identities of the nodes, formatted as `ModPath?PkgPath#Name`
+	Expected []string `yaml:"expected" json:"expected"`
+}
+
+// EvalSuite is a suite of evaluation cases, written in YAML:
+//
+//	cases:
+//	  - question: How does the server dispatch a request?
+//	    expected:
+//	      - github.com/a/b?github.com/a/b/server#Server.Dispatch
+type EvalSuite struct {
+	Cases []EvalCase `yaml:"cases" json:"cases"`
+}
+
+// LoadEvalSuite reads the suite from the YAML file
+func LoadEvalSuite(path string) (*EvalSuite, error) {
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var suite EvalSuite
+	if err := yaml.Unmarshal(bs, &suite); err != nil {
+		return nil, fmt.Errorf("parse eval suite %s failed: %v", path, err)
+	}
+	if len(suite.Cases) == 0 {
+		return nil, fmt.Errorf("no cases in eval suite %s", path)
+	}
+	for i, c := range suite.Cases {
+		if c.Question == "" || len(c.Expected) == 0 {
+			return nil, fmt.Errorf("case %d of eval suite %s must have a question and expected nodes", i+1, path)
+		}
+	}
+	return &suite, nil
+}
+
+// EvalResult is the result of a case
+type EvalResult struct {
+	Question  string   `json:"question"`
+	Expected  []string `json:"expected"`
+	Retrieved []string `json:"retrieved"`
+	// Precision is the ratio of the retrieved nodes which are expected
+	Precision float64 `json:"precision"`
+	// Recall is the ratio of the expected nodes which are retrieved
+	Recall float64 `json:"recall"`
+	Answer string  `json:"answer,omitempty"`
+	// Citations are the verified citations of the answer
+	Citations []tool.Citation `json:"citations,omitempty"`
+	// RejectedCitations are the reasons why the other citations are rejected
+	RejectedCitations []string `json:"rejected_citations,omitempty"`
+	Error             string   `json:"error,omitempty"`
+}
+
+// EvalReport is the results of a suite, with the precision and recall averaged over the cases
+type EvalReport struct {
+	Results   []EvalResult `json:"results"`
+	Precision float64      `json:"precision"`
+	Recall    float64      `json:"recall"`
+}
+
+// Evaluate asks the questions of the suite one by one without histories,
+// and scores the nodes the agent retrieved by get_ast_node against the expected ones
+func Evaluate(ctx context.Context, opts RepoAnnalyzerOptions, suite *EvalSuite) *EvalReport {
+	retrieved := NewRetrievedNodes()
+	opts.Retrieved = retrieved
+	if opts.AST == nil {
+		opts.AST = newASTReadTools(opts)
+	}
+	ag := NewRepoAnalyzer(ctx, opts)
+	grounder := NewGrounder(opts.AST)
+
+	report := &EvalReport{}
+	for i, c := range suite.Cases {
+		log.Info("eval case %d/%d: %s", i+1, len(suite.Cases), c.Question)
+		retrieved.Reset()
+		res := EvalResult{Question: c.Question, Expected: c.Expected}
+		generate := func(ctx context.Context, msgs []*schema.Message, _ []string) (*schema.Message, error) {
+			return ag.Generate(ctx, msgs, agent.WithComposeOptions(compose.WithCallbacks(llm.CallbackHandler{})))
+		}
+		msgs := []*schema.Message{schema.UserMessage(c.Question)}
+		msg, err := generate(ctx, msgs, nil)
+		if err != nil {
+			res.Error = err.Error()
+		} else {
+			msg, res.Citations, res.RejectedCitations = grounder.Ground(ctx, msgs, msg, generate)
+			res.Answer = msg.Content
+		}
+		res.Retrieved = retrieved.List()
+		res.Precision, res.Recall = scoreNodes(c.Expected, res.Retrieved)
+		report.Results = append(report.Results, res)
+		report.Precision += res.Precision
+		report.Recall += res.Recall
+	}
+	report.Precision /= float64(len(report.Results))
+	report.Recall /= float64(len(report.Results))
+	return report
+}
+
+// scoreNodes compares the identities, which are normalized as `ModPath?PkgPath#Name`
+func scoreNodes(expected, retrieved []string) (precision, recall float64) {
+	want := map[string]bool{}
+	for _, id := range expected {
+		want[uniast.NewIdentityFromString(id).Full()] = true
+	}
+	hit := 0
+	for _, id := range retrieved {
+		if want[uniast.NewIdentityFromString(id).Full()] {
+			hit++
+		}
+	}
+	if len(retrieved) > 0 {
+		precision = float64(hit) / float64(len(retrieved))
+	}
+	if len(want) > 0 {
+		recall = float64(hit) / float64(len(want))
+	}
+	return
+}
+
+// RetrievedNodes records the nodes fetched by get_ast_node, in the order of the first retrieval
+type RetrievedNodes struct {
+	mu    sync.Mutex
+	seen  map[string]bool
+	nodes []string
+}
+
+func NewRetrievedNodes() *RetrievedNodes {
+	return &RetrievedNodes{seen: map[string]bool{}}
+}
+
+func (r *RetrievedNodes) Add(id uniast.Identity) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := id.Full()
+	if !r.seen[key] {
+		r.seen[key] = true
+		r.nodes = append(r.nodes, key)
+	}
+}
+
+func (r *RetrievedNodes) List() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.nodes...)
+}
+
+func (r *RetrievedNodes) Reset() {
+	r.mu.Lock()
+	r.seen = map[string]bool{}
+	r.nodes = nil
+	r.mu.Unlock()
+}
+
+// recordedTool records the nodes requested to get_ast_node
+type recordedTool struct {
+	etool.InvokableTool
+	nodes *RetrievedNodes
+}
+
+// WithRetrievedNodes wraps the invokable tool t to record the nodes it retrieves, other tools are returned as is
+func WithRetrievedNodes(t etool.BaseTool, nodes *RetrievedNodes) etool.BaseTool {
+	it, ok := t.(etool.InvokableTool)
+	if !ok || nodes == nil {
+		return t
+	}
+	return recordedTool{InvokableTool: it, nodes: nodes}
+}
+
+func (t recordedTool) InvokableRun(ctx context.Context, args string, opts ...etool.Option) (string, error) {
+	info, err := t.Info(ctx)
+	if err != nil {
+		return "", err
+	}
+	if info.Name == tool.ToolGetASTNode {
+		var req tool.GetASTNodeReq
+		if err := json.Unmarshal([]byte(args), &req); err == nil {
+			for _, nid := range req.NodeIDs {
+				t.nodes.Add(nid.Identity())
+			}
+		}
+	}
+	return t.InvokableTool.InvokableRun(ctx, args, opts...)
+}
@@ -0,0 +1,93 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/cloudwego/abcoder/llm/tool"
+	etool "github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+type astNodeTool struct{}
+
+func (astNodeTool) Info(context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{Name: tool.ToolGetASTNode}, nil
+}
+
+func (astNodeTool) InvokableRun(context.Context, string, ...etool.Option) (string, error) {
+	return "{}", nil
+}
+
+func TestLoadEvalSuite(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "suite.yaml")
+	if err := os.WriteFile(path, []byte(`cases:
+  - question: How is a request dispatched?
+    expected:
+      - m?m/server#Server.Dispatch
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	suite, err := LoadEvalSuite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []EvalCase{{Question: "How is a request dispatched?", Expected: []string{"m?m/server#Server.Dispatch"}}}
+	if !reflect.DeepEqual(suite.Cases, want) {
+		t.Errorf("cases = %+v", suite.Cases)
+	}
+
+	if err := os.WriteFile(path, []byte("cases:\n  - question: no expected nodes\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadEvalSuite(path); err == nil {
+		t.Error("case without expected nodes should be invalid")
+	}
+}
+
+func TestRetrievedNodes(t *testing.T) {
+	nodes := NewRetrievedNodes()
+	tt := WithRetrievedNodes(astNodeTool{}, nodes).(etool.InvokableTool)
+	for _, args := range []string{
+		`{"repo_name":"r","node_ids":[{"mod_path":"m","pkg_path":"m/a","name":"A"},{"mod_path":"m","pkg_path":"m/a","name":"B"}]}`,
+		`{"repo_name":"r","node_ids":[{"mod_path":"m","pkg_path":"m/a","name":"A"}]}`,
+	} {
+		if _, err := tt.InvokableRun(context.Background(), args); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got := nodes.List()
+	if want := []string{"m?m/a#A", "m?m/a#B"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("retrieved = %v, want %v", got, want)
+	}
+
+	precision, recall := scoreNodes([]string{"m?m/a#A", "m?m/a#C"}, got)
+	if precision != 0.5 || recall != 0.5 {
+		t.Errorf("scoreNodes() = %v, %v", precision, recall)
+	}
+
+	nodes.Reset()
+	if got := nodes.List(); len(got) != 0 {
+		t.Errorf("retrieved after reset = %v", got)
+	}
+}
@@ -0,0 +1,249 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/cloudwego/abcoder/llm/tool"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// patchesFence opens the block of the proposed code changes at the end of a task answer
+const patchesFence = "```patches"
+
+// patchesInstruction is appended to the prompts of the tasks which propose patches
+const patchesInstruction = `
+
+# Patches
+For the findings worth fixing, propose the code changes in a ` + "`patches`" + ` fenced block (after the report block, before the citations block), which is a JSON array like:
+
+` + "```patches" + `
+[
+  {
+    "repo_name": "the repository",
+    "node_id": {"mod_path": "...", "pkg_path": "...", "name": "..."},
+    "codes": "the complete new codes of the node, including its comments, which replace the current ones",
+    "findings": ["the titles of the findings fixed by the change"],
+    "rationale": "why the change fixes them"
+  }
+]
+` + "```" + `
+
+Only change the nodes you have read by the tools, one item per node. Output an empty array if nothing should be changed.`
+
+// NodeEdit is a code change proposed by the agent, which replaces the codes of a node
+type NodeEdit struct {
+	RepoName  string      `json:"repo_name,omitempty"`
+	NodeID    tool.NodeID `json:"node_id"`
+	Codes     string      `json:"codes"`
+	Findings  []string    `json:"findings,omitempty"`
+	Rationale string      `json:"rationale,omitempty"`
+}
+
+// PatchProposal is the code changes proposed by a task for human review, see WriteTo
+type PatchProposal struct {
+	Task    string      `json:"task"`
+	Summary string      `json:"summary,omitempty"`
+	Files   []FilePatch `json:"files"`
+	// Rejected are the reasons why the other edits can't be turned into patches
+	Rejected []string `json:"rejected,omitempty"`
+}
+
+// FilePatch is the unified diff of a file, each hunk of which is explained by a rationale
+type FilePatch struct {
+	RepoName string `json:"repo_name"`
+	File     string `json:"file"`
+	// Diff is the path of the unified diff relative to the output dir
+	Diff  string          `json:"diff"`
+	Hunks []HunkRationale `json:"hunks"`
+	// Unified is the unified diff of the file, written to Diff
+	Unified string `json:"-"`
+}
+
+// HunkRationale maps a hunk of the diff to the node it changes and the findings it fixes
+type HunkRationale struct {
+	// Header is the header of the hunk, like `@@ -10,4 +10,5 @@`
+	Header    string      `json:"header"`
+	NodeID    tool.NodeID `json:"node_id"`
+	Findings  []string    `json:"findings,omitempty"`
+	Rationale string      `json:"rationale,omitempty"`
+}
+
+// parsePatches parses the patches block of the answer, ok is false if there is no such block
+func parsePatches(answer string) (edits []NodeEdit, ok bool, err error) {
+	start := strings.LastIndex(answer, patchesFence)
+	if start < 0 {
+		return nil, false, nil
+	}
+	body := answer[start+len(patchesFence):]
+	if end := strings.Index(body, "```"); end >= 0 {
+		body = body[:end]
+	}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(body)), &edits); err != nil {
+		return nil, true, fmt.Errorf("the patches block is not a JSON array of patches: %v", err)
+	}
+	return edits, true, nil
+}
+
+// located is an edit with the current location of its node
+type located struct {
+	NodeEdit
+	line int
+	olds []string
+}
+
+// ProposePatches turns the edits into the unified diffs of the files, by replacing the codes of the nodes in the ASTs.
+// The edits of unknown nodes, without changes or overlapping others are rejected
+func ProposePatches(ast *tool.ASTReadTools, task string, summary string, edits []NodeEdit) *PatchProposal {
+	ret := &PatchProposal{Task: task, Summary: summary, Files: []FilePatch{}}
+	type fileKey struct{ repo, file string }
+	files := map[fileKey][]located{}
+	for _, e := range edits {
+		id := e.NodeID.Identity().Full()
+		name, err := ast.ResolveRepo(e.RepoName)
+		if err != nil {
+			ret.Rejected = append(ret.Rejected, fmt.Sprintf("%s: %v", id, err))
+			continue
+		}
+		repo, err := ast.GetRepo(name)
+		if err != nil {
+			ret.Rejected = append(ret.Rejected, fmt.Sprintf("%s: %v", id, err))
+			continue
+		}
+		node := repo.GetNode(e.NodeID.Identity())
+		if node == nil {
+			ret.Rejected = append(ret.Rejected, fmt.Sprintf("%s: node not found in repo %s", id, name))
+			continue
+		}
+		fl := node.FileLine()
+		if fl.File == "" {
+			ret.Rejected = append(ret.Rejected, fmt.Sprintf("%s: node has no source location", id))
+			continue
+		}
+		if node.Content() == e.Codes {
+			ret.Rejected = append(ret.Rejected, fmt.Sprintf("%s: the codes are not changed", id))
+			continue
+		}
+		e.RepoName = name
+		k := fileKey{name, filepath.ToSlash(fl.File)}
+		files[k] = append(files[k], located{NodeEdit: e, line: fl.Line, olds: splitLines(node.Content())})
+	}
+
+	keys := make([]fileKey, 0, len(files))
+	for k := range files {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].repo != keys[j].repo {
+			return keys[i].repo < keys[j].repo
+		}
+		return keys[i].file < keys[j].file
+	})
+	for _, k := range keys {
+		fp := FilePatch{RepoName: k.repo, File: k.file, Diff: filepath.ToSlash(filepath.Join(k.repo, k.file)) + ".diff"}
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "--- a/%s\n+++ b/%s\n", k.file, k.file)
+		es := files[k]
+		sort.SliceStable(es, func(i, j int) bool { return es[i].line < es[j].line })
+		// delta is the lines added by the previous edits, which shifts the new side of the hunks
+		delta, last := 0, 0
+		for _, e := range es {
+			if e.line <= last {
+				ret.Rejected = append(ret.Rejected, fmt.Sprintf("%s: overlaps another edit of %s", e.NodeID.Identity().Full(), k.file))
+				continue
+			}
+			last = e.line + len(e.olds) - 1
+			news := splitLines(e.Codes)
+			for _, g := range difflib.NewMatcher(e.olds, news).GetGroupedOpCodes(3) {
+				first, end := g[0], g[len(g)-1]
+				header := fmt.Sprintf("@@ -%s +%s @@", hunkRange(e.line+first.I1, end.I2-first.I1), hunkRange(e.line+delta+first.J1, end.J2-first.J1))
+				sb.WriteString(header + "\n")
+				for _, op := range g {
+					if op.Tag == 'e' {
+						writeLines(&sb, " ", e.olds[op.I1:op.I2])
+						continue
+					}
+					if op.Tag == 'r' || op.Tag == 'd' {
+						writeLines(&sb, "-", e.olds[op.I1:op.I2])
+					}
+					if op.Tag == 'r' || op.Tag == 'i' {
+						writeLines(&sb, "+", news[op.J1:op.J2])
+					}
+				}
+				fp.Hunks = append(fp.Hunks, HunkRationale{Header: header, NodeID: e.NodeID, Findings: e.Findings, Rationale: e.Rationale})
+			}
+			delta += len(news) - len(e.olds)
+		}
+		if len(fp.Hunks) == 0 {
+			continue
+		}
+		fp.Unified = sb.String()
+		ret.Files = append(ret.Files, fp)
+	}
+	return ret
+}
+
+func splitLines(codes string) []string {
+	return strings.Split(strings.TrimSuffix(codes, "\n"), "\n")
+}
+
+func writeLines(sb *strings.Builder, prefix string, lines []string) {
+	for _, l := range lines {
+		sb.WriteString(prefix)
+		sb.WriteString(l)
+		sb.WriteString("\n")
+	}
+}
+
+// hunkRange formats the range of a hunk side, whose start is the line before if it is empty
+func hunkRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start-1)
+	case 1:
+		return fmt.Sprintf("%d", start)
+	default:
+		return fmt.Sprintf("%d,%d", start, count)
+	}
+}
+
+// WriteTo writes the diff of each file to <dir>/<repo>/<file>.diff, and the rationales to <dir>/rationale.json
+func (p *PatchProposal) WriteTo(dir string) error {
+	for _, f := range p.Files {
+		path := filepath.Join(dir, filepath.FromSlash(f.Diff))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, []byte(f.Unified), 0644); err != nil {
+			return err
+		}
+	}
+	bs, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "rationale.json"), bs, 0644)
+}
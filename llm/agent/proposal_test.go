@@ -0,0 +1,87 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/abcoder/llm/tool"
+)
+
+func TestProposePatches(t *testing.T) {
+	dir := t.TempDir()
+	bs, err := os.ReadFile("../../testdata/asts/localsession.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "localsession.json"), bs, 0644); err != nil {
+		t.Fatal(err)
+	}
+	ast := tool.NewASTReadTools(tool.ASTReadToolsOptions{RepoASTsDir: dir})
+
+	bind := `{"mod_path": "github.com/cloudwego/localsession", "pkg_path": "github.com/cloudwego/localsession", "name": "BindSession"}`
+	missing := `{"mod_path": "github.com/cloudwego/localsession", "pkg_path": "github.com/cloudwego/localsession", "name": "NoSuchFunc"}`
+	codes := "// BindSession binds the session with current goroutine\n//\n// NOTICE: MUST call `InitDefaultManager()` once before using this API\n" +
+		"func BindSession(s Session) {\n\tif defaultManagerObj == nil {\n\t\tpanic(\"default manager is not initialized\")\n\t}\n\tdefaultManagerObj.BindSession(SessionID(goID()), s)\n}"
+	answer := "Fix it.\n\n```patches\n[" +
+		`{"node_id": ` + bind + `, "codes": "` + strings.ReplaceAll(strings.ReplaceAll(strings.ReplaceAll(codes, `"`, `\"`), "\n", `\n`), "\t", `\t`) + `", "findings": ["silent no-op"], "rationale": "fail fast"},` +
+		`{"node_id": ` + missing + `, "codes": "func NoSuchFunc() {}"}` +
+		"]\n```\n"
+	edits, ok, err := parsePatches(answer)
+	if !ok || err != nil || len(edits) != 2 {
+		t.Fatalf("edits = %+v, ok = %v, err = %v", edits, ok, err)
+	}
+
+	p := ProposePatches(ast, "dead-code", "one issue", edits)
+	if len(p.Files) != 1 || len(p.Rejected) != 1 || !strings.Contains(p.Rejected[0], "not found") {
+		t.Fatalf("proposal = %+v", p)
+	}
+	f := p.Files[0]
+	want := "--- a/gls.go\n+++ b/gls.go\n@@ -117,7 +117,7 @@\n" +
+		" // NOTICE: MUST call `InitDefaultManager()` once before using this API\n" +
+		" func BindSession(s Session) {\n" +
+		" \tif defaultManagerObj == nil {\n" +
+		"-\t\treturn\n" +
+		"+\t\tpanic(\"default manager is not initialized\")\n" +
+		" \t}\n" +
+		" \tdefaultManagerObj.BindSession(SessionID(goID()), s)\n" +
+		" }\n"
+	if f.File != "gls.go" || f.Unified != want {
+		t.Errorf("diff of %s = %q, want %q", f.File, f.Unified, want)
+	}
+	if len(f.Hunks) != 1 || f.Hunks[0].Header != "@@ -117,7 +117,7 @@" || f.Hunks[0].NodeID.Name != "BindSession" || f.Hunks[0].Findings[0] != "silent no-op" {
+		t.Errorf("hunks = %+v", f.Hunks)
+	}
+
+	out := t.TempDir()
+	if err := p.WriteTo(out); err != nil {
+		t.Fatal(err)
+	}
+	if bs, err := os.ReadFile(filepath.Join(out, filepath.FromSlash(f.Diff))); err != nil || string(bs) != want {
+		t.Errorf("diff file = %q, %v", bs, err)
+	}
+	if bs, err := os.ReadFile(filepath.Join(out, "rationale.json")); err != nil || !strings.Contains(string(bs), `"rationale": "fail fast"`) {
+		t.Errorf("rationale.json = %s, %v", bs, err)
+	}
+
+	if _, ok, _ := parsePatches("no patches"); ok {
+		t.Error("expect no patches block")
+	}
+}
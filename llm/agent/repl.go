@@ -0,0 +1,258 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package agent
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/cloudwego/abcoder/internal/utils"
+	"github.com/cloudwego/abcoder/lang/uniast"
+	"github.com/cloudwego/abcoder/llm/tool"
+	"github.com/cloudwego/eino/schema"
+	"golang.org/x/term"
+)
+
+// replCommand is a slash command of the REPL, which is run locally without asking the model
+type replCommand struct {
+	usage string
+	desc  string
+	run   func(a *Agent, ctx context.Context, arg string, w io.Writer) error
+}
+
+var replCommands map[string]replCommand
+
+func init() {
+	// initialized here since /help refers to the map itself
+	replCommands = map[string]replCommand{
+		"/help":   {"/help", "show the commands", (*Agent).cmdHelp},
+		"/repos":  {"/repos", "list the repositories", (*Agent).cmdRepos},
+		"/node":   {"/node <mod?pkg#name>", "show the codes and dependencies of a node", (*Agent).cmdNode},
+		"/search": {"/search <name>", "find the nodes named so and their references", (*Agent).cmdSearch},
+		"/reset":  {"/reset", "forget the conversation and start a new session", (*Agent).cmdReset},
+		"/save":   {"/save [file]", "save the session, and the transcript in markdown if file is given", (*Agent).cmdSave},
+		"/usage":  {"/usage", "show the tokens and the cost of the model calls so far", (*Agent).cmdUsage},
+		"/exit":   {"/exit", "quit", nil},
+	}
+}
+
+// errExit tells the REPL to quit
+var errExit = errors.New("exit")
+
+// command runs the slash command line
+func (a *Agent) command(ctx context.Context, line string, w io.Writer) error {
+	name, arg, _ := strings.Cut(line, " ")
+	cmd, ok := replCommands[name]
+	if !ok {
+		return fmt.Errorf("unknown command %s, see /help", name)
+	}
+	if cmd.run == nil {
+		return errExit
+	}
+	return cmd.run(a, ctx, strings.TrimSpace(arg), w)
+}
+
+func (a *Agent) cmdHelp(_ context.Context, _ string, w io.Writer) error {
+	names := make([]string, 0, len(replCommands))
+	for name := range replCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-22s %s\n", replCommands[name].usage, replCommands[name].desc)
+	}
+	fmt.Fprintln(w, "Other inputs are asked to the agent.")
+	return nil
+}
+
+func (a *Agent) cmdUsage(_ context.Context, _ string, w io.Writer) error {
+	fmt.Fprintln(w, a.usage.Stats())
+	return nil
+}
+
+func (a *Agent) cmdRepos(ctx context.Context, _ string, w io.Writer) error {
+	resp, err := a.ast.ListRepos(ctx, tool.ListReposReq{})
+	if err != nil {
+		return err
+	}
+	for _, name := range resp.RepoNames {
+		fmt.Fprintln(w, name)
+	}
+	return nil
+}
+
+// repoNames returns the repos to look up nodes in
+func (a *Agent) repoNames(ctx context.Context) ([]string, error) {
+	if len(a.opts.Repos) > 0 {
+		return a.opts.Repos, nil
+	}
+	resp, err := a.ast.ListRepos(ctx, tool.ListReposReq{})
+	if err != nil {
+		return nil, err
+	}
+	return resp.RepoNames, nil
+}
+
+func (a *Agent) cmdNode(ctx context.Context, arg string, w io.Writer) error {
+	if arg == "" {
+		return errors.New("usage: " + replCommands["/node"].usage)
+	}
+	id := uniast.NewIdentityFromString(arg)
+	repos, err := a.repoNames(ctx)
+	if err != nil {
+		return err
+	}
+	// the node is looked up in the repos one by one, since its id does not tell the repo
+	for _, repo := range repos {
+		resp, err := a.ast.GetASTNode(ctx, tool.GetASTNodeReq{RepoName: repo, NodeIDs: []tool.NodeID{tool.NewNodeID(id)}})
+		if err != nil {
+			return err
+		}
+		if len(resp.Nodes) == 0 {
+			continue
+		}
+		return printJSON(w, resp.Nodes)
+	}
+	return fmt.Errorf("node %s not found, the id is like `mod?pkg#name`", arg)
+}
+
+func (a *Agent) cmdSearch(ctx context.Context, arg string, w io.Writer) error {
+	if arg == "" {
+		return errors.New("usage: " + replCommands["/search"].usage)
+	}
+	resp, err := a.ast.FindSymbolAcrossRepos(ctx, tool.FindSymbolAcrossReposReq{Name: arg, RepoNames: a.opts.Repos})
+	if err != nil {
+		return err
+	}
+	if resp.Error != "" {
+		return errors.New(resp.Error)
+	}
+	for _, sym := range resp.Symbols {
+		fmt.Fprintf(w, "%s %s %s (%s:%d)\n", sym.Type, sym.RepoName, sym.NodeID.Identity().Full(), sym.File, sym.Line)
+		for _, ref := range sym.References {
+			fmt.Fprintf(w, "    <- %s (%s:%d)\n", ref.NodeID.Identity().Full(), ref.File, ref.Line)
+		}
+	}
+	return nil
+}
+
+func (a *Agent) cmdReset(_ context.Context, _ string, w io.Writer) error {
+	a.histories = NewHistories(a.histories.max)
+	cache := a.session.ToolResults
+	a.session = NewSession(a.opts.ASTsDir)
+	// the ASTs are unchanged, thus the tool results are still valid
+	a.session.ToolResults = cache
+	fmt.Fprintf(w, "Started a new session %s.\n", a.session.ID)
+	return nil
+}
+
+func (a *Agent) cmdSave(_ context.Context, arg string, w io.Writer) error {
+	a.session.Histories = a.histories.Get()
+	if err := a.session.Save(a.opts.SessionDir); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Saved session %s, continue it later with `--resume %s`.\n", a.session.ID, a.session.ID)
+	if arg == "" {
+		return nil
+	}
+	if err := utils.MustWriteFile(arg, []byte(transcript(a.histories.Get()))); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Wrote the transcript to %s.\n", arg)
+	return nil
+}
+
+// transcript renders the user questions and the answers in markdown
+func transcript(msgs []*schema.Message) string {
+	var sb strings.Builder
+	for _, msg := range msgs {
+		switch msg.Role {
+		case schema.User:
+			sb.WriteString("## " + strings.ReplaceAll(msg.Content, "\n", " ") + "\n\n")
+		case schema.Assistant:
+			if msg.Content != "" {
+				sb.WriteString(msg.Content + "\n\n")
+			}
+		}
+	}
+	return sb.String()
+}
+
+func printJSON(w io.Writer, v interface{}) error {
+	out, err := utils.MarshalJSONIndent(v)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(w, out)
+	return nil
+}
+
+// lineReader reads the inputs of the REPL line by line
+type lineReader interface {
+	ReadLine() (string, error)
+}
+
+// newLineReader returns a reader with line editing and history if stdin is a terminal
+func newLineReader() lineReader {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return scanReader{bufio.NewScanner(os.Stdin)}
+	}
+	return &termReader{
+		fd: fd,
+		term: term.NewTerminal(struct {
+			io.Reader
+			io.Writer
+		}{os.Stdin, os.Stdout}, "> "),
+	}
+}
+
+type scanReader struct {
+	sc *bufio.Scanner
+}
+
+func (r scanReader) ReadLine() (string, error) {
+	if !r.sc.Scan() {
+		if err := r.sc.Err(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	}
+	return r.sc.Text(), nil
+}
+
+// termReader puts the terminal in raw mode only while reading a line,
+// thus the streamed answers are printed as usual
+type termReader struct {
+	fd   int
+	term *term.Terminal
+}
+
+func (r *termReader) ReadLine() (string, error) {
+	state, err := term.MakeRaw(r.fd)
+	if err != nil {
+		return "", err
+	}
+	defer term.Restore(r.fd, state)
+	return r.term.ReadLine()
+}
@@ -0,0 +1,85 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package agent
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/abcoder/llm/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestAgent_command(t *testing.T) {
+	dir := t.TempDir()
+	astsDir := "../../testdata/asts"
+	session := NewSession(astsDir)
+	a := &Agent{
+		opts:      AgentOptions{ASTsDir: astsDir, SessionDir: dir},
+		ast:       tool.NewASTReadTools(tool.ASTReadToolsOptions{RepoASTsDir: astsDir}),
+		histories: NewHistories(10),
+		session:   session,
+	}
+	run := func(line string) (string, error) {
+		var buf bytes.Buffer
+		err := a.command(context.Background(), line, &buf)
+		return buf.String(), err
+	}
+
+	if out, err := run("/repos"); err != nil || !strings.Contains(out, "localsession") {
+		t.Errorf("/repos = %q, %v", out, err)
+	}
+	if out, err := run("/node github.com/cloudwego/localsession?github.com/cloudwego/localsession#CurSession"); err != nil || !strings.Contains(out, "func CurSession") {
+		t.Errorf("/node = %q, %v", out, err)
+	}
+	if _, err := run("/node a?b#NotExist"); err == nil {
+		t.Error("expect an error for the missing node")
+	}
+	if out, err := run("/search CurSession"); err != nil || !strings.Contains(out, "#CurSession") || !strings.Contains(out, "<- ") {
+		t.Errorf("/search = %q, %v", out, err)
+	}
+	if _, err := run("/unknown"); err == nil {
+		t.Error("expect an error for the unknown command")
+	}
+	if _, err := run("/exit"); err != errExit {
+		t.Errorf("/exit = %v", err)
+	}
+
+	a.histories.Add(&schema.Message{Role: schema.User, Content: "what is CurSession?"})
+	a.histories.Add(&schema.Message{Role: schema.Assistant, Content: "It returns the current session."})
+	file := filepath.Join(dir, "transcript.md")
+	if _, err := run("/save " + file); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, session.ID+".json")); err != nil {
+		t.Error(err)
+	}
+	if bs, _ := os.ReadFile(file); string(bs) != "## what is CurSession?\n\nIt returns the current session.\n\n" {
+		t.Errorf("transcript = %q", bs)
+	}
+
+	if _, err := run("/reset"); err != nil {
+		t.Fatal(err)
+	}
+	if len(a.histories.Get()) != 0 || a.session.ID == session.ID || a.session.ToolResults != session.ToolResults {
+		t.Errorf("reset session = %+v", a.session)
+	}
+}
@@ -0,0 +1,175 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package agent
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/abcoder/internal/utils"
+	"github.com/cloudwego/abcoder/llm/log"
+	etool "github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+// Session is a conversation of the agent, persisted as `<id>.json` under the session dir
+type Session struct {
+	ID        string    `json:"id"`
+	ASTsDir   string    `json:"asts_dir"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// the conversation histories, from the oldest
+	Histories []*schema.Message `json:"histories,omitempty"`
+	// results of the AST tools called in the session
+	ToolResults *ToolCache `json:"tool_results,omitempty"`
+}
+
+// DefaultSessionDir returns `~/.abcoder/sessions`
+func DefaultSessionDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".abcoder", "sessions")
+	}
+	return filepath.Join(home, ".abcoder", "sessions")
+}
+
+func NewSession(astsDir string) *Session {
+	var rnd [4]byte
+	_, _ = rand.Read(rnd[:])
+	now := time.Now()
+	return &Session{
+		ID:          now.Format("20060102-150405") + "-" + hex.EncodeToString(rnd[:]),
+		ASTsDir:     astsDir,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		ToolResults: NewToolCache(),
+	}
+}
+
+// LoadSession loads the session of id from dir.
+// The cached tool results are dropped if any AST under astsDir has changed since the last save.
+func LoadSession(dir, id, astsDir string) (*Session, error) {
+	bs, err := os.ReadFile(filepath.Join(dir, id+".json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("session '%s' not found in %s", id, dir)
+		}
+		return nil, err
+	}
+	var s Session
+	if err := json.Unmarshal(bs, &s); err != nil {
+		return nil, utils.WrapError(err, "decode session "+id)
+	}
+	if s.ToolResults == nil || astsChangedSince(astsDir, s.UpdatedAt) {
+		s.ToolResults = NewToolCache()
+	}
+	s.ASTsDir = astsDir
+	return &s, nil
+}
+
+func astsChangedSince(astsDir string, t time.Time) bool {
+	files, _ := filepath.Glob(filepath.Join(astsDir, "*.json"))
+	for _, f := range files {
+		if fi, err := os.Stat(f); err == nil && fi.ModTime().After(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// Save writes the session into dir
+func (s *Session) Save(dir string) error {
+	s.UpdatedAt = time.Now()
+	bs, err := utils.MarshalJSONBytes(s)
+	if err != nil {
+		return err
+	}
+	return utils.MustWriteFile(filepath.Join(dir, s.ID+".json"), bs)
+}
+
+// ToolCache caches tool results by the tool name and arguments
+type ToolCache struct {
+	mu      sync.Mutex
+	results map[string]string
+}
+
+func NewToolCache() *ToolCache {
+	return &ToolCache{results: map[string]string{}}
+}
+
+func (c *ToolCache) Get(name, args string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	v, ok := c.results[name+" "+args]
+	return v, ok
+}
+
+func (c *ToolCache) Set(name, args, result string) {
+	c.mu.Lock()
+	c.results[name+" "+args] = result
+	c.mu.Unlock()
+}
+
+func (c *ToolCache) MarshalJSON() ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return json.Marshal(c.results)
+}
+
+func (c *ToolCache) UnmarshalJSON(data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return json.Unmarshal(data, &c.results)
+}
+
+// cachedTool returns the result in cache if the tool has been called with the same arguments
+type cachedTool struct {
+	etool.InvokableTool
+	cache *ToolCache
+}
+
+// WithToolCache wraps the invokable tool t to cache its results, other tools are returned as is
+func WithToolCache(t etool.BaseTool, cache *ToolCache) etool.BaseTool {
+	it, ok := t.(etool.InvokableTool)
+	if !ok || cache == nil {
+		return t
+	}
+	return cachedTool{InvokableTool: it, cache: cache}
+}
+
+func (t cachedTool) InvokableRun(ctx context.Context, args string, opts ...etool.Option) (string, error) {
+	info, err := t.Info(ctx)
+	if err != nil {
+		return "", err
+	}
+	if ret, ok := t.cache.Get(info.Name, args); ok {
+		log.Debug("tool %s hits cache, args: %s", info.Name, args)
+		return ret, nil
+	}
+	ret, err := t.InvokableTool.InvokableRun(ctx, args, opts...)
+	if err == nil {
+		t.cache.Set(info.Name, args, ret)
+	}
+	return ret, err
+}
@@ -0,0 +1,93 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	etool "github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+type countTool struct {
+	calls int
+}
+
+func (t *countTool) Info(context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{Name: "count"}, nil
+}
+
+func (t *countTool) InvokableRun(_ context.Context, args string, _ ...etool.Option) (string, error) {
+	t.calls++
+	return args + "!", nil
+}
+
+func TestSession_SaveLoad(t *testing.T) {
+	dir := t.TempDir()
+	asts := t.TempDir()
+	if err := os.WriteFile(filepath.Join(asts, "repo.json"), []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewSession(asts)
+	s.Histories = []*schema.Message{schema.UserMessage("hi"), schema.AssistantMessage("hello", nil)}
+	ct := &countTool{}
+	tt := WithToolCache(ct, s.ToolResults).(etool.InvokableTool)
+	for i := 0; i < 2; i++ {
+		if out, err := tt.InvokableRun(context.Background(), `{"a":1}`); err != nil || out != `{"a":1}!` {
+			t.Fatalf("InvokableRun() = %q, %v", out, err)
+		}
+	}
+	if ct.calls != 1 {
+		t.Errorf("tool is called %d times, want 1", ct.calls)
+	}
+	if err := s.Save(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := LoadSession(dir, s.ID, asts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Histories) != 2 || got.Histories[1].Content != "hello" {
+		t.Errorf("histories = %v", got.Histories)
+	}
+	if out, ok := got.ToolResults.Get("count", `{"a":1}`); !ok || out != `{"a":1}!` {
+		t.Errorf("cached tool result = %q, %v", out, ok)
+	}
+
+	// the cache is dropped once the ASTs are updated
+	future := time.Now().Add(time.Minute)
+	if err := os.Chtimes(filepath.Join(asts, "repo.json"), future, future); err != nil {
+		t.Fatal(err)
+	}
+	got, err = LoadSession(dir, s.ID, asts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := got.ToolResults.Get("count", `{"a":1}`); ok {
+		t.Error("tool results should be dropped after ASTs changed")
+	}
+
+	if _, err := LoadSession(dir, "not-exist", asts); err == nil {
+		t.Error("expect error for unknown session")
+	}
+}
@@ -0,0 +1,311 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/cloudwego/abcoder/llm"
+	"github.com/cloudwego/abcoder/llm/prompt"
+	"github.com/cloudwego/abcoder/llm/tool"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/flow/agent"
+	"github.com/cloudwego/eino/schema"
+)
+
+// Task is a predefined analysis, which drives the agent by a purpose-built prompt and reports the findings
+type Task struct {
+	Name        string
+	Description string
+	// Params are the arguments accepted by the prompt template, name => description
+	Params map[string]string
+}
+
+// tasks are the builtin tasks, whose prompt templates are prompt/tasks/<name>.md
+var tasks = []Task{
+	{
+		Name:        "dead-code",
+		Description: "find the functions, types and vars which are never used",
+		Params:      map[string]string{"pkg": "only check the package", "exported": "only report exported nodes, e.g. true"},
+	},
+	{
+		Name:        "security-review",
+		Description: "review the codes for injections, missing auth checks, leaked secrets and other vulnerabilities",
+		Params:      map[string]string{"pkg": "focus on the package", "focus": "the kinds of issues to pay special attention to"},
+	},
+	{
+		Name:        "api-surface",
+		Description: "summarize the public API and its smells",
+		Params:      map[string]string{"pkg": "only summarize the package"},
+	},
+	{
+		Name:        "architecture",
+		Description: "draw the component and sequence diagrams (mermaid) of the architecture",
+		Params:      map[string]string{"pkg": "only draw the packages under the path", "entry": "the entry function of the sequence diagram, e.g. mod?pkg#name"},
+	},
+}
+
+// Tasks returns the builtin tasks
+func Tasks() []Task {
+	return tasks
+}
+
+// GetTask returns the builtin task by name
+func GetTask(name string) (Task, error) {
+	var names []string
+	for _, t := range tasks {
+		if t.Name == name {
+			return t, nil
+		}
+		names = append(names, t.Name)
+	}
+	return Task{}, fmt.Errorf("unknown task %q, must be one of %s", name, strings.Join(names, ", "))
+}
+
+// reportFence opens the block of the structured report at the end of a task answer
+const reportFence = "```report"
+
+// reportInstruction is appended to the prompts of all tasks, which asks for the structured report
+const reportInstruction = `
+
+# Report
+Explain your findings in markdown first. Then end the answer with the structured report in a ` + "`report`" + ` fenced block (before the citations block), which is a JSON object like:
+
+` + "```report" + `
+{
+  "summary": "one paragraph of the overall conclusion",
+  "findings": [
+    {
+      "title": "short title of the finding",
+      "severity": "critical | high | medium | low | info",
+      "repo_name": "the repository",
+      "node_id": {"mod_path": "...", "pkg_path": "...", "name": "..."},
+      "file": "file path",
+      "line": 1,
+      "detail": "what is found and why it matters",
+      "suggestion": "how to fix or use it"
+    }
+  ]
+}
+` + "```" + `
+
+Every finding must be located at a node you have read by the tools. Report an empty findings list if nothing is found.`
+
+// Finding is an item of a task report
+type Finding struct {
+	Title      string       `json:"title"`
+	Severity   string       `json:"severity,omitempty"`
+	RepoName   string       `json:"repo_name,omitempty"`
+	NodeID     *tool.NodeID `json:"node_id,omitempty"`
+	File       string       `json:"file,omitempty"`
+	Line       int          `json:"line,omitempty"`
+	Detail     string       `json:"detail,omitempty"`
+	Suggestion string       `json:"suggestion,omitempty"`
+}
+
+// TaskReport is the structured result of a task
+type TaskReport struct {
+	Task     string            `json:"task"`
+	Args     map[string]string `json:"args,omitempty"`
+	Summary  string            `json:"summary"`
+	Findings []Finding         `json:"findings"`
+	// Answer is the full answer of the agent, including the explanation in markdown
+	Answer string `json:"answer"`
+	// Citations are the verified citations of the answer
+	Citations []tool.Citation `json:"citations,omitempty"`
+	// RejectedCitations are the reasons why the other citations are rejected
+	RejectedCitations []string `json:"rejected_citations,omitempty"`
+	// Error tells why the report can't be parsed from the answer, the answer is kept anyway
+	Error string `json:"error,omitempty"`
+	// Proposal are the code changes proposed for the findings, if asked by RepoAnnalyzerOptions.ProposePatches
+	Proposal *PatchProposal `json:"proposal,omitempty"`
+}
+
+// TaskPrompt renders the prompt of the task with the args, unknown args are rejected
+func TaskPrompt(name string, repos []string, args map[string]string) (string, error) {
+	task, err := GetTask(name)
+	if err != nil {
+		return "", err
+	}
+	for k := range args {
+		if _, ok := task.Params[k]; !ok {
+			return "", fmt.Errorf("unknown argument %q of task %s, must be one of %s", k, name, strings.Join(task.ParamNames(), ", "))
+		}
+	}
+	text, err := prompt.TaskPrompt(name)
+	if err != nil {
+		return "", err
+	}
+	tpl, err := template.New(name).Funcs(template.FuncMap{"join": strings.Join}).Parse(text)
+	if err != nil {
+		return "", err
+	}
+	if args == nil {
+		args = map[string]string{}
+	}
+	var sb strings.Builder
+	if err := tpl.Execute(&sb, map[string]any{"Repos": repos, "Args": args}); err != nil {
+		return "", err
+	}
+	sb.WriteString(reportInstruction)
+	return sb.String(), nil
+}
+
+// ParamNames returns the sorted names of the params
+func (t Task) ParamNames() []string {
+	names := make([]string, 0, len(t.Params))
+	for k := range t.Params {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RunTask runs the task by the agent, and parses the structured report from the answer.
+// The error is only returned if the agent fails, a malformed report is told by TaskReport.Error
+func RunTask(ctx context.Context, opts RepoAnnalyzerOptions, name string, args map[string]string) (*TaskReport, error) {
+	text, err := TaskPrompt(name, opts.Repos, args)
+	if err != nil {
+		return nil, err
+	}
+	if opts.ProposePatches {
+		text += patchesInstruction
+	}
+	if opts.AST == nil {
+		opts.AST = newASTReadTools(opts)
+	}
+	ag := NewRepoAnalyzer(ctx, opts)
+	generate := func(ctx context.Context, msgs []*schema.Message, _ []string) (*schema.Message, error) {
+		return ag.Generate(ctx, msgs, agent.WithComposeOptions(compose.WithCallbacks(llm.CallbackHandler{})))
+	}
+	msgs := []*schema.Message{schema.UserMessage(text)}
+	msg, err := generate(ctx, msgs, nil)
+	if err != nil {
+		return nil, err
+	}
+	report := &TaskReport{Task: name, Args: args}
+	msg, report.Citations, report.RejectedCitations = NewGrounder(opts.AST).Ground(ctx, msgs, msg, generate)
+	report.Answer = msg.Content
+	if err := report.parse(msg.Content); err != nil {
+		report.Error = err.Error()
+	}
+	if opts.ProposePatches {
+		edits, ok, err := parsePatches(msg.Content)
+		report.Proposal = ProposePatches(opts.AST, name, report.Summary, edits)
+		if !ok {
+			report.Proposal.Rejected = append(report.Proposal.Rejected, "the answer has no patches block")
+		} else if err != nil {
+			report.Proposal.Rejected = append(report.Proposal.Rejected, err.Error())
+		}
+	}
+	return report, nil
+}
+
+// parse fills the summary and the findings by the report block of the answer
+func (r *TaskReport) parse(answer string) error {
+	start := strings.LastIndex(answer, reportFence)
+	if start < 0 {
+		return fmt.Errorf("no report block in the answer")
+	}
+	body := answer[start+len(reportFence):]
+	if end := strings.Index(body, "```"); end >= 0 {
+		body = body[:end]
+	}
+	var rep struct {
+		Summary  string    `json:"summary"`
+		Findings []Finding `json:"findings"`
+	}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(body)), &rep); err != nil {
+		return fmt.Errorf("the report block is not a JSON report: %v", err)
+	}
+	r.Summary, r.Findings = rep.Summary, rep.Findings
+	return nil
+}
+
+// severityOrder ranks the findings in the markdown report
+var severityOrder = map[string]int{"critical": 0, "high": 1, "medium": 2, "low": 3, "info": 4}
+
+// Markdown renders the report for humans, findings are grouped by severity
+func (r *TaskReport) Markdown() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# %s report\n\n", r.Task)
+	if len(r.Args) > 0 {
+		keys := make([]string, 0, len(r.Args))
+		for k := range r.Args {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(&sb, "- %s: %s\n", k, r.Args[k])
+		}
+		sb.WriteString("\n")
+	}
+	if r.Error != "" {
+		fmt.Fprintf(&sb, "> The structured report is unavailable: %s. The answer follows.\n\n%s\n", r.Error, r.Answer)
+		return sb.String()
+	}
+	fmt.Fprintf(&sb, "## Summary\n\n%s\n\n## Findings (%d)\n\n", r.Summary, len(r.Findings))
+	findings := append([]Finding(nil), r.Findings...)
+	rank := func(s string) int {
+		if o, ok := severityOrder[strings.ToLower(s)]; ok {
+			return o
+		}
+		return len(severityOrder)
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		return rank(findings[i].Severity) < rank(findings[j].Severity)
+	})
+	for i, f := range findings {
+		fmt.Fprintf(&sb, "### %d. %s", i+1, f.Title)
+		if f.Severity != "" {
+			fmt.Fprintf(&sb, " [%s]", f.Severity)
+		}
+		sb.WriteString("\n\n")
+		if f.NodeID != nil {
+			fmt.Fprintf(&sb, "- Node: `%s`\n", f.NodeID.Identity().Full())
+		}
+		if f.File != "" {
+			fmt.Fprintf(&sb, "- Location: %s:%d\n", f.File, f.Line)
+		}
+		if f.Detail != "" {
+			fmt.Fprintf(&sb, "\n%s\n", f.Detail)
+		}
+		if f.Suggestion != "" {
+			fmt.Fprintf(&sb, "\n**Suggestion:** %s\n", f.Suggestion)
+		}
+		sb.WriteString("\n")
+	}
+	if p := r.Proposal; p != nil {
+		fmt.Fprintf(&sb, "## Proposed patches (%d files)\n\n", len(p.Files))
+		for _, f := range p.Files {
+			fmt.Fprintf(&sb, "- %s (%d hunks): %s\n", f.File, len(f.Hunks), f.Diff)
+		}
+		if len(p.Rejected) > 0 {
+			fmt.Fprintf(&sb, "\n> Rejected patches: %s\n", strings.Join(p.Rejected, "; "))
+		}
+		sb.WriteString("\n")
+	}
+	if len(r.RejectedCitations) > 0 {
+		fmt.Fprintf(&sb, "> Unverified citations are dropped: %s\n", strings.Join(r.RejectedCitations, "; "))
+	}
+	return sb.String()
+}
@@ -0,0 +1,82 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package agent
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTaskPrompt(t *testing.T) {
+	for _, task := range Tasks() {
+		text, err := TaskPrompt(task.Name, nil, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", task.Name, err)
+		}
+		if !strings.Contains(text, "```report") || strings.Contains(text, "{{") {
+			t.Errorf("%s: prompt = %s", task.Name, text)
+		}
+	}
+
+	text, err := TaskPrompt("dead-code", []string{"svc", "sdk"}, map[string]string{"pkg": "a/b/util"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(text, "in svc, sdk, only those of package `a/b/util`") {
+		t.Errorf("prompt = %s", text)
+	}
+
+	if _, err := TaskPrompt("dead-code", nil, map[string]string{"focus": "x"}); err == nil || !strings.Contains(err.Error(), "exported, pkg") {
+		t.Errorf("unknown arg: %v", err)
+	}
+	if _, err := TaskPrompt("perf", nil, nil); err == nil || !strings.Contains(err.Error(), "dead-code") {
+		t.Errorf("unknown task: %v", err)
+	}
+}
+
+func TestTaskReport(t *testing.T) {
+	answer := "Found 2 issues.\n\n```report\n" + `{
+  "summary": "two issues",
+  "findings": [
+    {"title": "unused helper", "severity": "low", "node_id": {"mod_path": "a", "pkg_path": "a/p", "name": "helper"}, "file": "p/h.go", "line": 3},
+    {"title": "SQL injection", "severity": "critical", "detail": "query is concatenated", "suggestion": "use placeholders"}
+  ]
+}` + "\n```\n\n```citations\n[]\n```"
+	rep := &TaskReport{Task: "security-review", Answer: answer}
+	if err := rep.parse(answer); err != nil {
+		t.Fatal(err)
+	}
+	if rep.Summary != "two issues" || len(rep.Findings) != 2 || rep.Findings[0].NodeID == nil || rep.Findings[0].NodeID.Name != "helper" {
+		t.Fatalf("report = %+v", rep)
+	}
+
+	md := rep.Markdown()
+	critical, low := strings.Index(md, "### 1. SQL injection [critical]"), strings.Index(md, "### 2. unused helper [low]")
+	if critical < 0 || low < critical || !strings.Contains(md, "- Node: `a?a/p#helper`") || !strings.Contains(md, "**Suggestion:** use placeholders") {
+		t.Errorf("markdown = %s", md)
+	}
+
+	bad := &TaskReport{Task: "dead-code", Answer: "nothing"}
+	if err := bad.parse(bad.Answer); err == nil {
+		t.Error("expect an error without the report block")
+	} else {
+		bad.Error = err.Error()
+	}
+	if md := bad.Markdown(); !strings.Contains(md, "unavailable") || !strings.Contains(md, "nothing") {
+		t.Errorf("markdown = %s", md)
+	}
+}
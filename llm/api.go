@@ -37,6 +37,11 @@ type ModelConfig struct {
 	Temperature *float32  `json:"temperature"`
 	// TopP        *float32  `json:"top_p"`
 	MaxTokens int `json:"max_tokens"`
+	// CacheDir persists the responses keyed by the requests, see ResponseCache. No cache if empty
+	CacheDir string `json:"cache_dir,omitempty"`
+	// InputPrice and OutputPrice are the prices in USD per million prompt and completion tokens, see Usage
+	InputPrice  float64 `json:"input_price,omitempty"`
+	OutputPrice float64 `json:"output_price,omitempty"`
 }
 
 type ModelType string
@@ -0,0 +1,207 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package llm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudwego/abcoder/internal/utils"
+	"github.com/cloudwego/abcoder/llm/log"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// ResponseCache persists the responses of the model under a dir, keyed by the hash of the request,
+// thus re-running the same analysis on unchanged ASTs is answered without calling the provider.
+// The responses are never expired, remove the dir to clear it
+type ResponseCache struct {
+	dir string
+}
+
+func NewResponseCache(dir string) *ResponseCache {
+	return &ResponseCache{dir: dir}
+}
+
+func (c *ResponseCache) path(key string) string {
+	return filepath.Join(c.dir, key[:2], key+".json")
+}
+
+// Get returns the cached response of the key
+func (c *ResponseCache) Get(key string) (*schema.Message, bool) {
+	bs, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+	var msg schema.Message
+	if err := json.Unmarshal(bs, &msg); err != nil {
+		log.Error("decode cached response %s failed: %v\n", key, err)
+		return nil, false
+	}
+	return &msg, true
+}
+
+func (c *ResponseCache) Set(key string, msg *schema.Message) {
+	bs, err := utils.MarshalJSONBytes(msg)
+	if err == nil {
+		err = utils.MustWriteFile(c.path(key), bs)
+	}
+	if err != nil {
+		log.Error("cache response %s failed: %v\n", key, err)
+	}
+}
+
+// cachedChatModel answers by the cached responses, and caches the new ones
+type cachedChatModel struct {
+	ChatModel
+	conf  ModelConfig
+	tools []*schema.ToolInfo
+	cache *ResponseCache
+}
+
+// WithResponseCache wraps the model of conf to cache its responses, the model is returned as is if cache is nil
+func WithResponseCache(m ChatModel, conf ModelConfig, cache *ResponseCache) ChatModel {
+	if cache == nil {
+		return m
+	}
+	return &cachedChatModel{ChatModel: m, conf: conf, cache: cache}
+}
+
+func (m *cachedChatModel) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	cm, err := m.ChatModel.WithTools(tools)
+	if err != nil {
+		return nil, err
+	}
+	return &cachedChatModel{ChatModel: cm, conf: m.conf, tools: tools, cache: m.cache}, nil
+}
+
+// IsCallbacksEnabled tells the callbacks are triggered by the wrapped model, thus they are not triggered on cache hits
+func (m *cachedChatModel) IsCallbacksEnabled() bool {
+	return true
+}
+
+type toolKey struct {
+	Name   string
+	Desc   string
+	Params *openapi3.Schema
+}
+
+// key hashes the model, the tools, the options and the messages of the request
+func (m *cachedChatModel) key(input []*schema.Message, opts []model.Option) (string, error) {
+	o := model.GetCommonOptions(nil, opts...)
+	tools := m.tools
+	if o.Tools != nil {
+		tools = o.Tools
+	}
+	tks := make([]toolKey, 0, len(tools))
+	for _, t := range tools {
+		tk := toolKey{Name: t.Name, Desc: t.Desc}
+		if t.ParamsOneOf != nil {
+			params, err := t.ParamsOneOf.ToOpenAPIV3()
+			if err != nil {
+				return "", err
+			}
+			tk.Params = params
+		}
+		tks = append(tks, tk)
+	}
+	bs, err := json.Marshal(struct {
+		APIType     ModelType
+		ModelName   string
+		Temperature *float32
+		MaxTokens   int
+		Options     *model.Options
+		Tools       []toolKey
+		Messages    []*schema.Message
+	}{m.conf.APIType, m.conf.ModelName, m.conf.Temperature, m.conf.MaxTokens, &model.Options{
+		Temperature: o.Temperature,
+		MaxTokens:   o.MaxTokens,
+		Model:       o.Model,
+		TopP:        o.TopP,
+		Stop:        o.Stop,
+		ToolChoice:  o.ToolChoice,
+	}, tks, input})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(bs)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func (m *cachedChatModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	key, err := m.key(input, opts)
+	if err != nil {
+		log.Error("hash the request failed, skip the cache: %v\n", err)
+		return m.ChatModel.Generate(ctx, input, opts...)
+	}
+	if msg, ok := m.cache.Get(key); ok {
+		log.Debug("response cache hits: %s", key)
+		return msg, nil
+	}
+	msg, err := m.ChatModel.Generate(ctx, input, opts...)
+	if err == nil {
+		m.cache.Set(key, msg)
+	}
+	return msg, err
+}
+
+// Stream replays the cached response as a single chunk.
+// A new response is cached after the stream is fully read without errors
+func (m *cachedChatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	key, err := m.key(input, opts)
+	if err != nil {
+		log.Error("hash the request failed, skip the cache: %v\n", err)
+		return m.ChatModel.Stream(ctx, input, opts...)
+	}
+	if msg, ok := m.cache.Get(key); ok {
+		log.Debug("response cache hits: %s", key)
+		return schema.StreamReaderFromArray([]*schema.Message{msg}), nil
+	}
+	sr, err := m.ChatModel.Stream(ctx, input, opts...)
+	if err != nil {
+		return nil, err
+	}
+	srs := sr.Copy(2)
+	go func() {
+		defer srs[1].Close()
+		var chunks []*schema.Message
+		for {
+			chunk, err := srs[1].Recv()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return
+			}
+			chunks = append(chunks, chunk)
+		}
+		msg, err := schema.ConcatMessages(chunks)
+		if err != nil {
+			log.Error("concat the streamed response failed: %v\n", err)
+			return
+		}
+		m.cache.Set(key, msg)
+	}()
+	return srs[0], nil
+}
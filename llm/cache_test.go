@@ -0,0 +1,122 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package llm
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// countModel counts the calls to streamModel
+type countModel struct {
+	streamModel
+	calls int
+}
+
+func (m *countModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	m.calls++
+	return m.streamModel.Generate(ctx, input, opts...)
+}
+
+func (m *countModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	m.calls++
+	return m.streamModel.Stream(ctx, input, opts...)
+}
+
+func (m *countModel) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	return m, nil
+}
+
+func readAll(t *testing.T, sr *schema.StreamReader[*schema.Message]) string {
+	defer sr.Close()
+	var chunks []*schema.Message
+	for {
+		chunk, err := sr.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		chunks = append(chunks, chunk)
+	}
+	msg, err := schema.ConcatMessages(chunks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return msg.Content
+}
+
+func TestWithResponseCache(t *testing.T) {
+	ctx := context.Background()
+	inner := &countModel{streamModel: streamModel{chunks: []*schema.Message{schema.AssistantMessage("hello ", nil), schema.AssistantMessage("world", nil)}}}
+	cache := NewResponseCache(t.TempDir())
+	conf := ModelConfig{APIType: ModelTypeOpenAI, ModelName: "gpt"}
+	m, err := WithResponseCache(inner, conf, cache).WithTools([]*schema.ToolInfo{{Name: "get_ast_node"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := []*schema.Message{schema.UserMessage("what is it?")}
+
+	for i := 0; i < 2; i++ {
+		msg, err := m.Generate(ctx, input)
+		if err != nil || msg.Content != "hello world" {
+			t.Fatalf("Generate() = %v, %v", msg, err)
+		}
+	}
+	if inner.calls != 1 {
+		t.Errorf("calls = %d, want 1", inner.calls)
+	}
+
+	// another question, streamed
+	input = []*schema.Message{schema.UserMessage("what else?")}
+	sr, err := m.Stream(ctx, input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := readAll(t, sr); got != "hello world" {
+		t.Fatalf("Stream() = %q", got)
+	}
+	// the streamed response is cached asynchronously
+	key, _ := m.(*cachedChatModel).key(input, nil)
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(10 * time.Millisecond) {
+		if _, ok := cache.Get(key); ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the streamed response is not cached")
+		}
+	}
+	sr, err = m.Stream(ctx, input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := readAll(t, sr); got != "hello world" || inner.calls != 2 {
+		t.Errorf("Stream() = %q, calls = %d", got, inner.calls)
+	}
+
+	// other tools miss the cache
+	other, _ := WithResponseCache(inner, conf, cache).WithTools([]*schema.ToolInfo{{Name: "find_references"}})
+	if _, err := other.Generate(ctx, input); err != nil || inner.calls != 3 {
+		t.Errorf("calls = %d, %v", inner.calls, err)
+	}
+}
@@ -16,8 +16,8 @@ package log
 
 import (
 	"fmt"
-	"log"
-	"os"
+
+	core "github.com/cloudwego/abcoder/lang/log"
 )
 
 type Logger interface {
@@ -27,48 +27,42 @@ type Logger interface {
 	Output(calldepth int, s string) error
 }
 
-type StdLogger struct {
-	errlogger   *log.Logger
-	infologger  *log.Logger
-	debuglogger *log.Logger
-}
+// StdLogger writes to lang/log, thus shares its levels, scopes, format and output
+type StdLogger struct{}
 
 func NewStdLogger() *StdLogger {
-	return &StdLogger{
-		errlogger:   log.New(os.Stderr, "[ERROR]", log.Ltime|log.Lshortfile),
-		infologger:  log.New(os.Stderr, "[INFO]", log.Ltime|log.Lshortfile),
-		debuglogger: log.New(os.Stderr, "[DEBUG]", log.Ltime|log.Lshortfile),
-	}
+	return &StdLogger{}
 }
 
 func (l *StdLogger) Infof(s string, args ...interface{}) {
-	l.infologger.Output(3, fmt.Sprintf(s, args...))
+	core.Output(core.InfoLevel, 2, fmt.Sprintf(s, args...))
 }
 
 func (l *StdLogger) Errorf(s string, args ...interface{}) {
-	l.errlogger.Output(3, fmt.Sprintf(s, args...))
+	core.Output(core.ErrorLevel, 2, fmt.Sprintf(s, args...))
 }
 
 func (l *StdLogger) Debugf(s string, args ...interface{}) {
-	l.debuglogger.Output(3, fmt.Sprintf(s, args...))
+	core.Output(core.DebugLevel, 2, fmt.Sprintf(s, args...))
 }
 
 func (l *StdLogger) Output(calldepth int, s string) error {
-	return l.infologger.Output(calldepth+2, s)
+	core.Output(core.InfoLevel, calldepth+1, s)
+	return nil
 }
 
-type LogLevel uint8
+type LogLevel = core.LogLevel
 
 const (
-	ErrorLevel LogLevel = 1
-	InfoLevel  LogLevel = 2
-	DebugLevel LogLevel = 3
+	ErrorLevel = core.ErrorLevel
+	WarnLevel  = core.WarnLevel
+	InfoLevel  = core.InfoLevel
+	DebugLevel = core.DebugLevel
 )
 
-var logLevel LogLevel = ErrorLevel
-
+// SetLogLevel sets the default level, which is shared with lang/log
 func SetLogLevel(level LogLevel) {
-	logLevel = level
+	core.SetLogLevel(level)
 }
 
 func SetDefaultLogger(logger Logger) {
@@ -78,14 +72,14 @@ func SetDefaultLogger(logger Logger) {
 var defaultLogger Logger = NewStdLogger()
 
 func Info(s string, args ...interface{}) {
-	if logLevel < InfoLevel {
+	if !core.Enabled(InfoLevel, 1) {
 		return
 	}
 	defaultLogger.Infof(s, args...)
 }
 
 func Info_skip(calldepth int, s string, args ...interface{}) {
-	if logLevel < InfoLevel {
+	if !core.Enabled(InfoLevel, 1+calldepth) {
 		return
 	}
 	s = fmt.Sprintf(s, args...)
@@ -93,23 +87,50 @@ func Info_skip(calldepth int, s string, args ...interface{}) {
 }
 
 func Debug(s string, args ...interface{}) {
-	if logLevel < DebugLevel {
+	if !core.Enabled(DebugLevel, 1) {
 		return
 	}
 	defaultLogger.Debugf(s, args...)
 }
 
 func Error(s string, args ...interface{}) {
-	if logLevel < ErrorLevel {
+	if !core.Enabled(ErrorLevel, 1) {
 		return
 	}
 	defaultLogger.Errorf(s, args...)
 }
 
 func Error_skip(calldepth int, s string, args ...interface{}) {
-	if logLevel < ErrorLevel {
+	if !core.Enabled(ErrorLevel, 1+calldepth) {
 		return
 	}
 	s = fmt.Sprintf(s, args...)
+	if _, ok := defaultLogger.(*StdLogger); ok {
+		core.Output(ErrorLevel, 1+calldepth, s)
+		return
+	}
 	defaultLogger.Output(1+calldepth, s)
 }
+
+// Warn and the structured ones below always write to lang/log, since Logger has no such methods
+
+func Warn(s string, args ...interface{}) {
+	core.Output(WarnLevel, 1, fmt.Sprintf(s, args...))
+}
+
+// Infow writes the message with the key-value fields, see lang/log.Infow
+func Infow(msg string, kv ...any) {
+	core.Output(InfoLevel, 1, msg, kv...)
+}
+
+func Debugw(msg string, kv ...any) {
+	core.Output(DebugLevel, 1, msg, kv...)
+}
+
+func Warnw(msg string, kv ...any) {
+	core.Output(WarnLevel, 1, msg, kv...)
+}
+
+func Errorw(msg string, kv ...any) {
+	core.Output(ErrorLevel, 1, msg, kv...)
+}
@@ -0,0 +1,342 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	alog "github.com/cloudwego/abcoder/llm/log"
+	"github.com/cloudwego/abcoder/llm/tool"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"gopkg.in/yaml.v3"
+)
+
+// AnyClient names the permission of the clients which have no permissions of their own
+const AnyClient = "*"
+
+// ClientHeader is the HTTP header naming the client, since clientInfo is not kept by HTTP sessions
+const ClientHeader = "X-Abcoder-Client"
+
+// Permission restricts the tools and repos a client can use
+type Permission struct {
+	// AllowTools are the tools the client can call, all tools if empty
+	AllowTools []string `yaml:"allow_tools" json:"allow_tools,omitempty"`
+	// DenyTools are the tools the client can't call, which overrides AllowTools
+	DenyTools []string `yaml:"deny_tools" json:"deny_tools,omitempty"`
+	// AllowRepos are the repos (names or aliases) the client can access, all repos if empty
+	AllowRepos []string `yaml:"allow_repos" json:"allow_repos,omitempty"`
+	// ReadOnly denies the tools which are not annotated as read-only (readOnlyHint), i.e. the write tools
+	ReadOnly bool `yaml:"read_only" json:"read_only,omitempty"`
+}
+
+// Permissions maps the client name to its permission.
+// The client is named by clientInfo.name of its initialize request, or by ClientHeader over HTTP.
+// Clients without their own permission take the one of AnyClient, and are denied everything if there is none.
+//
+// NOTICE: the names are asserted by the clients themselves, thus it keeps well-behaved clients within their scopes,
+// but is not an authentication. Serve the untrusted clients with a separate server if needed
+type Permissions map[string]Permission
+
+// LoadPermissions reads the permissions from a yaml or json file, like
+//
+//	claude-code:
+//	  read_only: true
+//	"*":
+//	  allow_tools: [list_repos, get_repo_structure]
+//	  allow_repos: [localsession]
+func LoadPermissions(path string) (Permissions, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read permissions %s failed: %v", path, err)
+	}
+	var ret Permissions
+	if err := yaml.Unmarshal(data, &ret); err != nil {
+		return nil, fmt.Errorf("parse permissions %s failed: %v", path, err)
+	}
+	if ret == nil {
+		ret = Permissions{}
+	}
+	return ret, nil
+}
+
+// AuditEntry records a tool call
+type AuditEntry struct {
+	Time   time.Time `json:"time"`
+	Client string    `json:"client"`
+	Tool   string    `json:"tool"`
+	// Repos are the repos given by repo_name or repo_names
+	Repos []string `json:"repos,omitempty"`
+	// Denied is the reason why the call is denied, empty if it is allowed
+	Denied     string `json:"denied,omitempty"`
+	IsError    bool   `json:"is_error,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+type clientKey struct{}
+
+// withClient names the client of the request, overriding the clientInfo of the session
+func withClient(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, clientKey{}, name)
+}
+
+func clientName(ctx context.Context) string {
+	if name, _ := ctx.Value(clientKey{}).(string); name != "" {
+		return name
+	}
+	if s, ok := server.ClientSessionFromContext(ctx).(server.SessionWithClientInfo); ok {
+		return s.GetClientInfo().Name
+	}
+	return ""
+}
+
+// accessControl enforces the permissions before dispatching the tool calls, and audits every call
+type accessControl struct {
+	perms Permissions
+	// tool => if it is annotated as read-only
+	readOnly map[string]bool
+	// resolves repo_name to the repo name
+	resolve func(string) (string, error)
+
+	mu    sync.Mutex
+	audit io.Writer
+}
+
+func newAccessControl(opts ServerOptions, tools []Tool, resolve func(string) (string, error)) *accessControl {
+	ac := &accessControl{
+		perms:    opts.Permissions,
+		readOnly: make(map[string]bool, len(tools)),
+		resolve:  resolve,
+		audit:    opts.AuditLog,
+	}
+	for _, t := range tools {
+		ac.readOnly[t.Name] = t.Annotations.ReadOnlyHint != nil && *t.Annotations.ReadOnlyHint
+	}
+	return ac
+}
+
+// repoName resolves the repo as the tools do, since repo_name may be an alias or a part of the name.
+// Unknown repos keep their names, whose calls fail anyway
+func (ac *accessControl) repoName(name string) string {
+	if real, err := ac.resolve(name); err == nil {
+		return real
+	}
+	return name
+}
+
+// requestRepos returns the repos given by repo_name and repo_names
+func requestRepos(args map[string]any) []string {
+	var ret []string
+	if name, ok := args["repo_name"].(string); ok && name != "" {
+		ret = append(ret, name)
+	}
+	if names, ok := args["repo_names"].([]any); ok {
+		for _, n := range names {
+			if name, ok := n.(string); ok && name != "" {
+				ret = append(ret, name)
+			}
+		}
+	}
+	return ret
+}
+
+// check returns the reason why the call is denied, or empty if it is allowed
+func (ac *accessControl) check(client string, req mcp.CallToolRequest, repos []string) (*Permission, string) {
+	perm, denied := ac.permission(client)
+	if perm == nil {
+		return nil, denied
+	}
+	name := req.Params.Name
+	for _, t := range perm.DenyTools {
+		if t == name {
+			return nil, fmt.Sprintf("tool %s is denied", name)
+		}
+	}
+	if len(perm.AllowTools) > 0 {
+		allowed := false
+		for _, t := range perm.AllowTools {
+			allowed = allowed || t == name
+		}
+		if !allowed {
+			return nil, fmt.Sprintf("tool %s is not allowed", name)
+		}
+	}
+	if perm.ReadOnly && !ac.readOnly[name] {
+		return nil, fmt.Sprintf("tool %s is not read-only", name)
+	}
+	for _, repo := range repos {
+		if !ac.repoAllowed(perm, repo) {
+			return nil, fmt.Sprintf("repo %s is not allowed", repo)
+		}
+	}
+	return perm, ""
+}
+
+// permission returns the permission of the client, or the reason why it has none
+func (ac *accessControl) permission(client string) (*Permission, string) {
+	perm, ok := ac.perms[client]
+	if !ok {
+		if perm, ok = ac.perms[AnyClient]; !ok {
+			return nil, fmt.Sprintf("client %q has no permission", client)
+		}
+	}
+	return &perm, ""
+}
+
+// checkRepo enforces the permission of the client to read the repo by a resource or a prompt, and audits it.
+// Only the repos are restricted, since the tool permissions do not apply to them
+func (ac *accessControl) checkRepo(ctx context.Context, name string, repo string) error {
+	entry := AuditEntry{
+		Time:   time.Now(),
+		Client: clientName(ctx),
+		Tool:   name,
+		Repos:  []string{repo},
+	}
+	if ac.perms != nil {
+		perm, denied := ac.permission(entry.Client)
+		if perm != nil && !ac.repoAllowed(perm, repo) {
+			denied = fmt.Sprintf("repo %s is not allowed", repo)
+		}
+		if denied != "" {
+			entry.Denied = denied
+			ac.log(entry)
+			return fmt.Errorf("permission denied: %s", denied)
+		}
+	}
+	ac.log(entry)
+	return nil
+}
+
+func (ac *accessControl) repoAllowed(perm *Permission, repo string) bool {
+	if len(perm.AllowRepos) == 0 {
+		return true
+	}
+	repo = ac.repoName(repo)
+	for _, r := range perm.AllowRepos {
+		if ac.repoName(r) == repo {
+			return true
+		}
+	}
+	return false
+}
+
+// middleware enforces the permissions if any, and audits the call
+func (ac *accessControl) middleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		args := req.GetArguments()
+		entry := AuditEntry{
+			Time:   start,
+			Client: clientName(ctx),
+			Tool:   req.Params.Name,
+			Repos:  requestRepos(args),
+		}
+		var perm *Permission
+		if ac.perms != nil {
+			perm, entry.Denied = ac.check(entry.Client, req, entry.Repos)
+			if entry.Denied != "" {
+				ac.log(entry)
+				return mcp.NewToolResultError("permission denied: " + entry.Denied), nil
+			}
+			if len(perm.AllowRepos) > 0 && req.Params.Name == tool.ToolFindSymbolAcrossRepos && len(entry.Repos) == 0 {
+				// search the allowed repos instead of all
+				repos := make([]any, 0, len(perm.AllowRepos))
+				for _, r := range perm.AllowRepos {
+					repos = append(repos, ac.repoName(r))
+				}
+				if args == nil {
+					args = map[string]any{}
+				}
+				args["repo_names"] = repos
+				req.Params.Arguments = args
+			}
+		}
+		res, err := next(ctx, req)
+		if err == nil && res != nil && perm != nil && len(perm.AllowRepos) > 0 && req.Params.Name == tool.ToolListRepos {
+			res = ac.filterRepos(perm, res)
+		}
+		entry.IsError = err != nil || res != nil && res.IsError
+		entry.DurationMS = time.Since(start).Milliseconds()
+		ac.log(entry)
+		return res, err
+	}
+}
+
+// filterRepos removes the repos, their aliases and snapshots not allowed from the result of list_repos
+func (ac *accessControl) filterRepos(perm *Permission, res *mcp.CallToolResult) *mcp.CallToolResult {
+	if len(res.Content) != 1 {
+		return res
+	}
+	text, ok := res.Content[0].(mcp.TextContent)
+	if !ok {
+		return res
+	}
+	var resp tool.ListReposResp
+	if err := json.Unmarshal([]byte(text.Text), &resp); err != nil {
+		return res
+	}
+	names := resp.RepoNames[:0]
+	for _, name := range resp.RepoNames {
+		if ac.repoAllowed(perm, name) {
+			names = append(names, name)
+		}
+	}
+	resp.RepoNames = names
+	for alias, name := range resp.Aliases {
+		if !ac.repoAllowed(perm, name) {
+			delete(resp.Aliases, alias)
+		}
+	}
+	repos := resp.Repos[:0]
+	for _, repo := range resp.Repos {
+		if ac.repoAllowed(perm, repo.RepoName) {
+			repos = append(repos, repo)
+		}
+	}
+	resp.Repos = repos
+	js, err := json.Marshal(resp)
+	if err != nil {
+		return res
+	}
+	return &mcp.CallToolResult{Content: []mcp.Content{mcp.NewTextContent(string(js))}}
+}
+
+func (ac *accessControl) log(entry AuditEntry) {
+	if ac.audit == nil {
+		status := "ok"
+		if entry.Denied != "" {
+			status = "denied: " + entry.Denied
+		} else if entry.IsError {
+			status = "error"
+		}
+		alog.Info("[audit] client=%q tool=%s repos=%s %s (%dms)", entry.Client, entry.Tool, strings.Join(entry.Repos, ","), status, entry.DurationMS)
+		return
+	}
+	js, _ := json.Marshal(entry)
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+	if _, err := ac.audit.Write(append(js, '\n')); err != nil {
+		alog.Error("write audit log failed: %v", err)
+	}
+}
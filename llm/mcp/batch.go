@@ -0,0 +1,136 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/cloudwego/abcoder/llm/tool"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	ToolBatch = "batch"
+	DescBatch = "Call several read tools in one round trip instead of one by one, e.g. get_ast_node of the nodes in different repos, or get_file_structure of several files. The calls run concurrently and independently, the results are in the order of the calls. Input: calls of tool names and their arguments, at most 20. Output: the result or the error of each call."
+)
+
+var SchemaBatch = tool.GetJSONSchema(BatchReq{})
+
+const (
+	// maxBatchCalls limits the calls of a batch
+	maxBatchCalls = 20
+	// batchConcurrency limits the calls of a batch running at the same time
+	batchConcurrency = 8
+)
+
+type BatchCall struct {
+	Tool      string         `json:"tool" jsonschema:"description=the name of the read tool to call, like get_ast_node"`
+	Arguments map[string]any `json:"arguments,omitempty" jsonschema:"description=the arguments of the tool, as if it is called alone"`
+}
+
+type BatchReq struct {
+	Calls []BatchCall `json:"calls" jsonschema:"description=the tool calls, at most 20"`
+}
+
+type BatchResult struct {
+	Tool   string          `json:"tool"`
+	Result json.RawMessage `json:"result,omitempty" jsonschema:"description=the output of the tool"`
+	Error  string          `json:"error,omitempty" jsonschema:"description=the error of the call, the other calls are not affected"`
+}
+
+type BatchResp struct {
+	Results []BatchResult `json:"results"`
+}
+
+// batcher dispatches the calls of a batch to the read tools.
+// Each call goes through the middleware, thus it is checked by the permissions and audited as if it is called alone
+type batcher struct {
+	handlers   map[string]server.ToolHandlerFunc
+	middleware server.ToolHandlerMiddleware
+}
+
+func newBatcher(tools []Tool) *batcher {
+	b := &batcher{handlers: make(map[string]server.ToolHandlerFunc, len(tools))}
+	for _, t := range tools {
+		b.handlers[t.Name] = t.Handler
+	}
+	return b
+}
+
+func (b *batcher) tool() Tool {
+	return NewTool(ToolBatch, DescBatch, SchemaBatch, b.Call)
+}
+
+// Call runs the calls concurrently, a failed call only sets the error of its result
+func (b *batcher) Call(ctx context.Context, req BatchReq) (*BatchResp, error) {
+	if len(req.Calls) == 0 {
+		return nil, fmt.Errorf("no calls in the batch")
+	}
+	if len(req.Calls) > maxBatchCalls {
+		return nil, fmt.Errorf("too many calls in the batch: %d, at most %d", len(req.Calls), maxBatchCalls)
+	}
+	resp := &BatchResp{Results: make([]BatchResult, len(req.Calls))}
+	sem := make(chan struct{}, batchConcurrency)
+	var wg sync.WaitGroup
+	for i, call := range req.Calls {
+		resp.Results[i].Tool = call.Tool
+		handler, ok := b.handlers[call.Tool]
+		if !ok {
+			resp.Results[i].Error = fmt.Sprintf("unknown tool %q, only the read tools can be batched", call.Tool)
+			continue
+		}
+		if b.middleware != nil {
+			handler = b.middleware(handler)
+		}
+		wg.Add(1)
+		go func(ret *BatchResult, call BatchCall) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			var r mcp.CallToolRequest
+			r.Params.Name = call.Tool
+			r.Params.Arguments = call.Arguments
+			res, err := handler(ctx, r)
+			if err != nil {
+				ret.Error = err.Error()
+				return
+			}
+			var texts []string
+			for _, c := range res.Content {
+				if text, ok := c.(mcp.TextContent); ok {
+					texts = append(texts, text.Text)
+				}
+			}
+			text := strings.Join(texts, "\n")
+			switch {
+			case res.IsError:
+				ret.Error = text
+			case json.Valid([]byte(text)):
+				ret.Result = json.RawMessage(text)
+			default:
+				ret.Result, _ = json.Marshal(text)
+			}
+		}(&resp.Results[i], call)
+	}
+	wg.Wait()
+	return resp, nil
+}
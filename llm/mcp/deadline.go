@@ -0,0 +1,150 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// MethodCancelled is the notification of the clients to cancel an in-flight request
+const MethodCancelled = "notifications/cancelled"
+
+// requestIDMeta is the _meta field of a tool call carrying its JSON-RPC id from the hook to the handler,
+// since mcp-go does not pass the id to the handlers
+const requestIDMeta = "abcoder/request_id"
+
+// partialResultGrace is how long a stopped call is waited for the results it collected so far
+const partialResultGrace = 200 * time.Millisecond
+
+// deadlines bounds the time of the tool calls, and cancels them on the notifications/cancelled of the clients.
+// The tools get the deadline by their contexts: the ones checking it return the results collected so far marked as truncated,
+// and the others are abandoned with an error result soon after the deadline
+type deadlines struct {
+	timeout  time.Duration
+	timeouts map[string]time.Duration
+
+	mu       sync.Mutex
+	inflight map[string]context.CancelCauseFunc
+}
+
+func newDeadlines(options ServerOptions) *deadlines {
+	return &deadlines{
+		timeout:  options.ToolTimeout,
+		timeouts: options.ToolTimeouts,
+		inflight: map[string]context.CancelCauseFunc{},
+	}
+}
+
+func (d *deadlines) timeoutOf(tool string) time.Duration {
+	if timeout, ok := d.timeouts[tool]; ok {
+		return timeout
+	}
+	return d.timeout
+}
+
+// inflightKey identifies a request among the sessions
+func inflightKey(ctx context.Context, id any) string {
+	var session string
+	if s := server.ClientSessionFromContext(ctx); s != nil {
+		session = s.SessionID()
+	}
+	return fmt.Sprintf("%s/%v", session, id)
+}
+
+// beforeCallTool stashes the request id into the _meta of the call, see requestIDMeta
+func (d *deadlines) beforeCallTool(_ context.Context, id any, req *mcp.CallToolRequest) {
+	if id == nil {
+		return
+	}
+	if req.Params.Meta == nil {
+		req.Params.Meta = &mcp.Meta{}
+	}
+	if req.Params.Meta.AdditionalFields == nil {
+		req.Params.Meta.AdditionalFields = map[string]any{}
+	}
+	req.Params.Meta.AdditionalFields[requestIDMeta] = id
+}
+
+// handleCancelled cancels the in-flight call of the notification
+func (d *deadlines) handleCancelled(ctx context.Context, notification mcp.JSONRPCNotification) {
+	id, ok := notification.Params.AdditionalFields["requestId"]
+	if !ok {
+		return
+	}
+	d.mu.Lock()
+	cancel := d.inflight[inflightKey(ctx, id)]
+	d.mu.Unlock()
+	if cancel != nil {
+		reason, _ := notification.Params.AdditionalFields["reason"].(string)
+		cancel(fmt.Errorf("cancelled by the client: %s", reason))
+	}
+}
+
+// middleware runs the call with the deadline of the tool, and returns once the call is stopped
+// even if the tool does not check its context
+func (d *deadlines) middleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		ctx, cancel := context.WithCancelCause(ctx)
+		defer cancel(nil)
+		if req.Params.Meta != nil {
+			if id, ok := req.Params.Meta.AdditionalFields[requestIDMeta]; ok {
+				key := inflightKey(ctx, id)
+				d.mu.Lock()
+				d.inflight[key] = cancel
+				d.mu.Unlock()
+				defer func() {
+					d.mu.Lock()
+					delete(d.inflight, key)
+					d.mu.Unlock()
+				}()
+			}
+		}
+		if timeout := d.timeoutOf(req.Params.Name); timeout > 0 {
+			var cancelTimeout context.CancelFunc
+			ctx, cancelTimeout = context.WithTimeoutCause(ctx, timeout, fmt.Errorf("%s timed out after %v", req.Params.Name, timeout))
+			defer cancelTimeout()
+		}
+
+		type result struct {
+			res *mcp.CallToolResult
+			err error
+		}
+		done := make(chan result, 1)
+		go func() {
+			res, err := next(ctx, req)
+			done <- result{res, err}
+		}()
+		select {
+		case r := <-done:
+			return r.res, r.err
+		case <-ctx.Done():
+		}
+		// the tools checking the context return their partial results soon
+		select {
+		case r := <-done:
+			return r.res, r.err
+		case <-time.After(partialResultGrace):
+			return mcp.NewToolResultError(fmt.Sprintf("the call of %s is stopped: %v", req.Params.Name, context.Cause(ctx))), nil
+		}
+	}
+}
@@ -23,13 +23,23 @@ import (
 	"github.com/cloudwego/abcoder/internal/utils"
 	"github.com/cloudwego/abcoder/llm/prompt"
 	"github.com/cloudwego/abcoder/llm/tool"
+	"github.com/invopop/jsonschema"
 	"github.com/mark3labs/mcp-go/mcp"
 )
 
+// NewTool creates a tool from its handler. The arguments are validated against the schema before calling the handler,
+// and the errors are returned as the results, thus the model can correct them
 func NewTool[R any, T any](name string, desc string, schema json.RawMessage, handler func(ctx context.Context, req R) (*T, error)) Tool {
+	var sch jsonschema.Schema
+	if err := json.Unmarshal(schema, &sch); err != nil {
+		panic(err)
+	}
 	return Tool{ // get_repo_structure
 		Tool: mcp.NewToolWithRawSchema(name, desc, schema),
 		Handler: func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			if err := tool.ValidateArguments(&sch, request.GetRawArguments()); err != nil {
+				return mcp.NewToolResultError("invalid arguments: " + err.Error() + ". See the input schema of " + name), nil
+			}
 			var req R
 			if err := request.BindArguments(&req); err != nil {
 				return nil, err
@@ -55,15 +65,34 @@ func NewTool[R any, T any](name string, desc string, schema json.RawMessage, han
 	}
 }
 
-func getASTTools(opts tool.ASTReadToolsOptions) []Tool {
-	ast := tool.NewASTReadTools(opts)
-	return []Tool{
+func getASTTools(ast *tool.ASTReadTools) []Tool {
+	tools := []Tool{
 		NewTool(tool.ToolListRepos, tool.DescListRepos, tool.SchemaListRepos, ast.ListRepos),
 		NewTool(tool.ToolGetRepoStructure, tool.DescGetRepoStructure, tool.SchemaGetRepoStructure, ast.GetRepoStructure),
+		NewTool(tool.ToolGetRepoStats, tool.DescGetRepoStats, tool.SchemaGetRepoStats, ast.GetRepoStats),
 		NewTool(tool.ToolGetPackageStructure, tool.DescGetPackageStructure, tool.SchemaGetPackageStructure, ast.GetPackageStructure),
 		NewTool(tool.ToolGetFileStructure, tool.DescGetFileStructure, tool.SchemaGetFileStructure, ast.GetFileStructure),
 		NewTool(tool.ToolGetASTNode, tool.DescGetASTNode, tool.SchemaGetASTNode, ast.GetASTNode),
+		NewTool(tool.ToolGetTestsForNode, tool.DescGetTestsForNode, tool.SchemaGetTestsForNode, ast.GetTestsForNode),
+		NewTool(tool.ToolFindReferences, tool.DescFindReferences, tool.SchemaFindReferences, ast.FindReferences),
+		NewTool(tool.ToolFindSymbolAcrossRepos, tool.DescFindSymbolAcrossRepos, tool.SchemaFindSymbolAcrossRepos, ast.FindSymbolAcrossRepos),
+		NewTool(tool.ToolGetNodeMetrics, tool.DescGetNodeMetrics, tool.SchemaGetNodeMetrics, ast.GetNodeMetrics),
+		NewTool(tool.ToolChunkRepo, tool.DescChunkRepo, tool.SchemaChunkRepo, ast.ChunkRepo),
+		NewTool(tool.ToolGetNodeOwners, tool.DescGetNodeOwners, tool.SchemaGetNodeOwners, ast.GetNodeOwners),
+		NewTool(tool.ToolFindUnreachableNodes, tool.DescFindUnreachableNodes, tool.SchemaFindUnreachableNodes, ast.FindUnreachableNodes),
+		NewTool(tool.ToolGetDependencies, tool.DescGetDependencies, tool.SchemaGetDependencies, ast.GetDependencies),
+		NewTool(tool.ToolLocateNodeByPosition, tool.DescLocateNodeByPosition, tool.SchemaLocateNodeByPosition, ast.LocateNodeByPosition),
+		NewTool(tool.ToolGetPublicAPI, tool.DescGetPublicAPI, tool.SchemaGetPublicAPI, ast.GetPublicAPI),
+		NewTool(tool.ToolGetDiagram, tool.DescGetDiagram, tool.SchemaGetDiagram, ast.GetDiagram),
+		NewTool(tool.ToolGetImplementations, tool.DescGetImplementations, tool.SchemaGetImplementations, ast.GetImplementations),
+		NewTool(tool.ToolListTodos, tool.DescListTodos, tool.SchemaListTodos, ast.ListTodos),
+		NewTool(tool.ToolFindErrorOrigins, tool.DescFindErrorOrigins, tool.SchemaFindErrorOrigins, ast.FindErrorOrigins),
+	}
+	// the AST tools never modify the ASTs, thus they are allowed by read-only permissions
+	for i := range tools {
+		tools[i].Annotations.ReadOnlyHint = mcp.ToBoolPtr(true)
 	}
+	return tools
 }
 
 func handleAnalyzeRepoPrompt(
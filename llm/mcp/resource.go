@@ -0,0 +1,328 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/cloudwego/abcoder/internal/utils"
+	"github.com/cloudwego/abcoder/lang/uniast"
+	"github.com/cloudwego/abcoder/llm/prompt"
+	"github.com/cloudwego/abcoder/llm/tool"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// the resources of each repo, the name is put last since it contains slashes
+const (
+	ResourceReadme   = "readme"
+	ResourceModules  = "modules"
+	ResourcePackages = "packages"
+)
+
+// ResourceURI returns the URI of the resource of the repo, like `abcoder://modules/github.com/a/b`
+func ResourceURI(kind string, repo string) string {
+	return "abcoder://" + kind + "/" + repo
+}
+
+type repoResource struct {
+	kind string
+	desc string
+	mime string
+	read func(repo *uniast.Repository) (string, error)
+}
+
+var repoResources = []repoResource{
+	{ResourceReadme, "the README of the repository", "text/markdown", readReadme},
+	{ResourceModules, "the modules of the repository with their languages, versions, dependencies and node counts", "application/json", readModules},
+	{ResourcePackages, "the packages of the repository with their files and node counts", "application/json", readPackages},
+}
+
+// addResources serves the resources of the repos by templates.
+// The resources of the repos loaded at start are listed as well, unless the clients are restricted,
+// since the list is not filtered by their permissions
+func addResources(s *server.MCPServer, ast *tool.ASTReadTools, ac *accessControl) {
+	var repos []string
+	if ac.perms == nil {
+		if resp, err := ast.ListRepos(context.Background(), tool.ListReposReq{}); err == nil {
+			repos = resp.RepoNames
+		}
+	}
+	for _, r := range repoResources {
+		handler := resourceHandler(r, ast, ac)
+		s.AddResourceTemplate(mcp.NewResourceTemplate(ResourceURI(r.kind, "{+repo_name}"), r.kind,
+			mcp.WithTemplateDescription(r.desc), mcp.WithTemplateMIMEType(r.mime)), handler)
+	}
+	for _, repo := range repos {
+		s.AddResources(listedResources(ast, ac, repo)...)
+	}
+}
+
+// listedResources returns the resources of the repo to list
+func listedResources(ast *tool.ASTReadTools, ac *accessControl, repo string) []server.ServerResource {
+	ret := make([]server.ServerResource, 0, len(repoResources))
+	for _, r := range repoResources {
+		ret = append(ret, server.ServerResource{
+			Resource: mcp.NewResource(ResourceURI(r.kind, repo), repo+" "+r.kind,
+				mcp.WithResourceDescription(r.desc), mcp.WithMIMEType(r.mime)),
+			Handler: resourceHandler(r, ast, ac),
+		})
+	}
+	return ret
+}
+
+func resourceHandler(r repoResource, ast *tool.ASTReadTools, ac *accessControl) func(context.Context, mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	return func(ctx context.Context, req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		name := strings.TrimPrefix(req.Params.URI, ResourceURI(r.kind, ""))
+		if err := ac.checkRepo(ctx, "resource:"+r.kind, name); err != nil {
+			return nil, err
+		}
+		repo, err := ast.GetRepo(name)
+		if err != nil {
+			return nil, err
+		}
+		text, err := r.read(repo)
+		if err != nil {
+			return nil, err
+		}
+		return []mcp.ResourceContents{mcp.TextResourceContents{URI: req.Params.URI, MIMEType: r.mime, Text: text}}, nil
+	}
+}
+
+// NotificationRepoChanged is sent to the clients once a repo is added, updated or removed,
+// whose params are tool.RepoEvent, thus the clients can drop the results of the tools on the repo
+const NotificationRepoChanged = "notifications/abcoder/repo_changed"
+
+// notifyRepoChanges keeps the listed resources in sync with the repos, which notifies the clients by
+// `notifications/resources/list_changed`, and sends `notifications/resources/updated` of the resources
+// and NotificationRepoChanged once a repo is reloaded.
+// Nothing is sent if the clients are restricted, since the notifications are not filtered by their permissions
+func notifyRepoChanges(s *server.MCPServer, ast *tool.ASTReadTools, ac *accessControl) {
+	if ac.perms != nil {
+		return
+	}
+	ast.Subscribe(func(ev tool.RepoEvent) {
+		switch ev.Op {
+		case tool.RepoAdded:
+			s.AddResources(listedResources(ast, ac, ev.RepoName)...)
+		case tool.RepoRemoved:
+			for _, r := range repoResources {
+				s.RemoveResource(ResourceURI(r.kind, ev.RepoName))
+			}
+		case tool.RepoUpdated:
+			for _, r := range repoResources {
+				s.SendNotificationToAllClients(mcp.MethodNotificationResourceUpdated, map[string]any{"uri": ResourceURI(r.kind, ev.RepoName)})
+			}
+		}
+		s.SendNotificationToAllClients(NotificationRepoChanged, map[string]any{"repo_name": ev.RepoName, "op": ev.Op})
+	})
+}
+
+// readReadme reads the README under the repo path, which must be on this machine
+func readReadme(repo *uniast.Repository) (string, error) {
+	entries, err := os.ReadDir(repo.Path)
+	if err != nil {
+		return "", fmt.Errorf("the sources of %s are not found at %s", repo.Name, repo.Path)
+	}
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasPrefix(strings.ToUpper(e.Name()), "README") {
+			bs, err := os.ReadFile(filepath.Join(repo.Path, e.Name()))
+			return string(bs), err
+		}
+	}
+	return "", fmt.Errorf("no README in %s", repo.Path)
+}
+
+type moduleSummary struct {
+	ModPath      string          `json:"mod_path"`
+	Language     uniast.Language `json:"language,omitempty"`
+	Version      string          `json:"version,omitempty"`
+	Dir          string          `json:"dir"`
+	Packages     int             `json:"packages"`
+	Files        int             `json:"files"`
+	Functions    int             `json:"functions"`
+	Types        int             `json:"types"`
+	Vars         int             `json:"vars"`
+	Dependencies []string        `json:"dependencies,omitempty"`
+}
+
+// readModules summarizes the internal modules
+func readModules(repo *uniast.Repository) (string, error) {
+	var mods []moduleSummary
+	for path, mod := range repo.Modules {
+		if mod.IsExternal() {
+			continue
+		}
+		m := moduleSummary{ModPath: path, Language: mod.Language, Version: mod.Version, Dir: mod.Dir, Packages: len(mod.Packages), Files: len(mod.Files)}
+		for _, pkg := range mod.Packages {
+			m.Functions += len(pkg.Functions)
+			m.Types += len(pkg.Types)
+			m.Vars += len(pkg.Vars)
+		}
+		for _, dep := range mod.Dependencies {
+			m.Dependencies = append(m.Dependencies, dep)
+		}
+		sort.Strings(m.Dependencies)
+		mods = append(mods, m)
+	}
+	sort.Slice(mods, func(i, j int) bool { return mods[i].ModPath < mods[j].ModPath })
+	return utils.MarshalJSONIndent(mods)
+}
+
+type packageSummary struct {
+	ModPath uniast.ModPath `json:"mod_path"`
+	PkgPath uniast.PkgPath `json:"pkg_path"`
+	Files   []string       `json:"files"`
+	Nodes   int            `json:"nodes"`
+}
+
+// readPackages lists the packages of the internal modules
+func readPackages(repo *uniast.Repository) (string, error) {
+	var pkgs []packageSummary
+	for path, mod := range repo.Modules {
+		if mod.IsExternal() {
+			continue
+		}
+		for _, pkg := range mod.Packages {
+			p := packageSummary{ModPath: path, PkgPath: pkg.PkgPath, Nodes: len(pkg.Functions) + len(pkg.Types) + len(pkg.Vars)}
+			files := map[string]bool{}
+			for _, f := range pkg.Functions {
+				files[f.File] = true
+			}
+			for _, t := range pkg.Types {
+				files[t.File] = true
+			}
+			for _, v := range pkg.Vars {
+				files[v.File] = true
+			}
+			for f := range files {
+				p.Files = append(p.Files, f)
+			}
+			sort.Strings(p.Files)
+			pkgs = append(pkgs, p)
+		}
+	}
+	sort.Slice(pkgs, func(i, j int) bool {
+		if pkgs[i].ModPath != pkgs[j].ModPath {
+			return pkgs[i].ModPath < pkgs[j].ModPath
+		}
+		return pkgs[i].PkgPath < pkgs[j].PkgPath
+	})
+	return utils.MarshalJSONIndent(pkgs)
+}
+
+// the prompts grounded in the ASTs, see prompt.MCPPrompt
+const (
+	PromptExplainNode      = "explain_node"
+	PromptTraceRequestPath = "trace_request_path"
+)
+
+// addPrompts serves the prompts, whose templates are filled with the codes of the nodes
+func addPrompts(s *server.MCPServer, ast *tool.ASTReadTools, ac *accessControl) {
+	s.AddPrompt(mcp.NewPrompt(PromptExplainNode,
+		mcp.WithPromptDescription("Explain what a node (function, type or var) does, how it is used and its pitfalls"),
+		mcp.WithArgument("repo_name", mcp.ArgumentDescription("the name of the repository"), mcp.RequiredArgument()),
+		mcp.WithArgument("node_id", mcp.ArgumentDescription("the identity of the node as `mod_path?pkg_path#name`"), mcp.RequiredArgument()),
+	), func(ctx context.Context, req mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		args := req.Params.Arguments
+		if err := ac.checkRepo(ctx, "prompt:"+PromptExplainNode, args["repo_name"]); err != nil {
+			return nil, err
+		}
+		node, err := getNode(ctx, ast, args["repo_name"], args["node_id"])
+		if err != nil {
+			return nil, err
+		}
+		return renderPrompt(PromptExplainNode, "Explain "+node.Name, map[string]any{
+			"RepoName": args["repo_name"],
+			"ModPath":  node.ModPath,
+			"PkgPath":  node.PkgPath,
+			"Name":     node.Name,
+			"Type":     strings.ToLower(node.Type),
+			"File":     node.File,
+			"Line":     node.Line,
+			"Codes":    node.Codes,
+		})
+	})
+
+	s.AddPrompt(mcp.NewPrompt(PromptTraceRequestPath,
+		mcp.WithPromptDescription("Trace the path of a request from an entry (like an HTTP handler) through the repository"),
+		mcp.WithArgument("repo_name", mcp.ArgumentDescription("the name of the repository"), mcp.RequiredArgument()),
+		mcp.WithArgument("entry", mcp.ArgumentDescription("the entry of the request, a node identity as `mod_path?pkg_path#name` or a route like `POST /users`"), mcp.RequiredArgument()),
+		mcp.WithArgument("target", mcp.ArgumentDescription("where to stop tracing, like a database table or a downstream service")),
+	), func(ctx context.Context, req mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		args := req.Params.Arguments
+		if err := ac.checkRepo(ctx, "prompt:"+PromptTraceRequestPath, args["repo_name"]); err != nil {
+			return nil, err
+		}
+		data := map[string]any{
+			"RepoName": args["repo_name"],
+			"Entry":    args["entry"],
+			"Target":   args["target"],
+		}
+		// a route is left to the model to find
+		if strings.Contains(args["entry"], "#") {
+			node, err := getNode(ctx, ast, args["repo_name"], args["entry"])
+			if err != nil {
+				return nil, err
+			}
+			data["Codes"] = node.Codes
+		}
+		return renderPrompt(PromptTraceRequestPath, "Trace the request from "+args["entry"], data)
+	})
+}
+
+// getNode gets the node with its codes, the error tells why it is not found
+func getNode(ctx context.Context, ast *tool.ASTReadTools, repo string, id string) (*tool.NodeStruct, error) {
+	resp, err := ast.GetASTNode(ctx, tool.GetASTNodeReq{
+		RepoName: repo,
+		NodeIDs:  []tool.NodeID{tool.NewNodeID(uniast.NewIdentityFromString(id))},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Nodes) == 0 {
+		if resp.Error != "" {
+			return nil, fmt.Errorf("%s", resp.Error)
+		}
+		return nil, fmt.Errorf("node %s not found in %s", id, repo)
+	}
+	return &resp.Nodes[0], nil
+}
+
+func renderPrompt(name string, desc string, data map[string]any) (*mcp.GetPromptResult, error) {
+	text, err := prompt.MCPPrompt(name)
+	if err != nil {
+		return nil, err
+	}
+	tpl, err := template.New(name).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	var sb strings.Builder
+	if err := tpl.Execute(&sb, data); err != nil {
+		return nil, err
+	}
+	return mcp.NewGetPromptResult(desc, []mcp.PromptMessage{
+		mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(sb.String())),
+	}), nil
+}
@@ -18,7 +18,10 @@ package mcp
 
 import (
 	"context"
+	"io"
 	"log"
+	"net/http"
+	"time"
 
 	alog "github.com/cloudwego/abcoder/llm/log"
 	"github.com/cloudwego/abcoder/llm/tool"
@@ -40,30 +43,58 @@ type ServerOptions struct {
 	ServerVersion string
 	Verbose       bool
 	tool.ASTReadToolsOptions
+
+	// Permissions restricts the tools and repos of the clients, everything is allowed if nil
+	Permissions Permissions
+	// AuditLog receives the tool calls as JSON lines of AuditEntry, they are logged at info level if nil
+	AuditLog io.Writer
+	// ToolTimeout bounds the time of each tool call, no limit if 0.
+	// The calls stopped by it return the results collected so far marked as truncated
+	ToolTimeout time.Duration
+	// ToolTimeouts overrides ToolTimeout for the tools of the names, 0 for no limit
+	ToolTimeouts map[string]time.Duration
 }
 
 func NewServer(options ServerOptions) *Server {
 	opts := []server.ServerOption{
 		server.WithPromptCapabilities(false),
 		server.WithToolCapabilities(false),
+		server.WithResourceCapabilities(false, true),
 	}
 	if options.Verbose {
 		opts = append(opts, server.WithLogging())
 	}
+	ast := tool.NewASTReadTools(options.ASTReadToolsOptions)
+	tools := getASTTools(ast)
+	batch := newBatcher(tools)
+	tools = append(tools, batch.tool())
+	// the batch only calls the read tools
+	tools[len(tools)-1].Annotations.ReadOnlyHint = mcp.ToBoolPtr(true)
+	ac := newAccessControl(options, tools, ast.ResolveRepo)
+	dl := newDeadlines(options)
+	batch.middleware = func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return ac.middleware(dl.middleware(next))
+	}
+	hooks := &server.Hooks{}
+	hooks.AddBeforeCallTool(dl.beforeCallTool)
+	opts = append(opts, server.WithHooks(hooks), server.WithToolHandlerMiddleware(ac.middleware), server.WithToolHandlerMiddleware(dl.middleware))
 	// Create a new MCP server
 	mcpServer := server.NewMCPServer(options.ServerName, options.ServerVersion, opts...)
 
 	// Enable sampling capability
 	// mcpServer.EnableSampling()
 
-	tools := getASTTools(options.ASTReadToolsOptions)
 	for _, tool := range tools {
 		mcpServer.AddTool(tool.Tool, tool.Handler)
 	}
 
 	mcpServer.AddPrompt(mcp.NewPrompt("prompt_analyze_repo", mcp.WithPromptDescription("A prompt for analyzing code repository")), handleAnalyzeRepoPrompt)
+	addPrompts(mcpServer, ast, ac)
+	addResources(mcpServer, ast, ac)
+	notifyRepoChanges(mcpServer, ast, ac)
 
 	mcpServer.AddNotificationHandler("notification", handleNotification)
+	mcpServer.AddNotificationHandler(MethodCancelled, dl.handleCancelled)
 
 	// // Start the stdio server
 	// log.Println("Starting sampling example server...")
@@ -87,6 +118,14 @@ func (s *Server) ServeStdio() error {
 }
 
 func (s *Server) ServeHTTP(addr string) error {
-	httpServer := server.NewStreamableHTTPServer(s.Server, server.WithLogger(alog.NewStdLogger()))
+	httpServer := server.NewStreamableHTTPServer(s.Server,
+		server.WithLogger(alog.NewStdLogger()),
+		server.WithHTTPContextFunc(func(ctx context.Context, r *http.Request) context.Context {
+			if name := r.Header.Get(ClientHeader); name != "" {
+				return withClient(ctx, name)
+			}
+			return ctx
+		}),
+	)
 	return httpServer.Start(addr)
 }
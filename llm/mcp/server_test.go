@@ -18,16 +18,22 @@ package mcp
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
 	"io"
 	"log"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/cloudwego/abcoder/lang/uniast"
 	alog "github.com/cloudwego/abcoder/llm/log"
 	"github.com/cloudwego/abcoder/llm/tool"
 
+	mcpgo "github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 )
 
@@ -121,3 +127,387 @@ func TestASTServer(t *testing.T) {
 		t.Errorf("unexpected server error: %v", err)
 	}
 }
+
+func TestServer_Permissions(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"localsession.json", "metainfo.json"} {
+		bs, err := os.ReadFile(filepath.Join("../../testdata/asts", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), bs, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the snapshot of a repo not allowed must not leak through list_repos
+	secret := uniast.NewRepository("secret")
+	secret.VCS = &uniast.VCS{Type: "git", Commit: "0123abcd", Branch: "release", Dirty: true}
+	bs, err := json.Marshal(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "secret.json"), bs, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var audit bytes.Buffer
+	svr := NewServer(ServerOptions{
+		ServerName:          "abcoder",
+		ServerVersion:       "1.0.0",
+		ASTReadToolsOptions: tool.ASTReadToolsOptions{RepoASTsDir: dir},
+		Permissions: Permissions{
+			"trusted": {},
+			AnyClient: {
+				ReadOnly:   true,
+				DenyTools:  []string{tool.ToolGetRepoStats},
+				AllowRepos: []string{"metainfo"},
+			},
+		},
+		AuditLog: &audit,
+	})
+	svr.Server.AddTool(mcpgo.NewTool("write_something"), func(ctx context.Context, req mcpgo.CallToolRequest) (*mcpgo.CallToolResult, error) {
+		return mcpgo.NewToolResultText("written"), nil
+	})
+
+	call := func(client, name string, args map[string]any) (string, bool) {
+		msg, _ := json.Marshal(map[string]any{
+			"jsonrpc": "2.0",
+			"id":      1,
+			"method":  "tools/call",
+			"params":  map[string]any{"name": name, "arguments": args},
+		})
+		resp, ok := svr.Server.HandleMessage(withClient(context.Background(), client), msg).(mcpgo.JSONRPCResponse)
+		if !ok {
+			t.Fatalf("call %s failed", name)
+		}
+		res := resp.Result.(mcpgo.CallToolResult)
+		return res.Content[0].(mcpgo.TextContent).Text, res.IsError
+	}
+
+	tests := []struct {
+		client, tool string
+		args         map[string]any
+		denied       string
+	}{
+		{"trusted", "write_something", nil, ""},
+		{"trusted", tool.ToolGetRepoStats, map[string]any{"repo_name": "localsession"}, ""},
+		{"other", "write_something", nil, "tool write_something is not read-only"},
+		{"other", tool.ToolGetRepoStats, map[string]any{"repo_name": "metainfo"}, "tool get_repo_stats is denied"},
+		{"other", tool.ToolGetRepoStructure, map[string]any{"repo_name": "localsession"}, "repo localsession is not allowed"},
+		{"other", tool.ToolGetRepoStructure, map[string]any{"repo_name": "metainfo"}, ""},
+		{"other", tool.ToolFindSymbolAcrossRepos, map[string]any{"name": "Foo", "repo_names": []string{"metainfo", "localsession"}}, "repo localsession is not allowed"},
+	}
+	for _, tt := range tests {
+		text, isError := call(tt.client, tt.tool, tt.args)
+		if tt.denied == "" {
+			if isError {
+				t.Errorf("%s calling %s: unexpected error %s", tt.client, tt.tool, text)
+			}
+		} else if !isError || text != "permission denied: "+tt.denied {
+			t.Errorf("%s calling %s = %s, want denied by %s", tt.client, tt.tool, text, tt.denied)
+		}
+	}
+
+	text, _ := call("other", tool.ToolListRepos, nil)
+	var repos tool.ListReposResp
+	if err := json.Unmarshal([]byte(text), &repos); err != nil {
+		t.Fatal(err)
+	}
+	if len(repos.RepoNames) != 1 || !strings.HasSuffix(repos.RepoNames[0], "/metainfo") {
+		t.Errorf("list_repos = %v, want only metainfo", repos.RepoNames)
+	}
+	for _, repo := range repos.Repos {
+		if !strings.HasSuffix(repo.RepoName, "/metainfo") {
+			t.Errorf("list_repos leaks the snapshot of %+v", repo)
+		}
+	}
+	if text, _ := call("trusted", tool.ToolListRepos, nil); !strings.Contains(text, "0123abcd") {
+		t.Errorf("list_repos of the trusted client = %s, want the snapshot of secret", text)
+	}
+
+	lines := strings.Split(strings.TrimSpace(audit.String()), "\n")
+	if len(lines) != len(tests)+2 {
+		t.Fatalf("got %d audit entries, want %d", len(lines), len(tests)+2)
+	}
+	var entry AuditEntry
+	if err := json.Unmarshal([]byte(lines[2]), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry.Client != "other" || entry.Tool != "write_something" || entry.Denied == "" {
+		t.Errorf("audit entry = %+v", entry)
+	}
+}
+
+func TestServer_ResourcesAndPrompts(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	if err := os.MkdirAll(src, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "README.md"), []byte("# svc\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	repo := uniast.NewRepository("svc")
+	repo.Path = src
+	mod := uniast.NewModule("github.com/a/svc", ".", uniast.Golang)
+	mod.Dependencies["github.com/b/lib"] = "github.com/b/lib@v1.0.0"
+	pkg := uniast.NewPackage("github.com/a/svc/handler")
+	id := uniast.NewIdentity("github.com/a/svc", "github.com/a/svc/handler", "Handle")
+	pkg.Functions["Handle"] = &uniast.Function{Identity: id, FileLine: uniast.FileLine{File: "handler/handle.go", Line: 3}, Content: "func Handle() {}"}
+	mod.Packages[pkg.PkgPath] = pkg
+	repo.Modules[mod.Name] = mod
+	bs, _ := json.Marshal(repo)
+	if err := os.WriteFile(filepath.Join(dir, "svc.json"), bs, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	handle := func(svr *Server, client string, method string, params map[string]any) mcpgo.JSONRPCMessage {
+		msg, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 1, "method": method, "params": params})
+		return svr.Server.HandleMessage(withClient(context.Background(), client), msg)
+	}
+	svr := NewServer(ServerOptions{ServerName: "abcoder", ServerVersion: "1.0.0", ASTReadToolsOptions: tool.ASTReadToolsOptions{RepoASTsDir: dir}})
+
+	list := handle(svr, "", "resources/list", nil).(mcpgo.JSONRPCResponse).Result.(mcpgo.ListResourcesResult)
+	if len(list.Resources) != 3 {
+		t.Errorf("resources = %+v", list.Resources)
+	}
+	read := func(svr *Server, client, uri string) (string, bool) {
+		resp, ok := handle(svr, client, "resources/read", map[string]any{"uri": uri}).(mcpgo.JSONRPCResponse)
+		if !ok {
+			return "", false
+		}
+		return resp.Result.(mcpgo.ReadResourceResult).Contents[0].(mcpgo.TextResourceContents).Text, true
+	}
+	if text, _ := read(svr, "", ResourceURI(ResourceReadme, "svc")); text != "# svc\n" {
+		t.Errorf("readme = %q", text)
+	}
+	if text, _ := read(svr, "", ResourceURI(ResourceModules, "svc")); !strings.Contains(text, `"functions": 1`) || !strings.Contains(text, "github.com/b/lib@v1.0.0") {
+		t.Errorf("modules = %s", text)
+	}
+	if text, _ := read(svr, "", ResourceURI(ResourcePackages, "svc")); !strings.Contains(text, "handler/handle.go") {
+		t.Errorf("packages = %s", text)
+	}
+
+	resp := handle(svr, "", "prompts/get", map[string]any{"name": PromptExplainNode, "arguments": map[string]any{"repo_name": "svc", "node_id": id.Full()}})
+	text := resp.(mcpgo.JSONRPCResponse).Result.(mcpgo.GetPromptResult).Messages[0].Content.(mcpgo.TextContent).Text
+	if !strings.Contains(text, "func Handle() {}") || !strings.Contains(text, "handler/handle.go:3") {
+		t.Errorf("prompt = %s", text)
+	}
+	if _, ok := handle(svr, "", "prompts/get", map[string]any{"name": PromptExplainNode, "arguments": map[string]any{"repo_name": "svc", "node_id": "a?b#C"}}).(mcpgo.JSONRPCResponse); ok {
+		t.Error("expect an error for the missing node")
+	}
+
+	// the restricted clients can't list or read the resources of the repos not allowed
+	restricted := NewServer(ServerOptions{ServerName: "abcoder", ServerVersion: "1.0.0", ASTReadToolsOptions: tool.ASTReadToolsOptions{RepoASTsDir: dir},
+		Permissions: Permissions{AnyClient: {AllowRepos: []string{"other"}}, "trusted": {}}})
+	list = handle(restricted, "", "resources/list", nil).(mcpgo.JSONRPCResponse).Result.(mcpgo.ListResourcesResult)
+	if len(list.Resources) != 0 {
+		t.Errorf("resources = %+v", list.Resources)
+	}
+	if _, ok := read(restricted, "other", ResourceURI(ResourceModules, "svc")); ok {
+		t.Error("expect the resource denied")
+	}
+	if _, ok := read(restricted, "trusted", ResourceURI(ResourceModules, "svc")); !ok {
+		t.Error("expect the resource allowed")
+	}
+}
+
+func TestServer_Batch(t *testing.T) {
+	var audit bytes.Buffer
+	svr := NewServer(ServerOptions{
+		ServerName:          "abcoder",
+		ServerVersion:       "1.0.0",
+		ASTReadToolsOptions: tool.ASTReadToolsOptions{RepoASTsDir: "../../testdata/asts"},
+		Permissions:         Permissions{AnyClient: {AllowRepos: []string{"metainfo"}}},
+		AuditLog:            &audit,
+	})
+	call := func(calls []map[string]any) (BatchResp, string, bool) {
+		msg, _ := json.Marshal(map[string]any{
+			"jsonrpc": "2.0",
+			"id":      1,
+			"method":  "tools/call",
+			"params":  map[string]any{"name": ToolBatch, "arguments": map[string]any{"calls": calls}},
+		})
+		resp := svr.Server.HandleMessage(context.Background(), msg).(mcpgo.JSONRPCResponse)
+		res := resp.Result.(mcpgo.CallToolResult)
+		text := res.Content[0].(mcpgo.TextContent).Text
+		var ret BatchResp
+		if !res.IsError {
+			if err := json.Unmarshal([]byte(text), &ret); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return ret, text, res.IsError
+	}
+
+	resp, text, isError := call([]map[string]any{
+		{"tool": tool.ToolGetRepoStructure, "arguments": map[string]any{"repo_name": "metainfo"}},
+		{"tool": tool.ToolGetRepoStructure, "arguments": map[string]any{"repo_name": "localsession"}},
+		{"tool": ToolBatch},
+		{"tool": tool.ToolGetASTNode, "arguments": map[string]any{"repo_name": "metainfo"}},
+	})
+	if isError || len(resp.Results) != 4 {
+		t.Fatalf("batch = %s", text)
+	}
+	if r := resp.Results[0]; r.Error != "" || !strings.Contains(string(r.Result), "modules") {
+		t.Errorf("result 0 = %+v", r)
+	}
+	if r := resp.Results[1]; r.Error != "permission denied: repo localsession is not allowed" {
+		t.Errorf("result 1 = %+v", r)
+	}
+	if r := resp.Results[2]; !strings.Contains(r.Error, "unknown tool") {
+		t.Errorf("result 2 = %+v", r)
+	}
+	// the arguments are validated before the call
+	if r := resp.Results[3]; r.Tool != tool.ToolGetASTNode || !strings.Contains(r.Error, "missing the required argument node_ids") {
+		t.Errorf("result 3 = %+v", r)
+	}
+	// the batch and its 3 dispatched calls are audited
+	if lines := strings.Split(strings.TrimSpace(audit.String()), "\n"); len(lines) != 4 {
+		t.Errorf("audit = %s", audit.String())
+	}
+
+	if _, _, isError := call(nil); !isError {
+		t.Error("expect an error for an empty batch")
+	}
+}
+
+type testSession struct {
+	ch chan mcpgo.JSONRPCNotification
+}
+
+func (s *testSession) Initialize()                                           {}
+func (s *testSession) Initialized() bool                                     { return true }
+func (s *testSession) NotificationChannel() chan<- mcpgo.JSONRPCNotification { return s.ch }
+func (s *testSession) SessionID() string                                     { return "test" }
+
+func TestServer_RepoNotifications(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string) {
+		bs, _ := json.Marshal(uniast.NewRepository(name))
+		// write through a temp file, thus the watcher sees a complete AST
+		tmp := filepath.Join(dir, name+".tmp")
+		if err := os.WriteFile(tmp, bs, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Rename(tmp, filepath.Join(dir, name+".json")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("svc")
+	svr := NewServer(ServerOptions{ServerName: "abcoder", ServerVersion: "1.0.0", ASTReadToolsOptions: tool.ASTReadToolsOptions{RepoASTsDir: dir}})
+	session := &testSession{ch: make(chan mcpgo.JSONRPCNotification, 64)}
+	if err := svr.Server.RegisterSession(context.Background(), session); err != nil {
+		t.Fatal(err)
+	}
+	// wait receives the notifications until the one of the method with the params
+	wait := func(method string, params map[string]any) {
+		t.Helper()
+		timeout := time.After(5 * time.Second)
+		for {
+			select {
+			case n := <-session.ch:
+				if n.Method != method {
+					continue
+				}
+				match := true
+				for k, v := range params {
+					if n.Params.AdditionalFields[k] != v {
+						match = false
+					}
+				}
+				if match {
+					return
+				}
+			case <-timeout:
+				t.Fatalf("no notification %s %v", method, params)
+			}
+		}
+	}
+
+	write("lib")
+	wait(mcpgo.MethodNotificationResourcesListChanged, nil)
+	wait(NotificationRepoChanged, map[string]any{"repo_name": "lib", "op": tool.RepoAdded})
+	msg, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 1, "method": "resources/list"})
+	list := svr.Server.HandleMessage(context.Background(), msg).(mcpgo.JSONRPCResponse).Result.(mcpgo.ListResourcesResult)
+	if len(list.Resources) != 6 {
+		t.Errorf("resources = %+v", list.Resources)
+	}
+
+	write("svc")
+	wait(mcpgo.MethodNotificationResourceUpdated, map[string]any{"uri": ResourceURI(ResourceModules, "svc")})
+	wait(NotificationRepoChanged, map[string]any{"repo_name": "svc", "op": tool.RepoUpdated})
+
+	if err := os.Remove(filepath.Join(dir, "lib.json")); err != nil {
+		t.Fatal(err)
+	}
+	wait(NotificationRepoChanged, map[string]any{"repo_name": "lib", "op": tool.RepoRemoved})
+	list = svr.Server.HandleMessage(context.Background(), msg).(mcpgo.JSONRPCResponse).Result.(mcpgo.ListResourcesResult)
+	if len(list.Resources) != 3 {
+		t.Errorf("resources = %+v", list.Resources)
+	}
+}
+
+func TestServer_Deadlines(t *testing.T) {
+	svr := NewServer(ServerOptions{
+		ServerName:          "abcoder",
+		ServerVersion:       "1.0.0",
+		ASTReadToolsOptions: tool.ASTReadToolsOptions{RepoASTsDir: "../../testdata/asts"},
+		ToolTimeout:         50 * time.Millisecond,
+		ToolTimeouts:        map[string]time.Duration{"stubborn": 20 * time.Millisecond, "waiting": 0},
+	})
+	untilDone := func(ctx context.Context, _ mcpgo.CallToolRequest) (*mcpgo.CallToolResult, error) {
+		<-ctx.Done()
+		return mcpgo.NewToolResultText("partial: " + context.Cause(ctx).Error()), nil
+	}
+	svr.Server.AddTool(mcpgo.NewTool("partial"), untilDone)
+	svr.Server.AddTool(mcpgo.NewTool("waiting"), untilDone)
+	svr.Server.AddTool(mcpgo.NewTool("stubborn"), func(ctx context.Context, _ mcpgo.CallToolRequest) (*mcpgo.CallToolResult, error) {
+		time.Sleep(2 * time.Second)
+		return mcpgo.NewToolResultText("done"), nil
+	})
+	call := func(id int, name string) (string, bool) {
+		msg, _ := json.Marshal(map[string]any{
+			"jsonrpc": "2.0",
+			"id":      id,
+			"method":  "tools/call",
+			"params":  map[string]any{"name": name},
+		})
+		res := svr.Server.HandleMessage(context.Background(), msg).(mcpgo.JSONRPCResponse).Result.(mcpgo.CallToolResult)
+		return res.Content[0].(mcpgo.TextContent).Text, res.IsError
+	}
+
+	if text, isError := call(1, "partial"); isError || text != "partial: partial timed out after 50ms" {
+		t.Errorf("partial = %s", text)
+	}
+	start := time.Now()
+	if text, isError := call(2, "stubborn"); !isError || !strings.Contains(text, "stubborn timed out after 20ms") {
+		t.Errorf("stubborn = %s", text)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("the stubborn call is not abandoned, took %v", d)
+	}
+
+	// the call without a timeout is stopped by the client
+	done := make(chan string)
+	go func() {
+		text, _ := call(3, "waiting")
+		done <- text
+	}()
+	cancel, _ := json.Marshal(map[string]any{
+		"jsonrpc": "2.0",
+		"method":  MethodCancelled,
+		"params":  map[string]any{"requestId": 3, "reason": "user abort"},
+	})
+	for {
+		svr.Server.HandleMessage(context.Background(), cancel)
+		select {
+		case text := <-done:
+			if text != "partial: cancelled by the client: user abort" {
+				t.Errorf("waiting = %s", text)
+			}
+			return
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
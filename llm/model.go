@@ -25,7 +25,21 @@ import (
 	"github.com/cloudwego/eino-ext/components/model/openai"
 )
 
-func NewChatModel(m ModelConfig) (model ChatModel) {
+func NewChatModel(m ModelConfig) ChatModel {
+	return NewMeteredChatModel(m, nil)
+}
+
+// NewMeteredChatModel creates the model recording its usage, see WithUsage.
+// The cached responses are not recorded since they are not billed
+func NewMeteredChatModel(m ModelConfig, usage *Usage) ChatModel {
+	model := WithUsage(newChatModel(m), m, usage)
+	if m.CacheDir != "" {
+		return WithResponseCache(model, m, NewResponseCache(m.CacheDir))
+	}
+	return model
+}
+
+func newChatModel(m ModelConfig) (model ChatModel) {
 	if m.MaxTokens == 0 {
 		m.MaxTokens = 16 * 1024
 	}
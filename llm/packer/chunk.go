@@ -0,0 +1,198 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package packer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cloudwego/abcoder/lang/uniast"
+)
+
+// DefaultChunkTokens is the default max tokens of a chunk
+const DefaultChunkTokens = 512
+
+type ChunkOptions struct {
+	// MaxTokens bounds the tokens of a chunk, DefaultChunkTokens if 0.
+	// A node exceeding it is split by lines into several chunks
+	MaxTokens int
+	// PkgPath only chunks the package if not empty
+	PkgPath uniast.PkgPath
+}
+
+// Chunk is a piece of the codes of a package, made of whole nodes or a part of a large node
+type Chunk struct {
+	// ID is derived from the identities of the nodes rather than the codes,
+	// thus it is stable across parses as long as the chunk holds the same nodes
+	ID      string
+	ModPath uniast.ModPath
+	PkgPath uniast.PkgPath
+	// the nodes in the chunk, in the order of files and lines
+	Nodes []uniast.Identity
+	// Part is the 1-based index of the part of a split node, 0 if the chunk holds whole nodes
+	Part int
+	// the codes of the nodes, each led by a header line of the identity and the location
+	Text   string
+	Tokens int
+}
+
+type chunkNode struct {
+	id      uniast.Identity
+	fl      uniast.FileLine
+	content string
+}
+
+func (n chunkNode) header() string {
+	return Item{Identity: n.id, FileLine: n.fl}.Header()
+}
+
+// ChunkRepo splits the codes of the internal modules into chunks for embedding.
+// The nodes (functions, types and vars with their docs) of a package are ordered by files and lines,
+// and packed into chunks of at most MaxTokens, which never cross packages.
+// A node exceeding MaxTokens is split by lines into several chunks on its own.
+func ChunkRepo(repo *uniast.Repository, opts ChunkOptions) []Chunk {
+	maxTokens := opts.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = DefaultChunkTokens
+	}
+	mods := repo.InternalModules()
+	sort.Slice(mods, func(i, j int) bool { return mods[i].Name < mods[j].Name })
+
+	var ret []Chunk
+	for _, mod := range mods {
+		paths := make([]uniast.PkgPath, 0, len(mod.Packages))
+		for path := range mod.Packages {
+			if opts.PkgPath == "" || path == opts.PkgPath {
+				paths = append(paths, path)
+			}
+		}
+		sort.Strings(paths)
+		for _, path := range paths {
+			ret = append(ret, chunkPackage(mod.Name, mod.Packages[path], maxTokens)...)
+		}
+	}
+	return ret
+}
+
+func chunkPackage(modPath uniast.ModPath, pkg *uniast.Package, maxTokens int) []Chunk {
+	var nodes []chunkNode
+	add := func(id uniast.Identity, fl uniast.FileLine, content string) {
+		if strings.TrimSpace(content) != "" {
+			nodes = append(nodes, chunkNode{id: id, fl: fl, content: content})
+		}
+	}
+	for _, f := range pkg.Functions {
+		add(f.Identity, f.FileLine, f.Content)
+	}
+	for _, t := range pkg.Types {
+		add(t.Identity, t.FileLine, t.Content)
+	}
+	for _, v := range pkg.Vars {
+		add(v.Identity, v.FileLine, v.Content)
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		a, b := nodes[i].fl, nodes[j].fl
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return nodes[i].id.Full() < nodes[j].id.Full()
+	})
+
+	var ret []Chunk
+	cur := Chunk{ModPath: modPath, PkgPath: pkg.PkgPath}
+	var sb strings.Builder
+	flush := func() {
+		if len(cur.Nodes) == 0 {
+			return
+		}
+		cur.Text = sb.String()
+		cur.Tokens = EstimateTokens(cur.Text)
+		cur.ID = chunkID(cur)
+		ret = append(ret, cur)
+		cur = Chunk{ModPath: modPath, PkgPath: pkg.PkgPath}
+		sb.Reset()
+	}
+	for _, n := range nodes {
+		text := n.header() + n.content + "\n\n"
+		tokens := EstimateTokens(text)
+		if tokens > maxTokens {
+			flush()
+			ret = append(ret, splitNode(modPath, pkg.PkgPath, n, maxTokens)...)
+			continue
+		}
+		if EstimateTokens(sb.String()+text) > maxTokens {
+			flush()
+		}
+		cur.Nodes = append(cur.Nodes, n.id)
+		sb.WriteString(text)
+	}
+	flush()
+	return ret
+}
+
+// splitNode splits the codes of a large node into parts by lines, each part is led by the header.
+// A single line exceeding the budget makes a part on its own
+func splitNode(modPath uniast.ModPath, pkgPath uniast.PkgPath, n chunkNode, maxTokens int) []Chunk {
+	var parts []string
+	var sb strings.Builder
+	// reserve the tokens of the part marker of the header
+	budget := maxTokens - EstimateTokens(n.header()+" (part 100/100)\n\n")
+	for _, line := range strings.Split(n.content, "\n") {
+		if sb.Len() > 0 && EstimateTokens(sb.String()+line) > budget {
+			parts = append(parts, sb.String())
+			sb.Reset()
+		}
+		sb.WriteString(line)
+		sb.WriteByte('\n')
+	}
+	if sb.Len() > 0 {
+		parts = append(parts, sb.String())
+	}
+
+	ret := make([]Chunk, 0, len(parts))
+	for i, part := range parts {
+		header := fmt.Sprintf("// %s (%s:%d) (part %d/%d)\n", n.id.Full(), n.fl.File, n.fl.Line, i+1, len(parts))
+		c := Chunk{
+			ModPath: modPath,
+			PkgPath: pkgPath,
+			Nodes:   []uniast.Identity{n.id},
+			Part:    i + 1,
+			Text:    header + part + "\n",
+		}
+		c.Tokens = EstimateTokens(c.Text)
+		c.ID = chunkID(c)
+		ret = append(ret, c)
+	}
+	return ret
+}
+
+func chunkID(c Chunk) string {
+	var sb strings.Builder
+	sb.WriteString(string(c.PkgPath))
+	for _, id := range c.Nodes {
+		sb.WriteByte('\n')
+		sb.WriteString(id.Full())
+	}
+	if c.Part > 0 {
+		fmt.Fprintf(&sb, "\n#%d", c.Part)
+	}
+	return uniast.ContentHash(sb.String())
+}
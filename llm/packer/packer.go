@@ -0,0 +1,346 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package packer packs the codes of AST nodes into a LLM context of limited tokens.
+package packer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cloudwego/abcoder/lang/uniast"
+)
+
+// EstimateTokens estimates the token count of the text, taking 4 bytes as a token
+func EstimateTokens(text string) int {
+	return tokensOfBytes(len(text))
+}
+
+func tokensOfBytes(n int) int {
+	return (n + 3) / 4
+}
+
+// Level is how much of a node is kept in the context
+type Level int
+
+const (
+	// LevelOmitted means the node doesn't fit the budget at all
+	LevelOmitted Level = iota
+	// LevelOutline keeps the docs and the declaration, without the body
+	LevelOutline
+	// LevelTruncated keeps the head of the whole codes
+	LevelTruncated
+	// LevelFull keeps the whole codes
+	LevelFull
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelOutline:
+		return "outline"
+	case LevelTruncated:
+		return "truncated"
+	case LevelFull:
+		return "full"
+	default:
+		return "omitted"
+	}
+}
+
+// max lines of the outline of a type or var
+const maxOutlineLines = 20
+
+type Options struct {
+	// Budget is the max tokens of the packed context
+	Budget int
+	// WithDeps packs the outlines of the dependencies of the nodes with the remaining budget.
+	// A dependency shared by more nodes goes first, and is packed only once.
+	WithDeps bool
+}
+
+// Item is a packed node
+type Item struct {
+	uniast.Identity
+	FileLine uniast.FileLine
+	Level    Level
+	// the packed codes
+	Text string
+	// tokens of the rendered item, including the header
+	Tokens int
+	// if it is a dependency of the requested nodes
+	IsDep bool
+}
+
+// Header returns the header line of the item in the context
+func (i Item) Header() string {
+	return fmt.Sprintf("// %s (%s:%d)\n", i.Identity.Full(), i.FileLine.File, i.FileLine.Line)
+}
+
+type Result struct {
+	// the packed nodes, requested nodes first in the order given, then dependencies
+	Items  []Item
+	Tokens int
+}
+
+// String renders the packed context
+func (r Result) String() string {
+	var sb strings.Builder
+	for _, it := range r.Items {
+		if it.Level == LevelOmitted {
+			continue
+		}
+		sb.WriteString(it.Header())
+		sb.WriteString(it.Text)
+		sb.WriteString("\n\n")
+	}
+	return sb.String()
+}
+
+type candidate struct {
+	node    *uniast.Node
+	full    string
+	outline string
+}
+
+// Pack selects and truncates the codes of the nodes to fit the token budget:
+//  1. the outlines of all nodes, in the order given;
+//  2. the full codes of the nodes in order, truncating the ones which don't fit;
+//  3. the outlines of the deduplicated dependencies, if Options.WithDeps.
+//
+// Nodes not found in the repo are ignored.
+func Pack(repo *uniast.Repository, ids []uniast.Identity, opts Options) Result {
+	var cands []candidate
+	requested := make(map[uniast.Identity]bool, len(ids))
+	for _, id := range ids {
+		if requested[id] {
+			continue
+		}
+		node := repo.GetNode(id)
+		if node == nil {
+			continue
+		}
+		requested[id] = true
+		full := node.Content()
+		cands = append(cands, candidate{node: node, full: full, outline: Outline(node.Type, full)})
+	}
+
+	var ret Result
+	remain := opts.Budget
+	// 1. outlines
+	for _, c := range cands {
+		it := Item{Identity: c.node.Identity, FileLine: c.node.FileLine()}
+		tokens := EstimateTokens(it.Header()) + EstimateTokens(c.outline)
+		if tokens <= remain {
+			it.Level, it.Text, it.Tokens = LevelOutline, c.outline, tokens
+			remain -= tokens
+		}
+		ret.Items = append(ret.Items, it)
+	}
+
+	// 2. full codes
+	for i, c := range cands {
+		it := &ret.Items[i]
+		if it.Level != LevelOutline || c.full == c.outline {
+			if it.Level == LevelOutline {
+				it.Level = LevelFull
+			}
+			continue
+		}
+		avail := remain + it.Tokens
+		header := EstimateTokens(it.Header())
+		if tokens := header + EstimateTokens(c.full); tokens <= avail {
+			it.Level, it.Text = LevelFull, c.full
+			remain, it.Tokens = avail-tokens, tokens
+			continue
+		}
+		// truncate the codes if it keeps more than the outline
+		if text := Truncate(c.full, avail-header); len(text) > len(c.outline) {
+			it.Level, it.Text = LevelTruncated, text
+			it.Tokens = header + EstimateTokens(text)
+			remain = avail - it.Tokens
+		}
+	}
+
+	// 3. dependencies
+	if opts.WithDeps {
+		for _, dep := range sharedDeps(repo, cands, requested) {
+			it := Item{Identity: dep.Identity, FileLine: dep.FileLine(), IsDep: true}
+			outline := Outline(dep.Type, dep.Content())
+			if outline == "" {
+				continue
+			}
+			tokens := EstimateTokens(it.Header()) + EstimateTokens(outline)
+			if tokens > remain {
+				continue
+			}
+			it.Level, it.Text, it.Tokens = LevelOutline, outline, tokens
+			remain -= tokens
+			ret.Items = append(ret.Items, it)
+		}
+	}
+
+	for _, it := range ret.Items {
+		ret.Tokens += it.Tokens
+	}
+	return ret
+}
+
+// sharedDeps returns the internal dependencies of the candidates, the most shared first
+func sharedDeps(repo *uniast.Repository, cands []candidate, requested map[uniast.Identity]bool) []*uniast.Node {
+	count := map[uniast.Identity]int{}
+	var order []uniast.Identity
+	for _, c := range cands {
+		seen := map[uniast.Identity]bool{}
+		for _, dep := range c.node.Dependencies {
+			if requested[dep.Identity] || seen[dep.Identity] {
+				continue
+			}
+			seen[dep.Identity] = true
+			if count[dep.Identity] == 0 {
+				order = append(order, dep.Identity)
+			}
+			count[dep.Identity]++
+		}
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return count[order[i]] > count[order[j]]
+	})
+	ret := make([]*uniast.Node, 0, len(order))
+	for _, id := range order {
+		if node := repo.GetNode(id); node != nil && node.Type != uniast.UNKNOWN {
+			ret = append(ret, node)
+		}
+	}
+	return ret
+}
+
+// Inline collects the dependencies of the node up to depth levels in breadth-first order:
+// the full codes of the direct dependencies, and the outlines of the deeper ones.
+// Each dependency is inlined once, the node itself and the nodes not found in the repo are skipped
+func Inline(repo *uniast.Repository, id uniast.Identity, depth int) Result {
+	var ret Result
+	node := repo.GetNode(id)
+	if node == nil {
+		return ret
+	}
+	visited := map[uniast.Identity]bool{id: true}
+	level := []*uniast.Node{node}
+	for d := 1; d <= depth && len(level) > 0; d++ {
+		var next []*uniast.Node
+		for _, n := range level {
+			for _, dep := range n.Dependencies {
+				if visited[dep.Identity] {
+					continue
+				}
+				visited[dep.Identity] = true
+				dn := repo.GetNode(dep.Identity)
+				if dn == nil || dn.Type == uniast.UNKNOWN {
+					continue
+				}
+				next = append(next, dn)
+				it := Item{Identity: dn.Identity, FileLine: dn.FileLine(), Level: LevelFull, Text: dn.Content(), IsDep: true}
+				if d > 1 {
+					it.Level, it.Text = LevelOutline, Outline(dn.Type, it.Text)
+				}
+				if it.Text == "" {
+					continue
+				}
+				it.Tokens = EstimateTokens(it.Header()) + EstimateTokens(it.Text)
+				ret.Tokens += it.Tokens
+				ret.Items = append(ret.Items, it)
+			}
+		}
+		level = next
+	}
+	return ret
+}
+
+// Outline returns the codes of a node without the function body.
+// Types and vars are kept as is, but at most maxOutlineLines lines.
+func Outline(typ uniast.NodeType, content string) string {
+	if typ != uniast.FUNC {
+		return truncateLines(content, maxOutlineLines)
+	}
+	// `func foo() {` of go, `fn foo() {` of rust, `void foo() {` of c/java
+	if idx := bodyStart(content); idx >= 0 && strings.TrimSpace(content[idx+1:]) != "}" {
+		return content[:idx+1] + " ... }"
+	}
+	// `def foo():` of python
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "def ") || strings.HasPrefix(trimmed, "async def ") {
+			for j := i; j < len(lines); j++ {
+				if strings.HasSuffix(strings.TrimSpace(lines[j]), ":") {
+					if j == len(lines)-1 {
+						return content
+					}
+					return strings.Join(lines[:j+1], "\n") + "\n    ..."
+				}
+			}
+			break
+		}
+	}
+	return content
+}
+
+// bodyStart returns the index of the `{` starting the function body,
+// which is the first one out of the parameter list, like the last one of `func f(v interface{}) {`
+func bodyStart(content string) int {
+	depth := 0
+	for i := 0; i < len(content); i++ {
+		switch content[i] {
+		case '(', '[':
+			depth++
+		case ')', ']':
+			depth--
+		case '{':
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// Truncate keeps the head lines of the content within the token budget
+func Truncate(content string, budget int) string {
+	if EstimateTokens(content) <= budget {
+		return content
+	}
+	lines := strings.Split(content, "\n")
+	size := 0
+	for i, line := range lines {
+		marker := fmt.Sprintf("\n... (%d lines truncated)", len(lines)-i)
+		if tokensOfBytes(size+len(line)+len(marker)) > budget {
+			if i == 0 {
+				return ""
+			}
+			return strings.Join(lines[:i], "\n") + marker
+		}
+		size += len(line) + 1
+	}
+	return content
+}
+
+func truncateLines(content string, max int) string {
+	lines := strings.SplitN(content, "\n", max+1)
+	if len(lines) <= max {
+		return content
+	}
+	return strings.Join(lines[:max], "\n") + "\n..."
+}
@@ -0,0 +1,206 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package packer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/abcoder/lang/uniast"
+)
+
+func TestOutline(t *testing.T) {
+	tests := []struct {
+		name    string
+		typ     uniast.NodeType
+		content string
+		want    string
+	}{
+		{"go", uniast.FUNC, "func Foo(v interface{}) error {\n\treturn nil\n}", "func Foo(v interface{}) error { ... }"},
+		{"empty body", uniast.FUNC, "func Foo() {}", "func Foo() {}"},
+		{"python", uniast.FUNC, "def foo(a,\n        b):\n    return a + b", "def foo(a,\n        b):\n    ..."},
+		{"type", uniast.TYPE, "type A struct {\n\tB int\n}", "type A struct {\n\tB int\n}"},
+		{"long type", uniast.TYPE, strings.Repeat("a\n", 30), strings.Repeat("a\n", 20)[:39] + "\n..."},
+	}
+	for _, tt := range tests {
+		if got := Outline(tt.typ, tt.content); got != tt.want {
+			t.Errorf("%s: Outline() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	content := strings.Repeat("0123456789abcdef\n", 10) + "end"
+	got := Truncate(content, 20)
+	if EstimateTokens(got) > 20 || !strings.HasSuffix(got, "lines truncated)") || !strings.HasPrefix(got, "0123456789abcdef\n") {
+		t.Errorf("Truncate() = %q", got)
+	}
+	if got := Truncate(content, 1000); got != content {
+		t.Errorf("Truncate() should keep the content within budget")
+	}
+}
+
+func TestPack(t *testing.T) {
+	const mod, pkg = "a.b/c", "a.b/c"
+	repo := uniast.NewRepository("c")
+	repo.Modules[mod] = uniast.NewModule(mod, ".", uniast.Golang)
+	body := "func Big() int {\n" + strings.Repeat("\tx := 1\n", 100) + "\treturn x\n}"
+	fns := map[string]string{
+		"Big":   body,
+		"Small": "func Small() int {\n\treturn Dep()\n}",
+		"Dep":   "func Dep() int {\n\treturn 1\n}",
+	}
+	for name, content := range fns {
+		id := uniast.NewIdentity(mod, pkg, name)
+		fn := &uniast.Function{Identity: id, FileLine: uniast.FileLine{File: "c.go", Line: 1}, Content: content}
+		if name != "Dep" {
+			fn.FunctionCalls = []uniast.Dependency{uniast.NewDependency(uniast.NewIdentity(mod, pkg, "Dep"), uniast.FileLine{})}
+		}
+		repo.SetFunction(id, fn)
+	}
+	ids := []uniast.Identity{uniast.NewIdentity(mod, pkg, "Big"), uniast.NewIdentity(mod, pkg, "Small")}
+
+	// everything fits
+	res := Pack(&repo, ids, Options{Budget: 10000, WithDeps: true})
+	if len(res.Items) != 3 || res.Items[0].Level != LevelFull || res.Items[1].Level != LevelFull {
+		t.Fatalf("items = %+v", res.Items)
+	}
+	if dep := res.Items[2]; !dep.IsDep || dep.Name != "Dep" || dep.Text != "func Dep() int { ... }" {
+		t.Errorf("dep = %+v", dep)
+	}
+
+	// the big one is truncated, the small one is still full
+	res = Pack(&repo, []uniast.Identity{ids[1], ids[0]}, Options{Budget: 150, WithDeps: true})
+	if res.Tokens > 150 {
+		t.Errorf("tokens %d exceed the budget", res.Tokens)
+	}
+	if res.Items[0].Level != LevelFull || res.Items[1].Level != LevelTruncated {
+		t.Errorf("items = %+v", res.Items)
+	}
+	if !strings.Contains(res.String(), "// a.b/c?a.b/c#Small (c.go:1)\n") {
+		t.Errorf("rendered = %s", res.String())
+	}
+
+	// only outlines fit
+	res = Pack(&repo, ids, Options{Budget: 28})
+	if res.Items[0].Level != LevelOutline || res.Items[0].Text != "func Big() int { ... }" || res.Items[1].Level != LevelOutline {
+		t.Errorf("items = %+v", res.Items)
+	}
+	if res.Tokens > 28 {
+		t.Errorf("tokens %d exceed the budget", res.Tokens)
+	}
+
+	// nothing fits
+	res = Pack(&repo, ids, Options{Budget: 5})
+	if res.Items[0].Level != LevelOmitted || res.Tokens != 0 || res.String() != "" {
+		t.Errorf("items = %+v", res.Items)
+	}
+}
+
+func TestInline(t *testing.T) {
+	const mod, pkg = "a.b/c", "a.b/c"
+	repo := uniast.NewRepository("c")
+	repo.Modules[mod] = uniast.NewModule(mod, ".", uniast.Golang)
+	// Top -> Mid -> Leaf, Mid -> Top
+	calls := map[string][]string{"Top": {"Mid"}, "Mid": {"Leaf", "Top"}, "Leaf": nil}
+	for name, callees := range calls {
+		id := uniast.NewIdentity(mod, pkg, name)
+		fn := &uniast.Function{Identity: id, FileLine: uniast.FileLine{File: "c.go", Line: 1}, Content: "func " + name + "() {\n\tx()\n}"}
+		for _, callee := range callees {
+			fn.FunctionCalls = append(fn.FunctionCalls, uniast.NewDependency(uniast.NewIdentity(mod, pkg, callee), uniast.FileLine{}))
+		}
+		repo.SetFunction(id, fn)
+	}
+
+	res := Inline(&repo, uniast.NewIdentity(mod, pkg, "Top"), 1)
+	if len(res.Items) != 1 || res.Items[0].Name != "Mid" || res.Items[0].Level != LevelFull {
+		t.Fatalf("items = %+v", res.Items)
+	}
+
+	// the deeper ones are outlines
+	res = Inline(&repo, uniast.NewIdentity(mod, pkg, "Top"), 3)
+	if len(res.Items) != 2 || res.Items[1].Name != "Leaf" || res.Items[1].Level != LevelOutline || res.Items[1].Text != "func Leaf() { ... }" {
+		t.Fatalf("items = %+v", res.Items)
+	}
+	if !strings.Contains(res.String(), "// a.b/c?a.b/c#Mid (c.go:1)\nfunc Mid() {\n\tx()\n}") {
+		t.Errorf("rendered = %s", res.String())
+	}
+
+	// the node itself is not inlined through the cycle
+	res = Inline(&repo, uniast.NewIdentity(mod, pkg, "Mid"), 2)
+	if len(res.Items) != 2 || res.Items[0].Name != "Leaf" || res.Items[1].Name != "Top" {
+		t.Fatalf("items = %+v", res.Items)
+	}
+
+	res = Inline(&repo, uniast.NewIdentity(mod, pkg, "Leaf"), 3)
+	if len(res.Items) != 0 || res.String() != "" {
+		t.Errorf("items = %+v", res.Items)
+	}
+}
+
+func TestChunkRepo(t *testing.T) {
+	newRepo := func(body string) *uniast.Repository {
+		repo := uniast.NewRepository("a")
+		mod := uniast.NewModule("a", ".", uniast.Golang)
+		for _, path := range []string{"a/x", "a/y"} {
+			pkg := uniast.NewPackage(uniast.PkgPath(path))
+			for i, name := range []string{"A", "B", "C"} {
+				pkg.Functions[name] = &uniast.Function{
+					Identity: uniast.NewIdentity("a", pkg.PkgPath, name),
+					FileLine: uniast.FileLine{File: path + "/f.go", Line: i*10 + 1},
+					Content:  "func " + name + "() {\n" + body + "\n}",
+				}
+			}
+			mod.Packages[pkg.PkgPath] = pkg
+		}
+		big := mod.Packages["a/y"].Functions["C"]
+		big.Content = "func C() {\n" + strings.Repeat("\tprintln(\"0123456789abcdef\")\n", 20) + "}"
+		repo.Modules[mod.Name] = mod
+		return &repo
+	}
+
+	chunks := ChunkRepo(newRepo("\treturn"), ChunkOptions{MaxTokens: 30})
+	var got []string
+	for _, c := range chunks {
+		var names []string
+		for _, id := range c.Nodes {
+			names = append(names, string(id.PkgPath)+"."+id.Name)
+		}
+		got = append(got, strings.Join(names, ","))
+		if c.Tokens > 30 || c.Tokens != EstimateTokens(c.Text) {
+			t.Errorf("tokens of chunk %v = %d", names, c.Tokens)
+		}
+	}
+	// about 12 tokens a node, except C of a/y which has 150+ tokens and is split into parts
+	if len(got) < 5 || got[0] != "a/x.A,a/x.B" || got[1] != "a/x.C" || got[2] != "a/y.A,a/y.B" || got[3] != "a/y.C" || got[len(got)-1] != "a/y.C" {
+		t.Fatalf("chunks = %v", got)
+	}
+	if chunks[3].Part != 1 || !strings.Contains(chunks[3].Text, "(part 1/") || !strings.HasPrefix(chunks[4].Text, "// a?a/y#C") {
+		t.Errorf("first part = %+v", chunks[3])
+	}
+
+	// ids are stable when the codes change
+	again := ChunkRepo(newRepo("\treturn // changed"), ChunkOptions{MaxTokens: 30})
+	if len(again) != len(chunks) || again[0].ID != chunks[0].ID || again[0].Text == chunks[0].Text {
+		t.Errorf("chunk ids are not stable: %+v", again[0])
+	}
+
+	only := ChunkRepo(newRepo("\treturn"), ChunkOptions{PkgPath: "a/x"})
+	if len(only) != 1 || len(only[0].Nodes) != 3 {
+		t.Errorf("chunks of a/x = %+v", only)
+	}
+}
@@ -18,7 +18,7 @@ package prompt
 
 import (
 	"bytes"
-	_ "embed"
+	"embed"
 	"html/template"
 	"os"
 )
@@ -92,3 +92,21 @@ func NewTextPrompt(content string) Prompt {
 
 //go:embed analyzer.md
 var PromptAnalyzeRepo string
+
+//go:embed tasks/*.md
+var taskPrompts embed.FS
+
+// TaskPrompt returns the prompt template of the agent task, see agent.Tasks
+func TaskPrompt(name string) (string, error) {
+	bs, err := taskPrompts.ReadFile("tasks/" + name + ".md")
+	return string(bs), err
+}
+
+//go:embed mcp/*.md
+var mcpPrompts embed.FS
+
+// MCPPrompt returns the template of the prompt served by the MCP server, see mcp.NewServer
+func MCPPrompt(name string) (string, error) {
+	bs, err := mcpPrompts.ReadFile("mcp/" + name + ".md")
+	return string(bs), err
+}
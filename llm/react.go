@@ -18,11 +18,15 @@ package llm
 
 import (
 	"context"
+	"fmt"
+	"io"
 
 	"github.com/cloudwego/abcoder/internal/utils"
 	"github.com/cloudwego/abcoder/llm/log"
 	"github.com/cloudwego/abcoder/llm/prompt"
 	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components"
+	"github.com/cloudwego/eino/components/model"
 	"github.com/cloudwego/eino/compose"
 	"github.com/cloudwego/eino/flow/agent"
 	"github.com/cloudwego/eino/flow/agent/react"
@@ -55,6 +59,34 @@ func NewReactAgent(name string, opts ReactAgentOptions) *ReactAgent {
 	}
 }
 
+// StreamToolCallChecker returns the checker which tells if the streamed output of the model calls tools,
+// nil for the default one of react.
+// The default one decides by the first non-empty chunk, thus the answer is streamed at once.
+// But claude outputs texts before the tool calls, so the whole output must be read before deciding
+func StreamToolCallChecker(typ ModelType) func(ctx context.Context, sr *schema.StreamReader[*schema.Message]) (bool, error) {
+	if typ == ModelTypeClaude {
+		return fullStreamToolCallChecker
+	}
+	return nil
+}
+
+// fullStreamToolCallChecker reads the stream until a tool call is found
+func fullStreamToolCallChecker(_ context.Context, sr *schema.StreamReader[*schema.Message]) (bool, error) {
+	defer sr.Close()
+	for {
+		msg, err := sr.Recv()
+		if err == io.EOF {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		if len(msg.ToolCalls) > 0 {
+			return true, nil
+		}
+	}
+}
+
 func newMessageModifier(sysPrompt string, name string, limit int) func(ctx context.Context, input []*schema.Message) []*schema.Message {
 	return func(ctx context.Context, input []*schema.Message) []*schema.Message {
 		log.Debug("newMessageModifier, name: %v, limit: %d, input: %v", name, limit, len(input))
@@ -88,6 +120,39 @@ func (p *ReactAgent) Call(ctx context.Context, input string) (string, error) {
 	return out.Content, nil
 }
 
+// StreamGenerate streams the final answer of the agent, onChunk is called with every chunk as it arrives.
+// The chunks received are still returned on errors (e.g. timeouts), thus the partial answer is not lost
+func (p *ReactAgent) StreamGenerate(ctx context.Context, input []*schema.Message, onChunk func(*schema.Message), opts ...agent.AgentOption) (*schema.Message, error) {
+	sr, err := p.Stream(ctx, input, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer sr.Close()
+	var chunks []*schema.Message
+	for {
+		chunk, err := sr.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if len(chunks) > 0 {
+				if msg, cerr := schema.ConcatMessages(chunks); cerr == nil {
+					return msg, err
+				}
+			}
+			return nil, err
+		}
+		chunks = append(chunks, chunk)
+		if onChunk != nil {
+			onChunk(chunk)
+		}
+	}
+	if len(chunks) == 0 {
+		return nil, fmt.Errorf("empty response from the model")
+	}
+	return schema.ConcatMessages(chunks)
+}
+
 /*
 	type Handler interface {
 		OnStart(ctx context.Context, info *RunInfo, input CallbackInput) context.Context
@@ -136,12 +201,44 @@ func (h CallbackHandler) OnError(ctx context.Context, info *callbacks.RunInfo, e
 
 func (h CallbackHandler) OnStartWithStreamInput(ctx context.Context, info *callbacks.RunInfo,
 	input *schema.StreamReader[callbacks.CallbackInput]) context.Context {
-	// Implementation for handling stream input start
+	// the copied stream must be closed
+	input.Close()
 	return ctx
 }
 
+// OnEndWithStreamOutput logs the incremental tokens and tool-call deltas of the model, as they arrive
 func (h CallbackHandler) OnEndWithStreamOutput(ctx context.Context, info *callbacks.RunInfo,
 	output *schema.StreamReader[callbacks.CallbackOutput]) context.Context {
-	// Implementation for handling stream output end
+	if info == nil || info.Component != components.ComponentOfChatModel {
+		output.Close()
+		return ctx
+	}
+	go func() {
+		defer output.Close()
+		for {
+			chunk, err := output.Recv()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				log.Error("<%s> stream error: %v", info.Name, err)
+				return
+			}
+			out := model.ConvCallbackOutput(chunk)
+			if out == nil || out.Message == nil {
+				continue
+			}
+			if out.Message.Content != "" {
+				log.Debug("<%s> %s", info.Name, out.Message.Content)
+			}
+			for _, tc := range out.Message.ToolCalls {
+				idx := 0
+				if tc.Index != nil {
+					idx = *tc.Index
+				}
+				log.Debug("<%s> tool call #%d %s: %s", info.Name, idx, tc.Function.Name, tc.Function.Arguments)
+			}
+		}
+	}()
 	return ctx
 }
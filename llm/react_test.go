@@ -0,0 +1,102 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package llm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/abcoder/llm/prompt"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/flow/agent/react"
+	"github.com/cloudwego/eino/schema"
+)
+
+// streamModel streams the chunks, then fails with err if not nil
+type streamModel struct {
+	chunks []*schema.Message
+	err    error
+}
+
+func (m *streamModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	return schema.ConcatMessages(m.chunks)
+}
+
+func (m *streamModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	sr, sw := schema.Pipe[*schema.Message](len(m.chunks) + 1)
+	go func() {
+		defer sw.Close()
+		for _, c := range m.chunks {
+			sw.Send(c, nil)
+		}
+		if m.err != nil {
+			sw.Send(nil, m.err)
+		}
+	}()
+	return sr, nil
+}
+
+func (m *streamModel) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	return m, nil
+}
+
+func TestReactAgent_StreamGenerate(t *testing.T) {
+	newAgent := func(m *streamModel) *ReactAgent {
+		return NewReactAgent("test", ReactAgentOptions{
+			SysPrompt:   prompt.NewTextPrompt("you are a test"),
+			AgentConfig: &react.AgentConfig{ToolCallingModel: m, MaxStep: 3},
+		})
+	}
+	chunks := []*schema.Message{schema.AssistantMessage("Hel", nil), schema.AssistantMessage("lo", nil)}
+
+	var got []string
+	msg, err := newAgent(&streamModel{chunks: chunks}).StreamGenerate(context.Background(), []*schema.Message{schema.UserMessage("hi")}, func(m *schema.Message) {
+		got = append(got, m.Content)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Content != "Hello" || strings.Join(got, "|") != "Hel|lo" {
+		t.Errorf("StreamGenerate() = %q, chunks %q", msg.Content, got)
+	}
+
+	// the partial answer is kept on errors
+	msg, err = newAgent(&streamModel{chunks: chunks, err: errors.New("timeout")}).StreamGenerate(context.Background(), []*schema.Message{schema.UserMessage("hi")}, nil)
+	if err == nil || msg == nil || msg.Content != "Hello" {
+		t.Errorf("StreamGenerate() = %v, %v, want the partial answer with the error", msg, err)
+	}
+}
+
+func Test_fullStreamToolCallChecker(t *testing.T) {
+	call := schema.AssistantMessage("", []schema.ToolCall{{Function: schema.FunctionCall{Name: "list_repos"}}})
+	for _, tt := range []struct {
+		chunks []*schema.Message
+		want   bool
+	}{
+		{[]*schema.Message{schema.AssistantMessage("answer", nil)}, false},
+		{[]*schema.Message{call}, true},
+		// texts before the tool call
+		{[]*schema.Message{schema.AssistantMessage("let me check", nil), call}, true},
+	} {
+		sr := schema.StreamReaderFromArray(tt.chunks)
+		if got, err := fullStreamToolCallChecker(context.Background(), sr); err != nil || got != tt.want {
+			t.Errorf("fullStreamToolCallChecker(%v) = %v, %v, want %v", tt.chunks, got, err, tt.want)
+		}
+	}
+}
@@ -20,68 +20,161 @@ import (
 	"context"
 	"fmt"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
+	"unicode/utf8"
 
 	abutil "github.com/cloudwego/abcoder/internal/utils"
 	"github.com/cloudwego/abcoder/lang/uniast"
 	"github.com/cloudwego/abcoder/llm/log"
+	"github.com/cloudwego/abcoder/llm/packer"
 	"github.com/cloudwego/eino/components/tool"
 	"github.com/cloudwego/eino/components/tool/utils"
 	"github.com/fsnotify/fsnotify"
 )
 
 const (
-	ToolListRepos           = "list_repos"
-	DescListRepos           = "[DISCOVERY] level1/4: List all repositories. No parameters required. Always the first step in any analysis workflow."
-	ToolGetRepoStructure    = "get_repo_structure"
-	DescGetRepoStructure    = "[STRUCTURE] level2/4: Get repository structure. Input: repo_name from list_repos output. Output: modules with packages and files."
-	ToolGetPackageStructure = "get_package_structure"
-	DescGetPackageStructure = "[STRUCTURE] level3/4: Get package structure with node_ids. Input: repo_name, mod_path, pkg_path from get_repo_structure output. Output: files with node_ids."
-	ToolGetFileStructure    = "get_file_structure"
-	DescGetFileStructure    = "[STRUCTURE] level3/4: Get file structure with node list. Input: repo_name, file_path from get_repo_structure output. Output: nodes with signatures."
-	ToolGetASTNode          = "get_ast_node"
-	DescGetASTNode          = "[ANALYSIS] level4/4: Get detailed AST node info. Input: repo_name, node_ids from previous calls. Output: codes, dependencies, references, implementations."
+	ToolListRepos             = "list_repos"
+	DescListRepos             = "[DISCOVERY] level1/4: List all repositories, with the commits, branches and dirty states of the sources they are parsed from. No parameters required. Always the first step in any analysis workflow."
+	ToolGetRepoStructure      = "get_repo_structure"
+	DescGetRepoStructure      = "[STRUCTURE] level2/4: Get repository structure. Input: repo_name from list_repos output, optional page/page_size/max_bytes to page the packages. Output: modules with packages and files."
+	ToolGetPackageStructure   = "get_package_structure"
+	DescGetPackageStructure   = "[STRUCTURE] level3/4: Get package structure with node_ids. Input: repo_name, mod_path, pkg_path from get_repo_structure output, optional page/page_size/max_bytes to page the files. Output: files with node_ids, and init_order of the vars and init functions for go."
+	ToolGetFileStructure      = "get_file_structure"
+	DescGetFileStructure      = "[STRUCTURE] level3/4: Get file structure with node list. Input: repo_name, file_path from get_repo_structure output. Output: nodes with signatures."
+	ToolGetASTNode            = "get_ast_node"
+	DescGetASTNode            = "[ANALYSIS] level4/4: Get detailed AST node info. Input: repo_name, node_ids from previous calls, optional token_budget to fit large nodes, optional inline_depth to get the codes of the dependencies together, page/page_size/max_bytes to page the nodes. Output: codes, dependencies, references, implementations, inlined dependencies."
+	ToolGetRepoStats          = "get_repo_stats"
+	DescGetRepoStats          = "[DISCOVERY] level2/4: Get repository statistics. Input: repo_name from list_repos output. Output: module/package/file counts, node counts per kind, largest files and functions, most-referenced nodes, package fan-in/fan-out rankings."
+	ToolGetTestsForNode       = "get_tests_for_node"
+	DescGetTestsForNode       = "[ANALYSIS] level4/4: Get the tests exercising an AST node, linked by test names and calls. Input: repo_name, node_id from previous calls. Output: test node_ids with locations."
+	ToolFindReferences        = "find_references"
+	DescFindReferences        = "[ANALYSIS] level4/4: Find all nodes referencing an AST node, including indirect references through its typedefs and the interface methods it implements. Input: repo_name, node_id from previous calls. Output: referencing node_ids with file:line locations, grouped by package."
+	ToolFindSymbolAcrossRepos = "find_symbol_across_repos"
+	DescFindSymbolAcrossRepos = "[ANALYSIS] level4/4: Find a symbol by name in several repositories, with the nodes referencing it in each of them (e.g. the downstream consumers of an SDK type). Input: name, optional pkg_path and repo_names. Output: repo_name qualified node_ids of the definitions and references."
+	ToolGetNodeMetrics        = "get_node_metrics"
+	DescGetNodeMetrics        = "[ANALYSIS] level4/4: Get the metrics of functions to prioritize refactoring: lines of code, rough cyclomatic complexity, fan-in (distinct callers) and fan-out (distinct callees). Input: repo_name, optional node_ids; without node_ids the functions (of pkg_path if given) are ranked by sort_by, paged by page/page_size/max_bytes. Output: node_ids with metrics."
+	ToolChunkRepo             = "chunk_repo"
+	DescChunkRepo             = "[RAG] Split the codes of a repository into chunks for embedding. Each chunk holds whole nodes (codes with signatures and docs) of one package in the order of files and lines, bounded by max_tokens; a larger node is split into parts. Chunk ids are stable as long as the chunk holds the same nodes. Input: repo_name, optional pkg_path, max_tokens and page/page_size/max_bytes. Output: chunks with ids, node_ids and texts."
+	ToolGetNodeOwners         = "get_node_owners"
+	DescGetNodeOwners         = "[ANALYSIS] level4/4: Get the primary authors and the last modified times of AST nodes by git blame, and the suggested reviewers of a change touching all of them. Only available if the repository is parsed with --blame. Input: repo_name, node_ids from previous calls. Output: node_ids with authors, and reviewers ranked by the lines they wrote."
+	ToolFindUnreachableNodes  = "find_unreachable_nodes"
+	DescFindUnreachableNodes  = "[ANALYSIS] level4/4: Find the dead nodes which are never reached from the entrypoints through dependencies, accounting for interface implementations and init functions. Input: repo_name, optional entrypoints (node_ids, default to the main functions and tests), include_api to take the exported nodes as entrypoints too (for libraries), pkg_path to filter the results, page/page_size/max_bytes. Output: unreachable node_ids with locations."
+	ToolGetDependencies       = "get_dependencies"
+	DescGetDependencies       = "[ANALYSIS] level4/4: Get the third-party dependencies of a repository for supply-chain queries: names, resolved versions, languages, licenses (only if parsed with --detect-licenses) and the internal modules depending on them. Input: repo_name, optional name to filter by substring, page/page_size/max_bytes. Output: dependencies with module names whose symbols are loaded."
+	ToolLocateNodeByPosition  = "locate_node_by_position"
+	DescLocateNodeByPosition  = "[ANALYSIS] level4/4: Locate the AST nodes owning source positions, to explain crashes, compile errors or coverage reports. Input: repo_name, position as file:line or file:line:col, and/or a pasted stack trace (Go panic, Rust panic or backtrace, compiler output) whose file:line frames are resolved in order; absolute paths of other machines are matched by suffix. Output: the innermost node of each position with codes, frames outside the repository are skipped."
+	ToolGetPublicAPI          = "get_public_api"
+	DescGetPublicAPI          = "[STRUCTURE] level2/4: Get the public API of the modules cheaply, to answer what a library exposes: the exported functions, types and vars with signatures but without bodies (go structs keep only the exported fields). Tests, main packages and go internal packages are skipped. Input: repo_name, optional mod_path (default to all internal modules), pkg_path to filter, page/page_size/max_bytes. Output: node_ids with types and signatures ordered by ids."
+	ToolGetDiagram            = "get_diagram"
+	DescGetDiagram            = "[ANALYSIS] level4/4: Draw a mermaid diagram of the architecture from the AST, to embed it in markdown reports. `component` draws the dependencies among the packages (or the nodes with granularity node) under pkg_path; `sequence` draws the calls from the entry function in order, whose participants are the packages. The diagram is derived deterministically, give the labels to rename the packages or calls readable after reading them. Input: repo_name, kind, entry (for sequence), depth, pkg_path, granularity, external, labels. Output: the mermaid fenced block."
+	ToolGetImplementations    = "get_implementations"
+	DescGetImplementations    = "[ANALYSIS] level4/4: Get the implements relations between types and interfaces across modules, including the external interfaces like io.Reader. Input: repo_name, optional node_id: of an interface to get the types implementing it, or of a type to get the interfaces it implements; without node_id the whole matrix is paged by page/page_size/max_bytes. Output: interfaces with the node_ids of their implementations, and the interfaces implemented by the type."
+	ToolListTodos             = "list_todos"
+	DescListTodos             = "[ANALYSIS] level3/4: List the TODO, FIXME, XXX, HACK and BUG markers in the comments, to mine the known issues and the unfinished work. Each is attached to the node containing it or else the nearest following one. The repo must be parsed with `--comments`. Input: repo_name, optional marker to filter (like FIXME), pkg_path to only list those of the nodes in the package, page/page_size/max_bytes. Output: markers with owners, messages, file lines and node_ids ordered by files and lines."
+	ToolFindErrorOrigins      = "find_error_origins"
+	DescFindErrorOrigins      = "[ANALYSIS] level4/4: Find where an error can originate, to trace an error message or a failure back to its sources. The functions record whether they return errors, the errors they create by messages (errors.New, fmt.Errorf), wrap (fmt.Errorf %w, errors.Wrap), return as sentinels (io.EOF) or create as error types (go), and the error types of their Results (rust). Input: repo_name, node_id: of an error type or a sentinel var to get the sites originating it, or of a function to get the sites of itself and of its callees returning errors transitively; without node_id all the sites of the repo are paged by page/page_size/max_bytes. Output: sites with kinds, errors, messages, file lines, node_ids of the functions and the call chains to them."
 	// ToolWriteASTNode        = "write_ast_node"
 )
 
 var (
-	SchemaListRepos           = GetJSONSchema(ListReposReq{})
-	SchemaGetRepoStructure    = GetJSONSchema(GetRepoStructReq{})
-	SchemaGetPackageStructure = GetJSONSchema(GetPackageStructReq{})
-	SchemaGetFileStructure    = GetJSONSchema(GetFileStructReq{})
-	SchemaGetASTNode          = GetJSONSchema(GetASTNodeReq{})
+	SchemaListRepos             = GetJSONSchema(ListReposReq{})
+	SchemaGetRepoStructure      = GetJSONSchema(GetRepoStructReq{})
+	SchemaGetPackageStructure   = GetJSONSchema(GetPackageStructReq{})
+	SchemaGetFileStructure      = GetJSONSchema(GetFileStructReq{})
+	SchemaGetASTNode            = GetJSONSchema(GetASTNodeReq{})
+	SchemaGetTestsForNode       = GetJSONSchema(GetTestsForNodeReq{})
+	SchemaFindReferences        = GetJSONSchema(FindReferencesReq{})
+	SchemaGetRepoStats          = GetJSONSchema(GetRepoStatsReq{})
+	SchemaFindSymbolAcrossRepos = GetJSONSchema(FindSymbolAcrossReposReq{})
+	SchemaGetNodeMetrics        = GetJSONSchema(GetNodeMetricsReq{})
+	SchemaChunkRepo             = GetJSONSchema(ChunkRepoReq{})
+	SchemaGetNodeOwners         = GetJSONSchema(GetNodeOwnersReq{})
+	SchemaFindUnreachableNodes  = GetJSONSchema(FindUnreachableNodesReq{})
+	SchemaGetDependencies       = GetJSONSchema(GetDependenciesReq{})
+	SchemaLocateNodeByPosition  = GetJSONSchema(LocateNodeByPositionReq{})
+	SchemaGetPublicAPI          = GetJSONSchema(GetPublicAPIReq{})
+	SchemaGetDiagram            = GetJSONSchema(GetDiagramReq{})
+	SchemaGetImplementations    = GetJSONSchema(GetImplementationsReq{})
+	SchemaListTodos             = GetJSONSchema(ListTodosReq{})
+	SchemaFindErrorOrigins      = GetJSONSchema(FindErrorOriginsReq{})
 )
 
 type ASTReadToolsOptions struct {
 	// PatchOptions patch.Options
 	RepoASTsDir string
+	// TokenBudget is the default token_budget of get_ast_node, no limit if 0
+	TokenBudget int
+	// MaxBytes is the default max_bytes of the paged tools, no limit if 0
+	MaxBytes int
+	// MaxLoadedRepos is the max count of repos kept decoded in memory, no limit if 0.
+	// The ASTs are decoded on first use, and the least recently used ones are evicted.
+	MaxLoadedRepos int
+	// RepoAliases maps the alias to the repo name, which can be used as repo_name
+	RepoAliases map[string]string
+	// Repos are searched by find_symbol_across_repos if repo_names is not given, all repos if empty
+	Repos []string
 }
 
 type ASTReadTools struct {
 	opts  ASTReadToolsOptions
-	repos sync.Map
+	repos *repoCache
 	tools map[string]tool.InvokableTool
+
+	subMu       sync.Mutex
+	subscribers []func(RepoEvent)
+}
+
+// Subscribe registers fn to be called (on the watcher goroutine) once a repo is added, updated or removed
+// since its AST file under RepoASTsDir changed, thus the caches built on the repo can be invalidated
+func (t *ASTReadTools) Subscribe(fn func(RepoEvent)) {
+	t.subMu.Lock()
+	defer t.subMu.Unlock()
+	t.subscribers = append(t.subscribers, fn)
+}
+
+func (t *ASTReadTools) notify(events []RepoEvent) {
+	t.subMu.Lock()
+	subs := t.subscribers
+	t.subMu.Unlock()
+	for _, ev := range events {
+		log.Info("repo %s %s", ev.RepoName, ev.Op)
+		for _, fn := range subs {
+			fn(ev)
+		}
+	}
 }
 
 func NewASTReadTools(opts ASTReadToolsOptions) *ASTReadTools {
+	// the names registered by `abcoder repos add` are aliases, unless overridden
+	if reg, err := LoadRegistry(opts.RepoASTsDir); err != nil {
+		log.Error("Load the registry of repos failed: %v", err)
+	} else if aliases := reg.Aliases(); len(aliases) > 0 {
+		for alias, name := range opts.RepoAliases {
+			aliases[alias] = name
+		}
+		opts.RepoAliases = aliases
+	}
+
 	ret := &ASTReadTools{
 		opts: opts,
 		// patcher: patch.NewPatcher(repo, opts.PatchOptions),
 		tools: map[string]tool.InvokableTool{},
+		repos: newRepoCache(opts.MaxLoadedRepos, opts.RepoAliases),
 	}
 
-	// read all *.json files in opts.RepoASTsDir
+	// index all *.json files in opts.RepoASTsDir, they are decoded on first use
 	files, err := filepath.Glob(filepath.Join(opts.RepoASTsDir, "*.json"))
 	if err != nil {
 		panic(err)
 	}
 	for _, f := range files {
-		// parse json
-		if repo, err := uniast.LoadRepo(f); err != nil {
+		if _, err := ret.repos.Add(f); err != nil {
 			panic("Load Uniast JSON file failed: " + err.Error())
-		} else {
-			ret.repos.Store(repo.Name, repo)
 		}
 	}
 
@@ -91,13 +184,13 @@ func NewASTReadTools(opts ASTReadToolsOptions) *ASTReadTools {
 			return
 		}
 		if op&fsnotify.Write != 0 || op&fsnotify.Create != 0 {
-			if repo, err := uniast.LoadRepo(file); err != nil {
+			events, err := ret.repos.Add(file)
+			if err != nil {
 				log.Error("Load Uniast JSON file failed: %v", err)
-			} else {
-				ret.repos.Store(repo.Name, repo)
 			}
-		} else if op&fsnotify.Remove != 0 {
-			ret.repos.Delete(filepath.Base(file))
+			ret.notify(events)
+		} else if op&(fsnotify.Remove|fsnotify.Rename) != 0 {
+			ret.notify(ret.repos.Remove(file))
 		}
 	})
 
@@ -121,6 +214,16 @@ func NewASTReadTools(opts ASTReadToolsOptions) *ASTReadTools {
 	}
 	ret.tools[ToolGetRepoStructure] = tt
 
+	tt, err = utils.InferTool(ToolGetRepoStats,
+		DescGetRepoStats,
+		ret.GetRepoStats, utils.WithMarshalOutput(func(ctx context.Context, output interface{}) (string, error) {
+			return abutil.MarshalJSONIndent(output)
+		}))
+	if err != nil {
+		panic(err)
+	}
+	ret.tools[ToolGetRepoStats] = tt
+
 	tt, err = utils.InferTool(string(ToolGetPackageStructure),
 		string(DescGetPackageStructure),
 		ret.GetPackageStructure, utils.WithMarshalOutput(func(ctx context.Context, output interface{}) (string, error) {
@@ -150,6 +253,146 @@ func NewASTReadTools(opts ASTReadToolsOptions) *ASTReadTools {
 		panic(err)
 	}
 	ret.tools[ToolGetASTNode] = tt
+
+	tt, err = utils.InferTool(ToolGetTestsForNode,
+		DescGetTestsForNode,
+		ret.GetTestsForNode, utils.WithMarshalOutput(func(ctx context.Context, output interface{}) (string, error) {
+			return abutil.MarshalJSONIndent(output)
+		}))
+	if err != nil {
+		panic(err)
+	}
+	ret.tools[ToolGetTestsForNode] = tt
+
+	tt, err = utils.InferTool(ToolFindReferences,
+		DescFindReferences,
+		ret.FindReferences, utils.WithMarshalOutput(func(ctx context.Context, output interface{}) (string, error) {
+			return abutil.MarshalJSONIndent(output)
+		}))
+	if err != nil {
+		panic(err)
+	}
+	ret.tools[ToolFindReferences] = tt
+
+	tt, err = utils.InferTool(ToolFindSymbolAcrossRepos,
+		DescFindSymbolAcrossRepos,
+		ret.FindSymbolAcrossRepos, utils.WithMarshalOutput(func(ctx context.Context, output interface{}) (string, error) {
+			return abutil.MarshalJSONIndent(output)
+		}))
+	if err != nil {
+		panic(err)
+	}
+	ret.tools[ToolFindSymbolAcrossRepos] = tt
+
+	tt, err = utils.InferTool(ToolGetNodeMetrics,
+		DescGetNodeMetrics,
+		ret.GetNodeMetrics, utils.WithMarshalOutput(func(ctx context.Context, output interface{}) (string, error) {
+			return abutil.MarshalJSONIndent(output)
+		}))
+	if err != nil {
+		panic(err)
+	}
+	ret.tools[ToolGetNodeMetrics] = tt
+
+	tt, err = utils.InferTool(ToolChunkRepo,
+		DescChunkRepo,
+		ret.ChunkRepo, utils.WithMarshalOutput(func(ctx context.Context, output interface{}) (string, error) {
+			return abutil.MarshalJSONIndent(output)
+		}))
+	if err != nil {
+		panic(err)
+	}
+	ret.tools[ToolChunkRepo] = tt
+
+	tt, err = utils.InferTool(ToolGetNodeOwners,
+		DescGetNodeOwners,
+		ret.GetNodeOwners, utils.WithMarshalOutput(func(ctx context.Context, output interface{}) (string, error) {
+			return abutil.MarshalJSONIndent(output)
+		}))
+	if err != nil {
+		panic(err)
+	}
+	ret.tools[ToolGetNodeOwners] = tt
+
+	tt, err = utils.InferTool(ToolFindUnreachableNodes,
+		DescFindUnreachableNodes,
+		ret.FindUnreachableNodes, utils.WithMarshalOutput(func(ctx context.Context, output interface{}) (string, error) {
+			return abutil.MarshalJSONIndent(output)
+		}))
+	if err != nil {
+		panic(err)
+	}
+	ret.tools[ToolFindUnreachableNodes] = tt
+
+	tt, err = utils.InferTool(ToolGetDependencies,
+		DescGetDependencies,
+		ret.GetDependencies, utils.WithMarshalOutput(func(ctx context.Context, output interface{}) (string, error) {
+			return abutil.MarshalJSONIndent(output)
+		}))
+	if err != nil {
+		panic(err)
+	}
+	ret.tools[ToolGetDependencies] = tt
+
+	tt, err = utils.InferTool(ToolLocateNodeByPosition,
+		DescLocateNodeByPosition,
+		ret.LocateNodeByPosition, utils.WithMarshalOutput(func(ctx context.Context, output interface{}) (string, error) {
+			return abutil.MarshalJSONIndent(output)
+		}))
+	if err != nil {
+		panic(err)
+	}
+	ret.tools[ToolLocateNodeByPosition] = tt
+
+	tt, err = utils.InferTool(ToolGetPublicAPI,
+		DescGetPublicAPI,
+		ret.GetPublicAPI, utils.WithMarshalOutput(func(ctx context.Context, output interface{}) (string, error) {
+			return abutil.MarshalJSONIndent(output)
+		}))
+	if err != nil {
+		panic(err)
+	}
+	ret.tools[ToolGetPublicAPI] = tt
+
+	tt, err = utils.InferTool(ToolGetDiagram,
+		DescGetDiagram,
+		ret.GetDiagram, utils.WithMarshalOutput(func(ctx context.Context, output interface{}) (string, error) {
+			return abutil.MarshalJSONIndent(output)
+		}))
+	if err != nil {
+		panic(err)
+	}
+	ret.tools[ToolGetDiagram] = tt
+
+	tt, err = utils.InferTool(ToolGetImplementations,
+		DescGetImplementations,
+		ret.GetImplementations, utils.WithMarshalOutput(func(ctx context.Context, output interface{}) (string, error) {
+			return abutil.MarshalJSONIndent(output)
+		}))
+	if err != nil {
+		panic(err)
+	}
+	ret.tools[ToolGetImplementations] = tt
+
+	tt, err = utils.InferTool(ToolListTodos,
+		DescListTodos,
+		ret.ListTodos, utils.WithMarshalOutput(func(ctx context.Context, output interface{}) (string, error) {
+			return abutil.MarshalJSONIndent(output)
+		}))
+	if err != nil {
+		panic(err)
+	}
+	ret.tools[ToolListTodos] = tt
+
+	tt, err = utils.InferTool(ToolFindErrorOrigins,
+		DescFindErrorOrigins,
+		ret.FindErrorOrigins, utils.WithMarshalOutput(func(ctx context.Context, output interface{}) (string, error) {
+			return abutil.MarshalJSONIndent(output)
+		}))
+	if err != nil {
+		panic(err)
+	}
+	ret.tools[ToolFindErrorOrigins] = tt
 	return ret
 }
 
@@ -168,25 +411,40 @@ func (t *ASTReadTools) GetTool(name string) Tool {
 type ListReposReq struct{}
 
 type ListReposResp struct {
-	RepoNames []string `json:"repo_names" jsonschema:"description=the names of the repositories"`
+	RepoNames []string          `json:"repo_names" jsonschema:"description=the names of the repositories"`
+	Aliases   map[string]string `json:"aliases,omitempty" jsonschema:"description=the aliases of the repositories, which can also be used as repo_name"`
+	Repos     []RepoInfo        `json:"repos,omitempty" jsonschema:"description=the snapshots of the sources which the repositories are parsed from"`
+}
+
+type RepoInfo struct {
+	RepoName string `json:"repo_name" jsonschema:"description=the name of the repository"`
+	Commit   string `json:"commit,omitempty" jsonschema:"description=the commit of the sources, unknown if absent"`
+	Branch   string `json:"branch,omitempty" jsonschema:"description=the branch of the sources, absent if detached"`
+	Dirty    bool   `json:"dirty,omitempty" jsonschema:"description=the sources had uncommitted changes, thus the AST may differ from the commit"`
+	Version  string `json:"version,omitempty" jsonschema:"description=the abcoder version which parsed the repository"`
 }
 
 func (t *ASTReadTools) ListRepos(ctx context.Context, req ListReposReq) (*ListReposResp, error) {
-	ret := ListReposResp{}
-	t.repos.Range(func(key, value interface{}) bool {
-		ret.RepoNames = append(ret.RepoNames, key.(string))
-		return true
-	})
-	return &ret, nil
+	resp := &ListReposResp{RepoNames: t.repos.Names(), Aliases: t.opts.RepoAliases}
+	for _, h := range t.repos.Headers() {
+		info := RepoInfo{RepoName: h.Name, Version: h.ToolVersion}
+		if h.VCS != nil {
+			info.Commit, info.Branch, info.Dirty = h.VCS.Commit, h.VCS.Branch, h.VCS.Dirty
+		}
+		resp.Repos = append(resp.Repos, info)
+	}
+	return resp, nil
 }
 
 type GetRepoStructReq struct {
 	RepoName string `json:"repo_name" jsonschema:"description=the name of the repository (output of list_repos tool)"`
+	PageReq
 }
 
 type GetRepoStructResp struct {
-	Modules []ModuleStruct `json:"modules" jsonschema:"description=the module structure of the repository"`
-	Error   string         `json:"error,omitempty" jsonschema:"description=the error message"`
+	Modules []ModuleStruct `json:"modules" jsonschema:"description=the module structure of the repository paged by packages"`
+	PageResp
+	Error string `json:"error,omitempty" jsonschema:"description=the error message"`
 }
 
 type ModuleStruct struct {
@@ -214,11 +472,13 @@ type NodeStruct struct {
 	File         string         `json:"file,omitempty" jsonschema:"description=the file path of the node"`
 	Line         int            `json:"line,omitempty" jsonschema:"description=the line of the node"`
 	Codes        string         `json:"codes,omitempty" jsonschema:"description=the codes of the node"`
+	Packed       string         `json:"packed,omitempty" jsonschema:"description=how the codes are packed under token_budget: full, truncated, outline (without function body) or omitted"`
 	Dependencies []NodeID       `json:"dependencies,omitempty" jsonschema:"description=the dependencies of the node"`
 	References   []NodeID       `json:"references,omitempty" jsonschema:"description=the references of the node"`
 	Implements   []NodeID       `json:"implements,omitempty" jsonschema:"description=the implements of the node"`
 	Groups       []NodeID       `json:"groups,omitempty" jsonschema:"description=the groups of the node"`
 	Inherits     []NodeID       `json:"inherits,omitempty" jsonschema:"description=the inherits of the node"`
+	Inlined      string         `json:"inlined,omitempty" jsonschema:"description=the codes of the dependencies up to inline_depth in a block, each headed by a comment line of its identity and file line. Full codes of the direct dependencies, signatures of the deeper ones"`
 }
 
 type NodeID struct {
@@ -243,33 +503,22 @@ func (n NodeID) Identity() uniast.Identity {
 	}
 }
 
+// ResolveRepo returns the name of the repo which repo_name refers to, see list_repos
+func (t *ASTReadTools) ResolveRepo(repoName string) (string, error) {
+	return t.repos.Resolve(repoName)
+}
+
+// GetRepo returns the AST of the repo which repo_name refers to. It must not be modified
+func (t *ASTReadTools) GetRepo(repoName string) (*uniast.Repository, error) {
+	return t.getRepoAST(repoName)
+}
+
 func (t *ASTReadTools) getRepoAST(repoName string) (*uniast.Repository, error) {
-	repo, ok := t.repos.Load(repoName)
-	if !ok {
-		candis := []string{}
-		t.repos.Range(func(key, value interface{}) bool {
-			if strings.Contains(key.(string), repoName) {
-				candis = append(candis, key.(string))
-			}
-			return true
-		})
-		if len(candis) == 1 {
-			repo, ok = t.repos.Load(candis[0])
-			if !ok {
-				return nil, fmt.Errorf("repo '%s' not found. Use `list_repos` to get valid repo_name", candis[0])
-			}
-			return repo.(*uniast.Repository), nil
-		} else if len(candis) > 1 {
-			return nil, fmt.Errorf("repo '%s' is ambiguous, maybe you want one of %v", repoName, candis)
-		} else {
-			return nil, fmt.Errorf("repo '%s' not found. Use `list_repos` to get valid repo_name", repoName)
-		}
-	}
-	return repo.(*uniast.Repository), nil
+	return t.repos.Get(repoName)
 }
 
 // GetRepoStructure list the packages and file-paths
-func (t *ASTReadTools) GetRepoStructure(_ context.Context, req GetRepoStructReq) (*GetRepoStructResp, error) {
+func (t *ASTReadTools) GetRepoStructure(ctx context.Context, req GetRepoStructReq) (*GetRepoStructResp, error) {
 	log.Debug("get repo structure, req: %v", abutil.MarshalJSONIndentNoError(req))
 	repo, err := t.getRepoAST(req.RepoName)
 	if err != nil {
@@ -278,15 +527,23 @@ func (t *ASTReadTools) GetRepoStructure(_ context.Context, req GetRepoStructReq)
 		}, nil
 	}
 
-	resp := new(GetRepoStructResp)
+	// list the packages in order, to page them stably
+	type modPackage struct {
+		mod uniast.ModPath
+		PackageStruct
+	}
+	var pkgs []modPackage
+	stopped := false
+collect:
 	for _, mod := range repo.Modules {
 		if mod.IsExternal() {
 			continue
 		}
-		mm := ModuleStruct{
-			ModPath: mod.Name,
-		}
 		for p := range mod.Packages {
+			if ctx.Err() != nil {
+				stopped = true
+				break collect
+			}
 			pp := PackageStruct{
 				PkgPath: p,
 			}
@@ -296,48 +553,152 @@ func (t *ASTReadTools) GetRepoStructure(_ context.Context, req GetRepoStructReq)
 					FilePath: f.Path,
 				})
 			}
-			mm.Packages = append(mm.Packages, pp)
+			pkgs = append(pkgs, modPackage{mod.Name, pp})
+		}
+	}
+	sort.Slice(pkgs, func(i, j int) bool {
+		if pkgs[i].mod != pkgs[j].mod {
+			return pkgs[i].mod < pkgs[j].mod
+		}
+		return pkgs[i].PkgPath < pkgs[j].PkgPath
+	})
+
+	resp := new(GetRepoStructResp)
+	for _, p := range paginate(pkgs, req.PageReq, t.opts.MaxBytes, &resp.PageResp) {
+		if n := len(resp.Modules); n == 0 || resp.Modules[n-1].ModPath != p.mod {
+			resp.Modules = append(resp.Modules, ModuleStruct{ModPath: p.mod})
 		}
-		resp.Modules = append(resp.Modules, mm)
+		mm := &resp.Modules[len(resp.Modules)-1]
+		mm.Packages = append(mm.Packages, p.PackageStruct)
+	}
+	if stopped {
+		markPartial(ctx, &resp.PageResp)
 	}
 	log.Debug("get repo structure, resp: %v", abutil.MarshalJSONIndentNoError(resp))
 	return resp, nil
 }
 
+const defaultStatsTop = 10
+
+type GetRepoStatsReq struct {
+	RepoName string `json:"repo_name" jsonschema:"description=the name of the repository (output of list_repos tool)"`
+	Top      int    `json:"top,omitempty" jsonschema:"description=the max number of entries of each ranking, default to 10,default=10"`
+}
+
+type FileStat struct {
+	FilePath string `json:"file_path" jsonschema:"description=the path of the file"`
+	LOC      int    `json:"loc" jsonschema:"description=the lines of nodes in the file"`
+	Nodes    int    `json:"nodes" jsonschema:"description=the number of nodes in the file"`
+}
+
+type NodeStat struct {
+	NodeID
+	File  string `json:"file,omitempty" jsonschema:"description=the file path of the node"`
+	Count int    `json:"count" jsonschema:"description=lines of the function, or the number of nodes referencing the node"`
+}
+
+type PackageStat struct {
+	ModPath uniast.ModPath `json:"mod_path" jsonschema:"description=the module path"`
+	PkgPath uniast.PkgPath `json:"pkg_path" jsonschema:"description=the package path"`
+	Count   int            `json:"count" jsonschema:"description=the number of internal packages depended on, or depending on it"`
+}
+
+type GetRepoStatsResp struct {
+	Modules          int            `json:"modules" jsonschema:"description=the number of internal modules"`
+	Packages         int            `json:"packages" jsonschema:"description=the number of internal packages"`
+	Files            int            `json:"files" jsonschema:"description=the number of internal files"`
+	LOC              int            `json:"loc" jsonschema:"description=the total lines of all nodes"`
+	Nodes            map[string]int `json:"nodes,omitempty" jsonschema:"description=node counts per kind, like function, method, test, struct, interface, var, const"`
+	LargestFiles     []FileStat     `json:"largest_files,omitempty" jsonschema:"description=files with the most lines"`
+	LargestFunctions []NodeStat     `json:"largest_functions,omitempty" jsonschema:"description=functions with the most lines"`
+	MostReferenced   []NodeStat     `json:"most_referenced,omitempty" jsonschema:"description=nodes referenced by the most other nodes"`
+	FanIn            []PackageStat  `json:"fan_in,omitempty" jsonschema:"description=packages depended on by the most other packages"`
+	FanOut           []PackageStat  `json:"fan_out,omitempty" jsonschema:"description=packages depending on the most other packages"`
+	Error            string         `json:"error,omitempty" jsonschema:"description=the error message"`
+}
+
+// GetRepoStats get the statistics and hotspots of the repo
+func (t *ASTReadTools) GetRepoStats(_ context.Context, req GetRepoStatsReq) (*GetRepoStatsResp, error) {
+	log.Debug("get repo stats, req: %v", abutil.MarshalJSONIndentNoError(req))
+	repo, err := t.getRepoAST(req.RepoName)
+	if err != nil {
+		return &GetRepoStatsResp{
+			Error: err.Error(),
+		}, nil
+	}
+	top := req.Top
+	if top <= 0 {
+		top = defaultStatsTop
+	}
+
+	stats := repo.Stats(top)
+	resp := &GetRepoStatsResp{
+		Modules:  stats.Modules,
+		Packages: stats.Packages,
+		Files:    stats.Files,
+		LOC:      stats.LOC,
+		Nodes:    stats.Nodes,
+	}
+	for _, f := range stats.LargestFiles {
+		resp.LargestFiles = append(resp.LargestFiles, FileStat{FilePath: f.Path, LOC: f.LOC, Nodes: f.Nodes})
+	}
+	for _, n := range stats.LargestFunctions {
+		resp.LargestFunctions = append(resp.LargestFunctions, NodeStat{NodeID: NewNodeID(n.Identity), File: n.File, Count: n.Count})
+	}
+	for _, n := range stats.MostReferenced {
+		resp.MostReferenced = append(resp.MostReferenced, NodeStat{NodeID: NewNodeID(n.Identity), File: n.File, Count: n.Count})
+	}
+	for _, p := range stats.FanIn {
+		resp.FanIn = append(resp.FanIn, PackageStat(p))
+	}
+	for _, p := range stats.FanOut {
+		resp.FanOut = append(resp.FanOut, PackageStat(p))
+	}
+
+	log.Debug("get repo stats, resp: %v", abutil.MarshalJSONIndentNoError(resp))
+	return resp, nil
+}
+
 type GetPackageStructReq struct {
 	RepoName string         `json:"repo_name" jsonschema:"description=the name of the repository (output of list_repos tool)"`
 	ModPath  uniast.ModPath `json:"mod_path" jsonschema:"description=the module path (output of get_repo_structure tool)"`
 	PkgPath  uniast.PkgPath `json:"package_path" jsonschema:"description=the package path (output of get_repo_structure tool)"`
+	PageReq
 }
 
 type GetPackageStructResp struct {
-	Files []FileStruct `json:"files" jsonschema:"description=the file structures"`
-	Error string       `json:"error,omitempty" jsonschema:"description=the error message"`
+	Files     []FileStruct `json:"files" jsonschema:"description=the file structures paged by files"`
+	InitOrder []NodeID     `json:"init_order,omitempty" jsonschema:"description=the vars and init functions in the order they run on the package initialization (go only)"`
+	PageResp
+	Error string `json:"error,omitempty" jsonschema:"description=the error message"`
 }
 
-func (t *ASTReadTools) getPkgFiles(ctx context.Context, pkg *uniast.Package, repo string) []FileStruct {
-	files := make(map[string]bool, 8)
-	for _, f := range pkg.Functions {
-		files[f.File] = true
-	}
-	for _, f := range pkg.Types {
-		files[f.File] = true
-	}
-	for _, f := range pkg.Vars {
-		files[f.File] = true
-	}
-	ret := make([]FileStruct, 0, len(files))
-	for f := range files {
-		resp, err := t.getFileStructure(ctx, GetFileStructReq{
-			RepoName: repo,
-			FilePath: f,
-		}, false)
-		if err != nil {
+// getPkgFiles groups the nodes of the package by files.
+// If ctx is done, the files grouped so far are returned with the error of ctx
+func (t *ASTReadTools) getPkgFiles(ctx context.Context, repo *uniast.Repository, mod uniast.ModPath, pkg uniast.PkgPath) ([]FileStruct, error) {
+	var ret []FileStruct
+	files := make(map[string]int, 8)
+	for _, n := range repo.GetPackageNodes(mod, pkg) {
+		if err := ctx.Err(); err != nil {
+			return ret, err
+		}
+		file := n.FileLine().File
+		if file == "" {
 			continue
 		}
-		ret = append(ret, resp.FileStruct)
+		i, ok := files[file]
+		if !ok {
+			i = len(ret)
+			files[file] = i
+			ret = append(ret, FileStruct{FilePath: file})
+		}
+		ret[i].Nodes = append(ret[i].Nodes, NodeStruct{
+			ModPath: n.Identity.ModPath,
+			PkgPath: n.Identity.PkgPath,
+			Name:    n.Identity.Name,
+		})
 	}
-	return ret
+	return ret, nil
 }
 
 // GetPackageStruct get package structure
@@ -351,24 +712,32 @@ func (t *ASTReadTools) GetPackageStructure(ctx context.Context, req GetPackageSt
 	}
 
 	resp := new(GetPackageStructResp)
+	addInitOrder := func(pkg *uniast.Package) {
+		for _, id := range pkg.InitOrder {
+			resp.InitOrder = append(resp.InitOrder, NewNodeID(id))
+		}
+	}
+	// stopped tells the files are partial since ctx is done
+	var stopped error
 	if req.ModPath == "" {
 		for _, mod := range repo.Modules {
-			if pkg, ok := mod.Packages[req.PkgPath]; ok {
-				fs := t.getPkgFiles(ctx, pkg, repo.Name)
-				resp.Files = append(resp.Files, fs...)
+			if pkg, ok := mod.Packages[req.PkgPath]; ok && stopped == nil {
+				var files []FileStruct
+				files, stopped = t.getPkgFiles(ctx, repo, mod.Name, req.PkgPath)
+				resp.Files = append(resp.Files, files...)
+				addInitOrder(pkg)
 			}
 		}
 	} else {
-		mod := repo.GetModule(req.ModPath)
-		if mod != nil {
-			if pkg, ok := mod.Packages[req.PkgPath]; ok {
-				fs := t.getPkgFiles(ctx, pkg, repo.Name)
-				resp.Files = append(resp.Files, fs...)
-			}
+		resp.Files, stopped = t.getPkgFiles(ctx, repo, req.ModPath, req.PkgPath)
+		if pkg := repo.GetPackage(req.ModPath, req.PkgPath); pkg != nil {
+			addInitOrder(pkg)
 		}
 	}
 
-	if len(resp.Files) == 0 {
+	if len(resp.Files) == 0 && stopped != nil {
+		resp.Error = fmt.Sprintf("the call was stopped before any file was collected: %v", context.Cause(ctx))
+	} else if len(resp.Files) == 0 {
 		candidates := []string{}
 		if mod, ok := repo.Modules[req.ModPath]; ok {
 			for p := range mod.Packages {
@@ -376,6 +745,14 @@ func (t *ASTReadTools) GetPackageStructure(ctx context.Context, req GetPackageSt
 			}
 		}
 		resp.Error = fmt.Sprintf("package '%s' not found, maybe you want one of %v", req.PkgPath, candidates)
+	} else {
+		sort.Slice(resp.Files, func(i, j int) bool {
+			return resp.Files[i].FilePath < resp.Files[j].FilePath
+		})
+		resp.Files = paginate(resp.Files, req.PageReq, t.opts.MaxBytes, &resp.PageResp)
+		if stopped != nil {
+			markPartial(ctx, &resp.PageResp)
+		}
 	}
 
 	log.Debug("get repo structure, resp: %v", abutil.MarshalJSONIndentNoError(resp))
@@ -395,7 +772,7 @@ type GetFileStructResp struct {
 // GetFileStruct get node list, each node only includes ID\Type\Signature
 func (t *ASTReadTools) GetFileStructure(_ context.Context, req GetFileStructReq) (*GetFileStructResp, error) {
 	log.Debug("get file structure, req: %v", abutil.MarshalJSONIndentNoError(req))
-	resp, err := t.getFileStructure(context.Background(), req, true)
+	resp, err := t.getFileStructure(req)
 	if err != nil {
 		return &GetFileStructResp{
 			Error: err.Error(),
@@ -405,14 +782,14 @@ func (t *ASTReadTools) GetFileStructure(_ context.Context, req GetFileStructReq)
 	return resp, nil
 }
 
-func (t *ASTReadTools) getFileStructure(_ context.Context, req GetFileStructReq, needNodeDetail bool) (*GetFileStructResp, error) {
+func (t *ASTReadTools) getFileStructure(req GetFileStructReq) (*GetFileStructResp, error) {
 	repo, err := t.getRepoAST(req.RepoName)
 	if err != nil {
 		return nil, err
 	}
 
 	resp := new(GetFileStructResp)
-	file, mod := repo.GetFile(req.FilePath)
+	file, _ := repo.GetFile(req.FilePath)
 	if file == nil {
 		return nil, fmt.Errorf("file '%s' not found. Use 'get_repo_structure' to get valid file paths", req.FilePath)
 	}
@@ -420,37 +797,34 @@ func (t *ASTReadTools) getFileStructure(_ context.Context, req GetFileStructReq,
 	nodes := repo.GetFileNodes(req.FilePath)
 	ff := FileStruct{
 		FilePath: req.FilePath,
-	}
-	if needNodeDetail {
-		ff.Imports = file.Imports
+		Imports:  file.Imports,
 	}
 	for _, n := range nodes {
-		nn := NodeStruct{
-			ModPath: mod.Name,
-			PkgPath: file.Package,
-			Name:    n.Identity.Name,
-		}
-		if needNodeDetail {
-			nn.Type = n.Type.String()
-			nn.Signature = n.Signature()
-			nn.Line = n.FileLine().Line
-		}
-		ff.Nodes = append(ff.Nodes,
-			nn,
-		)
+		ff.Nodes = append(ff.Nodes, NodeStruct{
+			ModPath:   n.Identity.ModPath,
+			PkgPath:   n.Identity.PkgPath,
+			Name:      n.Identity.Name,
+			Type:      n.Type.String(),
+			Signature: n.Signature(),
+			Line:      n.FileLine().Line,
+		})
 	}
 	resp.FileStruct = ff
 	return resp, nil
 }
 
 type GetASTNodeReq struct {
-	RepoName string   `json:"repo_name" jsonschema:"description=the name of the repository (output of list_repos tool)"`
-	NodeIDs  []NodeID `json:"node_ids" jsonschema:"description=the identities of the ast node (output of get_package_structure or get_file_structure tool)"`
+	RepoName    string   `json:"repo_name" jsonschema:"description=the name of the repository (output of list_repos tool)"`
+	NodeIDs     []NodeID `json:"node_ids" jsonschema:"description=the identities of the ast node (output of get_package_structure or get_file_structure tool)"`
+	TokenBudget int      `json:"token_budget,omitempty" jsonschema:"description=the max tokens of the codes of all nodes. If set, the codes of large nodes are reduced to outlines or truncated to fit"`
+	InlineDepth int      `json:"inline_depth,omitempty" jsonschema:"description=if set, the codes of the dependencies up to the depth are returned in inlined, saving the calls to get them one by one. Full codes of the direct dependencies (depth 1), signatures of the deeper ones"`
+	PageReq
 }
 
 type GetASTNodeResp struct {
-	Nodes []NodeStruct `json:"nodes" jsonschema:"description=the ast nodes"`
-	Error string       `json:"error,omitempty" jsonschema:"description=the error message"`
+	Nodes []NodeStruct `json:"nodes" jsonschema:"description=the ast nodes paged by nodes"`
+	PageResp
+	Error string `json:"error,omitempty" jsonschema:"description=the error message"`
 }
 
 func (t *ASTReadTools) GetASTNode(_ context.Context, params GetASTNodeReq) (*GetASTNodeResp, error) {
@@ -463,6 +837,22 @@ func (t *ASTReadTools) GetASTNode(_ context.Context, params GetASTNodeReq) (*Get
 		}, nil
 	}
 
+	if params.TokenBudget <= 0 {
+		params.TokenBudget = t.opts.TokenBudget
+	}
+	var packed map[uniast.Identity]packer.Item
+	if params.TokenBudget > 0 {
+		ids := make([]uniast.Identity, 0, len(params.NodeIDs))
+		for _, nid := range params.NodeIDs {
+			ids = append(ids, nid.Identity())
+		}
+		res := packer.Pack(repo, ids, packer.Options{Budget: params.TokenBudget})
+		packed = make(map[uniast.Identity]packer.Item, len(res.Items))
+		for _, it := range res.Items {
+			packed[it.Identity] = it
+		}
+	}
+
 	resp := new(GetASTNodeResp)
 	for _, nid := range params.NodeIDs {
 		id := nid.Identity()
@@ -491,7 +881,7 @@ func (t *ASTReadTools) GetASTNode(_ context.Context, params GetASTNodeReq) (*Get
 		for _, grp := range node.Groups {
 			grps = append(grps, NewNodeID(grp.Identity))
 		}
-		resp.Nodes = append(resp.Nodes, NodeStruct{
+		ns := NodeStruct{
 			ModPath:      node.Identity.ModPath,
 			PkgPath:      node.Identity.PkgPath,
 			Name:         node.Identity.Name,
@@ -504,13 +894,1129 @@ func (t *ASTReadTools) GetASTNode(_ context.Context, params GetASTNodeReq) (*Get
 			Implements:   imps,
 			Inherits:     inhs,
 			Groups:       grps,
-		})
+		}
+		if it, ok := packed[id]; ok {
+			ns.Codes, ns.Packed = it.Text, it.Level.String()
+		}
+		if params.InlineDepth > 0 {
+			ns.Inlined = strings.TrimSuffix(packer.Inline(repo, id, params.InlineDepth).String(), "\n\n")
+		}
+		resp.Nodes = append(resp.Nodes, ns)
 	}
 
 	if len(resp.Nodes) == 0 {
 		resp.Error = "node not found. Use `get_package_structure` to list all valid nodes. If it cannot be confirmed, call `get_file_structure` for detailed node information"
+	} else {
+		maxBytes := t.opts.MaxBytes
+		if params.MaxBytes > 0 {
+			maxBytes = params.MaxBytes
+		}
+		resp.Nodes = paginate(resp.Nodes, params.PageReq, maxBytes, &resp.PageResp)
+		// a single node may still exceed, cut its codes
+		if len(resp.Nodes) == 1 && maxBytes > 0 {
+			truncateCodes(&resp.Nodes[0], maxBytes, &resp.PageResp)
+		}
 	}
 
 	log.Debug("get repo structure, resp: %v", abutil.MarshalJSONIndentNoError(resp))
 	return resp, nil
 }
+
+type GetTestsForNodeReq struct {
+	RepoName string `json:"repo_name" jsonschema:"description=the name of the repository (output of list_repos tool)"`
+	NodeID   NodeID `json:"node_id" jsonschema:"description=the identity of the ast node under test (output of get_package_structure or get_file_structure tool)"`
+}
+
+type TestStruct struct {
+	NodeID
+	File  string   `json:"file,omitempty" jsonschema:"description=the file path of the test"`
+	Line  int      `json:"line,omitempty" jsonschema:"description=the line of the test"`
+	Kinds []string `json:"kinds" jsonschema:"description=how the test is linked to the node: 'name' by the test name, 'call' by calling the node directly or through test helpers"`
+}
+
+type GetTestsForNodeResp struct {
+	Tests []TestStruct `json:"tests" jsonschema:"description=the tests exercising the node"`
+	Error string       `json:"error,omitempty" jsonschema:"description=the error message"`
+}
+
+// GetTestsForNode get the tests linked to the node
+func (t *ASTReadTools) GetTestsForNode(_ context.Context, req GetTestsForNodeReq) (*GetTestsForNodeResp, error) {
+	log.Debug("get tests for node, req: %v", abutil.MarshalJSONIndentNoError(req))
+	repo, err := t.getRepoAST(req.RepoName)
+	if err != nil {
+		return &GetTestsForNodeResp{
+			Error: err.Error(),
+		}, nil
+	}
+
+	id := req.NodeID.Identity()
+	if repo.GetNode(id) == nil {
+		return &GetTestsForNodeResp{
+			Error: "node not found. Use `get_package_structure` to list all valid nodes",
+		}, nil
+	}
+
+	resp := new(GetTestsForNodeResp)
+	for _, link := range repo.TestsOf(id) {
+		ts := TestStruct{
+			NodeID: NewNodeID(link.Test),
+		}
+		if fn := repo.GetFunction(link.Test); fn != nil {
+			ts.File = fn.File
+			ts.Line = fn.Line
+		}
+		for _, k := range link.Kinds {
+			ts.Kinds = append(ts.Kinds, string(k))
+		}
+		resp.Tests = append(resp.Tests, ts)
+	}
+	if len(resp.Tests) == 0 {
+		resp.Error = "no tests found for the node. Tests are only collected when the repo is parsed with tests"
+	}
+
+	log.Debug("get tests for node, resp: %v", abutil.MarshalJSONIndentNoError(resp))
+	return resp, nil
+}
+
+type FindReferencesReq struct {
+	RepoName string `json:"repo_name" jsonschema:"description=the name of the repository (output of list_repos tool)"`
+	NodeID   NodeID `json:"node_id" jsonschema:"description=the identity of the referenced ast node (output of get_package_structure or get_file_structure tool)"`
+}
+
+type ReferenceStruct struct {
+	NodeID
+	File string  `json:"file,omitempty" jsonschema:"description=the file path of the referencing codes"`
+	Line int     `json:"line,omitempty" jsonschema:"description=the line of the referencing codes"`
+	Kind string  `json:"kind" jsonschema:"description=how the node is referenced: 'direct'; 'typedef' through a typedef of the type; 'interface' through an interface method the method implements"`
+	Via  *NodeID `json:"via,omitempty" jsonschema:"description=the typedef or interface method through which the node is referenced"`
+}
+
+type PackageReferences struct {
+	ModPath    string            `json:"mod_path" jsonschema:"description=the module path of the referencing nodes"`
+	PkgPath    string            `json:"pkg_path" jsonschema:"description=the package path of the referencing nodes"`
+	References []ReferenceStruct `json:"references" jsonschema:"description=the references in the package"`
+}
+
+type FindReferencesResp struct {
+	Packages []PackageReferences `json:"packages" jsonschema:"description=the references grouped by package"`
+	Total    int                 `json:"total,omitempty" jsonschema:"description=the number of references in all packages"`
+	Error    string              `json:"error,omitempty" jsonschema:"description=the error message"`
+}
+
+// FindReferences get the nodes referencing the node, grouped by package
+func (t *ASTReadTools) FindReferences(_ context.Context, req FindReferencesReq) (*FindReferencesResp, error) {
+	log.Debug("find references, req: %v", abutil.MarshalJSONIndentNoError(req))
+	repo, err := t.getRepoAST(req.RepoName)
+	if err != nil {
+		return &FindReferencesResp{
+			Error: err.Error(),
+		}, nil
+	}
+	repo.EnsureGraph()
+
+	id := req.NodeID.Identity()
+	if repo.GetNode(id) == nil {
+		return &FindReferencesResp{
+			Error: "node not found. Use `get_package_structure` to list all valid nodes",
+		}, nil
+	}
+
+	resp := new(FindReferencesResp)
+	// package => index in resp.Packages
+	pkgs := map[uniast.Identity]int{}
+	for _, ref := range repo.ReferencesOf(id) {
+		rs := ReferenceStruct{
+			NodeID: NewNodeID(ref.Node),
+			File:   ref.File,
+			Line:   ref.Line,
+			Kind:   string(ref.Kind),
+		}
+		if ref.Via != nil {
+			via := NewNodeID(*ref.Via)
+			rs.Via = &via
+		}
+		pkg := uniast.NewIdentity(ref.Node.ModPath, ref.Node.PkgPath, "")
+		i, ok := pkgs[pkg]
+		if !ok {
+			i = len(resp.Packages)
+			pkgs[pkg] = i
+			resp.Packages = append(resp.Packages, PackageReferences{
+				ModPath: ref.Node.ModPath,
+				PkgPath: ref.Node.PkgPath,
+			})
+		}
+		resp.Packages[i].References = append(resp.Packages[i].References, rs)
+		resp.Total++
+	}
+	sort.Slice(resp.Packages, func(i, j int) bool {
+		a, b := resp.Packages[i], resp.Packages[j]
+		if a.ModPath != b.ModPath {
+			return a.ModPath < b.ModPath
+		}
+		return a.PkgPath < b.PkgPath
+	})
+	if resp.Total == 0 {
+		resp.Error = "no references found for the node"
+	}
+
+	log.Debug("find references, resp: %v", abutil.MarshalJSONIndentNoError(resp))
+	return resp, nil
+}
+
+type FindSymbolAcrossReposReq struct {
+	Name      string   `json:"name" jsonschema:"description=the name of the node, like 'Client', or 'Client.Call' and 'Call' for methods"`
+	PkgPath   string   `json:"pkg_path,omitempty" jsonschema:"description=the package path of the node, to tell apart the symbols of the same name"`
+	RepoNames []string `json:"repo_names,omitempty" jsonschema:"description=the repositories to search (output of list_repos tool), the default ones if empty"`
+	PageReq
+}
+
+type RepoNodeID struct {
+	RepoName string `json:"repo_name" jsonschema:"description=the repository where the node is found"`
+	NodeID
+	File string `json:"file,omitempty" jsonschema:"description=the file path of the node"`
+	Line int    `json:"line,omitempty" jsonschema:"description=the line of the node"`
+}
+
+type SymbolStruct struct {
+	RepoNodeID
+	Type       string       `json:"type" jsonschema:"description=the type of the node"`
+	References []RepoNodeID `json:"references,omitempty" jsonschema:"description=the nodes referencing the symbol in all searched repositories"`
+}
+
+type FindSymbolAcrossReposResp struct {
+	Symbols    []SymbolStruct `json:"symbols" jsonschema:"description=the definitions of the symbol"`
+	Unsearched []string       `json:"unsearched_repos,omitempty" jsonschema:"description=the repositories not searched since the call timed out"`
+	PageResp
+	Error string `json:"error,omitempty" jsonschema:"description=the error message"`
+}
+
+// symbolKey identifies a node among repositories, ignoring the module version,
+// since a downstream repo refers to the upstream module with the version it depends on
+type symbolKey struct {
+	mod, pkg, name string
+}
+
+func newSymbolKey(id uniast.Identity) symbolKey {
+	return symbolKey{mod: uniast.ModPathName(id.ModPath), pkg: id.PkgPath, name: id.Name}
+}
+
+func matchSymbolName(name, query string) bool {
+	return name == query || strings.HasSuffix(name, "."+query)
+}
+
+// FindSymbolAcrossRepos finds the definitions of the symbol in the repositories,
+// and the nodes referencing them in any of the repositories
+func (t *ASTReadTools) FindSymbolAcrossRepos(ctx context.Context, req FindSymbolAcrossReposReq) (*FindSymbolAcrossReposResp, error) {
+	log.Debug("find symbol across repos, req: %v", abutil.MarshalJSONIndentNoError(req))
+	if req.Name == "" {
+		return &FindSymbolAcrossReposResp{Error: "name is required"}, nil
+	}
+	names := req.RepoNames
+	if len(names) == 0 {
+		names = t.opts.Repos
+	}
+	if len(names) == 0 {
+		names = t.repos.Names()
+	}
+	// loading the repos takes the most time, the loaded ones are still searched if ctx is done
+	resp := new(FindSymbolAcrossReposResp)
+	repos := make([]*uniast.Repository, 0, len(names))
+	for i, name := range names {
+		if ctx.Err() != nil {
+			resp.Unsearched = names[i:]
+			break
+		}
+		repo, err := t.getRepoAST(name)
+		if err != nil {
+			return &FindSymbolAcrossReposResp{Error: err.Error()}, nil
+		}
+		repo.EnsureGraph()
+		repos = append(repos, repo)
+	}
+
+	defs := map[symbolKey]int{}
+	for _, repo := range repos {
+		for _, node := range repo.Graph {
+			if node.Type == uniast.UNKNOWN || !matchSymbolName(node.Identity.Name, req.Name) ||
+				(req.PkgPath != "" && node.Identity.PkgPath != req.PkgPath) {
+				continue
+			}
+			if mod := repo.GetModule(node.Identity.ModPath); mod == nil || mod.IsExternal() {
+				continue
+			}
+			key := newSymbolKey(node.Identity)
+			if _, ok := defs[key]; ok {
+				continue
+			}
+			defs[key] = len(resp.Symbols)
+			resp.Symbols = append(resp.Symbols, SymbolStruct{
+				RepoNodeID: newRepoNodeID(repo, node.Identity),
+				Type:       node.Type.String(),
+			})
+		}
+	}
+	if len(resp.Symbols) == 0 {
+		resp.Error = "symbol not found in the repositories. Check the name by `get_package_structure` or `get_file_structure`"
+		if len(resp.Unsearched) > 0 {
+			markPartial(ctx, &resp.PageResp)
+		}
+		return resp, nil
+	}
+
+	// the references in downstream repos are found by their nodes of the upstream symbol
+	for _, repo := range repos {
+		for _, node := range repo.Graph {
+			i, ok := defs[newSymbolKey(node.Identity)]
+			if !ok {
+				continue
+			}
+			for _, ref := range node.References {
+				resp.Symbols[i].References = append(resp.Symbols[i].References, newRepoNodeID(repo, ref.Identity))
+			}
+		}
+	}
+	sort.Slice(resp.Symbols, func(i, j int) bool {
+		a, b := resp.Symbols[i], resp.Symbols[j]
+		if a.RepoName != b.RepoName {
+			return a.RepoName < b.RepoName
+		}
+		return a.NodeID.Identity().Full() < b.NodeID.Identity().Full()
+	})
+	resp.Symbols = paginate(resp.Symbols, req.PageReq, t.opts.MaxBytes, &resp.PageResp)
+	if len(resp.Unsearched) > 0 {
+		markPartial(ctx, &resp.PageResp)
+	}
+
+	log.Debug("find symbol across repos, resp: %v", abutil.MarshalJSONIndentNoError(resp))
+	return resp, nil
+}
+
+func newRepoNodeID(repo *uniast.Repository, id uniast.Identity) RepoNodeID {
+	ret := RepoNodeID{RepoName: repo.Name, NodeID: NewNodeID(id)}
+	if node := repo.GetNode(id); node != nil {
+		fl := node.FileLine()
+		ret.File, ret.Line = fl.File, fl.Line
+	}
+	return ret
+}
+
+// truncateCodes cuts the codes of the node to fit in max bytes, and marks it as truncated.
+// The inlined dependencies are cut first
+func truncateCodes(n *NodeStruct, maxBytes int, page *PageResp) {
+	if n.Inlined != "" && jsonSize(n) > maxBytes {
+		cutCodes(n, &n.Inlined, maxBytes)
+		page.Truncated = fmt.Sprintf("the inlined dependencies of %s are truncated to fit in %d bytes", n.Name, maxBytes)
+	}
+	if jsonSize(n) <= maxBytes {
+		return
+	}
+	cutCodes(n, &n.Codes, maxBytes)
+	n.Packed = packer.LevelTruncated.String()
+	page.Truncated = fmt.Sprintf("the codes of %s are truncated to fit in %d bytes", n.Name, maxBytes)
+}
+
+// cutCodes cuts the codes, which is a field of the node, until the node fits in max bytes or the codes are empty
+func cutCodes(n *NodeStruct, field *string, maxBytes int) {
+	codes := *field
+	over := jsonSize(n) - maxBytes
+	// the escaped codes and the marker take more bytes, so cut again until fit
+	for keep := len(codes) - over; ; keep -= over {
+		if keep < 0 {
+			keep = 0
+		}
+		// do not split a rune
+		for keep > 0 && !utf8.RuneStart(codes[keep]) {
+			keep--
+		}
+		*field = codes[:keep] + fmt.Sprintf("\n... (%d bytes truncated)", len(codes)-keep)
+		if over = jsonSize(n) - maxBytes; over <= 0 || keep == 0 {
+			break
+		}
+	}
+}
+
+// defaultMetricsPageSize is the page size of the ranked functions of get_node_metrics
+const defaultMetricsPageSize = 20
+
+type GetNodeMetricsReq struct {
+	RepoName string         `json:"repo_name" jsonschema:"description=the name of the repository (output of list_repos tool)"`
+	NodeIDs  []NodeID       `json:"node_ids,omitempty" jsonschema:"description=the functions to measure (output of get_package_structure or get_file_structure tool). All functions are ranked if empty"`
+	PkgPath  uniast.PkgPath `json:"pkg_path,omitempty" jsonschema:"description=only rank the functions of the package, if node_ids is empty"`
+	SortBy   string         `json:"sort_by,omitempty" jsonschema:"description=the metric to rank the functions by in descending order: complexity (default), loc, fan_in or fan_out,enum=complexity,enum=loc,enum=fan_in,enum=fan_out,default=complexity"`
+	PageReq
+}
+
+type NodeMetrics struct {
+	NodeID
+	File       string `json:"file,omitempty" jsonschema:"description=the file path of the function"`
+	Line       int    `json:"line,omitempty" jsonschema:"description=the line of the function"`
+	LOC        int    `json:"loc" jsonschema:"description=lines of code"`
+	Complexity int    `json:"complexity" jsonschema:"description=rough cyclomatic complexity, 1 plus the branches"`
+	FanIn      int    `json:"fan_in" jsonschema:"description=the number of distinct functions calling it"`
+	FanOut     int    `json:"fan_out" jsonschema:"description=the number of distinct functions and methods it calls"`
+}
+
+type GetNodeMetricsResp struct {
+	Nodes []NodeMetrics `json:"nodes" jsonschema:"description=the functions with metrics"`
+	PageResp
+	Error string `json:"error,omitempty" jsonschema:"description=the error message"`
+}
+
+func newNodeMetrics(fn *uniast.Function) NodeMetrics {
+	m := fn.Metrics
+	return NodeMetrics{
+		NodeID:     NewNodeID(fn.Identity),
+		File:       fn.File,
+		Line:       fn.Line,
+		LOC:        m.LOC,
+		Complexity: m.Complexity,
+		FanIn:      m.FanIn,
+		FanOut:     m.FanOut,
+	}
+}
+
+// GetNodeMetrics get the metrics of the functions, or rank the functions of the repo by a metric
+func (t *ASTReadTools) GetNodeMetrics(_ context.Context, req GetNodeMetricsReq) (*GetNodeMetricsResp, error) {
+	log.Debug("get node metrics, req: %v", abutil.MarshalJSONIndentNoError(req))
+	repo, err := t.getRepoAST(req.RepoName)
+	if err != nil {
+		return &GetNodeMetricsResp{
+			Error: err.Error(),
+		}, nil
+	}
+	var less func(a, b NodeMetrics) bool
+	switch req.SortBy {
+	case "", "complexity":
+		less = func(a, b NodeMetrics) bool { return a.Complexity > b.Complexity }
+	case "loc":
+		less = func(a, b NodeMetrics) bool { return a.LOC > b.LOC }
+	case "fan_in":
+		less = func(a, b NodeMetrics) bool { return a.FanIn > b.FanIn }
+	case "fan_out":
+		less = func(a, b NodeMetrics) bool { return a.FanOut > b.FanOut }
+	default:
+		return &GetNodeMetricsResp{
+			Error: "invalid sort_by, must be one of complexity, loc, fan_in and fan_out",
+		}, nil
+	}
+	// ASTs parsed by older versions have no metrics
+	repo.EnsureGraph()
+	if !hasMetrics(repo) {
+		repo.ComputeMetrics()
+	}
+
+	resp := new(GetNodeMetricsResp)
+	if len(req.NodeIDs) > 0 {
+		var missing []string
+		for _, id := range req.NodeIDs {
+			fn := repo.GetFunction(id.Identity())
+			if fn == nil || fn.Metrics == nil {
+				missing = append(missing, id.Identity().Full())
+				continue
+			}
+			resp.Nodes = append(resp.Nodes, newNodeMetrics(fn))
+		}
+		if len(missing) > 0 {
+			resp.Error = "functions not found: " + strings.Join(missing, ", ") + ". Metrics are only measured for functions and methods"
+		}
+		resp.Nodes = paginate(resp.Nodes, req.PageReq, t.opts.MaxBytes, &resp.PageResp)
+		log.Debug("get node metrics, resp: %v", abutil.MarshalJSONIndentNoError(resp))
+		return resp, nil
+	}
+
+	for _, mod := range repo.InternalModules() {
+		for path, pkg := range mod.Packages {
+			if req.PkgPath != "" && path != req.PkgPath {
+				continue
+			}
+			for _, fn := range pkg.Functions {
+				if fn.Metrics != nil {
+					resp.Nodes = append(resp.Nodes, newNodeMetrics(fn))
+				}
+			}
+		}
+	}
+	sort.Slice(resp.Nodes, func(i, j int) bool {
+		a, b := resp.Nodes[i], resp.Nodes[j]
+		if less(a, b) || less(b, a) {
+			return less(a, b)
+		}
+		return a.Identity().Full() < b.Identity().Full()
+	})
+	page := req.PageReq
+	if page.PageSize <= 0 {
+		page.PageSize = defaultMetricsPageSize
+	}
+	resp.Nodes = paginate(resp.Nodes, page, t.opts.MaxBytes, &resp.PageResp)
+	if resp.Total == 0 {
+		resp.Error = "no functions found"
+	}
+
+	log.Debug("get node metrics, resp: %v", abutil.MarshalJSONIndentNoError(resp))
+	return resp, nil
+}
+
+// hasMetrics tells if the metrics of the repo are computed
+func hasMetrics(repo *uniast.Repository) bool {
+	for _, mod := range repo.InternalModules() {
+		for _, pkg := range mod.Packages {
+			for _, fn := range pkg.Functions {
+				return fn.Metrics != nil
+			}
+		}
+	}
+	return true
+}
+
+type ChunkRepoReq struct {
+	RepoName  string         `json:"repo_name" jsonschema:"description=the name of the repository (output of list_repos tool)"`
+	PkgPath   uniast.PkgPath `json:"pkg_path,omitempty" jsonschema:"description=only chunk the package if given"`
+	MaxTokens int            `json:"max_tokens,omitempty" jsonschema:"description=the max estimated tokens of a chunk. Default to 512"`
+	PageReq
+}
+
+type ChunkStruct struct {
+	ID      string   `json:"id" jsonschema:"description=the stable id of the chunk"`
+	ModPath string   `json:"mod_path" jsonschema:"description=the module of the chunk"`
+	PkgPath string   `json:"pkg_path" jsonschema:"description=the package of the chunk"`
+	NodeIDs []NodeID `json:"node_ids" jsonschema:"description=the nodes in the chunk"`
+	Part    int      `json:"part,omitempty" jsonschema:"description=the 1-based part of a node split into several chunks, absent if the chunk holds whole nodes"`
+	Tokens  int      `json:"tokens" jsonschema:"description=the estimated tokens of the text"`
+	Text    string   `json:"text" jsonschema:"description=the codes of the nodes, each led by a header line of the identity and the location"`
+}
+
+type ChunkRepoResp struct {
+	Chunks []ChunkStruct `json:"chunks" jsonschema:"description=the chunks of the repository"`
+	PageResp
+	Error string `json:"error,omitempty" jsonschema:"description=the error message"`
+}
+
+// ChunkRepo splits the codes of the repo into chunks for embedding, see packer.ChunkRepo
+func (t *ASTReadTools) ChunkRepo(_ context.Context, req ChunkRepoReq) (*ChunkRepoResp, error) {
+	log.Debug("chunk repo, req: %v", abutil.MarshalJSONIndentNoError(req))
+	repo, err := t.getRepoAST(req.RepoName)
+	if err != nil {
+		return &ChunkRepoResp{
+			Error: err.Error(),
+		}, nil
+	}
+	chunks := packer.ChunkRepo(repo, packer.ChunkOptions{MaxTokens: req.MaxTokens, PkgPath: req.PkgPath})
+	resp := &ChunkRepoResp{Chunks: make([]ChunkStruct, 0, len(chunks))}
+	for _, c := range chunks {
+		cs := ChunkStruct{
+			ID:      c.ID,
+			ModPath: c.ModPath,
+			PkgPath: c.PkgPath,
+			Part:    c.Part,
+			Tokens:  c.Tokens,
+			Text:    c.Text,
+		}
+		for _, id := range c.Nodes {
+			cs.NodeIDs = append(cs.NodeIDs, NewNodeID(id))
+		}
+		resp.Chunks = append(resp.Chunks, cs)
+	}
+	resp.Chunks = paginate(resp.Chunks, req.PageReq, t.opts.MaxBytes, &resp.PageResp)
+	if resp.Total == 0 {
+		resp.Error = "no chunks found"
+		if req.PkgPath != "" {
+			resp.Error = "package not found or empty: " + req.PkgPath
+		}
+	}
+	log.Debug("chunk repo, resp: %d chunks", len(resp.Chunks))
+	return resp, nil
+}
+
+type GetNodeOwnersReq struct {
+	RepoName string   `json:"repo_name" jsonschema:"description=the name of the repository (output of list_repos tool)"`
+	NodeIDs  []NodeID `json:"node_ids" jsonschema:"description=the nodes changed or to review (output of get_package_structure, get_file_structure or find_references tool)"`
+}
+
+type OwnerStruct struct {
+	Name  string `json:"name" jsonschema:"description=the name of the author"`
+	Email string `json:"email,omitempty" jsonschema:"description=the email of the author"`
+	Lines int    `json:"lines" jsonschema:"description=the count of the lines last modified by the author"`
+}
+
+type NodeOwners struct {
+	NodeID
+	File         string        `json:"file,omitempty" jsonschema:"description=the file path of the node"`
+	Line         int           `json:"line,omitempty" jsonschema:"description=the line of the node"`
+	Authors      []OwnerStruct `json:"authors" jsonschema:"description=the primary authors of the node, most lines first"`
+	LastModified string        `json:"last_modified,omitempty" jsonschema:"description=the latest author time of the lines of the node, in RFC 3339"`
+}
+
+type GetNodeOwnersResp struct {
+	Nodes     []NodeOwners  `json:"nodes" jsonschema:"description=the nodes with their owners"`
+	Reviewers []OwnerStruct `json:"reviewers,omitempty" jsonschema:"description=the authors of all the nodes ranked by the lines they wrote, as the suggested reviewers"`
+	Error     string        `json:"error,omitempty" jsonschema:"description=the error message"`
+}
+
+// GetNodeOwners get the owners of the nodes recorded by `parse --blame`, and ranks the reviewers of them
+func (t *ASTReadTools) GetNodeOwners(_ context.Context, req GetNodeOwnersReq) (*GetNodeOwnersResp, error) {
+	log.Debug("get node owners, req: %v", abutil.MarshalJSONIndentNoError(req))
+	repo, err := t.getRepoAST(req.RepoName)
+	if err != nil {
+		return &GetNodeOwnersResp{
+			Error: err.Error(),
+		}, nil
+	}
+	resp := new(GetNodeOwnersResp)
+	reviewers := map[string]*OwnerStruct{}
+	var missing, unowned []string
+	for _, id := range req.NodeIDs {
+		owners, fl, ok := nodeOwnership(repo, id.Identity())
+		if !ok {
+			missing = append(missing, id.Identity().Full())
+			continue
+		}
+		if owners == nil {
+			unowned = append(unowned, id.Identity().Full())
+			continue
+		}
+		n := NodeOwners{NodeID: id, File: fl.File, Line: fl.Line, LastModified: owners.LastModified.Format(time.RFC3339)}
+		for _, o := range owners.Authors {
+			n.Authors = append(n.Authors, OwnerStruct{Name: o.Name, Email: o.Email, Lines: o.Lines})
+			key := o.Email
+			if key == "" {
+				key = o.Name
+			}
+			if r := reviewers[key]; r != nil {
+				r.Lines += o.Lines
+			} else {
+				reviewers[key] = &OwnerStruct{Name: o.Name, Email: o.Email, Lines: o.Lines}
+			}
+		}
+		resp.Nodes = append(resp.Nodes, n)
+	}
+	for _, r := range reviewers {
+		resp.Reviewers = append(resp.Reviewers, *r)
+	}
+	sort.Slice(resp.Reviewers, func(i, j int) bool {
+		a, b := resp.Reviewers[i], resp.Reviewers[j]
+		if a.Lines != b.Lines {
+			return a.Lines > b.Lines
+		}
+		return a.Name < b.Name
+	})
+
+	var errs []string
+	if len(missing) > 0 {
+		errs = append(errs, "nodes not found: "+strings.Join(missing, ", "))
+	}
+	if len(unowned) > 0 {
+		errs = append(errs, "no owners recorded for: "+strings.Join(unowned, ", ")+". The repository must be parsed with --blame, and uncommitted codes have no owners")
+	}
+	resp.Error = strings.Join(errs, "; ")
+	log.Debug("get node owners, resp: %v", abutil.MarshalJSONIndentNoError(resp))
+	return resp, nil
+}
+
+// nodeOwnership returns the ownership and the location of the node, false if the node is not found
+func nodeOwnership(repo *uniast.Repository, id uniast.Identity) (*uniast.Ownership, uniast.FileLine, bool) {
+	if fn := repo.GetFunction(id); fn != nil {
+		return fn.Owners, fn.FileLine, true
+	}
+	if typ := repo.GetType(id); typ != nil {
+		return typ.Owners, typ.FileLine, true
+	}
+	if v := repo.GetVar(id); v != nil {
+		return v.Owners, v.FileLine, true
+	}
+	return nil, uniast.FileLine{}, false
+}
+
+type FindUnreachableNodesReq struct {
+	RepoName    string         `json:"repo_name" jsonschema:"description=the name of the repository (output of list_repos tool)"`
+	Entrypoints []NodeID       `json:"entrypoints,omitempty" jsonschema:"description=the roots of the reachability. Default to the main functions and the tests"`
+	IncludeAPI  bool           `json:"include_api,omitempty" jsonschema:"description=take the exported nodes as the entrypoints too, since they are the public API of a library"`
+	PkgPath     uniast.PkgPath `json:"pkg_path,omitempty" jsonschema:"description=only return the unreachable nodes of the package"`
+	PageReq
+}
+
+type UnreachableNode struct {
+	NodeID
+	Type string `json:"type" jsonschema:"description=the type of the node: FUNC, TYPE or VAR"`
+	File string `json:"file,omitempty" jsonschema:"description=the file path of the node"`
+	Line int    `json:"line,omitempty" jsonschema:"description=the line of the node"`
+}
+
+type FindUnreachableNodesResp struct {
+	Nodes []UnreachableNode `json:"nodes" jsonschema:"description=the nodes never reached from the entrypoints"`
+	PageResp
+	Error string `json:"error,omitempty" jsonschema:"description=the error message"`
+}
+
+// FindUnreachableNodes finds the dead nodes of the repo, see uniast.Repository.UnreachableNodes
+func (t *ASTReadTools) FindUnreachableNodes(_ context.Context, req FindUnreachableNodesReq) (*FindUnreachableNodesResp, error) {
+	log.Debug("find unreachable nodes, req: %v", abutil.MarshalJSONIndentNoError(req))
+	repo, err := t.getRepoAST(req.RepoName)
+	if err != nil {
+		return &FindUnreachableNodesResp{
+			Error: err.Error(),
+		}, nil
+	}
+	var roots []uniast.Identity
+	if len(req.Entrypoints) > 0 {
+		for _, id := range req.Entrypoints {
+			roots = append(roots, id.Identity())
+		}
+		if req.IncludeAPI {
+			roots = append(roots, repo.Entrypoints(true)...)
+		}
+	} else {
+		roots = repo.Entrypoints(req.IncludeAPI)
+	}
+
+	resp := new(FindUnreachableNodesResp)
+	for _, id := range repo.UnreachableNodes(roots) {
+		if req.PkgPath != "" && id.PkgPath != req.PkgPath {
+			continue
+		}
+		n := UnreachableNode{NodeID: NewNodeID(id)}
+		if node := repo.GetNode(id); node != nil {
+			fl := node.FileLine()
+			n.Type, n.File, n.Line = node.Type.String(), fl.File, fl.Line
+		}
+		resp.Nodes = append(resp.Nodes, n)
+	}
+	resp.Nodes = paginate(resp.Nodes, req.PageReq, t.opts.MaxBytes, &resp.PageResp)
+	log.Debug("find unreachable nodes, resp: %d nodes", len(resp.Nodes))
+	return resp, nil
+}
+
+type GetDependenciesReq struct {
+	RepoName string `json:"repo_name" jsonschema:"description=the name of the repository (output of list_repos tool)"`
+	Name     string `json:"name,omitempty" jsonschema:"description=only return the dependencies whose names contain it, case-insensitive"`
+	PageReq
+}
+
+type DependencyStruct struct {
+	Name       string   `json:"name" jsonschema:"description=the module path or package name of the dependency"`
+	Version    string   `json:"version,omitempty" jsonschema:"description=the resolved version"`
+	Language   string   `json:"language,omitempty" jsonschema:"description=the language of the dependency"`
+	License    string   `json:"license,omitempty" jsonschema:"description=the SPDX identifiers of the licenses, joined by OR. Empty if not detected"`
+	ModPath    string   `json:"mod_path,omitempty" jsonschema:"description=the module holding the loaded symbols of the dependency, as the mod_path of node_ids"`
+	Dependents []string `json:"dependents,omitempty" jsonschema:"description=the internal modules depending on it"`
+}
+
+type GetDependenciesResp struct {
+	Dependencies []DependencyStruct `json:"dependencies" jsonschema:"description=the third-party dependencies ordered by names"`
+	PageResp
+	Error string `json:"error,omitempty" jsonschema:"description=the error message"`
+}
+
+// GetDependencies gets the third-party dependencies of the repo, see uniast.Repository.ExternalDependencies
+func (t *ASTReadTools) GetDependencies(_ context.Context, req GetDependenciesReq) (*GetDependenciesResp, error) {
+	log.Debug("get dependencies, req: %v", abutil.MarshalJSONIndentNoError(req))
+	repo, err := t.getRepoAST(req.RepoName)
+	if err != nil {
+		return &GetDependenciesResp{
+			Error: err.Error(),
+		}, nil
+	}
+	deps := repo.Dependencies
+	if len(deps) == 0 {
+		// parsed by an older version
+		deps = repo.ExternalDependencies()
+	}
+	name := strings.ToLower(req.Name)
+	resp := new(GetDependenciesResp)
+	for _, d := range deps {
+		if name != "" && !strings.Contains(strings.ToLower(d.Name), name) {
+			continue
+		}
+		resp.Dependencies = append(resp.Dependencies, DependencyStruct{
+			Name:       d.Name,
+			Version:    d.Version,
+			Language:   string(d.Language),
+			License:    d.License,
+			ModPath:    d.Module,
+			Dependents: d.Dependents,
+		})
+	}
+	resp.Dependencies = paginate(resp.Dependencies, req.PageReq, t.opts.MaxBytes, &resp.PageResp)
+	if resp.Total == 0 && req.Name != "" {
+		resp.Error = "no dependencies matched: " + req.Name
+	}
+	log.Debug("get dependencies, resp: %d dependencies", len(resp.Dependencies))
+	return resp, nil
+}
+
+type LocateNodeByPositionReq struct {
+	RepoName   string `json:"repo_name" jsonschema:"description=the name of the repository (output of list_repos tool)"`
+	Position   string `json:"position,omitempty" jsonschema:"description=the source position as file:line or file:line:col, file is relative to the repository or absolute"`
+	StackTrace string `json:"stack_trace,omitempty" jsonschema:"description=a pasted stack trace or compiler output, every file:line[:col] in it is located"`
+	PageReq
+}
+
+type PositionNode struct {
+	Position string     `json:"position" jsonschema:"description=the position as file:line[:col] found in the input"`
+	Node     NodeStruct `json:"node" jsonschema:"description=the innermost node containing the position, with codes"`
+}
+
+type LocateNodeByPositionResp struct {
+	Nodes []PositionNode `json:"nodes" jsonschema:"description=the located nodes in the order of the positions, each node is returned once for its first position"`
+	// Unresolved counts the positions outside the repository, like the frames of the runtime or the dependencies
+	Unresolved int `json:"unresolved,omitempty" jsonschema:"description=the number of positions not in any node of the repository"`
+	PageResp
+	Error string `json:"error,omitempty" jsonschema:"description=the error message"`
+}
+
+// positionRegexp matches file:line[:col] in stack traces and compiler outputs, like
+// `/src/a/b.go:12 +0x1d` of Go panics, `src/main.rs:10:5` of Rust panics, or `a/b.go:12:5: undefined: x`
+var positionRegexp = regexp.MustCompile(`([^\s:()"'\[\]]+\.[A-Za-z0-9]+):(\d+)(?::(\d+))?`)
+
+// LocateNodeByPosition locates the nodes at the positions in req, see uniast.Repository.NodeAt
+func (t *ASTReadTools) LocateNodeByPosition(_ context.Context, req LocateNodeByPositionReq) (*LocateNodeByPositionResp, error) {
+	log.Debug("locate node by position, req: %v", abutil.MarshalJSONIndentNoError(req))
+	repo, err := t.getRepoAST(req.RepoName)
+	if err != nil {
+		return &LocateNodeByPositionResp{
+			Error: err.Error(),
+		}, nil
+	}
+	matches := positionRegexp.FindAllStringSubmatch(req.Position+"\n"+req.StackTrace, -1)
+	if len(matches) == 0 {
+		return &LocateNodeByPositionResp{
+			Error: "no file:line found in position or stack_trace",
+		}, nil
+	}
+
+	resp := new(LocateNodeByPositionResp)
+	seen := map[uniast.Identity]bool{}
+	for _, m := range matches {
+		line, _ := strconv.Atoi(m[2])
+		col, _ := strconv.Atoi(m[3])
+		node := repo.NodeAt(m[1], line, col)
+		if node == nil {
+			resp.Unresolved++
+			continue
+		}
+		if seen[node.Identity] {
+			continue
+		}
+		seen[node.Identity] = true
+		fl := node.FileLine()
+		resp.Nodes = append(resp.Nodes, PositionNode{
+			Position: m[0],
+			Node: NodeStruct{
+				ModPath:   node.Identity.ModPath,
+				PkgPath:   node.Identity.PkgPath,
+				Name:      node.Identity.Name,
+				Type:      node.Type.String(),
+				Signature: node.Signature(),
+				File:      fl.File,
+				Line:      fl.Line,
+				Codes:     node.Content(),
+			},
+		})
+	}
+	if len(resp.Nodes) == 0 {
+		resp.Error = "no node found at the positions. The files may be outside the repository, use `get_repo_structure` to list the files"
+	} else {
+		maxBytes := t.opts.MaxBytes
+		if req.MaxBytes > 0 {
+			maxBytes = req.MaxBytes
+		}
+		resp.Nodes = paginate(resp.Nodes, req.PageReq, maxBytes, &resp.PageResp)
+		if len(resp.Nodes) == 1 && maxBytes > 0 {
+			truncateCodes(&resp.Nodes[0].Node, maxBytes, &resp.PageResp)
+		}
+	}
+	log.Debug("locate node by position, resp: %d nodes", len(resp.Nodes))
+	return resp, nil
+}
+
+type GetPublicAPIReq struct {
+	RepoName string         `json:"repo_name" jsonschema:"description=the name of the repository (output of list_repos tool)"`
+	ModPath  uniast.ModPath `json:"mod_path,omitempty" jsonschema:"description=the internal module of the API. Default to all internal modules"`
+	PkgPath  uniast.PkgPath `json:"pkg_path,omitempty" jsonschema:"description=only return the API of the package"`
+	PageReq
+}
+
+type APIEntry struct {
+	NodeID
+	Type      string `json:"type" jsonschema:"description=the type of the node: FUNC, TYPE or VAR"`
+	Signature string `json:"signature" jsonschema:"description=the signature of the function, or the declaration of the type or var without the body"`
+}
+
+type GetPublicAPIResp struct {
+	Entries []APIEntry `json:"entries" jsonschema:"description=the exported nodes ordered by node_ids"`
+	PageResp
+	Error string `json:"error,omitempty" jsonschema:"description=the error message"`
+}
+
+// GetPublicAPI gets the API surface of the modules, see uniast.Repository.PublicAPI
+func (t *ASTReadTools) GetPublicAPI(_ context.Context, req GetPublicAPIReq) (*GetPublicAPIResp, error) {
+	log.Debug("get public api, req: %v", abutil.MarshalJSONIndentNoError(req))
+	repo, err := t.getRepoAST(req.RepoName)
+	if err != nil {
+		return &GetPublicAPIResp{
+			Error: err.Error(),
+		}, nil
+	}
+	mods := []uniast.ModPath{req.ModPath}
+	if req.ModPath == "" {
+		mods = mods[:0]
+		for _, m := range repo.InternalModules() {
+			mods = append(mods, m.Name)
+		}
+		sort.Strings(mods)
+	}
+
+	resp := new(GetPublicAPIResp)
+	for _, mod := range mods {
+		api, err := repo.PublicAPI(mod)
+		if err != nil {
+			return &GetPublicAPIResp{
+				Error: err.Error() + ". Use `get_repo_structure` to list the modules",
+			}, nil
+		}
+		for _, e := range api {
+			if req.PkgPath != "" && e.PkgPath != req.PkgPath {
+				continue
+			}
+			resp.Entries = append(resp.Entries, APIEntry{NodeID: NewNodeID(e.Identity), Type: e.Type.String(), Signature: e.Signature})
+		}
+	}
+	resp.Entries = paginate(resp.Entries, req.PageReq, t.opts.MaxBytes, &resp.PageResp)
+	log.Debug("get public api, resp: %d entries", len(resp.Entries))
+	return resp, nil
+}
+
+type GetDiagramReq struct {
+	RepoName    string            `json:"repo_name" jsonschema:"description=the name of the repository (output of list_repos tool)"`
+	Kind        string            `json:"kind" jsonschema:"description=the kind of the diagram: component or sequence,enum=component,enum=sequence"`
+	Entry       *NodeID           `json:"entry,omitempty" jsonschema:"description=the entry function of the sequence diagram,nullable"`
+	Depth       int               `json:"depth,omitempty" jsonschema:"description=the max nesting of the calls in the sequence diagram. Default to 4,default=4"`
+	PkgPath     uniast.PkgPath    `json:"pkg_path,omitempty" jsonschema:"description=only draw the packages under the path in the component diagram"`
+	Granularity string            `json:"granularity,omitempty" jsonschema:"description=the vertices of the component diagram: package (default) or node,enum=package,enum=node,default=package"`
+	External    bool              `json:"external,omitempty" jsonschema:"description=also draw the external packages or calls"`
+	Labels      map[string]string `json:"labels,omitempty" jsonschema:"description=the readable labels of the packages keyed by mod_path?pkg_path, or of the nodes and calls keyed by mod_path?pkg_path#name"`
+}
+
+type GetDiagramResp struct {
+	Mermaid string `json:"mermaid,omitempty" jsonschema:"description=the mermaid fenced block of the diagram"`
+	Error   string `json:"error,omitempty" jsonschema:"description=the error message"`
+}
+
+// defaultDiagramDepth is the default max nesting of the calls in the sequence diagrams
+const defaultDiagramDepth = 4
+
+// GetDiagram draws the component diagram by uniast.Repository.ExportGraph, or the sequence diagram by uniast.Repository.CallSequence
+func (t *ASTReadTools) GetDiagram(_ context.Context, req GetDiagramReq) (*GetDiagramResp, error) {
+	log.Debug("get diagram, req: %v", abutil.MarshalJSONIndentNoError(req))
+	repo, err := t.getRepoAST(req.RepoName)
+	if err != nil {
+		return &GetDiagramResp{
+			Error: err.Error(),
+		}, nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString("```mermaid\n")
+	switch req.Kind {
+	case "component":
+		opts := uniast.ExportOptions{Granularity: uniast.GranularityPackage, External: req.External, PkgPrefix: req.PkgPath}
+		switch req.Granularity {
+		case "", string(uniast.GranularityPackage):
+		case string(uniast.GranularityNode):
+			opts.Granularity = uniast.GranularityNode
+		default:
+			return &GetDiagramResp{Error: fmt.Sprintf("unsupported granularity %q, must be package or node", req.Granularity)}, nil
+		}
+		g := repo.ExportGraph(opts)
+		if len(g.Vertices) == 0 {
+			return &GetDiagramResp{Error: fmt.Sprintf("no nodes under package %q", req.PkgPath)}, nil
+		}
+		_ = g.Relabel(req.Labels).WriteMermaid(&sb)
+	case "sequence":
+		if req.Entry == nil {
+			return &GetDiagramResp{Error: "entry is required by the sequence diagram"}, nil
+		}
+		depth := req.Depth
+		if depth <= 0 {
+			depth = defaultDiagramDepth
+		}
+		seq, err := repo.CallSequence(req.Entry.Identity(), uniast.SequenceOptions{MaxDepth: depth, External: req.External})
+		if err != nil {
+			return &GetDiagramResp{Error: err.Error() + ". Use `get_package_structure` to find the function"}, nil
+		}
+		_ = seq.Relabel(req.Labels).WriteMermaid(&sb)
+	default:
+		return &GetDiagramResp{Error: fmt.Sprintf("unsupported kind %q, must be component or sequence", req.Kind)}, nil
+	}
+	sb.WriteString("```\n")
+
+	if t.opts.MaxBytes > 0 && sb.Len() > t.opts.MaxBytes {
+		return &GetDiagramResp{
+			Error: fmt.Sprintf("the diagram is too large (%d bytes), narrow it by pkg_path, depth or the package granularity", sb.Len()),
+		}, nil
+	}
+	return &GetDiagramResp{Mermaid: sb.String()}, nil
+}
+
+type GetImplementationsReq struct {
+	RepoName string  `json:"repo_name" jsonschema:"description=the name of the repository (output of list_repos tool)"`
+	NodeID   *NodeID `json:"node_id,omitempty" jsonschema:"description=the interface or type to query (output of get_package_structure or get_file_structure tool), external interfaces have empty mod_path for go std like {pkg_path: io, name: Reader}. Default to the whole matrix,nullable"`
+	PageReq
+}
+
+type ImplementationStruct struct {
+	Interface       NodeID   `json:"interface" jsonschema:"description=the interface"`
+	Implementations []NodeID `json:"implementations" jsonschema:"description=the types implementing the interface ordered by node_ids"`
+}
+
+type GetImplementationsResp struct {
+	Interfaces []ImplementationStruct `json:"interfaces,omitempty" jsonschema:"description=the interfaces with the types implementing them ordered by node_ids"`
+	Implements []NodeID               `json:"implements,omitempty" jsonschema:"description=the interfaces implemented by the queried type"`
+	PageResp
+	Error string `json:"error,omitempty" jsonschema:"description=the error message"`
+}
+
+// GetImplementations gets the implements matrix, or the relations of one interface or type, see uniast.Repository.ImplementsMatrix
+func (t *ASTReadTools) GetImplementations(_ context.Context, req GetImplementationsReq) (*GetImplementationsResp, error) {
+	log.Debug("get implementations, req: %v", abutil.MarshalJSONIndentNoError(req))
+	repo, err := t.getRepoAST(req.RepoName)
+	if err != nil {
+		return &GetImplementationsResp{
+			Error: err.Error(),
+		}, nil
+	}
+	newImpl := func(iface uniast.Identity, types []uniast.Identity) ImplementationStruct {
+		impl := ImplementationStruct{Interface: NewNodeID(iface)}
+		for _, typ := range types {
+			impl.Implementations = append(impl.Implementations, NewNodeID(typ))
+		}
+		return impl
+	}
+
+	resp := new(GetImplementationsResp)
+	if req.NodeID == nil {
+		for _, impl := range repo.ImplementsMatrix() {
+			resp.Interfaces = append(resp.Interfaces, newImpl(impl.Interface, impl.Types))
+		}
+		resp.Interfaces = paginate(resp.Interfaces, req.PageReq, t.opts.MaxBytes, &resp.PageResp)
+		log.Debug("get implementations, resp: %d interfaces", len(resp.Interfaces))
+		return resp, nil
+	}
+
+	id := req.NodeID.Identity()
+	if types := repo.GetImplementations(id); len(types) > 0 {
+		resp.Interfaces = append(resp.Interfaces, newImpl(id, types))
+	}
+	for _, iface := range repo.GetImplementedInterfaces(id) {
+		resp.Implements = append(resp.Implements, NewNodeID(iface))
+	}
+	if len(resp.Interfaces) == 0 && len(resp.Implements) == 0 {
+		resp.Error = fmt.Sprintf("no implements relation of %s. Use `get_implementations` without node_id to list the interfaces", id.Full())
+	}
+	return resp, nil
+}
+
+type ListTodosReq struct {
+	RepoName string         `json:"repo_name" jsonschema:"description=the name of the repository (output of list_repos tool)"`
+	Marker   string         `json:"marker,omitempty" jsonschema:"description=only list the markers of the kind. Default to all,enum=TODO,enum=FIXME,enum=XXX,enum=HACK,enum=BUG"`
+	PkgPath  uniast.PkgPath `json:"pkg_path,omitempty" jsonschema:"description=only list the markers attached to the nodes of the package (output of get_repo_structure tool)"`
+	PageReq
+}
+
+type TodoStruct struct {
+	Marker  string  `json:"marker" jsonschema:"description=the marker like TODO or FIXME"`
+	Owner   string  `json:"owner,omitempty" jsonschema:"description=the owner in the parentheses after the marker, like TODO(alice)"`
+	Message string  `json:"message" jsonschema:"description=the text after the marker"`
+	File    string  `json:"file" jsonschema:"description=the file path"`
+	Line    int     `json:"line" jsonschema:"description=the line of the marker"`
+	NodeID  *NodeID `json:"node_id,omitempty" jsonschema:"description=the node the comment is attached to, absent if attached to the file"`
+}
+
+type ListTodosResp struct {
+	Todos []TodoStruct `json:"todos,omitempty" jsonschema:"description=the markers ordered by files and lines"`
+	PageResp
+	Error string `json:"error,omitempty" jsonschema:"description=the error message"`
+}
+
+// ListTodos lists the TODO markers collected from the comments, see uniast.Repository.Todos
+func (t *ASTReadTools) ListTodos(_ context.Context, req ListTodosReq) (*ListTodosResp, error) {
+	log.Debug("list todos, req: %v", abutil.MarshalJSONIndentNoError(req))
+	repo, err := t.getRepoAST(req.RepoName)
+	if err != nil {
+		return &ListTodosResp{
+			Error: err.Error(),
+		}, nil
+	}
+	resp := new(ListTodosResp)
+	for _, todo := range repo.Todos(req.Marker) {
+		if req.PkgPath != "" && (todo.Node == nil || todo.Node.PkgPath != req.PkgPath) {
+			continue
+		}
+		ts := TodoStruct{Marker: todo.Marker, Owner: todo.Owner, Message: todo.Message, File: todo.File, Line: todo.Line}
+		if todo.Node != nil {
+			id := NewNodeID(*todo.Node)
+			ts.NodeID = &id
+		}
+		resp.Todos = append(resp.Todos, ts)
+	}
+	if len(resp.Todos) == 0 {
+		resp.Error = "no TODO found. The comments are only collected if the repo is parsed with `--comments`"
+		return resp, nil
+	}
+	resp.Todos = paginate(resp.Todos, req.PageReq, t.opts.MaxBytes, &resp.PageResp)
+	return resp, nil
+}
+
+type FindErrorOriginsReq struct {
+	RepoName string  `json:"repo_name" jsonschema:"description=the name of the repository (output of list_repos tool)"`
+	NodeID   *NodeID `json:"node_id,omitempty" jsonschema:"description=the error type, the sentinel var or the function to query (output of get_package_structure or get_file_structure tool), the go std ones have empty mod_path like {pkg_path: io, name: EOF}. Default to all the sites,nullable"`
+	PageReq
+}
+
+type ErrorOriginStruct struct {
+	Kind     uniast.ErrorKind `json:"kind" jsonschema:"description=how the error originates,enum=new,enum=wrap,enum=sentinel,enum=type,enum=declared"`
+	Error    *NodeID          `json:"error,omitempty" jsonschema:"description=the error type or the sentinel var, the wrapped one for kind wrap"`
+	Message  string           `json:"message,omitempty" jsonschema:"description=the message or the format of the error"`
+	File     string           `json:"file" jsonschema:"description=the file path"`
+	Line     int              `json:"line" jsonschema:"description=the line of the site"`
+	Function NodeID           `json:"function" jsonschema:"description=the function originating the error"`
+	Via      []NodeID         `json:"via,omitempty" jsonschema:"description=the functions calling the function from the queried one in order"`
+}
+
+type FindErrorOriginsResp struct {
+	Origins []ErrorOriginStruct `json:"origins,omitempty" jsonschema:"description=the sites ordered by files and lines"`
+	PageResp
+	Error string `json:"error,omitempty" jsonschema:"description=the error message"`
+}
+
+// FindErrorOrigins finds where the errors originate, see uniast.Repository.ErrorOrigins
+func (t *ASTReadTools) FindErrorOrigins(_ context.Context, req FindErrorOriginsReq) (*FindErrorOriginsResp, error) {
+	log.Debug("find error origins, req: %v", abutil.MarshalJSONIndentNoError(req))
+	repo, err := t.getRepoAST(req.RepoName)
+	if err != nil {
+		return &FindErrorOriginsResp{
+			Error: err.Error(),
+		}, nil
+	}
+	var id uniast.Identity
+	if req.NodeID != nil {
+		id = req.NodeID.Identity()
+	}
+	resp := new(FindErrorOriginsResp)
+	for _, o := range repo.ErrorOrigins(id) {
+		es := ErrorOriginStruct{Kind: o.Kind, Message: o.Message, File: o.File, Line: o.Line, Function: NewNodeID(o.Function)}
+		if o.Error != nil {
+			e := NewNodeID(*o.Error)
+			es.Error = &e
+		}
+		for _, v := range o.Via {
+			es.Via = append(es.Via, NewNodeID(v))
+		}
+		resp.Origins = append(resp.Origins, es)
+	}
+	if len(resp.Origins) == 0 {
+		resp.Error = "no error origin found. The errors are only recorded by the parsers of go and rust, and the functions not returning errors have none"
+		return resp, nil
+	}
+	resp.Origins = paginate(resp.Origins, req.PageReq, t.opts.MaxBytes, &resp.PageResp)
+	return resp, nil
+}
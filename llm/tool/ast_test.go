@@ -18,9 +18,17 @@ package tool
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/cloudwego/abcoder/lang/uniast"
+	"github.com/cloudwego/abcoder/llm/packer"
 	"github.com/cloudwego/eino/components/tool"
 	"github.com/cloudwego/eino/components/tool/utils"
 	"github.com/cloudwego/eino/schema"
@@ -419,3 +427,629 @@ func TestASTTools_GetASTNode(t *testing.T) {
 // 		})
 // 	}
 // }
+
+func TestASTTools_FindSymbolAcrossRepos(t *testing.T) {
+	dir := t.TempDir()
+	save := func(file string, repo uniast.Repository) {
+		if err := repo.BuildGraph(); err != nil {
+			t.Fatal(err)
+		}
+		bs, err := json.Marshal(repo)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, file), bs, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the SDK defines Client
+	sdk := uniast.NewRepository("github.com/a/sdk")
+	mod := uniast.NewModule("github.com/a/sdk", ".", uniast.Golang)
+	pkg := uniast.NewPackage("github.com/a/sdk/client")
+	client := uniast.NewIdentity("github.com/a/sdk", "github.com/a/sdk/client", "Client")
+	pkg.Types["Client"] = &uniast.Type{Identity: client, FileLine: uniast.FileLine{File: "client/client.go", Line: 3}, TypeKind: uniast.TypeKindStruct}
+	mod.Packages[pkg.PkgPath] = pkg
+	sdk.Modules[mod.Name] = mod
+	save("sdk.json", sdk)
+
+	// the service consumes Client of the SDK as an external module
+	svc := uniast.NewRepository("github.com/a/svc")
+	mod = uniast.NewModule("github.com/a/svc", ".", uniast.Golang)
+	pkg = uniast.NewPackage("github.com/a/svc/handler")
+	extClient := uniast.NewIdentity("github.com/a/sdk@v1.0.0", "github.com/a/sdk/client", "Client")
+	pkg.Functions["Handle"] = &uniast.Function{
+		Identity: uniast.NewIdentity("github.com/a/svc", "github.com/a/svc/handler", "Handle"),
+		FileLine: uniast.FileLine{File: "handler/handle.go", Line: 10},
+		Types:    []uniast.Dependency{{Identity: extClient}},
+	}
+	mod.Packages[pkg.PkgPath] = pkg
+	svc.Modules[mod.Name] = mod
+	ext := uniast.NewModule("github.com/a/sdk@v1.0.0", "", uniast.Golang)
+	epkg := uniast.NewPackage("github.com/a/sdk/client")
+	epkg.Types["Client"] = &uniast.Type{Identity: extClient, TypeKind: uniast.TypeKindStruct}
+	ext.Packages[epkg.PkgPath] = epkg
+	svc.Modules["github.com/a/sdk@v1.0.0"] = ext
+	save("svc.json", svc)
+
+	tools := NewASTReadTools(ASTReadToolsOptions{RepoASTsDir: dir})
+	resp, err := tools.FindSymbolAcrossRepos(context.Background(), FindSymbolAcrossReposReq{Name: "Client"})
+	if err != nil || resp.Error != "" {
+		t.Fatal(err, resp.Error)
+	}
+	if len(resp.Symbols) != 1 || resp.Total != 1 || resp.Truncated != "" {
+		t.Fatalf("symbols = %+v", resp)
+	}
+	sym := resp.Symbols[0]
+	if sym.RepoName != "github.com/a/sdk" || sym.NodeID != NewNodeID(client) || sym.File != "client/client.go" {
+		t.Errorf("symbol = %+v", sym)
+	}
+	want := []RepoNodeID{{
+		RepoName: "github.com/a/svc",
+		NodeID:   NodeID{ModPath: "github.com/a/svc", PkgPath: "github.com/a/svc/handler", Name: "Handle"},
+		File:     "handler/handle.go",
+		Line:     10,
+	}}
+	if !reflect.DeepEqual(sym.References, want) {
+		t.Errorf("references = %+v", sym.References)
+	}
+
+	resp, _ = tools.FindSymbolAcrossRepos(context.Background(), FindSymbolAcrossReposReq{Name: "Client", RepoNames: []string{"github.com/a/svc"}})
+	if len(resp.Symbols) != 0 || resp.Error == "" {
+		t.Errorf("external nodes should not be regarded as definitions: %+v", resp)
+	}
+}
+
+func TestASTTools_GetNodeMetrics(t *testing.T) {
+	dir := t.TempDir()
+	repo := uniast.NewRepository("github.com/a/svc")
+	mod := uniast.NewModule("github.com/a/svc", ".", uniast.Golang)
+	pkg := uniast.NewPackage("github.com/a/svc/handler")
+	simple := uniast.NewIdentity("github.com/a/svc", "github.com/a/svc/handler", "Simple")
+	pkg.Functions["Simple"] = &uniast.Function{
+		Identity: simple,
+		FileLine: uniast.FileLine{File: "handler/handle.go", Line: 3},
+		Content:  "func Simple() {}",
+	}
+	pkg.Functions["Branchy"] = &uniast.Function{
+		Identity:      uniast.NewIdentity("github.com/a/svc", "github.com/a/svc/handler", "Branchy"),
+		FileLine:      uniast.FileLine{File: "handler/handle.go", Line: 5},
+		Content:       "func Branchy(a bool) {\n\tif a {\n\t\tSimple()\n\t}\n}",
+		FunctionCalls: []uniast.Dependency{{Identity: simple}},
+	}
+	mod.Packages[pkg.PkgPath] = pkg
+	repo.Modules[mod.Name] = mod
+	// saved without metrics, as the ASTs parsed by older versions
+	bs, err := json.Marshal(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "svc.json"), bs, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tools := NewASTReadTools(ASTReadToolsOptions{RepoASTsDir: dir})
+	resp, err := tools.GetNodeMetrics(context.Background(), GetNodeMetricsReq{RepoName: "github.com/a/svc"})
+	if err != nil || resp.Error != "" {
+		t.Fatal(err, resp.Error)
+	}
+	if len(resp.Nodes) != 2 || resp.Nodes[0].Name != "Branchy" || resp.Nodes[0].Complexity != 2 || resp.Nodes[0].FanOut != 1 {
+		t.Fatalf("nodes = %+v", resp.Nodes)
+	}
+
+	resp, _ = tools.GetNodeMetrics(context.Background(), GetNodeMetricsReq{RepoName: "github.com/a/svc", SortBy: "fan_in", PageReq: PageReq{PageSize: 1}})
+	want := NodeMetrics{NodeID: NewNodeID(simple), File: "handler/handle.go", Line: 3, LOC: 1, Complexity: 1, FanIn: 1}
+	if len(resp.Nodes) != 1 || resp.Nodes[0] != want || resp.NextPage != 2 {
+		t.Errorf("resp = %+v", resp)
+	}
+
+	resp, _ = tools.GetNodeMetrics(context.Background(), GetNodeMetricsReq{RepoName: "github.com/a/svc", NodeIDs: []NodeID{NewNodeID(simple), {Name: "Missing"}}})
+	if len(resp.Nodes) != 1 || resp.Nodes[0] != want || !strings.Contains(resp.Error, "Missing") {
+		t.Errorf("resp = %+v", resp)
+	}
+
+	resp, _ = tools.GetNodeMetrics(context.Background(), GetNodeMetricsReq{RepoName: "github.com/a/svc", SortBy: "size"})
+	if resp.Error == "" {
+		t.Errorf("invalid sort_by should be rejected")
+	}
+}
+
+func TestASTTools_FindReferences(t *testing.T) {
+	dir := t.TempDir()
+	repo := uniast.NewRepository("refs")
+	mod := uniast.NewModule("m", ".", uniast.Golang)
+	repo.Modules[mod.Name] = mod
+	target := uniast.NewIdentity("m", "m/a", "Config")
+	repo.SetType(target, &uniast.Type{Identity: target, TypeKind: uniast.TypeKindStruct})
+	for _, id := range []uniast.Identity{
+		uniast.NewIdentity("m", "m/b", "Load"),
+		uniast.NewIdentity("m", "m/a", "New"),
+		uniast.NewIdentity("m", "m/b", "Save"),
+	} {
+		repo.SetFunction(id, &uniast.Function{
+			Identity: id,
+			FileLine: uniast.FileLine{File: id.PkgPath + "/f.go", Line: 1},
+			Types:    []uniast.Dependency{uniast.NewDependency(target, uniast.FileLine{Line: 2})},
+		})
+	}
+	bs, err := json.Marshal(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "refs.json"), bs, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tools := NewASTReadTools(ASTReadToolsOptions{RepoASTsDir: dir})
+	resp, err := tools.FindReferences(context.Background(), FindReferencesReq{RepoName: "refs", NodeID: NewNodeID(target)})
+	if err != nil || resp.Error != "" {
+		t.Fatal(err, resp.Error)
+	}
+	var got []string
+	for _, pkg := range resp.Packages {
+		for _, ref := range pkg.References {
+			got = append(got, fmt.Sprintf("%s %s %s:%d", pkg.PkgPath, ref.Name, ref.File, ref.Line))
+		}
+	}
+	want := []string{"m/a New m/a/f.go:2", "m/b Load m/b/f.go:2", "m/b Save m/b/f.go:2"}
+	if !reflect.DeepEqual(got, want) || resp.Total != 3 {
+		t.Errorf("references = %q, total %d, want %q", got, resp.Total, want)
+	}
+
+	resp, _ = tools.FindReferences(context.Background(), FindReferencesReq{RepoName: "refs", NodeID: NodeID{ModPath: "m", PkgPath: "m/a", Name: "Unknown"}})
+	if resp.Error == "" {
+		t.Errorf("unknown node should fail: %+v", resp)
+	}
+}
+
+func TestASTTools_ChunkRepo(t *testing.T) {
+	tools := NewASTReadTools(ASTReadToolsOptions{RepoASTsDir: "../../testdata/asts"})
+	resp, err := tools.ChunkRepo(context.Background(), ChunkRepoReq{RepoName: "localsession", PageReq: PageReq{PageSize: 2}})
+	if err != nil || resp.Error != "" {
+		t.Fatal(err, resp.Error)
+	}
+	if len(resp.Chunks) != 2 || resp.NextPage != 2 || resp.Total <= 2 {
+		t.Fatalf("resp = %+v", resp.PageResp)
+	}
+	for _, c := range resp.Chunks {
+		if c.ID == "" || len(c.NodeIDs) == 0 || c.Tokens > packer.DefaultChunkTokens || !strings.HasPrefix(c.Text, "// ") {
+			t.Errorf("chunk = %+v", c)
+		}
+	}
+
+	resp, _ = tools.ChunkRepo(context.Background(), ChunkRepoReq{RepoName: "localsession", PkgPath: "not/exist"})
+	if resp.Error == "" {
+		t.Error("expect an error for the missing package")
+	}
+}
+
+func TestASTTools_GetNodeOwners(t *testing.T) {
+	dir := t.TempDir()
+	repo := uniast.NewRepository("github.com/a/svc")
+	mod := uniast.NewModule("github.com/a/svc", ".", uniast.Golang)
+	pkg := uniast.NewPackage("github.com/a/svc/handler")
+	when := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	alice := uniast.Owner{Name: "alice", Email: "alice@example.com", Lines: 3}
+	bob := uniast.Owner{Name: "bob", Email: "bob@example.com", Lines: 2}
+	handle := uniast.NewIdentity("github.com/a/svc", "github.com/a/svc/handler", "Handle")
+	pkg.Functions["Handle"] = &uniast.Function{
+		Identity: handle,
+		FileLine: uniast.FileLine{File: "handler/handle.go", Line: 3},
+		Owners:   &uniast.Ownership{Authors: []uniast.Owner{bob, {Name: "alice", Email: "alice@example.com", Lines: 1}}, LastModified: when},
+	}
+	req := uniast.NewIdentity("github.com/a/svc", "github.com/a/svc/handler", "Request")
+	pkg.Types["Request"] = &uniast.Type{
+		Identity: req,
+		FileLine: uniast.FileLine{File: "handler/handle.go", Line: 10},
+		Owners:   &uniast.Ownership{Authors: []uniast.Owner{alice}, LastModified: when},
+	}
+	unowned := uniast.NewIdentity("github.com/a/svc", "github.com/a/svc/handler", "Unowned")
+	pkg.Vars["Unowned"] = &uniast.Var{Identity: unowned}
+	mod.Packages[pkg.PkgPath] = pkg
+	repo.Modules[mod.Name] = mod
+	bs, err := json.Marshal(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "svc.json"), bs, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tools := NewASTReadTools(ASTReadToolsOptions{RepoASTsDir: dir})
+	resp, err := tools.GetNodeOwners(context.Background(), GetNodeOwnersReq{
+		RepoName: "github.com/a/svc",
+		NodeIDs:  []NodeID{NewNodeID(handle), NewNodeID(req), NewNodeID(unowned)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Nodes) != 2 || resp.Nodes[0].Authors[0].Name != "bob" || resp.Nodes[0].LastModified != "2025-03-01T00:00:00Z" {
+		t.Fatalf("nodes = %+v", resp.Nodes)
+	}
+	want := []OwnerStruct{{Name: "alice", Email: "alice@example.com", Lines: 4}, {Name: "bob", Email: "bob@example.com", Lines: 2}}
+	if !reflect.DeepEqual(resp.Reviewers, want) {
+		t.Errorf("reviewers = %+v, want %+v", resp.Reviewers, want)
+	}
+	if !strings.Contains(resp.Error, "Unowned") || !strings.Contains(resp.Error, "--blame") {
+		t.Errorf("error = %q", resp.Error)
+	}
+}
+
+func TestASTTools_FindUnreachableNodes(t *testing.T) {
+	tools := NewASTReadTools(ASTReadToolsOptions{RepoASTsDir: "../../testdata/asts"})
+	all, err := tools.FindUnreachableNodes(context.Background(), FindUnreachableNodesReq{RepoName: "localsession"})
+	if err != nil || all.Error != "" {
+		t.Fatal(err, all.Error)
+	}
+	if all.Total == 0 {
+		t.Fatal("a library without main has unreachable nodes besides the tests")
+	}
+	for _, n := range all.Nodes {
+		if n.Name == "" || n.Type == "" {
+			t.Errorf("node = %+v", n)
+		}
+	}
+
+	api, _ := tools.FindUnreachableNodes(context.Background(), FindUnreachableNodesReq{RepoName: "localsession", IncludeAPI: true})
+	if api.Total >= all.Total {
+		t.Errorf("the exported API should reach more nodes: %d >= %d", api.Total, all.Total)
+	}
+}
+
+func TestASTTools_GetASTNode_Inline(t *testing.T) {
+	tools := NewASTReadTools(ASTReadToolsOptions{RepoASTsDir: "../../testdata/asts"})
+	id := NodeID{ModPath: "github.com/cloudwego/localsession", PkgPath: "github.com/cloudwego/localsession", Name: "CurSession"}
+	plain, err := tools.GetASTNode(context.Background(), GetASTNodeReq{RepoName: "localsession", NodeIDs: []NodeID{id}})
+	if err != nil || plain.Error != "" {
+		t.Fatal(err, plain.Error)
+	}
+	if plain.Nodes[0].Inlined != "" {
+		t.Errorf("inlined without inline_depth: %s", plain.Nodes[0].Inlined)
+	}
+
+	one, _ := tools.GetASTNode(context.Background(), GetASTNodeReq{RepoName: "localsession", NodeIDs: []NodeID{id}, InlineDepth: 1})
+	inlined := one.Nodes[0].Inlined
+	if !strings.Contains(inlined, "#SessionManager.GetSession (") || !strings.Contains(inlined, "func (self *SessionManager) GetSession(") {
+		t.Fatalf("inlined = %s", inlined)
+	}
+	if one.Nodes[0].Codes != plain.Nodes[0].Codes {
+		t.Errorf("codes changed by inlining: %s", one.Nodes[0].Codes)
+	}
+
+	two, _ := tools.GetASTNode(context.Background(), GetASTNodeReq{RepoName: "localsession", NodeIDs: []NodeID{id}, InlineDepth: 2})
+	if deeper := two.Nodes[0].Inlined; len(deeper) <= len(inlined) || !strings.HasPrefix(deeper, inlined) {
+		t.Errorf("inlined of depth 2 = %s", deeper)
+	}
+
+	small, _ := tools.GetASTNode(context.Background(), GetASTNodeReq{RepoName: "localsession", NodeIDs: []NodeID{id}, InlineDepth: 2, PageReq: PageReq{MaxBytes: 1800}})
+	if n := small.Nodes[0]; jsonSize(n) > 1800 || n.Codes != plain.Nodes[0].Codes || !strings.Contains(n.Inlined, "bytes truncated)") {
+		t.Errorf("node = %+v", n)
+	}
+}
+
+func TestASTTools_GetDependencies(t *testing.T) {
+	dir := t.TempDir()
+	repo := uniast.NewRepository("github.com/a/svc")
+	mod := uniast.NewModule("github.com/a/svc", ".", uniast.Golang)
+	mod.Dependencies = map[string]string{
+		"github.com/b/sonic":  "github.com/b/sonic@v1.2.0",
+		"github.com/c/errors": "github.com/c/errors@v0.9.1",
+	}
+	repo.Modules[mod.Name] = mod
+	repo.Dependencies = repo.ExternalDependencies()
+	repo.Dependencies[0].License = "Apache-2.0"
+	bs, err := json.Marshal(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "svc.json"), bs, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tools := NewASTReadTools(ASTReadToolsOptions{RepoASTsDir: dir})
+	resp, err := tools.GetDependencies(context.Background(), GetDependenciesReq{RepoName: "github.com/a/svc"})
+	if err != nil || resp.Error != "" {
+		t.Fatal(err, resp.Error)
+	}
+	want := []DependencyStruct{
+		{Name: "github.com/b/sonic", Version: "v1.2.0", Language: "go", License: "Apache-2.0", Dependents: []string{"github.com/a/svc"}},
+		{Name: "github.com/c/errors", Version: "v0.9.1", Language: "go", Dependents: []string{"github.com/a/svc"}},
+	}
+	if !reflect.DeepEqual(resp.Dependencies, want) {
+		t.Errorf("dependencies = %+v, want %+v", resp.Dependencies, want)
+	}
+
+	resp, _ = tools.GetDependencies(context.Background(), GetDependenciesReq{RepoName: "github.com/a/svc", Name: "Errors"})
+	if len(resp.Dependencies) != 1 || resp.Dependencies[0].Name != "github.com/c/errors" {
+		t.Errorf("dependencies = %+v", resp.Dependencies)
+	}
+	resp, _ = tools.GetDependencies(context.Background(), GetDependenciesReq{RepoName: "github.com/a/svc", Name: "yaml"})
+	if resp.Error == "" {
+		t.Error("expect an error for no matches")
+	}
+}
+
+func TestASTTools_LocateNodeByPosition(t *testing.T) {
+	dir := t.TempDir()
+	repo := uniast.NewRepository("github.com/a/svc")
+	mod := uniast.NewModule("github.com/a/svc", ".", uniast.Golang)
+	repo.Modules[mod.Name] = mod
+	id := func(name string) uniast.Identity {
+		return uniast.NewIdentity(mod.Name, "github.com/a/svc/handler", name)
+	}
+	repo.SetFunction(id("Serve"), &uniast.Function{Identity: id("Serve"), FileLine: uniast.FileLine{File: "handler/serve.go", Line: 10, EndLine: 20},
+		Content: "func Serve() {\n\tparse(nil)\n}"})
+	repo.SetFunction(id("parse"), &uniast.Function{Identity: id("parse"), FileLine: uniast.FileLine{File: "handler/parse.go", Line: 3, EndLine: 8},
+		Content: "func parse(b []byte) {\n\t_ = b[0]\n}"})
+	bs, err := json.Marshal(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "svc.json"), bs, 0644); err != nil {
+		t.Fatal(err)
+	}
+	tools := NewASTReadTools(ASTReadToolsOptions{RepoASTsDir: dir})
+
+	resp, err := tools.LocateNodeByPosition(context.Background(), LocateNodeByPositionReq{RepoName: "github.com/a/svc", Position: "handler/serve.go:12"})
+	if err != nil || resp.Error != "" {
+		t.Fatal(err, resp.Error)
+	}
+	if len(resp.Nodes) != 1 || resp.Nodes[0].Node.Name != "Serve" || resp.Nodes[0].Node.Codes == "" {
+		t.Errorf("nodes = %+v", resp.Nodes)
+	}
+
+	trace := `panic: runtime error: index out of range [0] with length 0
+
+goroutine 1 [running]:
+github.com/a/svc/handler.parse(...)
+	/home/ci/go/src/github.com/a/svc/handler/parse.go:4
+github.com/a/svc/handler.Serve()
+	/home/ci/go/src/github.com/a/svc/handler/serve.go:11 +0x1d
+main.main()
+	/home/ci/go/src/github.com/a/svc/main.go:8 +0x25
+runtime.main()
+	/usr/local/go/src/runtime/proc.go:272 +0x28d
+`
+	resp, _ = tools.LocateNodeByPosition(context.Background(), LocateNodeByPositionReq{RepoName: "github.com/a/svc", StackTrace: trace})
+	if len(resp.Nodes) != 2 || resp.Nodes[0].Node.Name != "parse" || resp.Nodes[1].Node.Name != "Serve" || resp.Unresolved != 2 {
+		t.Errorf("resp = %+v", resp)
+	}
+	if pos := resp.Nodes[0].Position; pos != "/home/ci/go/src/github.com/a/svc/handler/parse.go:4" {
+		t.Errorf("position = %s", pos)
+	}
+
+	resp, _ = tools.LocateNodeByPosition(context.Background(), LocateNodeByPositionReq{RepoName: "github.com/a/svc", Position: "serve"})
+	if resp.Error == "" {
+		t.Error("expect an error for no positions")
+	}
+}
+
+func TestASTTools_GetPublicAPI(t *testing.T) {
+	dir := t.TempDir()
+	repo := uniast.NewRepository("github.com/a/lib")
+	mod := uniast.NewModule("github.com/a/lib", ".", uniast.Golang)
+	repo.Modules[mod.Name] = mod
+	id := func(pkg, name string) uniast.Identity {
+		return uniast.NewIdentity(mod.Name, "github.com/a/lib/"+pkg, name)
+	}
+	repo.SetFunction(id("codec", "Encode"), &uniast.Function{Identity: id("codec", "Encode"), Exported: true,
+		Signature: "func Encode(v any) ([]byte, error)", Content: "func Encode(v any) ([]byte, error) {\n\treturn encode(v)\n}"})
+	repo.SetFunction(id("codec", "encode"), &uniast.Function{Identity: id("codec", "encode"),
+		Signature: "func encode(v any) ([]byte, error)", Content: "func encode(v any) ([]byte, error) {\n\treturn nil, nil\n}"})
+	repo.SetFunction(id("pool", "Get"), &uniast.Function{Identity: id("pool", "Get"), Exported: true,
+		Signature: "func Get() []byte", Content: "func Get() []byte {\n\treturn nil\n}"})
+	bs, err := json.Marshal(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "lib.json"), bs, 0644); err != nil {
+		t.Fatal(err)
+	}
+	tools := NewASTReadTools(ASTReadToolsOptions{RepoASTsDir: dir})
+
+	resp, err := tools.GetPublicAPI(context.Background(), GetPublicAPIReq{RepoName: "github.com/a/lib"})
+	if err != nil || resp.Error != "" {
+		t.Fatal(err, resp.Error)
+	}
+	if len(resp.Entries) != 2 || resp.Entries[0].Name != "Encode" || resp.Entries[1].Name != "Get" {
+		t.Fatalf("entries = %+v", resp.Entries)
+	}
+	if e := resp.Entries[0]; e.Type != "FUNC" || e.Signature != "func Encode(v any) ([]byte, error)" {
+		t.Errorf("entry = %+v", e)
+	}
+
+	resp, _ = tools.GetPublicAPI(context.Background(), GetPublicAPIReq{RepoName: "github.com/a/lib", PkgPath: "github.com/a/lib/pool"})
+	if len(resp.Entries) != 1 || resp.Entries[0].Name != "Get" {
+		t.Errorf("entries = %+v", resp.Entries)
+	}
+
+	resp, _ = tools.GetPublicAPI(context.Background(), GetPublicAPIReq{RepoName: "github.com/a/lib", ModPath: "github.com/a/other"})
+	if resp.Error == "" {
+		t.Error("expect an error for unknown module")
+	}
+}
+
+func TestASTTools_GetDiagram(t *testing.T) {
+	dir := t.TempDir()
+	repo := uniast.NewRepository("github.com/a/svc")
+	mod := uniast.NewModule("github.com/a/svc", ".", uniast.Golang)
+	repo.Modules[mod.Name] = mod
+	serve := uniast.NewIdentity(mod.Name, "github.com/a/svc/handler", "Serve")
+	save := uniast.NewIdentity(mod.Name, "github.com/a/svc/store", "Save")
+	repo.SetFunction(serve, &uniast.Function{Identity: serve, FunctionCalls: []uniast.Dependency{uniast.NewDependency(save, uniast.FileLine{Line: 3})}})
+	repo.SetFunction(save, &uniast.Function{Identity: save})
+	bs, err := json.Marshal(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "svc.json"), bs, 0644); err != nil {
+		t.Fatal(err)
+	}
+	tools := NewASTReadTools(ASTReadToolsOptions{RepoASTsDir: dir})
+
+	resp, err := tools.GetDiagram(context.Background(), GetDiagramReq{RepoName: "github.com/a/svc", Kind: "component",
+		Labels: map[string]string{"github.com/a/svc?github.com/a/svc/store": "Storage"}})
+	if err != nil || resp.Error != "" {
+		t.Fatal(err, resp.Error)
+	}
+	if !strings.HasPrefix(resp.Mermaid, "```mermaid\nflowchart LR\n") || !strings.Contains(resp.Mermaid, `n1[["Storage"]]`) || !strings.Contains(resp.Mermaid, "n0 --> n1") {
+		t.Errorf("component diagram:\n%s", resp.Mermaid)
+	}
+
+	entry := NewNodeID(serve)
+	resp, _ = tools.GetDiagram(context.Background(), GetDiagramReq{RepoName: "github.com/a/svc", Kind: "sequence", Entry: &entry})
+	if !strings.Contains(resp.Mermaid, "sequenceDiagram\n") || !strings.Contains(resp.Mermaid, "p0->>p1: Save\n") {
+		t.Errorf("sequence diagram:\n%s", resp.Mermaid)
+	}
+
+	for _, req := range []GetDiagramReq{
+		{RepoName: "github.com/a/svc", Kind: "class"},
+		{RepoName: "github.com/a/svc", Kind: "sequence"},
+		{RepoName: "github.com/a/svc", Kind: "component", PkgPath: "github.com/b"},
+	} {
+		if resp, _ := tools.GetDiagram(context.Background(), req); resp.Error == "" {
+			t.Errorf("expect an error for %+v", req)
+		}
+	}
+}
+
+func TestASTTools_GetImplementations(t *testing.T) {
+	dir := t.TempDir()
+	repo := uniast.NewRepository("github.com/a/buf")
+	mod := uniast.NewModule("github.com/a/buf", ".", uniast.Golang)
+	repo.Modules[mod.Name] = mod
+	reader := uniast.Identity{PkgPath: "io", Name: "Reader"}
+	sizer := uniast.NewIdentity(mod.Name, "github.com/a/buf", "Sizer")
+	buf := uniast.NewIdentity(mod.Name, "github.com/a/buf", "Buf")
+	file := uniast.NewIdentity(mod.Name, "github.com/a/buf", "File")
+	repo.SetType(sizer, &uniast.Type{Identity: sizer, TypeKind: uniast.TypeKindInterface})
+	repo.SetType(buf, &uniast.Type{Identity: buf, TypeKind: uniast.TypeKindStruct, Implements: []uniast.Identity{sizer, reader}})
+	repo.SetType(file, &uniast.Type{Identity: file, TypeKind: uniast.TypeKindStruct, Implements: []uniast.Identity{reader}})
+	bs, err := json.Marshal(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "buf.json"), bs, 0644); err != nil {
+		t.Fatal(err)
+	}
+	tools := NewASTReadTools(ASTReadToolsOptions{RepoASTsDir: dir})
+
+	resp, err := tools.GetImplementations(context.Background(), GetImplementationsReq{RepoName: "github.com/a/buf"})
+	if err != nil || resp.Error != "" {
+		t.Fatal(err, resp.Error)
+	}
+	if len(resp.Interfaces) != 2 || resp.Interfaces[0].Interface != NewNodeID(reader) || len(resp.Interfaces[0].Implementations) != 2 ||
+		resp.Interfaces[1].Interface != NewNodeID(sizer) || len(resp.Interfaces[1].Implementations) != 1 {
+		t.Fatalf("interfaces = %+v", resp.Interfaces)
+	}
+
+	id := NewNodeID(reader)
+	resp, _ = tools.GetImplementations(context.Background(), GetImplementationsReq{RepoName: "github.com/a/buf", NodeID: &id})
+	if len(resp.Interfaces) != 1 || !reflect.DeepEqual(resp.Interfaces[0].Implementations, []NodeID{NewNodeID(buf), NewNodeID(file)}) || resp.Implements != nil {
+		t.Errorf("implementations of io.Reader = %+v", resp)
+	}
+
+	id = NewNodeID(buf)
+	resp, _ = tools.GetImplementations(context.Background(), GetImplementationsReq{RepoName: "github.com/a/buf", NodeID: &id})
+	if resp.Interfaces != nil || !reflect.DeepEqual(resp.Implements, []NodeID{NewNodeID(reader), NewNodeID(sizer)}) {
+		t.Errorf("interfaces of Buf = %+v", resp)
+	}
+
+	id = NewNodeID(uniast.Identity{PkgPath: "io", Name: "Writer"})
+	if resp, _ = tools.GetImplementations(context.Background(), GetImplementationsReq{RepoName: "github.com/a/buf", NodeID: &id}); resp.Error == "" {
+		t.Errorf("expect an error for io.Writer")
+	}
+}
+
+func TestASTTools_ListTodos(t *testing.T) {
+	dir := t.TempDir()
+	repo := uniast.NewRepository("github.com/a/buf")
+	mod := uniast.NewModule("github.com/a/buf", ".", uniast.Golang)
+	repo.Modules[mod.Name] = mod
+	buf := uniast.NewIdentity(mod.Name, "github.com/a/buf", "Buf")
+	file := uniast.NewFile("buf.go")
+	file.Comments = []uniast.Comment{
+		{Kind: uniast.CommentTodo, Text: "// TODO: pool the buffers", Line: 1, EndLine: 1},
+		{Kind: uniast.CommentTodo, Text: "// FIXME(bob): grow twice", Line: 5, EndLine: 5, Node: &buf},
+	}
+	mod.Files[file.Path] = file
+	bs, err := json.Marshal(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "buf.json"), bs, 0644); err != nil {
+		t.Fatal(err)
+	}
+	tools := NewASTReadTools(ASTReadToolsOptions{RepoASTsDir: dir})
+
+	resp, err := tools.ListTodos(context.Background(), ListTodosReq{RepoName: "github.com/a/buf"})
+	if err != nil || resp.Error != "" {
+		t.Fatal(err, resp.Error)
+	}
+	id := NewNodeID(buf)
+	want := []TodoStruct{
+		{Marker: "TODO", Message: "pool the buffers", File: "buf.go", Line: 1},
+		{Marker: "FIXME", Owner: "bob", Message: "grow twice", File: "buf.go", Line: 5, NodeID: &id},
+	}
+	if !reflect.DeepEqual(resp.Todos, want) {
+		t.Errorf("todos = %+v", resp.Todos)
+	}
+
+	resp, _ = tools.ListTodos(context.Background(), ListTodosReq{RepoName: "github.com/a/buf", PkgPath: "github.com/a/buf"})
+	if len(resp.Todos) != 1 || resp.Todos[0].Marker != "FIXME" {
+		t.Errorf("todos of the package = %+v", resp.Todos)
+	}
+	if resp, _ = tools.ListTodos(context.Background(), ListTodosReq{RepoName: "github.com/a/buf", Marker: "HACK"}); resp.Error == "" {
+		t.Errorf("expect an error for no HACK")
+	}
+}
+
+func TestASTTools_FindErrorOrigins(t *testing.T) {
+	dir := t.TempDir()
+	repo := uniast.NewRepository("github.com/a/kv")
+	mod := uniast.NewModule("github.com/a/kv", ".", uniast.Golang)
+	repo.Modules[mod.Name] = mod
+	id := func(name string) uniast.Identity { return uniast.NewIdentity(mod.Name, "github.com/a/kv", name) }
+	eof := uniast.NewIdentity("", "io", "EOF")
+	read := &uniast.Function{Identity: id("read"), FileLine: uniast.FileLine{File: "kv.go", Line: 3}, Errors: &uniast.Errors{
+		Returns: true,
+		Sites:   []uniast.ErrorSite{{Kind: uniast.ErrorSentinel, Error: &eof, FileLine: uniast.FileLine{File: "kv.go", Line: 5}}},
+	}}
+	get := &uniast.Function{Identity: id("Get"), FileLine: uniast.FileLine{File: "kv.go", Line: 10}, Errors: &uniast.Errors{
+		Returns: true,
+		Sites:   []uniast.ErrorSite{{Kind: uniast.ErrorWrap, Message: "get %s: %w", FileLine: uniast.FileLine{File: "kv.go", Line: 13}}},
+	}, FunctionCalls: []uniast.Dependency{uniast.NewDependency(read.Identity, uniast.FileLine{File: "kv.go", Line: 11})}}
+	repo.SetFunction(read.Identity, read)
+	repo.SetFunction(get.Identity, get)
+	bs, err := json.Marshal(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "kv.json"), bs, 0644); err != nil {
+		t.Fatal(err)
+	}
+	tools := NewASTReadTools(ASTReadToolsOptions{RepoASTsDir: dir})
+
+	getID := NewNodeID(get.Identity)
+	resp, err := tools.FindErrorOrigins(context.Background(), FindErrorOriginsReq{RepoName: "github.com/a/kv", NodeID: &getID})
+	if err != nil || resp.Error != "" {
+		t.Fatal(err, resp.Error)
+	}
+	eofID := NewNodeID(eof)
+	want := []ErrorOriginStruct{
+		{Kind: uniast.ErrorSentinel, Error: &eofID, File: "kv.go", Line: 5, Function: NewNodeID(read.Identity)},
+		{Kind: uniast.ErrorWrap, Message: "get %s: %w", File: "kv.go", Line: 13, Function: getID},
+	}
+	if !reflect.DeepEqual(resp.Origins, want) {
+		t.Errorf("origins of Get = %+v", resp.Origins)
+	}
+
+	resp, _ = tools.FindErrorOrigins(context.Background(), FindErrorOriginsReq{RepoName: "github.com/a/kv", NodeID: &eofID})
+	if len(resp.Origins) != 1 || resp.Origins[0].Function.Name != "read" {
+		t.Errorf("origins of io.EOF = %+v", resp.Origins)
+	}
+	sizeID := NewNodeID(id("Size"))
+	if resp, _ = tools.FindErrorOrigins(context.Background(), FindErrorOriginsReq{RepoName: "github.com/a/kv", NodeID: &sizeID}); resp.Error == "" {
+		t.Errorf("expect an error for no origin")
+	}
+}
@@ -30,6 +30,7 @@ import (
 
 const (
 	ToolWriteASTNode = "write_ast_node"
+	ToolMoveASTNode  = "move_ast_node"
 )
 
 type ASTWriteToolsOptions struct {
@@ -58,6 +59,14 @@ func NewASTWriteTools(repo *uniast.Repository, opts ASTWriteToolsOptions) *ASTWr
 		panic(err)
 	}
 	ret.tools[string(ToolWriteASTNode)] = tt
+
+	tt, err = utils.InferTool(string(ToolMoveASTNode),
+		"move a function, type or var to another package of the repo, the methods of a type go with it. The references to it are qualified and the imports are adjusted",
+		ret.MoveASTNode)
+	if err != nil {
+		panic(err)
+	}
+	ret.tools[string(ToolMoveASTNode)] = tt
 	return ret
 }
 
@@ -76,7 +85,7 @@ func (t ASTWriteTools) GetTool(name string) Tool {
 type WriteASTNodeReq struct {
 	ID        uniast.Identity   `json:"id" jsonschema:"description=the id of the ast node"`
 	Codes     string            `json:"codes" jsonschema:"description=the codes of the ast node"`
-	Type      string            `json:"type" jsonschema:"description=the type of the ast node, must be enum of 'FUNC'|'TYPE'|'VAR'"`
+	Type      string            `json:"type" jsonschema:"description=the type of the ast node, must be enum of 'FUNC'|'TYPE'|'VAR',enum=FUNC,enum=TYPE,enum=VAR"`
 	File      string            `json:"file,omitempty" jsonschema:"description=the file path for newly-added ast node"`
 	AddedDeps []uniast.Identity `json:"added_deps" jsonschema:"description=the added dependencies of the ast node"`
 }
@@ -131,3 +140,44 @@ func (t ASTWriteTools) WriteASTNode(_ context.Context, req WriteASTNodeReq) (*Wr
 	log.Debug("write ast node, resp: %v", abutil.MarshalJSONIndentNoError(resp))
 	return resp, nil
 }
+
+type MoveASTNodeReq struct {
+	ID      uniast.Identity `json:"id" jsonschema:"description=the id of the ast node to move"`
+	ModPath string          `json:"mod_path,omitempty" jsonschema:"description=the module of the destination package. Default to the module of the node"`
+	PkgPath string          `json:"pkg_path" jsonschema:"description=the destination package"`
+	File    string          `json:"file" jsonschema:"description=the destination file path, which is created if not exists"`
+}
+
+type MoveASTNodeResp struct {
+	Success bool            `json:"success" jsonschema:"description=whether the ast node is moved successfully"`
+	ID      uniast.Identity `json:"id" jsonschema:"description=the new id of the ast node"`
+	Message string          `json:"message" jsonschema:"description=the feedback message"`
+}
+
+func (t ASTWriteTools) MoveASTNode(_ context.Context, req MoveASTNodeReq) (*MoveASTNodeResp, error) {
+	log.Debug("move ast node, req: %v", abutil.MarshalJSONIndentNoError(req))
+	mv := patch.Move{
+		Id:      req.ID,
+		ModPath: req.ModPath,
+		PkgPath: req.PkgPath,
+		File:    req.File,
+	}
+	if err := t.patcher.Move(mv); err != nil {
+		return nil, fmt.Errorf("move node '%s' failed: %v", req.ID.Full(), err)
+	}
+	if err := t.patcher.Flush(); err != nil {
+		return nil, fmt.Errorf("flush patcher failed: %v", err)
+	}
+	id := req.ID
+	if req.ModPath != "" {
+		id.ModPath = req.ModPath
+	}
+	id.PkgPath = req.PkgPath
+	resp := &MoveASTNodeResp{
+		Success: true,
+		ID:      id,
+		Message: "Move the ast node successfully. Please check if the moved codes and their references compile.",
+	}
+	log.Debug("move ast node, resp: %v", abutil.MarshalJSONIndentNoError(resp))
+	return resp, nil
+}
@@ -0,0 +1,82 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tool
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Citation is a node and the lines of it which an answer is based on
+type Citation struct {
+	// RepoName is the repo of the node, can be omitted if there is only one repo
+	RepoName string `json:"repo_name,omitempty"`
+	NodeID
+	File      string `json:"file"`
+	StartLine int    `json:"start_line"`
+	// EndLine is the last cited line, the same as StartLine if 0
+	EndLine int `json:"end_line,omitempty"`
+}
+
+func (c Citation) String() string {
+	id := c.Identity().Full()
+	if c.RepoName != "" {
+		id = c.RepoName + ":" + id
+	}
+	if c.EndLine > c.StartLine {
+		return fmt.Sprintf("%s (%s:%d-%d)", id, c.File, c.StartLine, c.EndLine)
+	}
+	return fmt.Sprintf("%s (%s:%d)", id, c.File, c.StartLine)
+}
+
+// VerifyCitation checks the cited node exists in the repo, and the cited lines are within the node in its file.
+// The repo name of the citation is resolved to the full one
+func (t *ASTReadTools) VerifyCitation(c *Citation) error {
+	name, err := t.repos.Resolve(c.RepoName)
+	if err != nil {
+		return err
+	}
+	repo, err := t.repos.Get(name)
+	if err != nil {
+		return err
+	}
+	c.RepoName = name
+	node := repo.GetNode(c.Identity())
+	if node == nil {
+		return fmt.Errorf("node %s not found in repo %s", c.Identity().Full(), name)
+	}
+	fl := node.FileLine()
+	if fl.File == "" {
+		return fmt.Errorf("node %s has no source location", c.Identity().Full())
+	}
+	if filepath.ToSlash(filepath.Clean(c.File)) != filepath.ToSlash(filepath.Clean(fl.File)) {
+		return fmt.Errorf("node %s is in file %s, not %s", c.Identity().Full(), fl.File, c.File)
+	}
+	last := fl.EndLine
+	if last == 0 {
+		last = fl.Line + strings.Count(node.Content(), "\n")
+	}
+	end := c.EndLine
+	if end == 0 {
+		end = c.StartLine
+	}
+	if c.StartLine < fl.Line || end > last || end < c.StartLine {
+		return fmt.Errorf("lines %d-%d are out of node %s, which spans lines %d-%d", c.StartLine, end, c.Identity().Full(), fl.Line, last)
+	}
+	return nil
+}
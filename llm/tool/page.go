@@ -0,0 +1,113 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// PageReq pages the items of a response, to keep it within the context of the model
+type PageReq struct {
+	Page     int `json:"page,omitempty" jsonschema:"description=the page to return starting from 1. Default to 1,default=1"`
+	PageSize int `json:"page_size,omitempty" jsonschema:"description=the max number of items in a page. All items if 0"`
+	MaxBytes int `json:"max_bytes,omitempty" jsonschema:"description=the max bytes of the items in a page. If exceeded the page is shrunk and marked as truncated. No limit if 0"`
+}
+
+// PageResp tells the position of the returned page
+type PageResp struct {
+	Page      int    `json:"page,omitempty" jsonschema:"description=the returned page"`
+	PageSize  int    `json:"page_size,omitempty" jsonschema:"description=the page size of the returned page. Smaller than the requested one if the page is truncated"`
+	Total     int    `json:"total,omitempty" jsonschema:"description=the number of items in all pages"`
+	NextPage  int    `json:"next_page,omitempty" jsonschema:"description=the page to request next with the returned page_size. Absent at the last page"`
+	Truncated string `json:"truncated,omitempty" jsonschema:"description=the truncation marker which tells what is omitted due to max_bytes, or that the items are partial since the call timed out"`
+}
+
+// paginate returns the items in the requested page, at least one item is returned if the page is not empty.
+// If the items exceed max bytes, the page size is reduced to a divisor of the requested one,
+// thus the following items can still be got by next_page.
+func paginate[T any](items []T, req PageReq, maxBytes int, resp *PageResp) []T {
+	total := len(items)
+	page, size := req.Page, req.PageSize
+	if page <= 0 {
+		page = 1
+	}
+	if size <= 0 || size > total {
+		size, page = total, 1
+	}
+	if req.MaxBytes > 0 {
+		maxBytes = req.MaxBytes
+	}
+	resp.Total = total
+	if total == 0 {
+		return items
+	}
+
+	start := (page - 1) * size
+	if start >= total {
+		resp.Page, resp.PageSize = page, size
+		resp.Truncated = fmt.Sprintf("page %d is out of range, there are %d items", page, total)
+		return items[:0]
+	}
+	end := min(start+size, total)
+
+	if n := fitBytes(items[start:end], maxBytes); n < end-start {
+		// the new size must divide the old one, so that start is still the beginning of a page
+		fit := n
+		for start%fit != 0 {
+			fit--
+		}
+		resp.Truncated = fmt.Sprintf("only %d of %d items fit in %d bytes, the page is shrunk to page_size=%d", n, end-start, maxBytes, fit)
+		size, page, end = fit, start/fit+1, start+fit
+	}
+	resp.Page, resp.PageSize = page, size
+	if end < total {
+		resp.NextPage = page + 1
+	}
+	return items[start:end]
+}
+
+// markPartial marks the page as truncated since the collection of the items was stopped by ctx,
+// thus a timed-out or cancelled call still returns the items collected so far
+func markPartial(ctx context.Context, resp *PageResp) {
+	msg := fmt.Sprintf("the items are partial since the call was stopped: %v", context.Cause(ctx))
+	if resp.Truncated != "" {
+		msg = resp.Truncated + "; " + msg
+	}
+	resp.Truncated = msg
+}
+
+// fitBytes returns how many items fit in max bytes when marshaled as JSON, at least 1
+func fitBytes[T any](items []T, maxBytes int) int {
+	if maxBytes <= 0 {
+		return len(items)
+	}
+	n := 0
+	for i, it := range items {
+		n += jsonSize(it) + 1
+		if n > maxBytes && i > 0 {
+			return i
+		}
+	}
+	return len(items)
+}
+
+func jsonSize(v any) int {
+	bs, _ := json.Marshal(v)
+	return len(bs)
+}
@@ -0,0 +1,136 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tool
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_paginate(t *testing.T) {
+	items := []string{"aaaa", "bbbb", "cccc", "dddd", "eeee", "ffff", "gggg"}
+	tests := []struct {
+		name      string
+		req       PageReq
+		want      []string
+		wantPage  PageResp
+		truncated bool
+	}{
+		{"all", PageReq{}, items, PageResp{Page: 1, PageSize: 7, Total: 7}, false},
+		{"first", PageReq{Page: 1, PageSize: 3}, items[:3], PageResp{Page: 1, PageSize: 3, Total: 7, NextPage: 2}, false},
+		{"last", PageReq{Page: 3, PageSize: 3}, items[6:], PageResp{Page: 3, PageSize: 3, Total: 7}, false},
+		{"out of range", PageReq{Page: 4, PageSize: 3}, []string{}, PageResp{Page: 4, PageSize: 3, Total: 7}, true},
+		// each item takes 7 bytes, 2 items fit in 15 bytes
+		{"shrunk", PageReq{MaxBytes: 15}, items[:2], PageResp{Page: 1, PageSize: 2, Total: 7, NextPage: 2}, true},
+		// the page starts from the 4th item, the size is shrunk to 1 which divides 3
+		{"shrunk aligned", PageReq{Page: 2, PageSize: 3, MaxBytes: 15}, items[3:4], PageResp{Page: 4, PageSize: 1, Total: 7, NextPage: 5}, true},
+		{"at least one", PageReq{MaxBytes: 1}, items[:1], PageResp{Page: 1, PageSize: 1, Total: 7, NextPage: 2}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resp PageResp
+			got := paginate(items, tt.req, 0, &resp)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("paginate() = %v, want %v", got, tt.want)
+			}
+			if (resp.Truncated != "") != tt.truncated {
+				t.Errorf("paginate() truncated = %q, want %v", resp.Truncated, tt.truncated)
+			}
+			resp.Truncated = ""
+			if resp != tt.wantPage {
+				t.Errorf("paginate() page = %+v, want %+v", resp, tt.wantPage)
+			}
+		})
+	}
+}
+
+func TestASTTools_Paging(t *testing.T) {
+	tr := NewASTReadTools(ASTReadToolsOptions{RepoASTsDir: "../../testdata/asts"})
+	ctx := context.Background()
+
+	all, err := tr.GetRepoStructure(ctx, GetRepoStructReq{RepoName: "localsession"})
+	if err != nil || all.Error != "" {
+		t.Fatal(err, all.Error)
+	}
+	pkgs := tr.pagePackages(t, "localsession", 2)
+	var want []string
+	for _, m := range all.Modules {
+		for _, p := range m.Packages {
+			want = append(want, string(p.PkgPath))
+		}
+	}
+	if len(want) != all.Total || !reflect.DeepEqual(pkgs, want) {
+		t.Errorf("paged packages = %v, want %v", pkgs, want)
+	}
+
+	node, err := tr.GetASTNode(ctx, GetASTNodeReq{
+		RepoName: "localsession",
+		NodeIDs: []NodeID{
+			{ModPath: "github.com/cloudwego/localsession", PkgPath: "github.com/cloudwego/localsession/backup", Name: "RecoverCtxOnDemands"},
+			{ModPath: "github.com/cloudwego/localsession", PkgPath: "github.com/cloudwego/localsession", Name: "CurSession"},
+		},
+		PageReq: PageReq{MaxBytes: 1500},
+	})
+	if err != nil || node.Error != "" {
+		t.Fatal(err, node.Error)
+	}
+	if len(node.Nodes) != 1 || node.NextPage != 2 || node.Truncated == "" {
+		t.Fatalf("GetASTNode() = %+v", node)
+	}
+	if n := node.Nodes[0]; n.Packed != "truncated" || !strings.Contains(n.Codes, "bytes truncated)") || jsonSize(n) > 1500 {
+		t.Errorf("GetASTNode() node = %+v", n)
+	}
+}
+
+func TestASTTools_Stopped(t *testing.T) {
+	tr := NewASTReadTools(ASTReadToolsOptions{RepoASTsDir: "../../testdata/asts"})
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cancel(errors.New("timed out"))
+
+	repo, err := tr.GetRepoStructure(ctx, GetRepoStructReq{RepoName: "localsession"})
+	if err != nil || len(repo.Modules) != 0 || !strings.Contains(repo.Truncated, "the items are partial since the call was stopped: timed out") {
+		t.Errorf("GetRepoStructure() = %+v, %v", repo, err)
+	}
+	pkg, err := tr.GetPackageStructure(ctx, GetPackageStructReq{RepoName: "localsession", PkgPath: "github.com/cloudwego/localsession"})
+	if err != nil || pkg.Error != "the call was stopped before any file was collected: timed out" {
+		t.Errorf("GetPackageStructure() = %+v, %v", pkg, err)
+	}
+	sym, err := tr.FindSymbolAcrossRepos(ctx, FindSymbolAcrossReposReq{Name: "CurSession", RepoNames: []string{"localsession"}})
+	if err != nil || !reflect.DeepEqual(sym.Unsearched, []string{"localsession"}) || !strings.Contains(sym.Truncated, "the items are partial since the call was stopped: timed out") {
+		t.Errorf("FindSymbolAcrossRepos() = %+v, %v", sym, err)
+	}
+}
+
+// pagePackages lists the packages of the repo page by page
+func (t *ASTReadTools) pagePackages(tb testing.TB, repo string, size int) (ret []string) {
+	for page := 1; page > 0; {
+		resp, err := t.GetRepoStructure(context.Background(), GetRepoStructReq{RepoName: repo, PageReq: PageReq{Page: page, PageSize: size}})
+		if err != nil || resp.Error != "" {
+			tb.Fatal(err, resp.Error)
+		}
+		for _, m := range resp.Modules {
+			for _, p := range m.Packages {
+				ret = append(ret, string(p.PkgPath))
+			}
+		}
+		page = resp.NextPage
+	}
+	return ret
+}
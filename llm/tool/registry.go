@@ -0,0 +1,255 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tool
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/abcoder/lang/uniast"
+	"gopkg.in/yaml.v3"
+)
+
+// RegistryFile is the file under the AST directory recording the repos registered by `abcoder repos add`.
+// It is not a *.json file, thus it is not taken as an AST
+const RegistryFile = "repos.yaml"
+
+// RegisteredRepo is an AST file registered in the AST directory with a friendly name
+type RegisteredRepo struct {
+	// Name is the friendly name, usable as repo_name of the tools
+	Name string `yaml:"name" json:"name"`
+	// Repo is the id of the repository in the AST
+	Repo string `yaml:"repo" json:"repo"`
+	// File is the AST file relative to the AST directory
+	File string `yaml:"file" json:"file"`
+	// Source is the path of the parsed repository
+	Source string `yaml:"source,omitempty" json:"source,omitempty"`
+	// Commit is the source commit the AST is parsed from, empty if unknown
+	Commit string `yaml:"commit,omitempty" json:"commit,omitempty"`
+	// Dirty tells if the sources had uncommitted changes when parsed
+	Dirty bool `yaml:"dirty,omitempty" json:"dirty,omitempty"`
+	// ParsedAt is when the AST is written
+	ParsedAt time.Time `yaml:"parsed_at" json:"parsed_at"`
+}
+
+// Registry is the repos registered in an AST directory
+type Registry struct {
+	dir   string
+	Repos []RegisteredRepo `yaml:"repos"`
+}
+
+// DefaultASTsDir is the AST directory used by `abcoder repos` if not given, ~/.abcoder/asts
+func DefaultASTsDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".abcoder", "asts")
+	}
+	return filepath.Join(home, ".abcoder", "asts")
+}
+
+// LoadRegistry reads the registry of the AST directory, which is empty if the directory has none
+func LoadRegistry(dir string) (*Registry, error) {
+	ret := &Registry{dir: dir}
+	bs, err := os.ReadFile(filepath.Join(dir, RegistryFile))
+	if os.IsNotExist(err) {
+		return ret, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(bs, ret); err != nil {
+		return nil, fmt.Errorf("parse %s failed: %v", filepath.Join(dir, RegistryFile), err)
+	}
+	return ret, nil
+}
+
+// Save writes the registry into the AST directory
+func (r *Registry) Save() error {
+	bs, err := yaml.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(r.dir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(r.dir, RegistryFile), bs, 0644)
+}
+
+// Get returns the registered repo by its name or repo id, nil if not found
+func (r *Registry) Get(name string) *RegisteredRepo {
+	for i := range r.Repos {
+		if r.Repos[i].Name == name {
+			return &r.Repos[i]
+		}
+	}
+	for i := range r.Repos {
+		if r.Repos[i].Repo == name {
+			return &r.Repos[i]
+		}
+	}
+	return nil
+}
+
+// Names returns the sorted names of the registered repos
+func (r *Registry) Names() []string {
+	ret := make([]string, 0, len(r.Repos))
+	for _, repo := range r.Repos {
+		ret = append(ret, repo.Name)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// Aliases maps the names to the repo ids, see ASTReadToolsOptions.RepoAliases
+func (r *Registry) Aliases() map[string]string {
+	ret := make(map[string]string, len(r.Repos))
+	for _, repo := range r.Repos {
+		if repo.Name != repo.Repo {
+			ret[repo.Name] = repo.Repo
+		}
+	}
+	return ret
+}
+
+// Add registers the AST file by the name, which is the base name of the repo id if empty.
+// The file is copied into the AST directory as <name>.json unless it is already there.
+// If commit is empty, the source commit is the one recorded in the AST, or read from the git repository at the path of the AST
+func (r *Registry) Add(file, name, commit string) (*RegisteredRepo, error) {
+	header, err := uniast.LoadRepoHeader(file)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		name = filepath.Base(header.Name)
+	}
+	if name == "" || name == "." || strings.ContainsAny(name, `/\`) {
+		return nil, fmt.Errorf("invalid repo name %q, it must be a valid file name", name)
+	}
+	var dirty bool
+	if commit == "" && header.VCS != nil {
+		commit, dirty = header.VCS.Commit, header.VCS.Dirty
+	} else if commit == "" {
+		commit = gitCommit(header.Path)
+	}
+	if old := r.Get(name); old != nil && old.Name == name && old.Repo != header.Name {
+		return nil, fmt.Errorf("name %s is already registered for repo %s, remove it first", name, old.Repo)
+	}
+
+	dst := filepath.Join(r.dir, name+".json")
+	if !sameFile(file, dst) {
+		if err := copyFile(file, dst); err != nil {
+			return nil, err
+		}
+	}
+	entry := RegisteredRepo{
+		Name:     name,
+		Repo:     header.Name,
+		File:     name + ".json",
+		Source:   header.Path,
+		Commit:   commit,
+		Dirty:    dirty,
+		ParsedAt: info.ModTime().UTC().Truncate(time.Second),
+	}
+	if old := r.Get(name); old != nil && old.Name == name {
+		*old = entry
+	} else {
+		r.Repos = append(r.Repos, entry)
+	}
+	sort.Slice(r.Repos, func(i, j int) bool { return r.Repos[i].Name < r.Repos[j].Name })
+	return r.Get(name), nil
+}
+
+// Remove unregisters the repo by its name or repo id, and deletes its AST file
+func (r *Registry) Remove(name string) (*RegisteredRepo, error) {
+	repo := r.Get(name)
+	if repo == nil {
+		return nil, fmt.Errorf("repo %s is not registered", name)
+	}
+	removed := *repo
+	if err := os.Remove(filepath.Join(r.dir, removed.File)); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for i := range r.Repos {
+		if r.Repos[i].Name == removed.Name {
+			r.Repos = append(r.Repos[:i], r.Repos[i+1:]...)
+			break
+		}
+	}
+	return &removed, nil
+}
+
+// gitCommit returns the HEAD commit of the git repository at dir, empty if it is not one
+func gitCommit(dir string) string {
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return ""
+	}
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func sameFile(a, b string) bool {
+	ia, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	ib, err := os.Stat(b)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(ia, ib)
+}
+
+// copyFile copies src to dst through a temp file, thus the watchers never see a half-written AST
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dst), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, in); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dst)
+}
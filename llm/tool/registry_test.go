@@ -0,0 +1,80 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tool
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(t.TempDir(), "ast.json")
+	if err := os.WriteFile(src, []byte(`{"id":"github.com/a/b","Path":"/nonexistent","VCS":{"Type":"git","Commit":"abc123","Branch":"main","Dirty":true},"Modules":{}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	reg, err := LoadRegistry(dir)
+	if err != nil || len(reg.Repos) != 0 {
+		t.Fatal(err, reg)
+	}
+	// the commit is recorded in the AST
+	repo, err := reg.Add(src, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repo.Name != "b" || repo.Repo != "github.com/a/b" || repo.File != "b.json" || repo.Commit != "abc123" || !repo.Dirty || repo.ParsedAt.IsZero() {
+		t.Errorf("added = %+v", repo)
+	}
+	if _, err := reg.Add(src, "bad/name", ""); err == nil {
+		t.Errorf("names with path separators should be rejected")
+	}
+	if err := reg.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the name is an alias of the repo for the tools
+	tools := NewASTReadTools(ASTReadToolsOptions{RepoASTsDir: dir})
+	if name, err := tools.ResolveRepo("b"); err != nil || name != "github.com/a/b" {
+		t.Errorf("resolve = %v, %v", name, err)
+	}
+	list, _ := tools.ListRepos(context.Background(), ListReposReq{})
+	want := []RepoInfo{{RepoName: "github.com/a/b", Commit: "abc123", Branch: "main", Dirty: true}}
+	if !reflect.DeepEqual(list.Repos, want) || list.Aliases["b"] != "github.com/a/b" {
+		t.Errorf("list repos = %+v", list)
+	}
+
+	reg, err = LoadRegistry(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(reg.Names(), []string{"b"}) {
+		t.Errorf("names = %v", reg.Names())
+	}
+	if _, err := reg.Remove("github.com/a/b"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "b.json")); !os.IsNotExist(err) {
+		t.Errorf("the AST file should be deleted: %v", err)
+	}
+	if _, err := reg.Remove("b"); err == nil {
+		t.Errorf("removing an unregistered repo should fail")
+	}
+}
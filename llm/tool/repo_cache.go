@@ -0,0 +1,242 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tool
+
+import (
+	"container/list"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/cloudwego/abcoder/lang/uniast"
+)
+
+// repoCache indexes the AST files by repo names, and decodes them lazily on first use.
+// At most max decoded repos are kept, the least recently used ones are evicted.
+type repoCache struct {
+	mu      sync.Mutex
+	max     int
+	aliases map[string]string
+	// repo name => AST file
+	files map[string]string
+	// repo name => the fields before Modules, see uniast.LoadRepoHeader
+	headers map[string]*uniast.Repository
+	// decoded repos, the most recently used first
+	lru     *list.List
+	entries map[string]*list.Element
+}
+
+type repoEntry struct {
+	name string
+	file string
+	once sync.Once
+	repo *uniast.Repository
+	err  error
+}
+
+func newRepoCache(max int, aliases map[string]string) *repoCache {
+	return &repoCache{
+		max:     max,
+		aliases: aliases,
+		files:   map[string]string{},
+		headers: map[string]*uniast.Repository{},
+		lru:     list.New(),
+		entries: map[string]*list.Element{},
+	}
+}
+
+// RepoOp tells how an indexed repo changed
+type RepoOp string
+
+const (
+	RepoAdded   RepoOp = "added"
+	RepoUpdated RepoOp = "updated"
+	RepoRemoved RepoOp = "removed"
+)
+
+// RepoEvent tells a repo is added, updated or removed since its AST file changed
+type RepoEvent struct {
+	RepoName string `json:"repo_name"`
+	Op       RepoOp `json:"op"`
+}
+
+// Add indexes the AST file, dropping the decoded repo of the file if any.
+// It returns the changes of the indexed repos, the file may have been renamed to another repo
+func (c *repoCache) Add(file string) ([]RepoEvent, error) {
+	header, err := uniast.LoadRepoHeader(file)
+	if err != nil {
+		return nil, err
+	}
+	if header.Name == "" {
+		return nil, fmt.Errorf("id not found in %s", file)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, updated := c.files[header.Name]
+	var ret []RepoEvent
+	for _, name := range c.removeFileLocked(file) {
+		if name != header.Name {
+			ret = append(ret, RepoEvent{RepoName: name, Op: RepoRemoved})
+		}
+	}
+	c.removeNameLocked(header.Name)
+	c.files[header.Name] = file
+	c.headers[header.Name] = header
+	if updated {
+		ret = append(ret, RepoEvent{RepoName: header.Name, Op: RepoUpdated})
+	} else {
+		ret = append(ret, RepoEvent{RepoName: header.Name, Op: RepoAdded})
+	}
+	return ret, nil
+}
+
+// Remove drops the AST file from the index, and returns the removed repos
+func (c *repoCache) Remove(file string) []RepoEvent {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var ret []RepoEvent
+	for _, name := range c.removeFileLocked(file) {
+		ret = append(ret, RepoEvent{RepoName: name, Op: RepoRemoved})
+	}
+	return ret
+}
+
+// removeFileLocked drops the repos of the file and returns their names
+func (c *repoCache) removeFileLocked(file string) []string {
+	var ret []string
+	for name, f := range c.files {
+		if f != file {
+			continue
+		}
+		c.removeNameLocked(name)
+		ret = append(ret, name)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+func (c *repoCache) removeNameLocked(name string) {
+	delete(c.files, name)
+	delete(c.headers, name)
+	if e := c.entries[name]; e != nil {
+		c.lru.Remove(e)
+		delete(c.entries, name)
+	}
+}
+
+// Names returns the sorted names of all indexed repos
+func (c *repoCache) Names() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ret := make([]string, 0, len(c.files))
+	for name := range c.files {
+		ret = append(ret, name)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// Headers returns the headers of all indexed repos sorted by names, without decoding the ASTs
+func (c *repoCache) Headers() []*uniast.Repository {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ret := make([]*uniast.Repository, 0, len(c.headers))
+	for _, h := range c.headers {
+		ret = append(ret, h)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Name < ret[j].Name })
+	return ret
+}
+
+// Resolve returns the name of the repo which the repo name (maybe an alias or a part of the name) refers to
+func (c *repoCache) Resolve(repoName string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.resolveLocked(repoName)
+}
+
+// Get resolves the repo name and returns the decoded repo
+func (c *repoCache) Get(repoName string) (*uniast.Repository, error) {
+	c.mu.Lock()
+	name, err := c.resolveLocked(repoName)
+	if err != nil {
+		c.mu.Unlock()
+		return nil, err
+	}
+	var entry *repoEntry
+	if e := c.entries[name]; e != nil {
+		c.lru.MoveToFront(e)
+		entry = e.Value.(*repoEntry)
+	} else {
+		entry = &repoEntry{name: name, file: c.files[name]}
+		c.entries[name] = c.lru.PushFront(entry)
+		for c.max > 0 && c.lru.Len() > c.max {
+			last := c.lru.Back()
+			c.lru.Remove(last)
+			delete(c.entries, last.Value.(*repoEntry).name)
+		}
+	}
+	c.mu.Unlock()
+
+	// decode out of the lock, thus other repos are not blocked
+	entry.once.Do(func() {
+		entry.repo, entry.err = uniast.LoadRepo(entry.file)
+	})
+	if entry.err != nil {
+		c.mu.Lock()
+		if e := c.entries[name]; e != nil && e.Value == entry {
+			c.lru.Remove(e)
+			delete(c.entries, name)
+		}
+		c.mu.Unlock()
+		return nil, fmt.Errorf("load repo '%s' failed: %v", name, entry.err)
+	}
+	return entry.repo, nil
+}
+
+// resolveLocked finds the repo name by, in order: the exact name, the alias,
+// the unique name with the prefix, and the unique name containing it
+func (c *repoCache) resolveLocked(repoName string) (string, error) {
+	if _, ok := c.files[repoName]; ok {
+		return repoName, nil
+	}
+	if name, ok := c.aliases[repoName]; ok {
+		if _, ok := c.files[name]; ok {
+			return name, nil
+		}
+		return "", fmt.Errorf("repo '%s' (alias of '%s') not found. Use `list_repos` to get valid repo_name", name, repoName)
+	}
+	for _, match := range []func(string) bool{
+		func(name string) bool { return strings.HasPrefix(name, repoName) },
+		func(name string) bool { return strings.Contains(name, repoName) },
+	} {
+		var candis []string
+		for name := range c.files {
+			if match(name) {
+				candis = append(candis, name)
+			}
+		}
+		if len(candis) == 1 {
+			return candis[0], nil
+		} else if len(candis) > 1 {
+			sort.Strings(candis)
+			return "", fmt.Errorf("repo '%s' is ambiguous, maybe you want one of %v", repoName, candis)
+		}
+	}
+	return "", fmt.Errorf("repo '%s' not found. Use `list_repos` to get valid repo_name", repoName)
+}
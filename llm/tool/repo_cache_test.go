@@ -0,0 +1,100 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tool
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/abcoder/lang/uniast"
+)
+
+func TestRepoCache(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string) string {
+		bs, err := json.Marshal(uniast.NewRepository(name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		f := filepath.Join(dir, strings.ReplaceAll(name, "/", "_")+".json")
+		if err := os.WriteFile(f, bs, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return f
+	}
+	c := newRepoCache(2, map[string]string{"svc": "github.com/a/service"})
+	for _, name := range []string{"github.com/a/service", "github.com/a/server", "github.com/b/lib"} {
+		if _, err := c.Add(write(name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := c.Names(); !reflect.DeepEqual(got, []string{"github.com/a/server", "github.com/a/service", "github.com/b/lib"}) {
+		t.Errorf("Names() = %v", got)
+	}
+	if c.lru.Len() != 0 {
+		t.Error("repos should be loaded lazily")
+	}
+
+	tests := []struct {
+		name    string
+		want    string
+		wantErr string
+	}{
+		{"github.com/b/lib", "github.com/b/lib", ""},
+		{"svc", "github.com/a/service", ""},
+		{"github.com/a/serve", "github.com/a/server", ""},
+		{"github.com/a/serv", "", "ambiguous"},
+		{"github.com/b", "github.com/b/lib", ""},
+		{"server", "github.com/a/server", ""},
+		{"nothing", "", "not found"},
+	}
+	for _, tt := range tests {
+		repo, err := c.Get(tt.name)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Get(%s) err = %v, want %s", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || repo.Name != tt.want {
+			t.Errorf("Get(%s) = %v, %v, want %s", tt.name, repo, err, tt.want)
+		}
+	}
+	// only the 2 most recently used are kept
+	if c.lru.Len() != 2 || c.entries["github.com/b/lib"] == nil || c.entries["github.com/a/server"] == nil {
+		t.Errorf("loaded repos = %v", c.entries)
+	}
+
+	if events, err := c.Add(write("github.com/a/server")); err != nil || !reflect.DeepEqual(events, []RepoEvent{{"github.com/a/server", RepoUpdated}}) {
+		t.Errorf("Add() = %v, %v, want updated", events, err)
+	}
+	if c.entries["github.com/a/server"] != nil {
+		t.Error("the decoded repo should be dropped once updated")
+	}
+
+	events := c.Remove(filepath.Join(dir, "github.com_b_lib.json"))
+	if !reflect.DeepEqual(events, []RepoEvent{{"github.com/b/lib", RepoRemoved}}) {
+		t.Errorf("Remove() = %v, want removed", events)
+	}
+	if _, err := c.Get("github.com/b/lib"); err == nil || c.entries["github.com/b/lib"] != nil {
+		t.Error("removed repo should not be found")
+	}
+}
@@ -0,0 +1,166 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tool
+
+import (
+	"context"
+	"time"
+
+	abutil "github.com/cloudwego/abcoder/internal/utils"
+	"github.com/cloudwego/abcoder/lang/runner"
+	"github.com/cloudwego/abcoder/llm/log"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+)
+
+const (
+	ToolRunBuild = "run_build"
+	DescRunBuild = "[VALIDATION] Compile and test the written codes of a repository. Input: repo_name from list_repos output. Output: success, diagnostics with file:line and the node_id where each error locates."
+)
+
+var (
+	SchemaRunBuild = GetJSONSchema(RunBuildReq{})
+)
+
+// max bytes of command output returned to the model, only the tail is kept
+const maxRunOutput = 4 << 10
+
+type RunnerToolsOptions struct {
+	// Dir where the commands run, usually the written output dir. If empty, use the repo path
+	Dir string
+	// Commands to run, like `go build ./...`. If empty, use the default commands of the repo language
+	Commands []string
+	// Timeout of each command
+	Timeout time.Duration
+}
+
+// RunnerTools lets the agent validate its edits by compiling and testing the codes.
+// Repos are looked up from the ASTReadTools.
+type RunnerTools struct {
+	opts  RunnerToolsOptions
+	ast   *ASTReadTools
+	tools map[string]tool.InvokableTool
+}
+
+func NewRunnerTools(ast *ASTReadTools, opts RunnerToolsOptions) *RunnerTools {
+	ret := &RunnerTools{
+		opts:  opts,
+		ast:   ast,
+		tools: map[string]tool.InvokableTool{},
+	}
+
+	tt, err := utils.InferTool(ToolRunBuild,
+		DescRunBuild,
+		ret.RunBuild, utils.WithMarshalOutput(func(ctx context.Context, output interface{}) (string, error) {
+			return abutil.MarshalJSONIndent(output)
+		}))
+	if err != nil {
+		panic(err)
+	}
+	ret.tools[ToolRunBuild] = tt
+	return ret
+}
+
+func (t *RunnerTools) GetTools() []Tool {
+	ret := make([]Tool, 0, len(t.tools))
+	for _, tt := range t.tools {
+		ret = append(ret, tt)
+	}
+	return ret
+}
+
+func (t *RunnerTools) GetTool(name string) Tool {
+	return t.tools[name]
+}
+
+type RunBuildReq struct {
+	RepoName string `json:"repo_name" jsonschema:"description=the name of the repository (output of list_repos tool)"`
+}
+
+type RunBuildResp struct {
+	Success bool            `json:"success" jsonschema:"description=whether all the commands succeeded"`
+	Results []CommandResult `json:"results,omitempty" jsonschema:"description=the results of executed commands, stop at the first failed one"`
+	Error   string          `json:"error,omitempty" jsonschema:"description=the error message"`
+}
+
+type CommandResult struct {
+	Command     string           `json:"command" jsonschema:"description=the executed command"`
+	Success     bool             `json:"success" jsonschema:"description=whether the command succeeded"`
+	Output      string           `json:"output,omitempty" jsonschema:"description=the tail of the command output"`
+	Diagnostics []DiagnosticNode `json:"diagnostics,omitempty" jsonschema:"description=the file:line errors reported by the command"`
+}
+
+type DiagnosticNode struct {
+	File    string  `json:"file" jsonschema:"description=the file path relative to the repo"`
+	Line    int     `json:"line" jsonschema:"description=the line of the error"`
+	Column  int     `json:"column,omitempty" jsonschema:"description=the column of the error"`
+	Message string  `json:"message" jsonschema:"description=the error message"`
+	NodeID  *NodeID `json:"node_id,omitempty" jsonschema:"description=the ast node where the error locates, can be passed to get_ast_node"`
+}
+
+func (t *RunnerTools) RunBuild(ctx context.Context, req RunBuildReq) (*RunBuildResp, error) {
+	log.Debug("run build, req: %v", abutil.MarshalJSONIndentNoError(req))
+	repo, err := t.ast.getRepoAST(req.RepoName)
+	if err != nil {
+		return &RunBuildResp{Error: err.Error()}, nil
+	}
+
+	opts := runner.Options{
+		Dir:     t.opts.Dir,
+		Timeout: t.opts.Timeout,
+	}
+	if opts.Dir == "" {
+		opts.Dir = repo.Path
+	}
+	for _, c := range t.opts.Commands {
+		opts.Commands = append(opts.Commands, runner.Command(c))
+	}
+	results, err := runner.NewRunner(repo, opts).Run(ctx)
+	if err != nil {
+		return &RunBuildResp{Error: err.Error()}, nil
+	}
+
+	resp := &RunBuildResp{Success: true}
+	for _, r := range results {
+		cr := CommandResult{
+			Command: string(r.Command),
+			Success: r.Success,
+		}
+		if !r.Success {
+			resp.Success = false
+			cr.Output = r.Output
+			if len(cr.Output) > maxRunOutput {
+				cr.Output = "..." + cr.Output[len(cr.Output)-maxRunOutput:]
+			}
+		}
+		for _, d := range r.Diagnostics {
+			dn := DiagnosticNode{
+				File:    d.File,
+				Line:    d.Line,
+				Column:  d.Column,
+				Message: d.Message,
+			}
+			if d.Node != nil {
+				id := NewNodeID(*d.Node)
+				dn.NodeID = &id
+			}
+			cr.Diagnostics = append(cr.Diagnostics, dn)
+		}
+		resp.Results = append(resp.Results, cr)
+	}
+	return resp, nil
+}
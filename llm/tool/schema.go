@@ -18,19 +18,269 @@ package tool
 
 import (
 	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
 
 	"github.com/invopop/jsonschema"
 )
 
+// GetJSONSchema reflects the JSON schema of a tool request from its struct tags.
+// Besides `json`, the `jsonschema` tags tell the keywords, e.g. `description=...,enum=a,enum=b,default=a`.
+// A field is required unless its json tag has `omitempty`, and an optional struct pointer (like *NodeID) tagged
+// `nullable` is expressed as oneOf the object and null. Unlike github.com/invopop/jsonschema,
+// the descriptions may contain commas
 func GetJSONSchema(sch any) json.RawMessage {
 	rt := &jsonschema.Reflector{
 		DoNotReference: true,
 		Anonymous:      true,
 	}
 	schema := rt.Reflect(sch)
+	describe(schema, reflect.TypeOf(sch))
 	js, err := json.MarshalIndent(schema, "", "  ")
 	if err != nil {
 		panic(err)
 	}
 	return json.RawMessage(js)
 }
+
+// schemaKeywords are the keywords of the jsonschema tags, the other segments split by commas belong to the descriptions
+var schemaKeywords = []string{"title=", "description=", "enum=", "default=", "example=", "minimum=", "maximum=",
+	"minLength=", "maxLength=", "pattern=", "format=", "minItems=", "maxItems=", "oneof_type=", "required", "nullable"}
+
+// schemaDescription returns the description in the jsonschema tag, including its commas
+func schemaDescription(tag string) string {
+	var desc []string
+	in := false
+	for _, seg := range strings.Split(tag, ",") {
+		keyword := false
+		for _, k := range schemaKeywords {
+			if seg == k || (strings.HasSuffix(k, "=") && strings.HasPrefix(seg, k)) {
+				keyword = true
+				break
+			}
+		}
+		switch {
+		case strings.HasPrefix(seg, "description="):
+			in = true
+			desc = append(desc, strings.TrimPrefix(seg, "description="))
+		case keyword:
+			in = false
+		case in:
+			desc = append(desc, seg)
+		}
+	}
+	return strings.Join(desc, ",")
+}
+
+// describe sets the whole descriptions of the properties of the struct type recursively,
+// which are cut at the first commas by the reflector
+func describe(s *jsonschema.Schema, t reflect.Type) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		if s.Items != nil {
+			describe(s.Items, t.Elem())
+		}
+		return
+	case reflect.Struct:
+	default:
+		return
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if f.Anonymous && name == "" {
+			describe(s, f.Type)
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		if !f.IsExported() || name == "-" || s.Properties == nil {
+			continue
+		}
+		prop, ok := s.Properties.Get(name)
+		if !ok {
+			continue
+		}
+		if desc := schemaDescription(f.Tag.Get("jsonschema")); desc != "" {
+			prop.Description = desc
+		}
+		// the description is set to the inner schema of a nullable field
+		for _, alt := range prop.OneOf {
+			if alt.Type != "null" {
+				alt.Description = ""
+				describe(alt, f.Type)
+			}
+		}
+		describe(prop, f.Type)
+	}
+}
+
+// ValidateArguments checks the arguments of a tool call against the schema of the tool, see GetJSONSchema.
+// The error tells which argument is wrong and what is expected, thus the caller can correct it.
+// args is the decoded JSON object, or the raw JSON
+func ValidateArguments(schema *jsonschema.Schema, args any) error {
+	switch v := args.(type) {
+	case nil:
+		args = map[string]any{}
+	case map[string]any:
+		if v == nil {
+			args = map[string]any{}
+		}
+	case json.RawMessage, []byte, string:
+		var raw []byte
+		switch v := v.(type) {
+		case json.RawMessage:
+			raw = v
+		case []byte:
+			raw = v
+		case string:
+			raw = []byte(v)
+		}
+		if len(raw) == 0 {
+			args = map[string]any{}
+		} else if err := json.Unmarshal(raw, &args); err != nil {
+			return fmt.Errorf("the arguments are not a JSON object: %v", err)
+		}
+	}
+	return validateValue(schema, args, "")
+}
+
+func validateValue(s *jsonschema.Schema, v any, path string) error {
+	if s == nil {
+		return nil
+	}
+	name := path
+	if name == "" {
+		name = "the arguments"
+	}
+	if len(s.OneOf) > 0 {
+		var first error
+		for _, alt := range s.OneOf {
+			err := validateValue(alt, v, path)
+			if err == nil {
+				return nil
+			}
+			if first == nil && alt.Type != "null" {
+				first = err
+			}
+		}
+		return first
+	}
+	if s.Type != "" && !isType(v, s.Type) {
+		js, _ := json.Marshal(v)
+		return fmt.Errorf("%s must be %s %s, got %s", name, article(s.Type), s.Type, js)
+	}
+	if len(s.Enum) > 0 {
+		for _, e := range s.Enum {
+			if fmt.Sprint(e) == fmt.Sprint(v) {
+				return nil
+			}
+		}
+		js, _ := json.Marshal(v)
+		enums, _ := json.Marshal(s.Enum)
+		return fmt.Errorf("%s must be one of %s, got %s", name, enums, js)
+	}
+
+	switch v := v.(type) {
+	case map[string]any:
+		return validateObject(s, v, path)
+	case []any:
+		for i, item := range v {
+			if err := validateValue(s.Items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func validateObject(s *jsonschema.Schema, v map[string]any, path string) error {
+	prefix := ""
+	if path != "" {
+		prefix = path + "."
+	}
+	required := map[string]bool{}
+	for _, req := range s.Required {
+		required[req] = true
+		if _, ok := v[req]; !ok {
+			err := fmt.Errorf("missing the required argument %s%s", prefix, req)
+			if prop, ok := s.Properties.Get(req); ok && prop.Description != "" {
+				err = fmt.Errorf("%v: %s", err, prop.Description)
+			}
+			return err
+		}
+	}
+	keys := make([]string, 0, len(v))
+	for k := range v {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if v[k] == nil && !required[k] {
+			// null is taken as absent for the optional arguments
+			continue
+		}
+		var prop *jsonschema.Schema
+		if s.Properties != nil {
+			prop, _ = s.Properties.Get(k)
+		}
+		if prop == nil {
+			if reflect.DeepEqual(s.AdditionalProperties, jsonschema.FalseSchema) {
+				var names []string
+				if s.Properties != nil {
+					for p := s.Properties.Oldest(); p != nil; p = p.Next() {
+						names = append(names, p.Key)
+					}
+				}
+				return fmt.Errorf("unknown argument %s%s, the arguments are %s", prefix, k, strings.Join(names, ", "))
+			}
+			prop = s.AdditionalProperties
+		}
+		if err := validateValue(prop, v[k], prefix+k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isType tells if the decoded JSON value is of the JSON schema type
+func isType(v any, typ string) bool {
+	switch typ {
+	case "object":
+		_, ok := v.(map[string]any)
+		return ok
+	case "array":
+		_, ok := v.([]any)
+		return ok
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "number":
+		_, ok := v.(float64)
+		return ok
+	case "integer":
+		f, ok := v.(float64)
+		return ok && f == math.Trunc(f)
+	case "null":
+		return v == nil
+	}
+	return true
+}
+
+func article(typ string) string {
+	switch typ {
+	case "object", "array", "integer":
+		return "an"
+	}
+	return "a"
+}
@@ -0,0 +1,89 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tool
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/invopop/jsonschema"
+)
+
+func TestGetJSONSchema(t *testing.T) {
+	var sch jsonschema.Schema
+	if err := json.Unmarshal(GetJSONSchema(GetDiagramReq{}), &sch); err != nil {
+		t.Fatal(err)
+	}
+	labels, _ := sch.Properties.Get("labels")
+	if want := "the readable labels of the packages keyed by mod_path?pkg_path, or of the nodes and calls keyed by mod_path?pkg_path#name"; labels.Description != want {
+		t.Errorf("description = %q, want %q", labels.Description, want)
+	}
+	kind, _ := sch.Properties.Get("kind")
+	if len(kind.Enum) != 2 || kind.Enum[0] != "component" || kind.Description != "the kind of the diagram: component or sequence" {
+		t.Errorf("kind = %+v", kind)
+	}
+	depth, _ := sch.Properties.Get("depth")
+	if depth.Default != json.Number("4") && depth.Default != float64(4) {
+		t.Errorf("default depth = %#v", depth.Default)
+	}
+	entry, _ := sch.Properties.Get("entry")
+	if len(entry.OneOf) != 2 || entry.OneOf[0].Type != "object" || entry.OneOf[1].Type != "null" || entry.OneOf[0].Description != "" {
+		t.Errorf("entry = %+v", entry)
+	}
+	if strings.Join(sch.Required, ",") != "repo_name,kind" {
+		t.Errorf("required = %v", sch.Required)
+	}
+}
+
+func TestValidateArguments(t *testing.T) {
+	var sch jsonschema.Schema
+	if err := json.Unmarshal(GetJSONSchema(GetDiagramReq{}), &sch); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		args string
+		err  string
+	}{
+		{"valid", `{"repo_name": "a", "kind": "sequence", "entry": {"mod_path": "", "pkg_path": "io", "name": "Copy"}, "depth": 2, "labels": {"a?b": "B"}}`, ""},
+		{"null entry", `{"repo_name": "a", "kind": "component", "entry": null}`, ""},
+		{"missing", `{"kind": "component"}`, "missing the required argument repo_name: the name of the repository"},
+		{"enum", `{"repo_name": "a", "kind": "class"}`, `kind must be one of ["component","sequence"], got "class"`},
+		{"type", `{"repo_name": "a", "kind": "sequence", "depth": "2"}`, `depth must be an integer, got "2"`},
+		{"float", `{"repo_name": "a", "kind": "sequence", "depth": 2.5}`, `depth must be an integer, got 2.5`},
+		{"nested", `{"repo_name": "a", "kind": "sequence", "entry": {"pkg_path": "io", "name": "Copy"}}`, "missing the required argument entry.mod_path"},
+		{"not nullable object", `{"repo_name": "a", "kind": "sequence", "entry": "io#Copy"}`, `entry must be an object, got "io#Copy"`},
+		{"unknown", `{"repo_name": "a", "kind": "sequence", "entry_id": {}}`, "unknown argument entry_id, the arguments are repo_name, kind, entry"},
+		{"map value", `{"repo_name": "a", "kind": "component", "labels": {"a?b": 1}}`, `labels.a?b must be a string, got 1`},
+		{"not object", `[1]`, "the arguments must be an object"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateArguments(&sch, json.RawMessage(tt.args))
+			if tt.err == "" {
+				if err != nil {
+					t.Errorf("ValidateArguments() = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("ValidateArguments() = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// ErrBudgetExceeded is returned by the metered models once the budget of the usage is spent
+var ErrBudgetExceeded = errors.New("budget exceeded")
+
+// Budget limits the usage of the model, no limit if the field is 0
+type Budget struct {
+	USD    float64 `json:"usd,omitempty"`
+	Tokens int     `json:"tokens,omitempty"`
+}
+
+// UsageStats are the tokens and the cost of the model calls
+type UsageStats struct {
+	Calls            int     `json:"calls"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	CostUSD          float64 `json:"cost_usd"`
+}
+
+// TotalTokens returns the sum of the prompt and completion tokens
+func (s UsageStats) TotalTokens() int {
+	return s.PromptTokens + s.CompletionTokens
+}
+
+func (s UsageStats) String() string {
+	return fmt.Sprintf("%d model calls, %d tokens (prompt %d, completion %d), cost $%.4f",
+		s.Calls, s.TotalTokens(), s.PromptTokens, s.CompletionTokens, s.CostUSD)
+}
+
+// Usage accumulates the tokens reported by the provider for every model call of a session,
+// priced by ModelConfig.InputPrice and OutputPrice, and stops the calls once the budget is spent
+type Usage struct {
+	budget Budget
+	mu     sync.Mutex
+	stats  UsageStats
+}
+
+func NewUsage(budget Budget) *Usage {
+	return &Usage{budget: budget}
+}
+
+// Stats returns the usage so far
+func (u *Usage) Stats() UsageStats {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.stats
+}
+
+// Exceeded returns ErrBudgetExceeded (wrapped with the spent amount) if the budget is spent
+func (u *Usage) Exceeded() error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.budget.USD > 0 && u.stats.CostUSD >= u.budget.USD {
+		return fmt.Errorf("%w: spent $%.4f of $%.4f", ErrBudgetExceeded, u.stats.CostUSD, u.budget.USD)
+	}
+	if u.budget.Tokens > 0 && u.stats.TotalTokens() >= u.budget.Tokens {
+		return fmt.Errorf("%w: spent %d of %d tokens", ErrBudgetExceeded, u.stats.TotalTokens(), u.budget.Tokens)
+	}
+	return nil
+}
+
+// add records the tokens of a call (or a part of a streamed call) priced by conf
+func (u *Usage) add(conf ModelConfig, call bool, prompt, completion int) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if call {
+		u.stats.Calls++
+	}
+	u.stats.PromptTokens += prompt
+	u.stats.CompletionTokens += completion
+	u.stats.CostUSD += (float64(prompt)*conf.InputPrice + float64(completion)*conf.OutputPrice) / 1e6
+}
+
+// meteredChatModel records the usage of the calls, and refuses the calls once the budget is spent
+type meteredChatModel struct {
+	ChatModel
+	conf  ModelConfig
+	usage *Usage
+}
+
+// WithUsage wraps the model of conf to record its usage, the model is returned as is if usage is nil
+func WithUsage(m ChatModel, conf ModelConfig, usage *Usage) ChatModel {
+	if usage == nil {
+		return m
+	}
+	return &meteredChatModel{ChatModel: m, conf: conf, usage: usage}
+}
+
+func (m *meteredChatModel) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	cm, err := m.ChatModel.WithTools(tools)
+	if err != nil {
+		return nil, err
+	}
+	return &meteredChatModel{ChatModel: cm, conf: m.conf, usage: m.usage}, nil
+}
+
+// IsCallbacksEnabled tells the callbacks are triggered by the wrapped model
+func (m *meteredChatModel) IsCallbacksEnabled() bool {
+	return true
+}
+
+func (m *meteredChatModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	if err := m.usage.Exceeded(); err != nil {
+		return nil, err
+	}
+	msg, err := m.ChatModel.Generate(ctx, input, opts...)
+	if err != nil {
+		return nil, err
+	}
+	var prompt, completion int
+	if msg.ResponseMeta != nil && msg.ResponseMeta.Usage != nil {
+		prompt, completion = msg.ResponseMeta.Usage.PromptTokens, msg.ResponseMeta.Usage.CompletionTokens
+	}
+	m.usage.add(m.conf, true, prompt, completion)
+	return msg, nil
+}
+
+// Stream records the usage as the chunks are read. The providers report the usage of a stream
+// in some chunks accumulatively (see schema.ConcatMessages), thus only the increments are added
+func (m *meteredChatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	if err := m.usage.Exceeded(); err != nil {
+		return nil, err
+	}
+	sr, err := m.ChatModel.Stream(ctx, input, opts...)
+	if err != nil {
+		return nil, err
+	}
+	m.usage.add(m.conf, true, 0, 0)
+	var prompt, completion int
+	return schema.StreamReaderWithConvert(sr, func(chunk *schema.Message) (*schema.Message, error) {
+		if chunk.ResponseMeta != nil && chunk.ResponseMeta.Usage != nil {
+			u := chunk.ResponseMeta.Usage
+			dp, dc := max(u.PromptTokens-prompt, 0), max(u.CompletionTokens-completion, 0)
+			prompt, completion = prompt+dp, completion+dc
+			if dp > 0 || dc > 0 {
+				m.usage.add(m.conf, false, dp, dc)
+			}
+		}
+		return chunk, nil
+	}), nil
+}
@@ -0,0 +1,77 @@
+/**
+ * Copyright 2025 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package llm
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestWithUsage(t *testing.T) {
+	ctx := context.Background()
+	withUsage := func(content string, prompt, completion int) *schema.Message {
+		return &schema.Message{Role: schema.Assistant, Content: content,
+			ResponseMeta: &schema.ResponseMeta{Usage: &schema.TokenUsage{PromptTokens: prompt, CompletionTokens: completion, TotalTokens: prompt + completion}}}
+	}
+	// the usage of a stream is reported accumulatively
+	m := &streamModel{chunks: []*schema.Message{withUsage("hello", 1000, 0), withUsage(" world", 1000, 200), withUsage("", 1000, 500)}}
+	conf := ModelConfig{InputPrice: 3, OutputPrice: 15}
+	usage := NewUsage(Budget{Tokens: 3000})
+	cm := WithUsage(m, conf, usage)
+
+	sr, err := cm.Stream(ctx, []*schema.Message{schema.UserMessage("hi")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := readAll(t, sr); got != "hello world" {
+		t.Errorf("Stream() = %q", got)
+	}
+	got := usage.Stats()
+	if got.Calls != 1 || got.PromptTokens != 1000 || got.CompletionTokens != 500 || math.Abs(got.CostUSD-(1000*3+500*15)/1e6) > 1e-9 {
+		t.Errorf("Stats() = %+v", got)
+	}
+	if err := usage.Exceeded(); err != nil {
+		t.Errorf("Exceeded() = %v", err)
+	}
+
+	if _, err := cm.Generate(ctx, []*schema.Message{schema.UserMessage("hi")}); err != nil {
+		t.Fatal(err)
+	}
+	if got := usage.Stats(); got.Calls != 2 || got.TotalTokens() != 3000 {
+		t.Errorf("Stats() = %+v", got)
+	}
+	// the calls are refused once the budget is spent
+	if _, err := cm.Generate(ctx, []*schema.Message{schema.UserMessage("hi")}); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("Generate() err = %v, want budget exceeded", err)
+	}
+	if _, err := cm.Stream(ctx, []*schema.Message{schema.UserMessage("hi")}); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("Stream() err = %v, want budget exceeded", err)
+	}
+	if got := usage.Stats(); got.Calls != 2 {
+		t.Errorf("refused calls are counted: %+v", got)
+	}
+
+	usage = NewUsage(Budget{USD: 0.01})
+	usage.add(conf, true, 1000, 1000)
+	if err := usage.Exceeded(); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("Exceeded() = %v, want budget exceeded by $0.018", err)
+	}
+}
@@ -31,19 +31,34 @@
 package main
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
-	"os/exec"
+	"os/signal"
 	"path/filepath"
 	"runtime"
 	"runtime/pprof"
 	runtimeTrace "runtime/trace"
+	"slices"
+	"sort"
 	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
 
 	internalCmd "github.com/cloudwego/abcoder/internal/cmd"
+	"github.com/cloudwego/abcoder/internal/config"
 	"github.com/cloudwego/abcoder/lang"
+	"github.com/cloudwego/abcoder/lang/collect"
+	"github.com/cloudwego/abcoder/lang/external"
+	"github.com/cloudwego/abcoder/lang/golang/parser"
 	"github.com/cloudwego/abcoder/lang/log"
+	"github.com/cloudwego/abcoder/lang/lsp"
+	"github.com/cloudwego/abcoder/lang/progress"
+	"github.com/cloudwego/abcoder/lang/scip"
 	"github.com/cloudwego/abcoder/lang/uniast"
 	"github.com/cloudwego/abcoder/lang/utils"
 	"github.com/cloudwego/abcoder/llm"
@@ -74,14 +89,31 @@ writing, and analyzing code structures.`,
 
 	// Global flags
 	cmd.PersistentFlags().BoolP("verbose", "v", false, "Verbose mode.")
+	cmd.PersistentFlags().String("config", "", "Path to the config file of per-repo defaults (default: abcoder.yaml or .abcoder.toml under the repo or working directory).")
+	cmd.PersistentFlags().String("log-level", "", "Log levels, as the default level and scope=level pairs separated by commas, e.g. 'info,collector=debug,lsp=warn'. Levels: error, warn, info, debug. -v sets the default level to debug.")
+	cmd.PersistentFlags().String("log-format", "text", "Log format: text or json (one record per line).")
+	cmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		if spec, _ := cmd.Flags().GetString("log-level"); spec != "" {
+			if err := log.SetLevels(spec); err != nil {
+				return err
+			}
+		}
+		format, _ := cmd.Flags().GetString("log-format")
+		return log.SetFormat(log.Format(format))
+	}
 
 	// Add subcommands
 	cmd.AddCommand(newVersionCmd())
 	cmd.AddCommand(newParseCmd())
 	cmd.AddCommand(newWriteCmd())
+	cmd.AddCommand(newQueryCmd())
+	cmd.AddCommand(newLintASTCmd())
+	cmd.AddCommand(newExportCmd())
+	cmd.AddCommand(newImportCmd())
 	cmd.AddCommand(newMcpCmd())
 	cmd.AddCommand(newInitSpecCmd())
 	cmd.AddCommand(newAgentCmd())
+	cmd.AddCommand(newReposCmd())
 
 	return cmd
 }
@@ -108,16 +140,24 @@ func newParseCmd() *cobra.Command {
 		flagTrace        string
 		flagMutexProfile string
 		flagBlockProfile string
+		flagProgress     string
+		flagWatch        bool
 		opts             lang.ParseOptions
+		slim             uniast.SlimOptions
 	)
 
 	cmd := &cobra.Command{
-		Use:   "parse <language> <path>",
+		Use:   "parse [language] <path>",
 		Short: "Parse repository and export to UniAST JSON format",
 		Long: `Parse the specified repository and generate its Universal AST representation.
 
 By default, outputs to stdout. Use --output to write to a file.
 
+The language is detected if omitted: the languages whose manifest (go.mod, Cargo.toml,
+pyproject.toml, setup.py, pom.xml, build.gradle, build.sbt, composer.json, Gemfile, tsconfig.json, package.json) is at the root
+are all parsed and merged into one AST. Without any manifest, the language of the source files
+is taken, and it must be given explicitly if there are several.
+
 Language Support:
   go      - Go projects
   rust    - Rust projects
@@ -125,17 +165,59 @@ Language Support:
   python   - Python projects
   ts       - TypeScript projects
   js       - JavaScript projects
-  java     - Java projects`,
-		Example: `abcoder parse go ./my-project -o ast.json`,
-		Args:    cobra.ExactArgs(2),
+  java     - Java projects
+  scala    - Scala projects (by metals)
+  php      - PHP projects (by intelephense or phpactor)
+  ruby     - Ruby projects (by solargraph)
+
+Other languages are parsed by the external parsers, given by --external-parser
+or found as abcoder-parser-<language> in PATH. See docs/external-parser.md for the protocol.
+
+With --std, the std lib of the go or rust toolchain is parsed into a shared AST instead,
+named like go-std@go1.24.5, which the repos parsed with --link-std refer to by the module paths.`,
+		Example: `abcoder parse go ./my-project -o ast.json
+abcoder parse ./my-project -o ast.json
+abcoder parse go ./my-project --watch -o ast.json
+abcoder parse go --std -o go-std.json`,
+		Args: cobra.RangeArgs(1, 2),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) == 0 {
+				// the language, or the path if it is detected
+				return parseLanguages, cobra.ShellCompDirectiveDefault
+			}
+			return nil, cobra.ShellCompDirectiveFilterDirs
+		},
 		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if opts.Std {
+				// the std lib of the toolchain is parsed instead of a repo, see lang.ParseOptions.Std
+				if len(args) != 1 {
+					return fmt.Errorf("--std only takes the language")
+				}
+				opts.Language = uniast.NewLanguage(args[0])
+				return validateParseOptions(&opts, flagProgress)
+			}
+			if err := loadConfig(cmd, args[len(args)-1]); err != nil {
+				return err
+			}
+			if len(args) == 1 {
+				// detected by lang.DetectLanguages
+				opts.Language = uniast.Unknown
+				if opts.ExternalParser != "" {
+					return fmt.Errorf("the language must be given with --external-parser")
+				}
+				return validateParseOptions(&opts, flagProgress)
+			}
 			// Validate language
 			language := uniast.NewLanguage(args[0])
 			if language == uniast.Unknown {
-				return fmt.Errorf("unsupported language: %s", args[0])
+				// languages without builtin parsers are parsed by the external parsers
+				language = uniast.Language(strings.ToLower(args[0]))
+				if _, ok := external.Lookup(language); !ok && opts.ExternalParser == "" {
+					return fmt.Errorf("unsupported language: %s", args[0])
+				}
 			}
 			opts.Language = language
-			return nil
+			return validateParseOptions(&opts, flagProgress)
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			verbose, _ := cmd.Flags().GetBool("verbose")
@@ -144,16 +226,7 @@ Language Support:
 				opts.Verbose = true
 			}
 
-			language := uniast.NewLanguage(args[0])
-			uri := args[1]
-
-			if language == uniast.TypeScript {
-				if err := parseTSProject(context.Background(), uri, opts, flagOutput); err != nil {
-					log.Error("Failed to parse: %v\n", err)
-					return err
-				}
-				return nil
-			}
+			uri := args[len(args)-1]
 
 			if flagLsp != "" {
 				opts.LSP = flagLsp
@@ -213,40 +286,92 @@ Language Support:
 			lspOptions["java_parser"] = "ipc"
 			opts.LspOptions = lspOptions
 
-			out, err := lang.Parse(context.Background(), uri, opts)
-			if err != nil {
-				log.Error("Failed to parse: %v\n", err)
-				return err
-			}
+			// SIGINT and SIGTERM stop the collection, and the symbols collected so far are still written out
+			ctx, stop := notifyInterrupt(context.Background())
+			defer stop()
 
-			if flagOutput != "" {
-				if err := utils.MustWriteFile(flagOutput, out); err != nil {
-					log.Error("Failed to write output: %v\n", err)
-					return err
+			if flagWatch {
+				if flagOutput == "" || opts.Language != uniast.Golang {
+					return fmt.Errorf("--watch only supports go, and requires --output")
 				}
-			} else {
-				fmt.Fprintf(os.Stdout, "%s\n", out)
+				return lang.WatchRepo(ctx, uri, opts, lang.WatchOptions{
+					OnUpdate: func(repo *uniast.Repository, changed []string) error {
+						if err := writeOutput(flagOutput, repo.Slim(slim)); err != nil {
+							return err
+						}
+						log.Info("written %s, watching for changes...\n", flagOutput)
+						return nil
+					},
+				})
 			}
 
+			repo, perr := lang.ParseRepo(ctx, uri, opts)
+			if repo == nil {
+				log.Error("Failed to parse: %v\n", perr)
+				return perr
+			}
+			if perr != nil {
+				log.Error("Parsing interrupted, writing the collected symbols: %v\n", perr)
+			}
+
+			if err := writeOutput(flagOutput, repo.Slim(slim)); err != nil {
+				log.Error("Failed to write output: %v\n", err)
+				return err
+			}
+			if perr != nil {
+				return fmt.Errorf("parsing interrupted, output is incomplete: %w", perr)
+			}
 			return nil
 		},
 	}
 
 	// Flags
 	cmd.Flags().StringVarP(&flagOutput, "output", "o", "", "Output path for UniAST JSON (default: stdout).")
-	cmd.Flags().StringVar(&flagLsp, "lsp", "", "Path to Language Server Protocol executable. Required for languages with LSP support (e.g., Java).")
+	cmd.Flags().StringVar(&flagLsp, "lsp", "", "Path to Language Server Protocol executable, or the address of a running server like tcp://host:port or ws://host:port/path. Required for languages with LSP support (e.g., Java).")
+	cmd.Flags().StringVar(&opts.LSPRemoteRoot, "lsp-remote-root", "", "Path of the repo seen by the remote LSP server given by --lsp, if it differs from the local one (e.g. /workspace in a devcontainer).")
+	cmd.Flags().StringVar(&opts.LSPTraceDir, "lsp-trace", "", "Record the JSON-RPC messages exchanged with the LSP server into trace files under the directory, with the repo path replaced by $ROOT. A trace is replayed by --lsp replay://<trace file> to reproduce the parsing without the server.")
+	cmd.Flags().BoolVar(&opts.LSPWorkspaceSymbols, "lsp-workspace-symbols", false, "Complete the symbols reported by textDocument/documentSymbol with workspace/symbol, for the LSP servers reporting sparse document symbols (e.g. older pylsp). It is done anyway for the files without any document symbol.")
 	cmd.Flags().StringVar(&javaHome, "java-home", "", "Java installation directory (JAVA_HOME). Required when using LSP for Java.")
 	cmd.Flags().BoolVar(&opts.LoadExternalSymbol, "load-external-symbol", false, "Load external symbol references into AST results (slower but more complete).")
+	cmd.Flags().BoolVar(&opts.FetchSources, "fetch-sources", false, "Download the sources of the exact dependency versions missing in the local caches (from GOPROXY or crates.io) to load their external symbols, used with --load-external-symbol (only works for Go and Rust).")
 	cmd.Flags().BoolVar(&opts.NoNeedComment, "no-need-comment", false, "Skip parsing code comments (only works for Go).")
 	cmd.Flags().BoolVar(&opts.NotNeedTest, "no-need-test", false, "Skip test files during parsing (only works for Go).")
 	cmd.Flags().BoolVar(&opts.LoadByPackages, "load-by-packages", false, "Load packages one by one instead of all at once (only works for Go, uses more memory).")
 	cmd.Flags().BoolVar(&opts.DisableBuildGraph, "disable-build-graph", false, "Disable the step of building the dependency graph among AST nodes.")
+	cmd.Flags().BoolVar(&opts.FailOnError, "fail-on-error", false, "Fail if the compiler or LSP reports errors (e.g. syntax errors) on the codes.")
+	cmd.Flags().BoolVar(&opts.Dedup, "dedup", false, "Collapse the identical nodes of external modules and vendored or generated dirs (vendor, kitex_gen, hertz_gen) into one, recording the others as its aliases.")
+	cmd.Flags().BoolVar(&opts.Blame, "blame", false, "Record the primary authors and the last modified times of the nodes by git blame, to tell who should review the changes of them.")
+	cmd.Flags().BoolVar(&opts.Comments, "comments", false, "Collect the comments which are not a part of any node (license headers, section banners, free-floating notes) and the TODO/FIXME comments everywhere into the files, attached to the nearest nodes.")
+	cmd.Flags().BoolVar(&opts.Std, "std", false, "Parse the std lib of the go or rust toolchain into a shared AST instead of a repo, whose modules are versioned by the toolchain like std@go1.24.5.")
+	cmd.Flags().BoolVar(&opts.LinkStd, "link-std", false, "Refer the std symbols to the shared AST of the toolchain (see --std) by the versioned module paths, instead of ignoring or collecting them. Go and rust only.")
+	cmd.Flags().BoolVar(&opts.DetectLicenses, "detect-licenses", false, "Detect the licenses of the third-party dependencies by their sources in the vendor dir or the module caches.")
 	cmd.Flags().StringSliceVar(&opts.Excludes, "exclude", []string{}, "Files or directories to exclude from parsing (can be specified multiple times).")
+	cmd.Flags().StringSliceVar(&opts.OnlyPkgs, "only-pkg", []string{}, "Only parse these packages (e.g. a/b/c, or a/b/... for the subtree) and their direct dependencies (only works for Go, can be specified multiple times).")
+	cmd.Flags().StringSliceVar(&opts.OnlyDirs, "only-dir", []string{}, "Only parse the codes under these directories and their direct dependencies (can be specified multiple times).")
 	cmd.Flags().StringSliceVar(&opts.Sysroots, "sysroot", []string{}, "Filesystem prefix(es) whose contents should be classified under module `cstdlib` (e.g. /opt/toolchain/sysroot). Repeatable. C++ only.")
+	cmd.Flags().StringVar(&opts.PythonEnv, "python-env", "", "Python interpreter or virtualenv dir whose site-packages are resolved, thus the third-party symbols are loaded with --load-external-symbol. Default to the activated virtualenv or the .venv of the repo. Python only.")
+	cmd.Flags().StringSliceVar(&opts.IncludeKinds, "include-kinds", []string{}, "Only keep the nodes of these kinds in the AST: "+strings.Join(uniast.NodeKinds, ", ")+". E.g. function,method for call graph analysis.")
+	cmd.Flags().StringSliceVar(&opts.ExcludeKinds, "exclude-kinds", []string{}, "Remove the nodes of these kinds from the AST, e.g. var,const. Their dependencies are not collected either, which saves parsing time.")
 	cmd.Flags().StringVar(&opts.RepoID, "repo-id", "", "Custom identifier for this repository (useful for multi-repo scenarios).")
 	cmd.Flags().StringArrayVar(&opts.BuildFlags, "build-flag", []string{}, "Pass build flags to the Go parser (e.g. -tags=xxx).")
+	cmd.Flags().StringVar(&opts.GoCallGraph, "callgraph", "", "Resolve the targets of dynamic calls (through interfaces and func values) of Go codes by SSA, using the algorithm cha or rta. They are added to MethodCalls as Dynamic dependencies. Disabled by default since it is slow on large repos.")
+	cmd.Flags().IntVar(&opts.GoClosureMinLines, "go-closure-min-lines", 0, "Parse the Go function literals (closures) spanning at least these lines as child functions named like `Parent.func1`, linked to the enclosing ones by ParentFunction, thus handlers registered as closures are addressable. Disabled if 0.")
+	cmd.Flags().StringArrayVar(&opts.GoTags, "go-tags", []string{}, "Parse Go codes under the build tag set (e.g. linux,amd64), GOOS and GOARCH values are set by env. Repeat it to parse multiple tag sets and merge the variants.")
+	cmd.Flags().StringSliceVar(&opts.Features, "features", []string{}, "Cargo features to enable, thus the cfg-gated codes are collected (only works for Rust).")
+	cmd.Flags().BoolVar(&opts.AllFeatures, "all-features", false, "Enable all cargo features (only works for Rust).")
+	cmd.Flags().BoolVar(&opts.NoDefaultFeatures, "no-default-features", false, "Disable the default cargo feature (only works for Rust).")
+	cmd.Flags().StringVar(&opts.ExternalParser, "external-parser", "", "Path to an external parser executable, which speaks JSON lines over stdio and produces UniAST modules (see docs/external-parser.md).")
 	cmd.Flags().StringVar(&opts.TSConfig, "tsconfig", "", "Path to tsconfig.json file for TypeScript project configuration.")
 	cmd.Flags().StringSliceVar(&opts.TSSrcDir, "ts-src-dir", []string{}, "Additional TypeScript source directories (can be specified multiple times).")
+	cmd.Flags().DurationVar(&opts.LSPRequestTimeout, "lsp-timeout", lsp.DefaultRequestTimeout, "How long an LSP request waits for the response, beyond which the LSP server is taken as hung and restarted. Negative disables it.")
+	cmd.Flags().IntVar(&opts.LSPMaxRestarts, "lsp-max-restarts", lsp.DefaultMaxRestarts, "Max restarts of the crashed or hung LSP server. Negative disables the restarts.")
+	cmd.Flags().IntVar(&opts.LSPMaxRetries, "lsp-max-retries", 0, fmt.Sprintf("Max retries of an LSP request failed by a crash or hang, after the server restarts (default: %d, 0 for C++ whose server crashes on the same request again). Negative disables the retries.", lsp.DefaultMaxRetries))
+	cmd.Flags().StringVar(&opts.LSPCachePath, "lsp-cache-path", "", "Directory for the caches of LSP parsing like the checkpoints (default: abcoder/lsp under the user cache dir).")
+	cmd.Flags().DurationVar(&opts.CheckpointInterval, "checkpoint-interval", collect.DefaultCheckpointInterval, "How often the symbols collected by LSP are checkpointed under --lsp-cache-path, thus a crashed parsing can be resumed. 0 disables it.")
+	cmd.Flags().BoolVar(&opts.Resume, "resume", false, "Resume the parsing from the checkpoint of the last crashed or interrupted one, unless the files or options have changed since then.")
+	cmd.Flags().BoolVar(&flagWatch, "watch", false, "Keep the output up to date: watch the repo and re-parse the packages of the changed files, then rewrite the output atomically. Only works for Go, requires --output.")
+	addSlimFlags(cmd, &slim)
+	cmd.Flags().StringVar(&flagProgress, "progress", "", "Report the parsing progress onto stderr, in format: json (JSON lines of phase, done/total and ETA).")
 	cmd.Flags().StringVar(&flagCPUProfile, "cpu-profile", "", "Write a CPU pprof profile to this file.")
 	cmd.Flags().StringVar(&flagTrace, "trace", "", "Write a runtime/trace event file to this file.")
 	cmd.Flags().StringVar(&flagMutexProfile, "mutex-profile", "", "Write a mutex contention pprof profile to this file.")
@@ -269,7 +394,7 @@ func newWriteCmd() *cobra.Command {
 			if args[0] == "" {
 				return fmt.Errorf("argument Path is required")
 			}
-			return nil
+			return loadConfig(cmd, ".")
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			verbose, _ := cmd.Flags().GetBool("verbose")
@@ -302,12 +427,417 @@ func newWriteCmd() *cobra.Command {
 
 	cmd.Flags().StringVarP(&flagOutput, "output", "o", "", "Output directory for generated code files (default: <basename of input file>).")
 	cmd.Flags().StringVar(&wopts.Compiler, "compiler", "", "Path to compiler executable (language-specific).")
+	cmd.Flags().BoolVar(&wopts.ScaffoldExternal, "scaffold-external", false, "Write the external symbols loaded in the AST as placeholder modules, so the output compiles offline.")
+	cmd.Flags().BoolVar(&wopts.Validate, "validate", false, "Compile the written codes, and report the errors by the nodes causing them.")
+	cmd.Flags().BoolVar(&wopts.Preserve, "preserve", false, "Patch the original source files of the repo with the minimal edits of the changed nodes, instead of rebuilding them, to keep the formatting and get minimal diffs.")
+	cmd.Flags().StringSliceVar(&wopts.LocalPrefixes, "local-prefix", nil, "Group the imports of the written Go files with the prefixes after the third-party ones, like `goimports -local`.")
+	cmd.Flags().StringArrayVar(&wopts.Formatters, "formatter", nil, "Command formatting the written Go files in place, with the file paths appended (e.g. 'gofumpt -w'). Can be repeated to run a chain in order.")
+	cmd.Flags().StringVar(&wopts.RustfmtConfig, "rustfmt-config", "", "Path of a rustfmt.toml, the written Rust files are formatted by rustfmt with it.")
 
 	return cmd
 }
 
-func newMcpCmd() *cobra.Command {
+func newQueryCmd() *cobra.Command {
 	return &cobra.Command{
+		Use:   "query <ast-file> <query>",
+		Short: "Query the analysis results of a UniAST file",
+		Long: `Query the analysis results of a UniAST file, and print them as JSON.
+
+Queries:
+  cycles            - the dependency cycles among packages and among nodes (e.g. mutually recursive functions)
+  annotated:<name>  - the nodes with the annotation of the name, which is a Go directive (go:noinline),
+                      a Rust attribute (derive), a Java annotation (Service) or a Python decorator (app.route)
+  unreachable       - the dead nodes never reached from the main functions, tests and init functions
+  unreachable:api   - the same, taking the exported nodes as the entrypoints too, for libraries
+  api[:<mod>]       - the public API of the internal modules (or the given one): exported functions, types and vars
+                      with signatures but without bodies
+  api-diff:<ast>    - the API added, removed and changed since the base version parsed as the given UniAST file
+  context[:<id>]    - the calls breaking the context propagation along the request paths (go only): the callees taking
+                      a context called without one or with context.Background/TODO. The request paths start from
+                      the given function (mod?pkg#name), or every function taking a context
+  assets[:<prefix>] - the static files shipped inside the binaries (go:embed), with the vars embedding them,
+                      only the ones under the path prefix (relative to the repo) if given
+  implements[:<id>] - the implements matrix: every interface with the types implementing it, including the external
+                      interfaces like io.Reader. Given a node (mod?pkg#name), the types implementing it if it is an
+                      interface, and the interfaces it implements if it is a type
+  todos[:<marker>]  - the TODO, FIXME, XXX, HACK and BUG markers in the comments with the nodes they are attached to,
+                      only the ones of the given marker if any. The AST must be parsed with --comments
+  errors[:<id>]     - where the errors originate (go and rust): given an error type or a sentinel var (mod?pkg#name),
+                      the sites creating, returning or wrapping it; given a function, the sites of itself and of its
+                      callees returning errors transitively; otherwise all the sites of the internal functions`,
+		Example: `abcoder query ast.json cycles
+abcoder query ast.json annotated:app.route
+abcoder query ast.json unreachable:api
+abcoder query ast.json api-diff:base.json
+abcoder query ast.json 'context:github.com/a/svc?github.com/a/svc/handler#Serve'
+abcoder query ast.json assets:web/static/
+abcoder query ast.json 'implements:?io#Reader'
+abcoder query ast.json todos:FIXME
+abcoder query ast.json 'errors:github.com/a/svc?github.com/a/svc/store#ErrNotFound'`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			verbose, _ := cmd.Flags().GetBool("verbose")
+			if verbose {
+				log.SetLogLevel(log.DebugLevel)
+			}
+
+			repo, err := uniast.LoadRepo(args[0])
+			if err != nil {
+				log.Error("Failed to load repo: %v\n", err)
+				return err
+			}
+
+			var result interface{}
+			switch query, arg, _ := strings.Cut(args[1], ":"); query {
+			case "cycles":
+				result = repo.DetectCycles()
+			case "annotated":
+				if arg == "" {
+					return fmt.Errorf("missing annotation name, e.g. annotated:app.route")
+				}
+				result = repo.FindAnnotated(arg)
+			case "unreachable":
+				if arg != "" && arg != "api" {
+					return fmt.Errorf("unsupported entrypoints: %s, only unreachable:api is supported", arg)
+				}
+				result = repo.UnreachableNodes(repo.Entrypoints(arg == "api"))
+			case "api":
+				result, err = publicAPI(repo, arg)
+				if err != nil {
+					return err
+				}
+			case "api-diff":
+				if arg == "" {
+					return fmt.Errorf("missing the UniAST file of the base version, e.g. api-diff:base.json")
+				}
+				base, err := uniast.LoadRepo(arg)
+				if err != nil {
+					log.Error("Failed to load base repo: %v\n", err)
+					return err
+				}
+				olds, err := publicAPI(base, "")
+				if err != nil {
+					return err
+				}
+				news, err := publicAPI(repo, "")
+				if err != nil {
+					return err
+				}
+				result = uniast.DiffAPI(olds, news)
+			case "assets":
+				result = repo.Assets(arg)
+			case "todos":
+				result = repo.Todos(arg)
+			case "implements":
+				if arg == "" {
+					result = repo.ImplementsMatrix()
+					break
+				}
+				id := uniast.NewIdentityFromString(arg)
+				result = struct {
+					Implementations []uniast.Identity
+					Implements      []uniast.Identity
+				}{repo.GetImplementations(id), repo.GetImplementedInterfaces(id)}
+			case "errors":
+				var id uniast.Identity
+				if arg != "" {
+					id = uniast.NewIdentityFromString(arg)
+				}
+				result = repo.ErrorOrigins(id)
+			case "context":
+				var opts uniast.ContextOptions
+				if arg != "" {
+					opts.Entries = []uniast.Identity{uniast.NewIdentityFromString(arg)}
+				}
+				result = repo.ContextIssues(opts)
+			default:
+				return fmt.Errorf("unsupported query: %s", args[1])
+			}
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(result)
+		},
+	}
+}
+
+func newLintASTCmd() *cobra.Command {
+	var (
+		flagFormat string
+		flagRules  []string
+		opts       uniast.LintOptions
+	)
+	cmd := &cobra.Command{
+		Use:   "lint-ast <ast-file>",
+		Short: "Check the invariants of a UniAST file",
+		Long: `Check the invariants of the internal modules of a UniAST file, which are broken by the regressions of the parsers.
+It exits with a non-zero status if any issue is found, thus it can guard the ASTs produced in CI.
+
+Rules:
+  dangling-dependency  - a dependency on a node missing in the AST, while its module is internal
+  empty-fileline       - a node without the file or the line
+  orphan-package       - a package, node or file not belonging to the module or package holding it
+  duplicate-identity   - an identity declared by several nodes, or differing from its key
+  offset-out-of-range  - a node spanning beyond its file (read under --source-dir), or with a reversed range`,
+		Example: `abcoder lint-ast ast.json
+abcoder lint-ast ast.json --rule dangling-dependency,empty-fileline --format text`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			verbose, _ := cmd.Flags().GetBool("verbose")
+			if verbose {
+				log.SetLogLevel(log.DebugLevel)
+			}
+			if flagFormat != "json" && flagFormat != "text" {
+				return fmt.Errorf("unsupported format: %s", flagFormat)
+			}
+			for _, rule := range flagRules {
+				opts.Rules = append(opts.Rules, uniast.LintRule(rule))
+			}
+
+			repo, err := uniast.LoadRepo(args[0])
+			if err != nil {
+				log.Error("Failed to load repo: %v\n", err)
+				return err
+			}
+			issues, err := repo.Lint(opts)
+			if err != nil {
+				return err
+			}
+			if flagFormat == "text" {
+				for _, issue := range issues {
+					node := "-"
+					if issue.Node != nil {
+						node = issue.Node.Full()
+					}
+					fmt.Printf("%s\t%s\t%s\t%s\n", issue.Rule, issue.File, node, issue.Message)
+				}
+			} else {
+				if issues == nil {
+					issues = []uniast.LintIssue{}
+				}
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				if err := enc.Encode(issues); err != nil {
+					return err
+				}
+			}
+			if len(issues) > 0 {
+				cmd.SilenceUsage = true
+				return fmt.Errorf("found %d issues in %s", len(issues), args[0])
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&flagFormat, "format", "json", "Output format: json (an array of the issues) or text (a tab-separated line per issue).")
+	cmd.Flags().StringSliceVar(&flagRules, "rule", []string{}, "Only check these rules (default: all).")
+	cmd.Flags().StringVar(&opts.SourceDir, "source-dir", "", "Directory of the sources to check the offsets against (default: the repo path recorded in the AST).")
+	return cmd
+}
+
+// publicAPI returns the public API of the module, or all the internal modules if mod is empty
+func publicAPI(repo *uniast.Repository, mod string) ([]uniast.APIEntry, error) {
+	if mod != "" {
+		return repo.PublicAPI(mod)
+	}
+	var ret []uniast.APIEntry
+	for _, m := range repo.InternalModules() {
+		api, err := repo.PublicAPI(m.Name)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, api...)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Full() < ret[j].Full() })
+	return ret, nil
+}
+
+func newExportCmd() *cobra.Command {
+	var (
+		flagFormat      string
+		flagGranularity string
+		flagOutput      string
+		flagEntry       string
+		opts            uniast.ExportOptions
+		seqOpts         uniast.SequenceOptions
+	)
+	cmd := &cobra.Command{
+		Use:   "export <ast-file>",
+		Short: "Export the dependency graph of a UniAST file to graph formats",
+		Long: `Export the dependency graph of a UniAST file to standard graph formats,
+for visualizing and running graph analytics in external tools.
+
+Formats:
+  dot      - Graphviz DOT, nodes are clustered by package (e.g. dot -Tsvg)
+  graphml  - GraphML, read by Gephi, yEd, NetworkX and so on
+  neo4j    - nodes.csv and relationships.csv under the --output directory, for neo4j-admin database import
+  mermaid  - mermaid flowchart (the component diagram), or the sequence diagram of the calls from --entry
+
+The vertices are the functions, types and vars by default, or the packages with --granularity package,
+whose edges count the relations among their nodes.`,
+		Example: `abcoder export ast.json --format dot --granularity package | dot -Tsvg -o deps.svg
+abcoder export ast.json --format neo4j -o ./neo4j-import
+abcoder export ast.json --format mermaid --entry 'github.com/a/svc?github.com/a/svc/handler#Serve' --depth 3`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			verbose, _ := cmd.Flags().GetBool("verbose")
+			if verbose {
+				log.SetLogLevel(log.DebugLevel)
+			}
+			switch opts.Granularity = uniast.ExportGranularity(flagGranularity); opts.Granularity {
+			case uniast.GranularityNode, uniast.GranularityPackage:
+			default:
+				return fmt.Errorf("unsupported granularity: %s", flagGranularity)
+			}
+			format := uniast.ExportFormat(flagFormat)
+			switch format {
+			case uniast.ExportDOT, uniast.ExportGraphML, uniast.ExportMermaid:
+			case uniast.ExportNeo4j:
+				if flagOutput == "" {
+					return fmt.Errorf("--output directory is required for the neo4j format")
+				}
+			default:
+				return fmt.Errorf("unsupported format: %s", flagFormat)
+			}
+			if flagEntry != "" && format != uniast.ExportMermaid {
+				return fmt.Errorf("--entry is only supported by the mermaid format")
+			}
+
+			repo, err := uniast.LoadRepo(args[0])
+			if err != nil {
+				log.Error("Failed to load repo: %v\n", err)
+				return err
+			}
+			var seq uniast.SequenceDiagram
+			if flagEntry != "" {
+				seqOpts.External = opts.External
+				if seq, err = repo.CallSequence(uniast.NewIdentityFromString(flagEntry), seqOpts); err != nil {
+					return err
+				}
+			}
+			graph := repo.ExportGraph(opts)
+
+			if format == uniast.ExportNeo4j {
+				return writeNeo4jCSV(flagOutput, graph)
+			}
+			out := io.Writer(os.Stdout)
+			if flagOutput != "" {
+				f, err := os.Create(flagOutput)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				out = f
+			}
+			switch {
+			case flagEntry != "":
+				return seq.WriteMermaid(out)
+			case format == uniast.ExportMermaid:
+				return graph.WriteMermaid(out)
+			case format == uniast.ExportDOT:
+				return graph.WriteDOT(out)
+			default:
+				return graph.WriteGraphML(out)
+			}
+		},
+	}
+	cmd.Flags().StringVar(&flagFormat, "format", string(uniast.ExportDOT), "Output format: dot, graphml, neo4j or mermaid.")
+	cmd.Flags().StringVar(&flagGranularity, "granularity", string(uniast.GranularityNode), "Vertices of the graph: node (functions, types and vars) or package.")
+	cmd.Flags().BoolVar(&opts.External, "external", false, "Keep the external nodes or packages that the internal ones depend on, or the external calls of the sequence diagram.")
+	cmd.Flags().StringVarP(&flagOutput, "output", "o", "", "Output file (default: stdout), or the output directory for the neo4j format.")
+	cmd.Flags().StringVar(&opts.PkgPrefix, "pkg", "", "Only export the internal nodes or packages whose package path has the prefix.")
+	cmd.Flags().StringVar(&flagEntry, "entry", "", "Export the mermaid sequence diagram of the calls from the function, whose id is mod?pkg#name.")
+	cmd.Flags().IntVar(&seqOpts.MaxDepth, "depth", 0, "Max nesting of the calls in the sequence diagram (default: no limit).")
+	return cmd
+}
+
+// addSlimFlags adds the flags of writing slim ASTs, see uniast.SlimOptions
+func addSlimFlags(cmd *cobra.Command, slim *uniast.SlimOptions) {
+	cmd.Flags().BoolVar(&slim.StripContent, "strip-content", false, "Write the nodes without their bodies, keeping the doc comments, declarations and all edges, for graph-only uses like visualization and CI checks.")
+	cmd.Flags().StringSliceVar(&slim.Modules, "keep-module", []string{}, "Only write these modules, with or without the versions (can be specified multiple times).")
+	cmd.Flags().BoolVar(&slim.Internal, "internal-only", false, "Only write the internal modules of the repo, dropping the external ones.")
+}
+
+// writeNeo4jCSV writes nodes.csv and relationships.csv of the graph under the dir
+func writeNeo4jCSV(dir string, graph uniast.ExportGraph) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	nodes, err := os.Create(filepath.Join(dir, "nodes.csv"))
+	if err != nil {
+		return err
+	}
+	defer nodes.Close()
+	rels, err := os.Create(filepath.Join(dir, "relationships.csv"))
+	if err != nil {
+		return err
+	}
+	defer rels.Close()
+	if err := graph.WriteNeo4j(nodes, rels); err != nil {
+		return err
+	}
+	log.Info("exported %d nodes and %d relationships to %s\n", len(graph.Vertices), len(graph.Edges), dir)
+	return nil
+}
+
+func newImportCmd() *cobra.Command {
+	var (
+		flagOutput            string
+		flagDisableBuildGraph bool
+		opts                  scip.Options
+		slim                  uniast.SlimOptions
+	)
+	cmd := &cobra.Command{
+		Use:   "import <index-file>",
+		Short: "Import a SCIP or LSIF index as UniAST JSON",
+		Long: `Import the SCIP index (index.scip) or LSIF dump (dump.lsif) produced by the Sourcegraph indexers
+as UniAST JSON, which can be served by the MCP server and the agent like the parsed ones.
+It gives the languages without builtin parsers, or the repos already indexed in CI.
+
+The definitions become functions, types and vars, and the references inside their enclosing ranges
+become their dependencies. The source texts are read from --repo-dir (default: the project root
+recorded in the index) unless they are embedded in the index.`,
+		Example: `scip-go && abcoder import index.scip -o ast.json
+abcoder import dump.lsif --repo-dir ./my-project -o ast.json`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			verbose, _ := cmd.Flags().GetBool("verbose")
+			if verbose {
+				log.SetLogLevel(log.DebugLevel)
+			}
+			repo, err := scip.Import(args[0], opts)
+			if err != nil {
+				log.Error("Failed to import %s: %v\n", args[0], err)
+				return err
+			}
+			repo.HashNodes()
+			if !flagDisableBuildGraph {
+				if err := repo.BuildGraph(); err != nil {
+					return err
+				}
+			}
+			repo.ToolVersion = version.Version
+			if err := writeOutput(flagOutput, repo.Slim(slim)); err != nil {
+				log.Error("Failed to write output: %v\n", err)
+				return err
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&flagOutput, "output", "o", "", "Output path for UniAST JSON (default: stdout).")
+	cmd.Flags().StringVar(&opts.RepoDir, "repo-dir", "", "Directory of the indexed sources, to read the texts not embedded in the index (default: the project root of the index).")
+	cmd.Flags().StringVar(&opts.RepoID, "repo-id", "", "Custom identifier for this repository, also the module name of the symbols without package names (default: the base name of the repo dir).")
+	cmd.Flags().BoolVar(&flagDisableBuildGraph, "disable-build-graph", false, "Disable the step of building the dependency graph among AST nodes.")
+	addSlimFlags(cmd, &slim)
+	return cmd
+}
+
+func newMcpCmd() *cobra.Command {
+	var tokenBudget, maxLoadedRepos, maxBytes int
+	var repoAliases map[string]string
+	var flagPermissions, flagAuditLog string
+	var toolTimeout time.Duration
+	var toolTimeouts map[string]string
+
+	cmd := &cobra.Command{
 		Use:   "mcp <directory>",
 		Short: "Start MCP server for AST files",
 		Long: `Start a Model Context Protocol (MCP) server that provides AST reading tools.
@@ -321,21 +851,52 @@ It serves all *.json AST files in the specified directory.`,
 			if args[0] == "" {
 				return fmt.Errorf("argument Path is required")
 			}
-			return nil
+			return loadConfig(cmd, ".")
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			verbose, _ := cmd.Flags().GetBool("verbose")
 
 			uri := args[0]
 
-			svr := mcp.NewServer(mcp.ServerOptions{
+			sopts := mcp.ServerOptions{
 				ServerName:    "abcoder",
 				ServerVersion: version.Version,
 				Verbose:       verbose,
 				ASTReadToolsOptions: tool.ASTReadToolsOptions{
-					RepoASTsDir: uri,
+					RepoASTsDir:    uri,
+					TokenBudget:    tokenBudget,
+					MaxBytes:       maxBytes,
+					MaxLoadedRepos: maxLoadedRepos,
+					RepoAliases:    repoAliases,
 				},
-			})
+				ToolTimeout: toolTimeout,
+			}
+			for name, v := range toolTimeouts {
+				timeout, err := time.ParseDuration(v)
+				if err != nil {
+					return fmt.Errorf("invalid timeout of %s: %w", name, err)
+				}
+				if sopts.ToolTimeouts == nil {
+					sopts.ToolTimeouts = map[string]time.Duration{}
+				}
+				sopts.ToolTimeouts[name] = timeout
+			}
+			if flagPermissions != "" {
+				perms, err := mcp.LoadPermissions(flagPermissions)
+				if err != nil {
+					return err
+				}
+				sopts.Permissions = perms
+			}
+			if flagAuditLog != "" {
+				f, err := os.OpenFile(flagAuditLog, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+				if err != nil {
+					return fmt.Errorf("open audit log: %w", err)
+				}
+				defer f.Close()
+				sopts.AuditLog = f
+			}
+			svr := mcp.NewServer(sopts)
 			if err := svr.ServeStdio(); err != nil {
 				log.Error("Failed to run MCP server: %v\n", err)
 				return err
@@ -344,6 +905,17 @@ It serves all *.json AST files in the specified directory.`,
 			return nil
 		},
 	}
+
+	cmd.Flags().IntVar(&tokenBudget, "token-budget", 0, "Default max tokens of the codes returned by get_ast_node. Large nodes are reduced to outlines or truncated to fit (default: no limit).")
+	cmd.Flags().IntVar(&maxBytes, "max-bytes", 0, "Default max bytes of a page returned by get_repo_structure, get_package_structure and get_ast_node. Larger pages are shrunk and marked as truncated (default: no limit).")
+	cmd.Flags().IntVar(&maxLoadedRepos, "max-loaded-repos", 0, "Max count of repo ASTs kept in memory. ASTs are loaded on first use and the least recently used ones are evicted (default: no limit).")
+	cmd.Flags().StringToStringVar(&repoAliases, "repo-alias", nil, "Alias of a repo name usable as repo_name, in format alias=repo_name (can be specified multiple times).")
+	cmd.Flags().StringVar(&flagPermissions, "permissions", "", "YAML or JSON file of the tools and repos allowed for each client, keyed by the client name (clientInfo.name), or * for the others. See README.md.")
+	cmd.Flags().StringVar(&flagAuditLog, "audit-log", "", "Append every tool call as a JSON line to this file (default: logged at info level).")
+	cmd.Flags().DurationVar(&toolTimeout, "tool-timeout", 0, "Max time of a tool call. The stopped calls return the results collected so far marked as truncated (default: no limit).")
+	cmd.Flags().StringToStringVar(&toolTimeouts, "tool-timeouts", nil, "Timeout of a tool overriding --tool-timeout, in format tool=duration like get_package_structure=30s (can be specified multiple times).")
+
+	return cmd
 }
 
 func newInitSpecCmd() *cobra.Command {
@@ -384,7 +956,14 @@ The command will:
 
 func newAgentCmd() *cobra.Command {
 	var (
-		aopts agent.AgentOptions
+		aopts        agent.AgentOptions
+		enableRunner bool
+		runnerOpts   tool.RunnerToolsOptions
+		flagAPIType  string
+		task         string
+		taskArgs     map[string]string
+		report       string
+		propose      string
 	)
 
 	cmd := &cobra.Command{
@@ -401,6 +980,9 @@ Required Environment Variables:
   MODEL_NAME Model identifier (e.g., gpt-4, claude-3-opus-20240229)
   BASE_URL    (Optional) Custom API base URL
 
+API_TYPE, MODEL_NAME and BASE_URL can also be given by flags or the 'agent' section
+of the config file. Flags override env, and env overrides the config file.
+
 Examples:
   # Basic usage with OpenAI
   API_TYPE=openai API_KEY=sk-xxx MODEL_NAME=gpt-4 \
@@ -408,13 +990,22 @@ Examples:
 
   # With custom API endpoint and step limit
   API_TYPE=custom API_KEY=xxx MODEL_NAME=my-model BASE_URL=https://api.example.com \
-    abcoder agent ./asts/ --agent-max-steps 100`,
+    abcoder agent ./asts/ --agent-max-steps 100
+
+  # Continue a previous conversation, the session id is printed on start
+  abcoder agent ./asts/ --resume 20250101-120000-1a2b3c4d
+
+  # Run a predefined analysis, writing the report to dead-code.json and dead-code.md
+  abcoder agent ./asts/ --task dead-code --task-arg pkg=github.com/a/b/util --report dead-code
+
+  # Propose the fixes of the findings as unified diffs with their rationales under ./fixes
+  abcoder agent ./asts/ --task security-review --propose ./fixes`,
 		Args: cobra.ExactArgs(1),
 		PreRunE: func(cmd *cobra.Command, args []string) error {
 			if args[0] == "" {
 				return fmt.Errorf("argument Path is required")
 			}
-			return nil
+			return loadAgentConfig(cmd)
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			verbose, _ := cmd.Flags().GetBool("verbose")
@@ -425,75 +1016,467 @@ Examples:
 			uri := args[0]
 
 			aopts.ASTsDir = uri
-			aopts.Model.APIType = llm.NewModelType(os.Getenv("API_TYPE"))
-			if aopts.Model.APIType == llm.ModelTypeUnknown {
-				log.Error("env API_TYPE is required")
-				return fmt.Errorf("env API_TYPE is required")
-			}
-			aopts.Model.APIKey = os.Getenv("API_KEY")
-			if aopts.Model.APIKey == "" {
-				log.Error("env API_KEY is required")
-				return fmt.Errorf("env API_KEY is required")
+			if err := fillModelConfig(&aopts.Model, flagAPIType); err != nil {
+				return err
 			}
-			aopts.Model.ModelName = os.Getenv("MODEL_NAME")
-			if aopts.Model.ModelName == "" {
-				log.Error("env MODEL_NAME is required")
-				return fmt.Errorf("env MODEL_NAME is required")
+			if err := checkBudget(aopts); err != nil {
+				return err
 			}
-			aopts.Model.BaseURL = os.Getenv("BASE_URL")
 
-			ag := agent.NewAgent(aopts)
+			if enableRunner {
+				aopts.Runner = &runnerOpts
+			}
+			if propose != "" && task == "" {
+				return fmt.Errorf("--propose requires --task")
+			}
+			if task != "" {
+				return runAgentTask(aopts, task, taskArgs, report, propose)
+			}
+			ag, err := agent.NewAgent(aopts)
+			if err != nil {
+				log.Error("Failed to create agent: %v\n", err)
+				return err
+			}
 			ag.Run(context.Background())
 
 			return nil
 		},
 	}
 
-	cmd.Flags().IntVar(&aopts.MaxSteps, "agent-max-steps", 50, "Maximum number of agent reasoning steps per task (default: 50). Higher values allow more complex tasks but increase cost.")
+	// shared with the eval subcommand
+	cmd.PersistentFlags().StringVar(&flagAPIType, "api-type", "", "LLM provider type (default: env API_TYPE).")
+	cmd.PersistentFlags().StringVar(&aopts.Model.ModelName, "model-name", "", "Model identifier (default: env MODEL_NAME).")
+	cmd.PersistentFlags().StringVar(&aopts.Model.BaseURL, "base-url", "", "Custom API base URL (default: env BASE_URL).")
+	cmd.PersistentFlags().StringVar(&aopts.Model.CacheDir, "llm-cache-dir", "", "Cache the model responses under the directory, keyed by the messages, model and tools, thus re-running the same analysis on unchanged ASTs does not call the provider again (default: no cache).")
+	cmd.PersistentFlags().Float64Var(&aopts.Model.InputPrice, "input-price", 0, "Price in USD per million prompt tokens of the model, to report the cost (default: 0).")
+	cmd.PersistentFlags().Float64Var(&aopts.Model.OutputPrice, "output-price", 0, "Price in USD per million completion tokens of the model, to report the cost (default: 0).")
+	cmd.PersistentFlags().Float64Var(&aopts.Budget.USD, "budget-usd", 0, "Stop the agent gracefully once the cost of the model calls exceeds the budget in USD, priced by --input-price and --output-price (default: no limit).")
+	cmd.PersistentFlags().IntVar(&aopts.Budget.Tokens, "budget-tokens", 0, "Stop the agent gracefully once the prompt and completion tokens of the model calls exceed the budget (default: no limit).")
+	cmd.PersistentFlags().IntVar(&aopts.MaxSteps, "agent-max-steps", 50, "Maximum number of agent reasoning steps per task (default: 50). Higher values allow more complex tasks but increase cost.")
+	cmd.PersistentFlags().IntVar(&aopts.TokenBudget, "token-budget", 0, "Max tokens of the codes returned by get_ast_node. Large nodes are reduced to outlines or truncated to fit (default: no limit).")
+	cmd.PersistentFlags().StringSliceVar(&aopts.Repos, "repos", nil, "Names of the repos to reason across together, like a service and its client SDK (default: all repos in the directory).")
 	cmd.Flags().IntVar(&aopts.MaxHistories, "agent-max-histories", 10, "Maximum number of conversation histories to maintain for context (default: 10).")
+	cmd.Flags().StringVar(&aopts.Resume, "resume", "", "Resume the conversation of a previous session by its id, including histories and cached tool results.")
+	cmd.Flags().StringVar(&aopts.SessionDir, "session-dir", "", "Directory to persist the sessions (default: ~/.abcoder/sessions).")
+	cmd.Flags().BoolVar(&enableRunner, "runner", false, "Let the agent compile and test the codes to validate its edits.")
+	cmd.Flags().StringVar(&runnerOpts.Dir, "runner-dir", "", "Directory where build/test commands run (default: the repo path in the AST).")
+	cmd.Flags().StringArrayVar(&runnerOpts.Commands, "runner-cmd", nil, "Build/test command run by the agent, can be repeated (default: by language, e.g. 'go build ./...', 'cargo check', 'pytest').")
+
+	var taskUsage []string
+	for _, t := range agent.Tasks() {
+		taskUsage = append(taskUsage, fmt.Sprintf("%s (%s; args: %s)", t.Name, t.Description, strings.Join(t.ParamNames(), ", ")))
+	}
+	cmd.Flags().StringVar(&task, "task", "", "Run a predefined analysis instead of the interactive session, one of:\n"+strings.Join(taskUsage, "\n"))
+	cmd.Flags().StringToStringVar(&taskArgs, "task-arg", nil, "Argument of the task as key=value, can be repeated.")
+	cmd.Flags().StringVar(&report, "report", "", "Write the task report to <report>.json and <report>.md, besides printing the markdown.")
+	cmd.Flags().StringVar(&propose, "propose", "", "Ask the task to propose the code changes of its findings, and write them to the directory as unified diffs (<repo>/<file>.diff) with rationale.json mapping each hunk to the node and the findings.")
+
+	_ = cmd.RegisterFlagCompletionFunc("task", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		var names []string
+		for _, t := range agent.Tasks() {
+			names = append(names, t.Name+"\t"+t.Description)
+		}
+		return names, cobra.ShellCompDirectiveNoFileComp
+	})
+	_ = cmd.RegisterFlagCompletionFunc("repos", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return completeRepoNames(args[0]), cobra.ShellCompDirectiveNoFileComp
+	})
 
+	cmd.AddCommand(newAgentEvalCmd(&aopts, &flagAPIType))
 	return cmd
 }
 
-func parseTSProject(ctx context.Context, repoPath string, opts lang.ParseOptions, outputPath string) error {
-	if outputPath == "" {
-		return fmt.Errorf("output path is required")
-	}
-
-	parserPath, err := exec.LookPath("abcoder-ts-parser")
+// runAgentTask runs the predefined task, and prints the report in markdown.
+// The proposed patches are written to the propose dir if given
+func runAgentTask(aopts agent.AgentOptions, task string, args map[string]string, report string, propose string) error {
+	usage := llm.NewUsage(aopts.Budget)
+	defer func() {
+		fmt.Fprintf(os.Stderr, "usage: %s\n", usage.Stats())
+	}()
+	rep, err := agent.RunTask(context.Background(), agent.RepoAnnalyzerOptions{
+		ModelConfig:    aopts.Model,
+		MaxSteps:       aopts.MaxSteps,
+		ASTsDir:        aopts.ASTsDir,
+		Runner:         aopts.Runner,
+		TokenBudget:    aopts.TokenBudget,
+		Repos:          aopts.Repos,
+		Usage:          usage,
+		ProposePatches: propose != "",
+	}, task, args)
 	if err != nil {
-		log.Info("abcoder-ts-parser not found, installing...")
-		cmd := exec.Command("npm", "install", "-g", "abcoder-ts-parser")
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("failed to install abcoder-ts-parser: %v", err)
+		return err
+	}
+	if rep.Proposal != nil {
+		if err := rep.Proposal.WriteTo(propose); err != nil {
+			return err
 		}
-		parserPath, err = exec.LookPath("abcoder-ts-parser")
+	}
+	md := rep.Markdown()
+	fmt.Fprintln(os.Stdout, md)
+	if report != "" {
+		bs, err := json.MarshalIndent(rep, "", "  ")
 		if err != nil {
-			return fmt.Errorf("failed to find abcoder-ts-parser after installation: %v", err)
+			return err
+		}
+		if err := os.WriteFile(report+".json", bs, 0644); err != nil {
+			return err
+		}
+		if err := os.WriteFile(report+".md", []byte(md), 0644); err != nil {
+			return err
+		}
+	}
+	if rep.Error != "" {
+		return fmt.Errorf("task %s: %s", task, rep.Error)
+	}
+	return nil
+}
+
+func newAgentEvalCmd(aopts *agent.AgentOptions, flagAPIType *string) *cobra.Command {
+	var (
+		output       string
+		minPrecision float64
+		minRecall    float64
+	)
+
+	cmd := &cobra.Command{
+		Use:   "eval <directory> <suite>",
+		Short: "Evaluate the analysis quality of the agent",
+		Long: `Ask the agent the questions of a suite one by one, and score the nodes it retrieved
+by get_ast_node against the expected ones by precision and recall.
+
+The suite is a YAML file mapping questions to the identities (ModPath?PkgPath#Name)
+of the nodes needed to answer them:
+
+  cases:
+    - question: How does the server dispatch a request?
+      expected:
+        - github.com/a/b?github.com/a/b/server#Server.Dispatch
+
+The command fails if the average precision or recall is below the thresholds,
+thus it can gate the upgrades of prompts or models. Options are read from the
+'eval' section of the config file.`,
+		Example: `abcoder agent eval ./asts/ suite.yaml --min-recall 0.8 -o report.json`,
+		Args:    cobra.ExactArgs(2),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return loadAgentConfig(cmd)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			verbose, _ := cmd.Flags().GetBool("verbose")
+			if verbose {
+				log.SetLogLevel(log.DebugLevel)
+			}
+			if err := fillModelConfig(&aopts.Model, *flagAPIType); err != nil {
+				return err
+			}
+			if err := checkBudget(*aopts); err != nil {
+				return err
+			}
+			suite, err := agent.LoadEvalSuite(args[1])
+			if err != nil {
+				return err
+			}
+
+			usage := llm.NewUsage(aopts.Budget)
+			report := agent.Evaluate(context.Background(), agent.RepoAnnalyzerOptions{
+				ModelConfig: aopts.Model,
+				MaxSteps:    aopts.MaxSteps,
+				ASTsDir:     args[0],
+				TokenBudget: aopts.TokenBudget,
+				Repos:       aopts.Repos,
+				Usage:       usage,
+			}, suite)
+
+			for i, res := range report.Results {
+				fmt.Fprintf(os.Stdout, "[%d] precision=%.2f recall=%.2f retrieved=%d %s\n", i+1, res.Precision, res.Recall, len(res.Retrieved), res.Question)
+				if res.Error != "" {
+					fmt.Fprintf(os.Stdout, "    error: %s\n", res.Error)
+				}
+			}
+			fmt.Fprintf(os.Stdout, "cases=%d precision=%.2f recall=%.2f\n", len(report.Results), report.Precision, report.Recall)
+			fmt.Fprintf(os.Stdout, "usage: %s\n", usage.Stats())
+
+			if output != "" {
+				bs, err := json.MarshalIndent(report, "", "  ")
+				if err != nil {
+					return err
+				}
+				if err := os.WriteFile(output, bs, 0644); err != nil {
+					return err
+				}
+			}
+			if report.Precision < minPrecision || report.Recall < minRecall {
+				return fmt.Errorf("precision %.2f or recall %.2f is below the thresholds (%.2f, %.2f)", report.Precision, report.Recall, minPrecision, minRecall)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Write the report in JSON to the file.")
+	cmd.Flags().Float64Var(&minPrecision, "min-precision", 0, "Fail if the average precision is below it.")
+	cmd.Flags().Float64Var(&minRecall, "min-recall", 0, "Fail if the average recall is below it.")
+	return cmd
+}
+
+func newReposCmd() *cobra.Command {
+	var dir string
+
+	cmd := &cobra.Command{
+		Use:   "repos",
+		Short: "Manage the AST directory used by mcp and agent",
+		Long: `Register the parsed ASTs into an AST directory with friendly names, which can be used
+as repo_name by the MCP tools and the agent. The parse time and the source commit are recorded
+in repos.yaml under the directory.`,
+		Example: `abcoder parse go ./kitex -o kitex.json
+abcoder repos add kitex.json --name kitex
+abcoder repos list
+abcoder mcp ~/.abcoder/asts`,
+	}
+	cmd.PersistentFlags().StringVar(&dir, "dir", tool.DefaultASTsDir(), "The AST directory.")
+
+	var name, commit string
+	add := &cobra.Command{
+		Use:   "add <ast-file>",
+		Short: "Register an AST file into the AST directory",
+		Long: `Copy the AST file into the AST directory as <name>.json, and record its parse time and source commit.
+
+The name defaults to the base name of the repo id. The source commit is read from the git repository
+at the repo path of the AST if not given. Adding the same name again updates it.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			reg, err := tool.LoadRegistry(dir)
+			if err != nil {
+				return err
+			}
+			repo, err := reg.Add(args[0], name, commit)
+			if err != nil {
+				return err
+			}
+			if err := reg.Save(); err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stdout, "added %s (%s) as %s\n", repo.Name, repo.Repo, filepath.Join(dir, repo.File))
+			return nil
+		},
+	}
+	add.Flags().StringVar(&name, "name", "", "Friendly name of the repo (default: the base name of the repo id).")
+	add.Flags().StringVar(&commit, "commit", "", "Source commit of the AST (default: HEAD of the git repository at the repo path of the AST).")
+
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "List the repos in the AST directory",
+		Long:  `List the registered repos with their parse time and source commit, and the AST files which are not registered.`,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			reg, err := tool.LoadRegistry(dir)
+			if err != nil {
+				return err
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "NAME\tREPO\tPARSED AT\tCOMMIT\tFILE")
+			registered := map[string]bool{}
+			for _, repo := range reg.Repos {
+				registered[repo.File] = true
+				commit := repo.Commit
+				if len(commit) > 12 {
+					commit = commit[:12]
+				}
+				if commit == "" {
+					commit = "-"
+				} else if repo.Dirty {
+					commit += "-dirty"
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", repo.Name, repo.Repo, repo.ParsedAt.Local().Format("2006-01-02 15:04:05"), commit, repo.File)
+			}
+			files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+			for _, file := range files {
+				if registered[filepath.Base(file)] {
+					continue
+				}
+				id, err := uniast.LoadRepoID(file)
+				if err != nil {
+					id = "(invalid: " + err.Error() + ")"
+				}
+				fmt.Fprintf(w, "-\t%s\t-\t-\t%s\n", id, filepath.Base(file))
+			}
+			return w.Flush()
+		},
+	}
+
+	remove := &cobra.Command{
+		Use:   "remove <name>...",
+		Short: "Unregister repos and delete their AST files",
+		Args:  cobra.MinimumNArgs(1),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			reg, err := tool.LoadRegistry(dir)
+			if err != nil {
+				return nil, cobra.ShellCompDirectiveError
+			}
+			return reg.Names(), cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			reg, err := tool.LoadRegistry(dir)
+			if err != nil {
+				return err
+			}
+			for _, name := range args {
+				repo, err := reg.Remove(name)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(os.Stdout, "removed %s (%s)\n", repo.Name, repo.Repo)
+			}
+			return reg.Save()
+		},
+	}
+
+	cmd.AddCommand(add, list, remove)
+	return cmd
+}
+
+// parseLanguages are the languages completed for `abcoder parse`
+var parseLanguages = []string{"go", "rust", "cxx", "python", "ts", "js", "java", "scala", "php", "ruby"}
+
+// completeRepoNames returns the registered names and the repo ids of the AST files in dir
+func completeRepoNames(dir string) []string {
+	var ret []string
+	if reg, err := tool.LoadRegistry(dir); err == nil {
+		ret = reg.Names()
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	for _, file := range files {
+		if id, err := uniast.LoadRepoID(file); err == nil && !slices.Contains(ret, id) {
+			ret = append(ret, id)
 		}
 	}
+	return ret
+}
 
-	args := []string{"parse", repoPath}
-	if len(opts.TSSrcDir) > 0 {
-		args = append(args, "--src", strings.Join(opts.TSSrcDir, ","))
+// loadAgentConfig fills the model flags by env then the config file.
+// Env overrides the config file, but not the flags
+func loadAgentConfig(cmd *cobra.Command) error {
+	for flag, env := range map[string]string{"api-type": "API_TYPE", "model-name": "MODEL_NAME", "base-url": "BASE_URL"} {
+		if v := os.Getenv(env); v != "" && !cmd.Flags().Changed(flag) {
+			if err := cmd.Flags().Set(flag, v); err != nil {
+				return err
+			}
+		}
 	}
-	if opts.TSConfig != "" {
-		args = append(args, "--tsconfig", opts.TSConfig)
+	return loadConfig(cmd, ".")
+}
+
+// fillModelConfig checks the model flags, and reads the API key from env
+func fillModelConfig(conf *llm.ModelConfig, apiType string) error {
+	conf.APIType = llm.NewModelType(apiType)
+	if conf.APIType == llm.ModelTypeUnknown {
+		log.Error("env API_TYPE is required")
+		return fmt.Errorf("env API_TYPE is required")
+	}
+	conf.APIKey = os.Getenv("API_KEY")
+	if conf.APIKey == "" {
+		log.Error("env API_KEY is required")
+		return fmt.Errorf("env API_KEY is required")
 	}
-	if outputPath != "" {
-		args = append(args, "--output", outputPath)
+	if conf.ModelName == "" {
+		log.Error("env MODEL_NAME is required")
+		return fmt.Errorf("env MODEL_NAME is required")
 	}
+	return nil
+}
 
-	cmd := exec.CommandContext(ctx, parserPath, args...)
-	cmd.Env = append(os.Environ(), "NODE_OPTIONS=--max-old-space-size=65536")
-	cmd.Env = append(cmd.Env, "ABCODER_TOOL_VERSION="+version.Version)
-	cmd.Env = append(cmd.Env, "ABCODER_AST_VERSION="+uniast.Version)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+// checkBudget tells a cost budget can't be enforced without the prices
+func checkBudget(aopts agent.AgentOptions) error {
+	if aopts.Budget.USD > 0 && aopts.Model.InputPrice == 0 && aopts.Model.OutputPrice == 0 {
+		return fmt.Errorf("--budget-usd requires the prices of the model by --input-price and --output-price")
+	}
+	return nil
+}
 
-	log.Info("Running abcoder-ts-parser with args: %v", args)
+// loadConfig fills the flags of cmd which are not given in the command line,
+// with the section of cmd in the config file. The file is the one of --config, or found under dir.
+// validateParseOptions validates the flags of the parse command, and sets the progress reporter
+func validateParseOptions(opts *lang.ParseOptions, flagProgress string) error {
+	if err := opts.Kinds().Validate(); err != nil {
+		return err
+	}
+	switch opts.GoCallGraph {
+	case "", parser.CallGraphCHA, parser.CallGraphRTA:
+	default:
+		return fmt.Errorf("unsupported call graph algorithm: %s", opts.GoCallGraph)
+	}
+	switch flagProgress {
+	case "":
+	case "json":
+		opts.Progress = progress.JSONLines(os.Stderr)
+	default:
+		return fmt.Errorf("unsupported progress format: %s", flagProgress)
+	}
+	return nil
+}
+
+func loadConfig(cmd *cobra.Command, dir string) error {
+	path, _ := cmd.Flags().GetString("config")
+	if path == "" {
+		if path = config.Find(dir); path == "" {
+			return nil
+		}
+	}
+	conf, err := config.Load(path)
+	if err != nil {
+		return err
+	}
+	log.Info("load config from %s\n", path)
+	return conf.Apply(cmd.Name(), cmd.Flags())
+}
 
-	return cmd.Run()
+// notifyInterrupt returns a context canceled on the first SIGINT or SIGTERM.
+// The process only exits on the second signal, thus the caller can still flush the collected states.
+func notifyInterrupt(parent context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(parent)
+	sigs := make(chan os.Signal, 2)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case sig := <-sigs:
+			log.Error("received %v, stop collecting and write the collected symbols, send again to force exit\n", sig)
+			cancel()
+		case <-done:
+			return
+		}
+		select {
+		case <-sigs:
+			os.Exit(1)
+		case <-done:
+		}
+	}()
+	return ctx, func() {
+		signal.Stop(sigs)
+		close(done)
+		cancel()
+	}
+}
+
+// writeOutput serializes the AST onto path incrementally, or onto stdout if path is empty.
+// The file is written through utils.AbortSafeWriter, thus the output is either complete
+// or left as a detectable partial file.
+func writeOutput(path string, repo *uniast.Repository) error {
+	if path == "" {
+		return json.NewEncoder(os.Stdout).Encode(repo)
+	}
+	w, err := utils.NewAbortSafeWriter(path, 0)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+	bw := bufio.NewWriterSize(w, 1<<20)
+	if err := json.NewEncoder(bw).Encode(repo); err != nil {
+		return fmt.Errorf("serialize AST failed: %v", err)
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	return w.Commit()
 }
@@ -0,0 +1,9 @@
+package com.example.app;
+
+import com.example.core.Greeter;
+
+public class App {
+    public static void main(String[] args) {
+        System.out.println(new Greeter().greet("world"));
+    }
+}
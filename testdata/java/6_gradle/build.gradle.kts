@@ -0,0 +1,3 @@
+allprojects {
+    group = "com.example"
+}
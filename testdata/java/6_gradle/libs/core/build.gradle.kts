@@ -0,0 +1,5 @@
+plugins {
+    `java-library`
+}
+
+version = "2.0.0"
@@ -0,0 +1,7 @@
+package com.example.core;
+
+public class Greeter {
+    public String greet(String name) {
+        return "Hello, " + name;
+    }
+}
@@ -0,0 +1,8 @@
+rootProject.name = "my-gradle-app"
+
+// include("ignored")
+include(
+    "app",
+    ":core",
+)
+project(":core").projectDir = file("libs/core")
@@ -5,6 +5,7 @@ import fs from 'fs';
 import path from 'path';
 import { RepositoryParser } from './parser/RepositoryParser';
 import { JsonStreamStringify } from 'json-stream-stringify';
+import { serve } from './ipc/server';
 
 const program = new Command();
 
@@ -62,4 +63,17 @@ program
     }
   });
 
+program
+  .command('serve')
+  .description('Serve parse requests of abcoder over a Unix Domain Socket')
+  .requiredOption('--uds <socket>', 'Path of the Unix Domain Socket to connect')
+  .action(async (options) => {
+    try {
+      await serve(options.uds);
+    } catch (error) {
+      console.error('Error serving:', error);
+      process.exit(1);
+    }
+  });
+
 program.parse();
\ No newline at end of file
@@ -0,0 +1,68 @@
+/**
+ * IPC protocol between abcoder and the parser, the same wire format as the Java parser:
+ *   - 4 bytes: message length (big-endian uint32)
+ *   - bytes: JSON message body
+ */
+
+export const TYPE_PARSE_REQUEST = 'parse_request';
+export const TYPE_PROGRESS = 'progress';
+// the repository JSON is sent as string chunks, since it may exceed the max string length of V8
+export const TYPE_RESULT_CHUNK = 'result_chunk';
+export const TYPE_RESULT_END = 'result_end';
+export const TYPE_ERROR = 'error';
+
+export interface Message<T = unknown> {
+  type: string;
+  requestId?: string;
+  payload?: T;
+}
+
+export interface ParseRequest {
+  repoPath: string;
+  tsconfig?: string;
+  srcDirs?: string[];
+  // absolute path prefixes of the files to skip
+  excludes?: string[];
+  repoId?: string;
+  noDist?: boolean;
+  monorepoMode?: 'combined' | 'separate';
+}
+
+export interface ProgressPayload {
+  done: number;
+  total: number;
+  item?: string;
+}
+
+export interface ErrorPayload {
+  message: string;
+}
+
+export function encodeMessage(msg: Message): Buffer {
+  const body = Buffer.from(JSON.stringify(msg), 'utf8');
+  const head = Buffer.alloc(4);
+  head.writeUInt32BE(body.length, 0);
+  return Buffer.concat([head, body]);
+}
+
+/**
+ * Splits the length-prefixed messages out of the received chunks
+ */
+export class MessageDecoder {
+  private buf: Buffer = Buffer.alloc(0);
+
+  push(chunk: Buffer): Message[] {
+    this.buf = Buffer.concat([this.buf, chunk]);
+    const messages: Message[] = [];
+    while (this.buf.length >= 4) {
+      const length = this.buf.readUInt32BE(0);
+      if (this.buf.length < 4 + length) {
+        break;
+      }
+      const body = this.buf.subarray(4, 4 + length).toString('utf8');
+      this.buf = this.buf.subarray(4 + length);
+      messages.push(JSON.parse(body) as Message);
+    }
+    return messages;
+  }
+}
@@ -0,0 +1,94 @@
+import * as net from 'net';
+import * as path from 'path';
+import { JsonStreamStringify } from 'json-stream-stringify';
+import { RepositoryParser } from '../parser/RepositoryParser';
+import {
+  encodeMessage,
+  ErrorPayload,
+  Message,
+  MessageDecoder,
+  ParseRequest,
+  ProgressPayload,
+  TYPE_ERROR,
+  TYPE_PARSE_REQUEST,
+  TYPE_PROGRESS,
+  TYPE_RESULT_CHUNK,
+  TYPE_RESULT_END,
+} from './protocol';
+
+/**
+ * Connects to the Unix Domain Socket of abcoder, serves the parse requests until the socket is closed.
+ * Each request is answered with progress messages, then either the result chunks or an error.
+ */
+export function serve(socketPath: string): Promise<void> {
+  return new Promise((resolve, reject) => {
+    const conn = net.createConnection(socketPath);
+    const decoder = new MessageDecoder();
+    // requests are handled one by one
+    let queue = Promise.resolve();
+
+    conn.on('data', (chunk: Buffer) => {
+      let messages: Message[];
+      try {
+        messages = decoder.push(chunk);
+      } catch (error) {
+        conn.destroy(error as Error);
+        return;
+      }
+      for (const msg of messages) {
+        if (msg.type !== TYPE_PARSE_REQUEST) {
+          console.warn(`Unknown message type: ${msg.type}`);
+          continue;
+        }
+        queue = queue.then(() => handleParse(conn, msg as Message<ParseRequest>));
+      }
+    });
+    conn.on('end', () => queue.then(() => resolve()));
+    conn.on('error', reject);
+  });
+}
+
+async function handleParse(conn: net.Socket, msg: Message<ParseRequest>): Promise<void> {
+  const requestId = msg.requestId;
+  const send = (type: string, payload?: unknown): Promise<void> =>
+    new Promise(resolve => {
+      // wait for drain, thus the huge result doesn't pile up in memory
+      if (!conn.write(encodeMessage({ type, requestId, payload }))) {
+        conn.once('drain', resolve);
+      } else {
+        resolve();
+      }
+    });
+
+  try {
+    const req = msg.payload;
+    if (!req || !req.repoPath) {
+      throw new Error('repoPath is required');
+    }
+    const repoPath = path.resolve(req.repoPath);
+    const parser = new RepositoryParser(repoPath, req.tsconfig);
+    const repository = await parser.parseRepository(repoPath, {
+      loadExternalSymbols: false,
+      noDist: req.noDist,
+      srcPatterns: req.srcDirs,
+      excludes: (req.excludes || []).map(e => path.resolve(repoPath, e)),
+      monorepoMode: req.monorepoMode || 'combined',
+      onProgress: (done, total, item) => {
+        const progress: ProgressPayload = { done, total, item };
+        conn.write(encodeMessage({ type: TYPE_PROGRESS, requestId, payload: progress }));
+      },
+    });
+    if (req.repoId) {
+      repository.id = req.repoId;
+    }
+
+    const jsonStream = new JsonStreamStringify(repository);
+    for await (const chunk of jsonStream) {
+      await send(TYPE_RESULT_CHUNK, String(chunk));
+    }
+    await send(TYPE_RESULT_END);
+  } catch (error) {
+    const payload: ErrorPayload = { message: error instanceof Error ? error.message : String(error) };
+    await send(TYPE_ERROR, payload);
+  }
+}
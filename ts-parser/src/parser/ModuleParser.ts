@@ -22,7 +22,7 @@ export class ModuleParser {
     this.tsConfigCache = TsConfigCache.getInstance();
   }
 
-  async parseModule(modulePath: string, relativeDir: string, options: { loadExternalSymbols?: boolean, noDist?: boolean, srcPatterns?: string[] } = {}): Promise<Module> {
+  async parseModule(modulePath: string, relativeDir: string, options: { loadExternalSymbols?: boolean, noDist?: boolean, srcPatterns?: string[], excludes?: string[] } = {}): Promise<Module> {
     const packageJsonPath = path.join(modulePath, 'package.json');
     // eslint-disable-next-line @typescript-eslint/no-explicit-any
     let packageJson: any = {};
@@ -44,7 +44,8 @@ export class ModuleParser {
     const structure = analyzer.analyze({ 
       loadExternalSymbols: options.loadExternalSymbols,
       noDist: options.noDist,
-      srcPatterns: options.srcPatterns
+      srcPatterns: options.srcPatterns,
+      excludes: options.excludes
     });
 
     // Parse packages
@@ -12,6 +12,11 @@ import { GraphBuilder } from '../utils/graph-builder';
 import { ProjectFactory, RepositoryFactory } from '../utils/package-processor';
 import { ParsingStrategySelector } from '../utils/parsing-strategy';
 
+/**
+ * Receives the count of parsed modules, and the name of the last one
+ */
+export type ProgressCallback = (done: number, total: number, item: string) => void;
+
 export class RepositoryParser {
   private project?: Project;
   private moduleParser?: ModuleParser;
@@ -31,7 +36,9 @@ export class RepositoryParser {
       loadExternalSymbols?: boolean;
       noDist?: boolean;
       srcPatterns?: string[];
+      excludes?: string[];
       monorepoMode?: 'combined' | 'separate';
+      onProgress?: ProgressCallback;
     } = {}
   ): Promise<Repository> {
     const absolutePath = path.resolve(repoPath);
@@ -57,8 +64,10 @@ export class RepositoryParser {
         }
       }
     } else {
+      options.onProgress?.(0, 1, '');
       await this.parseSingleProjectMode(absolutePath, repository, options);
       this.buildGlobalGraph(repository);
+      options.onProgress?.(1, 1, path.basename(absolutePath));
     }
     return repository;
   }
@@ -73,6 +82,7 @@ export class RepositoryParser {
       loadExternalSymbols?: boolean;
       noDist?: boolean;
       srcPatterns?: string[];
+      excludes?: string[];
     }
   ): Promise<void> {
     console.log('Single project detected.');
@@ -184,6 +194,7 @@ export class RepositoryParser {
       loadExternalSymbols?: boolean;
       noDist?: boolean;
       srcPatterns?: string[];
+      excludes?: string[];
       monorepoMode?: 'combined' | 'separate';
       maxConcurrency?: number;
       enableParallel?: boolean;
@@ -264,7 +275,9 @@ export class RepositoryParser {
       loadExternalSymbols?: boolean;
       noDist?: boolean;
       srcPatterns?: string[];
+      excludes?: string[];
       monorepoMode?: 'combined' | 'separate';
+      onProgress?: ProgressCallback;
     }
   ): Promise<void> {
     // Analyze project size and select parsing strategy
@@ -311,6 +324,7 @@ export class RepositoryParser {
       const moduleParser = new ModuleParser(project, this.projectRoot);
       const module = await moduleParser.parseModule(pkg.absolutePath, pkg.path, options);
       repository.Modules[module.Name] = module;
+      options.onProgress?.(Object.keys(repository.Modules).length, packages.length, module.Name);
     }
   }
 }
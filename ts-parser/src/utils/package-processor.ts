@@ -11,6 +11,7 @@ export interface PackageProcessingOptions {
   loadExternalSymbols?: boolean;
   noDist?: boolean;
   srcPatterns?: string[];
+  excludes?: string[];
   monorepoMode?: 'combined' | 'separate';
 }
 
@@ -45,7 +45,7 @@ export class TypeScriptStructureAnalyzer {
     this.tsConfigCache = TsConfigCache.getInstance();
   }
 
-  analyze(options: { loadExternalSymbols?: boolean, noDist?: boolean, srcPatterns?: string[] } = {}): TypeScriptStructure {
+  analyze(options: { loadExternalSymbols?: boolean, noDist?: boolean, srcPatterns?: string[], excludes?: string[] } = {}): TypeScriptStructure {
     const structure: TypeScriptStructure = {
       modules: new Map(),
       packages: new Map(),
@@ -102,12 +102,12 @@ export class TypeScriptStructureAnalyzer {
   }
 
 
-  private analyzePackages(module: ModuleInfo, options: { noDist?: boolean, srcPatterns?: string[] } = {}): PackageInfo[] {
+  private analyzePackages(module: ModuleInfo, options: { noDist?: boolean, srcPatterns?: string[], excludes?: string[] } = {}): PackageInfo[] {
     const sourceFiles = this.findSourceFiles(module, options);
     return sourceFiles.map(file => this.createPackageFromFile(module, file));
   }
 
-  private findSourceFiles(module: ModuleInfo, options: { noDist?: boolean, srcPatterns?: string[] } = {}): string[] {
+  private findSourceFiles(module: ModuleInfo, options: { noDist?: boolean, srcPatterns?: string[], excludes?: string[] } = {}): string[] {
     // Handle default srcPatterns if not provided
     if (!options.srcPatterns || options.srcPatterns.length === 0) {
       options.srcPatterns = ['**/*.ts', '**/*.js'];
@@ -119,8 +119,13 @@ export class TypeScriptStructureAnalyzer {
       return relativePath.split(path.sep).includes('node_modules');
     };
 
+    // excludes are absolute path prefixes
+    const isExcluded = (filePath: string) => {
+      return (options.excludes || []).some(e => filePath.startsWith(e));
+    };
+
     const addFileIfAllowed = (filePath: string) => {
-      if (!isNodeModules(filePath)) {
+      if (!isNodeModules(filePath) && !isExcluded(filePath)) {
         allFiles.add(filePath);
       }
     };